@@ -0,0 +1,69 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+
+	"github.com/go-i2p/go-sam-bridge/lib/i2cp"
+)
+
+// runCheck verifies that a SAM bridge answers HELLO and PING, and
+// optionally that an I2P router accepts I2CP connections. It exits
+// non-zero on the first failure so it can back container health checks.
+func runCheck(args []string) error {
+	var samFlags samClientFlags
+	fs := flag.NewFlagSet("check", flag.ContinueOnError)
+	samFlags.register(fs)
+	i2cpAddr := fs.String("i2cp", "", "Also check the I2P router at this I2CP address")
+	var i2cpTLS i2cpTLSFlags
+	i2cpTLS.register(fs)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: sam-bridge check [flags]")
+		fmt.Fprintln(fs.Output())
+		fmt.Fprintln(fs.Output(), "Health-check a running SAM bridge, and optionally an I2P router.")
+		fmt.Fprintln(fs.Output())
+		fmt.Fprintln(fs.Output(), "Flags:")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	tlsConfig, err := i2cpTLS.tlsConfig()
+	if err != nil {
+		return err
+	}
+
+	client, err := dialSAM(&samFlags)
+	if err != nil {
+		return fmt.Errorf("SAM %s: %w", samFlags.addr, err)
+	}
+	defer client.Close()
+
+	reply, err := client.Command("PING check")
+	if err != nil {
+		return fmt.Errorf("SAM %s: PING: %w", samFlags.addr, err)
+	}
+	if reply.Verb != "PONG" {
+		return fmt.Errorf("SAM %s: PING: unexpected reply %s", samFlags.addr, reply.Verb)
+	}
+	fmt.Printf("SAM %s: OK (version %s)\n", samFlags.addr, client.Version)
+
+	if *i2cpAddr == "" {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), samFlags.timeout)
+	defer cancel()
+
+	i2cpConfig := &i2cp.ClientConfig{RouterAddr: *i2cpAddr}
+	applyI2CPTLS(i2cpConfig, tlsConfig)
+	i2cpClient := i2cp.NewClient(i2cpConfig)
+	if err := i2cpClient.Connect(ctx); err != nil {
+		return fmt.Errorf("I2CP %s: %w", *i2cpAddr, err)
+	}
+	defer i2cpClient.Close()
+
+	fmt.Printf("I2CP %s: OK (router %s)\n", *i2cpAddr, i2cpClient.RouterVersion())
+	return nil
+}
@@ -0,0 +1,223 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/go-i2p/go-sam-bridge/lib/bridge"
+	"github.com/go-i2p/go-sam-bridge/lib/protocol"
+)
+
+// consoleHistoryFile is the file, relative to the user's home directory,
+// that console history is kept in between runs.
+const consoleHistoryFile = ".sam-bridge_history"
+
+// consoleHistoryLimit is the number of history entries kept on disk.
+const consoleHistoryLimit = 500
+
+// runConsole opens an interactive SAM session for testing handlers by
+// hand. Each line is sent as a SAM command and the reply is printed with
+// one option per line. Lines starting with "!" recall history.
+func runConsole(args []string) error {
+	var samFlags samClientFlags
+	fs := flag.NewFlagSet("console", flag.ContinueOnError)
+	samFlags.register(fs)
+	raw := fs.Bool("raw", false, "Print replies exactly as received")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: sam-bridge console [flags]")
+		fmt.Fprintln(fs.Output())
+		fmt.Fprintln(fs.Output(), "Connect to a SAM bridge, perform HELLO, and send commands interactively.")
+		fmt.Fprintln(fs.Output(), "Type \"help\" at the prompt for console commands.")
+		fmt.Fprintln(fs.Output())
+		fmt.Fprintln(fs.Output(), "Flags:")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	client, err := dialSAM(&samFlags)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	c := &console{
+		client:  client,
+		in:      bufio.NewScanner(os.Stdin),
+		out:     os.Stdout,
+		raw:     *raw,
+		histLog: historyPath(),
+	}
+	c.loadHistory()
+	defer c.saveHistory()
+
+	fmt.Fprintf(c.out, "Connected to %s (SAM %s). Type \"help\" for help.\n", samFlags.addr, client.Version)
+	return c.run()
+}
+
+// console holds the state of an interactive session.
+type console struct {
+	client  *samClient
+	in      *bufio.Scanner
+	out     io.Writer
+	raw     bool
+	history []string
+	histLog string
+}
+
+// run reads commands until EOF or quit.
+func (c *console) run() error {
+	for {
+		fmt.Fprint(c.out, "sam> ")
+		if !c.in.Scan() {
+			fmt.Fprintln(c.out)
+			return c.in.Err()
+		}
+
+		line := strings.TrimSpace(c.in.Text())
+		if line == "" {
+			continue
+		}
+		if strings.HasPrefix(line, "!") {
+			recalled, err := c.recall(line)
+			if err != nil {
+				fmt.Fprintln(c.out, err)
+				continue
+			}
+			line = recalled
+			fmt.Fprintln(c.out, line)
+		}
+
+		switch strings.ToLower(line) {
+		case "quit", "exit":
+			return nil
+		case "help":
+			c.printHelp()
+			continue
+		case "history":
+			for i, h := range c.history {
+				fmt.Fprintf(c.out, "%4d  %s\n", i+1, h)
+			}
+			continue
+		}
+
+		c.history = append(c.history, line)
+		reply, err := c.client.Command(line)
+		if err != nil {
+			return err
+		}
+		c.printReply(reply)
+	}
+}
+
+// recall resolves "!!" (last command) and "!N" (history entry N).
+func (c *console) recall(line string) (string, error) {
+	if len(c.history) == 0 {
+		return "", fmt.Errorf("history is empty")
+	}
+	if line == "!!" {
+		return c.history[len(c.history)-1], nil
+	}
+	n, err := strconv.Atoi(line[1:])
+	if err != nil || n < 1 || n > len(c.history) {
+		return "", fmt.Errorf("%s: no such history entry", line)
+	}
+	return c.history[n-1], nil
+}
+
+// printReply prints the verb and action on one line and each option,
+// sorted by key, on its own line.
+func (c *console) printReply(reply *protocol.Command) {
+	if c.raw {
+		fmt.Fprintln(c.out, reply.Raw)
+		return
+	}
+
+	header := reply.Verb
+	keys := make([]string, 0, len(reply.Options))
+	width := 0
+	for k, v := range reply.Options {
+		// The parser reports a bare action word such as REPLY as an
+		// option without a value.
+		if v == "" && k == strings.ToUpper(k) && reply.Action == "" {
+			header += " " + k
+			continue
+		}
+		keys = append(keys, k)
+		width = max(width, len(k))
+	}
+	if reply.Action != "" {
+		header += " " + reply.Action
+	}
+	sort.Strings(keys)
+
+	fmt.Fprintln(c.out, header)
+	for _, k := range keys {
+		fmt.Fprintf(c.out, "  %-*s  %s\n", width, k, reply.Options[k])
+	}
+}
+
+// printHelp lists the console's local commands.
+func (c *console) printHelp() {
+	fmt.Fprintln(c.out, "Enter any SAM command, e.g. \"NAMING LOOKUP NAME=ME\" or \"DEST GENERATE\".")
+	fmt.Fprintln(c.out)
+	fmt.Fprintln(c.out, "Console commands:")
+	fmt.Fprintln(c.out, "  history  List previous commands")
+	fmt.Fprintln(c.out, "  !!       Repeat the last command")
+	fmt.Fprintln(c.out, "  !N       Repeat history entry N")
+	fmt.Fprintln(c.out, "  help     Show this help")
+	fmt.Fprintln(c.out, "  quit     Close the connection and exit")
+}
+
+// historyPath returns the history file path, or "" if there is no home
+// directory.
+func historyPath() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, consoleHistoryFile)
+}
+
+// loadHistory reads previous commands from the history file.
+func (c *console) loadHistory() {
+	if c.histLog == "" {
+		return
+	}
+	data, err := os.ReadFile(c.histLog)
+	if err != nil {
+		return
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		if line != "" {
+			c.history = append(c.history, line)
+		}
+	}
+}
+
+// saveHistory writes the most recent commands to the history file.
+// Commands carrying secrets, such as passwords, private keys and lease
+// set secrets, are not saved.
+func (c *console) saveHistory() {
+	if c.histLog == "" {
+		return
+	}
+	var lines []string
+	for _, h := range c.history {
+		if bridge.RedactLine(h) == h {
+			lines = append(lines, h)
+		}
+	}
+	if len(lines) > consoleHistoryLimit {
+		lines = lines[len(lines)-consoleHistoryLimit:]
+	}
+	_ = os.WriteFile(c.histLog, []byte(strings.Join(lines, "\n")+"\n"), 0o600)
+}
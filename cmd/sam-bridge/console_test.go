@@ -0,0 +1,85 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/go-i2p/go-sam-bridge/lib/protocol"
+)
+
+func TestConsoleRecall(t *testing.T) {
+	c := &console{history: []string{"HELLO VERSION", "NAMING LOOKUP NAME=ME", "DEST GENERATE"}}
+
+	tests := []struct {
+		line    string
+		want    string
+		wantErr bool
+	}{
+		{"!!", "DEST GENERATE", false},
+		{"!1", "HELLO VERSION", false},
+		{"!3", "DEST GENERATE", false},
+		{"!0", "", true},
+		{"!4", "", true},
+		{"!x", "", true},
+	}
+	for _, tt := range tests {
+		got, err := c.recall(tt.line)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("recall(%q) = %q, %v, want %q, wantErr %v", tt.line, got, err, tt.want, tt.wantErr)
+		}
+	}
+
+	if _, err := (&console{}).recall("!!"); err == nil {
+		t.Error("recall(!!) with empty history error = nil, want error")
+	}
+}
+
+func TestConsolePrintReply(t *testing.T) {
+	const line = "NAMING REPLY RESULT=OK NAME=ME VALUE=abc~"
+	reply, err := protocol.NewParser().Parse(line)
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	tests := []struct {
+		name string
+		raw  bool
+		want string
+	}{
+		{"formatted", false, "NAMING REPLY\n  NAME    ME\n  RESULT  OK\n  VALUE   abc~\n"},
+		{"raw", true, line + "\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out strings.Builder
+			c := &console{out: &out, raw: tt.raw}
+			c.printReply(reply)
+			if out.String() != tt.want {
+				t.Errorf("printReply() wrote %q, want %q", out.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestConsoleSaveHistory(t *testing.T) {
+	path := filepath.Join(t.TempDir(), consoleHistoryFile)
+	c := &console{histLog: path, history: []string{
+		"HELLO VERSION USER=alice PASSWORD=secret",
+		"SESSION CREATE STYLE=STREAM ID=a DESTINATION=privkey~",
+		"SESSION CREATE STYLE=STREAM ID=b DESTINATION=TRANSIENT i2cp.leaseSetSecret=hunter2",
+		"SESSION CREATE STYLE=STREAM ID=c DESTINATION=TRANSIENT",
+		"NAMING LOOKUP NAME=ME",
+	}}
+	c.saveHistory()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("ReadFile() error = %v", err)
+	}
+	want := "SESSION CREATE STYLE=STREAM ID=c DESTINATION=TRANSIENT\nNAMING LOOKUP NAME=ME\n"
+	if string(data) != want {
+		t.Errorf("history file = %q, want %q", data, want)
+	}
+}
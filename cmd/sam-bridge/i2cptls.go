@@ -0,0 +1,48 @@
+package main
+
+import (
+	"flag"
+
+	"github.com/go-i2p/go-sam-bridge/lib/embedding"
+	"github.com/go-i2p/go-sam-bridge/lib/i2cp"
+)
+
+// i2cpTLSFlags holds the flags shared by subcommands that connect to an
+// SSL-enabled I2CP port.
+type i2cpTLSFlags struct {
+	enabled bool
+	config  embedding.I2CPTLSConfig
+}
+
+// register adds the I2CP TLS flags to fs.
+func (f *i2cpTLSFlags) register(fs *flag.FlagSet) {
+	fs.BoolVar(&f.enabled, "i2cp-tls", false, "Connect to the I2P router over TLS (i2cp.SSL)")
+	fs.StringVar(&f.config.CAFile, "i2cp-tls-ca", "", "CA `file` verifying the router certificate (default: system roots)")
+	fs.StringVar(&f.config.CertFile, "i2cp-tls-cert", "", "Client certificate `file` presented to the router (optional)")
+	fs.StringVar(&f.config.KeyFile, "i2cp-tls-key", "", "Client key `file` for -i2cp-tls-cert")
+	fs.BoolVar(&f.config.Insecure, "i2cp-tls-insecure", false, "Skip verification of the router certificate")
+}
+
+// tlsConfig returns the configured settings, or nil without -i2cp-tls.
+func (f *i2cpTLSFlags) tlsConfig() (*embedding.I2CPTLSConfig, error) {
+	if !f.enabled {
+		return nil, nil
+	}
+	if (f.config.CertFile == "") != (f.config.KeyFile == "") {
+		return nil, embedding.ErrIncompleteTLSConfig
+	}
+	config := f.config
+	return &config, nil
+}
+
+// applyI2CPTLS copies tlsConfig, if any, into an I2CP client config.
+func applyI2CPTLS(config *i2cp.ClientConfig, tlsConfig *embedding.I2CPTLSConfig) {
+	if tlsConfig == nil {
+		return
+	}
+	config.TLSEnabled = true
+	config.TLSInsecure = tlsConfig.Insecure
+	config.TLSCAFile = tlsConfig.CAFile
+	config.TLSCertFile = tlsConfig.CertFile
+	config.TLSKeyFile = tlsConfig.KeyFile
+}
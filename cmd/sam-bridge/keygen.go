@@ -0,0 +1,110 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/go-i2p/go-sam-bridge/lib/destination"
+	"github.com/go-i2p/go-sam-bridge/lib/embedding"
+	"github.com/go-i2p/go-sam-bridge/lib/protocol"
+)
+
+// runKeygen generates a destination key pair locally, without contacting
+// a router. Without -out the private key is printed in the base64 format
+// accepted by SESSION CREATE DESTINATION=; with -out it is written as a
+// binary PrivateKeyFile that Java I2P and i2pd load as eepsite keys; with
+// -keystore and -name it is saved encrypted for DESTINATION=file:NAME.
+func runKeygen(args []string) error {
+	fs := flag.NewFlagSet("keygen", flag.ContinueOnError)
+	sigType := fs.Int("type", protocol.DefaultSignatureType, "Signature type (7 = Ed25519)")
+	out := fs.String("out", "", "Write a binary PrivateKeyFile (e.g. keys.dat) instead of printing the private key")
+	keyStoreDir := fs.String("keystore", "", "Save the key encrypted in this key store `directory` (passphrase from SAM_KEYSTORE_PASSPHRASE)")
+	name := fs.String("name", "", "Key store `name` to save the key as, used as DESTINATION=file:NAME")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: sam-bridge keygen [flags]")
+		fmt.Fprintln(fs.Output())
+		fmt.Fprintln(fs.Output(), "Generate a destination key pair. The public destination is printed")
+		fmt.Fprintln(fs.Output(), "on stdout. The base64 private key is printed too unless -out is given,")
+		fmt.Fprintln(fs.Output(), "in which case a Java I2P and i2pd compatible key file is written, or")
+		fmt.Fprintln(fs.Output(), "-keystore and -name are given, in which case it is saved encrypted")
+		fmt.Fprintln(fs.Output(), "for serve -keystore to use as DESTINATION=file:NAME.")
+		fmt.Fprintln(fs.Output())
+		fmt.Fprintln(fs.Output(), "Flags:")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() > 0 {
+		return fmt.Errorf("unexpected argument %q", fs.Arg(0))
+	}
+	if !destination.IsValidSignatureType(*sigType) {
+		return fmt.Errorf("unsupported signature type %d", *sigType)
+	}
+	if (*keyStoreDir == "") != (*name == "") {
+		return errors.New("-keystore and -name must be given together")
+	}
+	if *keyStoreDir != "" && *out != "" {
+		return errors.New("-out cannot be combined with -keystore")
+	}
+	passphrase := os.Getenv(embedding.EnvKeyStorePassphrase)
+	if *keyStoreDir != "" && passphrase == "" {
+		return fmt.Errorf("-keystore requires a passphrase in %s", embedding.EnvKeyStorePassphrase)
+	}
+
+	manager := destination.NewManager()
+	dest, privateKey, err := manager.Generate(*sigType)
+	if err != nil {
+		return fmt.Errorf("generating destination: %w", err)
+	}
+	pub, err := manager.EncodePublic(dest)
+	if err != nil {
+		return fmt.Errorf("encoding destination: %w", err)
+	}
+
+	if *out == "" {
+		priv, err := manager.Encode(dest, privateKey)
+		if err != nil {
+			return fmt.Errorf("encoding private key: %w", err)
+		}
+		if *keyStoreDir != "" {
+			raw, err := destination.Base64Decode(priv)
+			if err != nil {
+				return fmt.Errorf("encoding private key: %w", err)
+			}
+			store := destination.NewKeyStore(*keyStoreDir, destination.NewPassphraseWrapper(passphrase))
+			if err := store.Save(*name, raw); err != nil {
+				return err
+			}
+			fmt.Printf("PUB=%s\n", pub)
+			return nil
+		}
+		fmt.Printf("PUB=%s\n", pub)
+		fmt.Printf("PRIV=%s\n", priv)
+		return nil
+	}
+
+	keyFile, err := destination.EncodePrivateKeyFile(dest, privateKey)
+	if err != nil {
+		return fmt.Errorf("encoding private key file: %w", err)
+	}
+
+	f, err := os.OpenFile(*out, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if err != nil {
+		if errors.Is(err, os.ErrExist) {
+			return fmt.Errorf("%s already exists; refusing to overwrite a private key", *out)
+		}
+		return err
+	}
+	if _, err := f.Write(keyFile); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	fmt.Printf("PUB=%s\n", pub)
+	return nil
+}
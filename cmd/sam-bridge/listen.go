@@ -0,0 +1,88 @@
+package main
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/go-i2p/go-sam-bridge/lib/embedding"
+)
+
+// errListenTLSWithoutCert is returned when -listen-tls is used without a
+// TLS certificate from the config file or environment.
+var errListenTLSWithoutCert = errors.New("-listen-tls requires a TLS certificate (tls.cert and tls.key in -config, or SAM_TLS_CERT and SAM_TLS_KEY)")
+
+// listFlag is a repeatable string flag. Values given on the command line
+// replace the default rather than adding to it.
+type listFlag struct {
+	values []string
+	set    bool
+}
+
+// String implements flag.Value.
+func (f *listFlag) String() string {
+	if f == nil {
+		return ""
+	}
+	return strings.Join(f.values, ",")
+}
+
+// Set implements flag.Value.
+func (f *listFlag) Set(value string) error {
+	if !f.set {
+		f.values = nil
+		f.set = true
+	}
+	f.values = append(f.values, value)
+	return nil
+}
+
+// listenOptions maps -listen, -listen-tls, -listen-proxy and -unix onto
+// the bridge's listen address and endpoints.
+//
+// The embedding package applies TLS to its listen address whenever TLS is
+// configured, so with -listen-tls the first TLS address becomes the listen
+// address and every -listen address is served as a plain endpoint.
+// Without -listen-tls the first -listen address is the listen address, as
+// before, and uses TLS if a certificate is configured. -listen-proxy
+// addresses are always plain endpoints expecting PROXY protocol headers.
+func listenOptions(cfg *Config) []embedding.Option {
+	primary := cfg.ListenAddr
+	var endpoints []embedding.Endpoint
+
+	plain := append([]string{}, cfg.ExtraListenAddrs...)
+	if len(cfg.TLSListenAddrs) > 0 {
+		plain = append([]string{cfg.ListenAddr}, plain...)
+		primary = cfg.TLSListenAddrs[0]
+		for _, addr := range cfg.TLSListenAddrs[1:] {
+			endpoints = append(endpoints, embedding.Endpoint{Network: embedding.NetworkTCP, Address: addr, TLS: true})
+		}
+	}
+	for _, addr := range plain {
+		endpoints = append(endpoints, embedding.Endpoint{Network: embedding.NetworkTCP, Address: addr})
+	}
+	for _, addr := range cfg.ProxyListenAddrs {
+		endpoints = append(endpoints, embedding.Endpoint{Network: embedding.NetworkTCP, Address: addr, ProxyProtocol: true})
+	}
+	for _, path := range cfg.UnixSockets {
+		endpoints = append(endpoints, embedding.Endpoint{Network: embedding.NetworkUnix, Address: path})
+	}
+
+	opts := []embedding.Option{embedding.WithListenAddr(primary)}
+	if len(endpoints) > 0 {
+		opts = append(opts, embedding.WithEndpoints(endpoints...))
+	}
+	return opts
+}
+
+// tlsConfigured reports whether the config file or environment supplies
+// a TLS certificate.
+func tlsConfigured(cfg *Config) bool {
+	probe := embedding.DefaultConfig()
+	for _, opt := range cfg.FileOptions {
+		opt(probe)
+	}
+	for _, opt := range cfg.EnvOptions {
+		opt(probe)
+	}
+	return probe.TLSCertFile != ""
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+
+	"github.com/go-i2p/go-sam-bridge/lib/protocol"
+)
+
+// runLookup resolves a name (hostname, b32 address, or ME) through a
+// running SAM bridge using NAMING LOOKUP.
+func runLookup(args []string) error {
+	var samFlags samClientFlags
+	fs := flag.NewFlagSet("lookup", flag.ContinueOnError)
+	samFlags.register(fs)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: sam-bridge lookup [flags] NAME")
+		fmt.Fprintln(fs.Output())
+		fmt.Fprintln(fs.Output(), "Resolve NAME to a base64 destination through a running SAM bridge.")
+		fmt.Fprintln(fs.Output())
+		fmt.Fprintln(fs.Output(), "Flags:")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return fmt.Errorf("expected exactly one NAME")
+	}
+
+	client, err := dialSAM(&samFlags)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	reply, err := client.Send(protocol.NewCommand(protocol.VerbNaming, protocol.ActionLookup).WithOption("NAME", fs.Arg(0)))
+	if err != nil {
+		return err
+	}
+	if err := checkResult(reply); err != nil {
+		return fmt.Errorf("lookup %s: %w", fs.Arg(0), err)
+	}
+	fmt.Println(reply.Get("VALUE"))
+	return nil
+}
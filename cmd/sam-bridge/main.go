@@ -4,37 +4,90 @@
 //
 // Usage:
 //
-//	sam-bridge [flags]
+//	sam-bridge [command] [flags]
 //
-// Flags:
+// Commands:
 //
-//	-listen string     SAM listen address (default ":7656")
+//	serve    Run the SAM bridge server (default)
+//	keygen   Generate a destination key pair
+//	lookup   Resolve a name through a running SAM bridge
+//	passwd   Hash a password for the auth users config
+//	check    Health-check a running SAM bridge and I2P router
+//	console  Send SAM commands to a running bridge interactively
+//	service  Install, remove, or run as a Windows service
+//	version  Show version information
+//	help     Show help message
+//
+// Running sam-bridge without a command, or with flags only, runs serve.
+//
+// Serve flags:
+//
+//	-listen string     SAM listen address (default ":7656"; repeatable)
+//	-listen-tls string SAM TLS listen address (repeatable; needs a TLS certificate)
+//	-tls-min-version   Lowest accepted TLS version for SAM clients (default 1.2)
+//	-tls-cipher-suites Cipher suites allowed for TLS 1.2 and earlier
+//	-tls-alpn          ALPN protocols offered to SAM clients
+//	-listen-proxy string SAM listen address behind a PROXY protocol load balancer (repeatable)
+//	-unix string       SAM Unix socket path (repeatable)
 //	-i2cp string       I2CP router address (default "127.0.0.1:7654")
 //	-udp string        UDP datagram port (default ":7655")
+//	-config string     Configuration file (YAML, TOML, or JSON)
+//	-audit-log string  Append command audit records to this file
+//	-access-log string Append connection access records to this file
+//	-admin string      Serve the JSON admin API on this address
+//	-metrics-addr      Serve Prometheus metrics at /metrics on this address
+//	-pprof string      Serve net/http/pprof on this loopback address
+//	-i2pcontrol string Serve the I2PControl JSON-RPC API on this address
+//	-socks string      Serve a SOCKS5 proxy to .i2p hosts on this address
+//	-http-proxy string Serve an HTTP proxy to .i2p hosts on this address
+//	-http-outproxy     Send the HTTP proxy's clearnet requests through this .i2p host:port
+//	-bob string        Serve a BOB listener for legacy applications on this address
+//	-pidfile string    Write the process ID to this file while running
+//	-keystore string   Serve DESTINATION=file:NAME from encrypted keys in this directory
+//	-shutdown-timeout  Drain open connections for up to this long on shutdown
+//	-session-idle-timeout  Close sessions with no traffic for this long
+//	-stream-read-timeout   Close streams whose peer sends nothing for this long
+//	-stream-write-timeout  Close streams whose writes stall this long
+//	-max-sessions      Maximum open sessions (0 = no limit)
+//	-max-sessions-per-user  Maximum open sessions per authenticated user
+//	-max-outbound-streams   Maximum concurrent STREAM CONNECTs per session
+//	-max-inbound-streams    Maximum concurrent inbound streams per session
+//	-send-queue int    Queue datagram sends per session for retry (0 = off)
+//	-send-retries int  Retries per queued datagram (default 3)
+//	-send-expiry dur   Drop queued datagrams not sent within this long
+//	-log-file string   Write logs to this file instead of stdout
+//	-log-max-size int  Rotate the log file after this many megabytes (default 100)
+//	-log-max-age dur   Rotate the log file after this long
+//	-log-max-backups   Number of rotated log files to keep (default 5)
 //	-debug             Enable debug logging
-//	-help              Show help message
+//	-log-format string Log format: text or json (default "text")
+//	-log-sample-burst  Log at most this many repeated hot-path records per interval
+//	-log-sample-interval Log sampling interval (default 1s)
+//
+// Sending SIGHUP to serve rereads the config file and environment and
+// applies auth users, the log level, log sampling, and TLS certificates
+// in place; other changed settings are logged as requiring a restart.
+//
+// serve always runs in the foreground; to detach, run it under a
+// supervisor (systemd, runit, a Windows service). -pidfile records the
+// process ID and is removed on graceful shutdown.
+//
+// Under systemd with Type=notify, serve reports readiness once the
+// listener and I2CP connection are up, and pings the watchdog while the
+// bridge is healthy if WatchdogSec= is configured. With socket activation
+// (LISTEN_FDS), serve uses the passed ListenStream= sockets instead of
+// -listen, -listen-tls and -unix, and a ListenDatagram= socket instead of
+// -udp; without it, serve binds its own sockets.
 //
 // See SAMv3.md for the complete SAM protocol specification.
 package main
 
 import (
-	"context"
+	"errors"
 	"flag"
 	"fmt"
-	"net"
 	"os"
-	"os/signal"
-	"strconv"
-	"syscall"
-	"time"
-
-	"github.com/go-i2p/go-sam-bridge/lib/embedding"
-	"github.com/go-i2p/go-sam-bridge/lib/handler"
-	"github.com/go-i2p/go-sam-bridge/lib/i2cp"
-	"github.com/go-i2p/go-sam-bridge/lib/session"
-	samstreaming "github.com/go-i2p/go-sam-bridge/lib/streaming"
-	"github.com/go-i2p/go-streaming"
-	"github.com/sirupsen/logrus"
+	"strings"
 )
 
 var (
@@ -46,277 +99,93 @@ var (
 	GitCommit = "unknown"
 )
 
-func main() {
-	cfg := parseFlags()
-
-	// Configure logging
-	log := logrus.New()
-	log.SetOutput(os.Stdout)
-	if cfg.Debug {
-		log.SetLevel(logrus.DebugLevel)
-		log.SetFormatter(&logrus.TextFormatter{FullTimestamp: true})
-	} else {
-		log.SetLevel(logrus.InfoLevel)
-	}
-
-	log.WithFields(logrus.Fields{
-		"version":   Version,
-		"buildTime": BuildTime,
-		"commit":    GitCommit,
-	}).Info("Starting SAM bridge server")
-
-	// Connect to I2P router for I2CP integration
-	i2cpClient, err := connectI2CP(cfg, log)
-	if err != nil {
-		log.WithError(err).Error("Failed to connect to I2P router")
-		log.Info("Make sure I2P is running and SAM interface is enabled")
-		os.Exit(1)
-	}
-	defer i2cpClient.Close()
-
-	log.WithField("version", i2cpClient.RouterVersion()).Info("Connected to I2P router")
-
-	// Create I2CP provider adapter
-	i2cpProvider := newI2CPProviderAdapter(i2cpClient)
-
-	// Parse datagram port
-	datagramPort := parseDatagramPort(cfg.UDPAddr)
-
-	// Create bridge with embedding API
-	bridge, err := embedding.New(
-		embedding.WithListenAddr(cfg.ListenAddr),
-		embedding.WithI2CPAddr(cfg.I2CPAddr),
-		embedding.WithDatagramPort(datagramPort),
-		embedding.WithI2CPProvider(i2cpProvider),
-		embedding.WithLogger(log),
-		embedding.WithDebug(cfg.Debug),
-		embedding.WithHandlerRegistrar(createHandlerRegistrar(i2cpClient)),
-	)
-	if err != nil {
-		log.WithError(err).Error("Failed to create bridge")
-		os.Exit(1)
-	}
-
-	// Start bridge
-	ctx, cancel := context.WithCancel(context.Background())
-	defer cancel()
-
-	if err := bridge.Start(ctx); err != nil {
-		log.WithError(err).Error("Failed to start bridge")
-		os.Exit(1)
-	}
-
-	// Wait for shutdown signal
-	sigChan := make(chan os.Signal, 1)
-	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
-	<-sigChan
-
-	log.Info("Received shutdown signal")
-	bridge.Stop(context.Background())
+// commands maps subcommand names to their implementations.
+// Each receives the arguments following the subcommand name.
+// The help command is dispatched separately by main.
+var commands = map[string]func(args []string) error{
+	"serve":   runServe,
+	"keygen":  runKeygen,
+	"lookup":  runLookup,
+	"passwd":  runPasswd,
+	"check":   runCheck,
+	"console": runConsole,
+	"service": runService,
+	"version": runVersion,
 }
 
-// Config holds command-line configuration.
-type Config struct {
-	ListenAddr string
-	I2CPAddr   string
-	UDPAddr    string
-	Debug      bool
-	Username   string
-	Password   string
-}
-
-func parseFlags() *Config {
-	cfg := &Config{}
-
-	flag.StringVar(&cfg.ListenAddr, "listen", ":7656", "SAM listen address")
-	flag.StringVar(&cfg.I2CPAddr, "i2cp", "127.0.0.1:7654", "I2CP router address")
-	flag.StringVar(&cfg.UDPAddr, "udp", ":7655", "UDP datagram port")
-	flag.BoolVar(&cfg.Debug, "debug", false, "Enable debug logging")
-	flag.StringVar(&cfg.Username, "user", "", "I2CP username (optional)")
-	flag.StringVar(&cfg.Password, "pass", "", "I2CP password (optional)")
-
-	showVersion := flag.Bool("version", false, "Show version information")
-	showHelp := flag.Bool("help", false, "Show help message")
-
-	flag.Parse()
-
-	if *showVersion {
-		fmt.Printf("sam-bridge %s\n", Version)
-		fmt.Printf("Build time: %s\n", BuildTime)
-		fmt.Printf("Git commit: %s\n", GitCommit)
-		os.Exit(0)
-	}
-
-	if *showHelp {
-		fmt.Println("SAM Bridge - SAMv3.3 Protocol Bridge for I2P")
-		fmt.Println()
-		fmt.Println("Usage: sam-bridge [flags]")
-		fmt.Println()
-		fmt.Println("Flags:")
-		flag.PrintDefaults()
-		fmt.Println()
-		fmt.Println("Environment variables:")
-		fmt.Println("  SAM_LISTEN    SAM listen address (overrides -listen)")
-		fmt.Println("  I2CP_ADDR     I2CP router address (overrides -i2cp)")
-		fmt.Println("  SAM_DEBUG     Enable debug logging (overrides -debug)")
-		os.Exit(0)
-	}
-
-	// Override with environment variables
-	if env := os.Getenv("SAM_LISTEN"); env != "" {
-		cfg.ListenAddr = env
-	}
-	if env := os.Getenv("I2CP_ADDR"); env != "" {
-		cfg.I2CPAddr = env
-	}
-	if os.Getenv("SAM_DEBUG") != "" {
-		cfg.Debug = true
+func main() {
+	name, args := "serve", os.Args[1:]
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		name, args = args[0], args[1:]
+	}
+
+	// Keep the pre-subcommand -version and -help flags working.
+	if len(args) > 0 && name == "serve" {
+		switch strings.TrimLeft(args[0], "-") {
+		case "version":
+			name = "version"
+		case "help", "h":
+			name = "help"
+		}
 	}
 
-	return cfg
-}
-
-func connectI2CP(cfg *Config, log *logrus.Logger) (*i2cp.Client, error) {
-	i2cpConfig := &i2cp.ClientConfig{
-		RouterAddr: cfg.I2CPAddr,
-		Username:   cfg.Username,
-		Password:   cfg.Password,
+	if name == "help" {
+		runHelp(args)
+		return
 	}
 
-	client := i2cp.NewClient(i2cpConfig)
-	ctx := context.Background()
-
-	log.WithField("addr", cfg.I2CPAddr).Info("Connecting to I2P router")
-	if err := client.Connect(ctx); err != nil {
-		return nil, err
+	run, ok := commands[name]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "sam-bridge: unknown command %q\n\n", name)
+		printUsage()
+		os.Exit(2)
 	}
 
-	return client, nil
-}
-
-func parseDatagramPort(addr string) int {
-	if addr == "" {
-		return embedding.DefaultDatagramPort
-	}
-	_, portStr, err := net.SplitHostPort(addr)
-	if err != nil {
-		portStr = addr
-	}
-	if port, err := strconv.Atoi(portStr); err == nil {
-		return port
-	}
-	return embedding.DefaultDatagramPort
-}
-
-// createHandlerRegistrar returns a custom handler registrar with I2CP integration.
-// This extends the default registrar with I2CP-specific session callbacks.
-func createHandlerRegistrar(i2cpClient *i2cp.Client) embedding.HandlerRegistrarFunc {
-	return func(router *handler.Router, deps *embedding.Dependencies) {
-		log := deps.Logger
-
-		// Use default handler registrar for base handlers
-		embedding.DefaultHandlerRegistrar()(router, deps)
-
-		// Get the SESSION handler to add I2CP callback
-		// The default registrar already created it, we need to extend it
-		// For now, we re-register with the extended callback
-		streamConnector := handler.NewStreamingConnector()
-		streamAcceptor := handler.NewStreamingAcceptor()
-		streamForwarder := handler.NewStreamingForwarder()
-
-		sessionHandler := handler.NewSessionHandler(deps.DestManager)
-		sessionHandler.SetI2CPProvider(deps.I2CPProvider)
-
-		// Set session created callback for StreamManager wiring
-		sessionHandler.SetSessionCreatedCallback(func(sess session.Session, i2cpHandle session.I2CPSessionHandle) {
-			if sess.Style() != session.StyleStream || i2cpHandle == nil {
-				return
-			}
-
-			i2cpSess, ok := i2cpHandle.(*i2cp.I2CPSession)
-			if !ok {
-				log.WithField("sessionID", sess.ID()).Warn("Cannot create StreamManager: invalid I2CP session type")
-				return
-			}
-
-			underlyingSession := i2cpSess.Session()
-			underlyingClient := i2cpClient.I2CPClient()
-			if underlyingSession == nil || underlyingClient == nil {
-				log.WithField("sessionID", sess.ID()).Warn("Cannot create StreamManager: no underlying I2CP session/client")
-				return
-			}
-
-			streamManager, err := streaming.NewStreamManagerFromSession(underlyingClient, underlyingSession)
-			if err != nil {
-				log.WithField("sessionID", sess.ID()).WithError(err).Warn("Failed to create StreamManager from session")
-				return
-			}
-
-			adapter, err := samstreaming.NewAdapter(streamManager)
-			if err != nil {
-				log.WithField("sessionID", sess.ID()).WithError(err).Warn("Failed to create StreamManager adapter")
-				return
-			}
-
-			streamConnector.RegisterManager(sess.ID(), adapter)
-			streamAcceptor.RegisterManager(sess.ID(), adapter)
-			streamForwarder.RegisterManager(sess.ID(), adapter)
-
-			log.WithField("sessionID", sess.ID()).Debug("Registered StreamManager for session")
-		})
-
-		// Re-register SESSION handlers with extended callback
-		router.Register("SESSION CREATE", sessionHandler)
-		router.Register("SESSION ADD", sessionHandler)
-		router.Register("SESSION REMOVE", sessionHandler)
-
-		// Re-register STREAM handlers with new connectors
-		streamHandler := handler.NewStreamHandler(streamConnector, streamAcceptor, streamForwarder)
-		router.Register("STREAM CONNECT", streamHandler)
-		router.Register("STREAM ACCEPT", streamHandler)
-		router.Register("STREAM FORWARD", streamHandler)
-
-		// Wire destination resolver for NAMING handler
-		destResolver, err := i2cp.NewClientDestinationResolverAdapter(i2cpClient, 30*time.Second)
-		if err == nil {
-			namingHandler := handler.NewNamingHandler(deps.DestManager)
-			namingHandler.SetDestinationResolver(destResolver)
-			router.Register("NAMING LOOKUP", namingHandler)
-			log.Debug("Wired destination resolver to NAMING handler")
+	if err := run(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return
 		}
-
-		log.Debug("Extended handlers with I2CP integration")
+		fmt.Fprintf(os.Stderr, "sam-bridge %s: %v\n", name, err)
+		os.Exit(1)
 	}
 }
 
-// i2cpProviderAdapter wraps i2cp.Client to implement session.I2CPSessionProvider.
-type i2cpProviderAdapter struct {
-	client *i2cp.Client
+// runVersion prints version and build information.
+func runVersion(args []string) error {
+	fmt.Printf("sam-bridge %s\n", Version)
+	fmt.Printf("Build time: %s\n", BuildTime)
+	fmt.Printf("Git commit: %s\n", GitCommit)
+	return nil
 }
 
-func newI2CPProviderAdapter(client *i2cp.Client) *i2cpProviderAdapter {
-	return &i2cpProviderAdapter{client: client}
-}
-
-func (a *i2cpProviderAdapter) CreateSessionForSAM(ctx context.Context, samSessionID string, config *session.SessionConfig) (session.I2CPSessionHandle, error) {
-	i2cpConfig := &i2cp.SessionConfigFromSession{
-		SignatureType:          config.SignatureType,
-		EncryptionTypes:        config.EncryptionTypes,
-		InboundQuantity:        config.InboundQuantity,
-		OutboundQuantity:       config.OutboundQuantity,
-		InboundLength:          config.InboundLength,
-		OutboundLength:         config.OutboundLength,
-		InboundBackupQuantity:  config.InboundBackupQuantity,
-		OutboundBackupQuantity: config.OutboundBackupQuantity,
-		FastReceive:            config.FastReceive,
-		ReduceIdleTime:         config.ReduceIdleTime,
-		CloseIdleTime:          config.CloseIdleTime,
+// runHelp prints the top-level usage, or a subcommand's usage when one is
+// named (e.g. "sam-bridge help keygen").
+func runHelp(args []string) {
+	if len(args) > 0 && args[0] != "version" {
+		if run, ok := commands[args[0]]; ok {
+			_ = run([]string{"-help"})
+			return
+		}
 	}
-	return a.client.CreateSessionForSAM(ctx, samSessionID, i2cpConfig)
+	printUsage()
 }
 
-func (a *i2cpProviderAdapter) IsConnected() bool {
-	return a.client.IsConnected()
+// printUsage prints the top-level help message.
+func printUsage() {
+	fmt.Println("SAM Bridge - SAMv3.3 Protocol Bridge for I2P")
+	fmt.Println()
+	fmt.Println("Usage: sam-bridge [command] [flags]")
+	fmt.Println()
+	fmt.Println("Commands:")
+	fmt.Println("  serve    Run the SAM bridge server (default)")
+	fmt.Println("  keygen   Generate a destination key pair")
+	fmt.Println("  lookup   Resolve a name through a running SAM bridge")
+	fmt.Println("  passwd   Hash a password for the auth users config")
+	fmt.Println("  check    Health-check a running SAM bridge and I2P router")
+	fmt.Println("  console  Send SAM commands to a running bridge interactively")
+	fmt.Println("  service  Install, remove, or run as a Windows service")
+	fmt.Println("  version  Show version information")
+	fmt.Println("  help     Show help message")
+	fmt.Println()
+	fmt.Println("Run 'sam-bridge help <command>' for command flags.")
 }
-
-var _ session.I2CPSessionProvider = (*i2cpProviderAdapter)(nil)
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/go-i2p/go-sam-bridge/lib/bridge"
+)
+
+// runPasswd reads a password from the first line of stdin and prints its
+// Argon2id hash, for use in place of the plaintext password of a user in
+// -auth-users, SAM_AUTH_USERS or a config file. Reading stdin rather than
+// an argument keeps the password out of shell history and process lists.
+func runPasswd(args []string) error {
+	fs := flag.NewFlagSet("passwd", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: sam-bridge passwd < password.txt")
+		fmt.Fprintln(fs.Output())
+		fmt.Fprintln(fs.Output(), "Hash the password on the first line of stdin with Argon2id and print")
+		fmt.Fprintln(fs.Output(), "the hash. The bridge accepts the hash, or a bcrypt hash, wherever a")
+		fmt.Fprintln(fs.Output(), "user's password is configured, so plaintext need not be stored.")
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() > 0 {
+		return fmt.Errorf("unexpected argument %q", fs.Arg(0))
+	}
+
+	line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("reading password: %w", err)
+	}
+	password := strings.TrimRight(line, "\r\n")
+	if password == "" {
+		return errors.New("no password on stdin")
+	}
+
+	hash, err := bridge.HashPassword(password)
+	if err != nil {
+		return fmt.Errorf("hashing password: %w", err)
+	}
+	fmt.Println(hash)
+	return nil
+}
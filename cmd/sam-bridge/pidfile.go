@@ -0,0 +1,55 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+// writePIDFile records the current process ID in path so supervisors and
+// scripts can signal the daemon. It refuses to overwrite a PID file that
+// names another running process, and replaces stale ones left by a crash.
+func writePIDFile(path string) error {
+	if pid, err := readPIDFile(path); err == nil && pid != os.Getpid() && processAlive(pid) {
+		return fmt.Errorf("PID file %s names running process %d", path, pid)
+	}
+	data := []byte(strconv.Itoa(os.Getpid()) + "\n")
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return fmt.Errorf("writing PID file: %w", err)
+	}
+	return nil
+}
+
+// removePIDFile deletes path if it still names the current process.
+func removePIDFile(path string) error {
+	pid, err := readPIDFile(path)
+	if err != nil || pid != os.Getpid() {
+		return nil
+	}
+	return os.Remove(path)
+}
+
+// readPIDFile returns the process ID stored in path.
+func readPIDFile(path string) (int, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.Atoi(strings.TrimSpace(string(data)))
+}
+
+// processAlive reports whether a process with the given ID exists.
+// Signal 0 checks for existence without delivering a signal; where it is
+// unsupported (Windows) the process is assumed gone.
+func processAlive(pid int) bool {
+	if pid <= 0 {
+		return false
+	}
+	p, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	return p.Signal(syscall.Signal(0)) == nil
+}
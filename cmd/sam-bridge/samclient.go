@@ -0,0 +1,126 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/go-i2p/go-sam-bridge/lib/protocol"
+)
+
+// samClientFlags holds the flags shared by subcommands that talk to a
+// running SAM bridge.
+type samClientFlags struct {
+	addr     string
+	user     string
+	password string
+	timeout  time.Duration
+}
+
+// register adds the SAM client flags to fs.
+func (f *samClientFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&f.addr, "sam", "127.0.0.1:7656", "SAM bridge address")
+	fs.StringVar(&f.user, "user", "", "SAM username (optional)")
+	fs.StringVar(&f.password, "pass", "", "SAM password (optional)")
+	fs.DurationVar(&f.timeout, "timeout", 30*time.Second, "Timeout for each SAM command")
+}
+
+// samClient is a minimal SAM control socket client for the CLI.
+type samClient struct {
+	conn    net.Conn
+	reader  *bufio.Reader
+	parser  *protocol.Parser
+	timeout time.Duration
+
+	// Version is the SAM version negotiated by HELLO.
+	Version string
+}
+
+// dialSAM connects to the SAM bridge described by f and completes the
+// HELLO handshake.
+func dialSAM(f *samClientFlags) (*samClient, error) {
+	conn, err := net.DialTimeout("tcp", f.addr, f.timeout)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &samClient{
+		conn:    conn,
+		reader:  bufio.NewReader(conn),
+		parser:  protocol.NewParser(),
+		timeout: f.timeout,
+	}
+
+	hello := protocol.NewCommand(protocol.VerbHello, protocol.ActionVersion).
+		WithOption("MIN", protocol.SAMVersionMin).
+		WithOption("MAX", protocol.SAMVersionMax)
+	if f.user != "" {
+		hello.WithOption("USER", f.user).WithOption("PASSWORD", f.password)
+	}
+	reply, err := c.Send(hello)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	if err := checkResult(reply); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("HELLO: %w", err)
+	}
+	c.Version = reply.Get("VERSION")
+	return c, nil
+}
+
+// Command sends line as typed and returns the parsed reply.
+func (c *samClient) Command(line string) (*protocol.Command, error) {
+	return c.roundTrip([]byte(line + "\n"))
+}
+
+// Send sends cmd and returns the parsed reply.
+func (c *samClient) Send(cmd *protocol.Command) (*protocol.Command, error) {
+	return c.roundTrip(cmd.Bytes())
+}
+
+// roundTrip writes msg and reads the reply. Keepalive PINGs from the
+// bridge are answered while waiting.
+func (c *samClient) roundTrip(msg []byte) (*protocol.Command, error) {
+	if err := c.conn.SetDeadline(time.Now().Add(c.timeout)); err != nil {
+		return nil, err
+	}
+	if _, err := c.conn.Write(msg); err != nil {
+		return nil, err
+	}
+	for {
+		text, err := c.reader.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		reply, err := c.parser.Parse(strings.TrimRight(text, "\r\n"))
+		if err != nil || reply.Verb != protocol.VerbPing {
+			return reply, err
+		}
+		pong := "PONG" + strings.TrimPrefix(reply.Raw, "PING")
+		if _, err := c.conn.Write([]byte(pong + "\n")); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// Close closes the control socket.
+func (c *samClient) Close() error {
+	return c.conn.Close()
+}
+
+// checkResult returns an error describing reply unless RESULT=OK.
+func checkResult(reply *protocol.Command) error {
+	result := reply.Get("RESULT")
+	if result == protocol.ResultOK {
+		return nil
+	}
+	if msg := reply.Get("MESSAGE"); msg != "" {
+		return fmt.Errorf("%s: %s", result, msg)
+	}
+	return fmt.Errorf("%s", result)
+}
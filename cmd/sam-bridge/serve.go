@@ -0,0 +1,951 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"net"
+	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
+	"time"
+
+	"github.com/go-i2p/go-sam-bridge/lib/destination"
+	"github.com/go-i2p/go-sam-bridge/lib/embedding"
+	"github.com/go-i2p/go-sam-bridge/lib/handler"
+	"github.com/go-i2p/go-sam-bridge/lib/i2cp"
+	"github.com/go-i2p/go-sam-bridge/lib/session"
+	samstreaming "github.com/go-i2p/go-sam-bridge/lib/streaming"
+	"github.com/go-i2p/go-sam-bridge/lib/util"
+	"github.com/go-i2p/go-streaming"
+	"github.com/sirupsen/logrus"
+)
+
+// runServe runs the SAM bridge server until SIGINT or SIGTERM.
+func runServe(args []string) error {
+	return serve(args, waitForShutdown)
+}
+
+// waitFunc blocks until the bridge should shut down. It may call reload
+// to reapply the configuration to the running bridge.
+type waitFunc func(bridge *embedding.Bridge, log *logrus.Logger, reload func())
+
+// serve starts the SAM bridge configured by args, calls wait once it is
+// serving, and stops the bridge when wait returns. The wait function lets
+// service managers other than a terminal decide when to shut down.
+func serve(args []string, wait waitFunc) error {
+	cfg, err := parseServeFlags(args)
+	if err != nil {
+		return err
+	}
+	if cfg.Activation, err = sdListenSockets(); err != nil {
+		return err
+	}
+
+	// Configure logging
+	log := logrus.New()
+	log.SetOutput(os.Stdout)
+	if cfg.LogFile != "" {
+		logFile, err := util.NewRotatingFile(cfg.LogFile, cfg.LogRotation)
+		if err != nil {
+			return err
+		}
+		defer logFile.Close()
+		log.SetOutput(logFile)
+	}
+	formatter, err := embedding.NewLogFormatter(cfg.LogFormat)
+	if err != nil {
+		return err
+	}
+	log.SetFormatter(formatter)
+	if cfg.Debug {
+		log.SetLevel(logrus.DebugLevel)
+	} else {
+		log.SetLevel(logrus.InfoLevel)
+	}
+
+	if cfg.PIDFile != "" {
+		if err := writePIDFile(cfg.PIDFile); err != nil {
+			return err
+		}
+		defer func() {
+			if err := removePIDFile(cfg.PIDFile); err != nil {
+				log.WithError(err).Warn("Failed to remove PID file")
+			}
+		}()
+	}
+
+	log.WithFields(logrus.Fields{
+		"version":   Version,
+		"buildTime": BuildTime,
+		"commit":    GitCommit,
+	}).Info("Starting SAM bridge server")
+	if act := cfg.Activation; act != nil {
+		log.WithFields(logrus.Fields{
+			"listeners": len(act.listeners),
+			"datagram":  act.packetConn != nil,
+		}).Info("Using sockets from systemd socket activation")
+	}
+
+	// Connect to I2P router for I2CP integration
+	i2cpClient := newI2CPClient(cfg, log)
+	defer i2cpClient.Close()
+	if cfg.I2CPLazy {
+		connectCtx, stopConnect := context.WithCancel(context.Background())
+		defer stopConnect()
+		go connectI2CPInBackground(connectCtx, i2cpClient, log)
+	} else {
+		log.WithFields(logrus.Fields{
+			"addr":     cfg.I2CPAddr,
+			"failover": cfg.I2CPFailoverAddrs,
+		}).Info("Connecting to I2P router")
+		if err := i2cpClient.Connect(context.Background()); err != nil {
+			log.Info("Make sure I2P is running and SAM interface is enabled")
+			return fmt.Errorf("connecting to I2P router: %w", err)
+		}
+		logI2CPConnected(i2cpClient, log)
+	}
+
+	// Create I2CP provider adapter
+	i2cpProvider := i2cp.NewSessionProviderAdapter(i2cpClient)
+	registrar := createHandlerRegistrar(i2cpClient, cfg.LookupCache)
+
+	// Create bridge with embedding API
+	bridge, err := embedding.New(bridgeOptions(cfg, i2cpProvider, log, registrar)...)
+	if err != nil {
+		return fmt.Errorf("creating bridge: %w", err)
+	}
+
+	// Start bridge
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	if err := bridge.Start(ctx); err != nil {
+		return fmt.Errorf("starting bridge: %w", err)
+	}
+
+	// Tell systemd the listener is up, and the I2CP connection unless
+	// -i2cp-lazy is still connecting it
+	if ok, err := sdNotify("READY=1"); err != nil {
+		log.WithError(err).Warn("Failed to notify systemd")
+	} else if ok {
+		log.Debug("Notified systemd of readiness")
+	}
+	go runWatchdog(ctx, bridge, log)
+
+	reload := func() {
+		sdNotify("RELOADING=1")
+		defer sdNotify("READY=1")
+
+		newCfg, err := parseServeFlags(args)
+		if err != nil {
+			log.WithError(err).Error("Failed to reload configuration")
+			return
+		}
+		newCfg.Activation = cfg.Activation
+		result, err := bridge.Reload(bridgeOptions(newCfg, i2cpProvider, log, registrar)...)
+		if err != nil {
+			log.WithError(err).Error("Failed to reload configuration")
+			return
+		}
+		log.WithField("applied", result.Applied).Info("Reloaded configuration")
+		if len(result.RestartRequired) > 0 {
+			log.WithField("settings", result.RestartRequired).Warn("Changed settings require a restart")
+		}
+	}
+
+	wait(bridge, log, reload)
+
+	log.Info("Received shutdown signal")
+	sdNotify("STOPPING=1")
+	if err := bridge.Stop(context.Background()); err != nil && !errors.Is(err, context.DeadlineExceeded) {
+		return err
+	}
+	return nil
+}
+
+// bridgeOptions returns the embedding options for cfg.
+// Precedence is config file, then flags, then environment variables.
+func bridgeOptions(cfg *Config, provider *i2cp.SessionProviderAdapter, log *logrus.Logger, registrar embedding.HandlerRegistrarFunc) []embedding.Option {
+	opts := append([]embedding.Option{}, cfg.FileOptions...)
+	// Activated stream sockets replace -listen, -listen-tls and -unix
+	if cfg.Activation == nil || len(cfg.Activation.listeners) == 0 {
+		opts = append(opts, listenOptions(cfg)...)
+	}
+	opts = append(opts,
+		embedding.WithI2CPAddr(cfg.I2CPAddr),
+		embedding.WithDatagramPort(parseDatagramPort(cfg.UDPAddr)),
+		embedding.WithI2CPProvider(provider),
+		embedding.WithLogger(log),
+		embedding.WithDebug(cfg.Debug),
+		embedding.WithLogFormat(cfg.LogFormat),
+		embedding.WithHandlerRegistrar(registrar),
+	)
+	if cfg.Activation != nil {
+		opts = append(opts, cfg.Activation.options()...)
+	}
+	if cfg.AuditLog != "" {
+		opts = append(opts, embedding.WithAuditLogFile(cfg.AuditLog))
+	}
+	if cfg.AccessLog != "" {
+		opts = append(opts, embedding.WithAccessLogFile(cfg.AccessLog))
+	}
+	if cfg.AdminAddr != "" {
+		opts = append(opts, embedding.WithAdminAddr(cfg.AdminAddr))
+	}
+	if cfg.MetricsAddr != "" {
+		opts = append(opts, embedding.WithMetricsAddr(cfg.MetricsAddr))
+	}
+	if cfg.PprofAddr != "" {
+		opts = append(opts, embedding.WithPprofAddr(cfg.PprofAddr))
+	}
+	if cfg.I2PControlAddr != "" {
+		opts = append(opts, embedding.WithI2PControlAddr(cfg.I2PControlAddr))
+	}
+	if cfg.SOCKSAddr != "" {
+		opts = append(opts, embedding.WithSOCKSAddr(cfg.SOCKSAddr))
+	}
+	if cfg.HTTPProxyAddr != "" {
+		opts = append(opts, embedding.WithHTTPProxyAddr(cfg.HTTPProxyAddr))
+	}
+	if cfg.HTTPOutproxy != "" {
+		opts = append(opts, embedding.WithHTTPOutproxy(cfg.HTTPOutproxy))
+	}
+	if cfg.BOBAddr != "" {
+		opts = append(opts, embedding.WithBOBAddr(cfg.BOBAddr))
+	}
+	if cfg.KeyStoreDir != "" {
+		opts = append(opts, embedding.WithKeyStoreDir(cfg.KeyStoreDir))
+	}
+	if cfg.ShutdownTimeout > 0 {
+		opts = append(opts, embedding.WithDrainTimeout(cfg.ShutdownTimeout))
+	}
+	if cfg.IdleTimeout > 0 {
+		opts = append(opts, embedding.WithIdleTimeout(cfg.IdleTimeout))
+	}
+	if cfg.SessionIdleTimeout > 0 {
+		opts = append(opts, embedding.WithSessionIdleTimeout(cfg.SessionIdleTimeout))
+	}
+	if cfg.MaxSessions > 0 {
+		opts = append(opts, embedding.WithMaxSessions(cfg.MaxSessions))
+	}
+	if cfg.MaxSessionsPerUser > 0 {
+		opts = append(opts, embedding.WithMaxSessionsPerUser(cfg.MaxSessionsPerUser))
+	}
+	if cfg.MaxOutboundStreams > 0 {
+		opts = append(opts, embedding.WithMaxOutboundStreams(cfg.MaxOutboundStreams))
+	}
+	if cfg.MaxInboundStreams > 0 {
+		opts = append(opts, embedding.WithMaxInboundStreams(cfg.MaxInboundStreams))
+	}
+	if cfg.SendQueueSize > 0 {
+		opts = append(opts, embedding.WithSendQueue(cfg.SendQueueSize, cfg.SendRetries, cfg.SendExpiry))
+	}
+	if cfg.MaxConnections > 0 {
+		opts = append(opts, embedding.WithMaxConnections(cfg.MaxConnections, cfg.MaxQueuedConnections))
+	}
+	if cfg.MaxConnectionsPerIP > 0 {
+		opts = append(opts, embedding.WithMaxConnectionsPerIP(cfg.MaxConnectionsPerIP))
+	}
+	if !cfg.Socket.IsZero() {
+		opts = append(opts, embedding.WithSocketOptions(cfg.Socket))
+	}
+	if !cfg.ForwardRetry.IsZero() {
+		opts = append(opts, embedding.WithForwardRetry(cfg.ForwardRetry))
+	}
+	if !cfg.TLSPolicy.IsZero() {
+		opts = append(opts, embedding.WithTLSPolicy(cfg.TLSPolicy))
+	}
+	if cfg.DestinationPoolSize > 0 {
+		opts = append(opts, embedding.WithDestinationPoolSize(cfg.DestinationPoolSize))
+	}
+	if cfg.LogSampleBurst > 0 {
+		opts = append(opts, embedding.WithLogSampling(cfg.LogSampleBurst, cfg.LogSampleInterval))
+	}
+	if cfg.AcceptBacklog > 0 {
+		opts = append(opts, embedding.WithAcceptBacklog(cfg.AcceptBacklog))
+	}
+	if cfg.AcceptBacklogTimeout > 0 {
+		opts = append(opts, embedding.WithAcceptBacklogTimeout(cfg.AcceptBacklogTimeout))
+	}
+	if cfg.StreamReadTimeout > 0 {
+		opts = append(opts, embedding.WithStreamReadTimeout(cfg.StreamReadTimeout))
+	}
+	if cfg.StreamWriteTimeout > 0 {
+		opts = append(opts, embedding.WithStreamWriteTimeout(cfg.StreamWriteTimeout))
+	}
+	if cfg.StrictQuoting {
+		opts = append(opts, embedding.WithStrictQuoting(true))
+	}
+	if cfg.Trace {
+		opts = append(opts, embedding.WithTraceWriter(os.Stderr))
+	}
+	return append(opts, cfg.EnvOptions...)
+}
+
+// waitForShutdown blocks until SIGINT or SIGTERM is received.
+// SIGHUP rereads the config file and environment and applies reloadable
+// settings (auth users, log level, TLS certificates).
+func waitForShutdown(bridge *embedding.Bridge, log *logrus.Logger, reload func()) {
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP)
+	defer signal.Stop(sigChan)
+
+	for sig := range sigChan {
+		if sig != syscall.SIGHUP {
+			return
+		}
+		log.Info("Received SIGHUP; reloading configuration")
+		reload()
+	}
+}
+
+// Config holds command-line configuration.
+type Config struct {
+	ListenAddr string
+	I2CPAddr   string
+	UDPAddr    string
+	Debug      bool
+	LogFormat  string
+	Username   string
+	Password   string
+	ConfigFile string
+	AuditLog   string
+	AccessLog  string
+	AdminAddr  string
+	PIDFile    string
+
+	// MetricsAddr serves Prometheus metrics at /metrics when set.
+	MetricsAddr string
+
+	// PprofAddr serves net/http/pprof at /debug/pprof/ when set.
+	PprofAddr string
+
+	// I2PControlAddr serves the I2PControl JSON-RPC API when set.
+	I2PControlAddr string
+
+	// SOCKSAddr serves a SOCKS5 proxy to .i2p hosts when set.
+	SOCKSAddr string
+
+	// HTTPProxyAddr serves an HTTP proxy to .i2p hosts when set.
+	HTTPProxyAddr string
+
+	// HTTPOutproxy is the .i2p host:port the HTTP proxy sends clearnet
+	// requests through; when empty they are rejected.
+	HTTPOutproxy string
+
+	// BOBAddr serves a BOB listener for legacy applications when set.
+	BOBAddr string
+
+	// LookupCache sizes the NAMING LOOKUP cache of router lookups.
+	LookupCache i2cp.LookupCacheConfig
+
+	// I2CPTLS connects to the router over TLS when set.
+	I2CPTLS *embedding.I2CPTLSConfig
+
+	// I2CPLazy starts serving before the router is reachable and
+	// connects to it in the background.
+	I2CPLazy bool
+
+	// I2CPUnresponsiveTimeout is how long the router may leave a request
+	// unanswered before the bridge warns and reports itself unhealthy.
+	I2CPUnresponsiveTimeout time.Duration
+
+	// I2CPFailoverAddrs holds -i2cp-failover values: routers to fail
+	// over to when I2CPAddr is unreachable or drops.
+	I2CPFailoverAddrs []string
+
+	// KeyStoreDir holds the encrypted keys of SESSION CREATE
+	// DESTINATION=file:. The passphrase comes from the environment.
+	KeyStoreDir string
+
+	// ExtraListenAddrs holds -listen values after the first, which is
+	// ListenAddr. TLSListenAddrs, ProxyListenAddrs and UnixSockets hold
+	// -listen-tls, -listen-proxy and -unix values.
+	ExtraListenAddrs []string
+	TLSListenAddrs   []string
+	ProxyListenAddrs []string
+	UnixSockets      []string
+
+	// Activation holds sockets passed by systemd socket activation, if
+	// any. It is set once at startup and carried over on reload.
+	Activation *socketActivation
+
+	// ShutdownTimeout bounds how long shutdown drains open connections
+	// before force-closing them. Zero closes them immediately.
+	ShutdownTimeout time.Duration
+
+	// IdleTimeout closes connections without a session or commands for
+	// this long.
+	IdleTimeout time.Duration
+
+	// SessionIdleTimeout closes sessions without traffic for this long.
+	SessionIdleTimeout time.Duration
+
+	// MaxSessions and MaxSessionsPerUser limit open sessions in total and
+	// per authenticated user (0 = no limit).
+	MaxSessions        int
+	MaxSessionsPerUser int
+
+	// MaxOutboundStreams and MaxInboundStreams limit concurrent streams
+	// per session in each direction.
+	MaxOutboundStreams int
+	MaxInboundStreams  int
+
+	// SendQueueSize, SendRetries and SendExpiry configure the per-session
+	// queue that retries datagram sends while tunnels rebuild.
+	SendQueueSize int
+	SendRetries   int
+	SendExpiry    time.Duration
+
+	// MaxConnections and MaxQueuedConnections limit concurrently handled
+	// client connections and those waiting for a slot (0 = no limit).
+	MaxConnections       int
+	MaxQueuedConnections int
+
+	// MaxConnectionsPerIP limits concurrent connections from one IP.
+	MaxConnectionsPerIP int
+
+	// Socket holds TCP options for client and forwarded connections.
+	Socket util.SocketOptions
+
+	// ForwardRetry controls retrying STREAM FORWARD targets that are down.
+	ForwardRetry handler.ForwardRetry
+
+	// TLSPolicy holds the -tls-min-version, -tls-cipher-suites and
+	// -tls-alpn settings for the SAM control socket.
+	TLSPolicy embedding.TLSPolicy
+
+	// DestinationPoolSize is the number of destinations generated ahead
+	// of time (0 = none).
+	DestinationPoolSize int
+
+	// LogSampleBurst and LogSampleInterval limit hot-path log records;
+	// see embedding.WithLogSampling.
+	LogSampleBurst    int
+	LogSampleInterval time.Duration
+
+	// AcceptBacklog is the number of inbound streams held per session
+	// for STREAM ACCEPT, each for up to AcceptBacklogTimeout.
+	AcceptBacklog        int
+	AcceptBacklogTimeout time.Duration
+
+	// StreamReadTimeout and StreamWriteTimeout close streams whose peer
+	// is silent, or whose writes stall, for this long.
+	StreamReadTimeout  time.Duration
+	StreamWriteTimeout time.Duration
+
+	// StrictQuoting enforces SAM 3.2 quoting rules for 3.2 clients.
+	StrictQuoting bool
+
+	// Trace writes every SAM line sent and received to stderr.
+	Trace bool
+
+	// LogFile, if set, receives log output instead of stdout and is
+	// rotated according to LogRotation.
+	LogFile     string
+	LogRotation util.RotationPolicy
+
+	// FileOptions holds embedding options loaded from ConfigFile.
+	FileOptions []embedding.Option
+
+	// EnvOptions holds embedding options loaded from environment variables.
+	EnvOptions []embedding.Option
+}
+
+// parseServeFlags parses the serve subcommand's flags, then applies the
+// config file and environment variables.
+func parseServeFlags(args []string) (*Config, error) {
+	cfg := &Config{}
+
+	fs := flag.NewFlagSet("serve", flag.ContinueOnError)
+	listen := &listFlag{values: []string{embedding.DefaultListenAddr}}
+	listenTLS := &listFlag{}
+	listenProxy := &listFlag{}
+	unixSockets := &listFlag{}
+	fs.Var(listen, "listen", "SAM listen `address` (repeatable)")
+	fs.Var(listenTLS, "listen-tls", "SAM TLS listen `address` (repeatable; needs a TLS certificate)")
+	fs.Var(listenProxy, "listen-proxy", "SAM listen `address` behind a load balancer sending PROXY protocol headers (repeatable)")
+	fs.Var(unixSockets, "unix", "SAM Unix socket `path` (repeatable)")
+	fs.StringVar(&cfg.I2CPAddr, "i2cp", "127.0.0.1:7654", "I2CP router address")
+	i2cpFailover := &listFlag{}
+	fs.Var(i2cpFailover, "i2cp-failover", "I2CP router `address` to fail over to (repeatable)")
+	var i2cpTLS i2cpTLSFlags
+	i2cpTLS.register(fs)
+	var tlsPolicy tlsPolicyFlags
+	tlsPolicy.register(fs)
+	fs.IntVar(&cfg.LookupCache.Size, "lookup-cache-size", i2cp.DefaultLookupCacheSize, "Router lookups to cache for NAMING LOOKUP (0 disables)")
+	fs.DurationVar(&cfg.LookupCache.TTL, "lookup-cache-ttl", i2cp.DefaultLookupCacheTTL, "How long to cache router lookups")
+	fs.IntVar(&cfg.LookupCache.NegativeSize, "lookup-negative-cache-size", i2cp.DefaultNegativeLookupCacheSize, "Names not found to cache (0 disables)")
+	fs.DurationVar(&cfg.LookupCache.NegativeTTL, "lookup-negative-cache-ttl", i2cp.DefaultNegativeLookupCacheTTL, "How long to cache names not found")
+	fs.BoolVar(&cfg.I2CPLazy, "i2cp-lazy", false, "Start serving before the I2P router is up and connect in the background")
+	fs.DurationVar(&cfg.I2CPUnresponsiveTimeout, "i2cp-unresponsive-timeout", i2cp.DefaultUnresponsiveTimeout, "Warn when the I2P router leaves a request unanswered this long")
+	fs.StringVar(&cfg.UDPAddr, "udp", ":7655", "UDP datagram port")
+	fs.BoolVar(&cfg.Debug, "debug", false, "Enable debug logging")
+	fs.StringVar(&cfg.LogFormat, "log-format", embedding.LogFormatText, "Log format: text or json")
+	fs.IntVar(&cfg.LogSampleBurst, "log-sample-burst", 0, "Log at most this many repeated hot-path records per interval (0 logs all)")
+	fs.DurationVar(&cfg.LogSampleInterval, "log-sample-interval", 0, "Log sampling interval (0 = 1s)")
+	fs.StringVar(&cfg.Username, "user", "", "I2CP username (optional)")
+	fs.StringVar(&cfg.Password, "pass", "", "I2CP password (optional)")
+	fs.StringVar(&cfg.ConfigFile, "config", "", "Configuration file (YAML, TOML, or JSON)")
+	fs.StringVar(&cfg.AuditLog, "audit-log", "", "Append command audit records to this file")
+	fs.StringVar(&cfg.AccessLog, "access-log", "", "Append connection access records to this file")
+	fs.StringVar(&cfg.AdminAddr, "admin", "", "Serve the JSON admin API on this address (e.g. 127.0.0.1:7657)")
+	fs.StringVar(&cfg.MetricsAddr, "metrics-addr", "", "Serve Prometheus metrics at /metrics on this address (e.g. 127.0.0.1:9100)")
+	fs.StringVar(&cfg.PprofAddr, "pprof", "", "Serve net/http/pprof at /debug/pprof/ on this loopback address (e.g. 127.0.0.1:6060)")
+	fs.StringVar(&cfg.I2PControlAddr, "i2pcontrol", "", "Serve the I2PControl JSON-RPC API on this address (e.g. 127.0.0.1:7650)")
+	fs.StringVar(&cfg.SOCKSAddr, "socks", "", "Serve a SOCKS5 proxy to .i2p hosts on this address (e.g. 127.0.0.1:4447)")
+	fs.StringVar(&cfg.HTTPProxyAddr, "http-proxy", "", "Serve an HTTP proxy to .i2p hosts on this address (e.g. 127.0.0.1:4444)")
+	fs.StringVar(&cfg.HTTPOutproxy, "http-outproxy", "", "Send the HTTP proxy's clearnet requests through this .i2p `host:port` (rejected when empty)")
+	fs.StringVar(&cfg.BOBAddr, "bob", "", "Serve a BOB listener for legacy applications on this address (e.g. 127.0.0.1:2827)")
+	fs.StringVar(&cfg.PIDFile, "pidfile", "", "Write the process ID to this file while running")
+	fs.StringVar(&cfg.KeyStoreDir, "keystore", "", "Serve DESTINATION=file:NAME from encrypted keys in this `directory` (passphrase from SAM_KEYSTORE_PASSPHRASE)")
+	fs.DurationVar(&cfg.ShutdownTimeout, "shutdown-timeout", 0, "Drain open connections for up to this long on shutdown, e.g. 30s (0 closes them immediately)")
+	fs.DurationVar(&cfg.IdleTimeout, "idle-timeout", 0, "Close connections that send no command and create no session for this long after HELLO (0 disables)")
+	fs.DurationVar(&cfg.SessionIdleTimeout, "session-idle-timeout", 0, "Close sessions with no traffic for this long, e.g. 30m (0 keeps them open)")
+	fs.IntVar(&cfg.MaxSessions, "max-sessions", 0, "Maximum open sessions (0 = no limit)")
+	fs.IntVar(&cfg.MaxSessionsPerUser, "max-sessions-per-user", 0, "Maximum open sessions per authenticated user (0 = no limit)")
+	fs.IntVar(&cfg.MaxOutboundStreams, "max-outbound-streams", 0, "Maximum concurrent STREAM CONNECTs per session (0 = no limit)")
+	fs.IntVar(&cfg.MaxInboundStreams, "max-inbound-streams", 0, "Maximum concurrent inbound streams per session (0 = no limit)")
+	fs.IntVar(&cfg.SendQueueSize, "send-queue", 0, "Datagrams queued per session for retry while tunnels rebuild (0 sends synchronously)")
+	fs.IntVar(&cfg.SendRetries, "send-retries", 0, "Retries per queued datagram after a transient I2CP error (0 = 3)")
+	fs.DurationVar(&cfg.SendExpiry, "send-expiry", 0, "Drop queued datagrams not sent within this long (0 = 10s)")
+	fs.IntVar(&cfg.MaxConnections, "max-connections", 0, "Maximum concurrently handled client connections (0 = no limit)")
+	fs.IntVar(&cfg.MaxQueuedConnections, "max-queued-connections", 0, "Connections that may wait for a free slot when -max-connections are open")
+	fs.IntVar(&cfg.MaxConnectionsPerIP, "max-connections-per-ip", 0, "Maximum concurrent client connections from one IP address (0 = no limit)")
+	fs.DurationVar(&cfg.Socket.KeepAlive, "tcp-keepalive", 0, "TCP keepalive period for client and STREAM FORWARD connections (0 = Go default of 15s, negative disables)")
+	fs.BoolVar(&cfg.Socket.DisableNoDelay, "tcp-no-nodelay", false, "Clear TCP_NODELAY on client and STREAM FORWARD connections")
+	fs.IntVar(&cfg.Socket.ReadBuffer, "socket-read-buffer", 0, "SO_RCVBUF size in bytes for client and STREAM FORWARD connections (0 = OS default)")
+	fs.IntVar(&cfg.Socket.WriteBuffer, "socket-write-buffer", 0, "SO_SNDBUF size in bytes for client and STREAM FORWARD connections (0 = OS default)")
+	fs.IntVar(&cfg.ForwardRetry.Attempts, "forward-attempts", 0, "Connection attempts per inbound stream to a STREAM FORWARD target (0 = 5)")
+	fs.DurationVar(&cfg.ForwardRetry.Backoff, "forward-backoff", 0, "Wait before retrying a STREAM FORWARD target, doubling per attempt (0 = 250ms)")
+	fs.DurationVar(&cfg.ForwardRetry.MaxBackoff, "forward-max-backoff", 0, "Longest wait between STREAM FORWARD attempts (0 = 2s)")
+	fs.IntVar(&cfg.DestinationPoolSize, "dest-pool-size", 0, "Destinations to generate ahead of time for DEST GENERATE and TRANSIENT sessions (0 disables)")
+	fs.IntVar(&cfg.AcceptBacklog, "accept-backlog", 0, "Inbound streams each STREAM session holds for the next STREAM ACCEPT (0 disables)")
+	fs.DurationVar(&cfg.AcceptBacklogTimeout, "accept-backlog-timeout", 0, "How long a stream waits in the accept backlog (0 = 10s)")
+	fs.DurationVar(&cfg.StreamReadTimeout, "stream-read-timeout", 0, "Close streams whose peer sends nothing for this long (0 disables)")
+	fs.DurationVar(&cfg.StreamWriteTimeout, "stream-write-timeout", 0, "Close streams whose writes to the peer stall this long (0 disables)")
+	fs.BoolVar(&cfg.StrictQuoting, "strict-quoting", false, "Reject SAM 3.2 commands with quoting or escaping the specification does not allow")
+	fs.BoolVar(&cfg.Trace, "trace", false, "Write every SAM line sent and received to stderr, with secrets redacted")
+	fs.StringVar(&cfg.LogFile, "log-file", "", "Write logs to this file instead of stdout")
+	logMaxSize := fs.Int64("log-max-size", 100, "Rotate the log file after this many megabytes (0 disables)")
+	fs.DurationVar(&cfg.LogRotation.MaxAge, "log-max-age", 0, "Rotate the log file after this long, e.g. 24h (0 disables)")
+	fs.IntVar(&cfg.LogRotation.MaxBackups, "log-max-backups", 5, "Number of rotated log files to keep (0 keeps all)")
+	fs.Usage = func() { printServeUsage(fs) }
+
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+	cfg.LogRotation.MaxSize = *logMaxSize << 20
+	if len(listen.values) == 0 {
+		return nil, errors.New("-listen requires an address")
+	}
+	cfg.ListenAddr = listen.values[0]
+	cfg.ExtraListenAddrs = listen.values[1:]
+	cfg.TLSListenAddrs = listenTLS.values
+	cfg.ProxyListenAddrs = listenProxy.values
+	cfg.UnixSockets = unixSockets.values
+	cfg.I2CPFailoverAddrs = i2cpFailover.values
+	var err error
+	if cfg.I2CPTLS, err = i2cpTLS.tlsConfig(); err != nil {
+		return nil, err
+	}
+	if cfg.TLSPolicy, err = tlsPolicy.policy(); err != nil {
+		return nil, err
+	}
+
+	// Apply config file values not overridden by explicit flags
+	if cfg.ConfigFile != "" {
+		if err := applyConfigFile(cfg, fs); err != nil {
+			return nil, err
+		}
+	}
+
+	// Override with environment variables
+	if err := applyEnv(cfg); err != nil {
+		return nil, err
+	}
+
+	if len(cfg.TLSListenAddrs) > 0 && !tlsConfigured(cfg) {
+		return nil, errListenTLSWithoutCert
+	}
+
+	return cfg, nil
+}
+
+// printServeUsage prints help for the serve subcommand.
+func printServeUsage(fs *flag.FlagSet) {
+	out := fs.Output()
+	fmt.Fprintln(out, "Usage: sam-bridge [serve] [flags]")
+	fmt.Fprintln(out)
+	fmt.Fprintln(out, "Run the SAM bridge server.")
+	fmt.Fprintln(out)
+	fmt.Fprintln(out, "Flags:")
+	fs.PrintDefaults()
+	fmt.Fprintln(out)
+	fmt.Fprintln(out, "Environment variables:")
+	fmt.Fprintln(out, "  SAM_LISTEN             SAM listen address (overrides the first -listen)")
+	fmt.Fprintln(out, "  SAM_DATAGRAM_PORT      UDP datagram port (overrides -udp)")
+	fmt.Fprintln(out, "  SAM_DEBUG              Enable debug logging (overrides -debug)")
+	fmt.Fprintln(out, "  SAM_LOG_FORMAT         Log format: text or json (overrides -log-format)")
+	fmt.Fprintln(out, "  SAM_LOG_SAMPLE_BURST   Hot-path log records per interval (overrides -log-sample-burst)")
+	fmt.Fprintln(out, "  SAM_LOG_SAMPLE_INTERVAL  Log sampling interval (overrides -log-sample-interval)")
+	fmt.Fprintln(out, "  I2CP_ADDR              I2CP router address (overrides -i2cp)")
+	fmt.Fprintln(out, "  I2CP_USER              I2CP username (overrides -user)")
+	fmt.Fprintln(out, "  I2CP_PASSWORD          I2CP password (overrides -pass)")
+	fmt.Fprintln(out, "  I2CP_FAILOVER_ADDRS    Comma-separated failover routers (overrides -i2cp-failover)")
+	fmt.Fprintln(out, "  I2CP_TLS               Connect to the router over TLS (overrides -i2cp-tls)")
+	fmt.Fprintln(out, "  I2CP_TLS_CA, I2CP_TLS_CERT, I2CP_TLS_KEY, I2CP_TLS_INSECURE")
+	fmt.Fprintln(out, "                         I2CP TLS settings, used with I2CP_TLS")
+	fmt.Fprintln(out, "  SAM_AUTH_USERS         SAM users as user:pass,user:pass")
+	fmt.Fprintln(out, "  SAM_HANDSHAKE_TIMEOUT  HELLO timeout (e.g. 30s)")
+	fmt.Fprintln(out, "  SAM_COMMAND_TIMEOUT    Timeout between commands (e.g. 60s)")
+	fmt.Fprintln(out, "  SAM_DRAIN_TIMEOUT      Drain timeout on shutdown (overrides -shutdown-timeout)")
+	fmt.Fprintln(out, "  SAM_IDLE_TIMEOUT       Timeout for connections without a session (overrides -idle-timeout)")
+	fmt.Fprintln(out, "  SAM_READ_BUFFER_SIZE   Command read buffer size")
+	fmt.Fprintln(out, "  SAM_SESSION_IDLE_TIMEOUT  Idle session timeout (overrides -session-idle-timeout)")
+	fmt.Fprintln(out, "  SAM_STREAM_READ_TIMEOUT   Stream read inactivity timeout (overrides -stream-read-timeout)")
+	fmt.Fprintln(out, "  SAM_STREAM_WRITE_TIMEOUT  Stream write stall timeout (overrides -stream-write-timeout)")
+	fmt.Fprintln(out, "  SAM_MAX_LINE_LENGTH    Maximum command line length")
+	fmt.Fprintln(out, "  SAM_MAX_SESSIONS       Maximum open sessions (overrides -max-sessions)")
+	fmt.Fprintln(out, "  SAM_MAX_SESSIONS_PER_USER  Maximum sessions per user (overrides -max-sessions-per-user)")
+	fmt.Fprintln(out, "  SAM_MAX_OUTBOUND_STREAMS   Outbound streams per session (overrides -max-outbound-streams)")
+	fmt.Fprintln(out, "  SAM_MAX_INBOUND_STREAMS    Inbound streams per session (overrides -max-inbound-streams)")
+	fmt.Fprintln(out, "  SAM_SEND_QUEUE         Datagram send queue per session (overrides -send-queue)")
+	fmt.Fprintln(out, "  SAM_SEND_RETRIES       Retries per queued datagram (overrides -send-retries)")
+	fmt.Fprintln(out, "  SAM_SEND_EXPIRY        Queued datagram expiry (overrides -send-expiry)")
+	fmt.Fprintln(out, "  SAM_STREAM_BUFFER_SIZE  Stream copy buffer size per direction")
+	fmt.Fprintln(out, "  SAM_TLS_CERT           TLS certificate file")
+	fmt.Fprintln(out, "  SAM_TLS_KEY            TLS key file")
+	fmt.Fprintln(out, "  SAM_TLS_MIN_VERSION    Lowest accepted TLS version (overrides -tls-min-version)")
+	fmt.Fprintln(out, "  SAM_TLS_CIPHER_SUITES  Allowed cipher suites (overrides -tls-cipher-suites)")
+	fmt.Fprintln(out, "  SAM_TLS_ALPN           ALPN protocols (overrides -tls-alpn)")
+	fmt.Fprintln(out, "  SAM_AUDIT_LOG          Command audit log file (overrides -audit-log)")
+	fmt.Fprintln(out, "  SAM_ACCESS_LOG         Connection access log file (overrides -access-log)")
+	fmt.Fprintln(out, "  SAM_ADMIN_ADDR         Admin API address (overrides -admin)")
+	fmt.Fprintln(out, "  SAM_METRICS_ADDR       Metrics address (overrides -metrics-addr)")
+	fmt.Fprintln(out, "  SAM_PPROF_ADDR         pprof address (overrides -pprof)")
+	fmt.Fprintln(out, "  SAM_I2PCONTROL_ADDR    I2PControl API address (overrides -i2pcontrol)")
+	fmt.Fprintln(out, "  SAM_I2PCONTROL_PASSWORD  I2PControl API password (default itoopie, loopback only)")
+	fmt.Fprintln(out, "  SAM_SOCKS_ADDR         SOCKS5 proxy address (overrides -socks)")
+	fmt.Fprintln(out, "  SAM_SOCKS_USER         SAM username for the SOCKS5 proxy")
+	fmt.Fprintln(out, "  SAM_SOCKS_PASSWORD     SAM password for the SOCKS5 proxy")
+	fmt.Fprintln(out, "  SAM_HTTP_PROXY_ADDR    HTTP proxy address (overrides -http-proxy)")
+	fmt.Fprintln(out, "  SAM_HTTP_PROXY_USER    SAM username for the HTTP proxy")
+	fmt.Fprintln(out, "  SAM_HTTP_PROXY_PASSWORD  SAM password for the HTTP proxy")
+	fmt.Fprintln(out, "  SAM_HTTP_OUTPROXY      HTTP outproxy for clearnet hosts (overrides -http-outproxy)")
+	fmt.Fprintln(out, "  SAM_BOB_ADDR           BOB listener address (overrides -bob)")
+	fmt.Fprintln(out, "  SAM_BOB_USER           SAM username for the BOB listener")
+	fmt.Fprintln(out, "  SAM_BOB_PASSWORD       SAM password for the BOB listener")
+	fmt.Fprintln(out, "  SAM_KEYSTORE_DIR       Key store directory (overrides -keystore)")
+	fmt.Fprintln(out, "  SAM_KEYSTORE_PASSPHRASE  Passphrase encrypting the key store")
+	fmt.Fprintln(out)
+	fmt.Fprintln(out, "Multiple endpoints:")
+	fmt.Fprintln(out, "  -listen, -listen-tls, -listen-proxy and -unix may each be repeated. With -listen-tls,")
+	fmt.Fprintln(out, "  -listen addresses serve plain SAM; without it, a configured TLS")
+	fmt.Fprintln(out, "  certificate applies to the first -listen address. -listen-proxy addresses")
+	fmt.Fprintln(out, "  serve plain SAM to a load balancer that sends PROXY protocol v1 or v2")
+	fmt.Fprintln(out, "  headers, and see the client addresses from those headers.")
+	fmt.Fprintln(out)
+	fmt.Fprintln(out, "Router failover:")
+	fmt.Fprintln(out, "  With -i2cp-failover, startup tries each router in turn, and a dropped")
+	fmt.Fprintln(out, "  connection moves to the next one. Open SAM sessions are re-created on")
+	fmt.Fprintln(out, "  the new router under the same IDs.")
+	fmt.Fprintln(out)
+	fmt.Fprintln(out, "Starting before the router:")
+	fmt.Fprintln(out, "  With -i2cp-lazy, the SAM listener starts at once and the router is")
+	fmt.Fprintln(out, "  retried in the background. Until it connects, SESSION CREATE answers")
+	fmt.Fprintln(out, "  I2P_ERROR.")
+	fmt.Fprintln(out)
+	fmt.Fprintln(out, "Running in the background:")
+	fmt.Fprintln(out, "  serve always runs in the foreground. Run it under a supervisor such as")
+	fmt.Fprintln(out, "  systemd, runit, or a Windows service to detach; -pidfile records the")
+	fmt.Fprintln(out, "  process ID and is removed on graceful shutdown.")
+	fmt.Fprintln(out)
+	fmt.Fprintln(out, "systemd:")
+	fmt.Fprintln(out, "  With Type=notify the server reports READY=1 once serving, and pings")
+	fmt.Fprintln(out, "  the watchdog while healthy when WatchdogSec= is set. Under socket")
+	fmt.Fprintln(out, "  activation, ListenStream= sockets replace -listen, -listen-tls and")
+	fmt.Fprintln(out, "  -unix, and a ListenDatagram= socket replaces -udp.")
+	fmt.Fprintln(out)
+	fmt.Fprintln(out, "Signals:")
+	fmt.Fprintln(out, "  SIGHUP                 Reload config file: auth users, log level and format, TLS")
+}
+
+// applyConfigFile loads cfg.ConfigFile into cfg.FileOptions and copies the
+// settings main needs before the bridge exists (listen, I2CP, UDP, debug)
+// into cfg, unless the corresponding flag was set on the command line.
+func applyConfigFile(cfg *Config, fs *flag.FlagSet) error {
+	opts, err := embedding.ConfigFromFile(cfg.ConfigFile)
+	if err != nil {
+		return err
+	}
+	cfg.FileOptions = opts
+
+	fileCfg := embedding.DefaultConfig()
+	for _, opt := range opts {
+		opt(fileCfg)
+	}
+
+	set := make(map[string]bool)
+	fs.Visit(func(f *flag.Flag) { set[f.Name] = true })
+
+	if !set["listen"] {
+		cfg.ListenAddr = fileCfg.ListenAddr
+	}
+	if !set["i2cp"] {
+		cfg.I2CPAddr = fileCfg.I2CPAddr
+	}
+	if !set["udp"] {
+		cfg.UDPAddr = withPort(cfg.UDPAddr, fileCfg.DatagramPort)
+	}
+	if !set["user"] && fileCfg.I2CPUsername != "" {
+		cfg.Username = fileCfg.I2CPUsername
+	}
+	if !set["pass"] && fileCfg.I2CPPassword != "" {
+		cfg.Password = fileCfg.I2CPPassword
+	}
+	if !set["i2cp-tls"] && fileCfg.I2CPTLS != nil {
+		cfg.I2CPTLS = fileCfg.I2CPTLS
+	}
+	if !set["i2cp-failover"] && len(fileCfg.I2CPFailoverAddrs) > 0 {
+		cfg.I2CPFailoverAddrs = fileCfg.I2CPFailoverAddrs
+	}
+	if !set["debug"] {
+		cfg.Debug = fileCfg.Debug
+	}
+	if !set["log-format"] && fileCfg.LogFormat != "" {
+		cfg.LogFormat = fileCfg.LogFormat
+	}
+	return nil
+}
+
+// applyEnv loads environment variable options into cfg.EnvOptions and lets
+// any that are set override the settings main needs before the bridge exists.
+func applyEnv(cfg *Config) error {
+	opts, err := embedding.ConfigFromEnv()
+	if err != nil {
+		return err
+	}
+	cfg.EnvOptions = opts
+
+	envCfg := &embedding.Config{
+		ListenAddr:   cfg.ListenAddr,
+		I2CPAddr:     cfg.I2CPAddr,
+		DatagramPort: parseDatagramPort(cfg.UDPAddr),
+		I2CPUsername: cfg.Username,
+		I2CPPassword: cfg.Password,
+		Debug:        cfg.Debug,
+		LogFormat:    cfg.LogFormat,
+
+		I2CPFailoverAddrs: cfg.I2CPFailoverAddrs,
+		I2CPTLS:           cfg.I2CPTLS,
+	}
+	for _, opt := range opts {
+		opt(envCfg)
+	}
+
+	cfg.ListenAddr = envCfg.ListenAddr
+	cfg.I2CPAddr = envCfg.I2CPAddr
+	cfg.UDPAddr = withPort(cfg.UDPAddr, envCfg.DatagramPort)
+	cfg.Username = envCfg.I2CPUsername
+	cfg.Password = envCfg.I2CPPassword
+	cfg.I2CPFailoverAddrs = envCfg.I2CPFailoverAddrs
+	cfg.I2CPTLS = envCfg.I2CPTLS
+	cfg.Debug = envCfg.Debug
+	cfg.LogFormat = envCfg.LogFormat
+	return nil
+}
+
+// i2cpRetryInterval is how often -i2cp-lazy retries the router.
+const i2cpRetryInterval = 5 * time.Second
+
+// newI2CPClient creates the I2CP client for cfg without connecting it.
+func newI2CPClient(cfg *Config, log *logrus.Logger) *i2cp.Client {
+	i2cpConfig := &i2cp.ClientConfig{
+		RouterAddr:          cfg.I2CPAddr,
+		Username:            cfg.Username,
+		Password:            cfg.Password,
+		FailoverAddrs:       cfg.I2CPFailoverAddrs,
+		UnresponsiveTimeout: cfg.I2CPUnresponsiveTimeout,
+	}
+
+	applyI2CPTLS(i2cpConfig, cfg.I2CPTLS)
+
+	client := i2cp.NewClient(i2cpConfig)
+	client.SetCallbacks(&i2cp.ClientCallbacks{
+		OnFailover: func(addr string) {
+			log.WithField("addr", addr).Warn("Failed over to I2P router")
+		},
+	})
+	return client
+}
+
+// connectI2CPInBackground connects client, retrying until it succeeds or
+// ctx is done.
+func connectI2CPInBackground(ctx context.Context, client *i2cp.Client, log *logrus.Logger) {
+	log.WithField("addr", client.RouterAddr()).Info("Connecting to I2P router in the background")
+	err := client.ConnectWithRetry(ctx, i2cpRetryInterval, func(err error) {
+		log.WithError(err).WithField("retry", i2cpRetryInterval).Warn("I2P router not reachable")
+	})
+	if err != nil {
+		return
+	}
+	logI2CPConnected(client, log)
+}
+
+// logI2CPConnected logs the router a client connected to.
+func logI2CPConnected(client *i2cp.Client, log *logrus.Logger) {
+	log.WithFields(logrus.Fields{
+		"addr":    client.RouterAddr(),
+		"version": client.RouterVersion(),
+	}).Info("Connected to I2P router")
+}
+
+func parseDatagramPort(addr string) int {
+	if addr == "" {
+		return embedding.DefaultDatagramPort
+	}
+	_, portStr, err := net.SplitHostPort(addr)
+	if err != nil {
+		portStr = addr
+	}
+	if port, err := strconv.Atoi(portStr); err == nil {
+		return port
+	}
+	return embedding.DefaultDatagramPort
+}
+
+// withPort returns addr with its port replaced by port, keeping any host.
+func withPort(addr string, port int) string {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		host = ""
+	}
+	return net.JoinHostPort(host, strconv.Itoa(port))
+}
+
+// createHandlerRegistrar returns a custom handler registrar with I2CP integration.
+// This extends the default registrar with I2CP-specific session callbacks.
+func createHandlerRegistrar(i2cpClient *i2cp.Client, lookupCache i2cp.LookupCacheConfig) embedding.HandlerRegistrarFunc {
+	return func(router *handler.Router, deps *embedding.Dependencies) {
+		log := deps.Logger
+
+		// Use default handler registrar for base handlers
+		embedding.DefaultHandlerRegistrar()(router, deps)
+
+		// Get the SESSION handler to add I2CP callback
+		// The default registrar already created it, we need to extend it
+		// For now, we re-register with the extended callback
+		streamConnector, streamAcceptor, streamForwarder := embedding.NewStreamHandlers(deps)
+
+		sessionHandler := handler.NewSessionHandler(deps.DestManager)
+		sessionHandler.SetI2CPProvider(deps.I2CPProvider)
+		sessionHandler.SetSessionDefaults(deps.SessionDefaults)
+		sessionHandler.SetKeyStore(deps.KeyStore)
+		sessionHandler.SetTunnelEventCallback(embedding.TunnelEventLogger(deps))
+
+		// Set session created callback for StreamManager wiring
+		sessionHandler.SetSessionCreatedCallback(func(sess session.Session, i2cpHandle session.I2CPSessionHandle) {
+			if (sess.Style() != session.StyleStream && sess.Style() != session.StylePrimary) || i2cpHandle == nil {
+				return
+			}
+
+			i2cpSess, ok := i2cpHandle.(*i2cp.I2CPSession)
+			if !ok {
+				log.WithField("sessionID", sess.ID()).Warn("Cannot create StreamManager: invalid I2CP session type")
+				return
+			}
+
+			underlyingSession := i2cpSess.Session()
+			underlyingClient := i2cpClient.I2CPClient()
+			if underlyingSession == nil || underlyingClient == nil {
+				log.WithField("sessionID", sess.ID()).Warn("Cannot create StreamManager: no underlying I2CP session/client")
+				return
+			}
+
+			streamManager, err := streaming.NewStreamManagerFromSession(underlyingClient, underlyingSession)
+			if err != nil {
+				log.WithField("sessionID", sess.ID()).WithError(err).Warn("Failed to create StreamManager from session")
+				return
+			}
+
+			adapter, err := samstreaming.NewAdapter(streamManager)
+			if err != nil {
+				log.WithField("sessionID", sess.ID()).WithError(err).Warn("Failed to create StreamManager adapter")
+				return
+			}
+
+			// Streaming messages received on the destination drive the manager
+			i2cpSess.OnStreamMessage(streamManager.GetSessionCallbacks().OnMessage)
+
+			// A PRIMARY session's streams are accepted through its STREAM
+			// subsessions, chosen by the routing table
+			if primary, ok := sess.(session.PrimarySession); ok {
+				if err := streamAcceptor.RegisterPrimary(primary, adapter); err != nil {
+					log.WithField("sessionID", sess.ID()).WithError(err).Warn("Failed to route PRIMARY session streams")
+				}
+				return
+			}
+
+			streamConnector.RegisterManager(sess.ID(), adapter)
+			streamAcceptor.RegisterManager(sess.ID(), adapter)
+			streamForwarder.RegisterManager(sess.ID(), adapter)
+
+			b32, _ := destination.Base32FromBase64(string(sess.Destination().PublicKey))
+			log.WithFields(logrus.Fields{
+				"sessionID": sess.ID(),
+				"label":     session.Label(sess),
+				"b32":       b32,
+			}).Debug("Registered StreamManager for session")
+		})
+
+		// Drop a session's StreamManager when it leaves the registry, so
+		// closed sessions do not keep their managers reachable.
+		if observable, ok := deps.Registry.(session.Observable); ok {
+			observable.AddObserver(session.ObserverFuncs{
+				Unregistered: func(sess session.Session) {
+					streamConnector.UnregisterManager(sess.ID())
+					streamAcceptor.UnregisterManager(sess.ID())
+					streamForwarder.UnregisterManager(sess.ID())
+				},
+			})
+		}
+
+		// Re-register SESSION handlers with extended callback
+		router.Register("SESSION CREATE", sessionHandler)
+		router.Register("SESSION ADD", sessionHandler)
+		router.Register("SESSION REMOVE", sessionHandler)
+		router.Register("SESSION STATS", sessionHandler)
+		router.Register("SESSION LIST", sessionHandler)
+
+		// Re-register STREAM handlers with new connectors
+		streamHandler := handler.NewStreamHandler(streamConnector, streamAcceptor, streamForwarder)
+		router.Register("STREAM CONNECT", streamHandler)
+		router.Register("STREAM ACCEPT", streamHandler)
+		router.Register("STREAM FORWARD", streamHandler)
+
+		// Re-register DUMP so it reports the new forwarder
+		dumpHandler := handler.NewDumpHandler()
+		dumpHandler.SetForwarder(streamForwarder)
+		dumpHandler.SetI2CPProvider(deps.I2CPProvider)
+		handler.RegisterDumpHandler(router, dumpHandler)
+
+		// Wire destination resolver for NAMING handler
+		destResolver, err := i2cp.NewClientDestinationResolverAdapter(i2cpClient, 30*time.Second)
+		if err == nil {
+			destResolver.SetLookupCache(lookupCache)
+			namingHandler := handler.NewNamingHandler(deps.DestManager)
+			namingHandler.SetDestinationResolver(destResolver)
+			router.Register("NAMING LOOKUP", namingHandler)
+			log.Debug("Wired destination resolver to NAMING handler")
+		}
+
+		log.Debug("Extended handlers with I2CP integration")
+	}
+}
+
+// Compile-time check that the I2CP provider reports disconnects to the bridge.
+var _ embedding.ErrorNotifier = (*i2cp.SessionProviderAdapter)(nil)
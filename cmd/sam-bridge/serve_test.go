@@ -0,0 +1,108 @@
+package main
+
+import (
+	"flag"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/go-i2p/go-sam-bridge/lib/destination"
+	"github.com/go-i2p/go-sam-bridge/lib/embedding"
+	"github.com/go-i2p/go-sam-bridge/lib/handler"
+	"github.com/go-i2p/go-sam-bridge/lib/i2cp"
+	"github.com/go-i2p/go-sam-bridge/lib/protocol"
+	"github.com/go-i2p/go-sam-bridge/lib/session"
+	"github.com/sirupsen/logrus"
+)
+
+func TestWithPort(t *testing.T) {
+	tests := []struct {
+		addr string
+		port int
+		want string
+	}{
+		{":7655", 7656, ":7656"},
+		{"127.0.0.1:7655", 7656, "127.0.0.1:7656"},
+		{"[::1]:7655", 7656, "[::1]:7656"},
+		{"7655", 7656, ":7656"},
+		{"", 7656, ":7656"},
+	}
+	for _, tt := range tests {
+		if got := withPort(tt.addr, tt.port); got != tt.want {
+			t.Errorf("withPort(%q, %d) = %q, want %q", tt.addr, tt.port, got, tt.want)
+		}
+	}
+}
+
+func TestApplyConfigFile_KeepsUDPHost(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bridge.json")
+	if err := os.WriteFile(path, []byte(`{"datagram_port": 7656}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	cfg := &Config{ConfigFile: path, UDPAddr: "127.0.0.1:7655"}
+	if err := applyConfigFile(cfg, flag.NewFlagSet("serve", flag.ContinueOnError)); err != nil {
+		t.Fatalf("applyConfigFile() error = %v", err)
+	}
+	if want := "127.0.0.1:7656"; cfg.UDPAddr != want {
+		t.Errorf("UDPAddr = %q, want %q", cfg.UDPAddr, want)
+	}
+}
+
+func TestApplyEnv_KeepsUDPHost(t *testing.T) {
+	t.Setenv("SAM_DATAGRAM_PORT", "7656")
+	cfg := &Config{UDPAddr: "127.0.0.1:7655"}
+	if err := applyEnv(cfg); err != nil {
+		t.Fatalf("applyEnv() error = %v", err)
+	}
+	if want := "127.0.0.1:7656"; cfg.UDPAddr != want {
+		t.Errorf("UDPAddr = %q, want %q", cfg.UDPAddr, want)
+	}
+}
+
+func TestCreateHandlerRegistrar_FileDestination(t *testing.T) {
+	// A pass-through KMS keeps the test fast; the encryption itself is
+	// covered in lib/destination.
+	kms := destination.KMSWrapper{
+		Encrypt: func(k []byte) ([]byte, error) { return k, nil },
+		Decrypt: func(k []byte) ([]byte, error) { return k, nil },
+	}
+	store := destination.NewKeyStore(t.TempDir(), kms)
+	registry := session.NewRegistry()
+	deps := &embedding.Dependencies{
+		Registry:    registry,
+		DestManager: destination.NewManager(),
+		Logger:      logrus.New(),
+		KeyStore:    store,
+	}
+	deps.Logger.SetOutput(io.Discard)
+
+	router := handler.NewRouter()
+	createHandlerRegistrar(i2cp.NewClient(nil), i2cp.DefaultLookupCacheConfig())(router, deps)
+
+	client, server := net.Pipe()
+	defer client.Close()
+	defer server.Close()
+	ctx := handler.NewContext(server, registry)
+	ctx.HandshakeComplete = true
+	resp, err := router.Handle(ctx, &protocol.Command{
+		Verb:   "SESSION",
+		Action: "CREATE",
+		Options: map[string]string{
+			"STYLE":       "STREAM",
+			"ID":          "site",
+			"DESTINATION": "file:site",
+		},
+	})
+	if err != nil {
+		t.Fatalf("Handle() error = %v", err)
+	}
+	if !strings.Contains(resp.String(), "RESULT="+protocol.ResultOK) {
+		t.Fatalf("SESSION CREATE DESTINATION=file:site = %q, want RESULT=OK", resp.String())
+	}
+	if _, err := store.Load("site"); err != nil {
+		t.Errorf("store.Load() error = %v, want the generated key stored", err)
+	}
+}
@@ -0,0 +1,11 @@
+//go:build !windows
+
+package main
+
+import "errors"
+
+// runService reports that Windows service management is unavailable.
+// On other platforms, run serve under the system's service manager.
+func runService(args []string) error {
+	return errors.New("Windows services are only supported on Windows; use serve under your init system")
+}
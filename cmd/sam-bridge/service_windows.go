@@ -0,0 +1,154 @@
+//go:build windows
+
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/go-i2p/go-sam-bridge/lib/embedding"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/sys/windows/svc"
+	"golang.org/x/sys/windows/svc/mgr"
+)
+
+// defaultServiceName is the Windows service name used unless -name is given.
+const defaultServiceName = "sam-bridge"
+
+// runService manages the bridge as a native Windows service.
+//
+//	sam-bridge service install [-name NAME] [serve flags]
+//	sam-bridge service uninstall [-name NAME]
+//	sam-bridge service run [-name NAME] [serve flags]
+//
+// install registers the current executable to start automatically with
+// "service run" and the given serve flags. run is invoked by the service
+// control manager and should not be called by hand.
+func runService(args []string) error {
+	fs := flag.NewFlagSet("service", flag.ContinueOnError)
+	name := fs.String("name", defaultServiceName, "Windows service name")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: sam-bridge service install|uninstall|run [-name NAME] [serve flags]")
+		fmt.Fprintln(fs.Output())
+		fmt.Fprintln(fs.Output(), "Manage the SAM bridge as a Windows service. Serve flags given to")
+		fmt.Fprintln(fs.Output(), "install are passed to the service each time it starts.")
+		fmt.Fprintln(fs.Output())
+		fmt.Fprintln(fs.Output(), "Flags:")
+		fs.PrintDefaults()
+	}
+	if len(args) == 0 {
+		fs.Usage()
+		return errors.New("expected install, uninstall, or run")
+	}
+	action := args[0]
+	if err := fs.Parse(args[1:]); err != nil {
+		return err
+	}
+	serveArgs := fs.Args()
+
+	switch action {
+	case "install":
+		return installService(*name, serveArgs)
+	case "uninstall":
+		return uninstallService(*name)
+	case "run":
+		return svc.Run(*name, &windowsService{args: serveArgs})
+	default:
+		fs.Usage()
+		return fmt.Errorf("unknown service action %q", action)
+	}
+}
+
+// installService registers the running executable as an automatically
+// started service that runs serve with serveArgs.
+func installService(name string, serveArgs []string) error {
+	exe, err := os.Executable()
+	if err != nil {
+		return err
+	}
+	exe, err = filepath.Abs(exe)
+	if err != nil {
+		return err
+	}
+
+	m, err := mgr.Connect()
+	if err != nil {
+		return fmt.Errorf("connecting to service manager: %w", err)
+	}
+	defer m.Disconnect()
+
+	if s, err := m.OpenService(name); err == nil {
+		s.Close()
+		return fmt.Errorf("service %s already exists", name)
+	}
+
+	args := append([]string{"service", "run", "-name", name}, serveArgs...)
+	s, err := m.CreateService(name, exe, mgr.Config{
+		DisplayName: "SAM Bridge",
+		Description: "SAMv3.3 protocol bridge for I2P",
+		StartType:   mgr.StartAutomatic,
+	}, args...)
+	if err != nil {
+		return fmt.Errorf("creating service: %w", err)
+	}
+	defer s.Close()
+
+	fmt.Printf("Installed service %s\n", name)
+	return nil
+}
+
+// uninstallService removes the named service. A running service is
+// removed once it stops.
+func uninstallService(name string) error {
+	m, err := mgr.Connect()
+	if err != nil {
+		return fmt.Errorf("connecting to service manager: %w", err)
+	}
+	defer m.Disconnect()
+
+	s, err := m.OpenService(name)
+	if err != nil {
+		return fmt.Errorf("service %s is not installed", name)
+	}
+	defer s.Close()
+
+	if err := s.Delete(); err != nil {
+		return fmt.Errorf("deleting service: %w", err)
+	}
+	fmt.Printf("Removed service %s\n", name)
+	return nil
+}
+
+// windowsService adapts serve to the service control manager.
+type windowsService struct {
+	args []string
+}
+
+// Execute implements svc.Handler. It reports Running once the bridge is
+// serving and stops the bridge on Stop or Shutdown requests.
+func (s *windowsService) Execute(_ []string, requests <-chan svc.ChangeRequest, status chan<- svc.Status) (bool, uint32) {
+	const accepts = svc.AcceptStop | svc.AcceptShutdown
+
+	status <- svc.Status{State: svc.StartPending}
+	err := serve(s.args, func(_ *embedding.Bridge, log *logrus.Logger, _ func()) {
+		status <- svc.Status{State: svc.Running, Accepts: accepts}
+		for req := range requests {
+			switch req.Cmd {
+			case svc.Interrogate:
+				status <- req.CurrentStatus
+			case svc.Stop, svc.Shutdown:
+				status <- svc.Status{State: svc.StopPending}
+				return
+			default:
+				log.WithField("cmd", req.Cmd).Debug("Ignoring service control request")
+			}
+		}
+	})
+	if err != nil {
+		return false, 1
+	}
+	return false, 0
+}
@@ -0,0 +1,169 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/go-i2p/go-sam-bridge/lib/embedding"
+	"github.com/sirupsen/logrus"
+)
+
+// sdNotify sends state to the systemd notification socket named by
+// NOTIFY_SOCKET. It returns false without error when the daemon was not
+// started by systemd with Type=notify or NotifyAccess set.
+func sdNotify(state string) (bool, error) {
+	socket := os.Getenv("NOTIFY_SOCKET")
+	if socket == "" {
+		return false, nil
+	}
+
+	conn, err := net.DialUnix("unixgram", nil, &net.UnixAddr{Name: socket, Net: "unixgram"})
+	if err != nil {
+		return false, err
+	}
+	defer conn.Close()
+
+	if _, err := conn.Write([]byte(state)); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// sdWatchdogInterval returns the watchdog timeout systemd configured for
+// this process via WATCHDOG_USEC, or 0 if the watchdog is disabled.
+func sdWatchdogInterval() time.Duration {
+	usec, err := strconv.ParseInt(os.Getenv("WATCHDOG_USEC"), 10, 64)
+	if err != nil || usec <= 0 {
+		return 0
+	}
+	// WATCHDOG_PID, when set, names the process the watchdog applies to.
+	if pid := os.Getenv("WATCHDOG_PID"); pid != "" && pid != strconv.Itoa(os.Getpid()) {
+		return 0
+	}
+	return time.Duration(usec) * time.Microsecond
+}
+
+// runWatchdog pets the systemd watchdog at half its timeout while the
+// bridge reports healthy, until ctx is cancelled. Missing pings make
+// systemd restart the service according to its Restart= policy.
+func runWatchdog(ctx context.Context, bridge *embedding.Bridge, log *logrus.Logger) {
+	timeout := sdWatchdogInterval()
+	if timeout == 0 {
+		return
+	}
+	log.WithField("timeout", timeout).Debug("systemd watchdog enabled")
+
+	ticker := time.NewTicker(timeout / 2)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := bridge.Health(); err != nil {
+				log.WithError(err).Warn("Bridge unhealthy; skipping systemd watchdog ping")
+				continue
+			}
+			if _, err := sdNotify("WATCHDOG=1"); err != nil {
+				log.WithError(err).Warn("Failed to ping systemd watchdog")
+			}
+		}
+	}
+}
+
+// sdListenFDsStart is the first file descriptor passed by systemd socket
+// activation (SD_LISTEN_FDS_START).
+const sdListenFDsStart = 3
+
+// socketActivation holds the sockets systemd passed to the process.
+type socketActivation struct {
+	listeners  []net.Listener
+	packetConn net.PacketConn
+}
+
+// sdListenSockets returns the sockets passed by systemd socket activation
+// through LISTEN_FDS and LISTEN_PID, or nil if the process was not
+// socket-activated. Stream sockets become listeners in the order of the
+// unit's ListenStream= lines; at most one datagram socket, for the SAM
+// datagram port, is accepted. The variables are unset so that child
+// processes do not inherit them.
+func sdListenSockets() (*socketActivation, error) {
+	defer func() {
+		os.Unsetenv("LISTEN_PID")
+		os.Unsetenv("LISTEN_FDS")
+		os.Unsetenv("LISTEN_FDNAMES")
+	}()
+
+	pid, err := strconv.Atoi(os.Getenv("LISTEN_PID"))
+	if err != nil || pid != os.Getpid() {
+		return nil, nil
+	}
+	n, err := strconv.Atoi(os.Getenv("LISTEN_FDS"))
+	if err != nil || n <= 0 {
+		return nil, nil
+	}
+
+	act := &socketActivation{}
+	for fd := sdListenFDsStart; fd < sdListenFDsStart+n; fd++ {
+		if err := act.add(fd); err != nil {
+			act.close()
+			return nil, fmt.Errorf("socket activation: fd %d: %w", fd, err)
+		}
+	}
+	return act, nil
+}
+
+// add takes ownership of fd as a listener or datagram socket.
+func (a *socketActivation) add(fd int) error {
+	f := os.NewFile(uintptr(fd), "LISTEN_FD_"+strconv.Itoa(fd))
+	// net.FileListener and net.FilePacketConn duplicate the descriptor
+	defer f.Close()
+
+	if l, err := net.FileListener(f); err == nil {
+		a.listeners = append(a.listeners, l)
+		return nil
+	}
+	pc, err := net.FilePacketConn(f)
+	if err != nil {
+		return fmt.Errorf("not a stream or datagram socket: %w", err)
+	}
+	if a.packetConn != nil {
+		pc.Close()
+		return fmt.Errorf("more than one datagram socket")
+	}
+	a.packetConn = pc
+	return nil
+}
+
+// options maps the activated sockets onto the bridge. The first stream
+// socket replaces -listen, further ones are served as extra endpoints, and
+// the datagram socket replaces -udp. Settings for sockets systemd did not
+// pass keep their self-binding defaults.
+func (a *socketActivation) options() []embedding.Option {
+	var opts []embedding.Option
+	if len(a.listeners) > 0 {
+		opts = append(opts, embedding.WithListener(a.listeners[0]))
+		for _, l := range a.listeners[1:] {
+			opts = append(opts, embedding.WithEndpoints(embedding.Endpoint{Listener: l}))
+		}
+	}
+	if a.packetConn != nil {
+		opts = append(opts, embedding.WithDatagramPacketConn(a.packetConn))
+	}
+	return opts
+}
+
+// close releases all activated sockets.
+func (a *socketActivation) close() {
+	for _, l := range a.listeners {
+		l.Close()
+	}
+	if a.packetConn != nil {
+		a.packetConn.Close()
+	}
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"flag"
+	"strings"
+
+	"github.com/go-i2p/go-sam-bridge/lib/embedding"
+)
+
+// tlsPolicyFlags holds the flags setting the TLS policy of the SAM
+// control socket.
+type tlsPolicyFlags struct {
+	minVersion   string
+	cipherSuites string
+	alpn         string
+}
+
+// register adds the TLS policy flags to fs.
+func (f *tlsPolicyFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&f.minVersion, "tls-min-version", "", "Lowest accepted TLS `version` for SAM clients: 1.0, 1.1, 1.2 or 1.3 (default 1.2)")
+	fs.StringVar(&f.cipherSuites, "tls-cipher-suites", "", "Comma-separated cipher `suites` allowed for TLS 1.2 and earlier, e.g. TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384")
+	fs.StringVar(&f.alpn, "tls-alpn", "", "Comma-separated ALPN `protocols` offered to SAM clients")
+}
+
+// policy parses the flags.
+func (f *tlsPolicyFlags) policy() (embedding.TLSPolicy, error) {
+	var policy embedding.TLSPolicy
+	var err error
+	if policy.MinVersion, err = embedding.ParseTLSVersion(f.minVersion); err != nil {
+		return policy, err
+	}
+	if policy.CipherSuites, err = embedding.ParseCipherSuites(splitFlagList(f.cipherSuites)); err != nil {
+		return policy, err
+	}
+	policy.NextProtos = splitFlagList(f.alpn)
+	return policy, nil
+}
+
+// splitFlagList splits a comma-separated flag value, dropping empty
+// entries.
+func splitFlagList(s string) []string {
+	var list []string
+	for _, v := range strings.Split(s, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			list = append(list, v)
+		}
+	}
+	return list
+}
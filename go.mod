@@ -6,12 +6,17 @@ toolchain go1.24.12
 
 require (
 	github.com/go-i2p/common v0.1.2
+	github.com/go-i2p/crypto v0.1.3
 	github.com/go-i2p/go-datagrams v0.1.2
 	github.com/go-i2p/go-i2cp v0.1.2
 	github.com/go-i2p/go-i2p v0.1.2
 	github.com/go-i2p/go-streaming v0.1.2
 	github.com/hashicorp/golang-lru/v2 v2.0.7
+	github.com/pelletier/go-toml/v2 v2.2.4
 	github.com/sirupsen/logrus v1.9.4
+	go.yaml.in/yaml/v3 v3.0.4
+	golang.org/x/crypto v0.47.0
+	golang.org/x/sys v0.40.0
 )
 
 require (
@@ -22,7 +27,6 @@ require (
 	github.com/dchest/siphash v1.2.3 // indirect
 	github.com/eyedeekay/go-unzip v0.0.0-20240201194209-560d8225b50e // indirect
 	github.com/fsnotify/fsnotify v1.9.0 // indirect
-	github.com/go-i2p/crypto v0.1.3 // indirect
 	github.com/go-i2p/elgamal v0.0.2 // indirect
 	github.com/go-i2p/go-noise v0.1.2 // indirect
 	github.com/go-i2p/logger v0.1.2 // indirect
@@ -30,7 +34,6 @@ require (
 	github.com/go-i2p/su3 v0.0.1 // indirect
 	github.com/go-viper/mapstructure/v2 v2.5.0 // indirect
 	github.com/oklog/ulid/v2 v2.1.1 // indirect
-	github.com/pelletier/go-toml/v2 v2.2.4 // indirect
 	github.com/sagikazarmark/locafero v0.12.0 // indirect
 	github.com/samber/lo v1.52.0 // indirect
 	github.com/samber/oops v1.21.0 // indirect
@@ -42,10 +45,7 @@ require (
 	go.opentelemetry.io/otel v1.39.0 // indirect
 	go.opentelemetry.io/otel/trace v1.39.0 // indirect
 	go.step.sm/crypto v0.76.0 // indirect
-	go.yaml.in/yaml/v3 v3.0.4 // indirect
-	golang.org/x/crypto v0.47.0 // indirect
 	golang.org/x/net v0.49.0 // indirect
-	golang.org/x/sys v0.40.0 // indirect
 	golang.org/x/text v0.33.0 // indirect
 )
 
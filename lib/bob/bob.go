@@ -0,0 +1,611 @@
+// Package bob implements a listener for BOB, the "Basic Open Bridge"
+// protocol older I2P applications use to set up streaming tunnels. A BOB
+// client names a tunnel with setnick, gives it keys with newkeys or
+// setkeys, configures an inbound side (inhost/inport, a local port whose
+// connections are sent to the I2P destination they name on their first
+// line) and an outbound side (outhost/outport, where streams arriving on
+// the tunnel's destination are delivered), then starts it. The I2P side
+// of each tunnel is carried out by a Backend, which the embedding package
+// backs with SAM STREAM sessions on the bridge.
+//
+// BOB has no datagram support; tunnels carry streams only. The zap
+// command, which shuts BOB down, is refused.
+package bob
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Version is the BOB protocol version announced to clients.
+const Version = "00.00.10"
+
+// DefaultHost is the inhost and outhost of a new tunnel.
+const DefaultHost = "localhost"
+
+// maxLineLength bounds a command line, which may carry private keys.
+const maxLineLength = 16 * 1024
+
+// inboundHeaderTimeout bounds how long an inbound connection may take to
+// send the destination line.
+const inboundHeaderTimeout = 30 * time.Second
+
+// Backend carries out the I2P side of BOB commands.
+type Backend interface {
+	// NewKeys generates a destination, returning its public Base64 form
+	// and its private keys.
+	NewKeys(ctx context.Context) (dest, keys string, err error)
+
+	// Destination returns the public Base64 destination of keys.
+	Destination(keys string) (string, error)
+
+	// Lookup resolves an I2P hostname to a Base64 destination.
+	Lookup(ctx context.Context, name string) (string, error)
+
+	// Verify reports an error unless dest is a valid Base64 destination.
+	Verify(dest string) error
+
+	// Start brings up the I2P side of a tunnel.
+	Start(ctx context.Context, spec TunnelSpec) (Tunnel, error)
+}
+
+// TunnelSpec describes a tunnel for Backend.Start.
+type TunnelSpec struct {
+	// Nickname is the tunnel's BOB nickname.
+	Nickname string
+
+	// Keys are the private keys of the tunnel's destination.
+	Keys string
+
+	// Options are the I2CP options set with the option command.
+	Options map[string]string
+
+	// OutHost and OutPort, when OutPort is non-zero, are where streams
+	// arriving on the destination are delivered.
+	OutHost string
+	OutPort int
+
+	// Quiet suppresses the line naming the remote destination that is
+	// otherwise written before each delivered stream.
+	Quiet bool
+}
+
+// Tunnel is the I2P side of a running tunnel.
+type Tunnel interface {
+	// Dial opens a stream from the tunnel's destination to dest, an I2P
+	// hostname or Base64 destination.
+	Dial(ctx context.Context, dest string) (net.Conn, error)
+
+	// Close tears the tunnel down.
+	Close() error
+}
+
+// Server is a BOB listener. Tunnels are shared by all clients of a
+// Server, as in BOB, and run until stopped or the Server is closed.
+type Server struct {
+	backend Backend
+
+	// ErrorHandler, if set, is called with errors starting tunnels and
+	// opening their streams. It must not block.
+	ErrorHandler func(error)
+
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	mu        sync.Mutex
+	tunnels   map[string]*tunnel
+	listeners map[net.Listener]struct{}
+	conns     map[net.Conn]struct{}
+	closed    bool
+	wg        sync.WaitGroup
+}
+
+// NewServer creates a Server whose tunnels are carried by backend.
+func NewServer(backend Backend) *Server {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Server{
+		backend:   backend,
+		ctx:       ctx,
+		cancel:    cancel,
+		tunnels:   make(map[string]*tunnel),
+		listeners: make(map[net.Listener]struct{}),
+		conns:     make(map[net.Conn]struct{}),
+	}
+}
+
+// Serve accepts BOB clients on ln until ln fails or the server is closed.
+// It returns nil after Close.
+func (s *Server) Serve(ln net.Listener) error {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		ln.Close()
+		return nil
+	}
+	s.listeners[ln] = struct{}{}
+	s.mu.Unlock()
+
+	defer func() {
+		s.mu.Lock()
+		delete(s.listeners, ln)
+		s.mu.Unlock()
+	}()
+
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			if s.ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+		if !s.track(conn) {
+			conn.Close()
+			return nil
+		}
+		go func() {
+			defer s.wg.Done()
+			defer s.untrack(conn)
+			s.handle(conn)
+		}()
+	}
+}
+
+// Close stops all listeners, disconnects clients and stops every tunnel.
+// Safe to call multiple times.
+func (s *Server) Close() error {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return nil
+	}
+	s.closed = true
+	s.cancel()
+	for ln := range s.listeners {
+		ln.Close()
+	}
+	for conn := range s.conns {
+		conn.Close()
+	}
+	var running []*tunnel
+	for _, t := range s.tunnels {
+		if t.running {
+			running = append(running, t)
+		}
+	}
+	s.mu.Unlock()
+
+	for _, t := range running {
+		s.stop(t)
+	}
+	s.wg.Wait()
+	return nil
+}
+
+// track records an open client connection and counts its goroutine, or
+// returns false once closed.
+func (s *Server) track(conn net.Conn) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return false
+	}
+	s.conns[conn] = struct{}{}
+	s.wg.Add(1)
+	return true
+}
+
+// untrack closes and forgets a client connection.
+func (s *Server) untrack(conn net.Conn) {
+	conn.Close()
+	s.mu.Lock()
+	delete(s.conns, conn)
+	s.mu.Unlock()
+}
+
+// reportError passes err to ErrorHandler, if set.
+func (s *Server) reportError(err error) {
+	if s.ErrorHandler != nil {
+		s.ErrorHandler(err)
+	}
+}
+
+// client is the state of one BOB control connection.
+type client struct {
+	s    *Server
+	w    *bufio.Writer
+	nick string
+}
+
+// handle greets a client and runs its commands until it quits or
+// disconnects.
+func (s *Server) handle(conn net.Conn) {
+	c := &client{s: s, w: bufio.NewWriter(conn)}
+	c.reply("BOB " + Version)
+	c.reply("OK")
+	if c.w.Flush() != nil {
+		return
+	}
+
+	scanner := bufio.NewScanner(conn)
+	scanner.Buffer(make([]byte, 0, 4096), maxLineLength)
+	for scanner.Scan() {
+		cmd, arg, _ := strings.Cut(strings.TrimSpace(scanner.Text()), " ")
+		quit := c.run(strings.ToLower(cmd), strings.TrimSpace(arg))
+		if c.w.Flush() != nil || quit {
+			return
+		}
+	}
+}
+
+// reply writes one response line.
+func (c *client) reply(line string) {
+	c.w.WriteString(line)
+	c.w.WriteByte('\n')
+}
+
+// ok and fail write an OK or ERROR response.
+func (c *client) ok(format string, args ...any) {
+	c.reply("OK " + fmt.Sprintf(format, args...))
+}
+
+func (c *client) fail(format string, args ...any) {
+	c.reply("ERROR " + fmt.Sprintf(format, args...))
+}
+
+// run executes one command and reports whether the client quit.
+func (c *client) run(cmd, arg string) bool {
+	switch cmd {
+	case "":
+		return false
+	case "quit":
+		c.ok("Bye!")
+		return true
+	case "help":
+		c.ok("Commands: %s", strings.Join(commandNames, " "))
+	case "visit":
+		c.reply("OK")
+	case "zap":
+		c.fail("zap is not supported")
+	case "setnick":
+		c.setnick(arg)
+	case "getnick":
+		c.getnick(arg)
+	case "list":
+		c.list()
+	case "lookup":
+		c.lookup(arg)
+	case "verify":
+		if err := c.s.backend.Verify(arg); err != nil {
+			c.fail("not in BASE64 format")
+		} else {
+			c.ok("Destination is valid")
+		}
+	case "status":
+		c.status(arg)
+	default:
+		if update, ok := tunnelCommands[cmd]; ok {
+			c.withTunnel(update, arg)
+		} else {
+			c.fail("UNKNOWN COMMAND")
+		}
+	}
+	return false
+}
+
+// commandNames lists the commands for help.
+var commandNames = []string{
+	"clear", "getdest", "getkeys", "getnick", "help", "inhost", "inport", "list", "lookup", "newkeys",
+	"option", "outhost", "outport", "quiet", "quit", "setkeys", "setnick", "show", "showprops", "start",
+	"status", "stop", "verify", "visit", "zap",
+}
+
+// setnick creates a tunnel and selects it.
+func (c *client) setnick(nick string) {
+	if nick == "" || strings.ContainsAny(nick, " \t") {
+		c.fail("no nickname given")
+		return
+	}
+	c.s.mu.Lock()
+	defer c.s.mu.Unlock()
+	if _, ok := c.s.tunnels[nick]; ok {
+		c.fail("tunnel %s already exists", nick)
+		return
+	}
+	c.s.tunnels[nick] = newTunnel(nick)
+	c.nick = nick
+	c.ok("Nickname set to %s", nick)
+}
+
+// getnick selects an existing tunnel.
+func (c *client) getnick(nick string) {
+	c.s.mu.Lock()
+	defer c.s.mu.Unlock()
+	if _, ok := c.s.tunnels[nick]; !ok {
+		c.fail("Nickname not found")
+		return
+	}
+	c.nick = nick
+	c.ok("Nickname set to %s", nick)
+}
+
+// list writes the status of every tunnel.
+func (c *client) list() {
+	c.s.mu.Lock()
+	nicks := make([]string, 0, len(c.s.tunnels))
+	for nick := range c.s.tunnels {
+		nicks = append(nicks, nick)
+	}
+	sort.Strings(nicks)
+	for _, nick := range nicks {
+		c.reply("DATA " + c.s.tunnels[nick].status())
+	}
+	c.s.mu.Unlock()
+	c.ok("Listing done")
+}
+
+// status writes the status of the named tunnel.
+func (c *client) status(nick string) {
+	c.s.mu.Lock()
+	defer c.s.mu.Unlock()
+	t, ok := c.s.tunnels[nick]
+	if !ok {
+		c.fail("Nickname not found")
+		return
+	}
+	c.ok("%s", t.status())
+}
+
+// lookup resolves an I2P hostname.
+func (c *client) lookup(name string) {
+	dest, err := c.s.backend.Lookup(c.s.ctx, name)
+	if err != nil {
+		c.fail("Address Not found.")
+		return
+	}
+	c.ok("%s", dest)
+}
+
+// tunnelCommand runs a command on the client's selected tunnel, with the
+// server lock held. Commands that reach the backend or network release
+// the lock themselves and report so by returning true.
+type tunnelCommand func(c *client, t *tunnel, arg string) (unlocked bool)
+
+// tunnelCommands are the commands that act on the selected tunnel.
+var tunnelCommands = map[string]tunnelCommand{
+	"clear":     (*client).clear,
+	"getdest":   (*client).getdest,
+	"getkeys":   (*client).getkeys,
+	"inhost":    (*client).inhost,
+	"inport":    (*client).inport,
+	"newkeys":   (*client).newkeys,
+	"option":    (*client).option,
+	"outhost":   (*client).outhost,
+	"outport":   (*client).outport,
+	"quiet":     (*client).quiet,
+	"setkeys":   (*client).setkeys,
+	"show":      (*client).show,
+	"showprops": (*client).showprops,
+	"start":     (*client).start,
+	"stop":      (*client).stopTunnel,
+}
+
+// withTunnel runs update on the selected tunnel.
+func (c *client) withTunnel(update tunnelCommand, arg string) {
+	c.s.mu.Lock()
+	t, ok := c.s.tunnels[c.nick]
+	if !ok {
+		c.s.mu.Unlock()
+		c.fail("no nickname has been set")
+		return
+	}
+	if !update(c, t, arg) {
+		c.s.mu.Unlock()
+	}
+}
+
+// idle fails the command unless t is stopped, since a running tunnel's
+// settings cannot change.
+func (c *client) idle(t *tunnel) bool {
+	if t.starting || t.running || t.stopping {
+		c.fail("tunnel is active")
+		return false
+	}
+	return true
+}
+
+func (c *client) clear(t *tunnel, _ string) bool {
+	if c.idle(t) {
+		delete(c.s.tunnels, t.nick)
+		c.nick = ""
+		c.ok("cleared")
+	}
+	return false
+}
+
+func (c *client) getdest(t *tunnel, _ string) bool {
+	if t.dest == "" {
+		c.fail("keys not set")
+	} else {
+		c.ok("%s", t.dest)
+	}
+	return false
+}
+
+func (c *client) getkeys(t *tunnel, _ string) bool {
+	if t.keys == "" {
+		c.fail("keys not set")
+	} else {
+		c.ok("%s", t.keys)
+	}
+	return false
+}
+
+func (c *client) inhost(t *tunnel, arg string) bool {
+	if c.idle(t) {
+		t.inHost = arg
+		c.ok("inhost set")
+	}
+	return false
+}
+
+func (c *client) inport(t *tunnel, arg string) bool {
+	if c.idle(t) {
+		if port, err := parsePort(arg); err != nil {
+			c.fail("%v", err)
+		} else {
+			t.inPort = port
+			c.ok("inbound port set")
+		}
+	}
+	return false
+}
+
+func (c *client) outhost(t *tunnel, arg string) bool {
+	if c.idle(t) {
+		t.outHost = arg
+		c.ok("outhost set")
+	}
+	return false
+}
+
+func (c *client) outport(t *tunnel, arg string) bool {
+	if c.idle(t) {
+		if port, err := parsePort(arg); err != nil {
+			c.fail("%v", err)
+		} else {
+			t.outPort = port
+			c.ok("outbound port set")
+		}
+	}
+	return false
+}
+
+func (c *client) quiet(t *tunnel, arg string) bool {
+	if c.idle(t) {
+		if quiet, err := strconv.ParseBool(arg); err != nil {
+			c.fail("quiet must be true or false")
+		} else {
+			t.quiet = quiet
+			c.ok("Quiet set")
+		}
+	}
+	return false
+}
+
+func (c *client) option(t *tunnel, arg string) bool {
+	if c.idle(t) {
+		key, value, ok := strings.Cut(arg, "=")
+		if !ok || key == "" {
+			c.fail("option must be key=value")
+		} else {
+			t.options[key] = value
+			c.ok("%s set to %s", key, value)
+		}
+	}
+	return false
+}
+
+func (c *client) show(t *tunnel, _ string) bool {
+	c.ok("%s", t.status())
+	return false
+}
+
+func (c *client) showprops(t *tunnel, _ string) bool {
+	keys := make([]string, 0, len(t.options))
+	for k := range t.options {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	props := make([]string, len(keys))
+	for i, k := range keys {
+		props[i] = k + "=" + t.options[k]
+	}
+	c.ok("%s", strings.Join(props, " "))
+	return false
+}
+
+func (c *client) newkeys(t *tunnel, _ string) bool {
+	if !c.idle(t) {
+		return false
+	}
+	c.s.mu.Unlock()
+	dest, keys, err := c.s.backend.NewKeys(c.s.ctx)
+	if err != nil {
+		c.fail("%v", err)
+		return true
+	}
+	c.s.mu.Lock()
+	t.dest, t.keys = dest, keys
+	c.s.mu.Unlock()
+	c.ok("%s", dest)
+	return true
+}
+
+func (c *client) setkeys(t *tunnel, arg string) bool {
+	if !c.idle(t) {
+		return false
+	}
+	dest, err := c.s.backend.Destination(arg)
+	if err != nil {
+		c.fail("bad keys")
+		return false
+	}
+	t.dest, t.keys = dest, arg
+	c.ok("%s", dest)
+	return false
+}
+
+func (c *client) start(t *tunnel, _ string) bool {
+	switch {
+	case !c.idle(t):
+		return false
+	case c.s.closed:
+		c.fail("BOB is shutting down")
+		return false
+	case t.keys == "":
+		c.fail("keys not set")
+		return false
+	case t.inPort == 0 && t.outPort == 0:
+		c.fail("tunnel settings incomplete")
+		return false
+	}
+	t.starting = true
+	c.s.wg.Add(1)
+	go c.s.run(t, t.spec(), net.JoinHostPort(t.inHost, strconv.Itoa(t.inPort)), t.inPort != 0)
+	c.ok("tunnel starting")
+	return false
+}
+
+func (c *client) stopTunnel(t *tunnel, _ string) bool {
+	switch {
+	case t.starting:
+		c.fail("tunnel is starting")
+		return false
+	case !t.running || t.stopping:
+		c.fail("tunnel is inactive")
+		return false
+	}
+	t.stopping = true
+	c.s.mu.Unlock()
+	c.s.stop(t)
+	c.ok("tunnel stopping")
+	return true
+}
+
+// parsePort parses a TCP port number.
+func parsePort(s string) (int, error) {
+	port, err := strconv.Atoi(s)
+	if err != nil || port < 1 || port > 65535 {
+		return 0, errors.New("invalid port")
+	}
+	return port, nil
+}
@@ -0,0 +1,328 @@
+package bob
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeBackend carries tunnels over in-memory echo streams and records
+// what it is asked to do.
+type fakeBackend struct {
+	mu      sync.Mutex
+	specs   []TunnelSpec
+	dialed  []string
+	closed  int
+	failing bool
+}
+
+func (b *fakeBackend) NewKeys(ctx context.Context) (string, string, error) {
+	return "NEWDEST", "NEWKEYS", nil
+}
+
+func (b *fakeBackend) Destination(keys string) (string, error) {
+	if keys == "bad" {
+		return "", errors.New("bad keys")
+	}
+	return "DEST-OF-" + keys, nil
+}
+
+func (b *fakeBackend) Lookup(ctx context.Context, name string) (string, error) {
+	if name != "example.i2p" {
+		return "", errors.New("not found")
+	}
+	return "EXAMPLEDEST", nil
+}
+
+func (b *fakeBackend) Verify(dest string) error {
+	if dest == "" || strings.Contains(dest, "!") {
+		return errors.New("invalid")
+	}
+	return nil
+}
+
+func (b *fakeBackend) Start(ctx context.Context, spec TunnelSpec) (Tunnel, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.failing {
+		return nil, errors.New("router unavailable")
+	}
+	b.specs = append(b.specs, spec)
+	return &fakeTunnel{b: b}, nil
+}
+
+type fakeTunnel struct{ b *fakeBackend }
+
+func (t *fakeTunnel) Dial(ctx context.Context, dest string) (net.Conn, error) {
+	t.b.mu.Lock()
+	t.b.dialed = append(t.b.dialed, dest)
+	t.b.mu.Unlock()
+	local, remote := net.Pipe()
+	go io.Copy(remote, remote) // echo
+	return local, nil
+}
+
+func (t *fakeTunnel) Close() error {
+	t.b.mu.Lock()
+	t.b.closed++
+	t.b.mu.Unlock()
+	return nil
+}
+
+// bobClient is a test BOB control connection.
+type bobClient struct {
+	t    *testing.T
+	conn net.Conn
+	r    *bufio.Reader
+}
+
+// startTestServer serves s on a loopback listener and returns a client
+// that has read the greeting.
+func startTestServer(t *testing.T, s *Server) *bobClient {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("net.Listen() error = %v", err)
+	}
+	go s.Serve(ln)
+	t.Cleanup(func() { s.Close() })
+
+	conn, err := net.Dial("tcp", ln.Addr().String())
+	if err != nil {
+		t.Fatalf("net.Dial() error = %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+
+	c := &bobClient{t: t, conn: conn, r: bufio.NewReader(conn)}
+	if got := c.line(); got != "BOB "+Version {
+		t.Fatalf("greeting = %q, want %q", got, "BOB "+Version)
+	}
+	if got := c.line(); got != "OK" {
+		t.Fatalf("greeting = %q, want OK", got)
+	}
+	return c
+}
+
+func (c *bobClient) line() string {
+	c.t.Helper()
+	line, err := c.r.ReadString('\n')
+	if err != nil {
+		c.t.Fatalf("reading reply: %v", err)
+	}
+	return strings.TrimSuffix(line, "\n")
+}
+
+// cmd sends a command and returns its reply line.
+func (c *bobClient) cmd(line string) string {
+	c.t.Helper()
+	if _, err := io.WriteString(c.conn, line+"\n"); err != nil {
+		c.t.Fatalf("sending %q: %v", line, err)
+	}
+	return c.line()
+}
+
+// expect sends a command and fails unless the reply is want.
+func (c *bobClient) expect(line, want string) {
+	c.t.Helper()
+	if got := c.cmd(line); got != want {
+		c.t.Errorf("%s = %q, want %q", line, got, want)
+	}
+}
+
+// waitStatus polls status until it contains want.
+func (c *bobClient) waitStatus(nick, want string) string {
+	c.t.Helper()
+	for i := 0; i < 100; i++ {
+		if got := c.cmd("status " + nick); strings.Contains(got, want) {
+			return got
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	c.t.Fatalf("status %s never reported %q", nick, want)
+	return ""
+}
+
+// freePort returns a loopback TCP port that is free at the time of the
+// call.
+func freePort(t *testing.T) int {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("net.Listen() error = %v", err)
+	}
+	defer ln.Close()
+	return ln.Addr().(*net.TCPAddr).Port
+}
+
+func TestServer_Tunnel(t *testing.T) {
+	backend := &fakeBackend{}
+	c := startTestServer(t, NewServer(backend))
+	inPort := freePort(t)
+
+	c.expect("setnick web", "OK Nickname set to web")
+	c.expect("newkeys", "OK NEWDEST")
+	c.expect("getdest", "OK NEWDEST")
+	c.expect("getkeys", "OK NEWKEYS")
+	c.expect("inhost 127.0.0.1", "OK inhost set")
+	c.expect("inport "+strconv.Itoa(inPort), "OK inbound port set")
+	c.expect("outport 8080", "OK outbound port set")
+	c.expect("quiet true", "OK Quiet set")
+	c.expect("option inbound.length=1", "OK inbound.length set to 1")
+	c.expect("showprops", "OK inbound.length=1")
+	c.expect("start", "OK tunnel starting")
+
+	status := c.waitStatus("web", "RUNNING: true")
+	want := "OK NICKNAME: web STARTING: false RUNNING: true STOPPING: false KEYS: true QUIET: true INPORT: " +
+		strconv.Itoa(inPort) + " INHOST: 127.0.0.1 OUTPORT: 8080 OUTHOST: localhost"
+	if status != want {
+		t.Errorf("status = %q, want %q", status, want)
+	}
+	c.expect("inport 1234", "ERROR tunnel is active")
+
+	backend.mu.Lock()
+	spec := backend.specs[0]
+	backend.mu.Unlock()
+	if spec.Nickname != "web" || spec.Keys != "NEWKEYS" || spec.OutHost != "localhost" || spec.OutPort != 8080 ||
+		!spec.Quiet || spec.Options["inbound.length"] != "1" {
+		t.Errorf("started spec = %+v", spec)
+	}
+
+	// An inbound connection names its destination on the first line.
+	in, err := net.Dial("tcp", net.JoinHostPort("127.0.0.1", strconv.Itoa(inPort)))
+	if err != nil {
+		t.Fatalf("dialing inport: %v", err)
+	}
+	defer in.Close()
+	in.SetDeadline(time.Now().Add(2 * time.Second))
+	io.WriteString(in, "example.i2p\nping")
+	buf := make([]byte, 4)
+	if _, err := io.ReadFull(in, buf); err != nil || string(buf) != "ping" {
+		t.Errorf("echo = %q, %v; want %q", buf, err, "ping")
+	}
+	backend.mu.Lock()
+	dialed := backend.dialed
+	backend.mu.Unlock()
+	if len(dialed) != 1 || dialed[0] != "example.i2p" {
+		t.Errorf("dialed %v, want [example.i2p]", dialed)
+	}
+
+	c.expect("stop", "OK tunnel stopping")
+	c.waitStatus("web", "RUNNING: false")
+	if _, err := in.Read(buf); err == nil {
+		t.Error("inbound connection still open after stop")
+	}
+	backend.mu.Lock()
+	closed := backend.closed
+	backend.mu.Unlock()
+	if closed != 1 {
+		t.Errorf("tunnel closed %d times, want 1", closed)
+	}
+
+	c.expect("list", "DATA NICKNAME: web STARTING: false RUNNING: false STOPPING: false KEYS: true QUIET: true INPORT: "+
+		strconv.Itoa(inPort)+" INHOST: 127.0.0.1 OUTPORT: 8080 OUTHOST: localhost")
+	if got := c.line(); got != "OK Listing done" {
+		t.Errorf("list end = %q, want %q", got, "OK Listing done")
+	}
+	c.expect("clear", "OK cleared")
+	c.expect("getnick web", "ERROR Nickname not found")
+	c.expect("quit", "OK Bye!")
+}
+
+func TestServer_Commands(t *testing.T) {
+	tests := []struct {
+		name string
+		cmds []string
+		want string
+	}{
+		{"no nickname", []string{"newkeys"}, "ERROR no nickname has been set"},
+		{"duplicate nickname", []string{"setnick a", "setnick a"}, "ERROR tunnel a already exists"},
+		{"start without keys", []string{"setnick a", "outport 80", "start"}, "ERROR keys not set"},
+		{"start without ports", []string{"setnick a", "setkeys KEYS", "start"}, "ERROR tunnel settings incomplete"},
+		{"stop inactive", []string{"setnick a", "stop"}, "ERROR tunnel is inactive"},
+		{"setkeys", []string{"setnick a", "setkeys KEYS"}, "OK DEST-OF-KEYS"},
+		{"bad keys", []string{"setnick a", "setkeys bad"}, "ERROR bad keys"},
+		{"bad port", []string{"setnick a", "inport 70000"}, "ERROR invalid port"},
+		{"bad quiet", []string{"setnick a", "quiet maybe"}, "ERROR quiet must be true or false"},
+		{"bad option", []string{"setnick a", "option novalue"}, "ERROR option must be key=value"},
+		{"getdest without keys", []string{"setnick a", "getdest"}, "ERROR keys not set"},
+		{"lookup", []string{"lookup example.i2p"}, "OK EXAMPLEDEST"},
+		{"lookup unknown", []string{"lookup missing.i2p"}, "ERROR Address Not found."},
+		{"verify", []string{"verify ABCD"}, "OK Destination is valid"},
+		{"verify invalid", []string{"verify AB!D"}, "ERROR not in BASE64 format"},
+		{"status unknown", []string{"status nobody"}, "ERROR Nickname not found"},
+		{"zap", []string{"zap"}, "ERROR zap is not supported"},
+		{"unknown", []string{"frobnicate"}, "ERROR UNKNOWN COMMAND"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := startTestServer(t, NewServer(&fakeBackend{}))
+			var got string
+			for _, cmd := range tt.cmds {
+				got = c.cmd(cmd)
+			}
+			if got != tt.want {
+				t.Errorf("%s = %q, want %q", tt.cmds[len(tt.cmds)-1], got, tt.want)
+			}
+		})
+	}
+}
+
+func TestServer_StartFailure(t *testing.T) {
+	var mu sync.Mutex
+	var reported []error
+	s := NewServer(&fakeBackend{failing: true})
+	s.ErrorHandler = func(err error) {
+		mu.Lock()
+		reported = append(reported, err)
+		mu.Unlock()
+	}
+	c := startTestServer(t, s)
+
+	c.expect("setnick a", "OK Nickname set to a")
+	c.expect("setkeys KEYS", "OK DEST-OF-KEYS")
+	c.expect("outport 80", "OK outbound port set")
+	c.expect("start", "OK tunnel starting")
+	c.waitStatus("a", "STARTING: false")
+	if got := c.cmd("status a"); !strings.Contains(got, "RUNNING: false") {
+		t.Errorf("status after failed start = %q, want not running", got)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(reported) != 1 {
+		t.Errorf("reported errors = %v, want one", reported)
+	}
+}
+
+func TestServer_Close(t *testing.T) {
+	backend := &fakeBackend{}
+	s := NewServer(backend)
+	c := startTestServer(t, s)
+
+	c.expect("setnick a", "OK Nickname set to a")
+	c.expect("setkeys KEYS", "OK DEST-OF-KEYS")
+	c.expect("outport 80", "OK outbound port set")
+	c.expect("start", "OK tunnel starting")
+	c.waitStatus("a", "RUNNING: true")
+
+	s.Close()
+	s.Close()
+
+	backend.mu.Lock()
+	defer backend.mu.Unlock()
+	if backend.closed != 1 {
+		t.Errorf("tunnel closed %d times, want 1", backend.closed)
+	}
+	if _, err := c.r.ReadString('\n'); err == nil {
+		t.Error("control connection still open after Close")
+	}
+}
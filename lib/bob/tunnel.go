@@ -0,0 +1,198 @@
+package bob
+
+import (
+	"bufio"
+	"fmt"
+	"maps"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-i2p/go-sam-bridge/lib/util"
+)
+
+// tunnel is a BOB tunnel's settings and, while it runs, its I2P side and
+// inbound listener. Fields are guarded by Server.mu.
+type tunnel struct {
+	nick    string
+	keys    string
+	dest    string
+	inHost  string
+	inPort  int
+	outHost string
+	outPort int
+	quiet   bool
+	options map[string]string
+
+	starting bool
+	running  bool
+	stopping bool
+
+	active   Tunnel
+	listener net.Listener
+	conns    map[net.Conn]struct{}
+}
+
+// newTunnel returns a stopped tunnel with default settings.
+func newTunnel(nick string) *tunnel {
+	return &tunnel{
+		nick:    nick,
+		inHost:  DefaultHost,
+		outHost: DefaultHost,
+		options: make(map[string]string),
+		conns:   make(map[net.Conn]struct{}),
+	}
+}
+
+// status formats the tunnel as BOB reports it in show, status and list.
+func (t *tunnel) status() string {
+	port := func(p int) string {
+		if p == 0 {
+			return "not_set"
+		}
+		return strconv.Itoa(p)
+	}
+	return fmt.Sprintf("NICKNAME: %s STARTING: %t RUNNING: %t STOPPING: %t KEYS: %t QUIET: %t INPORT: %s INHOST: %s OUTPORT: %s OUTHOST: %s",
+		t.nick, t.starting, t.running, t.stopping, t.keys != "", t.quiet, port(t.inPort), t.inHost, port(t.outPort), t.outHost)
+}
+
+// spec returns the tunnel's settings for Backend.Start.
+func (t *tunnel) spec() TunnelSpec {
+	spec := TunnelSpec{
+		Nickname: t.nick,
+		Keys:     t.keys,
+		Options:  maps.Clone(t.options),
+		Quiet:    t.quiet,
+	}
+	if t.outPort != 0 {
+		spec.OutHost, spec.OutPort = t.outHost, t.outPort
+	}
+	return spec
+}
+
+// run brings up tunnel t from spec, listening on inAddr when listen is
+// set. It is started by the start command with s.wg counted.
+func (s *Server) run(t *tunnel, spec TunnelSpec, inAddr string, listen bool) {
+	defer s.wg.Done()
+
+	active, err := s.backend.Start(s.ctx, spec)
+	var ln net.Listener
+	if err == nil && listen {
+		if ln, err = net.Listen("tcp", inAddr); err != nil {
+			active.Close()
+		}
+	}
+
+	s.mu.Lock()
+	t.starting = false
+	if err == nil && s.closed {
+		err = net.ErrClosed
+		active.Close()
+		if ln != nil {
+			ln.Close()
+		}
+	}
+	if err != nil {
+		s.mu.Unlock()
+		s.reportError(fmt.Errorf("bob: start tunnel %s: %w", t.nick, err))
+		return
+	}
+	t.running = true
+	t.active, t.listener = active, ln
+	if ln != nil {
+		s.wg.Add(1)
+	}
+	s.mu.Unlock()
+
+	if ln != nil {
+		go func() {
+			defer s.wg.Done()
+			s.serveInbound(t, ln, active)
+		}()
+	}
+}
+
+// stop tears down tunnel t: its inbound listener and connections, then
+// its I2P side. Safe to call more than once.
+func (s *Server) stop(t *tunnel) {
+	s.mu.Lock()
+	t.stopping = true
+	active, ln, conns := t.active, t.listener, t.conns
+	t.active, t.listener, t.conns = nil, nil, make(map[net.Conn]struct{})
+	s.mu.Unlock()
+
+	if ln != nil {
+		ln.Close()
+	}
+	for conn := range conns {
+		conn.Close()
+	}
+	if active != nil {
+		active.Close()
+	}
+
+	s.mu.Lock()
+	t.running, t.stopping = false, false
+	s.mu.Unlock()
+}
+
+// serveInbound accepts local connections for tunnel t until its listener
+// closes.
+func (s *Server) serveInbound(t *tunnel, ln net.Listener, active Tunnel) {
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+
+		s.mu.Lock()
+		if s.closed || t.listener != ln {
+			s.mu.Unlock()
+			conn.Close()
+			return
+		}
+		t.conns[conn] = struct{}{}
+		s.wg.Add(1)
+		s.mu.Unlock()
+
+		go func() {
+			defer s.wg.Done()
+			defer func() {
+				conn.Close()
+				s.mu.Lock()
+				delete(t.conns, conn)
+				s.mu.Unlock()
+			}()
+			s.handleInbound(t.nick, conn, active)
+		}()
+	}
+}
+
+// handleInbound reads the destination an inbound connection names on its
+// first line, opens a stream to it and relays the connection over it.
+func (s *Server) handleInbound(nick string, conn net.Conn, active Tunnel) {
+	conn.SetReadDeadline(time.Now().Add(inboundHeaderTimeout))
+	r := bufio.NewReader(conn)
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return
+	}
+	dest := strings.TrimSpace(line)
+	conn.SetReadDeadline(time.Time{})
+
+	stream, err := active.Dial(s.ctx, dest)
+	if err != nil {
+		s.reportError(fmt.Errorf("bob: tunnel %s: connect to %s: %w", nick, dest, err))
+		return
+	}
+	// Pass on anything sent after the destination line.
+	if n := r.Buffered(); n > 0 {
+		data, _ := r.Peek(n)
+		if _, err := stream.Write(data); err != nil {
+			stream.Close()
+			return
+		}
+	}
+	util.Relay(conn, stream)
+}
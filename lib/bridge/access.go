@@ -0,0 +1,103 @@
+package bridge
+
+import (
+	"encoding/json"
+	"io"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// AccessRecord describes a SAM control connection, written when it
+// closes.
+type AccessRecord struct {
+	// Connected and Disconnected are when the connection was accepted
+	// and closed.
+	Connected    time.Time `json:"connected"`
+	Disconnected time.Time `json:"disconnected"`
+
+	// DurationSeconds is the time between Connected and Disconnected.
+	DurationSeconds float64 `json:"duration_seconds"`
+
+	// RemoteAddr is the client's network address.
+	RemoteAddr string `json:"remote_addr"`
+
+	// Version is the SAM version negotiated by HELLO, if any.
+	Version string `json:"version,omitempty"`
+
+	// User is the authenticated SAM username, if any.
+	User string `json:"user,omitempty"`
+
+	// SessionID is the session bound to the connection, if any.
+	SessionID string `json:"session_id,omitempty"`
+
+	// BytesIn and BytesOut count everything read from and written to the
+	// client, including stream data on STREAM CONNECT, ACCEPT and
+	// FORWARD connections.
+	BytesIn  uint64 `json:"bytes_in"`
+	BytesOut uint64 `json:"bytes_out"`
+}
+
+// AccessLogger writes one JSON record per closed connection. It is safe
+// for concurrent use by multiple connections.
+type AccessLogger struct {
+	mu  sync.Mutex
+	enc *json.Encoder
+}
+
+// NewAccessLogger creates an AccessLogger that writes JSON lines to w.
+func NewAccessLogger(w io.Writer) *AccessLogger {
+	return &AccessLogger{enc: json.NewEncoder(w)}
+}
+
+// Log writes a record. Write errors are ignored so a failing access log
+// never interrupts connection handling.
+func (a *AccessLogger) Log(rec AccessRecord) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	_ = a.enc.Encode(rec)
+}
+
+// newAccessRecord builds the record for c, closed now, which has
+// transferred the bytes counted by conn.
+func newAccessRecord(c *Connection, conn *countingConn) AccessRecord {
+	now := time.Now().UTC()
+	connected := c.CreatedAt().UTC()
+	return AccessRecord{
+		Connected:       connected,
+		Disconnected:    now,
+		DurationSeconds: now.Sub(connected).Seconds(),
+		RemoteAddr:      c.RemoteAddr(),
+		Version:         c.Version(),
+		User:            c.Username(),
+		SessionID:       c.SessionID(),
+		BytesIn:         conn.read.Load(),
+		BytesOut:        conn.written.Load(),
+	}
+}
+
+// countingConn counts the bytes read from and written to a connection.
+type countingConn struct {
+	net.Conn
+	read    atomic.Uint64
+	written atomic.Uint64
+}
+
+func (c *countingConn) Read(p []byte) (int, error) {
+	n, err := c.Conn.Read(p)
+	c.read.Add(uint64(n))
+	return n, err
+}
+
+func (c *countingConn) Write(p []byte) (int, error) {
+	n, err := c.Conn.Write(p)
+	c.written.Add(uint64(n))
+	return n, err
+}
+
+// NetConn returns the underlying connection, so that socket options can
+// be set on it.
+func (c *countingConn) NetConn() net.Conn {
+	return c.Conn
+}
@@ -0,0 +1,73 @@
+package bridge
+
+import (
+	"bufio"
+	"encoding/json"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-i2p/go-sam-bridge/lib/handler"
+	"github.com/go-i2p/go-sam-bridge/lib/protocol"
+)
+
+func TestServer_AccessLog(t *testing.T) {
+	buf := &syncBuffer{}
+	config := DefaultConfig()
+	config.Auth.Required = true
+	config.Auth.Users = map[string]string{"admin": "secret"}
+	config.AccessLog = buf
+
+	server, err := NewServer(config, newMockRegistry())
+	if err != nil {
+		t.Fatalf("NewServer() error = %v", err)
+	}
+	server.Router().RegisterFunc("HELLO", func(ctx *handler.Context, cmd *protocol.Command) (*protocol.Response, error) {
+		return protocol.NewResponse("HELLO").WithAction("REPLY").WithResult("OK").WithVersion("3.3"), nil
+	})
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("net.Listen() error = %v", err)
+	}
+	go server.Serve(listener)
+	defer server.Close()
+
+	conn, err := net.Dial("tcp", listener.Addr().String())
+	if err != nil {
+		t.Fatalf("net.Dial() error = %v", err)
+	}
+	hello := "HELLO VERSION MIN=3.0 MAX=3.3 USER=admin PASSWORD=secret\n"
+	conn.Write([]byte(hello))
+	reply, err := bufio.NewReader(conn).ReadString('\n')
+	if err != nil {
+		t.Fatalf("ReadString() error = %v", err)
+	}
+	if buf.String() != "" {
+		t.Errorf("access log before close = %q, want empty", buf.String())
+	}
+	conn.Close()
+
+	deadline := time.Now().Add(time.Second)
+	for buf.String() == "" && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	var rec AccessRecord
+	if err := json.Unmarshal([]byte(strings.TrimSpace(buf.String())), &rec); err != nil {
+		t.Fatalf("Unmarshal(%q) error = %v", buf.String(), err)
+	}
+	if rec.Version != "3.3" || rec.User != "admin" {
+		t.Errorf("record version, user = %q, %q; want 3.3, admin", rec.Version, rec.User)
+	}
+	if !strings.HasPrefix(rec.RemoteAddr, "127.0.0.1:") {
+		t.Errorf("record RemoteAddr = %q, want the client address", rec.RemoteAddr)
+	}
+	if rec.BytesIn != uint64(len(hello)) || rec.BytesOut != uint64(len(reply)) {
+		t.Errorf("record bytes in, out = %d, %d; want %d, %d", rec.BytesIn, rec.BytesOut, len(hello), len(reply))
+	}
+	if rec.Connected.IsZero() || rec.Disconnected.Before(rec.Connected) {
+		t.Errorf("record times = %v to %v, want connect before disconnect", rec.Connected, rec.Disconnected)
+	}
+}
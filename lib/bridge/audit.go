@@ -0,0 +1,124 @@
+package bridge
+
+import (
+	"encoding/json"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-i2p/go-sam-bridge/lib/protocol"
+)
+
+// redactedValue replaces sensitive option values in audit records.
+const redactedValue = "[REDACTED]"
+
+// AuditRecord describes a single processed SAM command.
+type AuditRecord struct {
+	// Time is when the command finished processing.
+	Time time.Time `json:"time"`
+
+	// RemoteAddr is the client's network address.
+	RemoteAddr string `json:"remote_addr"`
+
+	// User is the authenticated SAM username, if any.
+	User string `json:"user,omitempty"`
+
+	// SessionID is the session bound to the connection, if any.
+	SessionID string `json:"session_id,omitempty"`
+
+	// SessionLabel is the label of the bound session, if any.
+	SessionLabel string `json:"session_label,omitempty"`
+
+	// Verb and Action identify the command (e.g., SESSION CREATE).
+	Verb   string `json:"verb"`
+	Action string `json:"action,omitempty"`
+
+	// Result is the RESULT value of the response, if any.
+	Result string `json:"result,omitempty"`
+
+	// Options holds the command options with secrets redacted.
+	Options map[string]string `json:"options,omitempty"`
+}
+
+// AuditLogger writes one JSON record per processed command.
+// It is safe for concurrent use by multiple connections.
+type AuditLogger struct {
+	mu  sync.Mutex
+	enc *json.Encoder
+}
+
+// NewAuditLogger creates an AuditLogger that writes JSON lines to w.
+func NewAuditLogger(w io.Writer) *AuditLogger {
+	return &AuditLogger{enc: json.NewEncoder(w)}
+}
+
+// Log writes a record. Write errors are ignored so a failing audit sink
+// never interrupts command processing.
+func (a *AuditLogger) Log(rec AuditRecord) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	_ = a.enc.Encode(rec)
+}
+
+// LogCommand records cmd as processed on c with the given response.
+// Passwords and private key material are redacted.
+func (a *AuditLogger) LogCommand(c *Connection, cmd *protocol.Command, response *protocol.Response) {
+	a.Log(newAuditRecord(c, cmd, response))
+}
+
+// newAuditRecord builds the record for cmd as processed on c.
+func newAuditRecord(c *Connection, cmd *protocol.Command, response *protocol.Response) AuditRecord {
+	rec := AuditRecord{
+		Time:       time.Now().UTC(),
+		RemoteAddr: c.RemoteAddr(),
+		User:       c.Username(),
+		SessionID:  c.SessionID(),
+		Verb:       cmd.Verb,
+		Action:     cmd.Action,
+		Options:    redactOptions(cmd),
+	}
+	if response != nil {
+		rec.Result = getOptionValue(response.Options, "RESULT")
+	}
+	return rec
+}
+
+// redactOptions copies the command options, replacing secrets.
+// Options for which isSecretOption holds are always redacted.
+// DESTINATION is redacted for SESSION commands, where it carries the
+// private key per SAMv3.md, unless it is TRANSIENT.
+func redactOptions(cmd *protocol.Command) map[string]string {
+	if len(cmd.Options) == 0 {
+		return nil
+	}
+
+	isSession := strings.EqualFold(cmd.Verb, protocol.VerbSession)
+	opts := make(map[string]string, len(cmd.Options))
+	for k, v := range cmd.Options {
+		switch {
+		case isSecretOption(k):
+			v = redactedValue
+		case isSession && strings.EqualFold(k, "DESTINATION") && !strings.EqualFold(v, "TRANSIENT"):
+			v = redactedValue
+		}
+		opts[k] = v
+	}
+	return opts
+}
+
+// leaseSetClientPrefix starts the per-client encrypted lease set keys,
+// i2cp.leaseSetClient.dh.N and i2cp.leaseSetClient.psk.N.
+const leaseSetClientPrefix = "i2cp.leaseSetClient."
+
+// isSecretOption reports whether the value of the option key is a secret
+// whatever the command: PASSWORD (HELLO, AUTH ADD), PRIV, the SECRET and
+// AUTH_KEY of NAMING LOOKUP, and the encrypted lease set options
+// i2cp.leaseSetSecret and i2cp.leaseSetClient.*.
+func isSecretOption(key string) bool {
+	switch strings.ToLower(key) {
+	case "password", "priv", "secret", "auth_key", "i2cp.leasesetsecret":
+		return true
+	}
+	return len(key) >= len(leaseSetClientPrefix) && strings.EqualFold(key[:len(leaseSetClientPrefix)], leaseSetClientPrefix)
+}
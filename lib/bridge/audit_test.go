@@ -0,0 +1,296 @@
+package bridge
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-i2p/go-sam-bridge/lib/handler"
+	"github.com/go-i2p/go-sam-bridge/lib/protocol"
+	"github.com/go-i2p/go-sam-bridge/lib/session"
+)
+
+// syncBuffer is a bytes.Buffer safe for concurrent writes and reads.
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func decodeAuditRecords(t *testing.T, data string) []AuditRecord {
+	t.Helper()
+	var records []AuditRecord
+	for _, line := range strings.Split(strings.TrimSpace(data), "\n") {
+		if line == "" {
+			continue
+		}
+		var rec AuditRecord
+		if err := json.Unmarshal([]byte(line), &rec); err != nil {
+			t.Fatalf("Unmarshal(%q) error = %v", line, err)
+		}
+		records = append(records, rec)
+	}
+	return records
+}
+
+func TestAuditLogger_LogCommand(t *testing.T) {
+	var buf bytes.Buffer
+	audit := NewAuditLogger(&buf)
+
+	c := NewConnection(newMockConn(), 1024)
+	c.SetAuthenticated("alice")
+	c.BindSession("sess1")
+
+	cmd := &protocol.Command{
+		Verb:    "SESSION",
+		Action:  "CREATE",
+		Options: map[string]string{"STYLE": "STREAM", "ID": "sess1", "DESTINATION": "privatekeydata"},
+	}
+	response := protocol.NewResponse("SESSION").WithAction("STATUS").WithResult("OK")
+	audit.LogCommand(c, cmd, response)
+
+	records := decodeAuditRecords(t, buf.String())
+	if len(records) != 1 {
+		t.Fatalf("got %d records, want 1", len(records))
+	}
+	rec := records[0]
+
+	if rec.RemoteAddr != "127.0.0.1:12345" {
+		t.Errorf("RemoteAddr = %q, want %q", rec.RemoteAddr, "127.0.0.1:12345")
+	}
+	if rec.User != "alice" || rec.SessionID != "sess1" {
+		t.Errorf("User/SessionID = %q/%q, want alice/sess1", rec.User, rec.SessionID)
+	}
+	if rec.Verb != "SESSION" || rec.Action != "CREATE" || rec.Result != "OK" {
+		t.Errorf("Verb/Action/Result = %s/%s/%s, want SESSION/CREATE/OK", rec.Verb, rec.Action, rec.Result)
+	}
+	if rec.Time.IsZero() {
+		t.Error("Time should be set")
+	}
+	if rec.Options["DESTINATION"] != redactedValue {
+		t.Errorf("DESTINATION = %q, want redacted", rec.Options["DESTINATION"])
+	}
+	if rec.Options["STYLE"] != "STREAM" {
+		t.Errorf("STYLE = %q, want STREAM", rec.Options["STYLE"])
+	}
+	if strings.Contains(buf.String(), "privatekeydata") {
+		t.Error("audit output must not contain private key material")
+	}
+}
+
+func TestRedactOptions(t *testing.T) {
+	tests := []struct {
+		name string
+		cmd  *protocol.Command
+		key  string
+		want string
+	}{
+		{
+			name: "hello password",
+			cmd:  &protocol.Command{Verb: "HELLO", Action: "VERSION", Options: map[string]string{"USER": "alice", "PASSWORD": "secret"}},
+			key:  "PASSWORD",
+			want: redactedValue,
+		},
+		{
+			name: "hello user kept",
+			cmd:  &protocol.Command{Verb: "HELLO", Action: "VERSION", Options: map[string]string{"USER": "alice", "PASSWORD": "secret"}},
+			key:  "USER",
+			want: "alice",
+		},
+		{
+			name: "auth add password",
+			cmd:  &protocol.Command{Verb: "AUTH", Action: "ADD", Options: map[string]string{"USER": "bob", "PASSWORD": "hunter2"}},
+			key:  "PASSWORD",
+			want: redactedValue,
+		},
+		{
+			name: "transient destination kept",
+			cmd:  &protocol.Command{Verb: "SESSION", Action: "CREATE", Options: map[string]string{"DESTINATION": "TRANSIENT"}},
+			key:  "DESTINATION",
+			want: "TRANSIENT",
+		},
+		{
+			name: "lease set secret",
+			cmd:  &protocol.Command{Verb: "SESSION", Action: "CREATE", Options: map[string]string{"i2cp.leaseSetSecret": "hunter2"}},
+			key:  "i2cp.leaseSetSecret",
+			want: redactedValue,
+		},
+		{
+			name: "lease set client psk",
+			cmd:  &protocol.Command{Verb: "SESSION", Action: "CREATE", Options: map[string]string{"i2cp.leaseSetClient.psk.0": "alice:a2V5"}},
+			key:  "i2cp.leaseSetClient.psk.0",
+			want: redactedValue,
+		},
+		{
+			name: "lease set client dh",
+			cmd:  &protocol.Command{Verb: "SESSION", Action: "ADD", Options: map[string]string{"i2cp.leaseSetClient.dh.12": "bob:a2V5"}},
+			key:  "i2cp.leaseSetClient.dh.12",
+			want: redactedValue,
+		},
+		{
+			name: "lease set auth type kept",
+			cmd:  &protocol.Command{Verb: "SESSION", Action: "CREATE", Options: map[string]string{"i2cp.leaseSetAuthType": "2"}},
+			key:  "i2cp.leaseSetAuthType",
+			want: "2",
+		},
+		{
+			name: "naming lookup secret",
+			cmd:  &protocol.Command{Verb: "NAMING", Action: "LOOKUP", Options: map[string]string{"SECRET": "hunter2"}},
+			key:  "SECRET",
+			want: redactedValue,
+		},
+		{
+			name: "naming lookup auth key",
+			cmd:  &protocol.Command{Verb: "NAMING", Action: "LOOKUP", Options: map[string]string{"AUTH_KEY": "a2V5"}},
+			key:  "AUTH_KEY",
+			want: redactedValue,
+		},
+		{
+			name: "stream connect destination kept",
+			cmd:  &protocol.Command{Verb: "STREAM", Action: "CONNECT", Options: map[string]string{"DESTINATION": "publicdest"}},
+			key:  "DESTINATION",
+			want: "publicdest",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := redactOptions(tt.cmd)
+			if opts[tt.key] != tt.want {
+				t.Errorf("%s = %q, want %q", tt.key, opts[tt.key], tt.want)
+			}
+		})
+	}
+}
+
+func TestRedactOptions_DoesNotModifyCommand(t *testing.T) {
+	cmd := &protocol.Command{Verb: "HELLO", Options: map[string]string{"PASSWORD": "secret"}}
+	redactOptions(cmd)
+	if cmd.Options["PASSWORD"] != "secret" {
+		t.Error("redactOptions must not modify the command")
+	}
+}
+
+func TestServer_AuditLog(t *testing.T) {
+	registry := newMockRegistry()
+	buf := &syncBuffer{}
+	config := DefaultConfig()
+	config.Auth.Required = true
+	config.Auth.Users = map[string]string{"admin": "secret"}
+	config.AuditLog = buf
+
+	server, err := NewServer(config, registry)
+	if err != nil {
+		t.Fatalf("NewServer() error = %v", err)
+	}
+
+	server.Router().RegisterFunc("HELLO", func(ctx *handler.Context, cmd *protocol.Command) (*protocol.Response, error) {
+		return protocol.NewResponse("HELLO").
+			WithAction("REPLY").
+			WithResult("OK").
+			WithVersion("3.3"), nil
+	})
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("net.Listen() error = %v", err)
+	}
+
+	go server.Serve(listener)
+	defer server.Close()
+
+	conn, err := net.Dial("tcp", listener.Addr().String())
+	if err != nil {
+		t.Fatalf("net.Dial() error = %v", err)
+	}
+	defer conn.Close()
+
+	reader := bufio.NewReader(conn)
+	conn.Write([]byte("HELLO VERSION MIN=3.0 MAX=3.3 USER=admin PASSWORD=secret\n"))
+	reader.ReadString('\n')
+	conn.Write([]byte("NAMING LOOKUP NAME=ME\n"))
+	reader.ReadString('\n')
+
+	deadline := time.Now().Add(time.Second)
+	for strings.Count(buf.String(), "\n") < 2 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	records := decodeAuditRecords(t, buf.String())
+	if len(records) != 2 {
+		t.Fatalf("got %d records, want 2: %s", len(records), buf.String())
+	}
+	if records[0].Verb != "HELLO" || records[0].Result != "OK" {
+		t.Errorf("first record = %+v, want HELLO with RESULT=OK", records[0])
+	}
+	if records[1].User != "admin" {
+		t.Errorf("second record User = %q, want admin", records[1].User)
+	}
+	if records[1].Verb != "NAMING" || records[1].Result != "I2P_ERROR" {
+		t.Errorf("second record = %+v, want unrouted NAMING with RESULT=I2P_ERROR", records[1])
+	}
+	if strings.Contains(buf.String(), "secret") {
+		t.Error("audit output must not contain passwords")
+	}
+}
+
+func TestServer_AuditLogSessionLabel(t *testing.T) {
+	buf := &syncBuffer{}
+	config := DefaultConfig()
+	config.AuditLog = buf
+
+	server, err := NewServer(config, newMockRegistry())
+	if err != nil {
+		t.Fatalf("NewServer() error = %v", err)
+	}
+
+	server.Router().RegisterFunc("HELLO", func(ctx *handler.Context, cmd *protocol.Command) (*protocol.Response, error) {
+		cfg := session.DefaultSessionConfig()
+		cfg.Label = "my app"
+		ctx.BindSession(session.NewBaseSession("labelled", session.StyleStream, nil, nil, cfg))
+		return protocol.NewResponse("HELLO").WithAction("REPLY").WithResult("OK").WithVersion("3.3"), nil
+	})
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("net.Listen() error = %v", err)
+	}
+
+	go server.Serve(listener)
+	defer server.Close()
+
+	conn, err := net.Dial("tcp", listener.Addr().String())
+	if err != nil {
+		t.Fatalf("net.Dial() error = %v", err)
+	}
+	defer conn.Close()
+
+	conn.Write([]byte("HELLO VERSION\n"))
+	bufio.NewReader(conn).ReadString('\n')
+
+	deadline := time.Now().Add(time.Second)
+	for !strings.Contains(buf.String(), "\n") && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	records := decodeAuditRecords(t, buf.String())
+	if len(records) != 1 || records[0].SessionLabel != "my app" {
+		t.Errorf("records = %+v, want one with SessionLabel %q", records, "my app")
+	}
+}
@@ -23,6 +23,9 @@ type AuthStore struct {
 	mu      sync.RWMutex
 	enabled bool
 	users   map[string]string
+
+	// authFunc, if set, validates credentials instead of users.
+	authFunc func(user, password string) bool
 }
 
 // NewAuthStore creates a new authentication store.
@@ -42,8 +45,9 @@ func NewAuthStoreFromConfig(cfg AuthConfig) *AuthStore {
 		users[k] = v
 	}
 	return &AuthStore{
-		enabled: cfg.Required,
-		users:   users,
+		enabled:  cfg.Required,
+		users:    users,
+		authFunc: cfg.Func,
 	}
 }
 
@@ -93,6 +97,20 @@ func (s *AuthStore) RemoveUser(username string) error {
 	return nil
 }
 
+// SetUsers replaces all users with a copy of users, including any added
+// at runtime with AUTH ADD. It does not change whether authentication is
+// required.
+func (s *AuthStore) SetUsers(users map[string]string) {
+	replacement := make(map[string]string, len(users))
+	for k, v := range users {
+		replacement[k] = v
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.users = replacement
+}
+
 // HasUser returns true if the username exists.
 // Implements handler.AuthManager interface.
 func (s *AuthStore) HasUser(username string) bool {
@@ -121,13 +139,22 @@ func (s *AuthStore) ListUsers() []string {
 }
 
 // CheckPassword verifies the password for a user.
-// Returns true if the user exists and the password matches.
+// Returns true if the user exists and the password matches, in constant
+// time; see VerifyPassword for the stored forms accepted.
 // This method is used by the HELLO handler for authentication.
+// If an authentication callback is configured, it decides instead and
+// users added via AUTH ADD are not consulted.
 func (s *AuthStore) CheckPassword(username, password string) bool {
 	s.mu.RLock()
-	defer s.mu.RUnlock()
-	storedPassword, ok := s.users[username]
-	return ok && storedPassword == password
+	authFunc := s.authFunc
+	// The lock is not held while hashing.
+	stored, ok := lookupPassword(s.users, username)
+	s.mu.RUnlock()
+
+	if authFunc != nil {
+		return authFunc(username, password)
+	}
+	return checkUserPassword(stored, ok, password)
 }
 
 // UserCount returns the number of registered users.
@@ -151,5 +178,6 @@ func (s *AuthStore) ToConfig() AuthConfig {
 	return AuthConfig{
 		Required: s.enabled,
 		Users:    users,
+		Func:     s.authFunc,
 	}
 }
@@ -141,6 +141,28 @@ func TestAuthStore_AddUser_Update(t *testing.T) {
 	}
 }
 
+func TestAuthStore_SetUsers(t *testing.T) {
+	store := NewAuthStore()
+	store.AddUser("old", "pass")
+
+	users := map[string]string{"alice": "secret"}
+	store.SetUsers(users)
+	users["bob"] = "hunter2"
+
+	if store.HasUser("old") {
+		t.Error("SetUsers should remove existing users")
+	}
+	if !store.CheckPassword("alice", "secret") {
+		t.Error("SetUsers should add alice")
+	}
+	if store.HasUser("bob") {
+		t.Error("SetUsers should copy the map")
+	}
+	if store.IsAuthEnabled() {
+		t.Error("SetUsers should not enable authentication")
+	}
+}
+
 func TestAuthStore_RemoveUser(t *testing.T) {
 	store := NewAuthStore()
 	store.AddUser("testuser", "testpass")
@@ -252,6 +274,29 @@ func TestAuthStore_CheckPassword_EmptyPassword(t *testing.T) {
 	}
 }
 
+func TestAuthStore_CheckPassword_AuthFunc(t *testing.T) {
+	store := NewAuthStoreFromConfig(AuthConfig{
+		Required: true,
+		Func: func(user, password string) bool {
+			return user == "external" && password == "token"
+		},
+	})
+	store.AddUser("local", "pass")
+
+	if !store.CheckPassword("external", "token") {
+		t.Error("credentials accepted by Func should pass")
+	}
+	if store.CheckPassword("external", "wrong") {
+		t.Error("credentials rejected by Func should fail")
+	}
+	if store.CheckPassword("local", "pass") {
+		t.Error("Func should take precedence over stored users")
+	}
+	if store.ToConfig().Func == nil {
+		t.Error("ToConfig() should preserve Func")
+	}
+}
+
 func TestAuthStore_ToConfig(t *testing.T) {
 	store := NewAuthStore()
 	store.SetAuthEnabled(true)
@@ -5,7 +5,12 @@ package bridge
 
 import (
 	"crypto/tls"
+	"fmt"
+	"io"
+	"net"
 	"time"
+
+	"github.com/go-i2p/go-sam-bridge/lib/util"
 )
 
 // Default configuration values per SAMv3.md specification.
@@ -67,6 +72,43 @@ type Config struct {
 
 	// Limits holds connection limits and buffer sizes.
 	Limits LimitConfig
+
+	// AuditLog receives one JSON record per processed command if non-nil.
+	// Passwords and private keys are redacted. See AuditLogger.
+	AuditLog io.Writer
+
+	// AccessLog receives one JSON record per SAM control connection, when
+	// it closes, if non-nil. See AccessLogger.
+	AccessLog io.Writer
+
+	// StrictQuoting parses commands on connections that negotiated SAM 3.2
+	// or later with protocol.Parser.Strict, rejecting quoting and escaping
+	// the specification does not allow. Older clients are always parsed
+	// leniently.
+	StrictQuoting bool
+
+	// ReplaceInvalidUTF8 replaces invalid UTF-8 in commands parsed
+	// leniently with U+FFFD instead of rejecting them. See
+	// protocol.Parser.ReplaceInvalidUTF8.
+	ReplaceInvalidUTF8 bool
+
+	// Trace, if set, is called with every command line received and reply
+	// line sent on client connections, with secrets redacted, for
+	// debugging clients. See TraceWriter. It may be called from several
+	// goroutines at once and must not block.
+	Trace func(TraceRecord)
+
+	// ConnState is called with StateNew when a client connection is
+	// accepted and with StateClosed when it closes, like http.Server.ConnState.
+	// It runs on the connection's goroutine and must not block.
+	ConnState func(conn net.Conn, state ConnectionState)
+
+	// OnPanic, if set, is called with every panic recovered on a client
+	// connection. A panicking handler gets RESULT=I2P_ERROR sent in its
+	// place and the connection stays open; a panic elsewhere in the
+	// connection loop closes only that connection. OnPanic runs on the
+	// connection's goroutine and must not block.
+	OnPanic func(PanicRecord)
 }
 
 // AuthConfig holds authentication settings per SAM 3.2.
@@ -77,7 +119,13 @@ type AuthConfig struct {
 
 	// Users maps usernames to passwords for authentication.
 	// Empty map with Required=false disables authentication.
+	// A password may be stored as a bcrypt or Argon2id hash, such as
+	// one made by HashPassword, instead of the plaintext.
 	Users map[string]string
+
+	// Func, if set, validates credentials instead of Users.
+	// This lets applications check an external identity store.
+	Func func(user, password string) bool
 }
 
 // TimeoutConfig holds timeout settings for connections.
@@ -90,9 +138,22 @@ type TimeoutConfig struct {
 	// Per SAM 3.2, servers may implement timeouts for subsequent commands.
 	Command time.Duration
 
-	// Idle is the maximum time a connection can be idle (0 = no limit).
+	// Idle is how long a connection that completed HELLO but has no
+	// session bound may go without sending a command before it is closed
+	// (0 = no limit). It frees the goroutines and file descriptors of
+	// clients that connect and never use the connection. Where Command is
+	// shorter, Command applies.
 	Idle time.Duration
 
+	// Write is the maximum time a write to a client may block (0 = no
+	// limit). A client that stops reading is disconnected after it.
+	Write time.Duration
+
+	// SessionIdle is how long a session may go without traffic before
+	// the bridge closes it and notifies its control socket (0 = no limit).
+	// A session's i2cp.closeIdleTime option takes precedence.
+	SessionIdle time.Duration
+
 	// PongTimeout is the maximum time to wait for PONG after sending PING.
 	// Per SAM 3.2, PING/PONG is used for keepalive.
 	// If a PONG is not received within this duration, the connection may be closed.
@@ -110,8 +171,37 @@ type LimitConfig struct {
 	// MaxConnections is the maximum number of concurrent connections (0 = no limit).
 	MaxConnections int
 
+	// MaxQueuedConnections is how many accepted connections may wait for
+	// one of the MaxConnections slots, for at most Timeouts.Handshake,
+	// before further connections are closed at once (0 = no queue).
+	MaxQueuedConnections int
+
+	// MaxConnectionsPerIP is the maximum number of concurrently handled
+	// connections from one remote IP address (0 = no limit). Further
+	// connections get a HELLO REPLY RESULT=I2P_ERROR line and are closed.
+	// Connections waiting for a MaxConnections slot do not count.
+	MaxConnectionsPerIP int
+
 	// MaxSessionsPerClient is the maximum sessions per client IP (0 = no limit).
 	MaxSessionsPerClient int
+
+	// MaxSessions is the maximum number of registered sessions (0 = no limit).
+	// Further SESSION CREATEs fail with RESULT=I2P_ERROR.
+	MaxSessions int
+
+	// MaxSessionsPerUser is the maximum number of sessions created by one
+	// authenticated SAM user (0 = no limit). It does not apply to
+	// unauthenticated connections.
+	MaxSessionsPerUser int
+
+	// StreamBufferSize is the size of the buffers STREAM CONNECT and
+	// STREAM ACCEPT data is copied through, per direction (0 =
+	// util.DefaultCopyBufferSize). Buffers are pooled across streams.
+	StreamBufferSize int
+
+	// Socket sets TCP keepalive, TCP_NODELAY and socket buffer sizes on
+	// accepted client connections. The zero value keeps Go's defaults.
+	Socket util.SocketOptions
 }
 
 // DefaultConfig returns a Config with default values per SAMv3.md.
@@ -157,12 +247,44 @@ func (c *Config) Validate() error {
 	if c.Timeouts.Command < 0 {
 		return &ConfigError{Field: "Timeouts.Command", Message: "cannot be negative"}
 	}
+	if c.Timeouts.Idle < 0 {
+		return &ConfigError{Field: "Timeouts.Idle", Message: "cannot be negative"}
+	}
+	if c.Timeouts.SessionIdle < 0 {
+		return &ConfigError{Field: "Timeouts.SessionIdle", Message: "cannot be negative"}
+	}
 	if c.Limits.ReadBufferSize <= 0 {
 		return &ConfigError{Field: "Limits.ReadBufferSize", Message: "must be positive"}
 	}
 	if c.Limits.MaxLineLength <= 0 {
 		return &ConfigError{Field: "Limits.MaxLineLength", Message: "must be positive"}
 	}
+	if c.Limits.MaxSessions < 0 {
+		return &ConfigError{Field: "Limits.MaxSessions", Message: "cannot be negative"}
+	}
+	if c.Limits.MaxSessionsPerUser < 0 {
+		return &ConfigError{Field: "Limits.MaxSessionsPerUser", Message: "cannot be negative"}
+	}
+	if c.Limits.MaxConnections < 0 {
+		return &ConfigError{Field: "Limits.MaxConnections", Message: "cannot be negative"}
+	}
+	if c.Limits.MaxQueuedConnections < 0 {
+		return &ConfigError{Field: "Limits.MaxQueuedConnections", Message: "cannot be negative"}
+	}
+	if c.Limits.MaxConnectionsPerIP < 0 {
+		return &ConfigError{Field: "Limits.MaxConnectionsPerIP", Message: "cannot be negative"}
+	}
+	if c.Limits.Socket.ReadBuffer < 0 || c.Limits.Socket.WriteBuffer < 0 {
+		return &ConfigError{Field: "Limits.Socket", Message: "buffer sizes cannot be negative"}
+	}
+	if c.Limits.StreamBufferSize < 0 {
+		return &ConfigError{Field: "Limits.StreamBufferSize", Message: "cannot be negative"}
+	}
+	for user, password := range c.Auth.Users {
+		if err := CheckPasswordHash(password); err != nil {
+			return &ConfigError{Field: "Auth.Users", Message: fmt.Sprintf("user %q: %v", user, err)}
+		}
+	}
 	return nil
 }
 
@@ -217,10 +339,15 @@ func (c *Config) HasUser(username string) bool {
 }
 
 // CheckPassword verifies the password for a user.
-// Returns true if the user exists and the password matches.
+// Returns true if the user exists and the password matches, in constant
+// time; see VerifyPassword for the stored forms accepted.
+// If Auth.Func is set, it decides instead of the Users map.
 func (c *Config) CheckPassword(username, password string) bool {
-	storedPassword, ok := c.Auth.Users[username]
-	return ok && storedPassword == password
+	if c.Auth.Func != nil {
+		return c.Auth.Func(username, password)
+	}
+	stored, ok := lookupPassword(c.Auth.Users, username)
+	return checkUserPassword(stored, ok, password)
 }
 
 // ConfigError represents a configuration validation error.
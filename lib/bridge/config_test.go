@@ -122,6 +122,24 @@ func TestConfig_Validate(t *testing.T) {
 			wantErr:   true,
 			wantField: "Limits.MaxLineLength",
 		},
+		{
+			name:      "negative max sessions",
+			modify:    func(c *Config) { c.Limits.MaxSessions = -1 },
+			wantErr:   true,
+			wantField: "Limits.MaxSessions",
+		},
+		{
+			name:      "negative max sessions per user",
+			modify:    func(c *Config) { c.Limits.MaxSessionsPerUser = -1 },
+			wantErr:   true,
+			wantField: "Limits.MaxSessionsPerUser",
+		},
+		{
+			name:      "malformed password hash",
+			modify:    func(c *Config) { c.Auth.Users["alice"] = "$argon2id$v=19$bad" },
+			wantErr:   true,
+			wantField: "Auth.Users",
+		},
 	}
 
 	for _, tt := range tests {
@@ -309,6 +327,21 @@ func TestConfig_CheckPassword_EmptyPassword(t *testing.T) {
 	}
 }
 
+func TestConfig_CheckPassword_AuthFunc(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Auth.Users = map[string]string{"local": "pass"}
+	cfg.Auth.Func = func(user, password string) bool {
+		return user == "external" && password == "token"
+	}
+
+	if !cfg.CheckPassword("external", "token") {
+		t.Error("credentials accepted by Func should pass")
+	}
+	if cfg.CheckPassword("local", "pass") {
+		t.Error("Func should take precedence over Users")
+	}
+}
+
 func TestConfigError_Error(t *testing.T) {
 	err := &ConfigError{
 		Field:   "TestField",
@@ -5,6 +5,8 @@ import (
 	"net"
 	"sync"
 	"time"
+
+	"github.com/go-i2p/go-sam-bridge/lib/protocol"
 )
 
 // ConnectionState represents the current state of a client connection.
@@ -57,6 +59,9 @@ type Connection struct {
 	// reader is the buffered reader for the connection.
 	reader *bufio.Reader
 
+	// writer buffers responses to the connection.
+	writer *protocol.ResponseWriter
+
 	// state is the current connection state.
 	state ConnectionState
 
@@ -102,6 +107,7 @@ func NewConnection(conn net.Conn, bufferSize int) *Connection {
 	return &Connection{
 		conn:         conn,
 		reader:       bufio.NewReaderSize(conn, bufferSize),
+		writer:       protocol.NewResponseWriter(conn, 0),
 		state:        StateNew,
 		createdAt:    now,
 		lastActivity: now,
@@ -123,6 +129,15 @@ func (c *Connection) Reader() *bufio.Reader {
 	return c.reader
 }
 
+// ResponseWriter returns the buffered response writer. Write and
+// WriteLine go through it too, so their output is never interleaved
+// with a buffered response.
+func (c *Connection) ResponseWriter() *protocol.ResponseWriter {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.writer
+}
+
 // State returns the current connection state.
 func (c *Connection) State() ConnectionState {
 	c.mu.RLock()
@@ -269,11 +284,16 @@ func (c *Connection) SetWriteDeadline(t time.Time) error {
 	return c.conn.SetWriteDeadline(t)
 }
 
-// Write writes data to the underlying connection.
+// Write writes data to the connection, after anything buffered in the
+// response writer.
 func (c *Connection) Write(data []byte) (int, error) {
 	c.mu.RLock()
 	defer c.mu.RUnlock()
-	return c.conn.Write(data)
+	n, err := c.writer.Write(data)
+	if err != nil {
+		return n, err
+	}
+	return n, c.writer.Flush()
 }
 
 // WriteString writes a string to the underlying connection.
@@ -284,7 +304,12 @@ func (c *Connection) WriteString(s string) (int, error) {
 // WriteLine writes a string with CRLF terminator to the connection.
 // Per SAM spec, responses are terminated with newline.
 func (c *Connection) WriteLine(s string) (int, error) {
-	return c.Write([]byte(s + "\n"))
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if err := c.writer.WriteLine(s); err != nil {
+		return 0, err
+	}
+	return len(s) + 1, c.writer.Flush()
 }
 
 // SetPendingPing records that a PING has been sent and is awaiting PONG.
@@ -0,0 +1,94 @@
+package bridge
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/go-i2p/go-sam-bridge/lib/protocol"
+	"github.com/go-i2p/go-sam-bridge/lib/session"
+)
+
+const (
+	// idleSweepInterval is how often the server checks sessions for idleness.
+	idleSweepInterval = time.Second
+
+	// idleNotifyTimeout bounds the write that tells a client its session
+	// timed out, so an unresponsive client cannot hold its session open.
+	idleNotifyTimeout = 5 * time.Second
+)
+
+// runIdleSweeper closes idle sessions every idleSweepInterval until the
+// server shuts down.
+func (s *Server) runIdleSweeper() {
+	ticker := time.NewTicker(idleSweepInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.done:
+			return
+		case now := <-ticker.C:
+			s.closeIdleSessions(now)
+		}
+	}
+}
+
+// closeIdleSessions closes every session whose idle timeout has elapsed
+// at now and returns their IDs.
+func (s *Server) closeIdleSessions(now time.Time) []string {
+	var closed []string
+	for _, id := range s.registry.All() {
+		sess := s.registry.Get(id)
+		if sess == nil {
+			continue
+		}
+		timeout := s.sessionIdleTimeout(sess)
+		if timeout <= 0 {
+			continue
+		}
+		last, ok := session.LastActivity(sess)
+		if !ok || now.Sub(last) < timeout {
+			continue
+		}
+		s.closeIdleSession(sess, timeout)
+		closed = append(closed, id)
+	}
+	return closed
+}
+
+// sessionIdleTimeout returns how long sess may go without traffic. The
+// session's own CloseIdleTime (i2cp.closeIdleTime in SESSION CREATE)
+// takes precedence over Timeouts.SessionIdle; zero disables the check.
+func (s *Server) sessionIdleTimeout(sess session.Session) time.Duration {
+	if cp, ok := sess.(interface{ Config() *session.SessionConfig }); ok {
+		if cfg := cp.Config(); cfg != nil && cfg.CloseIdleTime > 0 {
+			return time.Duration(cfg.CloseIdleTime) * time.Second
+		}
+	}
+	return s.config.Timeouts.SessionIdle
+}
+
+// closeIdleSession unregisters sess, then tells the bound control socket
+// why its session is going away and closes the session in the
+// background, so a client that has stopped reading cannot stall the
+// sweep of other sessions. Closing the session also closes the control
+// socket per SAMv3.md.
+func (s *Server) closeIdleSession(sess session.Session, timeout time.Duration) {
+	_ = s.registry.Unregister(sess.ID())
+
+	s.idleClosing.Add(1)
+	go func() {
+		defer s.idleClosing.Done()
+		if conn := sess.ControlConn(); conn != nil {
+			response := protocol.NewResponse(protocol.VerbSession).
+				WithAction(protocol.ActionStatus).
+				WithResult(protocol.ResultI2PError).
+				WithOption("ID", sess.ID()).
+				WithMessage(fmt.Sprintf("session closed after %s idle", timeout))
+			// Best effort - the session is closing anyway
+			_ = conn.SetWriteDeadline(time.Now().Add(idleNotifyTimeout))
+			_, _ = conn.Write([]byte(response.String()))
+		}
+		_ = sess.Close()
+	}()
+}
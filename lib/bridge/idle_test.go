@@ -0,0 +1,117 @@
+package bridge
+
+import (
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-i2p/go-sam-bridge/lib/session"
+)
+
+func TestServer_CloseIdleSessions(t *testing.T) {
+	config := DefaultConfig()
+	config.Timeouts.SessionIdle = time.Minute
+	registry := newMockRegistry()
+	server, err := NewServer(config, registry)
+	if err != nil {
+		t.Fatalf("NewServer() error = %v", err)
+	}
+
+	conn := newMockConn()
+	sess := session.NewBaseSession("idle-1", session.StyleStream, nil, conn, nil)
+	registry.Register(sess)
+
+	if closed := server.closeIdleSessions(time.Now().Add(30 * time.Second)); len(closed) != 0 {
+		t.Fatalf("closeIdleSessions() before timeout closed %v", closed)
+	}
+
+	closed := server.closeIdleSessions(time.Now().Add(2 * time.Minute))
+	if len(closed) != 1 || closed[0] != "idle-1" {
+		t.Fatalf("closeIdleSessions() = %v, want [idle-1]", closed)
+	}
+	if registry.Get("idle-1") != nil {
+		t.Error("idle session should be unregistered")
+	}
+	server.idleClosing.Wait()
+	if !sess.IsClosed() || !conn.closed {
+		t.Error("idle session and its control socket should be closed")
+	}
+	got := string(conn.writeData)
+	if !strings.HasPrefix(got, "SESSION STATUS RESULT=I2P_ERROR ID=idle-1 ") {
+		t.Errorf("control socket got %q, want SESSION STATUS I2P_ERROR for idle-1", got)
+	}
+}
+
+func TestServer_CloseIdleSessions_StalledClient(t *testing.T) {
+	config := DefaultConfig()
+	config.Timeouts.SessionIdle = time.Minute
+	registry := newMockRegistry()
+	server, err := NewServer(config, registry)
+	if err != nil {
+		t.Fatalf("NewServer() error = %v", err)
+	}
+
+	// Nothing reads the client end, so writes to the server end block
+	client, stalled := net.Pipe()
+	defer client.Close()
+	registry.Register(session.NewBaseSession("stalled", session.StyleStream, nil, stalled, nil))
+	conn := newMockConn()
+	other := session.NewBaseSession("other", session.StyleStream, nil, conn, nil)
+	registry.Register(other)
+
+	start := time.Now()
+	closed := server.closeIdleSessions(start.Add(2 * time.Minute))
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("closeIdleSessions() took %v with a stalled client, want it not to block", elapsed)
+	}
+	if len(closed) != 2 {
+		t.Errorf("closeIdleSessions() = %v, want both sessions", closed)
+	}
+
+	client.Close()
+	server.idleClosing.Wait()
+	if !other.IsClosed() || !conn.closed {
+		t.Error("session behind the stalled client should still be closed")
+	}
+}
+
+func TestServer_CloseIdleSessions_Activity(t *testing.T) {
+	config := DefaultConfig()
+	config.Timeouts.SessionIdle = time.Minute
+	registry := newMockRegistry()
+	server, err := NewServer(config, registry)
+	if err != nil {
+		t.Fatalf("NewServer() error = %v", err)
+	}
+
+	sess := session.NewBaseSession("busy", session.StyleStream, nil, newMockConn(), nil)
+	registry.Register(sess)
+	sess.Stats().AddBytesSent(1)
+
+	last, ok := session.LastActivity(sess)
+	if !ok {
+		t.Fatal("LastActivity() should report traffic")
+	}
+	if closed := server.closeIdleSessions(last.Add(59 * time.Second)); len(closed) != 0 {
+		t.Errorf("closeIdleSessions() closed %v within the timeout of the last traffic", closed)
+	}
+}
+
+func TestServer_CloseIdleSessions_SessionOption(t *testing.T) {
+	registry := newMockRegistry()
+	server, err := NewServer(DefaultConfig(), registry)
+	if err != nil {
+		t.Fatalf("NewServer() error = %v", err)
+	}
+
+	cfg := session.DefaultSessionConfig()
+	cfg.CloseIdleTime = 10
+	registry.Register(session.NewBaseSession("opt-in", session.StyleStream, nil, newMockConn(), cfg))
+	registry.Register(session.NewBaseSession("default", session.StyleStream, nil, newMockConn(), nil))
+
+	closed := server.closeIdleSessions(time.Now().Add(time.Hour))
+	if len(closed) != 1 || closed[0] != "opt-in" {
+		t.Errorf("closeIdleSessions() = %v, want only the session with i2cp.closeIdleTime", closed)
+	}
+}
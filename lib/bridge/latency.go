@@ -0,0 +1,102 @@
+package bridge
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// LatencyBuckets are the upper bounds of the command latency histogram
+// buckets. They reach past a minute because SESSION CREATE waits for
+// tunnels to build and NAMING LOOKUP for the network database.
+var LatencyBuckets = []time.Duration{
+	time.Millisecond,
+	5 * time.Millisecond,
+	10 * time.Millisecond,
+	25 * time.Millisecond,
+	50 * time.Millisecond,
+	100 * time.Millisecond,
+	250 * time.Millisecond,
+	500 * time.Millisecond,
+	time.Second,
+	2500 * time.Millisecond,
+	5 * time.Second,
+	10 * time.Second,
+	30 * time.Second,
+	time.Minute,
+	2 * time.Minute,
+}
+
+// CommandLatency is a histogram of the time one command took, from
+// reading its line to writing its response.
+type CommandLatency struct {
+	// Command is the router key the command was handled by, such as
+	// "SESSION CREATE" or "PING".
+	Command string
+
+	// Buckets holds, for each of LatencyBuckets, how many commands took
+	// at most that long. Counts are cumulative.
+	Buckets []uint64
+
+	// Count is the number of commands measured.
+	Count uint64
+
+	// Sum is the total time of the commands measured.
+	Sum time.Duration
+}
+
+// commandLatencies records a latency histogram per command.
+type commandLatencies struct {
+	mu        sync.Mutex
+	byCommand map[string]*latencyHistogram
+}
+
+// latencyHistogram counts observations per bucket; the last count is for
+// observations above every bucket.
+type latencyHistogram struct {
+	counts []uint64
+	sum    time.Duration
+}
+
+// newCommandLatencies creates an empty set of histograms.
+func newCommandLatencies() *commandLatencies {
+	return &commandLatencies{byCommand: make(map[string]*latencyHistogram)}
+}
+
+// observe records that command took d.
+func (l *commandLatencies) observe(command string, d time.Duration) {
+	i := sort.Search(len(LatencyBuckets), func(i int) bool { return d <= LatencyBuckets[i] })
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	h := l.byCommand[command]
+	if h == nil {
+		h = &latencyHistogram{counts: make([]uint64, len(LatencyBuckets)+1)}
+		l.byCommand[command] = h
+	}
+	h.counts[i]++
+	h.sum += d
+}
+
+// snapshot returns the histograms sorted by command.
+func (l *commandLatencies) snapshot() []CommandLatency {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	latencies := make([]CommandLatency, 0, len(l.byCommand))
+	for command, h := range l.byCommand {
+		lat := CommandLatency{
+			Command: command,
+			Buckets: make([]uint64, len(LatencyBuckets)),
+			Sum:     h.sum,
+		}
+		for i, n := range h.counts {
+			lat.Count += n
+			if i < len(LatencyBuckets) {
+				lat.Buckets[i] = lat.Count
+			}
+		}
+		latencies = append(latencies, lat)
+	}
+	sort.Slice(latencies, func(i, j int) bool { return latencies[i].Command < latencies[j].Command })
+	return latencies
+}
@@ -0,0 +1,44 @@
+package bridge
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCommandLatencies(t *testing.T) {
+	l := newCommandLatencies()
+	l.observe("PING", 500*time.Microsecond)
+	l.observe("PING", time.Millisecond) // bucket bounds are inclusive
+	l.observe("SESSION CREATE", 45*time.Second)
+	l.observe("SESSION CREATE", 10*time.Minute) // above every bucket
+
+	got := l.snapshot()
+	if len(got) != 2 || got[0].Command != "PING" || got[1].Command != "SESSION CREATE" {
+		t.Fatalf("snapshot() = %+v, want PING then SESSION CREATE", got)
+	}
+
+	ping := got[0]
+	if ping.Count != 2 || ping.Sum != 1500*time.Microsecond {
+		t.Errorf("PING count = %d, sum = %v; want 2, 1.5ms", ping.Count, ping.Sum)
+	}
+	for i, n := range ping.Buckets {
+		if n != 2 {
+			t.Errorf("PING bucket %v = %d, want 2", LatencyBuckets[i], n)
+		}
+	}
+
+	create := got[1]
+	if create.Count != 2 {
+		t.Errorf("SESSION CREATE count = %d, want 2", create.Count)
+	}
+	// 45s is first counted by the one-minute bucket; 10m by none.
+	for i, n := range create.Buckets {
+		want := uint64(0)
+		if LatencyBuckets[i] >= time.Minute {
+			want = 1
+		}
+		if n != want {
+			t.Errorf("SESSION CREATE bucket %v = %d, want %d", LatencyBuckets[i], n, want)
+		}
+	}
+}
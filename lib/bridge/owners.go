@@ -0,0 +1,153 @@
+package bridge
+
+import (
+	"sync"
+
+	"github.com/go-i2p/go-sam-bridge/lib/session"
+	"github.com/go-i2p/go-sam-bridge/lib/util"
+)
+
+// sessionOwners records which authenticated user created each session.
+// It is used to count sessions per user and, while authentication is
+// enabled, to hide a user's sessions from other users' connections.
+type sessionOwners struct {
+	mu sync.Mutex
+
+	// owners maps session IDs to their creator. Entries whose session is
+	// no longer registered are pruned lazily.
+	owners map[string]sessionOwner
+}
+
+// sessionOwner is the creator of one registered session.
+type sessionOwner struct {
+	user string
+
+	// sess is the session the user created. A later session registered
+	// under the same ID does not inherit the owner.
+	sess session.Session
+}
+
+// newSessionOwners creates an empty sessionOwners.
+func newSessionOwners() *sessionOwners {
+	return &sessionOwners{owners: make(map[string]sessionOwner)}
+}
+
+// set records user as the creator of sess. It is a no-op for
+// unauthenticated connections, whose sessions have no owner.
+func (o *sessionOwners) set(sess session.Session, user string) {
+	if sess == nil || user == "" {
+		return
+	}
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.owners[sess.ID()] = sessionOwner{user: user, sess: sess}
+}
+
+// owner returns the user who created the session registered as id, or
+// the empty string if it has no owner.
+func (o *sessionOwners) owner(registry session.Registry, id string) string {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if !o.currentLocked(registry, id) {
+		return ""
+	}
+	return o.owners[id].user
+}
+
+// count returns the number of registered sessions created by user.
+func (o *sessionOwners) count(registry session.Registry, user string) int {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	n := 0
+	for id, owner := range o.owners {
+		if o.currentLocked(registry, id) && owner.user == user {
+			n++
+		}
+	}
+	return n
+}
+
+// currentLocked reports whether the entry for id still describes the
+// session registered under id, deleting it if not. Callers must hold o.mu.
+func (o *sessionOwners) currentLocked(registry session.Registry, id string) bool {
+	owner, ok := o.owners[id]
+	if !ok {
+		return false
+	}
+	if registry.Get(id) != owner.sess {
+		delete(o.owners, id)
+		return false
+	}
+	return true
+}
+
+// userRegistry is the session registry as seen by handlers on a
+// connection authenticated as user. While authentication is enabled,
+// sessions created by other users are hidden, so their IDs cannot be used
+// in STREAM, DATAGRAM, RAW or SESSION commands. Sessions without an owner
+// remain visible to everyone.
+type userRegistry struct {
+	session.Registry
+	owners *sessionOwners
+	auth   *AuthStore
+	user   string
+}
+
+// visible reports whether the session registered as id may be used by
+// r.user.
+func (r *userRegistry) visible(id string) bool {
+	if !r.auth.IsAuthEnabled() {
+		return true
+	}
+	owner := r.owners.owner(r.Registry, id)
+	return owner == "" || owner == r.user
+}
+
+// filter returns sess if r.user may use it, nil otherwise.
+func (r *userRegistry) filter(sess session.Session) session.Session {
+	if sess == nil || !r.visible(sess.ID()) {
+		return nil
+	}
+	return sess
+}
+
+// Get implements session.Registry.
+func (r *userRegistry) Get(id string) session.Session {
+	return r.filter(r.Registry.Get(id))
+}
+
+// Unregister implements session.Registry. Sessions hidden from r.user
+// are reported as not found.
+func (r *userRegistry) Unregister(id string) error {
+	if !r.visible(id) {
+		return util.ErrSessionNotFound
+	}
+	return r.Registry.Unregister(id)
+}
+
+// GetByDestination implements session.Registry.
+func (r *userRegistry) GetByDestination(destHash string) session.Session {
+	return r.filter(r.Registry.GetByDestination(destHash))
+}
+
+// MostRecentByStyle implements session.Registry.
+func (r *userRegistry) MostRecentByStyle(style session.Style) session.Session {
+	return r.filter(r.Registry.MostRecentByStyle(style))
+}
+
+// All implements session.Registry.
+func (r *userRegistry) All() []string {
+	all := r.Registry.All()
+	ids := make([]string, 0, len(all))
+	for _, id := range all {
+		if r.visible(id) {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// Count implements session.Registry.
+func (r *userRegistry) Count() int {
+	return len(r.All())
+}
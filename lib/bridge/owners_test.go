@@ -0,0 +1,153 @@
+package bridge
+
+import (
+	"bufio"
+	"net"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/go-i2p/go-sam-bridge/lib/handler"
+	"github.com/go-i2p/go-sam-bridge/lib/protocol"
+)
+
+func TestSessionOwners(t *testing.T) {
+	registry := newMockRegistry()
+	owners := newSessionOwners()
+
+	s1 := &mockSession{id: "s1"}
+	registry.Register(s1)
+	owners.set(s1, "alice")
+	owners.set(&mockSession{id: "anon"}, "")
+
+	if got := owners.owner(registry, "s1"); got != "alice" {
+		t.Errorf("owner(s1) = %q, want alice", got)
+	}
+	if got := owners.owner(registry, "anon"); got != "" {
+		t.Errorf("owner(anon) = %q, want empty", got)
+	}
+	if got := owners.count(registry, "alice"); got != 1 {
+		t.Errorf("count(alice) = %d, want 1", got)
+	}
+
+	// A new session under the same ID does not inherit the owner.
+	registry.Unregister("s1")
+	registry.Register(&mockSession{id: "s1"})
+	if got := owners.owner(registry, "s1"); got != "" {
+		t.Errorf("owner(s1) after re-register = %q, want empty", got)
+	}
+	if got := owners.count(registry, "alice"); got != 0 {
+		t.Errorf("count(alice) after re-register = %d, want 0", got)
+	}
+}
+
+func TestUserRegistry(t *testing.T) {
+	registry := newMockRegistry()
+	owners := newSessionOwners()
+	auth := NewAuthStoreFromConfig(AuthConfig{Required: true})
+
+	for id, user := range map[string]string{"a1": "alice", "b1": "bob", "anon": ""} {
+		sess := &mockSession{id: id}
+		registry.Register(sess)
+		owners.set(sess, user)
+	}
+
+	bob := &userRegistry{Registry: registry, owners: owners, auth: auth, user: "bob"}
+	if bob.Get("a1") != nil {
+		t.Error("Get(a1) should hide alice's session from bob")
+	}
+	if bob.Get("b1") == nil || bob.Get("anon") == nil {
+		t.Error("Get() should return bob's and unowned sessions")
+	}
+	ids := bob.All()
+	sort.Strings(ids)
+	if want := []string{"anon", "b1"}; !reflect.DeepEqual(ids, want) {
+		t.Errorf("All() = %v, want %v", ids, want)
+	}
+	if got := bob.Count(); got != 2 {
+		t.Errorf("Count() = %d, want 2", got)
+	}
+	if err := bob.Unregister("a1"); err == nil {
+		t.Error("Unregister(a1) should fail for bob")
+	}
+	if registry.Get("a1") == nil {
+		t.Error("alice's session should still be registered")
+	}
+
+	auth.SetAuthEnabled(false)
+	if bob.Get("a1") == nil {
+		t.Error("Get(a1) should be visible while authentication is disabled")
+	}
+}
+
+func TestServer_SessionOwnership(t *testing.T) {
+	registry := newMockRegistry()
+	config := DefaultConfig()
+	config.Auth.Required = true
+	config.Auth.Users = map[string]string{"alice": "secret", "bob": "secret"}
+
+	server, err := NewServer(config, registry)
+	if err != nil {
+		t.Fatalf("NewServer() error = %v", err)
+	}
+	server.Router().RegisterFunc("HELLO", func(ctx *handler.Context, cmd *protocol.Command) (*protocol.Response, error) {
+		return protocol.NewResponse("HELLO").WithAction("REPLY").WithResult("OK").WithVersion("3.3"), nil
+	})
+	server.Router().RegisterFunc("SESSION CREATE", func(ctx *handler.Context, cmd *protocol.Command) (*protocol.Response, error) {
+		sess := &mockSession{id: cmd.Get("ID")}
+		ctx.Registry.Register(sess)
+		ctx.BindSession(sess)
+		return protocol.NewResponse("SESSION").WithAction("STATUS").WithResult("OK"), nil
+	})
+	server.Router().RegisterFunc("STREAM CONNECT", func(ctx *handler.Context, cmd *protocol.Command) (*protocol.Response, error) {
+		if ctx.Registry.Get(cmd.Get("ID")) == nil {
+			return protocol.NewResponse("STREAM").WithAction("STATUS").WithResult("INVALID_ID"), nil
+		}
+		return protocol.NewResponse("STREAM").WithAction("STATUS").WithResult("OK"), nil
+	})
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("net.Listen() error = %v", err)
+	}
+	go server.Serve(listener)
+	defer server.Close()
+
+	dial := func(user string) (net.Conn, *bufio.Reader) {
+		t.Helper()
+		conn, err := net.Dial("tcp", listener.Addr().String())
+		if err != nil {
+			t.Fatalf("net.Dial() error = %v", err)
+		}
+		t.Cleanup(func() { conn.Close() })
+		reader := bufio.NewReader(conn)
+		conn.Write([]byte("HELLO VERSION MIN=3.0 MAX=3.3 USER=" + user + " PASSWORD=secret\n"))
+		reader.ReadString('\n')
+		return conn, reader
+	}
+	send := func(conn net.Conn, reader *bufio.Reader, line string) string {
+		t.Helper()
+		conn.Write([]byte(line + "\n"))
+		resp, err := reader.ReadString('\n')
+		if err != nil {
+			t.Fatalf("ReadString() error = %v", err)
+		}
+		return resp
+	}
+
+	alice, aliceReader := dial("alice")
+	if resp := send(alice, aliceReader, "SESSION CREATE STYLE=STREAM ID=s1 DESTINATION=TRANSIENT"); !strings.Contains(resp, "RESULT=OK") {
+		t.Fatalf("SESSION CREATE = %q, want RESULT=OK", resp)
+	}
+
+	alice2, alice2Reader := dial("alice")
+	if resp := send(alice2, alice2Reader, "STREAM CONNECT ID=s1 DESTINATION=peer"); !strings.Contains(resp, "RESULT=OK") {
+		t.Errorf("alice STREAM CONNECT = %q, want RESULT=OK", resp)
+	}
+
+	bob, bobReader := dial("bob")
+	if resp := send(bob, bobReader, "STREAM CONNECT ID=s1 DESTINATION=peer"); !strings.Contains(resp, "RESULT=INVALID_ID") {
+		t.Errorf("bob STREAM CONNECT = %q, want RESULT=INVALID_ID", resp)
+	}
+}
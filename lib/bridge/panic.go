@@ -0,0 +1,97 @@
+package bridge
+
+import (
+	"runtime/debug"
+	"strings"
+	"time"
+
+	"github.com/go-i2p/go-sam-bridge/lib/handler"
+	"github.com/go-i2p/go-sam-bridge/lib/protocol"
+)
+
+// panicMessage is the MESSAGE of the reply to a command whose handler
+// panicked. The panic value is not sent to the client.
+const panicMessage = "internal error"
+
+// PanicRecord describes a panic recovered while serving a client
+// connection.
+type PanicRecord struct {
+	Time       time.Time
+	RemoteAddr string
+
+	// User and SessionID are the connection's authenticated user and
+	// bound session, if any.
+	User      string
+	SessionID string
+
+	// Command is the verb and action of the command whose handler
+	// panicked, such as "SESSION CREATE", or empty if the panic happened
+	// in the connection loop outside any handler.
+	Command string
+
+	// Value is the value passed to panic.
+	Value any
+
+	// Stack is the stack trace of the panicking goroutine.
+	Stack []byte
+}
+
+// handle calls h, turning a panic into an I2P_ERROR reply so that a
+// faulty handler cannot take down the connection or the process.
+func (s *Server) handle(h handler.Handler, ctx *handler.Context, c *Connection, cmd *protocol.Command) (resp *protocol.Response, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			s.reportPanic(c, commandName(cmd), r)
+			resp, err = panicResponse(cmd), nil
+		}
+	}()
+	return h.Handle(ctx, cmd)
+}
+
+// recoverConnection recovers a panic in c's command loop and reports
+// it. It must be deferred by the goroutine serving c, which then closes c.
+func (s *Server) recoverConnection(c *Connection) {
+	if r := recover(); r != nil {
+		s.reportPanic(c, "", r)
+	}
+}
+
+// reportPanic passes a recovered panic to Config.OnPanic, if set. It
+// must be called from the deferred function that recovered the panic,
+// so that the stack trace shows where it happened.
+func (s *Server) reportPanic(c *Connection, command string, value any) {
+	if s.config.OnPanic == nil {
+		return
+	}
+	s.config.OnPanic(PanicRecord{
+		Time:       time.Now(),
+		RemoteAddr: c.RemoteAddr(),
+		User:       c.Username(),
+		SessionID:  c.SessionID(),
+		Command:    command,
+		Value:      value,
+		Stack:      debug.Stack(),
+	})
+}
+
+// commandName returns cmd's verb and action, upper-cased.
+func commandName(cmd *protocol.Command) string {
+	if cmd.Action == "" {
+		return strings.ToUpper(cmd.Verb)
+	}
+	return strings.ToUpper(cmd.Verb + " " + cmd.Action)
+}
+
+// panicResponse returns the I2P_ERROR reply to cmd after its handler
+// panicked, with the reply action clients expect for cmd's verb.
+func panicResponse(cmd *protocol.Command) *protocol.Response {
+	verb := strings.ToUpper(cmd.Verb)
+	resp := protocol.NewResponse(verb)
+	switch verb {
+	case protocol.VerbHello, protocol.VerbDest, protocol.VerbNaming:
+		resp = resp.WithAction(protocol.ActionReply)
+	case protocol.VerbSession, protocol.VerbStream, protocol.VerbDatagram, protocol.VerbRaw:
+		resp = resp.WithAction(protocol.ActionStatus)
+	}
+	return resp.WithResult(protocol.ResultI2PError).WithMessage(panicMessage)
+}
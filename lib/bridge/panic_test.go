@@ -0,0 +1,89 @@
+package bridge
+
+import (
+	"bufio"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-i2p/go-sam-bridge/lib/handler"
+	"github.com/go-i2p/go-sam-bridge/lib/protocol"
+)
+
+func TestServer_HandlerPanic(t *testing.T) {
+	config := DefaultConfig()
+	panics := make(chan PanicRecord, 1)
+	config.OnPanic = func(rec PanicRecord) { panics <- rec }
+
+	server, err := NewServer(config, newMockRegistry())
+	if err != nil {
+		t.Fatalf("NewServer() error = %v", err)
+	}
+	server.Router().RegisterFunc("HELLO", func(ctx *handler.Context, cmd *protocol.Command) (*protocol.Response, error) {
+		return protocol.NewResponse("HELLO").WithAction("REPLY").WithResult("OK").WithVersion("3.3"), nil
+	})
+	server.Router().RegisterFunc("NAMING LOOKUP", func(ctx *handler.Context, cmd *protocol.Command) (*protocol.Response, error) {
+		panic("boom")
+	})
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("net.Listen() error = %v", err)
+	}
+	go server.Serve(listener)
+	defer server.Close()
+
+	conn, err := net.Dial("tcp", listener.Addr().String())
+	if err != nil {
+		t.Fatalf("net.Dial() error = %v", err)
+	}
+	defer conn.Close()
+	reader := bufio.NewReader(conn)
+
+	conn.Write([]byte("HELLO VERSION\nNAMING LOOKUP NAME=ME\n"))
+	reader.ReadString('\n')
+	line, err := reader.ReadString('\n')
+	if err != nil {
+		t.Fatalf("ReadString() error = %v", err)
+	}
+	if !strings.HasPrefix(line, "NAMING REPLY RESULT=I2P_ERROR") {
+		t.Errorf("response = %q, want NAMING REPLY RESULT=I2P_ERROR", line)
+	}
+	if strings.Contains(line, "boom") {
+		t.Errorf("response %q leaks the panic value", line)
+	}
+
+	select {
+	case rec := <-panics:
+		if rec.Command != "NAMING LOOKUP" || rec.Value != "boom" || len(rec.Stack) == 0 {
+			t.Errorf("PanicRecord = %+v", rec)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("OnPanic not called")
+	}
+
+	// The connection survives the panic.
+	conn.Write([]byte("HELLO VERSION\n"))
+	if line, err := reader.ReadString('\n'); err != nil || !strings.Contains(line, "RESULT=OK") {
+		t.Errorf("after panic: response = %q, err = %v", line, err)
+	}
+}
+
+func TestPanicResponse(t *testing.T) {
+	tests := []struct {
+		verb string
+		want string
+	}{
+		{"HELLO", "HELLO REPLY RESULT=I2P_ERROR"},
+		{"session", "SESSION STATUS RESULT=I2P_ERROR"},
+		{"DEST", "DEST REPLY RESULT=I2P_ERROR"},
+		{"PING", "PING RESULT=I2P_ERROR"},
+	}
+	for _, tt := range tests {
+		got := panicResponse(&protocol.Command{Verb: tt.verb}).String()
+		if !strings.HasPrefix(got, tt.want) {
+			t.Errorf("panicResponse(%s) = %q, want prefix %q", tt.verb, got, tt.want)
+		}
+	}
+}
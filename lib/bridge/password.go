@@ -0,0 +1,168 @@
+package bridge
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Argon2id parameters used by HashPassword, following the OWASP
+// recommendation of 19 MiB of memory, two passes and one thread.
+const (
+	argon2Memory  = 19 * 1024
+	argon2Time    = 2
+	argon2Threads = 1
+	argon2SaltLen = 16
+	argon2KeyLen  = 32
+)
+
+// argon2Prefix starts an encoded Argon2id hash.
+const argon2Prefix = "$argon2id$"
+
+// maxArgon2Concurrency bounds how many Argon2id hashes are computed at
+// once. Each takes its memory parameter in KiB (19 MiB by default), so
+// without a bound a flood of logins could exhaust memory.
+const maxArgon2Concurrency = 4
+
+// argon2Slots holds a token for each Argon2id computation in progress.
+var argon2Slots = make(chan struct{}, maxArgon2Concurrency)
+
+// ErrInvalidPasswordHash is returned when a stored password looks like a
+// bcrypt or Argon2id hash but cannot be decoded.
+var ErrInvalidPasswordHash = errors.New("invalid password hash")
+
+// HashPassword returns an Argon2id hash of password in the PHC string
+// format, e.g. "$argon2id$v=19$m=19456,t=2,p=1$salt$hash", for storing
+// in AuthConfig.Users instead of the plaintext.
+func HashPassword(password string) (string, error) {
+	salt := make([]byte, argon2SaltLen)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+	key := argon2IDKey([]byte(password), salt, argon2Time, argon2Memory, argon2Threads, argon2KeyLen)
+	return fmt.Sprintf("%sv=%d$m=%d,t=%d,p=%d$%s$%s", argon2Prefix, argon2.Version,
+		argon2Memory, argon2Time, argon2Threads,
+		base64.RawStdEncoding.EncodeToString(salt), base64.RawStdEncoding.EncodeToString(key)), nil
+}
+
+// IsPasswordHash reports whether stored is a bcrypt ("$2a$", "$2b$" or
+// "$2y$") or Argon2id ("$argon2id$") hash rather than a plaintext password.
+func IsPasswordHash(stored string) bool {
+	return isBcryptHash(stored) || strings.HasPrefix(stored, argon2Prefix)
+}
+
+// CheckPasswordHash returns ErrInvalidPasswordHash if stored is a hash
+// that cannot be decoded, so that configuration errors surface at
+// startup rather than as failed logins. Plaintext passwords pass.
+func CheckPasswordHash(stored string) error {
+	switch {
+	case isBcryptHash(stored):
+		if _, err := bcrypt.Cost([]byte(stored)); err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidPasswordHash, err)
+		}
+	case strings.HasPrefix(stored, argon2Prefix):
+		if _, err := decodeArgon2(stored); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// VerifyPassword reports whether password matches stored, which is a
+// bcrypt or Argon2id hash, or otherwise a plaintext password. Every
+// comparison takes time independent of where the inputs differ.
+func VerifyPassword(stored, password string) bool {
+	switch {
+	case isBcryptHash(stored):
+		return bcrypt.CompareHashAndPassword([]byte(stored), []byte(password)) == nil
+	case strings.HasPrefix(stored, argon2Prefix):
+		h, err := decodeArgon2(stored)
+		if err != nil {
+			return false
+		}
+		key := argon2IDKey([]byte(password), h.salt, h.time, h.memory, h.threads, uint32(len(h.key)))
+		return subtle.ConstantTimeCompare(key, h.key) == 1
+	default:
+		// Comparing digests hides the stored password's length too.
+		a, b := sha256.Sum256([]byte(stored)), sha256.Sum256([]byte(password))
+		return subtle.ConstantTimeCompare(a[:], b[:]) == 1
+	}
+}
+
+// lookupPassword returns the stored password of user from users, which
+// maps usernames to stored passwords, and whether user exists. For an
+// unknown user it returns another user's stored password as a decoy for
+// checkUserPassword.
+func lookupPassword(users map[string]string, user string) (string, bool) {
+	if stored, ok := users[user]; ok {
+		return stored, true
+	}
+	for _, decoy := range users {
+		return decoy, false
+	}
+	return "", false
+}
+
+// checkUserPassword verifies password against stored, as returned by
+// lookupPassword. An unknown user's password is still verified against
+// the decoy, and rejected, so that the work done, cheap for plaintext
+// and costly for hashes, does not reveal which users exist.
+func checkUserPassword(stored string, ok bool, password string) bool {
+	match := VerifyPassword(stored, password)
+	return ok && match
+}
+
+// argon2IDKey is argon2.IDKey run while holding one of argon2Slots.
+func argon2IDKey(password, salt []byte, time, memory uint32, threads uint8, keyLen uint32) []byte {
+	argon2Slots <- struct{}{}
+	defer func() { <-argon2Slots }()
+	return argon2.IDKey(password, salt, time, memory, threads, keyLen)
+}
+
+// isBcryptHash reports whether stored has a bcrypt prefix.
+func isBcryptHash(stored string) bool {
+	return strings.HasPrefix(stored, "$2a$") || strings.HasPrefix(stored, "$2b$") || strings.HasPrefix(stored, "$2y$")
+}
+
+// argon2Hash is a decoded Argon2id hash.
+type argon2Hash struct {
+	memory  uint32
+	time    uint32
+	threads uint8
+	salt    []byte
+	key     []byte
+}
+
+// decodeArgon2 decodes a PHC string "$argon2id$v=19$m=M,t=T,p=P$salt$key".
+func decodeArgon2(stored string) (*argon2Hash, error) {
+	parts := strings.Split(stored, "$")
+	if len(parts) != 6 {
+		return nil, fmt.Errorf("%w: expected 6 fields", ErrInvalidPasswordHash)
+	}
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return nil, fmt.Errorf("%w: unsupported version %q", ErrInvalidPasswordHash, parts[2])
+	}
+	h := &argon2Hash{}
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &h.memory, &h.time, &h.threads); err != nil {
+		return nil, fmt.Errorf("%w: parameters %q", ErrInvalidPasswordHash, parts[3])
+	}
+	if h.memory == 0 || h.time == 0 || h.threads == 0 {
+		return nil, fmt.Errorf("%w: parameters %q", ErrInvalidPasswordHash, parts[3])
+	}
+	var err error
+	if h.salt, err = base64.RawStdEncoding.DecodeString(parts[4]); err != nil {
+		return nil, fmt.Errorf("%w: salt: %v", ErrInvalidPasswordHash, err)
+	}
+	if h.key, err = base64.RawStdEncoding.DecodeString(parts[5]); err != nil || len(h.key) == 0 {
+		return nil, fmt.Errorf("%w: key", ErrInvalidPasswordHash)
+	}
+	return h, nil
+}
@@ -0,0 +1,156 @@
+package bridge
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestHashPassword(t *testing.T) {
+	hash, err := HashPassword("secret")
+	if err != nil {
+		t.Fatalf("HashPassword() error = %v", err)
+	}
+	if !strings.HasPrefix(hash, "$argon2id$v=19$m=19456,t=2,p=1$") || !IsPasswordHash(hash) {
+		t.Errorf("HashPassword() = %q, want an Argon2id PHC string", hash)
+	}
+	if err := CheckPasswordHash(hash); err != nil {
+		t.Errorf("CheckPasswordHash() error = %v", err)
+	}
+	if other, _ := HashPassword("secret"); other == hash {
+		t.Error("HashPassword() returned the same hash twice; salt not random")
+	}
+}
+
+func TestVerifyPassword(t *testing.T) {
+	argon, err := HashPassword("secret")
+	if err != nil {
+		t.Fatalf("HashPassword() error = %v", err)
+	}
+	raw, err := bcrypt.GenerateFromPassword([]byte("secret"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatalf("bcrypt.GenerateFromPassword() error = %v", err)
+	}
+	bcryptHash := string(raw)
+
+	tests := []struct {
+		name     string
+		stored   string
+		password string
+		want     bool
+	}{
+		{"plaintext match", "secret", "secret", true},
+		{"plaintext mismatch", "secret", "secreT", false},
+		{"plaintext prefix", "secret", "secre", false},
+		{"argon2id match", argon, "secret", true},
+		{"argon2id mismatch", argon, "wrong", false},
+		{"argon2id is not its own password", argon, argon, false},
+		{"bcrypt match", bcryptHash, "secret", true},
+		{"bcrypt mismatch", bcryptHash, "wrong", false},
+		{"corrupt argon2id", "$argon2id$v=19$m=0,t=2,p=1$c2FsdA$a2V5", "secret", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := VerifyPassword(tt.stored, tt.password); got != tt.want {
+				t.Errorf("VerifyPassword() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckPasswordHash(t *testing.T) {
+	tests := []struct {
+		name    string
+		stored  string
+		wantErr bool
+	}{
+		{"plaintext", "secret", false},
+		{"plaintext with dollar", "$ecret", false},
+		{"argon2id", "$argon2id$v=19$m=19456,t=2,p=1$c2FsdHNhbHRzYWx0$a2V5a2V5a2V5", false},
+		{"argon2id missing key", "$argon2id$v=19$m=19456,t=2,p=1$c2FsdA", true},
+		{"argon2id wrong version", "$argon2id$v=16$m=19456,t=2,p=1$c2FsdA$a2V5", true},
+		{"argon2id bad parameters", "$argon2id$v=19$m=x$c2FsdA$a2V5", true},
+		{"argon2id bad base64", "$argon2id$v=19$m=19456,t=2,p=1$!!!$a2V5", true},
+		{"bcrypt truncated", "$2a$10$short", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := CheckPasswordHash(tt.stored)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("CheckPasswordHash() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, ErrInvalidPasswordHash) {
+				t.Errorf("CheckPasswordHash() error = %v, want ErrInvalidPasswordHash", err)
+			}
+		})
+	}
+}
+
+func TestAuthStore_CheckPassword_Hashed(t *testing.T) {
+	hash, err := HashPassword("secret")
+	if err != nil {
+		t.Fatalf("HashPassword() error = %v", err)
+	}
+	store := NewAuthStoreFromConfig(AuthConfig{Required: true, Users: map[string]string{"alice": hash}})
+	cfg := &Config{Auth: AuthConfig{Users: map[string]string{"alice": hash}}}
+
+	for name, check := range map[string]func(user, password string) bool{
+		"AuthStore": store.CheckPassword,
+		"Config":    cfg.CheckPassword,
+	} {
+		if !check("alice", "secret") {
+			t.Errorf("%s.CheckPassword(alice, secret) = false, want true", name)
+		}
+		if check("alice", "wrong") || check("alice", hash) || check("bob", "secret") {
+			t.Errorf("%s.CheckPassword accepted wrong credentials", name)
+		}
+	}
+}
+
+func TestLookupPassword(t *testing.T) {
+	users := map[string]string{"alice": "secret"}
+	if stored, ok := lookupPassword(users, "alice"); stored != "secret" || !ok {
+		t.Errorf("lookupPassword(alice) = %q, %v, want secret, true", stored, ok)
+	}
+	// An unknown user gets another user's password, so checking it costs
+	// the same as for a user in the same stored form.
+	if stored, ok := lookupPassword(users, "mallory"); stored != "secret" || ok {
+		t.Errorf("lookupPassword(mallory) = %q, %v, want secret, false", stored, ok)
+	}
+	if stored, ok := lookupPassword(nil, "mallory"); stored != "" || ok {
+		t.Errorf("lookupPassword(nil, mallory) = %q, %v, want empty, false", stored, ok)
+	}
+	if checkUserPassword("secret", false, "secret") {
+		t.Error("checkUserPassword() accepted the decoy password of an unknown user")
+	}
+}
+
+func TestVerifyPassword_Argon2Concurrency(t *testing.T) {
+	hash, err := HashPassword("secret")
+	if err != nil {
+		t.Fatalf("HashPassword() error = %v", err)
+	}
+
+	// With every slot taken, verification waits for one to free up.
+	for i := 0; i < maxArgon2Concurrency; i++ {
+		argon2Slots <- struct{}{}
+	}
+	done := make(chan bool)
+	go func() { done <- VerifyPassword(hash, "secret") }()
+	select {
+	case <-done:
+		t.Fatal("VerifyPassword() ran with no free Argon2id slot")
+	case <-time.After(50 * time.Millisecond):
+	}
+	for i := 0; i < maxArgon2Concurrency; i++ {
+		<-argon2Slots
+	}
+	if !<-done {
+		t.Error("VerifyPassword() = false after a slot freed, want true")
+	}
+}
@@ -0,0 +1,72 @@
+package bridge
+
+import (
+	"fmt"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/go-i2p/go-sam-bridge/lib/protocol"
+)
+
+// perIPRejectTimeout bounds how long writing the rejection line to a
+// connection over Limits.MaxConnectionsPerIP may block.
+const perIPRejectTimeout = 5 * time.Second
+
+// ipConns counts open connections per remote IP address to enforce
+// Limits.MaxConnectionsPerIP.
+type ipConns struct {
+	mu   sync.Mutex
+	open map[string]int
+}
+
+// newIPConns creates an empty ipConns.
+func newIPConns() *ipConns {
+	return &ipConns{open: make(map[string]int)}
+}
+
+// acquire counts a connection from ip and returns true, or returns false
+// without counting it if max connections from ip are already open.
+func (n *ipConns) acquire(ip string, max int) bool {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.open[ip] >= max {
+		return false
+	}
+	n.open[ip]++
+	return true
+}
+
+// release uncounts a connection counted by acquire.
+func (n *ipConns) release(ip string) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.open[ip]--; n.open[ip] <= 0 {
+		delete(n.open, ip)
+	}
+}
+
+// remoteIP returns the IP address conn's peer connects from, or its
+// whole remote address if that has no port.
+func remoteIP(conn net.Conn) string {
+	addr := conn.RemoteAddr().String()
+	if host, _, err := net.SplitHostPort(addr); err == nil {
+		return host
+	}
+	return addr
+}
+
+// rejectPerIP tells conn that its IP has Limits.MaxConnectionsPerIP
+// connections open, closes it, and counts it. The reply is a HELLO
+// REPLY, as clients expect before their first command is answered.
+func (s *Server) rejectPerIP(conn net.Conn, ip string) {
+	s.rejectedPerIP.Add(1)
+	defer conn.Close()
+
+	resp := protocol.NewResponse(protocol.VerbHello).
+		WithAction(protocol.ActionReply).
+		WithResult(protocol.ResultI2PError).
+		WithMessage(fmt.Sprintf("too many connections from %s (max %d)", ip, s.config.Limits.MaxConnectionsPerIP))
+	conn.SetWriteDeadline(time.Now().Add(perIPRejectTimeout))
+	conn.Write([]byte(resp.String()))
+}
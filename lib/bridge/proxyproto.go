@@ -0,0 +1,243 @@
+package bridge
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ProxyHeaderTimeout bounds how long a connection accepted by a
+// ProxyListener may take to send its PROXY protocol header.
+const ProxyHeaderTimeout = 10 * time.Second
+
+const (
+	// proxyV1MaxLength is the longest PROXY protocol v1 header line,
+	// including its CRLF.
+	proxyV1MaxLength = 107
+
+	// proxyV2HeaderLength is the length of the fixed part of a PROXY
+	// protocol v2 header: signature, version and command, family, length.
+	proxyV2HeaderLength = 16
+)
+
+// proxyV2Signature starts every PROXY protocol v2 header.
+var proxyV2Signature = []byte("\r\n\r\n\x00\r\nQUIT\n")
+
+// ErrInvalidProxyHeader is returned by reads from a connection accepted
+// by a ProxyListener that did not begin with a valid PROXY protocol header.
+var ErrInvalidProxyHeader = errors.New("invalid PROXY protocol header")
+
+// ProxyListener accepts connections from a TCP load balancer or proxy
+// that sends a PROXY protocol (v1 or v2) header first, as HAProxy does
+// with send-proxy. The RemoteAddr of each connection it returns is the
+// client address from the header, so authentication, connection limits,
+// audit records and traces see the real client rather than the proxy.
+//
+// Every connection must start with a header; one without is closed on
+// its first read. Headers are read on the connection's own goroutine,
+// not in Accept. A v2 LOCAL header or v1 UNKNOWN header, as proxies
+// send for health checks, keeps the proxy's address.
+//
+// Only enable it on listeners that proxies alone can reach: anyone able
+// to connect can claim any address. To serve TLS, wrap the
+// ProxyListener with tls.NewListener, since the header precedes the
+// TLS handshake.
+type ProxyListener struct {
+	net.Listener
+}
+
+// NewProxyListener returns a ProxyListener accepting connections from l.
+func NewProxyListener(l net.Listener) *ProxyListener {
+	return &ProxyListener{Listener: l}
+}
+
+// Accept waits for the next connection. Its PROXY protocol header is
+// read on the first Read or RemoteAddr call.
+func (l *ProxyListener) Accept() (net.Conn, error) {
+	conn, err := l.Listener.Accept()
+	if err != nil {
+		return nil, err
+	}
+	return &proxyConn{Conn: conn, reader: bufio.NewReader(conn)}, nil
+}
+
+// proxyConn is a connection whose PROXY protocol header is read once,
+// on first use.
+type proxyConn struct {
+	net.Conn
+	reader *bufio.Reader
+
+	once       sync.Once
+	remoteAddr net.Addr
+	err        error
+
+	// mu guards readDeadline, the read deadline last set by the caller,
+	// which is restored after the header has been read.
+	mu           sync.Mutex
+	readDeadline time.Time
+}
+
+// readHeader reads the PROXY protocol header, at most once, within
+// ProxyHeaderTimeout or the caller's read deadline, whichever is
+// earlier. If it is invalid, the connection is closed.
+func (c *proxyConn) readHeader() {
+	c.once.Do(func() {
+		c.mu.Lock()
+		deadline := c.readDeadline
+		c.mu.Unlock()
+		limit := time.Now().Add(ProxyHeaderTimeout)
+		if deadline.IsZero() || limit.Before(deadline) {
+			c.Conn.SetReadDeadline(limit)
+		}
+		c.remoteAddr, c.err = readProxyHeader(c.reader)
+		c.Conn.SetReadDeadline(deadline)
+		if c.err != nil {
+			c.Conn.Close()
+		}
+	})
+}
+
+// SetDeadline sets the read and write deadlines.
+func (c *proxyConn) SetDeadline(t time.Time) error {
+	c.mu.Lock()
+	c.readDeadline = t
+	c.mu.Unlock()
+	return c.Conn.SetDeadline(t)
+}
+
+// SetReadDeadline sets the read deadline.
+func (c *proxyConn) SetReadDeadline(t time.Time) error {
+	c.mu.Lock()
+	c.readDeadline = t
+	c.mu.Unlock()
+	return c.Conn.SetReadDeadline(t)
+}
+
+// NetConn returns the underlying connection, so that socket options can
+// be set on it. Reads from it would skip buffered data.
+func (c *proxyConn) NetConn() net.Conn {
+	return c.Conn
+}
+
+// Read reads data following the PROXY protocol header.
+func (c *proxyConn) Read(p []byte) (int, error) {
+	c.readHeader()
+	if c.err != nil {
+		return 0, c.err
+	}
+	return c.reader.Read(p)
+}
+
+// RemoteAddr returns the client address from the PROXY protocol header,
+// or the proxy's address if the header carries none or is invalid.
+func (c *proxyConn) RemoteAddr() net.Addr {
+	c.readHeader()
+	if c.remoteAddr != nil {
+		return c.remoteAddr
+	}
+	return c.Conn.RemoteAddr()
+}
+
+// readProxyHeader reads a PROXY protocol v1 or v2 header from r and
+// returns the source address it carries, or nil for a header without one.
+func readProxyHeader(r *bufio.Reader) (net.Addr, error) {
+	start, err := r.Peek(len("PROXY "))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidProxyHeader, err)
+	}
+	if string(start) == "PROXY " {
+		return readProxyV1(r)
+	}
+	if start[0] == proxyV2Signature[0] {
+		return readProxyV2(r)
+	}
+	return nil, fmt.Errorf("%w: missing", ErrInvalidProxyHeader)
+}
+
+// readProxyV1 reads a text header such as
+// "PROXY TCP4 192.0.2.1 198.51.100.1 56324 7656\r\n".
+func readProxyV1(r *bufio.Reader) (net.Addr, error) {
+	var line []byte
+	for len(line) < proxyV1MaxLength {
+		b, err := r.ReadByte()
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidProxyHeader, err)
+		}
+		line = append(line, b)
+		if b == '\n' {
+			break
+		}
+	}
+	text, ok := strings.CutSuffix(string(line), "\r\n")
+	if !ok {
+		return nil, fmt.Errorf("%w: v1 header not terminated", ErrInvalidProxyHeader)
+	}
+
+	fields := strings.Split(text, " ")
+	if len(fields) >= 2 && fields[1] == "UNKNOWN" {
+		return nil, nil
+	}
+	if len(fields) != 6 || (fields[1] != "TCP4" && fields[1] != "TCP6") {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidProxyHeader, text)
+	}
+	ip := net.ParseIP(fields[2])
+	port, err := strconv.ParseUint(fields[4], 10, 16)
+	if ip == nil || err != nil || (ip.To4() != nil) != (fields[1] == "TCP4") {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidProxyHeader, text)
+	}
+	return &net.TCPAddr{IP: ip, Port: int(port)}, nil
+}
+
+// readProxyV2 reads a binary header. TLVs are skipped.
+func readProxyV2(r *bufio.Reader) (net.Addr, error) {
+	header := make([]byte, proxyV2HeaderLength)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidProxyHeader, err)
+	}
+	if !bytes.Equal(header[:len(proxyV2Signature)], proxyV2Signature) {
+		return nil, fmt.Errorf("%w: bad v2 signature", ErrInvalidProxyHeader)
+	}
+	if header[12]>>4 != 2 {
+		return nil, fmt.Errorf("%w: unsupported version %d", ErrInvalidProxyHeader, header[12]>>4)
+	}
+	command, family := header[12]&0x0f, header[13]
+	body := make([]byte, binary.BigEndian.Uint16(header[14:]))
+	if _, err := io.ReadFull(r, body); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidProxyHeader, err)
+	}
+
+	switch command {
+	case 0x0: // LOCAL: the proxy's own connection, e.g. a health check
+		return nil, nil
+	case 0x1: // PROXY
+	default:
+		return nil, fmt.Errorf("%w: unknown v2 command %d", ErrInvalidProxyHeader, command)
+	}
+
+	var ipLen int
+	switch family >> 4 {
+	case 0x1: // AF_INET
+		ipLen = net.IPv4len
+	case 0x2: // AF_INET6
+		ipLen = net.IPv6len
+	default: // AF_UNSPEC, AF_UNIX: no address usable as a client IP
+		return nil, nil
+	}
+	if len(body) < 2*ipLen+4 {
+		return nil, fmt.Errorf("%w: v2 address block too short", ErrInvalidProxyHeader)
+	}
+	ip := net.IP(append([]byte(nil), body[:ipLen]...))
+	port := binary.BigEndian.Uint16(body[2*ipLen:])
+	if family&0x0f == 0x2 { // DGRAM
+		return &net.UDPAddr{IP: ip, Port: int(port)}, nil
+	}
+	return &net.TCPAddr{IP: ip, Port: int(port)}, nil
+}
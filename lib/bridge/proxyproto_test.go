@@ -0,0 +1,141 @@
+package bridge
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+// proxyV2 builds a PROXY protocol v2 header for a TCP source address.
+func proxyV2(command byte, src *net.TCPAddr) []byte {
+	header := append([]byte{}, proxyV2Signature...)
+	ip4 := src.IP.To4()
+	ipLen, family := net.IPv6len, byte(0x21)
+	if ip4 != nil {
+		ipLen, family = net.IPv4len, 0x11
+	}
+	header = append(header, 0x20|command, family)
+	body := make([]byte, 2*ipLen+4)
+	if ip4 != nil {
+		copy(body, ip4)
+	} else {
+		copy(body, src.IP.To16())
+	}
+	binary.BigEndian.PutUint16(body[2*ipLen:], uint16(src.Port))
+	binary.BigEndian.PutUint16(body[2*ipLen+2:], 7656)
+	header = binary.BigEndian.AppendUint16(header, uint16(len(body)))
+	return append(header, body...)
+}
+
+func TestReadProxyHeader(t *testing.T) {
+	tests := []struct {
+		name    string
+		header  string
+		want    string
+		wantErr bool
+	}{
+		{"v1 tcp4", "PROXY TCP4 192.0.2.1 198.51.100.1 56324 7656\r\n", "192.0.2.1:56324", false},
+		{"v1 tcp6", "PROXY TCP6 2001:db8::1 2001:db8::2 4000 7656\r\n", "[2001:db8::1]:4000", false},
+		{"v1 unknown", "PROXY UNKNOWN\r\n", "", false},
+		{"v1 family mismatch", "PROXY TCP4 2001:db8::1 2001:db8::2 4000 7656\r\n", "", true},
+		{"v1 bad port", "PROXY TCP4 192.0.2.1 198.51.100.1 99999 7656\r\n", "", true},
+		{"v1 unterminated", "PROXY TCP4 192.0.2.1 198.51.100.1 1 7656\n", "", true},
+		{"v1 too long", "PROXY " + strings.Repeat("x", 200), "", true},
+		{"v2 tcp4", string(proxyV2(1, &net.TCPAddr{IP: net.ParseIP("203.0.113.7"), Port: 1234})), "203.0.113.7:1234", false},
+		{"v2 tcp6", string(proxyV2(1, &net.TCPAddr{IP: net.ParseIP("2001:db8::7"), Port: 1234})), "[2001:db8::7]:1234", false},
+		{"v2 local", string(proxyV2(0, &net.TCPAddr{IP: net.ParseIP("203.0.113.7"), Port: 1234})), "", false},
+		{"missing", "HELLO VERSION\n", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			addr, err := readProxyHeader(bufio.NewReader(strings.NewReader(tt.header)))
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidProxyHeader) {
+					t.Errorf("readProxyHeader() error = %v, want ErrInvalidProxyHeader", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("readProxyHeader() error = %v", err)
+			}
+			got := ""
+			if addr != nil {
+				got = addr.String()
+			}
+			if got != tt.want {
+				t.Errorf("readProxyHeader() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestProxyListener(t *testing.T) {
+	inner, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("net.Listen() error = %v", err)
+	}
+	l := NewProxyListener(inner)
+	defer l.Close()
+
+	go func() {
+		conn, err := net.Dial("tcp", inner.Addr().String())
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		conn.Write([]byte("PROXY TCP4 192.0.2.1 198.51.100.1 56324 7656\r\nHELLO VERSION\n"))
+		io.Copy(io.Discard, conn)
+	}()
+
+	conn, err := l.Accept()
+	if err != nil {
+		t.Fatalf("Accept() error = %v", err)
+	}
+	defer conn.Close()
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+
+	if got := conn.RemoteAddr().String(); got != "192.0.2.1:56324" {
+		t.Errorf("RemoteAddr() = %q, want 192.0.2.1:56324", got)
+	}
+	line, err := bufio.NewReader(conn).ReadString('\n')
+	if err != nil || line != "HELLO VERSION\n" {
+		t.Errorf("ReadString() = %q, %v; want the command after the header", line, err)
+	}
+}
+
+func TestProxyListener_MissingHeader(t *testing.T) {
+	inner, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("net.Listen() error = %v", err)
+	}
+	l := NewProxyListener(inner)
+	defer l.Close()
+
+	go func() {
+		conn, err := net.Dial("tcp", inner.Addr().String())
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		conn.Write([]byte("HELLO VERSION\n"))
+		io.Copy(io.Discard, conn)
+	}()
+
+	conn, err := l.Accept()
+	if err != nil {
+		t.Fatalf("Accept() error = %v", err)
+	}
+	defer conn.Close()
+
+	if _, err := conn.Read(make([]byte, 64)); !errors.Is(err, ErrInvalidProxyHeader) {
+		t.Errorf("Read() error = %v, want ErrInvalidProxyHeader", err)
+	}
+	if !strings.HasPrefix(conn.RemoteAddr().String(), "127.0.0.1:") {
+		t.Errorf("RemoteAddr() = %v, want the peer's address", conn.RemoteAddr())
+	}
+}
@@ -0,0 +1,97 @@
+package bridge
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/go-i2p/go-sam-bridge/lib/handler"
+	"github.com/go-i2p/go-sam-bridge/lib/protocol"
+	"github.com/go-i2p/go-sam-bridge/lib/session"
+)
+
+// sessionQuota enforces Limits.MaxSessions and Limits.MaxSessionsPerUser
+// on SESSION CREATE. A create in progress holds a reservation, so
+// concurrent creates on different connections cannot overshoot a limit
+// while tunnels are being built.
+type sessionQuota struct {
+	mu sync.Mutex
+
+	// owners records the sessions created by authenticated users.
+	owners *sessionOwners
+
+	// pending counts reservations, in total and per user.
+	pending       int
+	pendingByUser map[string]int
+}
+
+// newSessionQuota creates an empty sessionQuota that records session
+// creators in owners.
+func newSessionQuota(owners *sessionOwners) *sessionQuota {
+	return &sessionQuota{
+		owners:        owners,
+		pendingByUser: make(map[string]int),
+	}
+}
+
+// acquire reserves a session slot for user, who may be empty if the
+// connection is not authenticated. It returns a rejection message if a
+// limit in limits is reached; otherwise the caller must call release.
+func (q *sessionQuota) acquire(registry session.Registry, limits LimitConfig, user string) string {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if limits.MaxSessions > 0 && registry.Count()+q.pending >= limits.MaxSessions {
+		return fmt.Sprintf("session limit reached (max %d)", limits.MaxSessions)
+	}
+	if limits.MaxSessionsPerUser > 0 && user != "" {
+		if q.owners.count(registry, user)+q.pendingByUser[user] >= limits.MaxSessionsPerUser {
+			return fmt.Sprintf("session quota exceeded for user %s (max %d)", user, limits.MaxSessionsPerUser)
+		}
+	}
+
+	q.pending++
+	if user != "" {
+		q.pendingByUser[user]++
+	}
+	return ""
+}
+
+// release ends a reservation made by acquire. If the create succeeded,
+// sess is the new session and is recorded as owned by user.
+func (q *sessionQuota) release(user string, sess session.Session) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.pending--
+	if user == "" {
+		return
+	}
+	if q.pendingByUser[user]--; q.pendingByUser[user] <= 0 {
+		delete(q.pendingByUser, user)
+	}
+	q.owners.set(sess, user)
+}
+
+// isSessionCreate returns true for SESSION CREATE commands.
+func isSessionCreate(cmd *protocol.Command) bool {
+	return strings.EqualFold(cmd.Verb, protocol.VerbSession) && strings.EqualFold(cmd.Action, protocol.ActionCreate)
+}
+
+// createdSession returns the session cmd created on ctx, or nil if the
+// create failed.
+func createdSession(ctx *handler.Context, cmd *protocol.Command) session.Session {
+	if ctx.Session == nil || ctx.Session.ID() != cmd.Get("ID") {
+		return nil
+	}
+	return ctx.Session
+}
+
+// sessionQuotaExceeded returns the SESSION STATUS response for a create
+// rejected by the session quota.
+func sessionQuotaExceeded(msg string) *protocol.Response {
+	return protocol.NewResponse(protocol.VerbSession).
+		WithAction(protocol.ActionStatus).
+		WithResult(protocol.ResultI2PError).
+		WithMessage(msg)
+}
@@ -0,0 +1,120 @@
+package bridge
+
+import (
+	"bufio"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/go-i2p/go-sam-bridge/lib/handler"
+	"github.com/go-i2p/go-sam-bridge/lib/protocol"
+)
+
+func TestSessionQuota_Global(t *testing.T) {
+	registry := newMockRegistry()
+	q := newSessionQuota(newSessionOwners())
+	limits := LimitConfig{MaxSessions: 2}
+
+	registry.Register(&mockSession{id: "a"})
+	if msg := q.acquire(registry, limits, ""); msg != "" {
+		t.Fatalf("acquire() = %q, want slot", msg)
+	}
+	// The pending reservation counts towards the limit.
+	if msg := q.acquire(registry, limits, ""); msg == "" {
+		t.Fatal("acquire() with a pending create should be rejected")
+	}
+	q.release("", nil)
+	if msg := q.acquire(registry, limits, ""); msg != "" {
+		t.Errorf("acquire() after release = %q, want slot", msg)
+	}
+}
+
+func TestSessionQuota_PerUser(t *testing.T) {
+	registry := newMockRegistry()
+	q := newSessionQuota(newSessionOwners())
+	limits := LimitConfig{MaxSessionsPerUser: 1}
+
+	if msg := q.acquire(registry, limits, "alice"); msg != "" {
+		t.Fatalf("acquire(alice) = %q, want slot", msg)
+	}
+	a1 := &mockSession{id: "a1"}
+	registry.Register(a1)
+	q.release("alice", a1)
+
+	msg := q.acquire(registry, limits, "alice")
+	if !strings.Contains(msg, "alice") {
+		t.Errorf("second acquire(alice) = %q, want quota message", msg)
+	}
+	if msg := q.acquire(registry, limits, "bob"); msg != "" {
+		t.Errorf("acquire(bob) = %q, want slot", msg)
+	}
+	q.release("bob", nil)
+	if msg := q.acquire(registry, limits, ""); msg != "" {
+		t.Errorf("unauthenticated acquire() = %q, want slot", msg)
+	}
+	q.release("", nil)
+
+	// Closing alice's session frees her quota.
+	registry.Unregister("a1")
+	if msg := q.acquire(registry, limits, "alice"); msg != "" {
+		t.Errorf("acquire(alice) after unregister = %q, want slot", msg)
+	}
+}
+
+func TestServer_SessionQuota(t *testing.T) {
+	registry := newMockRegistry()
+	config := DefaultConfig()
+	config.Limits.MaxSessionsPerUser = 1
+	config.Auth.Required = true
+	config.Auth.Users = map[string]string{"alice": "secret"}
+
+	server, err := NewServer(config, registry)
+	if err != nil {
+		t.Fatalf("NewServer() error = %v", err)
+	}
+	server.Router().RegisterFunc("HELLO", func(ctx *handler.Context, cmd *protocol.Command) (*protocol.Response, error) {
+		return protocol.NewResponse("HELLO").WithAction("REPLY").WithResult("OK").WithVersion("3.3"), nil
+	})
+	server.Router().RegisterFunc("SESSION CREATE", func(ctx *handler.Context, cmd *protocol.Command) (*protocol.Response, error) {
+		sess := &mockSession{id: cmd.Get("ID")}
+		ctx.Registry.Register(sess)
+		ctx.BindSession(sess)
+		return protocol.NewResponse("SESSION").WithAction("STATUS").WithResult("OK"), nil
+	})
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("net.Listen() error = %v", err)
+	}
+	go server.Serve(listener)
+	defer server.Close()
+
+	create := func(id string) string {
+		t.Helper()
+		conn, err := net.Dial("tcp", listener.Addr().String())
+		if err != nil {
+			t.Fatalf("net.Dial() error = %v", err)
+		}
+		t.Cleanup(func() { conn.Close() })
+		reader := bufio.NewReader(conn)
+		conn.Write([]byte("HELLO VERSION MIN=3.0 MAX=3.3 USER=alice PASSWORD=secret\n"))
+		reader.ReadString('\n')
+		conn.Write([]byte("SESSION CREATE STYLE=STREAM ID=" + id + " DESTINATION=TRANSIENT\n"))
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			t.Fatalf("ReadString() error = %v", err)
+		}
+		return line
+	}
+
+	if line := create("s1"); !strings.Contains(line, "RESULT=OK") {
+		t.Fatalf("first SESSION CREATE = %q, want RESULT=OK", line)
+	}
+	line := create("s2")
+	if !strings.Contains(line, "RESULT=I2P_ERROR") || !strings.Contains(line, "quota") {
+		t.Errorf("second SESSION CREATE = %q, want I2P_ERROR with quota message", line)
+	}
+	if registry.Get("s2") != nil {
+		t.Error("rejected session should not be registered")
+	}
+}
@@ -2,6 +2,7 @@ package bridge
 
 import (
 	"bufio"
+	"context"
 	"crypto/tls"
 	"errors"
 	"fmt"
@@ -22,11 +23,27 @@ import (
 // and processes SAM protocol commands.
 type Server struct {
 	config    *Config
-	listener  net.Listener
+	listeners []net.Listener
 	router    *handler.Router
 	registry  session.Registry
 	parser    *protocol.Parser
-	authStore *AuthStore
+
+	// strictParser parses commands of SAM 3.2 clients when
+	// Config.StrictQuoting is set.
+	strictParser *protocol.Parser
+	authStore    *AuthStore
+
+	// audit records processed commands. Nil if audit logging is disabled.
+	audit *AuditLogger
+
+	// access records closed connections. Nil if access logging is disabled.
+	access *AccessLogger
+
+	// owners records which authenticated user created each session.
+	owners *sessionOwners
+
+	// quota enforces session count limits on SESSION CREATE.
+	quota *sessionQuota
 
 	// udpListener handles UDP datagrams on port 7655 per SAM specification.
 	// May be nil if DatagramPort is 0 (disabled).
@@ -36,10 +53,84 @@ type Server struct {
 	connections map[*Connection]struct{}
 	closed      atomic.Bool
 
+	// draining is set by Shutdown once the listener has been closed and
+	// the server is waiting for existing connections to finish.
+	draining atomic.Bool
+
 	// done is closed when the server shuts down.
 	done chan struct{}
+
+	// idleOnce starts the idle session sweeper on the first Serve.
+	idleOnce sync.Once
+
+	// idleClosing tracks idle sessions being notified and closed in the
+	// background.
+	idleClosing sync.WaitGroup
+
+	// slots holds a token per connection being handled when
+	// Limits.MaxConnections is set. Nil means no limit.
+	slots chan struct{}
+
+	// queued counts connections waiting for a slot; rejected counts
+	// connections closed because no slot or queue place was free.
+	queued   atomic.Int64
+	rejected atomic.Uint64
+
+	// perIP counts open connections per remote IP when
+	// Limits.MaxConnectionsPerIP is set. Nil means no limit.
+	perIP         *ipConns
+	rejectedPerIP atomic.Uint64
+
+	// startedAt is when Serve was first called, guarded by mu.
+	// connectionsTotal counts connections served and commands counts
+	// commands dispatched to handlers, both since startedAt.
+	startedAt        time.Time
+	connectionsTotal atomic.Uint64
+	commands         atomic.Uint64
+
+	// latencies records how long each routed command took.
+	latencies *commandLatencies
+}
+
+// Stats reports server-wide activity counters.
+type Stats struct {
+	// StartedAt is when the server started serving. It is zero before
+	// the first call to Serve.
+	StartedAt time.Time
+
+	// Uptime is the time since StartedAt.
+	Uptime time.Duration
+
+	// ConnectionsTotal is the number of SAM control connections served,
+	// not counting those rejected by connection limits.
+	ConnectionsTotal uint64
+
+	// ConnectionsActive is the number of open SAM control connections.
+	ConnectionsActive int
+
+	// Commands is the number of commands processed, including those
+	// answered with an error. PONG replies are not counted.
+	Commands uint64
 }
 
+// AcceptStats reports how the server limited incoming connections.
+type AcceptStats struct {
+	// Queued is the number of connections waiting for a free slot.
+	Queued int
+
+	// Rejected is the number of connections closed unserved because
+	// Limits.MaxConnections were open and the queue was full, or their
+	// wait exceeded Timeouts.Handshake.
+	Rejected uint64
+
+	// RejectedPerIP is the number of connections closed because their
+	// remote IP had Limits.MaxConnectionsPerIP connections open.
+	RejectedPerIP uint64
+}
+
+// shutdownPollInterval is how often Shutdown checks for remaining connections.
+const shutdownPollInterval = 50 * time.Millisecond
+
 // NewServer creates a new SAM bridge server with the given configuration.
 func NewServer(config *Config, registry session.Registry) (*Server, error) {
 	if err := config.Validate(); err != nil {
@@ -49,14 +140,49 @@ func NewServer(config *Config, registry session.Registry) (*Server, error) {
 	// Initialize AuthStore from config
 	authStore := NewAuthStoreFromConfig(config.Auth)
 
+	var audit *AuditLogger
+	if config.AuditLog != nil {
+		audit = NewAuditLogger(config.AuditLog)
+	}
+	var access *AccessLogger
+	if config.AccessLog != nil {
+		access = NewAccessLogger(config.AccessLog)
+	}
+
+	owners := newSessionOwners()
+
+	parser := protocol.NewParser(
+		protocol.WithMaxLineLength(config.Limits.MaxLineLength),
+		protocol.WithReplaceInvalidUTF8(config.ReplaceInvalidUTF8),
+	)
+	strictParser := *parser
+	strictParser.Strict = true
+
+	var slots chan struct{}
+	if config.Limits.MaxConnections > 0 {
+		slots = make(chan struct{}, config.Limits.MaxConnections)
+	}
+	var perIP *ipConns
+	if config.Limits.MaxConnectionsPerIP > 0 {
+		perIP = newIPConns()
+	}
+
 	return &Server{
-		config:      config,
-		registry:    registry,
-		router:      handler.NewRouter(),
-		parser:      protocol.NewParser(),
-		authStore:   authStore,
-		connections: make(map[*Connection]struct{}),
-		done:        make(chan struct{}),
+		config:       config,
+		registry:     registry,
+		router:       handler.NewRouter(),
+		parser:       parser,
+		strictParser: &strictParser,
+		authStore:    authStore,
+		audit:        audit,
+		access:       access,
+		owners:       owners,
+		quota:        newSessionQuota(owners),
+		connections:  make(map[*Connection]struct{}),
+		done:         make(chan struct{}),
+		slots:        slots,
+		perIP:        perIP,
+		latencies:    newCommandLatencies(),
 	}, nil
 }
 
@@ -122,17 +248,23 @@ func (s *Server) stopUDPListener() {
 }
 
 // Serve accepts connections on the listener and handles them.
-// This method blocks until the server is closed.
+// This method blocks until the server is closed. Serve may be called
+// concurrently with several listeners to serve multiple endpoints;
+// Close and Shutdown close all of them.
 func (s *Server) Serve(listener net.Listener) error {
 	s.mu.Lock()
-	s.listener = listener
+	s.listeners = append(s.listeners, listener)
+	if s.startedAt.IsZero() {
+		s.startedAt = time.Now()
+	}
 	s.mu.Unlock()
+	s.idleOnce.Do(func() { go s.runIdleSweeper() })
 
 	for {
 		conn, err := listener.Accept()
 		if err != nil {
-			if s.closed.Load() {
-				return nil // Server was closed
+			if s.closed.Load() || s.draining.Load() {
+				return nil // Server was closed or is draining
 			}
 			// Check if it's a temporary error
 			var netErr net.Error
@@ -142,43 +274,170 @@ func (s *Server) Serve(listener net.Listener) error {
 			return err
 		}
 
-		// Check connection limits
-		if !s.canAccept() {
+		s.admit(conn)
+	}
+}
+
+// ServeConn serves conn as if a listener passed to Serve had accepted
+// it, subject to the same connection limits, and returns without waiting
+// for it to finish. In-process frontends use it to speak SAM to the
+// server over net.Pipe. conn is closed at once if the server is closed
+// or draining.
+func (s *Server) ServeConn(conn net.Conn) {
+	if s.closed.Load() || s.draining.Load() {
+		conn.Close()
+		return
+	}
+	s.idleOnce.Do(func() { go s.runIdleSweeper() })
+	s.admit(conn)
+}
+
+// admit handles conn once a connection slot is free. If all
+// Limits.MaxConnections slots are taken, conn waits in the queue, or is
+// closed if the queue is full too.
+func (s *Server) admit(conn net.Conn) {
+	if s.slots == nil {
+		go s.handleConnection(conn)
+		return
+	}
+
+	select {
+	case s.slots <- struct{}{}:
+		go s.handleSlot(conn)
+		return
+	default:
+	}
+
+	if s.queued.Add(1) > int64(s.config.Limits.MaxQueuedConnections) {
+		s.queued.Add(-1)
+		s.reject(conn)
+		return
+	}
+	go func() {
+		var timeout <-chan time.Time
+		if d := s.config.Timeouts.Handshake; d > 0 {
+			timer := time.NewTimer(d)
+			defer timer.Stop()
+			timeout = timer.C
+		}
+		select {
+		case s.slots <- struct{}{}:
+			s.queued.Add(-1)
+			s.handleSlot(conn)
+		case <-timeout:
+			s.queued.Add(-1)
+			s.reject(conn)
+		case <-s.done:
+			s.queued.Add(-1)
 			conn.Close()
-			continue
 		}
+	}()
+}
 
-		go s.handleConnection(conn)
+// handleSlot handles conn and then frees the slot it holds.
+func (s *Server) handleSlot(conn net.Conn) {
+	defer func() { <-s.slots }()
+	if s.draining.Load() {
+		conn.Close()
+		return
 	}
+	s.handleConnection(conn)
+}
+
+// reject closes conn unserved and counts it.
+func (s *Server) reject(conn net.Conn) {
+	s.rejected.Add(1)
+	conn.Close()
 }
 
-// canAccept returns true if the server can accept a new connection.
-func (s *Server) canAccept() bool {
-	if s.config.Limits.MaxConnections == 0 {
-		return true
+// AcceptStats returns the connection queue length and the number of
+// connections rejected so far by Limits.MaxConnections and
+// Limits.MaxConnectionsPerIP.
+func (s *Server) AcceptStats() AcceptStats {
+	return AcceptStats{
+		Queued:        int(s.queued.Load()),
+		Rejected:      s.rejected.Load(),
+		RejectedPerIP: s.rejectedPerIP.Load(),
 	}
+}
 
+// Stats returns the server's uptime, connection and command counters.
+func (s *Server) Stats() Stats {
 	s.mu.Lock()
-	defer s.mu.Unlock()
-	return len(s.connections) < s.config.Limits.MaxConnections
+	stats := Stats{
+		StartedAt:         s.startedAt,
+		ConnectionsActive: len(s.connections),
+	}
+	s.mu.Unlock()
+	if !stats.StartedAt.IsZero() {
+		stats.Uptime = time.Since(stats.StartedAt)
+	}
+	stats.ConnectionsTotal = s.connectionsTotal.Load()
+	stats.Commands = s.commands.Load()
+	return stats
+}
+
+// CommandLatencies returns a latency histogram for each command handled
+// since the server was created, sorted by command. Commands are named by
+// the router key they matched; commands no handler matches are not
+// measured. STREAM ACCEPT includes the wait for an incoming stream and
+// SESSION CREATE the wait for tunnels.
+func (s *Server) CommandLatencies() []CommandLatency {
+	return s.latencies.snapshot()
 }
 
 // handleConnection processes a single client connection.
 func (s *Server) handleConnection(conn net.Conn) {
-	c := NewConnection(conn, s.config.Limits.ReadBufferSize)
+	if s.perIP != nil {
+		ip := remoteIP(conn)
+		if !s.perIP.acquire(ip, s.config.Limits.MaxConnectionsPerIP) {
+			s.rejectPerIP(conn, ip)
+			return
+		}
+		defer s.perIP.release(ip)
+	}
+
+	// Best effort: a socket that rejects an option is still served
+	_ = s.config.Limits.Socket.Apply(conn)
+
+	// Bytes are counted only for the access log
+	rw := conn
+	var counted *countingConn
+	if s.access != nil {
+		counted = &countingConn{Conn: conn}
+		rw = counted
+	}
+
+	c := NewConnection(rw, s.config.Limits.ReadBufferSize)
 
 	s.mu.Lock()
 	s.connections[c] = struct{}{}
 	s.mu.Unlock()
+	s.connectionsTotal.Add(1)
+	s.setConnState(conn, StateNew)
 
 	defer func() {
+		c.Close()
+		// Log before removing c, so that the record is written once
+		// ConnectionCount no longer includes it
+		if s.access != nil {
+			s.access.Log(newAccessRecord(c, counted))
+		}
 		s.mu.Lock()
 		delete(s.connections, c)
 		s.mu.Unlock()
-		c.Close()
+		s.setConnState(conn, StateClosed)
 	}()
+	defer s.recoverConnection(c)
 
-	ctx := handler.NewContext(conn, s.registry)
+	c.ResponseWriter().SetWriteTimeout(s.config.Timeouts.Write)
+	if s.config.Trace != nil {
+		c.ResponseWriter().SetTrace(func(line string) { s.trace(c, TraceOut, line) })
+	}
+	ctx := handler.NewContext(rw, s.registry)
+	ctx.Writer = c.ResponseWriter()
+	ctx.CopyBufferSize = s.config.Limits.StreamBufferSize
+	defer s.releaseSession(ctx)
 
 	// Command loop
 	for {
@@ -217,6 +476,24 @@ func (s *Server) handleConnection(conn net.Conn) {
 	}
 }
 
+// releaseSession unregisters and closes the session bound to a control
+// socket that has gone away. Per SAMv3.md, a session is destroyed when
+// its control socket closes.
+func (s *Server) releaseSession(ctx *handler.Context) {
+	if ctx.Session == nil {
+		return
+	}
+	_ = s.registry.Unregister(ctx.Session.ID())
+	_ = ctx.Session.Close()
+}
+
+// setConnState reports a connection state change to Config.ConnState.
+func (s *Server) setConnState(conn net.Conn, state ConnectionState) {
+	if s.config.ConnState != nil {
+		s.config.ConnState(conn, state)
+	}
+}
+
 // readAndParseCommand reads a line and parses it as a SAM command.
 // Returns (cmd, shouldReturn). If shouldReturn is true, caller should return.
 // If cmd is nil and shouldReturn is false, there was a parse error that was handled.
@@ -230,29 +507,65 @@ func (s *Server) readAndParseCommand(c *Connection) (*protocol.Command, bool) {
 	}
 
 	// Read command line
-	line, err := s.readLine(c.Reader())
+	line, err := s.parser.ReadLine(c.Reader())
 	if err != nil {
 		if s.isTimeoutError(err) {
 			s.sendTimeoutError(c)
+		} else if errors.Is(err, protocol.ErrLineTooLong) {
+			// The rest of the line is unread, so the connection cannot
+			// recover; report why before closing it.
+			s.sendParseError(c, err)
 		}
 		return nil, true
 	}
+	s.trace(c, TraceIn, line)
 
 	c.UpdateActivity()
 
 	// Parse command
-	cmd, err := s.parser.Parse(line)
+	cmd, err := s.parserFor(c).Parse(line)
 	if err != nil {
 		s.sendParseError(c, err)
 		return nil, false
 	}
+
+	// Read the payload of DATAGRAM SEND and RAW SEND
+	if n, ok := cmd.PayloadSize(); ok {
+		if cmd.Payload, err = s.parser.ReadPayload(c.Reader(), n); err != nil {
+			if s.isTimeoutError(err) {
+				s.sendTimeoutError(c)
+			}
+			return nil, true
+		}
+	}
 	return cmd, false
 }
 
+// parserFor returns the parser for c's commands, the strict parser if
+// Config.StrictQuoting is set and c negotiated a version requiring it.
+func (s *Server) parserFor(c *Connection) *protocol.Parser {
+	if s.config.StrictQuoting && protocol.VersionRequiresStrictQuoting(c.Version()) {
+		return s.strictParser
+	}
+	return s.parser
+}
+
 // processCommand dispatches the command and sends the response.
 // Returns true if the connection should be closed.
 func (s *Server) processCommand(ctx *handler.Context, c *Connection, cmd *protocol.Command) bool {
+	s.commands.Add(1)
+	// The connection's activity was updated as the line was read, so the
+	// measurement covers parsing, handling and writing the response.
+	if key := s.router.RouteKey(cmd); key != "" {
+		start := c.LastActivity()
+		defer func() { s.latencies.observe(key, time.Since(start)) }()
+	}
 	response, err := s.dispatchCommand(ctx, c, cmd)
+	if s.audit != nil {
+		rec := newAuditRecord(c, cmd, response)
+		rec.SessionLabel = session.Label(ctx.Session)
+		s.audit.Log(rec)
+	}
 	if err != nil {
 		return true // Internal error, close connection
 	}
@@ -266,6 +579,8 @@ func (s *Server) processCommand(ctx *handler.Context, c *Connection, cmd *protoc
 }
 
 // syncContextState updates the handler context from connection state.
+// Once the connection authenticates as a user, handlers see the registry
+// through a userRegistry, which hides other users' sessions.
 func (s *Server) syncContextState(ctx *handler.Context, c *Connection) {
 	if c.Version() != "" && ctx.Version == "" {
 		ctx.Version = c.Version()
@@ -273,6 +588,15 @@ func (s *Server) syncContextState(ctx *handler.Context, c *Connection) {
 	}
 	if c.IsAuthenticated() {
 		ctx.Authenticated = true
+		ctx.User = c.Username()
+		if _, scoped := ctx.Registry.(*userRegistry); !scoped && c.Username() != "" {
+			ctx.Registry = &userRegistry{
+				Registry: s.registry,
+				owners:   s.owners,
+				auth:     s.authStore,
+				user:     c.Username(),
+			}
+		}
 	}
 }
 
@@ -286,6 +610,9 @@ func (s *Server) getDeadline(c *Connection) time.Time {
 	default:
 		timeout = s.config.Timeouts.Command
 	}
+	if idle := s.connIdleTimeout(c); idle > 0 {
+		timeout = idle
+	}
 
 	if timeout > 0 {
 		return time.Now().Add(timeout)
@@ -293,29 +620,18 @@ func (s *Server) getDeadline(c *Connection) time.Time {
 	return time.Time{}
 }
 
-// readLine reads a single line from the reader, enforcing max line length.
-func (s *Server) readLine(reader *bufio.Reader) (string, error) {
-	var line strings.Builder
-	maxLen := s.config.Limits.MaxLineLength
-
-	for {
-		part, isPrefix, err := reader.ReadLine()
-		if err != nil {
-			return "", err
-		}
-
-		line.Write(part)
-
-		if line.Len() > maxLen {
-			return "", errors.New("line too long")
-		}
-
-		if !isPrefix {
-			break
-		}
+// connIdleTimeout returns Timeouts.Idle if it limits how long c may wait
+// for its next command: c completed HELLO, has no session bound, and
+// Timeouts.Command is not shorter. Otherwise it returns zero.
+func (s *Server) connIdleTimeout(c *Connection) time.Duration {
+	idle := s.config.Timeouts.Idle
+	if idle <= 0 || c.State() != StateReady {
+		return 0
 	}
-
-	return line.String(), nil
+	if command := s.config.Timeouts.Command; command > 0 && command <= idle {
+		return 0
+	}
+	return idle
 }
 
 // dispatchCommand routes the command to the appropriate handler.
@@ -347,7 +663,15 @@ func (s *Server) dispatchCommand(
 			WithMessage("unknown command"), nil
 	}
 
-	response, err := h.Handle(ctx, cmd)
+	if isSessionCreate(cmd) {
+		user := c.Username()
+		if msg := s.quota.acquire(s.registry, s.config.Limits, user); msg != "" {
+			return sessionQuotaExceeded(msg), nil
+		}
+		defer func() { s.quota.release(user, createdSession(ctx, cmd)) }()
+	}
+
+	response, err := s.handle(h, ctx, c, cmd)
 	if err != nil {
 		return nil, err
 	}
@@ -386,7 +710,7 @@ func (s *Server) updateConnectionState(
 
 			// Handle authentication from HELLO
 			if user := cmd.Get("USER"); user != "" {
-				if s.config.CheckPassword(user, cmd.Get("PASSWORD")) {
+				if s.authStore.CheckPassword(user, cmd.Get("PASSWORD")) {
 					c.SetAuthenticated(user)
 				}
 			}
@@ -455,13 +779,19 @@ func (s *Server) isTimeoutError(err error) bool {
 func (s *Server) sendTimeoutError(c *Connection) {
 	var response *protocol.Response
 
-	switch c.State() {
-	case StateNew, StateHandshaking:
+	switch state := c.State(); {
+	case state == StateNew || state == StateHandshaking:
 		// Timeout before HELLO is complete
 		response = protocol.NewResponse("HELLO").
 			WithAction("REPLY").
 			WithResult("I2P_ERROR").
 			WithMessage("connection timeout: HELLO not received")
+	case s.connIdleTimeout(c) > 0:
+		// Idle after HELLO without creating a session
+		response = protocol.NewResponse("SESSION").
+			WithAction("STATUS").
+			WithResult("I2P_ERROR").
+			WithMessage(fmt.Sprintf("connection closed after %s idle without a session", s.config.Timeouts.Idle))
 	default:
 		// Timeout after HELLO, before next command
 		response = protocol.NewResponse("SESSION").
@@ -490,23 +820,14 @@ func (s *Server) sendPongTimeoutError(c *Connection) {
 // sendResponse writes a response to the connection.
 // If the response has additional lines (e.g., STREAM ACCEPT destination info),
 // they are written after the main response line.
+// The response and its additional lines (e.g., destination info for
+// STREAM ACCEPT) are sent in one write.
 func (s *Server) sendResponse(c *Connection, response *protocol.Response) error {
-	// response.String() includes trailing newline, use Write not WriteLine
-	_, err := c.Write([]byte(response.String()))
-	if err != nil {
+	w := c.ResponseWriter()
+	if err := w.WriteResponse(response); err != nil {
 		return err
 	}
-
-	// Send additional lines if present (e.g., destination info for STREAM ACCEPT)
-	// Each additional line needs a newline terminator
-	for _, additionalLine := range response.AdditionalLines {
-		_, err = c.WriteLine(additionalLine)
-		if err != nil {
-			return err
-		}
-	}
-
-	return nil
+	return w.Flush()
 }
 
 // Close gracefully shuts down the server.
@@ -521,15 +842,15 @@ func (s *Server) Close() error {
 	s.stopUDPListener()
 
 	s.mu.Lock()
-	listener := s.listener
+	listeners := append([]net.Listener(nil), s.listeners...)
 	connections := make([]*Connection, 0, len(s.connections))
 	for c := range s.connections {
 		connections = append(connections, c)
 	}
 	s.mu.Unlock()
 
-	// Close listener first
-	if listener != nil {
+	// Close listeners first
+	for _, listener := range listeners {
 		listener.Close()
 	}
 
@@ -541,6 +862,48 @@ func (s *Server) Close() error {
 	return nil
 }
 
+// Shutdown stops accepting new connections and waits for active connections
+// to finish on their own. Established streams keep forwarding data while
+// the server drains. If ctx is done before all connections have closed,
+// the remaining connections are force-closed and ctx.Err() is returned.
+// The server is fully closed when Shutdown returns.
+func (s *Server) Shutdown(ctx context.Context) error {
+	if s.closed.Load() {
+		return nil
+	}
+	s.draining.Store(true)
+
+	s.mu.Lock()
+	listeners := append([]net.Listener(nil), s.listeners...)
+	s.mu.Unlock()
+
+	// Stop accepting new connections
+	for _, listener := range listeners {
+		listener.Close()
+	}
+
+	ticker := time.NewTicker(shutdownPollInterval)
+	defer ticker.Stop()
+
+	for {
+		if s.ConnectionCount() == 0 {
+			return s.Close()
+		}
+		select {
+		case <-ctx.Done():
+			s.Close()
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// IsDraining returns true if Shutdown has been called and the server
+// is waiting for existing connections to finish.
+func (s *Server) IsDraining() bool {
+	return s.draining.Load() && !s.closed.Load()
+}
+
 // ConnectionCount returns the number of active connections.
 func (s *Server) ConnectionCount() int {
 	s.mu.Lock()
@@ -548,14 +911,27 @@ func (s *Server) ConnectionCount() int {
 	return len(s.connections)
 }
 
-// Addr returns the listener address, or empty string if not listening.
+// Addr returns the address of the first listener passed to Serve, or
+// empty string if not listening.
 func (s *Server) Addr() string {
 	s.mu.Lock()
 	defer s.mu.Unlock()
-	if s.listener == nil {
+	if len(s.listeners) == 0 {
 		return ""
 	}
-	return s.listener.Addr().String()
+	return s.listeners[0].Addr().String()
+}
+
+// Addrs returns the addresses of all listeners passed to Serve, in the
+// order Serve was called.
+func (s *Server) Addrs() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	addrs := make([]string, len(s.listeners))
+	for i, listener := range s.listeners {
+		addrs[i] = listener.Addr().String()
+	}
+	return addrs
 }
 
 // Done returns a channel that is closed when the server shuts down.
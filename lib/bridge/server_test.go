@@ -2,6 +2,9 @@ package bridge
 
 import (
 	"bufio"
+	"bytes"
+	"context"
+	"io"
 	"net"
 	"strings"
 	"sync"
@@ -204,6 +207,176 @@ func TestServer_Close(t *testing.T) {
 	}
 }
 
+func TestServer_ServeMultipleListeners(t *testing.T) {
+	server, err := NewServer(DefaultConfig(), newMockRegistry())
+	if err != nil {
+		t.Fatalf("NewServer() error = %v", err)
+	}
+
+	serveErr := make(chan error, 2)
+	var addrs []string
+	for i := 0; i < 2; i++ {
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatalf("net.Listen() error = %v", err)
+		}
+		addrs = append(addrs, listener.Addr().String())
+		go func() { serveErr <- server.Serve(listener) }()
+	}
+	time.Sleep(10 * time.Millisecond)
+
+	if got := server.Addrs(); len(got) != 2 {
+		t.Errorf("Addrs() = %v, want 2 addresses", got)
+	}
+	for _, addr := range addrs {
+		conn, err := net.Dial("tcp", addr)
+		if err != nil {
+			t.Fatalf("net.Dial(%s) error = %v", addr, err)
+		}
+		conn.Close()
+	}
+
+	server.Close()
+	for i := 0; i < 2; i++ {
+		select {
+		case err := <-serveErr:
+			if err != nil {
+				t.Errorf("Serve() error = %v, want nil", err)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("Serve() did not return after Close()")
+		}
+	}
+}
+
+func TestServer_Shutdown_NoConnections(t *testing.T) {
+	server, err := NewServer(DefaultConfig(), newMockRegistry())
+	if err != nil {
+		t.Fatalf("NewServer() error = %v", err)
+	}
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("net.Listen() error = %v", err)
+	}
+
+	serveErr := make(chan error, 1)
+	go func() { serveErr <- server.Serve(listener) }()
+	time.Sleep(10 * time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	if err := server.Shutdown(ctx); err != nil {
+		t.Errorf("Shutdown() error = %v, want nil", err)
+	}
+
+	select {
+	case err := <-serveErr:
+		if err != nil {
+			t.Errorf("Serve() error = %v, want nil", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Serve() did not return after Shutdown()")
+	}
+
+	select {
+	case <-server.Done():
+	default:
+		t.Error("Done() channel not closed after Shutdown()")
+	}
+}
+
+func TestServer_Shutdown_WaitsForConnections(t *testing.T) {
+	server, err := NewServer(DefaultConfig(), newMockRegistry())
+	if err != nil {
+		t.Fatalf("NewServer() error = %v", err)
+	}
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("net.Listen() error = %v", err)
+	}
+
+	go server.Serve(listener)
+	defer server.Close()
+
+	conn, err := net.Dial("tcp", listener.Addr().String())
+	if err != nil {
+		t.Fatalf("net.Dial() error = %v", err)
+	}
+	defer conn.Close()
+
+	// Wait for the server to register the connection
+	deadline := time.Now().Add(time.Second)
+	for server.ConnectionCount() == 0 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	done := make(chan error, 1)
+	go func() { done <- server.Shutdown(context.Background()) }()
+
+	time.Sleep(100 * time.Millisecond)
+	if !server.IsDraining() {
+		t.Error("IsDraining() = false while connection is open")
+	}
+
+	// New connections must be refused while draining
+	if c, err := net.DialTimeout("tcp", listener.Addr().String(), 100*time.Millisecond); err == nil {
+		c.Close()
+		t.Error("Dial() succeeded while draining, want error")
+	}
+
+	conn.Close()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("Shutdown() error = %v, want nil", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Shutdown() did not return after connection closed")
+	}
+}
+
+func TestServer_Shutdown_DeadlineForcesClose(t *testing.T) {
+	server, err := NewServer(DefaultConfig(), newMockRegistry())
+	if err != nil {
+		t.Fatalf("NewServer() error = %v", err)
+	}
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("net.Listen() error = %v", err)
+	}
+
+	go server.Serve(listener)
+
+	conn, err := net.Dial("tcp", listener.Addr().String())
+	if err != nil {
+		t.Fatalf("net.Dial() error = %v", err)
+	}
+	defer conn.Close()
+
+	deadline := time.Now().Add(time.Second)
+	for server.ConnectionCount() == 0 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	if err := server.Shutdown(ctx); err != context.DeadlineExceeded {
+		t.Errorf("Shutdown() error = %v, want %v", err, context.DeadlineExceeded)
+	}
+
+	// The client connection should have been force-closed
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+	if _, err := conn.Read(make([]byte, 1)); err == nil {
+		t.Error("Read() succeeded after forced shutdown, want error")
+	}
+}
+
 func TestServer_HandleConnection(t *testing.T) {
 	registry := newMockRegistry()
 	config := DefaultConfig()
@@ -254,6 +427,43 @@ func TestServer_HandleConnection(t *testing.T) {
 	}
 }
 
+func TestServer_ServeConn(t *testing.T) {
+	server, err := NewServer(DefaultConfig(), newMockRegistry())
+	if err != nil {
+		t.Fatalf("NewServer() error = %v", err)
+	}
+	server.Router().RegisterFunc("HELLO", func(ctx *handler.Context, cmd *protocol.Command) (*protocol.Response, error) {
+		return protocol.NewResponse("HELLO").
+			WithAction("REPLY").
+			WithResult("OK").
+			WithVersion("3.3"), nil
+	})
+
+	client, conn := net.Pipe()
+	defer client.Close()
+	server.ServeConn(conn)
+
+	client.SetDeadline(time.Now().Add(time.Second))
+	client.Write([]byte("HELLO VERSION MIN=3.0 MAX=3.3\n"))
+	line, err := bufio.NewReader(client).ReadString('\n')
+	if err != nil {
+		t.Fatalf("ReadString() error = %v", err)
+	}
+	if !strings.Contains(line, "RESULT=OK") {
+		t.Errorf("response = %q, want RESULT=OK", line)
+	}
+
+	// After Close, connections are refused
+	server.Close()
+	client2, conn2 := net.Pipe()
+	defer client2.Close()
+	server.ServeConn(conn2)
+	client2.SetDeadline(time.Now().Add(time.Second))
+	if _, err := client2.Read(make([]byte, 1)); err == nil {
+		t.Error("Read() after Close error = nil, want closed pipe")
+	}
+}
+
 func TestServer_HandshakeRequired(t *testing.T) {
 	registry := newMockRegistry()
 	config := DefaultConfig()
@@ -358,6 +568,73 @@ func TestServer_Authentication(t *testing.T) {
 	}
 }
 
+func TestServer_AuthFunc(t *testing.T) {
+	registry := newMockRegistry()
+	config := DefaultConfig()
+	config.Auth.Required = true
+	config.Auth.Func = func(user, password string) bool {
+		return user == "external" && password == "token"
+	}
+	config.Timeouts.Handshake = 100 * time.Millisecond
+
+	server, err := NewServer(config, registry)
+	if err != nil {
+		t.Fatalf("NewServer() error = %v", err)
+	}
+
+	server.Router().RegisterFunc("HELLO", func(ctx *handler.Context, cmd *protocol.Command) (*protocol.Response, error) {
+		return protocol.NewResponse("HELLO").
+			WithAction("REPLY").
+			WithResult("OK").
+			WithVersion("3.3"), nil
+	})
+	server.Router().RegisterFunc("PING", func(ctx *handler.Context, cmd *protocol.Command) (*protocol.Response, error) {
+		return protocol.NewResponse("PONG").WithResult("OK"), nil
+	})
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("net.Listen() error = %v", err)
+	}
+
+	go server.Serve(listener)
+	defer server.Close()
+
+	tests := []struct {
+		name     string
+		hello    string
+		wantPong bool
+	}{
+		{"accepted", "HELLO VERSION MIN=3.0 MAX=3.3 USER=external PASSWORD=token\n", true},
+		{"rejected", "HELLO VERSION MIN=3.0 MAX=3.3 USER=external PASSWORD=wrong\n", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conn, err := net.Dial("tcp", listener.Addr().String())
+			if err != nil {
+				t.Fatalf("net.Dial() error = %v", err)
+			}
+			defer conn.Close()
+
+			reader := bufio.NewReader(conn)
+			conn.Write([]byte(tt.hello))
+			if _, err := reader.ReadString('\n'); err != nil {
+				t.Fatalf("ReadString() error = %v", err)
+			}
+
+			conn.Write([]byte("PING\n"))
+			line, err := reader.ReadString('\n')
+			if err != nil {
+				t.Fatalf("ReadString() error = %v", err)
+			}
+			if got := strings.Contains(line, "RESULT=OK"); got != tt.wantPong {
+				t.Errorf("PING response = %q, want authenticated = %v", line, tt.wantPong)
+			}
+		})
+	}
+}
+
 func TestServer_MaxConnections(t *testing.T) {
 	registry := newMockRegistry()
 	config := DefaultConfig()
@@ -608,6 +885,7 @@ func TestSendTimeoutError(t *testing.T) {
 	tests := []struct {
 		name          string
 		state         ConnectionState
+		idle          time.Duration
 		wantVerb      string
 		wantSubstring string
 	}{
@@ -635,12 +913,27 @@ func TestSendTimeoutError(t *testing.T) {
 			wantVerb:      "SESSION",
 			wantSubstring: "no command received",
 		},
+		{
+			name:          "ready with idle timeout",
+			state:         StateReady,
+			idle:          10 * time.Second,
+			wantVerb:      "SESSION",
+			wantSubstring: "10s idle without a session",
+		},
+		{
+			name:          "session bound with idle timeout",
+			state:         StateSessionBound,
+			idle:          10 * time.Second,
+			wantVerb:      "SESSION",
+			wantSubstring: "no command received",
+		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			registry := newMockRegistry()
 			config := DefaultConfig()
+			config.Timeouts.Idle = tt.idle
 			server, err := NewServer(config, registry)
 			if err != nil {
 				t.Fatalf("NewServer() error = %v", err)
@@ -793,3 +1086,456 @@ func TestSendPing(t *testing.T) {
 		})
 	}
 }
+
+func TestServer_ConnState(t *testing.T) {
+	registry := newMockRegistry()
+	config := DefaultConfig()
+	states := make(chan ConnectionState, 2)
+	config.ConnState = func(conn net.Conn, state ConnectionState) {
+		states <- state
+	}
+
+	server, err := NewServer(config, registry)
+	if err != nil {
+		t.Fatalf("NewServer() error = %v", err)
+	}
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("net.Listen() error = %v", err)
+	}
+
+	go server.Serve(listener)
+	defer server.Close()
+
+	conn, err := net.Dial("tcp", listener.Addr().String())
+	if err != nil {
+		t.Fatalf("net.Dial() error = %v", err)
+	}
+
+	for _, want := range []ConnectionState{StateNew, StateClosed} {
+		if want == StateClosed {
+			conn.Close()
+		}
+		select {
+		case got := <-states:
+			if got != want {
+				t.Errorf("ConnState = %v, want %v", got, want)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("timed out waiting for ConnState %v", want)
+		}
+	}
+}
+
+func TestReadAndParseCommand_Payload(t *testing.T) {
+	server, err := NewServer(DefaultConfig(), newMockRegistry())
+	if err != nil {
+		t.Fatalf("NewServer() error = %v", err)
+	}
+
+	clientConn, serverConn := net.Pipe()
+	defer clientConn.Close()
+	defer serverConn.Close()
+	conn := NewConnection(serverConn, 1024)
+
+	go clientConn.Write([]byte("DATAGRAM SEND DESTINATION=abc SIZE=4\n\x00\n\x01\x02PING\n"))
+
+	cmd, closeConn := server.readAndParseCommand(conn)
+	if closeConn || cmd == nil {
+		t.Fatalf("readAndParseCommand() = %v, %v", cmd, closeConn)
+	}
+	if !bytes.Equal(cmd.Payload, []byte("\x00\n\x01\x02")) {
+		t.Errorf("Payload = %q, want the 4 bytes after the command line", cmd.Payload)
+	}
+
+	cmd, closeConn = server.readAndParseCommand(conn)
+	if closeConn || cmd == nil || cmd.Verb != "PING" {
+		t.Errorf("next command = %v, %v; want PING", cmd, closeConn)
+	}
+}
+
+func TestServer_StrictQuoting(t *testing.T) {
+	config := DefaultConfig()
+	config.StrictQuoting = true
+	server, err := NewServer(config, newMockRegistry())
+	if err != nil {
+		t.Fatalf("NewServer() error = %v", err)
+	}
+
+	clientConn, serverConn := net.Pipe()
+	defer clientConn.Close()
+	defer serverConn.Close()
+	conn := NewConnection(serverConn, 1024)
+
+	tests := []struct {
+		version string
+		wantErr bool
+	}{
+		{"", false},
+		{"3.1", false},
+		{"3.2", true},
+		{"3.3", true},
+	}
+	for _, tt := range tests {
+		conn.SetVersion(tt.version)
+		_, err := server.parserFor(conn).Parse(`NAMING LOOKUP NAME=a"b c"`)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("version %q: Parse() error = %v, wantErr %v", tt.version, err, tt.wantErr)
+		}
+	}
+
+	server.config.StrictQuoting = false
+	if server.parserFor(conn).Strict {
+		t.Error("parserFor() returned the strict parser with StrictQuoting off")
+	}
+}
+
+func TestReadAndParseCommand_LineTooLong(t *testing.T) {
+	config := DefaultConfig()
+	config.Limits.MaxLineLength = 16
+	server, err := NewServer(config, newMockRegistry())
+	if err != nil {
+		t.Fatalf("NewServer() error = %v", err)
+	}
+
+	clientConn, serverConn := net.Pipe()
+	defer clientConn.Close()
+	defer serverConn.Close()
+	conn := NewConnection(serverConn, 1024)
+
+	go clientConn.Write([]byte("NAMING LOOKUP NAME=much-too-long.i2p\n"))
+	reply := make(chan string, 1)
+	go func() {
+		line, _ := bufio.NewReader(clientConn).ReadString('\n')
+		reply <- line
+	}()
+
+	if cmd, closeConn := server.readAndParseCommand(conn); !closeConn || cmd != nil {
+		t.Errorf("readAndParseCommand() = %v, %v; want nil, true", cmd, closeConn)
+	}
+	if got := <-reply; !strings.Contains(got, "RESULT=I2P_ERROR") {
+		t.Errorf("reply = %q, want an I2P_ERROR", got)
+	}
+}
+
+func TestServer_MaxQueuedConnections(t *testing.T) {
+	config := DefaultConfig()
+	config.Limits.MaxConnections = 1
+	config.Limits.MaxQueuedConnections = 1
+	config.Timeouts.Handshake = time.Second
+
+	server, err := NewServer(config, newMockRegistry())
+	if err != nil {
+		t.Fatalf("NewServer() error = %v", err)
+	}
+	server.Router().RegisterFunc("HELLO", func(ctx *handler.Context, cmd *protocol.Command) (*protocol.Response, error) {
+		return protocol.NewResponse("HELLO").WithAction("REPLY").WithResult("OK").WithVersion("3.3"), nil
+	})
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("net.Listen() error = %v", err)
+	}
+	go server.Serve(listener)
+	defer server.Close()
+
+	hello := func(conn net.Conn) (string, error) {
+		conn.SetDeadline(time.Now().Add(2 * time.Second))
+		conn.Write([]byte("HELLO VERSION MIN=3.0 MAX=3.3\n"))
+		return bufio.NewReader(conn).ReadString('\n')
+	}
+	dial := func() net.Conn {
+		conn, err := net.Dial("tcp", listener.Addr().String())
+		if err != nil {
+			t.Fatalf("net.Dial() error = %v", err)
+		}
+		return conn
+	}
+	waitFor := func(cond func(AcceptStats) bool) {
+		deadline := time.Now().Add(time.Second)
+		for !cond(server.AcceptStats()) && time.Now().Before(deadline) {
+			time.Sleep(5 * time.Millisecond)
+		}
+	}
+
+	conn1 := dial()
+	defer conn1.Close()
+	if _, err := hello(conn1); err != nil {
+		t.Fatalf("first connection: %v", err)
+	}
+
+	// The second connection waits for the first's slot
+	conn2 := dial()
+	defer conn2.Close()
+	waitFor(func(s AcceptStats) bool { return s.Queued == 1 })
+
+	// The queue is full, so the third is closed
+	conn3 := dial()
+	defer conn3.Close()
+	waitFor(func(s AcceptStats) bool { return s.Rejected == 1 })
+	if stats := server.AcceptStats(); stats.Queued != 1 || stats.Rejected != 1 {
+		t.Fatalf("AcceptStats() = %+v, want 1 queued and 1 rejected", stats)
+	}
+
+	conn1.Close()
+	if reply, err := hello(conn2); err != nil || !strings.Contains(reply, "RESULT=OK") {
+		t.Errorf("queued connection got %q, %v; want a HELLO REPLY", reply, err)
+	}
+	if stats := server.AcceptStats(); stats.Queued != 0 {
+		t.Errorf("AcceptStats().Queued = %d after the slot freed, want 0", stats.Queued)
+	}
+}
+
+func TestServer_MaxConnectionsPerIP(t *testing.T) {
+	config := DefaultConfig()
+	config.Limits.MaxConnectionsPerIP = 1
+
+	server, err := NewServer(config, newMockRegistry())
+	if err != nil {
+		t.Fatalf("NewServer() error = %v", err)
+	}
+	server.Router().RegisterFunc("HELLO", func(ctx *handler.Context, cmd *protocol.Command) (*protocol.Response, error) {
+		return protocol.NewResponse("HELLO").WithAction("REPLY").WithResult("OK").WithVersion("3.3"), nil
+	})
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("net.Listen() error = %v", err)
+	}
+	go server.Serve(listener)
+	defer server.Close()
+
+	hello := func() (net.Conn, string) {
+		conn, err := net.Dial("tcp", listener.Addr().String())
+		if err != nil {
+			t.Fatalf("net.Dial() error = %v", err)
+		}
+		conn.SetDeadline(time.Now().Add(2 * time.Second))
+		conn.Write([]byte("HELLO VERSION\n"))
+		reply, _ := bufio.NewReader(conn).ReadString('\n')
+		return conn, reply
+	}
+
+	conn1, reply := hello()
+	defer conn1.Close()
+	if !strings.Contains(reply, "RESULT=OK") {
+		t.Fatalf("first connection got %q, want RESULT=OK", reply)
+	}
+
+	conn2, reply := hello()
+	defer conn2.Close()
+	if !strings.HasPrefix(reply, "HELLO REPLY RESULT=I2P_ERROR") || !strings.Contains(reply, "too many connections from 127.0.0.1") {
+		t.Errorf("second connection got %q, want a per-IP limit error", reply)
+	}
+	if n := server.AcceptStats().RejectedPerIP; n != 1 {
+		t.Errorf("AcceptStats().RejectedPerIP = %d, want 1", n)
+	}
+
+	// Closing the first connection frees its place
+	conn1.Close()
+	deadline := time.Now().Add(time.Second)
+	for {
+		conn3, reply := hello()
+		conn3.Close()
+		if strings.Contains(reply, "RESULT=OK") {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("connection after close got %q, want RESULT=OK", reply)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestServer_IdleTimeout(t *testing.T) {
+	config := DefaultConfig()
+	config.Timeouts.Idle = 100 * time.Millisecond
+
+	server, err := NewServer(config, newMockRegistry())
+	if err != nil {
+		t.Fatalf("NewServer() error = %v", err)
+	}
+	server.Router().RegisterFunc("HELLO", func(ctx *handler.Context, cmd *protocol.Command) (*protocol.Response, error) {
+		return protocol.NewResponse("HELLO").WithAction("REPLY").WithResult("OK").WithVersion("3.3"), nil
+	})
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("net.Listen() error = %v", err)
+	}
+	go server.Serve(listener)
+	defer server.Close()
+
+	conn, err := net.Dial("tcp", listener.Addr().String())
+	if err != nil {
+		t.Fatalf("net.Dial() error = %v", err)
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(2 * time.Second))
+	reader := bufio.NewReader(conn)
+
+	conn.Write([]byte("HELLO VERSION\n"))
+	if line, err := reader.ReadString('\n'); err != nil || !strings.Contains(line, "RESULT=OK") {
+		t.Fatalf("HELLO got %q, %v", line, err)
+	}
+
+	line, err := reader.ReadString('\n')
+	if err != nil {
+		t.Fatalf("ReadString() error = %v", err)
+	}
+	if !strings.Contains(line, "idle without a session") {
+		t.Errorf("response = %q, want the idle timeout message", line)
+	}
+	if _, err := reader.ReadString('\n'); err != io.EOF {
+		t.Errorf("after idle timeout: err = %v, want EOF", err)
+	}
+}
+
+func TestServer_Stats(t *testing.T) {
+	server, err := NewServer(DefaultConfig(), newMockRegistry())
+	if err != nil {
+		t.Fatalf("NewServer() error = %v", err)
+	}
+	server.Router().RegisterFunc("HELLO", func(ctx *handler.Context, cmd *protocol.Command) (*protocol.Response, error) {
+		return protocol.NewResponse("HELLO").WithAction("REPLY").WithResult("OK").WithVersion("3.3"), nil
+	})
+	if stats := server.Stats(); !stats.StartedAt.IsZero() || stats.Uptime != 0 {
+		t.Errorf("Stats() before Serve = %+v, want zero start time", stats)
+	}
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("net.Listen() error = %v", err)
+	}
+	go server.Serve(listener)
+	defer server.Close()
+
+	conn, err := net.Dial("tcp", listener.Addr().String())
+	if err != nil {
+		t.Fatalf("net.Dial() error = %v", err)
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(2 * time.Second))
+	reader := bufio.NewReader(conn)
+	for _, line := range []string{"HELLO VERSION\n", "BOGUS\n"} {
+		conn.Write([]byte(line))
+		if _, err := reader.ReadString('\n'); err != nil {
+			t.Fatalf("ReadString() error = %v", err)
+		}
+	}
+
+	stats := server.Stats()
+	if stats.StartedAt.IsZero() || stats.Uptime <= 0 {
+		t.Errorf("Stats() StartedAt = %v, Uptime = %v, want set", stats.StartedAt, stats.Uptime)
+	}
+	if stats.ConnectionsTotal != 1 || stats.ConnectionsActive != 1 {
+		t.Errorf("Stats() connections = %d total, %d active, want 1, 1", stats.ConnectionsTotal, stats.ConnectionsActive)
+	}
+	if stats.Commands != 2 {
+		t.Errorf("Stats().Commands = %d, want 2", stats.Commands)
+	}
+}
+
+func TestServer_CommandLatencies(t *testing.T) {
+	server, err := NewServer(DefaultConfig(), newMockRegistry())
+	if err != nil {
+		t.Fatalf("NewServer() error = %v", err)
+	}
+	server.Router().RegisterFunc("HELLO VERSION", func(ctx *handler.Context, cmd *protocol.Command) (*protocol.Response, error) {
+		return protocol.NewResponse("HELLO").WithAction("REPLY").WithResult("OK").WithVersion("3.3"), nil
+	})
+	server.Router().RegisterFunc("NAMING LOOKUP", func(ctx *handler.Context, cmd *protocol.Command) (*protocol.Response, error) {
+		time.Sleep(30 * time.Millisecond)
+		return protocol.NewResponse("NAMING").WithAction("REPLY").WithResult("KEY_NOT_FOUND"), nil
+	})
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("net.Listen() error = %v", err)
+	}
+	go server.Serve(listener)
+	defer server.Close()
+
+	conn, err := net.Dial("tcp", listener.Addr().String())
+	if err != nil {
+		t.Fatalf("net.Dial() error = %v", err)
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(2 * time.Second))
+	reader := bufio.NewReader(conn)
+	for _, line := range []string{"HELLO VERSION\n", "naming lookup NAME=a.i2p\n", "BOGUS\n", "NAMING LOOKUP NAME=b.i2p\n"} {
+		conn.Write([]byte(line))
+		if _, err := reader.ReadString('\n'); err != nil {
+			t.Fatalf("ReadString() error = %v", err)
+		}
+	}
+
+	// The last command is recorded just after its response is written.
+	var latencies []CommandLatency
+	for deadline := time.Now().Add(2 * time.Second); time.Now().Before(deadline); time.Sleep(5 * time.Millisecond) {
+		latencies = server.CommandLatencies()
+		if len(latencies) == 2 && latencies[1].Count == 2 {
+			break
+		}
+	}
+	if len(latencies) != 2 || latencies[0].Command != "HELLO VERSION" || latencies[1].Command != "NAMING LOOKUP" {
+		t.Fatalf("CommandLatencies() = %+v, want HELLO VERSION and NAMING LOOKUP", latencies)
+	}
+	lookup := latencies[1]
+	if lookup.Count != 2 || lookup.Sum < 60*time.Millisecond {
+		t.Errorf("NAMING LOOKUP latency = %d commands in %v, want 2 in at least 60ms", lookup.Count, lookup.Sum)
+	}
+	// Buckets are cumulative: none within 25ms, both within two minutes.
+	if lookup.Buckets[3] != 0 || lookup.Buckets[len(LatencyBuckets)-1] != 2 {
+		t.Errorf("NAMING LOOKUP buckets = %v, want both lookups above 25ms", lookup.Buckets)
+	}
+}
+
+func TestServer_ReleasesSessionOnDisconnect(t *testing.T) {
+	registry := newMockRegistry()
+	server, err := NewServer(DefaultConfig(), registry)
+	if err != nil {
+		t.Fatalf("NewServer() error = %v", err)
+	}
+	server.Router().RegisterFunc("HELLO", func(ctx *handler.Context, cmd *protocol.Command) (*protocol.Response, error) {
+		return protocol.NewResponse("HELLO").WithAction("REPLY").WithResult("OK").WithVersion("3.3"), nil
+	})
+	server.Router().RegisterFunc("SESSION CREATE", func(ctx *handler.Context, cmd *protocol.Command) (*protocol.Response, error) {
+		sess := &mockSession{id: cmd.Get("ID")}
+		ctx.Registry.Register(sess)
+		ctx.BindSession(sess)
+		return protocol.NewResponse("SESSION").WithAction("STATUS").WithResult("OK"), nil
+	})
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("net.Listen() error = %v", err)
+	}
+	go server.Serve(listener)
+	defer server.Close()
+
+	conn, err := net.Dial("tcp", listener.Addr().String())
+	if err != nil {
+		t.Fatalf("net.Dial() error = %v", err)
+	}
+	reader := bufio.NewReader(conn)
+	conn.Write([]byte("HELLO VERSION MIN=3.0 MAX=3.3\n"))
+	reader.ReadString('\n')
+	conn.Write([]byte("SESSION CREATE STYLE=STREAM ID=s1 DESTINATION=TRANSIENT\n"))
+	if line, err := reader.ReadString('\n'); err != nil || !strings.Contains(line, "RESULT=OK") {
+		t.Fatalf("SESSION CREATE = %q, %v, want RESULT=OK", line, err)
+	}
+	if registry.Get("s1") == nil {
+		t.Fatal("session s1 is not registered")
+	}
+
+	conn.Close()
+	deadline := time.Now().Add(5 * time.Second)
+	for registry.Get("s1") != nil {
+		if time.Now().After(deadline) {
+			t.Fatal("session s1 still registered after its control socket closed")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
@@ -0,0 +1,62 @@
+package bridge
+
+import (
+	"crypto/tls"
+	"fmt"
+	"sync/atomic"
+)
+
+// CertReloader serves a TLS certificate loaded from disk and allows it to be
+// replaced at runtime. Per SAM 3.2, optional SSL/TLS support may be offered;
+// long-running bridges use this to rotate certificates without a restart.
+//
+// Wire it into a tls.Config through GetCertificate (see TLSConfig). New
+// handshakes pick up the reloaded certificate; established connections
+// are unaffected.
+type CertReloader struct {
+	certFile string
+	keyFile  string
+	cert     atomic.Pointer[tls.Certificate]
+}
+
+// NewCertReloader loads the certificate and key from the given files.
+// Returns an error if the initial load fails.
+func NewCertReloader(certFile, keyFile string) (*CertReloader, error) {
+	r := &CertReloader{certFile: certFile, keyFile: keyFile}
+	if err := r.Reload(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Reload reads the certificate and key from disk again.
+// If loading fails, the previously loaded certificate stays in use.
+func (r *CertReloader) Reload() error {
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return fmt.Errorf("loading TLS key pair: %w", err)
+	}
+	r.cert.Store(&cert)
+	return nil
+}
+
+// GetCertificate returns the current certificate.
+// Its signature matches tls.Config.GetCertificate.
+func (r *CertReloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	return r.cert.Load(), nil
+}
+
+// TLSConfig returns a copy of base (or a new config if base is nil) that
+// serves certificates from this reloader. Static Certificates in base are
+// cleared so GetCertificate is always consulted.
+func (r *CertReloader) TLSConfig(base *tls.Config) *tls.Config {
+	var cfg *tls.Config
+	if base != nil {
+		cfg = base.Clone()
+	} else {
+		cfg = &tls.Config{}
+	}
+	cfg.Certificates = nil
+	cfg.GetCertificate = r.GetCertificate
+	return cfg
+}
@@ -0,0 +1,147 @@
+package bridge
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// writeTestKeyPair writes a self-signed certificate with the given common
+// name to dir and returns the certificate and key paths.
+func writeTestKeyPair(t *testing.T, dir, commonName string) (string, string) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey() error = %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: commonName},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("CreateCertificate() error = %v", err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatalf("MarshalECPrivateKey() error = %v", err)
+	}
+
+	certFile := filepath.Join(dir, "cert.pem")
+	keyFile := filepath.Join(dir, "key.pem")
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+	if err := os.WriteFile(certFile, certPEM, 0o600); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+	if err := os.WriteFile(keyFile, keyPEM, 0o600); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+	return certFile, keyFile
+}
+
+func leafCommonName(t *testing.T, cert *tls.Certificate) string {
+	t.Helper()
+	leaf, err := x509.ParseCertificate(cert.Certificate[0])
+	if err != nil {
+		t.Fatalf("ParseCertificate() error = %v", err)
+	}
+	return leaf.Subject.CommonName
+}
+
+func TestCertReloader_Reload(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := writeTestKeyPair(t, dir, "first")
+
+	r, err := NewCertReloader(certFile, keyFile)
+	if err != nil {
+		t.Fatalf("NewCertReloader() error = %v", err)
+	}
+
+	cert, _ := r.GetCertificate(nil)
+	if name := leafCommonName(t, cert); name != "first" {
+		t.Errorf("CommonName = %q, want %q", name, "first")
+	}
+
+	writeTestKeyPair(t, dir, "second")
+	if err := r.Reload(); err != nil {
+		t.Fatalf("Reload() error = %v", err)
+	}
+
+	cert, _ = r.GetCertificate(nil)
+	if name := leafCommonName(t, cert); name != "second" {
+		t.Errorf("CommonName after Reload() = %q, want %q", name, "second")
+	}
+}
+
+func TestCertReloader_ReloadFailureKeepsCertificate(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := writeTestKeyPair(t, dir, "first")
+
+	r, err := NewCertReloader(certFile, keyFile)
+	if err != nil {
+		t.Fatalf("NewCertReloader() error = %v", err)
+	}
+
+	if err := os.WriteFile(certFile, []byte("garbage"), 0o600); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+	if err := r.Reload(); err == nil {
+		t.Error("Reload() with invalid certificate should return error")
+	}
+
+	cert, _ := r.GetCertificate(nil)
+	if name := leafCommonName(t, cert); name != "first" {
+		t.Errorf("CommonName after failed Reload() = %q, want %q", name, "first")
+	}
+}
+
+func TestNewCertReloader_MissingFiles(t *testing.T) {
+	dir := t.TempDir()
+	if _, err := NewCertReloader(filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem")); err == nil {
+		t.Error("NewCertReloader() with missing files should return error")
+	}
+}
+
+func TestCertReloader_TLSConfig(t *testing.T) {
+	certFile, keyFile := writeTestKeyPair(t, t.TempDir(), "test")
+	r, err := NewCertReloader(certFile, keyFile)
+	if err != nil {
+		t.Fatalf("NewCertReloader() error = %v", err)
+	}
+
+	base := &tls.Config{MinVersion: tls.VersionTLS13, Certificates: []tls.Certificate{{}}}
+	cfg := r.TLSConfig(base)
+
+	if cfg == base {
+		t.Error("TLSConfig() should return a copy of base")
+	}
+	if cfg.MinVersion != tls.VersionTLS13 {
+		t.Errorf("MinVersion = %x, want %x", cfg.MinVersion, tls.VersionTLS13)
+	}
+	if len(cfg.Certificates) != 0 {
+		t.Error("TLSConfig() should clear static certificates")
+	}
+	if cfg.GetCertificate == nil {
+		t.Error("TLSConfig() should set GetCertificate")
+	}
+	if len(base.Certificates) != 1 {
+		t.Error("TLSConfig() must not modify base")
+	}
+
+	if r.TLSConfig(nil).GetCertificate == nil {
+		t.Error("TLSConfig(nil) should set GetCertificate")
+	}
+}
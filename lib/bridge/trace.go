@@ -0,0 +1,85 @@
+package bridge
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// TraceDirection tells whether a traced line was received from or sent
+// to the client.
+type TraceDirection string
+
+const (
+	// TraceIn marks a command line received from the client.
+	TraceIn TraceDirection = "in"
+	// TraceOut marks a reply line sent to the client.
+	TraceOut TraceDirection = "out"
+)
+
+// TraceRecord is one SAM line seen on a client connection.
+type TraceRecord struct {
+	Time       time.Time
+	RemoteAddr string
+	Direction  TraceDirection
+
+	// Line is the line without its newline, with PASSWORD, PRIV and
+	// DESTINATION values other than TRANSIENT redacted. Payloads of
+	// DATAGRAM, RAW and STREAM data are never traced.
+	Line string
+}
+
+// TraceWriter returns a Config.Trace function that writes one line of
+// text per record to w, for example:
+//
+//	2026-01-02T15:04:05.000Z 127.0.0.1:50000 > HELLO VERSION MIN=3.1
+//	2026-01-02T15:04:05.001Z 127.0.0.1:50000 < HELLO REPLY RESULT=OK VERSION=3.3
+//
+// Write errors are ignored. The function is safe for concurrent use.
+func TraceWriter(w io.Writer) func(TraceRecord) {
+	var mu sync.Mutex
+	return func(rec TraceRecord) {
+		arrow := ">"
+		if rec.Direction == TraceOut {
+			arrow = "<"
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		fmt.Fprintf(w, "%s %s %s %s\n", rec.Time.UTC().Format("2006-01-02T15:04:05.000Z07:00"), rec.RemoteAddr, arrow, rec.Line)
+	}
+}
+
+// secretOption matches options whose values are redacted from traces,
+// with a quoted or unquoted value: those of isSecretOption, and
+// DESTINATION.
+var secretOption = regexp.MustCompile(`(?i)(^|\s)(PASSWORD|PRIV|SECRET|AUTH_KEY|DESTINATION|i2cp\.leaseSetSecret|i2cp\.leaseSetClient\.[^\s=]*)=("(?:[^"\\]|\\.)*"?|\S*)`)
+
+// RedactLine replaces the values of secret options, such as PASSWORD,
+// PRIV and a private key DESTINATION, in a raw SAM line with
+// "[REDACTED]". It works on lines that fail to parse, so malformed
+// commands are redacted too.
+func RedactLine(line string) string {
+	return secretOption.ReplaceAllStringFunc(line, func(m string) string {
+		sub := secretOption.FindStringSubmatch(m)
+		if strings.EqualFold(strings.Trim(sub[3], `"`), "TRANSIENT") {
+			return m
+		}
+		return sub[1] + sub[2] + "=" + redactedValue
+	})
+}
+
+// trace passes line to Config.Trace, if set, redacted.
+func (s *Server) trace(c *Connection, dir TraceDirection, line string) {
+	if s.config.Trace == nil {
+		return
+	}
+	s.config.Trace(TraceRecord{
+		Time:       time.Now(),
+		RemoteAddr: c.RemoteAddr(),
+		Direction:  dir,
+		Line:       RedactLine(line),
+	})
+}
@@ -0,0 +1,97 @@
+package bridge
+
+import (
+	"bufio"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-i2p/go-sam-bridge/lib/handler"
+	"github.com/go-i2p/go-sam-bridge/lib/protocol"
+)
+
+func TestRedactLine(t *testing.T) {
+	tests := []struct {
+		line string
+		want string
+	}{
+		{"HELLO VERSION USER=alice PASSWORD=secret", "HELLO VERSION USER=alice PASSWORD=[REDACTED]"},
+		{`AUTH ADD USER=bob password="p w\"d" X=1`, "AUTH ADD USER=bob password=[REDACTED] X=1"},
+		{"SESSION CREATE STYLE=STREAM ID=a DESTINATION=abc~def", "SESSION CREATE STYLE=STREAM ID=a DESTINATION=[REDACTED]"},
+		{"SESSION CREATE STYLE=STREAM ID=a DESTINATION=TRANSIENT", "SESSION CREATE STYLE=STREAM ID=a DESTINATION=TRANSIENT"},
+		{"DEST REPLY PUB=pubkey PRIV=privkey", "DEST REPLY PUB=pubkey PRIV=[REDACTED]"},
+		{`SESSION CREATE DESTINATION="unterminated`, "SESSION CREATE DESTINATION=[REDACTED]"},
+		{"NAMING LOOKUP NAME=ME", "NAMING LOOKUP NAME=ME"},
+		{"SESSION CREATE ID=a i2cp.leaseSetSecret=hunter2 i2cp.leaseSetAuthType=2", "SESSION CREATE ID=a i2cp.leaseSetSecret=[REDACTED] i2cp.leaseSetAuthType=2"},
+		{"SESSION CREATE ID=a i2cp.leaseSetClient.psk.0=alice:a2V5 i2cp.leaseSetClient.dh.1=bob:a2V5", "SESSION CREATE ID=a i2cp.leaseSetClient.psk.0=[REDACTED] i2cp.leaseSetClient.dh.1=[REDACTED]"},
+		{"NAMING LOOKUP NAME=x.b32.i2p SECRET=hunter2 AUTH_KEY=a2V5", "NAMING LOOKUP NAME=x.b32.i2p SECRET=[REDACTED] AUTH_KEY=[REDACTED]"},
+		{"STREAM CONNECT ID=a FROM_DESTINATION=x", "STREAM CONNECT ID=a FROM_DESTINATION=x"},
+	}
+	for _, tt := range tests {
+		if got := RedactLine(tt.line); got != tt.want {
+			t.Errorf("RedactLine(%q) = %q, want %q", tt.line, got, tt.want)
+		}
+	}
+}
+
+func TestTraceWriter(t *testing.T) {
+	var sb strings.Builder
+	trace := TraceWriter(&sb)
+	at := time.Date(2026, 1, 2, 15, 4, 5, 0, time.UTC)
+	trace(TraceRecord{Time: at, RemoteAddr: "127.0.0.1:1", Direction: TraceIn, Line: "PING"})
+	trace(TraceRecord{Time: at, RemoteAddr: "127.0.0.1:1", Direction: TraceOut, Line: "PONG"})
+
+	want := "2026-01-02T15:04:05.000Z 127.0.0.1:1 > PING\n2026-01-02T15:04:05.000Z 127.0.0.1:1 < PONG\n"
+	if sb.String() != want {
+		t.Errorf("output = %q, want %q", sb.String(), want)
+	}
+}
+
+func TestServer_Trace(t *testing.T) {
+	var mu sync.Mutex
+	var records []TraceRecord
+	config := DefaultConfig()
+	config.Trace = func(rec TraceRecord) {
+		mu.Lock()
+		defer mu.Unlock()
+		records = append(records, rec)
+	}
+
+	server, err := NewServer(config, newMockRegistry())
+	if err != nil {
+		t.Fatalf("NewServer() error = %v", err)
+	}
+	server.Router().RegisterFunc("HELLO", func(ctx *handler.Context, cmd *protocol.Command) (*protocol.Response, error) {
+		return protocol.NewResponse("HELLO").WithAction("REPLY").WithResult("OK").WithVersion("3.3"), nil
+	})
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("net.Listen() error = %v", err)
+	}
+	go server.Serve(listener)
+	defer server.Close()
+
+	conn, err := net.Dial("tcp", listener.Addr().String())
+	if err != nil {
+		t.Fatalf("net.Dial() error = %v", err)
+	}
+	defer conn.Close()
+
+	conn.Write([]byte("HELLO VERSION MAX=3.3 USER=admin PASSWORD=secret\n"))
+	bufio.NewReader(conn).ReadString('\n')
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(records) != 2 {
+		t.Fatalf("got %d records, want 2: %+v", len(records), records)
+	}
+	if r := records[0]; r.Direction != TraceIn || r.Line != "HELLO VERSION MAX=3.3 USER=admin PASSWORD=[REDACTED]" {
+		t.Errorf("records[0] = %+v, want the redacted HELLO", r)
+	}
+	if r := records[1]; r.Direction != TraceOut || r.Line != "HELLO REPLY RESULT=OK VERSION=3.3" {
+		t.Errorf("records[1] = %+v, want the HELLO REPLY", r)
+	}
+}
@@ -5,12 +5,14 @@ package datagram
 import (
 	"fmt"
 	"net"
+	"strings"
 	"sync"
 )
 
 // ForwarderConfig holds configuration for a datagram forwarder.
 type ForwarderConfig struct {
-	// Host is the target host to forward datagrams to.
+	// Host is the target host to forward datagrams to. IPv6 addresses
+	// may be given with or without brackets.
 	// Default: "127.0.0.1"
 	Host string
 
@@ -23,6 +25,12 @@ type ForwarderConfig struct {
 	// - RAW: "FROM_PORT=nnn TO_PORT=nnn PROTOCOL=nnn\n"
 	// - DATAGRAM: "$destination\n" is prepended by the bridge itself
 	HeaderEnabled bool
+
+	// LocalAddr is the local host:port forwarded datagrams are sent from,
+	// so that a firewalled client can allow a fixed source address. Either
+	// part may be empty, e.g. ":7655" fixes only the port.
+	// Default: "" (any address, ephemeral port)
+	LocalAddr string
 }
 
 // Forwarder handles forwarding of received datagrams to client UDP sockets.
@@ -53,6 +61,7 @@ func NewForwarder(config ForwarderConfig) *Forwarder {
 		return nil
 	}
 
+	config.Host = strings.TrimSuffix(strings.TrimPrefix(config.Host, "["), "]")
 	if config.Host == "" {
 		config.Host = "127.0.0.1"
 	}
@@ -85,9 +94,17 @@ func (f *Forwarder) Start() error {
 	}
 	f.addr = addr
 
-	// Create UDP socket for sending
-	// We bind to any local port (":0") for outbound datagrams
-	conn, err := net.ListenPacket("udp", ":0")
+	// Create UDP socket for sending, in the target's address family so
+	// that an IPv6 target is not sent from an IPv4-only socket
+	network := "udp4"
+	if addr.IP.To4() == nil {
+		network = "udp6"
+	}
+	local := f.config.LocalAddr
+	if local == "" {
+		local = ":0"
+	}
+	conn, err := net.ListenPacket(network, local)
 	if err != nil {
 		return fmt.Errorf("failed to create forwarder socket: %w", err)
 	}
@@ -261,6 +278,17 @@ func (f *Forwarder) Addr() net.Addr {
 	return f.addr
 }
 
+// LocalAddr returns the local address forwarded datagrams are sent from,
+// or nil if the forwarder has not been started.
+func (f *Forwarder) LocalAddr() net.Addr {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	if f.conn == nil {
+		return nil
+	}
+	return f.conn.LocalAddr()
+}
+
 // FormatRawHeader formats the header line for RAW datagram forwarding.
 // Per SAM 3.2: "FROM_PORT=nnn TO_PORT=nnn PROTOCOL=nnn\n"
 func FormatRawHeader(fromPort, toPort, protocol int) string {
@@ -152,6 +152,107 @@ func TestForwarderStartAfterClose(t *testing.T) {
 	}
 }
 
+// TestForwarderIPv6 tests forwarding to an IPv6 target given with and
+// without brackets.
+func TestForwarderIPv6(t *testing.T) {
+	receiver, err := net.ListenPacket("udp6", "[::1]:0")
+	if err != nil {
+		t.Skipf("IPv6 loopback unavailable: %v", err)
+	}
+	defer receiver.Close()
+
+	receiverAddr := receiver.LocalAddr().(*net.UDPAddr)
+
+	for _, host := range []string{"::1", "[::1]"} {
+		t.Run(host, func(t *testing.T) {
+			f := NewForwarder(ForwarderConfig{
+				Host: host,
+				Port: receiverAddr.Port,
+			})
+			if err := f.Start(); err != nil {
+				t.Fatalf("Start failed: %v", err)
+			}
+			defer f.Close()
+
+			if err := f.ForwardRaw(0, 0, 18, []byte("v6")); err != nil {
+				t.Fatalf("ForwardRaw failed: %v", err)
+			}
+
+			buf := make([]byte, 64)
+			receiver.SetReadDeadline(time.Now().Add(time.Second))
+			n, _, err := receiver.ReadFrom(buf)
+			if err != nil {
+				t.Fatalf("Failed to read from receiver: %v", err)
+			}
+			if got := string(buf[:n]); got != "v6" {
+				t.Errorf("Expected %q, got %q", "v6", got)
+			}
+		})
+	}
+}
+
+// TestForwarderLocalAddr tests that forwarded datagrams are sent from the
+// configured local address.
+func TestForwarderLocalAddr(t *testing.T) {
+	receiver, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to create receiver: %v", err)
+	}
+	defer receiver.Close()
+
+	// Find a free port to send from
+	probe, err := net.ListenPacket("udp4", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to reserve local port: %v", err)
+	}
+	local := probe.LocalAddr().String()
+	probe.Close()
+
+	f := NewForwarder(ForwarderConfig{
+		Host:      "127.0.0.1",
+		Port:      receiver.LocalAddr().(*net.UDPAddr).Port,
+		LocalAddr: local,
+	})
+	if f.LocalAddr() != nil {
+		t.Error("LocalAddr() should be nil before Start()")
+	}
+	if err := f.Start(); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	defer f.Close()
+
+	if got := f.LocalAddr().String(); got != local {
+		t.Errorf("LocalAddr() = %s, want %s", got, local)
+	}
+	if err := f.ForwardRaw(0, 0, 18, []byte("x")); err != nil {
+		t.Fatalf("ForwardRaw failed: %v", err)
+	}
+
+	buf := make([]byte, 64)
+	receiver.SetReadDeadline(time.Now().Add(time.Second))
+	_, from, err := receiver.ReadFrom(buf)
+	if err != nil {
+		t.Fatalf("Failed to read from receiver: %v", err)
+	}
+	if from.String() != local {
+		t.Errorf("datagram source = %s, want %s", from, local)
+	}
+}
+
+// TestForwarderLocalAddrFamilyMismatch tests that an IPv4 bind address
+// cannot be used for an IPv6 target.
+func TestForwarderLocalAddrFamilyMismatch(t *testing.T) {
+	f := NewForwarder(ForwarderConfig{
+		Host:      "::1",
+		Port:      12345,
+		LocalAddr: "127.0.0.1:0",
+	})
+	if err := f.Start(); err == nil {
+		f.Close()
+		t.Error("Start with IPv4 LocalAddr for IPv6 target should fail")
+	}
+}
+
 // TestForwardRawWithoutStart tests that forwarding before start fails.
 func TestForwardRawWithoutStart(t *testing.T) {
 	f := NewForwarder(ForwarderConfig{
@@ -16,6 +16,7 @@ import (
 	"strings"
 	"sync"
 
+	"github.com/go-i2p/go-sam-bridge/lib/protocol"
 	"github.com/go-i2p/go-sam-bridge/lib/session"
 )
 
@@ -95,6 +96,10 @@ type UDPListener struct {
 	// addr is the listening address.
 	addr string
 
+	// packetConn is a pre-bound socket to serve instead of listening on addr.
+	// Nil unless created with NewUDPListenerFromConn.
+	packetConn net.PacketConn
+
 	// ctx controls the listener lifecycle.
 	ctx    context.Context
 	cancel context.CancelFunc
@@ -126,6 +131,16 @@ func NewUDPListener(addr string, registry session.Registry) *UDPListener {
 	}
 }
 
+// NewUDPListenerFromConn creates a UDP listener that serves datagrams on an
+// already bound socket, such as one passed in by systemd socket activation
+// or a test harness. The listener takes ownership of conn and closes it
+// on Close.
+func NewUDPListenerFromConn(conn net.PacketConn, registry session.Registry) *UDPListener {
+	l := NewUDPListener(conn.LocalAddr().String(), registry)
+	l.packetConn = conn
+	return l
+}
+
 // Start begins listening for UDP datagrams.
 // This method is non-blocking and starts a goroutine to handle incoming datagrams.
 func (l *UDPListener) Start() error {
@@ -141,10 +156,15 @@ func (l *UDPListener) Start() error {
 		return fmt.Errorf("listener already started")
 	}
 
-	// Create UDP listener using net.ListenPacket for interface compliance
-	conn, err := net.ListenPacket("udp", l.addr)
-	if err != nil {
-		return fmt.Errorf("failed to listen on UDP %s: %w", l.addr, err)
+	// Use the injected socket, or create a UDP listener using
+	// net.ListenPacket for interface compliance
+	conn := l.packetConn
+	if conn == nil {
+		var err error
+		conn, err = net.ListenPacket("udp", l.addr)
+		if err != nil {
+			return fmt.Errorf("failed to listen on UDP %s: %w", l.addr, err)
+		}
 	}
 	l.conn = conn
 
@@ -214,6 +234,9 @@ func (l *UDPListener) handleDatagram(data []byte, from net.Addr) {
 		return
 	}
 
+	// Drop options the client's SAM version does not define
+	header.limitToVersion(session.SAMVersion(sess))
+
 	// Route to session based on style
 	l.routeToSession(sess, header, payload)
 
@@ -296,9 +319,13 @@ func (l *UDPListener) Close() error {
 	// Signal shutdown
 	l.cancel()
 
-	// Close connection
-	if l.conn != nil {
-		if err := l.conn.Close(); err != nil {
+	// Close connection, including an injected socket that was never started
+	conn := l.conn
+	if conn == nil {
+		conn = l.packetConn
+	}
+	if conn != nil {
+		if err := conn.Close(); err != nil {
 			return err
 		}
 	}
@@ -391,6 +418,21 @@ func parseHeaderLine(line string) (*DatagramHeader, error) {
 	return header, nil
 }
 
+// limitToVersion clears the options that the header's version or the
+// version the session negotiated on its control connection predates:
+// FROM_PORT, TO_PORT and PROTOCOL before SAM 3.2, and SEND_TAGS,
+// TAG_THRESHOLD, EXPIRES and SEND_LEASESET before SAM 3.3. Like other
+// unknown options, they are ignored rather than rejected.
+func (h *DatagramHeader) limitToVersion(sessionVersion string) {
+	if !protocol.VersionSupportsPortInfo(h.Version) || !protocol.VersionSupportsPortInfo(sessionVersion) {
+		h.FromPort, h.ToPort, h.Protocol = 0, 0, 0
+	}
+	if !protocol.VersionSupportsSendOptions(h.Version) || !protocol.VersionSupportsSendOptions(sessionVersion) {
+		h.SendTags, h.TagThreshold, h.Expires = 0, 0, 0
+		h.SendLeaseSet = nil
+	}
+}
+
 // isValidSAMVersion checks if the version string is a valid SAM 3.x version.
 // Per SAM 3.2, any "3.x" format is allowed. Prior to 3.2, only "3.0" was valid.
 func isValidSAMVersion(version string) bool {
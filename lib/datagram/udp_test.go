@@ -185,6 +185,46 @@ func TestUDPListenerAddr(t *testing.T) {
 	}
 }
 
+// TestNewUDPListenerFromConn tests serving on an injected socket.
+func TestNewUDPListenerFromConn(t *testing.T) {
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("ListenPacket failed: %v", err)
+	}
+
+	listener := NewUDPListenerFromConn(pc, newMockSessionRegistry())
+	if err := listener.Start(); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	if listener.Addr().String() != pc.LocalAddr().String() {
+		t.Errorf("Addr() = %v, want injected socket address %v", listener.Addr(), pc.LocalAddr())
+	}
+
+	if err := listener.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	if _, err := pc.WriteTo([]byte("x"), pc.LocalAddr()); err == nil {
+		t.Error("Close should close the injected socket")
+	}
+}
+
+// TestNewUDPListenerFromConnCloseWithoutStart tests that an injected
+// socket is released even if the listener never started.
+func TestNewUDPListenerFromConnCloseWithoutStart(t *testing.T) {
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("ListenPacket failed: %v", err)
+	}
+
+	listener := NewUDPListenerFromConn(pc, newMockSessionRegistry())
+	if err := listener.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	if _, err := pc.WriteTo([]byte("x"), pc.LocalAddr()); err == nil {
+		t.Error("Close should close the injected socket")
+	}
+}
+
 // TestParseDatagramHeader tests header parsing.
 func TestParseDatagramHeader(t *testing.T) {
 	tests := []struct {
@@ -360,6 +400,40 @@ func TestParseDatagramHeaderOptions(t *testing.T) {
 	}
 }
 
+// TestDatagramHeaderLimitToVersion tests that options are dropped when the
+// header or session version predates them.
+func TestDatagramHeaderLimitToVersion(t *testing.T) {
+	tests := []struct {
+		name           string
+		data           []byte
+		sessionVersion string
+		wantToPort     int
+		wantSendTags   int
+	}{
+		{"3.0 header", []byte("3.0 nick dest~ TO_PORT=80 SEND_TAGS=4\n"), "", 0, 0},
+		{"3.2 header", []byte("3.2 nick dest~ TO_PORT=80 SEND_TAGS=4\n"), "", 80, 0},
+		{"3.3 header", []byte("3.3 nick dest~ TO_PORT=80 SEND_TAGS=4\n"), "", 80, 4},
+		{"3.3 header on 3.1 session", []byte("3.3 nick dest~ TO_PORT=80 SEND_TAGS=4\n"), "3.1", 0, 0},
+		{"3.3 header on 3.2 session", []byte("3.3 nick dest~ TO_PORT=80 SEND_TAGS=4\n"), "3.2", 80, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			header, _, err := ParseDatagramHeader(tt.data)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			header.limitToVersion(tt.sessionVersion)
+			if header.ToPort != tt.wantToPort {
+				t.Errorf("ToPort: got %d, want %d", header.ToPort, tt.wantToPort)
+			}
+			if header.SendTags != tt.wantSendTags {
+				t.Errorf("SendTags: got %d, want %d", header.SendTags, tt.wantSendTags)
+			}
+		})
+	}
+}
+
 // TestUDPListenerSendReceive tests sending a datagram and verifying it's handled.
 func TestUDPListenerSendReceive(t *testing.T) {
 	registry := newMockSessionRegistry()
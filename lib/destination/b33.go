@@ -0,0 +1,117 @@
+package destination
+
+import (
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"strings"
+
+	"github.com/go-i2p/common/signature"
+)
+
+// SigTypeRedDSA_SHA512_Ed25519 is the signature type of blinded
+// destinations. It is only used inside .b33 addresses and encrypted
+// LeaseSet2s, never for a destination's own keys.
+const SigTypeRedDSA_SHA512_Ed25519 = signature.SIGNATURE_TYPE_REDDSA_SHA512_ED25519
+
+// Flag bits of a .b33 address, per proposal 149.
+const (
+	b33FlagTwoByteSigTypes = 0x01
+	b33FlagSecret          = 0x02
+	b33FlagPerClientAuth   = 0x04
+)
+
+// b33PublicKeyLength is the signing public key length of the signature
+// types that can be blinded (Ed25519 and RedDSA).
+const b33PublicKeyLength = 32
+
+// ErrInvalidB33Address indicates a .b33 address that cannot be decoded.
+var ErrInvalidB33Address = errors.New("invalid b33 address")
+
+// BlindedAddress is a decoded .b33 address. It identifies a destination
+// that publishes an encrypted LeaseSet2 (i2cp.leaseSetType=5) by its
+// unblinded signing public key, and tells clients which credentials a
+// lookup needs. Like a .b32.i2p address it ends in ".b32.i2p"; it is told
+// apart by its length.
+type BlindedAddress struct {
+	// SigType is the destination's signature type, 7 or 11.
+	SigType int
+
+	// BlindedSigType is the signature type of the blinded key, 11.
+	BlindedSigType int
+
+	// PublicKey is the destination's signing public key.
+	PublicKey []byte
+
+	// SecretRequired reports that a lookup secret (i2cp.leaseSetSecret on
+	// the server) is needed to decrypt the lease set.
+	SecretRequired bool
+
+	// PerClientAuth reports that a per-client DH or PSK key is needed to
+	// decrypt the lease set.
+	PerClientAuth bool
+}
+
+// IsB33Address reports whether name has the form of a .b33 address: a
+// .b32.i2p name longer than the 52 characters of a destination hash.
+func IsB33Address(name string) bool {
+	lower := strings.ToLower(name)
+	return strings.HasSuffix(lower, B32Suffix) && len(lower)-len(B32Suffix) > 52
+}
+
+// ParseB33 decodes a .b33 address. Only addresses with one-byte signature
+// types are supported, which covers every type that can be blinded.
+func ParseB33(name string) (*BlindedAddress, error) {
+	if !IsB33Address(name) {
+		return nil, fmt.Errorf("%w: %q is not a .b33 address", ErrInvalidB33Address, name)
+	}
+	data, err := b32Encoding.DecodeString(strings.TrimSuffix(strings.ToLower(name), B32Suffix))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidB33Address, err)
+	}
+	if len(data) != 3+b33PublicKeyLength {
+		return nil, fmt.Errorf("%w: decoded to %d bytes, want %d", ErrInvalidB33Address, len(data), 3+b33PublicKeyLength)
+	}
+
+	// The first three bytes are masked with the CRC-32 of the public key.
+	check := crc32.ChecksumIEEE(data[3:])
+	flags := data[0] ^ byte(check)
+	addr := &BlindedAddress{
+		SigType:        int(data[1] ^ byte(check>>8)),
+		BlindedSigType: int(data[2] ^ byte(check>>16)),
+		PublicKey:      append([]byte(nil), data[3:]...),
+		SecretRequired: flags&b33FlagSecret != 0,
+		PerClientAuth:  flags&b33FlagPerClientAuth != 0,
+	}
+
+	if flags&b33FlagTwoByteSigTypes != 0 {
+		return nil, fmt.Errorf("%w: two-byte signature types are not supported", ErrInvalidB33Address)
+	}
+	if addr.SigType != SigTypeEd25519 && addr.SigType != SigTypeRedDSA_SHA512_Ed25519 {
+		return nil, fmt.Errorf("%w: signature type %d cannot be blinded", ErrInvalidB33Address, addr.SigType)
+	}
+	if addr.BlindedSigType != SigTypeRedDSA_SHA512_Ed25519 {
+		return nil, fmt.Errorf("%w: blinded signature type %d is not RedDSA", ErrInvalidB33Address, addr.BlindedSigType)
+	}
+	return addr, nil
+}
+
+// String encodes a as a .b33 address.
+func (a *BlindedAddress) String() string {
+	data := make([]byte, 3, 3+len(a.PublicKey))
+	if a.SecretRequired {
+		data[0] |= b33FlagSecret
+	}
+	if a.PerClientAuth {
+		data[0] |= b33FlagPerClientAuth
+	}
+	data[1] = byte(a.SigType)
+	data[2] = byte(a.BlindedSigType)
+	data = append(data, a.PublicKey...)
+
+	check := crc32.ChecksumIEEE(a.PublicKey)
+	data[0] ^= byte(check)
+	data[1] ^= byte(check >> 8)
+	data[2] ^= byte(check >> 16)
+	return b32Encoding.EncodeToString(data) + B32Suffix
+}
@@ -0,0 +1,93 @@
+package destination
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestBlindedAddress_RoundTrip(t *testing.T) {
+	key := bytes.Repeat([]byte{0x5a}, b33PublicKeyLength)
+	tests := []struct {
+		name string
+		addr BlindedAddress
+	}{
+		{"plain", BlindedAddress{SigType: SigTypeEd25519, BlindedSigType: SigTypeRedDSA_SHA512_Ed25519}},
+		{"secret", BlindedAddress{SigType: SigTypeEd25519, BlindedSigType: SigTypeRedDSA_SHA512_Ed25519, SecretRequired: true}},
+		{"per-client", BlindedAddress{SigType: SigTypeRedDSA_SHA512_Ed25519, BlindedSigType: SigTypeRedDSA_SHA512_Ed25519, PerClientAuth: true}},
+		{"both", BlindedAddress{SigType: SigTypeEd25519, BlindedSigType: SigTypeRedDSA_SHA512_Ed25519, SecretRequired: true, PerClientAuth: true}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.addr.PublicKey = key
+			name := tt.addr.String()
+			if !IsB33Address(name) {
+				t.Fatalf("IsB33Address(%q) = false", name)
+			}
+
+			got, err := ParseB33(strings.ToUpper(name[:10]) + name[10:])
+			if err != nil {
+				t.Fatalf("ParseB33(%q) error = %v", name, err)
+			}
+			if got.SigType != tt.addr.SigType || got.BlindedSigType != tt.addr.BlindedSigType ||
+				got.SecretRequired != tt.addr.SecretRequired || got.PerClientAuth != tt.addr.PerClientAuth ||
+				!bytes.Equal(got.PublicKey, key) {
+				t.Errorf("ParseB33() = %+v, want %+v", got, tt.addr)
+			}
+		})
+	}
+}
+
+func TestParseB33_Invalid(t *testing.T) {
+	valid := (&BlindedAddress{
+		SigType:        SigTypeEd25519,
+		BlindedSigType: SigTypeRedDSA_SHA512_Ed25519,
+		PublicKey:      bytes.Repeat([]byte{1}, b33PublicKeyLength),
+	}).String()
+
+	// Changing the key changes its checksum, so the decoded sig types are
+	// no longer valid.
+	corrupted := []byte(valid)
+	if corrupted[20] == 'a' {
+		corrupted[20] = 'b'
+	} else {
+		corrupted[20] = 'a'
+	}
+
+	tests := []struct {
+		name  string
+		input string
+	}{
+		{"b32 address", strings.Repeat("a", 52) + B32Suffix},
+		{"not base32", strings.Repeat("1", 56) + B32Suffix},
+		{"too long", strings.Repeat("a", 64) + B32Suffix},
+		{"bad checksum", string(corrupted)},
+		{"unblindable sig type", (&BlindedAddress{SigType: SigTypeECDSA_SHA256_P256, BlindedSigType: SigTypeRedDSA_SHA512_Ed25519, PublicKey: make([]byte, b33PublicKeyLength)}).String()},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ParseB33(tt.input); !errors.Is(err, ErrInvalidB33Address) {
+				t.Errorf("ParseB33(%q) error = %v, want ErrInvalidB33Address", tt.input, err)
+			}
+		})
+	}
+}
+
+func TestIsB33Address(t *testing.T) {
+	tests := []struct {
+		input string
+		want  bool
+	}{
+		{strings.Repeat("a", 56) + B32Suffix, true},
+		{strings.Repeat("A", 56) + ".B32.I2P", true},
+		{strings.Repeat("a", 52) + B32Suffix, false},
+		{"example.i2p", false},
+		{strings.Repeat("a", 56), false},
+	}
+	for _, tt := range tests {
+		if got := IsB33Address(tt.input); got != tt.want {
+			t.Errorf("IsB33Address(%q) = %v, want %v", tt.input, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,59 @@
+package destination
+
+import (
+	"crypto/sha256"
+	"encoding/base32"
+
+	commondest "github.com/go-i2p/common/destination"
+)
+
+// B32Suffix is the suffix of I2P Base32 addresses.
+const B32Suffix = ".b32.i2p"
+
+// b32Encoding is the lowercase, unpadded Base32 alphabet of .b32.i2p
+// addresses.
+var b32Encoding = base32.NewEncoding("abcdefghijklmnopqrstuvwxyz234567").WithPadding(base32.NoPadding)
+
+// Hash returns the I2P destination hash: the SHA-256 of the destination's
+// full serialized KeysAndCert, including its certificate.
+func Hash(dest *commondest.Destination) ([32]byte, error) {
+	if dest == nil {
+		return [32]byte{}, ErrInvalidDestination
+	}
+	data, err := dest.Bytes()
+	if err != nil {
+		return [32]byte{}, err
+	}
+	return sha256.Sum256(data), nil
+}
+
+// Base32 returns the 52-character .b32.i2p address of dest.
+func Base32(dest *commondest.Destination) (string, error) {
+	hash, err := Hash(dest)
+	if err != nil {
+		return "", err
+	}
+	return HashToBase32(hash), nil
+}
+
+// Base32FromBase64 returns the .b32.i2p address of a Base64 destination,
+// as carried in SAM commands and replies. Any private key material after
+// the destination is ignored. The hash covers the bytes as received:
+// go-i2p/common does not always re-serialize a parsed destination byte
+// for byte, so Base32 of the parsed value may differ.
+func Base32FromBase64(destBase64 string) (string, error) {
+	data, err := Base64Decode(destBase64)
+	if err != nil || len(data) == 0 {
+		return "", ErrInvalidDestination
+	}
+	_, remainder, err := commondest.ReadDestination(data)
+	if err != nil {
+		return "", ErrInvalidDestination
+	}
+	return HashToBase32(sha256.Sum256(data[:len(data)-len(remainder)])), nil
+}
+
+// HashToBase32 returns the .b32.i2p address encoding hash.
+func HashToBase32(hash [32]byte) string {
+	return b32Encoding.EncodeToString(hash[:]) + B32Suffix
+}
@@ -0,0 +1,52 @@
+package destination
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestBase32(t *testing.T) {
+	m := NewManager()
+	dest, _, err := m.Generate(SigTypeEd25519)
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+
+	b32, err := Base32(dest)
+	if err != nil {
+		t.Fatalf("Base32() error = %v", err)
+	}
+	if len(b32) != 52+len(B32Suffix) || !strings.HasSuffix(b32, B32Suffix) {
+		t.Errorf("Base32() = %q, want 52 characters and %s", b32, B32Suffix)
+	}
+	if want, _ := dest.Base32Address(); b32 != want {
+		t.Errorf("Base32() = %q, want %q", b32, want)
+	}
+
+	hash, err := Hash(dest)
+	if err != nil {
+		t.Fatalf("Hash() error = %v", err)
+	}
+	if got := HashToBase32(hash); got != b32 {
+		t.Errorf("HashToBase32() = %q, want %q", got, b32)
+	}
+
+	pub, err := m.EncodePublic(dest)
+	if err != nil {
+		t.Fatalf("EncodePublic() error = %v", err)
+	}
+	if got, err := Base32FromBase64(pub); err != nil || got != b32 {
+		t.Errorf("Base32FromBase64() = %q, %v, want %q", got, err, b32)
+	}
+}
+
+func TestBase32_Invalid(t *testing.T) {
+	if _, err := Base32(nil); err != ErrInvalidDestination {
+		t.Errorf("Base32(nil) error = %v, want ErrInvalidDestination", err)
+	}
+	for _, in := range []string{"", "not*base64", "AAAA"} {
+		if _, err := Base32FromBase64(in); err != ErrInvalidDestination {
+			t.Errorf("Base32FromBase64(%q) error = %v, want ErrInvalidDestination", in, err)
+		}
+	}
+}
@@ -0,0 +1,194 @@
+package destination
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"errors"
+	"strings"
+
+	commondest "github.com/go-i2p/common/destination"
+	"github.com/go-i2p/common/keys_and_cert"
+	"github.com/go-i2p/crypto/curve25519"
+
+	"github.com/go-i2p/go-sam-bridge/lib/util"
+)
+
+// ed25519SeedSize is the length of an Ed25519 signing private key in
+// Java I2P and i2pd key files, which store the seed only.
+const ed25519SeedSize = ed25519.SeedSize
+
+// x25519PublicKeySize is the length of an X25519 encryption public key,
+// which starts the 256-byte public key field of a destination.
+const x25519PublicKeySize = 32
+
+// ErrUnsupportedKeyFile indicates a private key file uses a key type this
+// package cannot convert.
+var ErrUnsupportedKeyFile = errors.New("unsupported private key file")
+
+// EncodePrivateKeyFile serializes a destination and its private keys in
+// the binary PrivateKeyFile format used by Java I2P and i2pd (e.g.
+// eepsite keys.dat files):
+//
+//	Destination || encryption private key || signing private key
+//
+// Key lengths follow the destination's key certificate: 256 bytes for
+// ElGamal or 32 for X25519, and the signing type's private key length.
+// privateKey is in the form returned by Generate. Ed25519 keys, which
+// this package holds as seed||public key, are written as the 32-byte
+// seed Java I2P and i2pd expect. Offline signature sections are
+// preserved.
+func EncodePrivateKeyFile(dest *commondest.Destination, privateKey []byte) ([]byte, error) {
+	if dest == nil {
+		return nil, ErrInvalidDestination
+	}
+	destBytes, err := dest.Bytes()
+	if err != nil {
+		return nil, util.NewSessionError("", "encode destination", err)
+	}
+
+	encLen, sigType := keyCertificateLayout(*dest)
+	sigLen, err := getSigningPrivateKeyLength(sigType)
+	if err != nil {
+		return nil, ErrUnsupportedKeyFile
+	}
+	if len(privateKey) < encLen+sigLen {
+		return nil, ErrInvalidPrivateKey
+	}
+
+	encKey := privateKey[:encLen]
+	sigKey := privateKey[encLen : encLen+sigLen]
+	rest := privateKey[encLen+sigLen:]
+	if isEd25519(sigType) {
+		sigKey = sigKey[:ed25519SeedSize]
+	}
+
+	out := make([]byte, 0, len(destBytes)+len(privateKey))
+	out = append(out, destBytes...)
+	out = append(out, encKey...)
+	out = append(out, sigKey...)
+	out = append(out, rest...)
+	return out, nil
+}
+
+// DecodePrivateKeyFile parses a binary PrivateKeyFile written by Java
+// I2P, i2pd, or EncodePrivateKeyFile. It returns the destination and the
+// private keys in the form used by Generate, Encode and SESSION CREATE.
+func DecodePrivateKeyFile(data []byte) (*commondest.Destination, []byte, error) {
+	if len(data) < keys_and_cert.KEYS_AND_CERT_MIN_SIZE {
+		return nil, nil, ErrInvalidPrivateKey
+	}
+	dest, remainder, err := parseDestination(data)
+	if err != nil {
+		return nil, nil, util.NewSessionError("", "parse destination", err)
+	}
+
+	encLen, sigType := keyCertificateLayout(dest)
+	if !isEd25519(sigType) {
+		// Other signing key types are stored identically
+		return &dest, remainder, nil
+	}
+	if len(remainder) < encLen+ed25519SeedSize {
+		return nil, nil, ErrInvalidPrivateKey
+	}
+
+	seed := remainder[encLen : encLen+ed25519SeedSize]
+	var sigKey []byte
+	if bytes.Equal(seed, make([]byte, ed25519SeedSize)) {
+		// An all-zero key marks an offline signature section; keep it zero
+		sigKey = make([]byte, ed25519.PrivateKeySize)
+	} else {
+		sigKey = ed25519.NewKeyFromSeed(seed)
+	}
+
+	privateKey := make([]byte, 0, len(remainder)+ed25519SeedSize)
+	privateKey = append(privateKey, remainder[:encLen]...)
+	privateKey = append(privateKey, sigKey...)
+	privateKey = append(privateKey, remainder[encLen+ed25519SeedSize:]...)
+	return &dest, privateKey, nil
+}
+
+// EncodeDestinationFile serializes the public part of a destination in
+// the binary form Java I2P and i2pd write for destination files and
+// address book entries, the same bytes SAM carries Base64-encoded.
+func EncodeDestinationFile(dest *commondest.Destination) ([]byte, error) {
+	if dest == nil {
+		return nil, ErrInvalidDestination
+	}
+	data, err := dest.Bytes()
+	if err != nil {
+		return nil, util.NewSessionError("", "encode destination", err)
+	}
+	return data, nil
+}
+
+// DecodeDestinationFile parses a public destination file written by Java
+// I2P, i2pd, or EncodeDestinationFile. Both the binary form and the I2P
+// Base64 text form (such as a .b64 file, with surrounding whitespace) are
+// accepted. Private keys following the destination, as in a
+// PrivateKeyFile, are ignored.
+func DecodeDestinationFile(data []byte) (*commondest.Destination, error) {
+	if text := strings.TrimSpace(string(data)); isBase64Text(text) {
+		if decoded, err := Base64Decode(text); err == nil {
+			data = decoded
+		}
+	}
+	if len(data) < keys_and_cert.KEYS_AND_CERT_MIN_SIZE {
+		return nil, ErrInvalidDestination
+	}
+	dest, _, err := parseDestination(data)
+	if err != nil {
+		return nil, util.NewSessionError("", "parse destination", err)
+	}
+	return &dest, nil
+}
+
+// isBase64Text reports whether s consists only of I2P Base64 characters.
+// A binary destination is practically never all printable.
+func isBase64Text(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, c := range s {
+		if (c < 'a' || c > 'z') && (c < 'A' || c > 'Z') && (c < '0' || c > '9') && c != '-' && c != '~' && c != '=' {
+			return false
+		}
+	}
+	return true
+}
+
+// parseDestination reads the destination at the start of data, like
+// commondest.ReadDestination, and returns the bytes after it.
+//
+// go-i2p/common builds X25519 encryption keys as empty slices, so a
+// parsed destination would serialize 32 bytes short; the key is restored
+// from data so Bytes, Base64 and the key file encoders round-trip.
+func parseDestination(data []byte) (commondest.Destination, []byte, error) {
+	dest, remainder, err := commondest.ReadDestination(data)
+	if err != nil {
+		return dest, remainder, err
+	}
+	kac := dest.KeysAndCert
+	if kac != nil && kac.KeyCertificate != nil && kac.KeyCertificate.PublicKeyType() == EncTypeECIES_X25519 &&
+		(kac.ReceivingPublic == nil || len(kac.ReceivingPublic.Bytes()) != x25519PublicKeySize) {
+		kac.ReceivingPublic = curve25519.Curve25519PublicKey(bytes.Clone(data[:x25519PublicKeySize]))
+	}
+	return dest, remainder, nil
+}
+
+// keyCertificateLayout returns the encryption private key length and the
+// signing type declared by the destination's key certificate.
+func keyCertificateLayout(dest commondest.Destination) (encLen, sigType int) {
+	encLen, sigType = 256, SigTypeDSA_SHA1
+	if dest.KeysAndCert != nil && dest.KeysAndCert.KeyCertificate != nil {
+		if dest.KeysAndCert.KeyCertificate.PublicKeyType() == EncTypeECIES_X25519 {
+			encLen = 32
+		}
+		sigType = dest.KeysAndCert.KeyCertificate.SigningPublicKeyType()
+	}
+	return encLen, sigType
+}
+
+// isEd25519 reports whether sigType uses Ed25519 keys.
+func isEd25519(sigType int) bool {
+	return sigType == SigTypeEd25519 || sigType == SigTypeEd25519ph
+}
@@ -0,0 +1,176 @@
+package destination
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestEncodePrivateKeyFile(t *testing.T) {
+	m := NewManager()
+	dest, privateKey, err := m.Generate(SigTypeEd25519)
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+	destBytes, err := dest.Bytes()
+	if err != nil {
+		t.Fatalf("Bytes() error = %v", err)
+	}
+
+	data, err := EncodePrivateKeyFile(dest, privateKey)
+	if err != nil {
+		t.Fatalf("EncodePrivateKeyFile() error = %v", err)
+	}
+
+	// Destination, 32-byte X25519 key, 32-byte Ed25519 seed
+	if want := len(destBytes) + 32 + ed25519.SeedSize; len(data) != want {
+		t.Fatalf("len = %d, want %d", len(data), want)
+	}
+	if !bytes.Equal(data[:len(destBytes)], destBytes) {
+		t.Error("key file should start with the destination")
+	}
+	seed := data[len(destBytes)+32:]
+	if !bytes.Equal(ed25519.NewKeyFromSeed(seed), privateKey[32:]) {
+		t.Error("signing key should be the Ed25519 seed of the private key")
+	}
+}
+
+func TestDecodePrivateKeyFile_RoundTrip(t *testing.T) {
+	m := NewManager()
+	dest, privateKey, err := m.Generate(SigTypeEd25519)
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+
+	data, err := EncodePrivateKeyFile(dest, privateKey)
+	if err != nil {
+		t.Fatalf("EncodePrivateKeyFile() error = %v", err)
+	}
+	gotDest, gotKey, err := DecodePrivateKeyFile(data)
+	if err != nil {
+		t.Fatalf("DecodePrivateKeyFile() error = %v", err)
+	}
+
+	if !bytes.Equal(gotKey, privateKey) {
+		t.Error("decoded private key should match the generated key")
+	}
+	if encLen, sigType := keyCertificateLayout(*gotDest); encLen != 32 || sigType != SigTypeEd25519 {
+		t.Errorf("decoded key layout = %d/%d, want X25519 with Ed25519", encLen, sigType)
+	}
+}
+
+func TestPrivateKeyFile_Invalid(t *testing.T) {
+	if _, err := EncodePrivateKeyFile(nil, nil); err != ErrInvalidDestination {
+		t.Errorf("EncodePrivateKeyFile(nil) error = %v, want ErrInvalidDestination", err)
+	}
+
+	dest, _, err := NewManager().Generate(SigTypeEd25519)
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+	if _, err := EncodePrivateKeyFile(dest, make([]byte, 10)); err != ErrInvalidPrivateKey {
+		t.Errorf("EncodePrivateKeyFile(short key) error = %v, want ErrInvalidPrivateKey", err)
+	}
+	if _, _, err := DecodePrivateKeyFile([]byte("short")); err != ErrInvalidPrivateKey {
+		t.Errorf("DecodePrivateKeyFile(short) error = %v, want ErrInvalidPrivateKey", err)
+	}
+}
+
+// The fixtures hold an ECIES-X25519/Ed25519 destination in the layout
+// i2pd and Java I2P write: keys.dat is a PrivateKeyFile, dest.dat the
+// binary public destination and dest.b64 its Base64 text.
+const fixtureB32 = "q5ln6avlw37psspt33kyalvdavvv7wi6yzqqkjcvohlwo5fxph3q.b32.i2p"
+
+func readFixture(t *testing.T, name string) []byte {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatalf("ReadFile() error = %v", err)
+	}
+	return data
+}
+
+func TestPrivateKeyFile_Fixture(t *testing.T) {
+	data := readFixture(t, "ed25519-x25519-keys.dat")
+
+	// Key certificate: type 5, length 4, signing type 7, crypto type 4
+	if cert := data[384:391]; !bytes.Equal(cert, []byte{5, 0, 4, 0, 7, 0, 4}) {
+		t.Fatalf("fixture certificate = %x", cert)
+	}
+
+	dest, privateKey, err := DecodePrivateKeyFile(data)
+	if err != nil {
+		t.Fatalf("DecodePrivateKeyFile() error = %v", err)
+	}
+	if len(privateKey) != 32+ed25519.PrivateKeySize {
+		t.Fatalf("private key length = %d, want %d", len(privateKey), 32+ed25519.PrivateKeySize)
+	}
+	if !bytes.Equal(privateKey[32:64], data[len(data)-ed25519.SeedSize:]) {
+		t.Error("signing key should expand the file's Ed25519 seed")
+	}
+
+	encoded, err := EncodePrivateKeyFile(dest, privateKey)
+	if err != nil {
+		t.Fatalf("EncodePrivateKeyFile() error = %v", err)
+	}
+	if !bytes.Equal(encoded, data) {
+		t.Error("re-encoded key file differs from the fixture")
+	}
+
+	// The SAM form of the key parses back to the same destination
+	priv, err := NewManager().Encode(dest, privateKey)
+	if err != nil {
+		t.Fatalf("Encode() error = %v", err)
+	}
+	b32, err := Base32FromBase64(priv)
+	if err != nil {
+		t.Fatalf("Base32FromBase64() error = %v", err)
+	}
+	if b32 != fixtureB32 {
+		t.Errorf("address = %s, want %s", b32, fixtureB32)
+	}
+}
+
+func TestDestinationFile_Fixture(t *testing.T) {
+	binary := readFixture(t, "ed25519-x25519-dest.dat")
+	text := readFixture(t, "ed25519-x25519-dest.b64")
+
+	for name, data := range map[string][]byte{
+		"binary":   binary,
+		"base64":   text,
+		"key file": readFixture(t, "ed25519-x25519-keys.dat"),
+	} {
+		t.Run(name, func(t *testing.T) {
+			dest, err := DecodeDestinationFile(data)
+			if err != nil {
+				t.Fatalf("DecodeDestinationFile() error = %v", err)
+			}
+			encoded, err := EncodeDestinationFile(dest)
+			if err != nil {
+				t.Fatalf("EncodeDestinationFile() error = %v", err)
+			}
+			if !bytes.Equal(encoded, binary) {
+				t.Error("re-encoded destination differs from the fixture")
+			}
+			b64, err := dest.Base64()
+			if err != nil {
+				t.Fatalf("Base64() error = %v", err)
+			}
+			if want := strings.TrimSpace(string(text)); b64 != want {
+				t.Errorf("Base64() = %s, want %s", b64, want)
+			}
+		})
+	}
+}
+
+func TestDestinationFile_Invalid(t *testing.T) {
+	if _, err := EncodeDestinationFile(nil); err != ErrInvalidDestination {
+		t.Errorf("EncodeDestinationFile(nil) error = %v, want ErrInvalidDestination", err)
+	}
+	if _, err := DecodeDestinationFile([]byte("AAAA\n")); err != ErrInvalidDestination {
+		t.Errorf("DecodeDestinationFile(short) error = %v, want ErrInvalidDestination", err)
+	}
+}
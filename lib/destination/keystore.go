@@ -0,0 +1,349 @@
+package destination
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// Key store errors.
+var (
+	// ErrKeyNotFound indicates the key store has no key under a name.
+	ErrKeyNotFound = errors.New("key not found")
+
+	// ErrKeyExists indicates Save would overwrite a stored key.
+	ErrKeyExists = errors.New("key already exists")
+
+	// ErrInvalidKeyName indicates a key name that is empty, too long, or
+	// not a plain file name.
+	ErrInvalidKeyName = errors.New("invalid key name")
+
+	// ErrKeyDecrypt indicates a stored key could not be decrypted, because
+	// the passphrase or KMS key is wrong or the file was modified.
+	ErrKeyDecrypt = errors.New("key decryption failed")
+)
+
+// MaxKeyNameLength is the longest name a key can be stored under.
+const MaxKeyNameLength = 64
+
+// KeyFileExt is appended to key names to form the file name.
+const KeyFileExt = ".key"
+
+// DefaultPassphraseIterations is the PBKDF2-HMAC-SHA256 iteration count
+// used by NewPassphraseWrapper.
+const DefaultPassphraseIterations = 600000
+
+// MaxPassphraseIterations is the highest PBKDF2 iteration count a stored
+// key may ask for. The count is read from the file before anything is
+// authenticated, so without a cap a modified file could stall Load.
+const MaxPassphraseIterations = 10 * DefaultPassphraseIterations
+
+// keyStoreMagic starts every key store file; the last byte is the format
+// version.
+const keyStoreMagic = "SAMKEYS\x01"
+
+// dataKeySize is the AES-256 key length of the per-file data keys.
+const dataKeySize = 32
+
+// KeyWrapper protects the per-file data keys of a KeyStore. Each file
+// is encrypted with a fresh random data key, which is stored alongside
+// it in wrapped form.
+type KeyWrapper interface {
+	// WrapKey encrypts dataKey and returns the wrapped form to store.
+	WrapKey(dataKey []byte) ([]byte, error)
+
+	// UnwrapKey recovers the data key from its wrapped form.
+	UnwrapKey(wrapped []byte) ([]byte, error)
+}
+
+// KMSWrapper is a KeyWrapper that delegates to callbacks, typically
+// calls to a key management service that encrypts data keys under a
+// master key it never releases.
+type KMSWrapper struct {
+	Encrypt func(dataKey []byte) ([]byte, error)
+	Decrypt func(wrapped []byte) ([]byte, error)
+}
+
+// WrapKey implements KeyWrapper.
+func (w KMSWrapper) WrapKey(dataKey []byte) ([]byte, error) {
+	return w.Encrypt(dataKey)
+}
+
+// UnwrapKey implements KeyWrapper. Failures are reported as ErrKeyDecrypt.
+func (w KMSWrapper) UnwrapKey(wrapped []byte) ([]byte, error) {
+	key, err := w.Decrypt(wrapped)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrKeyDecrypt, err)
+	}
+	return key, nil
+}
+
+// passphraseWrapper wraps data keys with AES-256-GCM under a key derived
+// from a passphrase with PBKDF2-HMAC-SHA256. The wrapped form is
+//
+//	iterations (4 bytes) || salt (16 bytes) || nonce (12 bytes) || sealed key
+type passphraseWrapper struct {
+	passphrase string
+	iterations int
+}
+
+// NewPassphraseWrapper returns a KeyWrapper that derives a key from
+// passphrase for every file, with a random salt.
+func NewPassphraseWrapper(passphrase string) KeyWrapper {
+	return &passphraseWrapper{passphrase: passphrase, iterations: DefaultPassphraseIterations}
+}
+
+// WrapKey implements KeyWrapper.
+func (w *passphraseWrapper) WrapKey(dataKey []byte) ([]byte, error) {
+	header := make([]byte, 4+16)
+	binary.BigEndian.PutUint32(header, uint32(w.iterations))
+	if _, err := rand.Read(header[4:]); err != nil {
+		return nil, err
+	}
+	gcm, err := w.cipher(header)
+	if err != nil {
+		return nil, err
+	}
+	return sealGCM(gcm, header, dataKey, header)
+}
+
+// UnwrapKey implements KeyWrapper.
+func (w *passphraseWrapper) UnwrapKey(wrapped []byte) ([]byte, error) {
+	if len(wrapped) < 4+16 {
+		return nil, ErrKeyDecrypt
+	}
+	header := wrapped[:4+16]
+	gcm, err := w.cipher(header)
+	if err != nil {
+		return nil, err
+	}
+	return openGCM(gcm, wrapped[len(header):], header)
+}
+
+// cipher derives the key-encryption key for the iterations and salt in
+// header.
+func (w *passphraseWrapper) cipher(header []byte) (cipher.AEAD, error) {
+	iterations := int(binary.BigEndian.Uint32(header))
+	if iterations < 1 || iterations > MaxPassphraseIterations {
+		return nil, fmt.Errorf("%w: %d PBKDF2 iterations", ErrKeyDecrypt, iterations)
+	}
+	kek, err := pbkdf2.Key(sha256.New, w.passphrase, header[4:], iterations, dataKeySize)
+	if err != nil {
+		return nil, err
+	}
+	return newGCM(kek)
+}
+
+// KeyStore saves destination private keys to a directory, encrypted at
+// rest with AES-256-GCM. Each file holds one key, named after it with
+// KeyFileExt appended, and is written with mode 0600.
+//
+// A KeyStore is safe for concurrent use; Save never overwrites a file.
+// Data keys are cached once unwrapped, so loading a key again, as every
+// SESSION CREATE DESTINATION=file:NAME does, costs neither another
+// PBKDF2 derivation nor another KMS call.
+type KeyStore struct {
+	dir     string
+	wrapper KeyWrapper
+
+	mu       sync.Mutex
+	dataKeys map[string][]byte // unwrapped data keys by wrapped form
+}
+
+// NewKeyStore returns a KeyStore for dir, protecting data keys with
+// wrapper, such as NewPassphraseWrapper or a KMSWrapper. The directory is
+// created on the first Save.
+func NewKeyStore(dir string, wrapper KeyWrapper) *KeyStore {
+	return &KeyStore{dir: dir, wrapper: wrapper}
+}
+
+// Dir returns the directory keys are stored in.
+func (s *KeyStore) Dir() string {
+	return s.dir
+}
+
+// Save encrypts privateKey and stores it as name. privateKey is the
+// binary private key that SESSION CREATE DESTINATION= and DEST REPLY
+// PRIV= carry Base64-encoded. Save returns ErrKeyExists rather than
+// replace a stored key.
+func (s *KeyStore) Save(name string, privateKey []byte) error {
+	path, err := s.path(name)
+	if err != nil {
+		return err
+	}
+	data, err := s.encrypt(privateKey)
+	if err != nil {
+		return fmt.Errorf("encrypt key %q: %w", name, err)
+	}
+
+	if err := os.MkdirAll(s.dir, 0o700); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if err != nil {
+		if errors.Is(err, fs.ErrExist) {
+			return fmt.Errorf("%w: %q", ErrKeyExists, name)
+		}
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		os.Remove(path)
+		return err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(path)
+		return err
+	}
+	return nil
+}
+
+// Load decrypts and returns the private key stored as name. It returns
+// ErrKeyNotFound if there is none, and ErrKeyDecrypt if the passphrase
+// or KMS key does not match.
+func (s *KeyStore) Load(name string) ([]byte, error) {
+	path, err := s.path(name)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, fmt.Errorf("%w: %q", ErrKeyNotFound, name)
+		}
+		return nil, err
+	}
+	privateKey, err := s.decrypt(data)
+	if err != nil {
+		return nil, fmt.Errorf("load key %q: %w", name, err)
+	}
+	return privateKey, nil
+}
+
+// path returns the file name for the key name, rejecting names that are
+// not plain file names.
+func (s *KeyStore) path(name string) (string, error) {
+	if name == "" || len(name) > MaxKeyNameLength || name[0] == '.' {
+		return "", fmt.Errorf("%w: %q", ErrInvalidKeyName, name)
+	}
+	for _, c := range name {
+		if (c < 'a' || c > 'z') && (c < 'A' || c > 'Z') && (c < '0' || c > '9') && c != '.' && c != '_' && c != '-' {
+			return "", fmt.Errorf("%w: %q may only contain letters, digits, '.', '_' and '-'", ErrInvalidKeyName, name)
+		}
+	}
+	return filepath.Join(s.dir, name+KeyFileExt), nil
+}
+
+// encrypt seals plaintext under a fresh data key. The file layout is
+//
+//	magic (8 bytes) || wrapped key length (2 bytes) || wrapped key ||
+//	nonce (12 bytes) || ciphertext
+//
+// with everything before the nonce authenticated as additional data.
+func (s *KeyStore) encrypt(plaintext []byte) ([]byte, error) {
+	dataKey := make([]byte, dataKeySize)
+	if _, err := rand.Read(dataKey); err != nil {
+		return nil, err
+	}
+	wrapped, err := s.wrapper.WrapKey(dataKey)
+	if err != nil {
+		return nil, err
+	}
+	if len(wrapped) > 0xffff {
+		return nil, fmt.Errorf("wrapped key is %d bytes, at most 65535 supported", len(wrapped))
+	}
+
+	header := make([]byte, 0, len(keyStoreMagic)+2+len(wrapped))
+	header = append(header, keyStoreMagic...)
+	header = binary.BigEndian.AppendUint16(header, uint16(len(wrapped)))
+	header = append(header, wrapped...)
+
+	gcm, err := newGCM(dataKey)
+	if err != nil {
+		return nil, err
+	}
+	return sealGCM(gcm, header, plaintext, header)
+}
+
+// decrypt reverses encrypt.
+func (s *KeyStore) decrypt(data []byte) ([]byte, error) {
+	if len(data) < len(keyStoreMagic)+2 || string(data[:len(keyStoreMagic)]) != keyStoreMagic {
+		return nil, fmt.Errorf("%w: not a key store file", ErrKeyDecrypt)
+	}
+	n := int(binary.BigEndian.Uint16(data[len(keyStoreMagic):]))
+	headerLen := len(keyStoreMagic) + 2 + n
+	if len(data) < headerLen {
+		return nil, fmt.Errorf("%w: truncated file", ErrKeyDecrypt)
+	}
+	header := data[:headerLen]
+	wrapped := header[len(keyStoreMagic)+2:]
+
+	s.mu.Lock()
+	dataKey, cached := s.dataKeys[string(wrapped)]
+	s.mu.Unlock()
+	if !cached {
+		var err error
+		if dataKey, err = s.wrapper.UnwrapKey(wrapped); err != nil {
+			return nil, err
+		}
+	}
+	gcm, err := newGCM(dataKey)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrKeyDecrypt, err)
+	}
+	plaintext, err := openGCM(gcm, data[headerLen:], header)
+	if err != nil {
+		return nil, err
+	}
+
+	// Only data keys that decrypted a file are cached.
+	if !cached {
+		s.mu.Lock()
+		if s.dataKeys == nil {
+			s.dataKeys = make(map[string][]byte)
+		}
+		s.dataKeys[string(wrapped)] = dataKey
+		s.mu.Unlock()
+	}
+	return plaintext, nil
+}
+
+// newGCM returns AES-GCM for key.
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// sealGCM appends a random nonce and the sealed plaintext to dst.
+func sealGCM(gcm cipher.AEAD, dst, plaintext, additional []byte) ([]byte, error) {
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	out := append(dst[:len(dst):len(dst)], nonce...)
+	return gcm.Seal(out, nonce, plaintext, additional), nil
+}
+
+// openGCM decrypts nonce || ciphertext as written by sealGCM.
+func openGCM(gcm cipher.AEAD, data, additional []byte) ([]byte, error) {
+	if len(data) < gcm.NonceSize() {
+		return nil, ErrKeyDecrypt
+	}
+	plaintext, err := gcm.Open(nil, data[:gcm.NonceSize()], data[gcm.NonceSize():], additional)
+	if err != nil {
+		return nil, ErrKeyDecrypt
+	}
+	return plaintext, nil
+}
@@ -0,0 +1,196 @@
+package destination
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// testPassphraseWrapper returns a passphrase wrapper with few iterations
+// so tests run quickly.
+func testPassphraseWrapper(passphrase string) KeyWrapper {
+	return &passphraseWrapper{passphrase: passphrase, iterations: 1000}
+}
+
+func TestKeyStore_SaveLoad(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "keys")
+	store := NewKeyStore(dir, testPassphraseWrapper("correct horse"))
+	key := []byte("private key material")
+
+	if _, err := store.Load("site"); !errors.Is(err, ErrKeyNotFound) {
+		t.Fatalf("Load() before Save error = %v, want ErrKeyNotFound", err)
+	}
+	if err := store.Save("site", key); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	got, err := store.Load("site")
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if !bytes.Equal(got, key) {
+		t.Errorf("Load() = %q, want %q", got, key)
+	}
+
+	path := filepath.Join(dir, "site"+KeyFileExt)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("ReadFile() error = %v", err)
+	}
+	if bytes.Contains(data, key) {
+		t.Error("key file contains the plaintext key")
+	}
+	if info, err := os.Stat(path); err == nil && info.Mode().Perm()&0o077 != 0 {
+		t.Errorf("key file mode = %v, want no group or other access", info.Mode().Perm())
+	}
+
+	if err := store.Save("site", key); !errors.Is(err, ErrKeyExists) {
+		t.Errorf("second Save() error = %v, want ErrKeyExists", err)
+	}
+}
+
+func TestKeyStore_WrongPassphrase(t *testing.T) {
+	dir := t.TempDir()
+	if err := NewKeyStore(dir, testPassphraseWrapper("right")).Save("site", []byte("key")); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	if _, err := NewKeyStore(dir, testPassphraseWrapper("wrong")).Load("site"); !errors.Is(err, ErrKeyDecrypt) {
+		t.Errorf("Load() with wrong passphrase error = %v, want ErrKeyDecrypt", err)
+	}
+}
+
+func TestKeyStore_Tampered(t *testing.T) {
+	dir := t.TempDir()
+	store := NewKeyStore(dir, testPassphraseWrapper("pass"))
+	if err := store.Save("site", []byte("key")); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	path := filepath.Join(dir, "site"+KeyFileExt)
+	data, _ := os.ReadFile(path)
+	data[len(data)-1] ^= 1
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.Load("site"); !errors.Is(err, ErrKeyDecrypt) {
+		t.Errorf("Load() of modified file error = %v, want ErrKeyDecrypt", err)
+	}
+
+	if err := os.WriteFile(path, []byte("not a key store file"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.Load("site"); !errors.Is(err, ErrKeyDecrypt) {
+		t.Errorf("Load() of foreign file error = %v, want ErrKeyDecrypt", err)
+	}
+}
+
+func TestKeyStore_KMSWrapper(t *testing.T) {
+	var wrapped int
+	kms := KMSWrapper{
+		Encrypt: func(dataKey []byte) ([]byte, error) {
+			wrapped++
+			out := append([]byte("kms:"), dataKey...)
+			return out, nil
+		},
+		Decrypt: func(w []byte) ([]byte, error) {
+			if !bytes.HasPrefix(w, []byte("kms:")) {
+				return nil, errors.New("unknown key")
+			}
+			return w[4:], nil
+		},
+	}
+
+	store := NewKeyStore(t.TempDir(), kms)
+	if err := store.Save("site", []byte("key")); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	if wrapped != 1 {
+		t.Errorf("Encrypt called %d times, want 1", wrapped)
+	}
+	if got, err := store.Load("site"); err != nil || string(got) != "key" {
+		t.Errorf("Load() = %q, %v, want key", got, err)
+	}
+
+	kms.Decrypt = func([]byte) ([]byte, error) { return nil, errors.New("access denied") }
+	if _, err := NewKeyStore(store.Dir(), kms).Load("site"); !errors.Is(err, ErrKeyDecrypt) {
+		t.Errorf("Load() with failing KMS error = %v, want ErrKeyDecrypt", err)
+	}
+}
+
+func TestKeyStore_CachesDataKeys(t *testing.T) {
+	var unwrapped int
+	kms := KMSWrapper{
+		Encrypt: func(dataKey []byte) ([]byte, error) { return append([]byte("kms:"), dataKey...), nil },
+		Decrypt: func(w []byte) ([]byte, error) {
+			unwrapped++
+			return w[4:], nil
+		},
+	}
+
+	store := NewKeyStore(t.TempDir(), kms)
+	if err := store.Save("site", []byte("key")); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	for i := 0; i < 3; i++ {
+		if got, err := store.Load("site"); err != nil || string(got) != "key" {
+			t.Fatalf("Load() = %q, %v, want key", got, err)
+		}
+	}
+	if unwrapped != 1 {
+		t.Errorf("Decrypt called %d times for three loads, want 1", unwrapped)
+	}
+
+	// The cached data key does not vouch for a modified ciphertext.
+	path := filepath.Join(store.Dir(), "site"+KeyFileExt)
+	data, _ := os.ReadFile(path)
+	data[len(data)-1] ^= 1
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.Load("site"); !errors.Is(err, ErrKeyDecrypt) {
+		t.Errorf("Load() of modified file error = %v, want ErrKeyDecrypt", err)
+	}
+}
+
+func TestPassphraseWrapper_IterationLimit(t *testing.T) {
+	w := testPassphraseWrapper("pass")
+	wrapped, err := w.WrapKey(bytes.Repeat([]byte{1}, dataKeySize))
+	if err != nil {
+		t.Fatalf("WrapKey() error = %v", err)
+	}
+
+	for _, iterations := range []uint32{0, MaxPassphraseIterations + 1, 0xffffffff} {
+		modified := bytes.Clone(wrapped)
+		binary.BigEndian.PutUint32(modified, iterations)
+		if _, err := w.UnwrapKey(modified); !errors.Is(err, ErrKeyDecrypt) {
+			t.Errorf("UnwrapKey() with %d iterations error = %v, want ErrKeyDecrypt", iterations, err)
+		}
+	}
+}
+
+func TestKeyStore_InvalidName(t *testing.T) {
+	store := NewKeyStore(t.TempDir(), testPassphraseWrapper("pass"))
+	for _, name := range []string{"", ".hidden", "../escape", "a/b", `a\b`, "with space", string(make([]byte, MaxKeyNameLength+1))} {
+		if err := store.Save(name, []byte("key")); !errors.Is(err, ErrInvalidKeyName) {
+			t.Errorf("Save(%q) error = %v, want ErrInvalidKeyName", name, err)
+		}
+		if _, err := store.Load(name); !errors.Is(err, ErrInvalidKeyName) {
+			t.Errorf("Load(%q) error = %v, want ErrInvalidKeyName", name, err)
+		}
+	}
+}
+
+func TestNewPassphraseWrapper(t *testing.T) {
+	w := NewPassphraseWrapper("pass")
+	wrapped, err := w.WrapKey(bytes.Repeat([]byte{1}, dataKeySize))
+	if err != nil {
+		t.Fatalf("WrapKey() error = %v", err)
+	}
+	got, err := w.UnwrapKey(wrapped)
+	if err != nil || !bytes.Equal(got, bytes.Repeat([]byte{1}, dataKeySize)) {
+		t.Errorf("UnwrapKey() = %x, %v", got, err)
+	}
+}
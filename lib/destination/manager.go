@@ -2,7 +2,9 @@
 package destination
 
 import (
+	"encoding/binary"
 	"errors"
+	"sync/atomic"
 
 	commondest "github.com/go-i2p/common/destination"
 	"github.com/go-i2p/common/keys_and_cert"
@@ -54,6 +56,43 @@ type ManagerImpl struct {
 
 	// cacheCapacity stores the maximum cache size set at construction.
 	cacheCapacity int
+
+	// Cache counters reported by CacheStats.
+	cacheHits      atomic.Uint64
+	cacheMisses    atomic.Uint64
+	cacheEvictions atomic.Uint64
+
+	// pool holds pre-generated destinations while StartPool is in effect.
+	pool atomic.Pointer[destPool]
+
+	// Pool counters reported by PoolStats.
+	poolHits   atomic.Uint64
+	poolMisses atomic.Uint64
+}
+
+// CacheStats is a snapshot of the destination cache counters.
+type CacheStats struct {
+	// Size is the number of cached destinations.
+	Size int
+
+	// Capacity is the most destinations the cache holds.
+	Capacity int
+
+	// Hits counts parses answered from the cache.
+	Hits uint64
+
+	// Misses counts parses that had to decode the destination.
+	Misses uint64
+
+	// Evictions counts destinations dropped to make room for new ones.
+	// ClearCache does not count as eviction.
+	Evictions uint64
+}
+
+// CacheStatsProvider is implemented by managers that cache parsed
+// destinations. ManagerImpl implements it.
+type CacheStatsProvider interface {
+	CacheStats() CacheStats
 }
 
 // NewManager creates a new destination manager with default cache size.
@@ -114,16 +153,36 @@ var (
 //   - Signing private key (64 bytes for Ed25519)
 //
 // Per SAMv3.md DEST GENERATE specification.
+//
+// If a pool was started with StartPool, a pre-generated destination is
+// returned when one is ready.
 func (m *ManagerImpl) Generate(signatureType int) (*commondest.Destination, []byte, error) {
+	if err := checkGenerateType(signatureType); err != nil {
+		return nil, nil, err
+	}
+	if dest, priv, ok := m.takePooled(); ok {
+		return dest, priv, nil
+	}
+	return generateEd25519()
+}
+
+// checkGenerateType returns ErrUnsupportedSignatureType unless
+// signatureType can be generated.
+func checkGenerateType(signatureType int) error {
 	if !IsValidSignatureType(signatureType) {
-		return nil, nil, ErrUnsupportedSignatureType
+		return ErrUnsupportedSignatureType
 	}
 
 	// Currently only Ed25519 is supported via go-i2p/keys
 	if signatureType != SigTypeEd25519 {
-		return nil, nil, ErrUnsupportedSignatureType
+		return ErrUnsupportedSignatureType
 	}
+	return nil
+}
 
+// generateEd25519 generates a new Ed25519 destination and its private
+// keys.
+func generateEd25519() (*commondest.Destination, []byte, error) {
 	// Use go-i2p/keys for proper key generation
 	keyStore, err := keys.NewDestinationKeyStore()
 	if err != nil {
@@ -178,13 +237,13 @@ func (m *ManagerImpl) Parse(privkeyBase64 string) (*commondest.Destination, []by
 	}
 
 	// Parse the destination using go-i2p/common
-	dest, remainder, err := commondest.ReadDestination(data)
+	dest, remainder, err := m.readDestination(data)
 	if err != nil {
 		return nil, nil, util.NewSessionError("", "parse destination", err)
 	}
 
 	// Remaining bytes are the private keys
-	return &dest, remainder, nil
+	return dest, remainder, nil
 }
 
 // ParseWithOffline decodes a Base64 private key string and also detects/parses
@@ -230,11 +289,50 @@ func (m *ManagerImpl) decodeAndParseDestination(privkeyBase64 string) (commondes
 		return commondest.Destination{}, nil, ErrInvalidPrivateKey
 	}
 
-	dest, remainder, err := commondest.ReadDestination(data)
+	dest, remainder, err := m.readDestination(data)
 	if err != nil {
 		return commondest.Destination{}, nil, util.NewSessionError("", "parse destination", err)
 	}
-	return dest, remainder, nil
+	return *dest, remainder, nil
+}
+
+// readDestination parses the destination at the start of data and
+// returns the bytes after it. Destinations are cached by the Base64 of
+// their public bytes, the same key ParsePublic uses, so a private key
+// parsed again for another SESSION CREATE skips decoding. Only the public
+// destination is cached, never the private keys that follow it.
+func (m *ManagerImpl) readDestination(data []byte) (*commondest.Destination, []byte, error) {
+	n := destinationLength(data)
+	if n == 0 {
+		dest, remainder, err := parseDestination(data)
+		return &dest, remainder, err
+	}
+
+	key := Base64Encode(data[:n])
+	if cached, ok := m.cacheGet(key); ok {
+		return cached, data[n:], nil
+	}
+	dest, remainder, err := parseDestination(data)
+	if err != nil {
+		return nil, nil, err
+	}
+	m.cacheAdd(key, &dest)
+	return &dest, remainder, nil
+}
+
+// destinationLength returns the length of the destination at the start
+// of data, read from its certificate header, or 0 if data is too short
+// to hold it.
+func destinationLength(data []byte) int {
+	if len(data) < keys_and_cert.KEYS_AND_CERT_MIN_SIZE {
+		return 0
+	}
+	certLen := int(binary.BigEndian.Uint16(data[keys_and_cert.KEYS_AND_CERT_DATA_SIZE+1:]))
+	n := keys_and_cert.KEYS_AND_CERT_MIN_SIZE + certLen
+	if n > len(data) {
+		return 0
+	}
+	return n
 }
 
 // buildParseResult creates the initial ParseResult with signature type.
@@ -302,7 +400,7 @@ func (m *ManagerImpl) ParsePublic(destBase64 string) (*commondest.Destination, e
 	}
 
 	// Check cache first (LRU cache is thread-safe)
-	if cached, ok := m.cache.Get(destBase64); ok {
+	if cached, ok := m.cacheGet(destBase64); ok {
 		return cached, nil
 	}
 
@@ -311,13 +409,13 @@ func (m *ManagerImpl) ParsePublic(destBase64 string) (*commondest.Destination, e
 		return nil, util.NewSessionError("", "parse destination", err)
 	}
 
-	dest, _, err := commondest.ReadDestination(data)
+	dest, _, err := parseDestination(data)
 	if err != nil {
 		return nil, util.NewSessionError("", "parse destination", err)
 	}
 
 	// Cache the parsed destination (LRU will evict oldest if at capacity)
-	m.cache.Add(destBase64, &dest)
+	m.cacheAdd(destBase64, &dest)
 
 	return &dest, nil
 }
@@ -366,5 +464,38 @@ func (m *ManagerImpl) CacheCapacity() int {
 	return m.cacheCapacity
 }
 
+// CacheStats returns the cache size and its hit, miss and eviction
+// counters.
+func (m *ManagerImpl) CacheStats() CacheStats {
+	return CacheStats{
+		Size:      m.cache.Len(),
+		Capacity:  m.cacheCapacity,
+		Hits:      m.cacheHits.Load(),
+		Misses:    m.cacheMisses.Load(),
+		Evictions: m.cacheEvictions.Load(),
+	}
+}
+
+// cacheGet looks up a cached destination and counts the hit or miss.
+func (m *ManagerImpl) cacheGet(key string) (*commondest.Destination, bool) {
+	dest, ok := m.cache.Get(key)
+	if ok {
+		m.cacheHits.Add(1)
+	} else {
+		m.cacheMisses.Add(1)
+	}
+	return dest, ok
+}
+
+// cacheAdd caches a parsed destination and counts the eviction, if any.
+func (m *ManagerImpl) cacheAdd(key string, dest *commondest.Destination) {
+	if m.cache.Add(key, dest) {
+		m.cacheEvictions.Add(1)
+	}
+}
+
 // Verify Manager interface compliance
-var _ Manager = (*ManagerImpl)(nil)
+var (
+	_ Manager            = (*ManagerImpl)(nil)
+	_ CacheStatsProvider = (*ManagerImpl)(nil)
+)
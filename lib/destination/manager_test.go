@@ -1,6 +1,7 @@
 package destination
 
 import (
+	"bytes"
 	"testing"
 )
 
@@ -157,6 +158,63 @@ func TestManagerImpl_Cache(t *testing.T) {
 	}
 }
 
+func TestManagerImpl_CacheStats(t *testing.T) {
+	gen := NewManager()
+	var privs, pubs []string
+	for i := 0; i < 3; i++ {
+		dest, priv, err := gen.Generate(SigTypeEd25519)
+		if err != nil {
+			t.Fatalf("Generate() error = %v", err)
+		}
+		encoded, err := gen.Encode(dest, priv)
+		if err != nil {
+			t.Fatalf("Encode() error = %v", err)
+		}
+		pub, err := gen.EncodePublic(dest)
+		if err != nil {
+			t.Fatalf("EncodePublic() error = %v", err)
+		}
+		privs = append(privs, encoded)
+		pubs = append(pubs, pub)
+	}
+
+	m := NewManagerWithCacheSize(2)
+	first, firstKey, err := m.Parse(privs[0])
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	result, err := m.ParseWithOffline(privs[0])
+	if err != nil {
+		t.Fatalf("ParseWithOffline() error = %v", err)
+	}
+	if !bytes.Equal(result.PrivateKey, firstKey) {
+		t.Error("cached ParseWithOffline() returned different private key bytes")
+	}
+	if pub, err := m.ParsePublic(pubs[0]); err != nil || pub != first {
+		t.Errorf("ParsePublic() = %p, %v; want cached %p", pub, err, first)
+	}
+
+	want := CacheStats{Size: 1, Capacity: 2, Hits: 2, Misses: 1}
+	if got := m.CacheStats(); got != want {
+		t.Errorf("CacheStats() = %+v, want %+v", got, want)
+	}
+
+	for _, priv := range privs[1:] {
+		if _, _, err := m.Parse(priv); err != nil {
+			t.Fatalf("Parse() error = %v", err)
+		}
+	}
+	want = CacheStats{Size: 2, Capacity: 2, Hits: 2, Misses: 3, Evictions: 1}
+	if got := m.CacheStats(); got != want {
+		t.Errorf("CacheStats() after filling = %+v, want %+v", got, want)
+	}
+
+	m.ClearCache()
+	if got := m.CacheStats(); got.Size != 0 || got.Evictions != 1 {
+		t.Errorf("CacheStats() after ClearCache() = %+v, want Size 0 and Evictions 1", got)
+	}
+}
+
 func TestNewManagerWithCacheSize(t *testing.T) {
 	t.Run("custom cache size", func(t *testing.T) {
 		m := NewManagerWithCacheSize(100)
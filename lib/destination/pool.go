@@ -0,0 +1,148 @@
+package destination
+
+import (
+	"sync"
+	"time"
+
+	commondest "github.com/go-i2p/common/destination"
+)
+
+// poolRetryDelay is how long the pool waits after a failed key
+// generation before trying again.
+const poolRetryDelay = time.Second
+
+// PoolStats is a snapshot of the destination pool counters.
+type PoolStats struct {
+	// Size is the number of destinations ready in the pool.
+	Size int
+
+	// Capacity is the most destinations the pool holds, or 0 if no pool
+	// is running.
+	Capacity int
+
+	// Hits counts Generate calls answered from the pool.
+	Hits uint64
+
+	// Misses counts Ed25519 Generate calls that found the pool empty
+	// while it was running.
+	Misses uint64
+}
+
+// PoolStatsProvider is implemented by managers that keep a pool of
+// pre-generated destinations. ManagerImpl implements it.
+type PoolStatsProvider interface {
+	PoolStats() PoolStats
+}
+
+var _ PoolStatsProvider = (*ManagerImpl)(nil)
+
+// destPool is a set of destinations generated ahead of time by a
+// background goroutine.
+type destPool struct {
+	ready    chan pooledDest
+	stop     chan struct{}
+	stopOnce sync.Once
+	done     chan struct{}
+}
+
+// pooledDest is one pre-generated destination and its private keys.
+type pooledDest struct {
+	dest *commondest.Destination
+	priv []byte
+}
+
+// StartPool keeps up to size Ed25519 destinations generated ahead of
+// time on a background goroutine, so that Generate, and with it
+// DEST GENERATE and SESSION CREATE DESTINATION=TRANSIENT, returns at once
+// when one is ready. Each pooled destination is handed out only once.
+//
+// The returned function stops the pool and zeroes the private keys of
+// destinations still in it. Starting a pool stops any earlier one.
+// StartPool does nothing if size <= 0.
+func (m *ManagerImpl) StartPool(size int) (stop func()) {
+	if size <= 0 {
+		return func() {}
+	}
+	p := &destPool{
+		ready: make(chan pooledDest, size),
+		stop:  make(chan struct{}),
+		done:  make(chan struct{}),
+	}
+	if old := m.pool.Swap(p); old != nil {
+		old.close()
+	}
+	go p.fill()
+
+	return func() {
+		m.pool.CompareAndSwap(p, nil)
+		p.close()
+	}
+}
+
+// PoolStats returns the current pool counters.
+func (m *ManagerImpl) PoolStats() PoolStats {
+	stats := PoolStats{
+		Hits:   m.poolHits.Load(),
+		Misses: m.poolMisses.Load(),
+	}
+	if p := m.pool.Load(); p != nil {
+		stats.Size = len(p.ready)
+		stats.Capacity = cap(p.ready)
+	}
+	return stats
+}
+
+// takePooled returns a destination from the pool, if one is running and
+// not empty.
+func (m *ManagerImpl) takePooled() (*commondest.Destination, []byte, bool) {
+	p := m.pool.Load()
+	if p == nil {
+		return nil, nil, false
+	}
+	select {
+	case d := <-p.ready:
+		m.poolHits.Add(1)
+		return d.dest, d.priv, true
+	default:
+		m.poolMisses.Add(1)
+		return nil, nil, false
+	}
+}
+
+// fill generates destinations until the pool is full, then waits for
+// room, until close is called.
+func (p *destPool) fill() {
+	defer close(p.done)
+	for {
+		dest, priv, err := generateEd25519()
+		if err != nil {
+			select {
+			case <-time.After(poolRetryDelay):
+				continue
+			case <-p.stop:
+				return
+			}
+		}
+		select {
+		case p.ready <- pooledDest{dest: dest, priv: priv}:
+		case <-p.stop:
+			clear(priv)
+			return
+		}
+	}
+}
+
+// close stops fill and zeroes the keys left in the pool. It is safe to
+// call more than once.
+func (p *destPool) close() {
+	p.stopOnce.Do(func() { close(p.stop) })
+	<-p.done
+	for {
+		select {
+		case d := <-p.ready:
+			clear(d.priv)
+		default:
+			return
+		}
+	}
+}
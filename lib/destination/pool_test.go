@@ -0,0 +1,68 @@
+package destination
+
+import (
+	"testing"
+	"time"
+)
+
+func TestManagerImpl_StartPool(t *testing.T) {
+	m := NewManager()
+	stop := m.StartPool(2)
+	defer stop()
+
+	deadline := time.Now().Add(10 * time.Second)
+	for m.PoolStats().Size < 2 {
+		if time.Now().After(deadline) {
+			t.Fatalf("pool not filled: %+v", m.PoolStats())
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	first, _, err := m.Generate(SigTypeEd25519)
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+	second, _, err := m.Generate(SigTypeEd25519)
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+	a, _ := m.EncodePublic(first)
+	b, _ := m.EncodePublic(second)
+	if a == b {
+		t.Error("pool handed out the same destination twice")
+	}
+
+	stats := m.PoolStats()
+	if stats.Hits != 2 || stats.Capacity != 2 {
+		t.Errorf("PoolStats() = %+v, want 2 hits and capacity 2", stats)
+	}
+
+	if _, _, err := m.Generate(SigTypeDSA_SHA1); err != ErrUnsupportedSignatureType {
+		t.Errorf("Generate(DSA) error = %v, want %v", err, ErrUnsupportedSignatureType)
+	}
+}
+
+func TestManagerImpl_StopPool(t *testing.T) {
+	m := NewManager()
+	stop := m.StartPool(1)
+	stop()
+	stop()
+
+	if stats := m.PoolStats(); stats.Capacity != 0 {
+		t.Errorf("PoolStats() after stop = %+v, want no pool", stats)
+	}
+	if _, _, err := m.Generate(SigTypeEd25519); err != nil {
+		t.Errorf("Generate() after stop error = %v", err)
+	}
+	if hits := m.PoolStats().Hits; hits != 0 {
+		t.Errorf("Hits = %d after stop, want 0", hits)
+	}
+}
+
+func TestManagerImpl_StartPoolZero(t *testing.T) {
+	m := NewManager()
+	m.StartPool(0)()
+	if stats := m.PoolStats(); stats != (PoolStats{}) {
+		t.Errorf("PoolStats() = %+v, want zero", stats)
+	}
+}
@@ -0,0 +1,176 @@
+package destination
+
+import (
+	"bytes"
+	"crypto/ecdh"
+	"crypto/ed25519"
+	"errors"
+	"fmt"
+
+	"github.com/go-i2p/common/keys_and_cert"
+)
+
+// Key validation and signing errors.
+var (
+	// ErrKeyMismatch indicates private keys that do not belong to the
+	// destination they were supplied with.
+	ErrKeyMismatch = errors.New("private key does not match destination")
+
+	// ErrOfflineKey indicates the destination's signing private key is
+	// kept offline, so only its transient key can sign.
+	ErrOfflineKey = errors.New("signing private key is offline")
+)
+
+// ValidateKeys checks that destBytes is a well-formed binary destination
+// with a key certificate and that privateKey, in the form Parse returns,
+// belongs to it:
+//
+//   - the key lengths match the types in the key certificate;
+//   - an X25519 encryption key derives the destination's public key;
+//   - an Ed25519 signing key derives the destination's signing public key.
+//
+// If the signing private key is all zeros, the offline signature section
+// that follows must be present, unexpired, signed by the destination, and
+// for Ed25519 hold a matching transient key pair. Ed25519 signing keys
+// are also accepted as the 32-byte seed Java I2P and i2pd write. Key
+// types other than X25519 and Ed25519 are checked for length only.
+func ValidateKeys(destBytes, privateKey []byte) error {
+	layout, err := readKeyLayout(destBytes)
+	if err != nil {
+		return err
+	}
+
+	sigPrivLen, err := getSigningPrivateKeyLength(layout.sigType)
+	if err != nil {
+		return ErrUnsupportedSignatureType
+	}
+	if isEd25519(layout.sigType) && len(privateKey) == layout.encLen+ed25519SeedSize {
+		sigPrivLen = ed25519SeedSize
+	}
+	if len(privateKey) < layout.encLen+sigPrivLen {
+		return fmt.Errorf("%w: %d bytes, want at least %d", ErrInvalidPrivateKey, len(privateKey), layout.encLen+sigPrivLen)
+	}
+
+	if layout.encLen == x25519PublicKeySize {
+		key, err := ecdh.X25519().NewPrivateKey(privateKey[:layout.encLen])
+		if err != nil || !bytes.Equal(key.PublicKey().Bytes(), destBytes[:x25519PublicKeySize]) {
+			return fmt.Errorf("%w: encryption key", ErrKeyMismatch)
+		}
+	}
+
+	sigPriv := privateKey[layout.encLen : layout.encLen+sigPrivLen]
+	if isAllZeros(sigPriv) {
+		return validateOffline(layout, privateKey[layout.encLen+sigPrivLen:])
+	}
+	if layout.sigType == SigTypeEd25519 && !bytes.Equal(ed25519PublicKey(sigPriv), layout.sigPub) {
+		return fmt.Errorf("%w: signing key", ErrKeyMismatch)
+	}
+	return nil
+}
+
+// Sign signs data with the signing private key in privateKey, which
+// must belong to destBytes. Only Ed25519 destinations are supported.
+// It returns ErrOfflineKey for destinations using offline signatures.
+func Sign(destBytes, privateKey, data []byte) ([]byte, error) {
+	layout, err := readKeyLayout(destBytes)
+	if err != nil {
+		return nil, err
+	}
+	if layout.sigType != SigTypeEd25519 {
+		return nil, ErrUnsupportedSignatureType
+	}
+	if len(privateKey) < layout.encLen+ed25519SeedSize {
+		return nil, ErrInvalidPrivateKey
+	}
+
+	seed := privateKey[layout.encLen : layout.encLen+ed25519SeedSize]
+	if isAllZeros(seed) {
+		return nil, ErrOfflineKey
+	}
+	key := ed25519.NewKeyFromSeed(seed)
+	defer clear(key)
+	if !bytes.Equal(key.Public().(ed25519.PublicKey), layout.sigPub) {
+		return nil, fmt.Errorf("%w: signing key", ErrKeyMismatch)
+	}
+	return ed25519.Sign(key, data), nil
+}
+
+// Verify reports whether sig is a valid signature of data by the signing
+// key of destBytes. Only Ed25519 destinations are supported; any other
+// destination never verifies.
+func Verify(destBytes, data, sig []byte) bool {
+	layout, err := readKeyLayout(destBytes)
+	if err != nil || layout.sigType != SigTypeEd25519 {
+		return false
+	}
+	return ed25519.Verify(layout.sigPub, data, sig)
+}
+
+// keyLayout describes the key types and signing public key of a binary
+// destination.
+type keyLayout struct {
+	encLen  int
+	sigType int
+	sigPub  []byte
+}
+
+// readKeyLayout parses destBytes, which must hold exactly one destination
+// with a key certificate.
+func readKeyLayout(destBytes []byte) (keyLayout, error) {
+	if n := destinationLength(destBytes); n == 0 || n != len(destBytes) {
+		return keyLayout{}, fmt.Errorf("%w: %d bytes is not one destination", ErrInvalidDestination, len(destBytes))
+	}
+	dest, _, err := parseDestination(destBytes)
+	if err != nil || dest.KeysAndCert == nil || dest.KeysAndCert.KeyCertificate == nil {
+		return keyLayout{}, fmt.Errorf("%w: no key certificate", ErrInvalidDestination)
+	}
+
+	encLen, sigType := keyCertificateLayout(dest)
+	sigPubLen, err := getSigningPublicKeyLength(sigType)
+	if err != nil || sigPubLen > keys_and_cert.KEYS_AND_CERT_SPK_SIZE {
+		// Larger keys spill into the certificate; not supported here
+		return keyLayout{}, ErrUnsupportedSignatureType
+	}
+	end := keys_and_cert.KEYS_AND_CERT_DATA_SIZE
+	return keyLayout{encLen: encLen, sigType: sigType, sigPub: destBytes[end-sigPubLen : end]}, nil
+}
+
+// validateOffline checks the offline signature section of a destination
+// whose signing private key is all zeros.
+func validateOffline(layout keyLayout, offlineData []byte) error {
+	offline, err := ParseOfflineSignature(offlineData, layout.sigType)
+	if err != nil {
+		return err
+	}
+	if offline.IsExpired() {
+		return ErrOfflineSignatureExpired
+	}
+
+	if layout.sigType == SigTypeEd25519 {
+		// Signed are expires, transient type and transient public key
+		signed := offlineData[:6+len(offline.TransientPublicKey)]
+		if !ed25519.Verify(layout.sigPub, signed, offline.Signature) {
+			return fmt.Errorf("%w: bad offline signature", ErrInvalidOfflineSignature)
+		}
+	}
+	if offline.TransientSigType == SigTypeEd25519 &&
+		!bytes.Equal(ed25519PublicKey(offline.TransientPrivateKey), offline.TransientPublicKey) {
+		return fmt.Errorf("%w: transient signing key", ErrKeyMismatch)
+	}
+	return nil
+}
+
+// ed25519PublicKey returns the public key for an Ed25519 private key in
+// seed or seed||public key form. A 64-byte key whose public half does not
+// match its seed yields nil.
+func ed25519PublicKey(priv []byte) []byte {
+	if len(priv) < ed25519SeedSize {
+		return nil
+	}
+	key := ed25519.NewKeyFromSeed(priv[:ed25519SeedSize])
+	defer clear(key)
+	if len(priv) == ed25519.PrivateKeySize && !bytes.Equal(key, priv) {
+		return nil
+	}
+	return bytes.Clone(key[ed25519SeedSize:])
+}
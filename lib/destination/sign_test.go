@@ -0,0 +1,107 @@
+package destination
+
+import (
+	"crypto/ed25519"
+	"encoding/binary"
+	"errors"
+	"testing"
+	"time"
+)
+
+// generateKeys returns a fresh Ed25519 destination's bytes and private key.
+func generateKeys(t *testing.T) ([]byte, []byte) {
+	t.Helper()
+	dest, priv, err := NewManager().Generate(SigTypeEd25519)
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+	destBytes, err := dest.Bytes()
+	if err != nil {
+		t.Fatalf("Bytes() error = %v", err)
+	}
+	return destBytes, priv
+}
+
+// offlineKey replaces the signing key of priv with an offline signature
+// section signed by signer, valid until expires.
+func offlineKey(t *testing.T, priv, signer []byte, expires time.Time) []byte {
+	t.Helper()
+	transientPub, transientPriv, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatalf("GenerateKey() error = %v", err)
+	}
+	signed := binary.BigEndian.AppendUint32(nil, uint32(expires.Unix()))
+	signed = binary.BigEndian.AppendUint16(signed, uint16(SigTypeEd25519))
+	signed = append(signed, transientPub...)
+
+	out := append([]byte(nil), priv[:32]...)
+	out = append(out, make([]byte, ed25519.PrivateKeySize)...)
+	out = append(out, signed...)
+	out = append(out, ed25519.Sign(ed25519.PrivateKey(signer[32:96]), signed)...)
+	return append(out, transientPriv...)
+}
+
+func TestValidateKeys(t *testing.T) {
+	destBytes, priv := generateKeys(t)
+	otherDest, otherPriv := generateKeys(t)
+
+	mixed := append(append([]byte(nil), priv[:32]...), otherPriv[32:]...)
+	seedOnly := append(append([]byte(nil), priv[:32]...), priv[32:64]...)
+
+	tests := []struct {
+		name    string
+		dest    []byte
+		priv    []byte
+		wantErr error
+	}{
+		{"matching keys", destBytes, priv, nil},
+		{"Ed25519 seed only", destBytes, seedOnly, nil},
+		{"offline signature", destBytes, offlineKey(t, priv, priv, time.Now().Add(time.Hour)), nil},
+		{"other destination's keys", otherDest, priv, ErrKeyMismatch},
+		{"other signing key", destBytes, mixed, ErrKeyMismatch},
+		{"short private key", destBytes, priv[:40], ErrInvalidPrivateKey},
+		{"expired offline signature", destBytes, offlineKey(t, priv, priv, time.Now().Add(-time.Hour)), ErrOfflineSignatureExpired},
+		{"offline signature by another key", destBytes, offlineKey(t, priv, otherPriv, time.Now().Add(time.Hour)), ErrInvalidOfflineSignature},
+		{"truncated destination", destBytes[:390], priv, ErrInvalidDestination},
+		{"trailing bytes", append(append([]byte(nil), destBytes...), 0), priv, ErrInvalidDestination},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateKeys(tt.dest, tt.priv)
+			if tt.wantErr == nil && err != nil {
+				t.Errorf("ValidateKeys() error = %v, want nil", err)
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Errorf("ValidateKeys() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSignVerify(t *testing.T) {
+	destBytes, priv := generateKeys(t)
+	otherDest, _ := generateKeys(t)
+	data := []byte("hello i2p")
+
+	sig, err := Sign(destBytes, priv, data)
+	if err != nil {
+		t.Fatalf("Sign() error = %v", err)
+	}
+	if !Verify(destBytes, data, sig) {
+		t.Error("Verify() = false for a valid signature")
+	}
+	if Verify(destBytes, []byte("tampered"), sig) {
+		t.Error("Verify() = true for different data")
+	}
+	if Verify(otherDest, data, sig) {
+		t.Error("Verify() = true for another destination")
+	}
+
+	if _, err := Sign(otherDest, priv, data); !errors.Is(err, ErrKeyMismatch) {
+		t.Errorf("Sign(other destination) error = %v, want ErrKeyMismatch", err)
+	}
+	offline := offlineKey(t, priv, priv, time.Now().Add(time.Hour))
+	if _, err := Sign(destBytes, offline, data); !errors.Is(err, ErrOfflineKey) {
+		t.Errorf("Sign(offline) error = %v, want ErrOfflineKey", err)
+	}
+}
@@ -0,0 +1,124 @@
+package destination
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime"
+	"strings"
+	"sync"
+
+	commondest "github.com/go-i2p/common/destination"
+)
+
+// MaxVanityPrefixLength is the longest .b32.i2p prefix GenerateVanity
+// searches for. Each character multiplies the expected number of keys to
+// generate by 32, so a 6-character prefix already needs about a billion.
+const MaxVanityPrefixLength = 6
+
+// ErrInvalidPattern indicates a vanity prefix is empty, too long, or
+// contains characters that cannot appear in a .b32.i2p address.
+var ErrInvalidPattern = errors.New("invalid vanity pattern")
+
+// VanityGenerator is implemented by managers that can search for a
+// destination whose .b32.i2p address starts with a chosen prefix.
+// ManagerImpl implements it.
+type VanityGenerator interface {
+	// GenerateVanity generates destinations until one's .b32.i2p address
+	// starts with prefix, using workers goroutines. It returns ctx.Err()
+	// (wrapped) if ctx is done first.
+	GenerateVanity(ctx context.Context, signatureType int, prefix string, workers int) (*commondest.Destination, []byte, error)
+}
+
+// ValidateVanityPattern checks that prefix can match the start of a
+// .b32.i2p address and returns it lowercased.
+func ValidateVanityPattern(prefix string) (string, error) {
+	prefix = strings.ToLower(prefix)
+	if prefix == "" {
+		return "", fmt.Errorf("%w: must not be empty", ErrInvalidPattern)
+	}
+	if len(prefix) > MaxVanityPrefixLength {
+		return "", fmt.Errorf("%w: %q is longer than %d characters", ErrInvalidPattern, prefix, MaxVanityPrefixLength)
+	}
+	for _, c := range prefix {
+		if (c < 'a' || c > 'z') && (c < '2' || c > '7') {
+			return "", fmt.Errorf("%w: %q may only contain a-z and 2-7", ErrInvalidPattern, prefix)
+		}
+	}
+	return prefix, nil
+}
+
+// GenerateVanity generates destinations with Generate until one's
+// .b32.i2p address starts with prefix. The search runs on workers
+// goroutines, or one per CPU if workers <= 0, and stops when ctx is done.
+//
+// The prefix is matched case-insensitively. Each extra character makes
+// the search about 32 times longer, so callers should bound ctx.
+func (m *ManagerImpl) GenerateVanity(ctx context.Context, signatureType int, prefix string, workers int) (*commondest.Destination, []byte, error) {
+	prefix, err := ValidateVanityPattern(prefix)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !IsValidSignatureType(signatureType) || signatureType != SigTypeEd25519 {
+		return nil, nil, ErrUnsupportedSignatureType
+	}
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	type match struct {
+		dest *commondest.Destination
+		priv []byte
+		err  error
+	}
+	found := make(chan match, workers)
+
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for ctx.Err() == nil {
+				dest, priv, err := generateEd25519()
+				if err == nil {
+					var ok bool
+					ok, err = hasB32Prefix(dest, prefix)
+					if err == nil && !ok {
+						continue
+					}
+				}
+				found <- match{dest: dest, priv: priv, err: err}
+				return
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(found)
+	}()
+
+	// found is closed without a match only once every worker has seen ctx
+	// done.
+	r, ok := <-found
+	if !ok {
+		return nil, nil, fmt.Errorf("vanity search for %q: %w", prefix, ctx.Err())
+	}
+	if r.err != nil {
+		return nil, nil, r.err
+	}
+	return r.dest, r.priv, nil
+}
+
+// hasB32Prefix reports whether the .b32.i2p address of dest starts with
+// the lowercase prefix.
+func hasB32Prefix(dest *commondest.Destination, prefix string) (bool, error) {
+	hash, err := Hash(dest)
+	if err != nil {
+		return false, err
+	}
+	// Only the leading bytes are needed: 5 bytes encode 8 characters.
+	return strings.HasPrefix(b32Encoding.EncodeToString(hash[:5]), prefix), nil
+}
@@ -0,0 +1,75 @@
+package destination
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestValidateVanityPattern(t *testing.T) {
+	tests := []struct {
+		prefix  string
+		want    string
+		wantErr bool
+	}{
+		{"abc", "abc", false},
+		{"ABC7", "abc7", false},
+		{"", "", true},
+		{"abcdefg", "", true},
+		{"ab1", "", true},
+		{"a.b", "", true},
+	}
+	for _, tt := range tests {
+		got, err := ValidateVanityPattern(tt.prefix)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ValidateVanityPattern(%q) error = %v, wantErr %v", tt.prefix, err, tt.wantErr)
+			continue
+		}
+		if err != nil && !errors.Is(err, ErrInvalidPattern) {
+			t.Errorf("ValidateVanityPattern(%q) error = %v, want ErrInvalidPattern", tt.prefix, err)
+		}
+		if got != tt.want {
+			t.Errorf("ValidateVanityPattern(%q) = %q, want %q", tt.prefix, got, tt.want)
+		}
+	}
+}
+
+func TestManagerImpl_GenerateVanity(t *testing.T) {
+	m := NewManager()
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	dest, privateKey, err := m.GenerateVanity(ctx, SigTypeEd25519, "A", 2)
+	if err != nil {
+		t.Fatalf("GenerateVanity() error = %v", err)
+	}
+	if len(privateKey) == 0 {
+		t.Error("GenerateVanity() returned empty private key")
+	}
+	addr, err := dest.Base32Address()
+	if err != nil {
+		t.Fatalf("Base32Address() error = %v", err)
+	}
+	if !strings.HasPrefix(addr, "a") {
+		t.Errorf("Base32Address() = %q, want prefix a", addr)
+	}
+}
+
+func TestManagerImpl_GenerateVanityErrors(t *testing.T) {
+	m := NewManager()
+
+	if _, _, err := m.GenerateVanity(context.Background(), SigTypeEd25519, "a1", 1); !errors.Is(err, ErrInvalidPattern) {
+		t.Errorf("GenerateVanity(a1) error = %v, want ErrInvalidPattern", err)
+	}
+	if _, _, err := m.GenerateVanity(context.Background(), SigTypeDSA_SHA1, "a", 1); err != ErrUnsupportedSignatureType {
+		t.Errorf("GenerateVanity(DSA) error = %v, want ErrUnsupportedSignatureType", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, _, err := m.GenerateVanity(ctx, SigTypeEd25519, "zzzzzz", 2); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("GenerateVanity(zzzzzz) error = %v, want context.DeadlineExceeded", err)
+	}
+}
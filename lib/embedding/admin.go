@@ -0,0 +1,238 @@
+package embedding
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/go-i2p/go-sam-bridge/lib/session"
+)
+
+// AdminStatus is the JSON document served at /status.
+type AdminStatus struct {
+	// Running reports whether the bridge is serving.
+	Running bool `json:"running"`
+
+	// Healthy is true when Health returns nil.
+	Healthy bool `json:"healthy"`
+
+	// Error is the Health error, if any.
+	Error string `json:"error,omitempty"`
+
+	// ListenAddr is the primary SAM control socket address.
+	ListenAddr string `json:"listen_addr"`
+
+	// Listeners lists every SAM control socket address, primary first.
+	Listeners []string `json:"listeners,omitempty"`
+
+	// Connections is the number of open SAM control connections.
+	Connections int `json:"connections"`
+
+	// ConnectionsTotal is the number of SAM control connections served
+	// since the bridge started.
+	ConnectionsTotal uint64 `json:"connections_total"`
+
+	// Commands is the number of SAM commands processed since the bridge
+	// started.
+	Commands uint64 `json:"commands"`
+
+	// UptimeSeconds is how long the bridge has been serving.
+	UptimeSeconds float64 `json:"uptime_seconds"`
+
+	// Sessions is the number of registered sessions.
+	Sessions int `json:"sessions"`
+
+	// I2CP describes the router connection.
+	I2CP AdminI2CPStatus `json:"i2cp"`
+}
+
+// AdminI2CPStatus describes the I2CP router connection in AdminStatus.
+type AdminI2CPStatus struct {
+	// Connected reports whether the I2CP provider is connected.
+	Connected bool `json:"connected"`
+
+	// RouterVersion is the router's version, if the provider reports it.
+	RouterVersion string `json:"router_version,omitempty"`
+}
+
+// AdminSession is the JSON document describing one session, served in
+// the /sessions list and at /sessions/{id}.
+type AdminSession struct {
+	ID     string `json:"id"`
+	Style  string `json:"style"`
+	Status string `json:"status"`
+
+	// Label is the human-readable session label, if set.
+	Label string `json:"label,omitempty"`
+
+	// Destination is the session's public base64 destination.
+	Destination string `json:"destination,omitempty"`
+
+	// Stats holds the traffic counters, if the session tracks them.
+	Stats *session.StatsSnapshot `json:"stats,omitempty"`
+
+	// Timeline holds when the session was created, its tunnels became
+	// ready and it started closing.
+	Timeline *session.Timeline `json:"timeline,omitempty"`
+
+	// UptimeSeconds is how long the session has existed.
+	UptimeSeconds float64 `json:"uptime_seconds,omitempty"`
+
+	// TimeToReadySeconds is how long the session's tunnels took to build,
+	// if known.
+	TimeToReadySeconds float64 `json:"time_to_ready_seconds,omitempty"`
+}
+
+// AdminHandler returns an http.Handler serving read-only JSON views of
+// the bridge:
+//
+//	GET /health          200 if Health returns nil, 503 otherwise
+//	GET /status          AdminStatus
+//	GET /sessions        []AdminSession, sorted by ID
+//	GET /sessions/{id}   AdminSession, or 404
+//
+// The handler performs no authentication; serve it on a loopback
+// address or behind an authenticating proxy. WithAdminAddr serves it
+// automatically while the bridge runs.
+func (b *Bridge) AdminHandler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /health", b.serveAdminHealth)
+	mux.HandleFunc("GET /status", b.serveAdminStatus)
+	mux.HandleFunc("GET /sessions", b.serveAdminSessions)
+	mux.HandleFunc("GET /sessions/{id}", b.serveAdminSession)
+	return mux
+}
+
+// AdminAddr returns the address the admin HTTP server is listening on,
+// or an empty string if it is not running.
+func (b *Bridge) AdminAddr() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.admin.addr()
+}
+
+// startAdmin serves AdminHandler on Config.AdminAddr.
+// It is a no-op when no admin address is configured.
+// Callers must hold b.mu.
+func (b *Bridge) startAdmin() error {
+	if b.config.AdminAddr == "" {
+		return nil
+	}
+	admin, err := b.startHTTPEndpoint(b.config.AdminAddr, b.AdminHandler(), SourceAdmin)
+	if err != nil {
+		return err
+	}
+	b.admin = admin
+	return nil
+}
+
+// stopAdmin closes the admin HTTP server, if running.
+func (b *Bridge) stopAdmin() {
+	b.mu.Lock()
+	admin := b.admin
+	b.admin = nil
+	b.mu.Unlock()
+
+	if err := admin.close(); err != nil {
+		b.deps.Logger.WithError(err).Warn("Error closing admin HTTP server")
+	}
+}
+
+// adminStatus builds the document served at /status.
+func (b *Bridge) adminStatus() AdminStatus {
+	stats := b.Stats()
+	status := AdminStatus{
+		Running:          b.Running(),
+		Listeners:        b.Addrs(),
+		Connections:      stats.ConnectionsActive,
+		ConnectionsTotal: stats.ConnectionsTotal,
+		Commands:         stats.Commands,
+		UptimeSeconds:    stats.Uptime.Seconds(),
+		Sessions:         b.deps.Registry.Count(),
+		I2CP: AdminI2CPStatus{
+			Connected: b.deps.I2CPProvider.IsConnected(),
+		},
+	}
+	if len(status.Listeners) > 0 {
+		status.ListenAddr = status.Listeners[0]
+	}
+	if err := b.Health(); err != nil {
+		status.Error = err.Error()
+	} else {
+		status.Healthy = true
+	}
+	if info, ok := b.RouterInfo(); ok {
+		status.I2CP.RouterVersion = info.Version
+	}
+	return status
+}
+
+// adminSession describes sess for the admin API.
+func adminSession(sess session.Session) AdminSession {
+	s := AdminSession{
+		ID:     sess.ID(),
+		Style:  string(sess.Style()),
+		Status: sess.Status().String(),
+		Label:  session.Label(sess),
+	}
+	if dest := sess.Destination(); dest != nil {
+		s.Destination = string(dest.PublicKey)
+	}
+	if sp, ok := sess.(session.StatsProvider); ok {
+		stats := sp.Stats().Snapshot()
+		s.Stats = &stats
+	}
+	if tp, ok := sess.(session.TimelineProvider); ok {
+		timeline := tp.Timeline()
+		s.Timeline = &timeline
+		s.UptimeSeconds = timeline.Uptime(time.Now()).Seconds()
+		if ready, ok := timeline.TimeToReady(); ok {
+			s.TimeToReadySeconds = ready.Seconds()
+		}
+	}
+	return s
+}
+
+func (b *Bridge) serveAdminHealth(w http.ResponseWriter, r *http.Request) {
+	if err := b.Health(); err != nil {
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+	w.Write([]byte("ok\n"))
+}
+
+func (b *Bridge) serveAdminStatus(w http.ResponseWriter, r *http.Request) {
+	writeAdminJSON(w, b.adminStatus())
+}
+
+func (b *Bridge) serveAdminSessions(w http.ResponseWriter, r *http.Request) {
+	ids := b.deps.Registry.All()
+	sort.Strings(ids)
+
+	sessions := make([]AdminSession, 0, len(ids))
+	for _, id := range ids {
+		// The session may close between All and Get
+		if sess := b.deps.Registry.Get(id); sess != nil {
+			sessions = append(sessions, adminSession(sess))
+		}
+	}
+	writeAdminJSON(w, sessions)
+}
+
+func (b *Bridge) serveAdminSession(w http.ResponseWriter, r *http.Request) {
+	sess := b.deps.Registry.Get(r.PathValue("id"))
+	if sess == nil {
+		http.Error(w, "session not found", http.StatusNotFound)
+		return
+	}
+	writeAdminJSON(w, adminSession(sess))
+}
+
+// writeAdminJSON writes v as an indented JSON response.
+func writeAdminJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	_ = enc.Encode(v)
+}
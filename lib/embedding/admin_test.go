@@ -0,0 +1,121 @@
+package embedding
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-i2p/go-sam-bridge/lib/session"
+)
+
+func getAdmin(t *testing.T, h http.Handler, path string) *httptest.ResponseRecorder {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+	return rec
+}
+
+func TestBridgeAdminHandler(t *testing.T) {
+	b, err := New(WithListenAddr("127.0.0.1:0"), WithI2CPProvider(&mockI2CPProvider{}), WithDatagramPort(0))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	h := b.AdminHandler()
+
+	if rec := getAdmin(t, h, "/health"); rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("/health before Start = %d, want %d", rec.Code, http.StatusServiceUnavailable)
+	}
+
+	if err := b.Start(context.Background()); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	defer b.Stop(context.Background())
+
+	cfg := session.DefaultSessionConfig()
+	cfg.Label = "admin app"
+	sess := session.NewBaseSession("admin-1", session.StyleRaw, &session.Destination{PublicKey: []byte("pubkey")}, nil, cfg)
+	if err := b.Dependencies().Registry.Register(sess); err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+	sess.Stats().AddDatagramSent(32)
+
+	if rec := getAdmin(t, h, "/health"); rec.Code != http.StatusOK {
+		t.Errorf("/health while running = %d, want %d", rec.Code, http.StatusOK)
+	}
+
+	var status AdminStatus
+	rec := getAdmin(t, h, "/status")
+	if err := json.Unmarshal(rec.Body.Bytes(), &status); err != nil {
+		t.Fatalf("decoding /status: %v", err)
+	}
+	if !status.Running || !status.Healthy || !status.I2CP.Connected || status.Sessions != 1 {
+		t.Errorf("/status = %+v, want running, healthy, connected with 1 session", status)
+	}
+
+	var sessions []AdminSession
+	rec = getAdmin(t, h, "/sessions")
+	if err := json.Unmarshal(rec.Body.Bytes(), &sessions); err != nil {
+		t.Fatalf("decoding /sessions: %v", err)
+	}
+	if len(sessions) != 1 || sessions[0].ID != "admin-1" || sessions[0].Destination != "pubkey" {
+		t.Fatalf("/sessions = %+v, want admin-1 with destination", sessions)
+	}
+	if sessions[0].Timeline == nil || sessions[0].Timeline.Created.IsZero() {
+		t.Errorf("/sessions timeline = %+v, want creation time", sessions[0].Timeline)
+	}
+	if sessions[0].Label != "admin app" {
+		t.Errorf("/sessions label = %q, want %q", sessions[0].Label, "admin app")
+	}
+	if sessions[0].Stats == nil || sessions[0].Stats.DatagramsSent != 1 {
+		t.Errorf("/sessions stats = %+v, want 1 datagram sent", sessions[0].Stats)
+	}
+
+	if rec := getAdmin(t, h, "/sessions/admin-1"); rec.Code != http.StatusOK {
+		t.Errorf("/sessions/admin-1 = %d, want %d", rec.Code, http.StatusOK)
+	}
+	if rec := getAdmin(t, h, "/sessions/missing"); rec.Code != http.StatusNotFound {
+		t.Errorf("/sessions/missing = %d, want %d", rec.Code, http.StatusNotFound)
+	}
+}
+
+func TestBridgeWithAdminAddr(t *testing.T) {
+	b, err := New(
+		WithListenAddr("127.0.0.1:0"),
+		WithI2CPProvider(&mockI2CPProvider{}),
+		WithDatagramPort(0),
+		WithAdminAddr("127.0.0.1:0"),
+	)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if b.AdminAddr() != "" {
+		t.Error("AdminAddr() should be empty before Start")
+	}
+
+	if err := b.Start(context.Background()); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	addr := b.AdminAddr()
+	if addr == "" {
+		t.Fatal("AdminAddr() should be set while running")
+	}
+
+	resp, err := http.Get("http://" + addr + "/health")
+	if err != nil {
+		t.Fatalf("GET /health error = %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("GET /health = %d, want %d", resp.StatusCode, http.StatusOK)
+	}
+
+	b.Stop(context.Background())
+	if b.AdminAddr() != "" {
+		t.Error("AdminAddr() should be empty after Stop")
+	}
+	if _, err := http.Get("http://" + addr + "/health"); err == nil {
+		t.Error("admin server should be closed after Stop")
+	}
+}
@@ -0,0 +1,58 @@
+package embedding
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+)
+
+// openAuditLog opens the configured audit log file, if any, and points
+// cfg.AuditLog at it. When both a file and a writer are configured,
+// records are written to both. The caller closes the returned file.
+func openAuditLog(cfg *Config) (*os.File, error) {
+	f, w, err := openLogFile(cfg.AuditLogFile, cfg.AuditLog)
+	if err != nil {
+		return nil, fmt.Errorf("embedding: opening audit log: %w", err)
+	}
+	cfg.AuditLog = w
+	return f, nil
+}
+
+// openAccessLog opens the configured access log file, if any, and points
+// cfg.AccessLog at it, like openAuditLog.
+func openAccessLog(cfg *Config) (*os.File, error) {
+	f, w, err := openLogFile(cfg.AccessLogFile, cfg.AccessLog)
+	if err != nil {
+		return nil, fmt.Errorf("embedding: opening access log: %w", err)
+	}
+	cfg.AccessLog = w
+	return f, nil
+}
+
+// openLogFile opens path for appending, if set, and returns it with a
+// writer to both it and w.
+func openLogFile(path string, w io.Writer) (*os.File, io.Writer, error) {
+	if path == "" {
+		return nil, w, nil
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o600)
+	if err != nil {
+		return nil, w, err
+	}
+	if w != nil {
+		return f, io.MultiWriter(w, f), nil
+	}
+	return f, f, nil
+}
+
+// closeLogFiles closes the non-nil files.
+func closeLogFiles(files ...*os.File) error {
+	var errs []error
+	for _, f := range files {
+		if f != nil {
+			errs = append(errs, f.Close())
+		}
+	}
+	return errors.Join(errs...)
+}
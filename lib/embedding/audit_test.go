@@ -0,0 +1,158 @@
+package embedding
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestOpenAuditLog(t *testing.T) {
+	var buf bytes.Buffer
+	path := filepath.Join(t.TempDir(), "audit.log")
+	cfg := &Config{AuditLog: &buf, AuditLogFile: path}
+
+	f, err := openAuditLog(cfg)
+	if err != nil {
+		t.Fatalf("openAuditLog() error = %v", err)
+	}
+	defer closeLogFiles(f)
+
+	if _, err := cfg.AuditLog.Write([]byte("record\n")); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	if buf.String() != "record\n" {
+		t.Errorf("writer got %q, want record", buf.String())
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("ReadFile() error = %v", err)
+	}
+	if string(data) != "record\n" {
+		t.Errorf("file got %q, want record", data)
+	}
+}
+
+func TestOpenAuditLog_NoFile(t *testing.T) {
+	cfg := &Config{}
+	f, err := openAuditLog(cfg)
+	if err != nil || f != nil {
+		t.Errorf("openAuditLog() = %v, %v; want nil, nil", f, err)
+	}
+	if cfg.AuditLog != nil {
+		t.Error("AuditLog should stay nil without a file")
+	}
+}
+
+func TestNewWithBadAuditLogFile(t *testing.T) {
+	_, err := New(
+		WithListenAddr("127.0.0.1:0"),
+		WithI2CPProvider(&mockI2CPProvider{}),
+		WithAuditLogFile(filepath.Join(t.TempDir(), "missing", "audit.log")),
+	)
+	if err == nil {
+		t.Error("New() with unwritable audit log should return error")
+	}
+}
+
+func TestBridgeAuditLogFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to create test listener: %v", err)
+	}
+
+	bridge, err := New(
+		WithListener(ln),
+		WithI2CPProvider(&mockI2CPProvider{}),
+		WithDatagramPort(0),
+		WithAuth(map[string]string{"alice": "secret"}),
+		WithAuditLogFile(path),
+	)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if err := bridge.Start(context.Background()); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+
+	conn, err := net.Dial("tcp", ln.Addr().String())
+	if err != nil {
+		t.Fatalf("Dial() error = %v", err)
+	}
+	conn.Write([]byte("HELLO VERSION MIN=3.0 MAX=3.3 USER=alice PASSWORD=secret\n"))
+	if _, err := bufio.NewReader(conn).ReadString('\n'); err != nil {
+		t.Fatalf("ReadString() error = %v", err)
+	}
+	conn.Close()
+
+	if err := bridge.Stop(context.Background()); err != nil {
+		t.Fatalf("Stop() error = %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("ReadFile() error = %v", err)
+	}
+	log := string(data)
+	if !strings.Contains(log, `"verb":"HELLO"`) || !strings.Contains(log, `"result":"OK"`) {
+		t.Errorf("audit log = %q, want HELLO record with RESULT=OK", log)
+	}
+	if strings.Contains(log, "secret") {
+		t.Error("audit log must not contain passwords")
+	}
+}
+
+func TestBridgeAccessLogFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "access.log")
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to create test listener: %v", err)
+	}
+
+	bridge, err := New(
+		WithListener(ln),
+		WithI2CPProvider(&mockI2CPProvider{}),
+		WithDatagramPort(0),
+		WithAccessLogFile(path),
+	)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if err := bridge.Start(context.Background()); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+
+	conn, err := net.Dial("tcp", ln.Addr().String())
+	if err != nil {
+		t.Fatalf("Dial() error = %v", err)
+	}
+	conn.Write([]byte("HELLO VERSION MIN=3.0 MAX=3.3\n"))
+	if _, err := bufio.NewReader(conn).ReadString('\n'); err != nil {
+		t.Fatalf("ReadString() error = %v", err)
+	}
+	conn.Close()
+
+	// The record is written when the bridge notices the close
+	deadline := time.Now().Add(time.Second)
+	for bridge.Stats().ConnectionsActive > 0 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if err := bridge.Stop(context.Background()); err != nil {
+		t.Fatalf("Stop() error = %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("ReadFile() error = %v", err)
+	}
+	log := string(data)
+	if !strings.Contains(log, `"version":"3.3"`) || !strings.Contains(log, `"bytes_in":30`) {
+		t.Errorf("access log = %q, want a record with version and bytes", log)
+	}
+}
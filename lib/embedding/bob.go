@@ -0,0 +1,143 @@
+package embedding
+
+import (
+	"context"
+	"net"
+
+	"github.com/go-i2p/go-sam-bridge/lib/bob"
+	"github.com/go-i2p/go-sam-bridge/lib/destination"
+)
+
+// bobFrontend is the BOB listener started by WithBOBAddr.
+type bobFrontend struct {
+	server   *bob.Server
+	listener net.Listener
+}
+
+// BOBAddr returns the address the BOB listener is listening on, or an
+// empty string if it is not running.
+func (b *Bridge) BOBAddr() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.bob == nil {
+		return ""
+	}
+	return b.bob.listener.Addr().String()
+}
+
+// startBOB serves the BOB listener on Config.BOBAddr.
+// It is a no-op when no BOB address is configured.
+// Callers must hold b.mu.
+func (b *Bridge) startBOB() error {
+	if b.config.BOBAddr == "" {
+		return nil
+	}
+	ln, err := net.Listen("tcp", b.config.BOBAddr)
+	if err != nil {
+		return err
+	}
+
+	backend := &bobBackend{
+		dests: b.deps.DestManager,
+		sam: &streamDialer{
+			name:     "bob",
+			serve:    b.server.ServeConn,
+			remote:   ln.Addr(),
+			user:     b.config.BOBUser,
+			password: b.config.BOBPassword,
+		},
+	}
+	server := bob.NewServer(backend)
+	server.ErrorHandler = func(err error) {
+		b.deps.ReportError(SourceBOB, err)
+	}
+	go func() {
+		if err := server.Serve(ln); err != nil {
+			b.deps.ReportError(SourceBOB, err)
+		}
+	}()
+
+	b.bob = &bobFrontend{server: server, listener: ln}
+	b.deps.Logger.WithField("addr", ln.Addr()).Info("BOB listener started")
+	return nil
+}
+
+// stopBOB closes the BOB listener and stops its tunnels, if running.
+func (b *Bridge) stopBOB() {
+	b.mu.Lock()
+	f := b.bob
+	b.bob = nil
+	b.mu.Unlock()
+
+	if f != nil {
+		f.server.Close()
+	}
+}
+
+// bobBackend carries BOB tunnels over SAM STREAM sessions on the bridge.
+// Each running tunnel has its own session, named after its nickname and
+// using its keys.
+type bobBackend struct {
+	dests destination.Manager
+
+	// sam carries naming lookups and holds the connection settings
+	// tunnel sessions are created with.
+	sam *streamDialer
+}
+
+// NewKeys generates an Ed25519 destination.
+func (bb *bobBackend) NewKeys(ctx context.Context) (string, string, error) {
+	dest, priv, err := bb.dests.Generate(7)
+	if err != nil {
+		return "", "", err
+	}
+	keys, err := bb.dests.Encode(dest, priv)
+	if err != nil {
+		return "", "", err
+	}
+	pub, err := bb.dests.EncodePublic(dest)
+	if err != nil {
+		return "", "", err
+	}
+	return pub, keys, nil
+}
+
+// Destination returns the public destination of keys.
+func (bb *bobBackend) Destination(keys string) (string, error) {
+	dest, _, err := bb.dests.Parse(keys)
+	if err != nil {
+		return "", err
+	}
+	return bb.dests.EncodePublic(dest)
+}
+
+// Lookup resolves name with NAMING LOOKUP.
+func (bb *bobBackend) Lookup(ctx context.Context, name string) (string, error) {
+	return bb.sam.lookup(ctx, name)
+}
+
+// Verify parses dest as a public destination.
+func (bb *bobBackend) Verify(dest string) error {
+	_, err := bb.dests.ParsePublic(dest)
+	return err
+}
+
+// Start creates the tunnel's STREAM session and, when it has an outbound
+// side, forwards incoming streams to it.
+func (bb *bobBackend) Start(ctx context.Context, spec bob.TunnelSpec) (bob.Tunnel, error) {
+	t, err := startTunnelSession(ctx, bb.sam, spec.Nickname, spec.Keys, spec.Options, spec.OutHost, spec.OutPort, spec.Quiet)
+	if err != nil {
+		return nil, err
+	}
+	return bobTunnel{t}, nil
+}
+
+// bobTunnel adapts a tunnelSession to bob.Tunnel.
+type bobTunnel struct {
+	*tunnelSession
+}
+
+// Dial opens a stream to dest through the tunnel's session.
+func (t bobTunnel) Dial(ctx context.Context, dest string) (net.Conn, error) {
+	return t.tunnelSession.Dial(ctx, dest, 0)
+}
@@ -0,0 +1,131 @@
+package embedding
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-i2p/go-sam-bridge/lib/bob"
+	"github.com/go-i2p/go-sam-bridge/lib/destination"
+)
+
+func TestBOBBackend(t *testing.T) {
+	sam := &fakeSAMServer{}
+	backend := &bobBackend{
+		dests: destination.NewManager(),
+		sam:   &streamDialer{name: "bob", serve: sam.serve, remote: &net.TCPAddr{IP: net.IPv4(127, 0, 0, 1)}},
+	}
+	ctx := context.Background()
+
+	dest, keys, err := backend.NewKeys(ctx)
+	if err != nil {
+		t.Fatalf("NewKeys() error = %v", err)
+	}
+	if got, err := backend.Destination(keys); err != nil || got != dest {
+		t.Errorf("Destination(keys) = %q, %v; want the generated destination", got, err)
+	}
+	if err := backend.Verify(dest); err != nil {
+		t.Errorf("Verify(dest) error = %v", err)
+	}
+	if err := backend.Verify("not a destination"); err == nil {
+		t.Error("Verify() accepted an invalid destination")
+	}
+	if got, err := backend.Lookup(ctx, "example.i2p"); err != nil || got != "exampledest" {
+		t.Errorf("Lookup() = %q, %v; want exampledest", got, err)
+	}
+
+	tun, err := backend.Start(ctx, bob.TunnelSpec{
+		Nickname: "web",
+		Keys:     keys,
+		Options:  map[string]string{"inbound.length": "1"},
+		OutHost:  "localhost",
+		OutPort:  8080,
+		Quiet:    true,
+	})
+	if err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	conn, err := tun.Dial(ctx, "example.i2p")
+	if err != nil {
+		t.Fatalf("Dial() error = %v", err)
+	}
+	conn.Write([]byte("hi"))
+	buf := make([]byte, 2)
+	if _, err := io.ReadFull(conn, buf); err != nil || string(buf) != "hi" {
+		t.Errorf("echo = %q, %v; want %q", buf, err, "hi")
+	}
+	conn.Close()
+	tun.Close()
+
+	sam.mu.Lock()
+	defer sam.mu.Unlock()
+	var create, forward string
+	for _, c := range sam.commands {
+		switch {
+		case strings.HasPrefix(c, "SESSION CREATE"):
+			create = c
+		case strings.HasPrefix(c, "STREAM FORWARD"):
+			forward = c
+		}
+	}
+	for _, want := range []string{"ID=bob-web-", "DESTINATION=" + keys, "inbound.length=1"} {
+		if !strings.Contains(create, want) {
+			t.Errorf("SESSION CREATE = %q, want %s", create, want)
+		}
+	}
+	for _, want := range []string{"PORT=8080", "HOST=localhost", "SILENT=true"} {
+		if !strings.Contains(forward, want) {
+			t.Errorf("STREAM FORWARD = %q, want %s", forward, want)
+		}
+	}
+}
+
+func TestBridgeWithBOBAddr(t *testing.T) {
+	b, err := New(WithListenAddr("127.0.0.1:0"), WithI2CPProvider(&mockI2CPProvider{}), WithDatagramPort(0), WithBOBAddr("127.0.0.1:0"))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if addr := b.BOBAddr(); addr != "" {
+		t.Errorf("BOBAddr() before Start = %q, want empty", addr)
+	}
+
+	if err := b.Start(context.Background()); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	addr := b.BOBAddr()
+	if addr == "" {
+		t.Fatal("BOBAddr() while running is empty")
+	}
+
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatalf("dialing BOB: %v", err)
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+	r := bufio.NewReader(conn)
+	io.WriteString(conn, "setnick web\nnewkeys\n")
+	var lines []string
+	for i := 0; i < 4; i++ {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			t.Fatalf("reading BOB reply: %v", err)
+		}
+		lines = append(lines, strings.TrimSpace(line))
+	}
+	if lines[0] != "BOB "+bob.Version || lines[2] != "OK Nickname set to web" {
+		t.Errorf("greeting and setnick = %q", lines[:3])
+	}
+	if dest := strings.TrimPrefix(lines[3], "OK "); dest == lines[3] || len(dest) < 516 {
+		t.Errorf("newkeys = %q, want OK and a destination", lines[3])
+	}
+
+	b.Stop(context.Background())
+	if addr := b.BOBAddr(); addr != "" {
+		t.Errorf("BOBAddr() after Stop = %q, want empty", addr)
+	}
+}
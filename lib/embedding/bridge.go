@@ -3,6 +3,8 @@ package embedding
 import (
 	"context"
 	"fmt"
+	"net"
+	"os"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -11,6 +13,9 @@ import (
 	"github.com/go-i2p/go-i2p/lib/embedded"
 	"github.com/go-i2p/go-sam-bridge/lib/bridge"
 	"github.com/go-i2p/go-sam-bridge/lib/datagram"
+	"github.com/go-i2p/go-sam-bridge/lib/session"
+	"github.com/go-i2p/go-sam-bridge/lib/tunnels"
+	"github.com/sirupsen/logrus"
 )
 
 // Lifecycle defines the interface for controlling a Bridge.
@@ -27,6 +32,9 @@ type Lifecycle interface {
 	// Returns any error that caused the shutdown.
 	Wait() error
 
+	// WaitContext is like Wait but returns ctx.Err() if ctx is done first.
+	WaitContext(ctx context.Context) error
+
 	// Running returns true if the bridge is actively serving.
 	Running() bool
 }
@@ -39,12 +47,37 @@ type Bridge struct {
 	server         *bridge.Server
 	embeddedRouter embedded.EmbeddedRouter
 	udpListener    *datagram.UDPListener
+	certReloader   *bridge.CertReloader
+	auditFile      *os.File
+	accessFile     *os.File
+	errs           chan error
+	releaseI2CP    func() error
+	stopDestPool   func()
+	admin          *httpEndpoint
+	metrics        *httpEndpoint
+	pprof          *httpEndpoint
+	i2pControl     *httpEndpoint
+	socks          *socksFrontend
+	httpProxy      *httpProxyFrontend
+	bob            *bobFrontend
+	tunnels        *tunnels.Manager
+
+	// listeners are the control sockets while running, primary first.
+	listeners []net.Listener
 
 	mu       sync.Mutex
 	running  atomic.Bool
+	stopping bool
 	done     chan struct{}
 	err      error
 	cancelFn context.CancelFunc
+	stopOnce sync.Once
+}
+
+// destinationPool is implemented by destination managers that can
+// generate destinations ahead of time, such as destination.ManagerImpl.
+type destinationPool interface {
+	StartPool(size int) (stop func())
 }
 
 // Ensure Bridge implements Lifecycle.
@@ -59,31 +92,80 @@ func New(opts ...Option) (*Bridge, error) {
 		return nil, err
 	}
 
+	certReloader, err := loadTLSFiles(cfg)
+	if err != nil {
+		return nil, err
+	}
+	cfg.TLSConfig = cfg.TLSPolicy.apply(cfg.TLSConfig)
+
+	auditFile, err := openAuditLog(cfg)
+	if err != nil {
+		return nil, err
+	}
+	accessFile, err := openAccessLog(cfg)
+	if err != nil {
+		closeLogFiles(auditFile)
+		return nil, err
+	}
+
+	errs := make(chan error, errorBufferSize)
 	deps := newDependencies(cfg)
+	deps.ReportError = newErrorReporter(errs, deps.Logger, deps.logSampler)
+	if err := addSessionObservers(cfg, deps.Registry); err != nil {
+		closeLogFiles(auditFile, accessFile)
+		return nil, err
+	}
+	if notifier, ok := deps.I2CPProvider.(ErrorNotifier); ok && cfg.SharedI2CP == nil {
+		notifier.SetErrorHandler(func(err error) {
+			deps.ReportError(SourceI2CP, err)
+		})
+	}
 
 	server, err := createServer(cfg, deps)
 	if err != nil {
+		closeLogFiles(auditFile, accessFile)
 		return nil, err
 	}
 
 	embeddedRouter, err := createEmbeddedRouter(cfg)
 	if err != nil {
+		closeLogFiles(auditFile, accessFile)
 		return nil, err
 	}
 
 	// Create UDP listener for datagram port 7655 if configured
 	var udpListener *datagram.UDPListener
-	if cfg.DatagramPort > 0 {
+	if cfg.DatagramPacketConn != nil {
+		udpListener = datagram.NewUDPListenerFromConn(cfg.DatagramPacketConn, deps.Registry)
+	} else if cfg.DatagramPort > 0 {
 		udpAddr := fmt.Sprintf(":%d", cfg.DatagramPort)
 		udpListener = datagram.NewUDPListener(udpAddr, deps.Registry)
 	}
 
+	// Take a reference on the shared I2CP provider last, so that no
+	// earlier failure has to release it.
+	var releaseI2CP func() error
+	if cfg.SharedI2CP != nil {
+		releaseI2CP, err = cfg.SharedI2CP.acquire(func(err error) {
+			deps.ReportError(SourceI2CP, err)
+		})
+		if err != nil {
+			closeLogFiles(auditFile, accessFile)
+			return nil, err
+		}
+	}
+
 	return &Bridge{
 		config:         cfg,
 		deps:           deps,
 		server:         server,
 		embeddedRouter: embeddedRouter,
 		udpListener:    udpListener,
+		certReloader:   certReloader,
+		auditFile:      auditFile,
+		accessFile:     accessFile,
+		errs:           errs,
+		releaseI2CP:    releaseI2CP,
 		done:           make(chan struct{}),
 	}, nil
 }
@@ -103,6 +185,7 @@ func buildConfig(opts []Option) (*Config, error) {
 // createServer creates and configures the bridge server.
 func createServer(cfg *Config, deps *Dependencies) (*bridge.Server, error) {
 	bridgeConfig := cfg.toBridgeConfig()
+	bridgeConfig.OnPanic = panicLogger(deps.Logger, cfg.PanicHandler)
 	server, err := bridge.NewServer(bridgeConfig, deps.Registry)
 	if err != nil {
 		return nil, err
@@ -112,6 +195,24 @@ func createServer(cfg *Config, deps *Dependencies) (*bridge.Server, error) {
 	return server, nil
 }
 
+// panicLogger returns a bridge.Config.OnPanic function that logs each
+// recovered panic with its stack trace and then calls fn, if set.
+func panicLogger(log *logrus.Logger, fn func(bridge.PanicRecord)) func(bridge.PanicRecord) {
+	return func(rec bridge.PanicRecord) {
+		log.WithFields(logrus.Fields{
+			"remote":    rec.RemoteAddr,
+			"user":      rec.User,
+			"sessionID": rec.SessionID,
+			"command":   rec.Command,
+			"panic":     fmt.Sprint(rec.Value),
+			"stack":     string(rec.Stack),
+		}).Error("Recovered panic serving SAM client")
+		if fn != nil {
+			fn(rec)
+		}
+	}
+}
+
 // registerHandlers registers command handlers on the server.
 func registerHandlers(cfg *Config, server *bridge.Server, deps *Dependencies) {
 	registrar := cfg.HandlerRegistrar
@@ -151,13 +252,88 @@ func createEmbeddedRouter(cfg *Config) (embedded.EmbeddedRouter, error) {
 // Start begins serving SAM connections.
 // The context is used for cancellation - when cancelled, the bridge stops.
 // This method is non-blocking and returns immediately after starting.
+// The OnStart callback, if configured, runs before Start returns.
 func (b *Bridge) Start(ctx context.Context) error {
+	if err := b.start(ctx); err != nil {
+		return err
+	}
+	if b.config.OnStart != nil {
+		b.config.OnStart()
+	}
+	return nil
+}
+
+// startAuxiliary starts the admin API, metrics and pprof endpoints, I2PControl API,
+// proxy frontends, BOB listener and tunnels configured to run alongside the SAM listeners. If
+// one fails to start, those already started are closed.
+// Callers must hold b.mu.
+func (b *Bridge) startAuxiliary() error {
+	for _, start := range []func() error{b.startAdmin, b.startMetrics, b.startPprof, b.startI2PControl, b.startSOCKS, b.startHTTPProxy, b.startBOB, b.startTunnels} {
+		if err := start(); err != nil {
+			b.closeAuxiliary()
+			return err
+		}
+	}
+	return nil
+}
+
+// closeAuxiliary closes everything startAuxiliary started.
+// Callers must hold b.mu.
+func (b *Bridge) closeAuxiliary() {
+	b.admin.close()
+	b.admin = nil
+	b.metrics.close()
+	b.metrics = nil
+	b.pprof.close()
+	b.pprof = nil
+	b.i2pControl.close()
+	b.i2pControl = nil
+	if b.socks != nil {
+		b.socks.server.Close()
+		b.socks.dialer.close()
+		b.socks = nil
+	}
+	if b.httpProxy != nil {
+		b.httpProxy.server.Close()
+		b.httpProxy.dialer.close()
+		b.httpProxy = nil
+	}
+	if b.bob != nil {
+		b.bob.server.Close()
+		b.bob = nil
+	}
+	if b.tunnels != nil {
+		b.tunnels.Close()
+		b.tunnels = nil
+	}
+}
+
+// start does the work of Start while holding b.mu.
+func (b *Bridge) start(ctx context.Context) error {
 	b.mu.Lock()
 	defer b.mu.Unlock()
 
+	if b.running.Load() {
+		return ErrBridgeAlreadyRunning
+	}
+
+	listeners, err := b.listen()
+	if err != nil {
+		return err
+	}
+	if err := b.startAuxiliary(); err != nil {
+		closeListeners(listeners)
+		return err
+	}
+	b.listeners = listeners
+
 	// Only start embedded router if we created one (port was available during New())
 	if b.embeddedRouter != nil {
 		if err := b.embeddedRouter.Start(); err != nil {
+			// Free the ports so that Start can be retried
+			closeListeners(listeners)
+			b.listeners = nil
+			b.closeAuxiliary()
 			return err
 		}
 		// Wait for embedded router to start listening
@@ -167,14 +343,14 @@ func (b *Bridge) Start(ctx context.Context) error {
 		b.deps.Logger.Info("Embedded router started")
 	}
 
-	if b.running.Load() {
-		return ErrBridgeAlreadyRunning
+	if pool, ok := b.deps.DestManager.(destinationPool); ok && b.config.DestinationPoolSize > 0 {
+		b.stopDestPool = pool.StartPool(b.config.DestinationPoolSize)
 	}
 
 	// Start UDP listener for datagram port 7655 per SAMv3.md
 	if b.udpListener != nil {
 		if err := b.udpListener.Start(); err != nil {
-			b.deps.Logger.WithError(err).Warn("Failed to start UDP datagram listener")
+			b.deps.ReportError(SourceUDP, err)
 			// Non-fatal: continue without UDP support
 		} else {
 			b.deps.Logger.WithField("addr", b.udpListener.Addr()).Info("UDP datagram listener started")
@@ -187,18 +363,29 @@ func (b *Bridge) Start(ctx context.Context) error {
 
 	// Start the server in a goroutine
 	go func() {
-		var err error
-		if b.config.Listener != nil {
-			// Use custom listener
-			err = b.server.Serve(b.config.Listener)
+		err := b.serve(listeners)
+
+		// When draining, Serve returns as soon as the listener closes;
+		// wait for the drain to finish before signalling done.
+		if err == nil {
+			<-b.server.Done()
 		} else {
-			// Create listener from address
-			err = b.server.ListenAndServe()
+			b.deps.ReportError(SourceServer, err)
+			b.stopAdmin()
+			b.stopMetrics()
+			b.stopPprof()
+			b.stopI2PControl()
+			b.stopSOCKS()
+			b.stopHTTPProxy()
+			b.stopBOB()
+			b.stopTunnels()
+			b.notifyStop(err)
 		}
 
 		// Store error and signal done
 		b.mu.Lock()
 		b.err = err
+		b.listeners = nil
 		b.running.Store(false)
 		b.mu.Unlock()
 
@@ -213,31 +400,106 @@ func (b *Bridge) Start(ctx context.Context) error {
 		b.Stop(context.Background())
 	}()
 
-	b.deps.Logger.WithField("addr", b.config.ListenAddr).Info("SAM bridge started")
+	for _, l := range listeners {
+		b.deps.Logger.WithField("addr", l.Addr()).Info("SAM bridge started")
+	}
 
 	return nil
 }
 
+// serve runs the server on each listener until all of them return.
+// If one fails, the server is closed so the others stop too, and the
+// first error is returned.
+func (b *Bridge) serve(listeners []net.Listener) error {
+	errs := make(chan error, len(listeners))
+	for _, l := range listeners {
+		go func(l net.Listener) {
+			errs <- b.server.Serve(l)
+		}(l)
+	}
+
+	var first error
+	for range listeners {
+		if err := <-errs; err != nil && first == nil {
+			first = err
+			b.server.Close()
+		}
+	}
+	return first
+}
+
 // Stop gracefully shuts down the bridge.
 // The context can be used to set a timeout for shutdown operations.
+// If a drain timeout is configured, Stop drains existing connections
+// first; see Drain.
 func (b *Bridge) Stop(ctx context.Context) error {
-	b.mu.Lock()
-	if !b.running.Load() {
-		b.mu.Unlock()
+	if b.config.DrainTimeout > 0 {
+		drainCtx, cancel := context.WithTimeout(ctx, b.config.DrainTimeout)
+		defer cancel()
+		return b.Drain(drainCtx)
+	}
+
+	if !b.beginStop() {
 		return nil // Already stopped
 	}
-	b.mu.Unlock()
 
 	b.deps.Logger.Info("Stopping SAM bridge...")
+	if err := b.server.Close(); err != nil {
+		b.deps.Logger.WithError(err).Warn("Error closing server")
+	}
+	b.shutdown()
+	return nil
+}
+
+// Drain stops accepting new connections and keeps forwarding existing
+// streams until they close or ctx is done, then force-closes whatever
+// remains and shuts the bridge down.
+// Returns ctx.Err() if connections had to be force-closed.
+func (b *Bridge) Drain(ctx context.Context) error {
+	if !b.beginStop() {
+		return nil // Already stopped
+	}
+
+	b.deps.Logger.WithField("connections", b.server.ConnectionCount()).Info("Draining SAM bridge...")
+	err := b.server.Shutdown(ctx)
+	if err != nil {
+		b.deps.Logger.WithError(err).Warn("Drain deadline reached, force-closed remaining connections")
+	}
+	b.shutdown()
+	return err
+}
+
+// beginStop marks the bridge as stopping.
+// Returns false if the bridge is not running or is already stopping.
+func (b *Bridge) beginStop() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if !b.running.Load() || b.stopping {
+		return false
+	}
+	b.stopping = true
+	return true
+}
 
+// shutdown releases sessions, the UDP listener and the embedded router
+// after the server has been closed.
+func (b *Bridge) shutdown() {
 	// Cancel the start context
 	if b.cancelFn != nil {
 		b.cancelFn()
 	}
 
-	// Close the server
-	if err := b.server.Close(); err != nil {
-		b.deps.Logger.WithError(err).Warn("Error closing server")
+	b.stopAdmin()
+	b.stopMetrics()
+	b.stopPprof()
+	b.stopI2PControl()
+	b.stopSOCKS()
+	b.stopHTTPProxy()
+	b.stopBOB()
+	b.stopTunnels()
+	if b.stopDestPool != nil {
+		b.stopDestPool()
+		b.stopDestPool = nil
 	}
 
 	// Close all sessions
@@ -254,6 +516,20 @@ func (b *Bridge) Stop(ctx context.Context) error {
 		}
 	}
 
+	if err := closeLogFiles(b.auditFile); err != nil {
+		b.deps.Logger.WithError(err).Warn("Error closing audit log")
+	}
+	if err := closeLogFiles(b.accessFile); err != nil {
+		b.deps.Logger.WithError(err).Warn("Error closing access log")
+	}
+
+	// Release the shared I2CP provider; the last bridge closes it
+	if b.releaseI2CP != nil {
+		if err := b.releaseI2CP(); err != nil {
+			b.deps.Logger.WithError(err).Warn("Error closing shared I2CP provider")
+		}
+	}
+
 	b.deps.Logger.Info("SAM bridge stopped")
 
 	// Stop embedded router if we started one
@@ -264,7 +540,14 @@ func (b *Bridge) Stop(ctx context.Context) error {
 		b.deps.Logger.Info("Embedded router stopped")
 	}
 
-	return nil
+	b.notifyStop(nil)
+}
+
+// notifyStop calls the OnStop callback, if configured, at most once.
+func (b *Bridge) notifyStop(err error) {
+	if b.config.OnStop != nil {
+		b.stopOnce.Do(func() { b.config.OnStop(err) })
+	}
 }
 
 // Wait blocks until the bridge has stopped.
@@ -276,11 +559,63 @@ func (b *Bridge) Wait() error {
 	return b.err
 }
 
+// WaitContext blocks until the bridge has stopped or ctx is done.
+// Returns the error that caused the shutdown, or ctx.Err() if ctx
+// finished first. The bridge keeps running when ctx expires.
+func (b *Bridge) WaitContext(ctx context.Context) error {
+	select {
+	case <-b.done:
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		return b.err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Errors returns a channel of background failures, each a *BackgroundError:
+// accept loop errors, UDP listener failures, I2CP disconnects (if the
+// I2CPProvider implements ErrorNotifier), and STREAM FORWARD failures.
+// Failures are also logged. The channel is buffered and never closed;
+// errors are dropped if it is full, so receive from it promptly.
+func (b *Bridge) Errors() <-chan error {
+	return b.errs
+}
+
 // Running returns true if the bridge is actively serving.
 func (b *Bridge) Running() bool {
 	return b.running.Load()
 }
 
+// Health returns nil if the bridge is serving and its I2CP provider is
+// connected to the router. Otherwise it returns ErrBridgeNotRunning or
+// ErrI2CPDisconnected, or ErrI2CPUnresponsive if the provider implements
+// session.I2CPHealthProvider and the router has stopped answering. It is
+// cheap enough to call from liveness probes and watchdogs.
+func (b *Bridge) Health() error {
+	if !b.Running() {
+		return ErrBridgeNotRunning
+	}
+	if !b.deps.I2CPProvider.IsConnected() {
+		return ErrI2CPDisconnected
+	}
+	if health, ok := b.I2CPHealth(); ok && health.Unresponsive {
+		return ErrI2CPUnresponsive
+	}
+	return nil
+}
+
+// I2CPHealth returns round-trip latency, error and disconnect counts for
+// the I2CP router connection. The second result is false if the
+// I2CPProvider does not implement session.I2CPHealthProvider.
+func (b *Bridge) I2CPHealth() (session.I2CPHealth, bool) {
+	provider, ok := b.deps.I2CPProvider.(session.I2CPHealthProvider)
+	if !ok {
+		return session.I2CPHealth{}, false
+	}
+	return provider.I2CPHealth(), true
+}
+
 // Server returns the underlying bridge.Server.
 // This allows advanced access to the server's Router and other internals.
 func (b *Bridge) Server() *bridge.Server {
@@ -293,6 +628,19 @@ func (b *Bridge) Dependencies() *Dependencies {
 	return b.deps
 }
 
+// RouterInfo returns the connected I2P router's version, clock, and
+// supported features, as reported during the I2CP handshake, so callers
+// can feature-gate on router capabilities. The second result is false if
+// the I2CPProvider does not implement session.RouterInfoProvider or is
+// not connected.
+func (b *Bridge) RouterInfo() (session.RouterInfo, bool) {
+	provider, ok := b.deps.I2CPProvider.(session.RouterInfoProvider)
+	if !ok {
+		return session.RouterInfo{}, false
+	}
+	return provider.RouterInfo()
+}
+
 // Config returns the bridge's configuration.
 // This is a read-only view; modifying the returned config has no effect.
 func (b *Bridge) Config() *Config {
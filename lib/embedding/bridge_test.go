@@ -1,11 +1,17 @@
 package embedding
 
 import (
+	"bufio"
 	"context"
+	"errors"
 	"net"
+	"strconv"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/go-i2p/go-i2p/lib/embedded"
+	"github.com/go-i2p/go-sam-bridge/lib/bridge"
 	"github.com/go-i2p/go-sam-bridge/lib/session"
 )
 
@@ -155,6 +161,60 @@ func TestBridgeLifecycle(t *testing.T) {
 	}
 }
 
+// failingRouter is an embedded router whose Start fails.
+type failingRouter struct {
+	embedded.EmbeddedRouter
+}
+
+func (failingRouter) Start() error { return errors.New("router failed") }
+
+// freeAddr returns a loopback address with a port that is free now.
+func freeAddr(t *testing.T) string {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("net.Listen() error = %v", err)
+	}
+	defer ln.Close()
+	return ln.Addr().String()
+}
+
+func TestBridgeStart_RouterError(t *testing.T) {
+	listenAddr, adminAddr := freeAddr(t), freeAddr(t)
+	b, err := New(
+		WithListenAddr(listenAddr),
+		WithI2CPProvider(&mockI2CPProvider{}),
+		WithDatagramPort(0),
+		WithAdminAddr(adminAddr),
+	)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	b.embeddedRouter = failingRouter{}
+	if err := b.Start(context.Background()); err == nil {
+		t.Fatal("Start() with a failing router error = nil, want error")
+	}
+	if b.Running() || b.admin != nil {
+		t.Error("bridge left running or serving admin after Start failed")
+	}
+	for _, addr := range []string{listenAddr, adminAddr} {
+		ln, err := net.Listen("tcp", addr)
+		if err != nil {
+			t.Errorf("%s still bound after Start failed: %v", addr, err)
+			continue
+		}
+		ln.Close()
+	}
+
+	// Start can be retried once the router problem is gone.
+	b.embeddedRouter = nil
+	if err := b.Start(context.Background()); err != nil {
+		t.Fatalf("Start() retry error = %v", err)
+	}
+	b.Stop(context.Background())
+}
+
 func TestBridgeContextCancellation(t *testing.T) {
 	// Create a test listener
 	ln, err := net.Listen("tcp", "127.0.0.1:0")
@@ -286,3 +346,472 @@ func TestBridgeWithUDPListener(t *testing.T) {
 		t.Errorf("Stop() error = %v", err)
 	}
 }
+
+func TestBridgeListenAddrWithDatagramPort(t *testing.T) {
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to find a free UDP port: %v", err)
+	}
+	port := pc.LocalAddr().(*net.UDPAddr).Port
+	pc.Close()
+
+	b, err := New(
+		WithListenAddr("127.0.0.1:0"),
+		WithI2CPProvider(&mockI2CPProvider{}),
+		WithDatagramPort(port),
+	)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if err := b.Start(context.Background()); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	defer b.Stop(context.Background())
+
+	// The datagram port must only be bound once
+	select {
+	case err := <-b.Errors():
+		t.Errorf("background error after Start: %v", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+	if !b.Running() {
+		t.Error("bridge should keep running with a datagram port")
+	}
+}
+
+func TestBridgeWithDatagramPacketConn(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to create test listener: %v", err)
+	}
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to create test packet conn: %v", err)
+	}
+
+	b, err := New(
+		WithListener(ln),
+		WithI2CPProvider(&mockI2CPProvider{}),
+		WithDatagramPacketConn(pc),
+	)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if err := b.Start(context.Background()); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+
+	if got := b.udpListener.Addr(); got == nil || got.String() != pc.LocalAddr().String() {
+		t.Errorf("UDP listener address = %v, want %v", got, pc.LocalAddr())
+	}
+
+	if err := b.Stop(context.Background()); err != nil {
+		t.Fatalf("Stop() error = %v", err)
+	}
+	if _, err := pc.WriteTo([]byte("x"), pc.LocalAddr()); err == nil {
+		t.Error("Stop should close the injected packet conn")
+	}
+}
+
+func TestBridgeDrain(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to create test listener: %v", err)
+	}
+
+	bridge, err := New(
+		WithListener(ln),
+		WithI2CPProvider(&mockI2CPProvider{}),
+		WithDatagramPort(0),
+	)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	if err := bridge.Start(context.Background()); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+
+	conn, err := net.Dial("tcp", ln.Addr().String())
+	if err != nil {
+		t.Fatalf("Dial() error = %v", err)
+	}
+	defer conn.Close()
+
+	deadline := time.Now().Add(time.Second)
+	for bridge.Server().ConnectionCount() == 0 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	drained := make(chan error, 1)
+	go func() { drained <- bridge.Drain(context.Background()) }()
+
+	// The existing connection keeps the bridge draining
+	select {
+	case err := <-drained:
+		t.Fatalf("Drain() returned early with %v while a connection was open", err)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	conn.Close()
+
+	select {
+	case err := <-drained:
+		if err != nil {
+			t.Errorf("Drain() error = %v, want nil", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Drain() did not return after the connection closed")
+	}
+
+	if err := bridge.Wait(); err != nil {
+		t.Errorf("Wait() error = %v", err)
+	}
+	if bridge.Running() {
+		t.Error("Bridge should not be running after Drain()")
+	}
+}
+
+func TestBridgeAuthFunc(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to create test listener: %v", err)
+	}
+
+	bridge, err := New(
+		WithListener(ln),
+		WithI2CPProvider(&mockI2CPProvider{}),
+		WithDatagramPort(0),
+		WithAuth(map[string]string{"local": "pass"}),
+		WithAuthFunc(func(user, password string) bool {
+			return user == "external" && password == "token"
+		}),
+	)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if err := bridge.Start(context.Background()); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	defer bridge.Stop(context.Background())
+
+	tests := []struct {
+		name   string
+		hello  string
+		wantOK bool
+	}{
+		{"accepted by callback", "HELLO VERSION MIN=3.0 MAX=3.3 USER=external PASSWORD=token\n", true},
+		{"static user ignored", "HELLO VERSION MIN=3.0 MAX=3.3 USER=local PASSWORD=pass\n", false},
+		{"no credentials", "HELLO VERSION MIN=3.0 MAX=3.3\n", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conn, err := net.Dial("tcp", ln.Addr().String())
+			if err != nil {
+				t.Fatalf("Dial() error = %v", err)
+			}
+			defer conn.Close()
+
+			conn.Write([]byte(tt.hello))
+			line, err := bufio.NewReader(conn).ReadString('\n')
+			if err != nil {
+				t.Fatalf("ReadString() error = %v", err)
+			}
+			if got := strings.Contains(line, "RESULT=OK"); got != tt.wantOK {
+				t.Errorf("HELLO response = %q, want OK = %v", line, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestBridgeDump(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to create test listener: %v", err)
+	}
+	bridge, err := New(
+		WithListener(ln),
+		WithI2CPProvider(&mockI2CPProvider{}),
+		WithDatagramPort(0),
+		WithAuth(map[string]string{"alice": "secret"}),
+	)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if err := bridge.Start(context.Background()); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	defer bridge.Stop(context.Background())
+
+	conn, err := net.Dial("tcp", ln.Addr().String())
+	if err != nil {
+		t.Fatalf("Dial() error = %v", err)
+	}
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	conn.Write([]byte("HELLO VERSION MIN=3.0 MAX=3.3 USER=alice PASSWORD=secret\n"))
+	r.ReadString('\n')
+
+	conn.Write([]byte("DUMP\n"))
+	line, err := r.ReadString('\n')
+	if err != nil || !strings.HasPrefix(line, "DUMP REPLY RESULT=OK COUNT=") {
+		t.Fatalf("DUMP reply = %q, %v", line, err)
+	}
+	n, _ := strconv.Atoi(strings.TrimSpace(strings.TrimPrefix(line, "DUMP REPLY RESULT=OK COUNT=")))
+	var text strings.Builder
+	for i := 0; i < n; i++ {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			t.Fatalf("reading DUMP line %d: %v", i, err)
+		}
+		text.WriteString(line)
+	}
+	for _, want := range []string{"TEXT=\"runtime ", "TEXT=\"forward forwarded=", "TEXT=\"i2cp connected="} {
+		if !strings.Contains(text.String(), want) {
+			t.Errorf("DUMP lines missing %q in %q", want, text.String())
+		}
+	}
+}
+
+func TestBridgeStopWithDrainTimeout(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to create test listener: %v", err)
+	}
+
+	bridge, err := New(
+		WithListener(ln),
+		WithI2CPProvider(&mockI2CPProvider{}),
+		WithDatagramPort(0),
+		WithDrainTimeout(100*time.Millisecond),
+	)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	if err := bridge.Start(context.Background()); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+
+	conn, err := net.Dial("tcp", ln.Addr().String())
+	if err != nil {
+		t.Fatalf("Dial() error = %v", err)
+	}
+	defer conn.Close()
+
+	deadline := time.Now().Add(time.Second)
+	for bridge.Server().ConnectionCount() == 0 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	// The connection never closes, so Stop must force-close it after the timeout
+	if err := bridge.Stop(context.Background()); err != context.DeadlineExceeded {
+		t.Errorf("Stop() error = %v, want %v", err, context.DeadlineExceeded)
+	}
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+	if _, err := conn.Read(make([]byte, 1)); err == nil {
+		t.Error("Connections should be force-closed after the drain timeout")
+	}
+}
+
+func TestBridgeWaitContext(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to create test listener: %v", err)
+	}
+
+	bridge, err := New(
+		WithListener(ln),
+		WithI2CPProvider(&mockI2CPProvider{}),
+		WithDatagramPort(0),
+	)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	if err := bridge.Start(context.Background()); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+
+	// Timeout while the bridge is still running
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := bridge.WaitContext(ctx); err != context.DeadlineExceeded {
+		t.Errorf("WaitContext() error = %v, want %v", err, context.DeadlineExceeded)
+	}
+	if !bridge.Running() {
+		t.Error("Bridge should keep running after WaitContext() times out")
+	}
+
+	if err := bridge.Stop(context.Background()); err != nil {
+		t.Fatalf("Stop() error = %v", err)
+	}
+
+	ctx2, cancel2 := context.WithTimeout(context.Background(), time.Second)
+	defer cancel2()
+	if err := bridge.WaitContext(ctx2); err != nil {
+		t.Errorf("WaitContext() after Stop() error = %v, want nil", err)
+	}
+}
+
+func TestBridgeLifecycleHooks(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to create test listener: %v", err)
+	}
+
+	started := make(chan struct{}, 1)
+	stopped := make(chan error, 2)
+	states := make(chan bridge.ConnectionState, 2)
+
+	b, err := New(
+		WithListener(ln),
+		WithI2CPProvider(&mockI2CPProvider{}),
+		WithDatagramPort(0),
+		WithOnStart(func() { started <- struct{}{} }),
+		WithOnStop(func(err error) { stopped <- err }),
+		WithOnConnection(func(conn net.Conn, state bridge.ConnectionState) { states <- state }),
+	)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	if err := b.Start(context.Background()); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	select {
+	case <-started:
+	default:
+		t.Error("OnStart should be called before Start returns")
+	}
+
+	conn, err := net.Dial("tcp", ln.Addr().String())
+	if err != nil {
+		t.Fatalf("Dial() error = %v", err)
+	}
+	for _, want := range []bridge.ConnectionState{bridge.StateNew, bridge.StateClosed} {
+		if want == bridge.StateClosed {
+			conn.Close()
+		}
+		select {
+		case got := <-states:
+			if got != want {
+				t.Errorf("OnConnection state = %v, want %v", got, want)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("timed out waiting for OnConnection %v", want)
+		}
+	}
+
+	if err := b.Stop(context.Background()); err != nil {
+		t.Fatalf("Stop() error = %v", err)
+	}
+	b.Stop(context.Background())
+
+	select {
+	case err := <-stopped:
+		if err != nil {
+			t.Errorf("OnStop error = %v, want nil", err)
+		}
+	default:
+		t.Fatal("OnStop should be called before Stop returns")
+	}
+	if len(stopped) != 0 {
+		t.Error("OnStop should be called once")
+	}
+}
+
+// routerInfoProvider is an I2CP provider that describes its router.
+type routerInfoProvider struct {
+	mockI2CPProvider
+	info session.RouterInfo
+}
+
+func (p *routerInfoProvider) RouterInfo() (session.RouterInfo, bool) { return p.info, true }
+
+func TestBridgeRouterInfo(t *testing.T) {
+	b, err := New(WithListenAddr("127.0.0.1:0"), WithI2CPProvider(&mockI2CPProvider{}), WithDatagramPort(0))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if _, ok := b.RouterInfo(); ok {
+		t.Error("RouterInfo() should report false for a provider without router info")
+	}
+
+	want := session.RouterInfo{Version: "0.9.66", MultiSession: true, LeaseSet2: true}
+	b, err = New(WithListenAddr("127.0.0.1:0"), WithI2CPProvider(&routerInfoProvider{info: want}), WithDatagramPort(0))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	got, ok := b.RouterInfo()
+	if !ok || got != want {
+		t.Errorf("RouterInfo() = %+v, %v; want %+v, true", got, ok, want)
+	}
+}
+
+// disconnectedI2CPProvider reports no router connection.
+type disconnectedI2CPProvider struct{ mockI2CPProvider }
+
+func (p *disconnectedI2CPProvider) IsConnected() bool { return false }
+
+func TestBridgeHealth(t *testing.T) {
+	b, err := New(WithListenAddr("127.0.0.1:0"), WithI2CPProvider(&mockI2CPProvider{}), WithDatagramPort(0))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if err := b.Health(); !errors.Is(err, ErrBridgeNotRunning) {
+		t.Errorf("Health() before Start = %v, want ErrBridgeNotRunning", err)
+	}
+
+	if err := b.Start(context.Background()); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	if err := b.Health(); err != nil {
+		t.Errorf("Health() while running = %v, want nil", err)
+	}
+	b.Stop(context.Background())
+
+	b, err = New(WithListenAddr("127.0.0.1:0"), WithI2CPProvider(&disconnectedI2CPProvider{}), WithDatagramPort(0))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if err := b.Start(context.Background()); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	defer b.Stop(context.Background())
+	if err := b.Health(); !errors.Is(err, ErrI2CPDisconnected) {
+		t.Errorf("Health() with disconnected provider = %v, want ErrI2CPDisconnected", err)
+	}
+}
+
+// healthProvider is an I2CP provider that reports connection health.
+type healthProvider struct {
+	mockI2CPProvider
+	health session.I2CPHealth
+}
+
+func (p *healthProvider) I2CPHealth() session.I2CPHealth { return p.health }
+
+func TestBridgeHealth_Unresponsive(t *testing.T) {
+	provider := &healthProvider{}
+	b, err := New(WithListenAddr("127.0.0.1:0"), WithI2CPProvider(provider), WithDatagramPort(0))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if err := b.Start(context.Background()); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	defer b.Stop(context.Background())
+
+	if err := b.Health(); err != nil {
+		t.Errorf("Health() = %v, want nil", err)
+	}
+	provider.health = session.I2CPHealth{Pending: 1, OldestPending: time.Minute, Unresponsive: true}
+	if err := b.Health(); !errors.Is(err, ErrI2CPUnresponsive) {
+		t.Errorf("Health() with unresponsive router = %v, want ErrI2CPUnresponsive", err)
+	}
+	if health, ok := b.I2CPHealth(); !ok || health.Pending != 1 {
+		t.Errorf("I2CPHealth() = %+v, %v; want Pending 1, true", health, ok)
+	}
+}
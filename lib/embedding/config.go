@@ -4,11 +4,18 @@ package embedding
 
 import (
 	"crypto/tls"
+	"fmt"
+	"io"
 	"net"
+	"time"
 
 	"github.com/go-i2p/go-sam-bridge/lib/bridge"
+	"github.com/go-i2p/go-sam-bridge/lib/destination"
 	"github.com/go-i2p/go-sam-bridge/lib/handler"
 	"github.com/go-i2p/go-sam-bridge/lib/session"
+	"github.com/go-i2p/go-sam-bridge/lib/socks"
+	"github.com/go-i2p/go-sam-bridge/lib/tunnels"
+	"github.com/go-i2p/go-sam-bridge/lib/util"
 	"github.com/sirupsen/logrus"
 )
 
@@ -40,31 +47,81 @@ type Config struct {
 	// DatagramPort is the UDP port for datagram forwarding (default 7655).
 	DatagramPort int
 
+	// DatagramPacketConn is a pre-bound UDP socket for datagram forwarding.
+	// When set, DatagramPort is ignored and the bridge closes the socket
+	// when it stops.
+	DatagramPacketConn net.PacketConn
+
 	// I2CPUsername for I2CP authentication (optional).
 	I2CPUsername string
 
 	// I2CPPassword for I2CP authentication (optional).
 	I2CPPassword string
 
+	// I2CPTLS enables TLS on the I2CP connection, for routers with
+	// i2cp.SSL enabled (optional).
+	I2CPTLS *I2CPTLSConfig
+
+	// I2CPFailoverAddrs are further I2CP router addresses to fail over to
+	// when I2CPAddr cannot be reached or drops (optional).
+	I2CPFailoverAddrs []string
+
 	// TLSConfig enables TLS on the control socket if non-nil.
 	TLSConfig *tls.Config
 
+	// TLSCertFile and TLSKeyFile enable TLS using a certificate and key
+	// loaded from disk. Unlike a static TLSConfig, the pair can be reloaded
+	// at runtime with Bridge.ReloadTLS. TLSConfig, if also set, supplies
+	// the remaining TLS settings.
+	TLSCertFile string
+	TLSKeyFile  string
+
+	// TLSPolicy restricts the protocol versions and cipher suites of the
+	// control socket's TLS and sets its ALPN protocols. It overrides the
+	// corresponding TLSConfig fields.
+	TLSPolicy TLSPolicy
+
 	// AuthUsers maps usernames to passwords for SAM authentication.
-	// Empty map disables authentication.
+	// Empty map disables authentication. Passwords may be bcrypt or
+	// Argon2id hashes (see bridge.HashPassword) instead of plaintext.
 	AuthUsers map[string]string
 
+	// AuthFunc validates SAM credentials if non-nil.
+	// It enables authentication and takes precedence over AuthUsers.
+	AuthFunc func(user, password string) bool
+
 	// Listener is a custom net.Listener for the SAM server.
 	// If nil, the bridge creates its own listener on ListenAddr.
 	Listener net.Listener
 
+	// Endpoints are additional control sockets served alongside
+	// ListenAddr or Listener, such as a Unix socket or a TLS port next
+	// to a plain one. TLSConfig applies to ListenAddr whenever it is set;
+	// each Endpoint opts in to TLS individually.
+	Endpoints []Endpoint
+
+	// ProxyProtocol requires connections to ListenAddr or Listener to
+	// begin with a PROXY protocol header from a load balancer, whose
+	// client address then replaces the balancer's. Endpoints enable it
+	// individually. See bridge.ProxyListener.
+	ProxyProtocol bool
+
 	// Registry is a custom session registry.
 	// If nil, a default registry is created.
 	Registry session.Registry
 
+	// SessionObservers are notified when sessions are registered and
+	// unregistered. The registry must implement session.Observable.
+	SessionObservers []session.Observer
+
 	// I2CPProvider is a custom I2CP session provider.
 	// If nil, the bridge creates one using I2CPAddr.
 	I2CPProvider session.I2CPSessionProvider
 
+	// SharedI2CP is an I2CP provider shared with other bridges in the
+	// process. It cannot be combined with I2CPProvider.
+	SharedI2CP *SharedI2CP
+
 	// Logger is a custom logger instance.
 	// If nil, a default logger is created.
 	Logger *logrus.Logger
@@ -73,8 +130,273 @@ type Config struct {
 	// If nil, DefaultHandlerRegistrar is used.
 	HandlerRegistrar HandlerRegistrarFunc
 
+	// HandshakeTimeout is the maximum time to wait for HELLO after a client
+	// connects. Zero uses bridge.DefaultHandshakeTimeout.
+	HandshakeTimeout time.Duration
+
+	// CommandTimeout is the maximum time to wait between commands after
+	// HELLO. Zero uses bridge.DefaultCommandTimeout.
+	CommandTimeout time.Duration
+
+	// IdleTimeout closes connections that completed HELLO but have no
+	// session and sent no command for this long. Zero (the default)
+	// leaves only CommandTimeout.
+	IdleTimeout time.Duration
+
+	// ReadBufferSize is the buffer size for reading commands.
+	// Zero uses bridge.DefaultReadBufferSize.
+	ReadBufferSize int
+
+	// MaxLineLength is the maximum allowed command line length.
+	// Zero uses bridge.DefaultMaxLineLength.
+	MaxLineLength int
+
+	// MaxSessions limits the number of registered sessions. Further
+	// SESSION CREATEs fail with RESULT=I2P_ERROR. Zero means no limit.
+	MaxSessions int
+
+	// MaxSessionsPerUser limits the sessions each authenticated SAM user
+	// may create. Zero means no limit.
+	MaxSessionsPerUser int
+
+	// MaxOutboundStreams and MaxInboundStreams cap the concurrent streams
+	// each session opens with STREAM CONNECT and accepts with STREAM
+	// ACCEPT or FORWARD. A CONNECT or ACCEPT beyond the limit fails with
+	// RESULT=I2P_ERROR. Sessions may lower them with the
+	// sam.maxOutboundStreams and sam.maxInboundStreams options. Zero (the
+	// default) means no limit.
+	MaxOutboundStreams int
+	MaxInboundStreams  int
+
+	// SendQueueSize enables a per-session queue of this many outgoing
+	// datagrams, so DATAGRAM and RAW sends survive a brief tunnel rebuild.
+	// Queued sends failing with a transient I2CP error are retried up to
+	// SendRetries times until SendExpiry has passed; zero values use
+	// session.DefaultSendRetries and session.DefaultSendExpiry. Sessions
+	// may override them with the sam.sendQueue, sam.sendRetries and
+	// sam.sendExpiry options. Zero (the default) sends synchronously.
+	SendQueueSize int
+	SendRetries   int
+	SendExpiry    time.Duration
+
+	// MaxConnections limits concurrently handled SAM client connections.
+	// Zero means no limit.
+	MaxConnections int
+
+	// MaxQueuedConnections is how many connections beyond MaxConnections
+	// may wait, up to the handshake timeout, for one to close. Others are
+	// closed at once.
+	MaxQueuedConnections int
+
+	// MaxConnectionsPerIP limits concurrently handled SAM client
+	// connections from one remote IP address. Zero means no limit.
+	MaxConnectionsPerIP int
+
+	// StreamBufferSize is the size of the pooled buffers stream data is
+	// copied through, per direction. Zero uses util.DefaultCopyBufferSize.
+	StreamBufferSize int
+
+	// SocketOptions sets TCP keepalive, TCP_NODELAY and socket buffer
+	// sizes on SAM client connections and on connections STREAM FORWARD
+	// makes to local targets. The zero value keeps Go's defaults.
+	SocketOptions util.SocketOptions
+
+	// ForwardRetry controls how STREAM FORWARD retries connecting to a
+	// local target that is down, such as a service restarting. The zero
+	// value uses handler.DefaultForwardRetry.
+	ForwardRetry handler.ForwardRetry
+
+	// AcceptBacklog is how many inbound streams each STREAM session holds
+	// for its next STREAM ACCEPT, each for up to AcceptBacklogTimeout
+	// (zero uses handler.DefaultAcceptBacklogTimeout). Zero disables the
+	// backlog.
+	AcceptBacklog        int
+	AcceptBacklogTimeout time.Duration
+
+	// StreamReadTimeout closes streams whose peer sends nothing for this
+	// long, and StreamWriteTimeout streams whose writes stall this long.
+	// They apply to STREAM CONNECT, ACCEPT and FORWARD alike. Sessions may
+	// override them with the sam.streamReadTimeout and sam.streamWriteTimeout
+	// options. Zero (the default) disables them.
+	StreamReadTimeout  time.Duration
+	StreamWriteTimeout time.Duration
+
+	// DestinationPoolSize is how many Ed25519 destinations are generated
+	// ahead of time for DEST GENERATE and TRANSIENT sessions while the
+	// bridge runs. Zero disables the pool.
+	DestinationPoolSize int
+
+	// SessionDefaults overrides the tunnel parameters applied to SESSION
+	// CREATE commands that do not specify them. Nil keeps the built-in defaults.
+	SessionDefaults *SessionDefaults
+
+	// KeyStore stores the private keys of SESSION CREATE
+	// DESTINATION=file:$name. It takes precedence over KeyStoreDir; use it
+	// to encrypt keys with a KMS through destination.KMSWrapper.
+	KeyStore *destination.KeyStore
+
+	// KeyStoreDir, with KeyStorePassphrase, enables DESTINATION=file: with
+	// keys kept in this directory, encrypted under the passphrase.
+	KeyStoreDir        string
+	KeyStorePassphrase string
+
+	// AuditLog receives one JSON record per processed SAM command if
+	// non-nil. Passwords and private keys are redacted.
+	AuditLog io.Writer
+
+	// AuditLogFile is a file path that audit records are appended to.
+	// It may be combined with AuditLog.
+	AuditLogFile string
+
+	// AccessLog receives one JSON record per SAM control connection when
+	// it closes, if non-nil: connect and disconnect times, client
+	// address, SAM version, user and bytes transferred.
+	AccessLog io.Writer
+
+	// AccessLogFile is a file path that access records are appended to.
+	// It may be combined with AccessLog.
+	AccessLogFile string
+
+	// AdminAddr, if set, serves Bridge.AdminHandler over HTTP on this
+	// address while the bridge runs. The admin API is unauthenticated;
+	// use a loopback address such as "127.0.0.1:7657".
+	AdminAddr string
+
+	// MetricsAddr, if set, serves Bridge.MetricsHandler over HTTP on this
+	// address while the bridge runs, for scraping by Prometheus.
+	MetricsAddr string
+
+	// PprofAddr, if set, serves the net/http/pprof profiling handlers
+	// under /debug/pprof/ on this address while the bridge runs. Profiles
+	// expose memory contents and are unauthenticated, so it must be a
+	// loopback address such as "127.0.0.1:6060".
+	PprofAddr string
+
+	// I2PControlAddr, if set, serves a subset of the I2PControl JSON-RPC
+	// API (see Bridge.I2PControlHandler) over HTTP on this address while
+	// the bridge runs, so I2P monitoring tools can read SAM statistics.
+	I2PControlAddr string
+
+	// I2PControlPassword is the password I2PControl clients authenticate
+	// with, or a bcrypt or Argon2id hash of it. Empty means
+	// DefaultI2PControlPassword, which is only accepted when
+	// I2PControlAddr is a loopback address.
+	I2PControlPassword string
+
+	// SOCKSAddr, if set, serves a SOCKS5 proxy on this address while the
+	// bridge runs, so applications that cannot speak SAM reach .i2p and
+	// .b32.i2p hosts through one shared STREAM session. Only CONNECT is
+	// supported. The proxy is unauthenticated; use a loopback address
+	// such as "127.0.0.1:4447".
+	SOCKSAddr string
+
+	// SOCKSUser and SOCKSPassword are sent with HELLO on the SOCKS
+	// proxy's SAM connections, for bridges that require authentication.
+	SOCKSUser     string
+	SOCKSPassword string
+
+	// HTTPProxyAddr, if set, serves an HTTP proxy on this address while
+	// the bridge runs. It tunnels CONNECT requests and forwards requests
+	// with an absolute URI to .i2p and .b32.i2p hosts through one shared
+	// STREAM session. Like the SOCKS5 proxy it is unauthenticated; use a
+	// loopback address such as "127.0.0.1:4444".
+	HTTPProxyAddr string
+
+	// HTTPProxyUser and HTTPProxyPassword are sent with HELLO on the HTTP
+	// proxy's SAM connections, for bridges that require authentication.
+	HTTPProxyUser     string
+	HTTPProxyPassword string
+
+	// HTTPOutproxy is the host:port of an HTTP proxy on I2P that the HTTP
+	// proxy sends requests for clearnet hosts through. When empty, such
+	// requests are rejected with 403 Forbidden.
+	HTTPOutproxy string
+
+	// BOBAddr, if set, serves a BOB listener on this address while the
+	// bridge runs, for legacy applications that set up tunnels with BOB
+	// instead of SAM. Each started BOB tunnel is a STREAM session on the
+	// bridge. BOB has no authentication and hands out private keys; use
+	// a loopback address such as "127.0.0.1:2827".
+	BOBAddr string
+
+	// BOBUser and BOBPassword are sent with HELLO on the BOB listener's
+	// SAM connections, for bridges that require authentication.
+	BOBUser     string
+	BOBPassword string
+
+	// Tunnels are client and server tunnels run while the bridge runs,
+	// each on its own STREAM session, restarted when it fails (see
+	// package tunnels).
+	Tunnels []tunnels.Tunnel
+
+	// TunnelUser and TunnelPassword are sent with HELLO on the tunnels'
+	// SAM connections, for bridges that require authentication.
+	TunnelUser     string
+	TunnelPassword string
+
+	// DrainTimeout enables drain mode for Stop when positive.
+	// Stop stops accepting new connections and waits up to this long
+	// for existing connections to close before force-closing them.
+	// Zero (the default) closes all connections immediately.
+	DrainTimeout time.Duration
+
+	// SessionIdleTimeout closes sessions that have had no traffic for
+	// this long, after telling their control socket why. Zero (the
+	// default) keeps idle sessions open unless a session sets
+	// i2cp.closeIdleTime itself.
+	SessionIdleTimeout time.Duration
+
+	// WriteTimeout is the longest a write to a SAM client may block
+	// before the client is disconnected. Zero (the default) waits
+	// indefinitely for clients that stop reading.
+	WriteTimeout time.Duration
+
+	// StrictQuoting rejects commands from SAM 3.2 and later clients whose
+	// quoting or escaping the specification does not allow, instead of
+	// repairing them. Clients negotiating 3.0 or 3.1 are unaffected.
+	StrictQuoting bool
+
+	// ReplaceInvalidUTF8 replaces invalid UTF-8 in commands with U+FFFD
+	// instead of rejecting them. It does not apply with StrictQuoting to
+	// SAM 3.2 and later clients, whose commands must be valid UTF-8.
+	ReplaceInvalidUTF8 bool
+
+	// OnStart is called after the bridge starts serving.
+	OnStart func()
+
+	// OnStop is called once when the bridge stops serving, with the error
+	// that caused it to stop, or nil after Stop or Drain.
+	OnStop func(err error)
+
+	// OnConnection is called when a SAM client connection is accepted
+	// (bridge.StateNew) and when it closes (bridge.StateClosed).
+	OnConnection func(conn net.Conn, state bridge.ConnectionState)
+
+	// Trace is called with every SAM line received from and sent to
+	// clients, with secrets redacted. See bridge.Config.Trace.
+	Trace func(bridge.TraceRecord)
+
+	// PanicHandler is called with every panic recovered while serving a
+	// SAM client, after the panic has been logged. See
+	// bridge.Config.OnPanic.
+	PanicHandler func(bridge.PanicRecord)
+
+	// LogFormat selects the logger's output format, LogFormatText or
+	// LogFormatJSON. Empty leaves the logger's formatter unchanged.
+	LogFormat string
+
 	// Debug enables debug logging.
 	Debug bool
+
+	// LogSampleBurst limits hot-path log records, such as forwarding
+	// failures and tunnel events, to this many per site per
+	// LogSampleInterval; the number dropped is logged with the next
+	// record as "suppressed". Zero logs everything.
+	LogSampleBurst int
+
+	// LogSampleInterval is the sampling window. Zero means
+	// DefaultLogSampleInterval.
+	LogSampleInterval time.Duration
 }
 
 // DefaultConfig returns a Config with sensible defaults.
@@ -89,15 +411,98 @@ func DefaultConfig() *Config {
 	}
 }
 
+// I2CPTLSConfig configures TLS for the I2CP router connection.
+type I2CPTLSConfig struct {
+	// CAFile verifies the router certificate; empty uses the system pool.
+	CAFile string
+
+	// CertFile and KeyFile hold a client certificate for routers that
+	// authenticate clients by certificate (optional).
+	CertFile string
+	KeyFile  string
+
+	// Insecure skips verification of the router certificate.
+	Insecure bool
+}
+
 // Validate checks that the configuration is valid.
 // Returns an error if any required fields are missing or invalid.
 func (c *Config) Validate() error {
 	if c.ListenAddr == "" && c.Listener == nil {
 		return ErrMissingListenAddr
 	}
-	if c.I2CPAddr == "" && c.I2CPProvider == nil {
+	if c.I2CPAddr == "" && c.I2CPProvider == nil && c.SharedI2CP == nil {
 		return ErrMissingI2CPAddr
 	}
+	if c.I2CPProvider != nil && c.SharedI2CP != nil {
+		return ErrConflictingI2CPProvider
+	}
+	if c.HandshakeTimeout < 0 || c.CommandTimeout < 0 || c.IdleTimeout < 0 || c.SessionIdleTimeout < 0 || c.WriteTimeout < 0 || c.AcceptBacklogTimeout < 0 ||
+		c.StreamReadTimeout < 0 || c.StreamWriteTimeout < 0 || c.SendExpiry < 0 || c.LogSampleInterval < 0 ||
+		c.ForwardRetry.Backoff < 0 || c.ForwardRetry.MaxBackoff < 0 {
+		return ErrInvalidTimeout
+	}
+	if c.ReadBufferSize < 0 || c.MaxLineLength < 0 || c.MaxSessions < 0 || c.MaxSessionsPerUser < 0 || c.StreamBufferSize < 0 ||
+		c.MaxConnections < 0 || c.MaxQueuedConnections < 0 || c.MaxConnectionsPerIP < 0 || c.DestinationPoolSize < 0 || c.AcceptBacklog < 0 ||
+		c.MaxOutboundStreams < 0 || c.MaxInboundStreams < 0 || c.ForwardRetry.Attempts < 0 ||
+		c.SendQueueSize < 0 || c.SendQueueSize > session.MaxSendQueueSize || c.SendRetries < 0 || c.LogSampleBurst < 0 ||
+		c.SocketOptions.ReadBuffer < 0 || c.SocketOptions.WriteBuffer < 0 {
+		return ErrInvalidLimit
+	}
+	if (c.TLSCertFile == "") != (c.TLSKeyFile == "") {
+		return ErrIncompleteTLSConfig
+	}
+	if err := c.TLSPolicy.validate(); err != nil {
+		return err
+	}
+	if c.I2CPTLS != nil && (c.I2CPTLS.CertFile == "") != (c.I2CPTLS.KeyFile == "") {
+		return ErrIncompleteTLSConfig
+	}
+	for _, e := range c.Endpoints {
+		if err := e.validate(); err != nil {
+			return err
+		}
+		if e.TLS && c.TLSConfig == nil && c.TLSCertFile == "" {
+			return ErrEndpointTLSUnavailable
+		}
+	}
+	if c.LogFormat != "" {
+		if _, err := NewLogFormatter(c.LogFormat); err != nil {
+			return err
+		}
+	}
+	if c.SessionDefaults != nil {
+		if err := c.SessionDefaults.validate(); err != nil {
+			return err
+		}
+	}
+	for user, password := range c.AuthUsers {
+		if err := bridge.CheckPasswordHash(password); err != nil {
+			return fmt.Errorf("embedding: auth user %q: %w", user, err)
+		}
+	}
+	if err := bridge.CheckPasswordHash(c.I2PControlPassword); err != nil {
+		return fmt.Errorf("embedding: I2PControl password: %w", err)
+	}
+	if c.PprofAddr != "" && !isLoopbackAddr(c.PprofAddr) {
+		return ErrPprofNotLoopback
+	}
+	if c.I2PControlAddr != "" && !isLoopbackAddr(c.I2PControlAddr) &&
+		(c.I2PControlPassword == "" || c.I2PControlPassword == DefaultI2PControlPassword) {
+		return ErrI2PControlDefaultPassword
+	}
+	if c.HTTPOutproxy != "" {
+		host, _, err := net.SplitHostPort(c.HTTPOutproxy)
+		if err != nil || !socks.IsI2PHost(host) {
+			return ErrInvalidOutproxy
+		}
+	}
+	if err := tunnels.Validate(c.Tunnels); err != nil {
+		return err
+	}
+	if c.KeyStore == nil && c.KeyStoreDir != "" && c.KeyStorePassphrase == "" {
+		return ErrMissingKeyStorePassphrase
+	}
 	return nil
 }
 
@@ -111,6 +516,38 @@ func (c *Config) toBridgeConfig() *bridge.Config {
 	cfg.I2CPAddr = c.I2CPAddr
 	cfg.DatagramPort = c.DatagramPort
 	cfg.TLSConfig = c.TLSConfig
+	cfg.AuditLog = c.AuditLog
+	cfg.AccessLog = c.AccessLog
+	cfg.ConnState = c.OnConnection
+	cfg.Trace = c.Trace
+	cfg.StrictQuoting = c.StrictQuoting
+	cfg.ReplaceInvalidUTF8 = c.ReplaceInvalidUTF8
+
+	// Zero timeouts keep the bridge defaults
+	if c.HandshakeTimeout > 0 {
+		cfg.Timeouts.Handshake = c.HandshakeTimeout
+	}
+	if c.CommandTimeout > 0 {
+		cfg.Timeouts.Command = c.CommandTimeout
+	}
+	cfg.Timeouts.Idle = c.IdleTimeout
+	cfg.Timeouts.SessionIdle = c.SessionIdleTimeout
+	cfg.Timeouts.Write = c.WriteTimeout
+
+	// Zero limits keep the bridge defaults
+	if c.ReadBufferSize > 0 {
+		cfg.Limits.ReadBufferSize = c.ReadBufferSize
+	}
+	cfg.Limits.StreamBufferSize = c.StreamBufferSize
+	cfg.Limits.MaxConnections = c.MaxConnections
+	cfg.Limits.MaxQueuedConnections = c.MaxQueuedConnections
+	cfg.Limits.MaxConnectionsPerIP = c.MaxConnectionsPerIP
+	cfg.Limits.Socket = c.SocketOptions
+	if c.MaxLineLength > 0 {
+		cfg.Limits.MaxLineLength = c.MaxLineLength
+	}
+	cfg.Limits.MaxSessions = c.MaxSessions
+	cfg.Limits.MaxSessionsPerUser = c.MaxSessionsPerUser
 
 	// Copy auth users if any
 	if len(c.AuthUsers) > 0 {
@@ -120,6 +557,10 @@ func (c *Config) toBridgeConfig() *bridge.Config {
 			cfg.Auth.Users[k] = v
 		}
 	}
+	if c.AuthFunc != nil {
+		cfg.Auth.Required = true
+		cfg.Auth.Func = c.AuthFunc
+	}
 
 	return cfg
 }
@@ -1,7 +1,12 @@
 package embedding
 
 import (
+	"errors"
 	"testing"
+	"time"
+
+	"github.com/go-i2p/go-sam-bridge/lib/bridge"
+	"github.com/go-i2p/go-sam-bridge/lib/tunnels"
 )
 
 func TestDefaultConfig(t *testing.T) {
@@ -64,6 +69,224 @@ func TestConfigValidate(t *testing.T) {
 			},
 			wantErr: nil,
 		},
+		{
+			name: "negative handshake timeout",
+			cfg: &Config{
+				ListenAddr:       DefaultListenAddr,
+				I2CPAddr:         DefaultI2CPAddr,
+				HandshakeTimeout: -time.Second,
+			},
+			wantErr: ErrInvalidTimeout,
+		},
+		{
+			name: "negative command timeout",
+			cfg: &Config{
+				ListenAddr:     DefaultListenAddr,
+				I2CPAddr:       DefaultI2CPAddr,
+				CommandTimeout: -time.Second,
+			},
+			wantErr: ErrInvalidTimeout,
+		},
+		{
+			name: "negative read buffer size",
+			cfg: &Config{
+				ListenAddr:     DefaultListenAddr,
+				I2CPAddr:       DefaultI2CPAddr,
+				ReadBufferSize: -1,
+			},
+			wantErr: ErrInvalidLimit,
+		},
+		{
+			name: "negative log sample burst",
+			cfg: &Config{
+				ListenAddr:     DefaultListenAddr,
+				I2CPAddr:       DefaultI2CPAddr,
+				LogSampleBurst: -1,
+			},
+			wantErr: ErrInvalidLimit,
+		},
+		{
+			name: "negative max line length",
+			cfg: &Config{
+				ListenAddr:    DefaultListenAddr,
+				I2CPAddr:      DefaultI2CPAddr,
+				MaxLineLength: -1,
+			},
+			wantErr: ErrInvalidLimit,
+		},
+		{
+			name: "negative max sessions per user",
+			cfg: &Config{
+				ListenAddr:         DefaultListenAddr,
+				I2CPAddr:           DefaultI2CPAddr,
+				MaxSessionsPerUser: -1,
+			},
+			wantErr: ErrInvalidLimit,
+		},
+		{
+			name: "TLS certificate without key",
+			cfg: &Config{
+				ListenAddr:  DefaultListenAddr,
+				I2CPAddr:    DefaultI2CPAddr,
+				TLSCertFile: "cert.pem",
+			},
+			wantErr: ErrIncompleteTLSConfig,
+		},
+		{
+			name: "I2CP TLS certificate without key",
+			cfg: &Config{
+				ListenAddr: DefaultListenAddr,
+				I2CPAddr:   DefaultI2CPAddr,
+				I2CPTLS:    &I2CPTLSConfig{CertFile: "client.pem"},
+			},
+			wantErr: ErrIncompleteTLSConfig,
+		},
+		{
+			name: "endpoint with unknown network",
+			cfg: &Config{
+				ListenAddr: DefaultListenAddr,
+				I2CPAddr:   DefaultI2CPAddr,
+				Endpoints:  []Endpoint{{Network: "udp", Address: ":7656"}},
+			},
+			wantErr: ErrInvalidEndpoint,
+		},
+		{
+			name: "endpoint without address",
+			cfg: &Config{
+				ListenAddr: DefaultListenAddr,
+				I2CPAddr:   DefaultI2CPAddr,
+				Endpoints:  []Endpoint{{Network: NetworkUnix}},
+			},
+			wantErr: ErrInvalidEndpoint,
+		},
+		{
+			name: "TLS endpoint without TLS configuration",
+			cfg: &Config{
+				ListenAddr: DefaultListenAddr,
+				I2CPAddr:   DefaultI2CPAddr,
+				Endpoints:  []Endpoint{{Network: NetworkTCP, Address: ":7666", TLS: true}},
+			},
+			wantErr: ErrEndpointTLSUnavailable,
+		},
+		{
+			name: "key store directory without passphrase",
+			cfg: &Config{
+				ListenAddr:  DefaultListenAddr,
+				I2CPAddr:    DefaultI2CPAddr,
+				KeyStoreDir: "/var/lib/sam-bridge/keys",
+			},
+			wantErr: ErrMissingKeyStorePassphrase,
+		},
+		{
+			name: "loopback pprof address",
+			cfg: &Config{
+				ListenAddr: DefaultListenAddr,
+				I2CPAddr:   DefaultI2CPAddr,
+				PprofAddr:  "localhost:6060",
+			},
+			wantErr: nil,
+		},
+		{
+			name: "pprof on all interfaces",
+			cfg: &Config{
+				ListenAddr: DefaultListenAddr,
+				I2CPAddr:   DefaultI2CPAddr,
+				PprofAddr:  ":6060",
+			},
+			wantErr: ErrPprofNotLoopback,
+		},
+		{
+			name: "I2PControl on all interfaces with the default password",
+			cfg: &Config{
+				ListenAddr:     DefaultListenAddr,
+				I2CPAddr:       DefaultI2CPAddr,
+				I2PControlAddr: ":7650",
+			},
+			wantErr: ErrI2PControlDefaultPassword,
+		},
+		{
+			name: "I2PControl on a public address with the default password spelled out",
+			cfg: &Config{
+				ListenAddr:         DefaultListenAddr,
+				I2CPAddr:           DefaultI2CPAddr,
+				I2PControlAddr:     "192.0.2.1:7650",
+				I2PControlPassword: DefaultI2PControlPassword,
+			},
+			wantErr: ErrI2PControlDefaultPassword,
+		},
+		{
+			name: "I2PControl on a public address with a password",
+			cfg: &Config{
+				ListenAddr:         DefaultListenAddr,
+				I2CPAddr:           DefaultI2CPAddr,
+				I2PControlAddr:     "192.0.2.1:7650",
+				I2PControlPassword: "secret",
+			},
+			wantErr: nil,
+		},
+		{
+			name: "loopback I2PControl with the default password",
+			cfg: &Config{
+				ListenAddr:     DefaultListenAddr,
+				I2CPAddr:       DefaultI2CPAddr,
+				I2PControlAddr: "127.0.0.1:7650",
+			},
+			wantErr: nil,
+		},
+		{
+			name: "pprof on a public address",
+			cfg: &Config{
+				ListenAddr: DefaultListenAddr,
+				I2CPAddr:   DefaultI2CPAddr,
+				PprofAddr:  "192.0.2.1:6060",
+			},
+			wantErr: ErrPprofNotLoopback,
+		},
+		{
+			name: "clearnet HTTP outproxy",
+			cfg: &Config{
+				ListenAddr:   DefaultListenAddr,
+				I2CPAddr:     DefaultI2CPAddr,
+				HTTPOutproxy: "proxy.example.com:3128",
+			},
+			wantErr: ErrInvalidOutproxy,
+		},
+		{
+			name: "HTTP outproxy without port",
+			cfg: &Config{
+				ListenAddr:   DefaultListenAddr,
+				I2CPAddr:     DefaultI2CPAddr,
+				HTTPOutproxy: "exit.example.i2p",
+			},
+			wantErr: ErrInvalidOutproxy,
+		},
+		{
+			name: "server tunnel without key file",
+			cfg: &Config{
+				ListenAddr: DefaultListenAddr,
+				I2CPAddr:   DefaultI2CPAddr,
+				Tunnels:    []tunnels.Tunnel{{Name: "web", Type: tunnels.TypeServer, Target: "127.0.0.1:8080"}},
+			},
+			wantErr: tunnels.ErrInvalidTunnel,
+		},
+		{
+			name: "malformed auth user hash",
+			cfg: &Config{
+				ListenAddr: DefaultListenAddr,
+				I2CPAddr:   DefaultI2CPAddr,
+				AuthUsers:  map[string]string{"alice": "$argon2id$v=19$bad"},
+			},
+			wantErr: bridge.ErrInvalidPasswordHash,
+		},
+		{
+			name: "malformed I2PControl password hash",
+			cfg: &Config{
+				ListenAddr:         DefaultListenAddr,
+				I2CPAddr:           DefaultI2CPAddr,
+				I2PControlPassword: "$2a$10$short",
+			},
+			wantErr: bridge.ErrInvalidPasswordHash,
+		},
 		{
 			name: "custom I2CP provider allows empty address",
 			cfg: &Config{
@@ -78,7 +301,7 @@ func TestConfigValidate(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			err := tt.cfg.Validate()
-			if err != tt.wantErr {
+			if !errors.Is(err, tt.wantErr) {
 				t.Errorf("Validate() error = %v, wantErr = %v", err, tt.wantErr)
 			}
 		})
@@ -118,3 +341,66 @@ func TestConfigToBridgeConfig(t *testing.T) {
 		t.Errorf("Auth.Users length = %d, want 2", len(bridgeCfg.Auth.Users))
 	}
 }
+
+func TestConfigToBridgeConfigAuthFunc(t *testing.T) {
+	cfg := &Config{
+		ListenAddr: DefaultListenAddr,
+		I2CPAddr:   DefaultI2CPAddr,
+		AuthFunc:   func(user, password string) bool { return user == "alice" },
+	}
+
+	bridgeCfg := cfg.toBridgeConfig()
+
+	if !bridgeCfg.Auth.Required {
+		t.Error("Auth.Required should be true when AuthFunc is set")
+	}
+	if !bridgeCfg.CheckPassword("alice", "any") || bridgeCfg.CheckPassword("bob", "any") {
+		t.Error("CheckPassword should delegate to AuthFunc")
+	}
+}
+
+func TestConfigToBridgeConfigTimeouts(t *testing.T) {
+	cfg := DefaultConfig()
+	bridgeCfg := cfg.toBridgeConfig()
+
+	if bridgeCfg.Timeouts.Handshake != bridge.DefaultHandshakeTimeout {
+		t.Errorf("Timeouts.Handshake = %v, want default %v", bridgeCfg.Timeouts.Handshake, bridge.DefaultHandshakeTimeout)
+	}
+	if bridgeCfg.Timeouts.Command != bridge.DefaultCommandTimeout {
+		t.Errorf("Timeouts.Command = %v, want default %v", bridgeCfg.Timeouts.Command, bridge.DefaultCommandTimeout)
+	}
+
+	cfg.HandshakeTimeout = 5 * time.Second
+	cfg.CommandTimeout = 10 * time.Second
+	bridgeCfg = cfg.toBridgeConfig()
+
+	if bridgeCfg.Timeouts.Handshake != 5*time.Second {
+		t.Errorf("Timeouts.Handshake = %v, want %v", bridgeCfg.Timeouts.Handshake, 5*time.Second)
+	}
+	if bridgeCfg.Timeouts.Command != 10*time.Second {
+		t.Errorf("Timeouts.Command = %v, want %v", bridgeCfg.Timeouts.Command, 10*time.Second)
+	}
+}
+
+func TestConfigToBridgeConfigLimits(t *testing.T) {
+	cfg := DefaultConfig()
+	bridgeCfg := cfg.toBridgeConfig()
+
+	if bridgeCfg.Limits.ReadBufferSize != bridge.DefaultReadBufferSize {
+		t.Errorf("Limits.ReadBufferSize = %d, want default %d", bridgeCfg.Limits.ReadBufferSize, bridge.DefaultReadBufferSize)
+	}
+	if bridgeCfg.Limits.MaxLineLength != bridge.DefaultMaxLineLength {
+		t.Errorf("Limits.MaxLineLength = %d, want default %d", bridgeCfg.Limits.MaxLineLength, bridge.DefaultMaxLineLength)
+	}
+
+	cfg.ReadBufferSize = 1024
+	cfg.MaxLineLength = 2048
+	bridgeCfg = cfg.toBridgeConfig()
+
+	if bridgeCfg.Limits.ReadBufferSize != 1024 {
+		t.Errorf("Limits.ReadBufferSize = %d, want %d", bridgeCfg.Limits.ReadBufferSize, 1024)
+	}
+	if bridgeCfg.Limits.MaxLineLength != 2048 {
+		t.Errorf("Limits.MaxLineLength = %d, want %d", bridgeCfg.Limits.MaxLineLength, 2048)
+	}
+}
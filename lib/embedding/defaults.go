@@ -0,0 +1,73 @@
+package embedding
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/go-i2p/go-sam-bridge/lib/session"
+)
+
+// SessionDefaults holds the tunnel parameters applied to every SESSION
+// CREATE that does not specify them. Start from DefaultSessionDefaults
+// and override the fields you need, since zero values are meaningful
+// (e.g. zero-hop tunnels).
+type SessionDefaults struct {
+	// InboundQuantity and OutboundQuantity are the number of tunnels
+	// (inbound.quantity, outbound.quantity).
+	InboundQuantity  int
+	OutboundQuantity int
+
+	// InboundLength and OutboundLength are the number of hops per tunnel
+	// (inbound.length, outbound.length).
+	InboundLength  int
+	OutboundLength int
+
+	// InboundBackupQuantity and OutboundBackupQuantity are the number of
+	// standby tunnels (inbound.backupQuantity, outbound.backupQuantity).
+	InboundBackupQuantity  int
+	OutboundBackupQuantity int
+
+	// CloseIdleTime closes the session after it has been idle this long
+	// (i2cp.closeIdleTime). The bridge enforces it itself rather than
+	// asking the router. Zero disables it.
+	CloseIdleTime time.Duration
+}
+
+// DefaultSessionDefaults returns the built-in session defaults.
+func DefaultSessionDefaults() SessionDefaults {
+	cfg := session.DefaultSessionConfig()
+	return SessionDefaults{
+		InboundQuantity:        cfg.InboundQuantity,
+		OutboundQuantity:       cfg.OutboundQuantity,
+		InboundLength:          cfg.InboundLength,
+		OutboundLength:         cfg.OutboundLength,
+		InboundBackupQuantity:  cfg.InboundBackupQuantity,
+		OutboundBackupQuantity: cfg.OutboundBackupQuantity,
+	}
+}
+
+// validate checks the defaults with session.SessionConfig.Validate, so
+// tunnel counts and lengths must be within the bounds a SESSION CREATE
+// accepts, and the idle time may not be negative.
+func (d *SessionDefaults) validate() error {
+	if d.CloseIdleTime < 0 {
+		return fmt.Errorf("%w: idle time may not be negative", ErrInvalidSessionDefaults)
+	}
+	if err := d.sessionConfig().Validate(); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidSessionDefaults, err)
+	}
+	return nil
+}
+
+// sessionConfig converts the defaults to a session configuration.
+func (d *SessionDefaults) sessionConfig() *session.SessionConfig {
+	cfg := session.DefaultSessionConfig()
+	cfg.InboundQuantity = d.InboundQuantity
+	cfg.OutboundQuantity = d.OutboundQuantity
+	cfg.InboundLength = d.InboundLength
+	cfg.OutboundLength = d.OutboundLength
+	cfg.InboundBackupQuantity = d.InboundBackupQuantity
+	cfg.OutboundBackupQuantity = d.OutboundBackupQuantity
+	cfg.CloseIdleTime = int(d.CloseIdleTime / time.Second)
+	return cfg
+}
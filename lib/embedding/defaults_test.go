@@ -0,0 +1,66 @@
+package embedding
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-i2p/go-sam-bridge/lib/session"
+)
+
+func TestDefaultSessionDefaults(t *testing.T) {
+	d := DefaultSessionDefaults()
+	if d.InboundQuantity != session.DefaultTunnelQuantity || d.OutboundLength != session.DefaultTunnelLength {
+		t.Errorf("DefaultSessionDefaults() = %+v, want built-in tunnel defaults", d)
+	}
+}
+
+func TestWithSessionDefaults(t *testing.T) {
+	d := DefaultSessionDefaults()
+	d.InboundQuantity = 5
+	d.InboundLength = 0
+	d.OutboundBackupQuantity = 2
+	d.CloseIdleTime = 30 * time.Minute
+
+	cfg := DefaultConfig()
+	WithSessionDefaults(d)(cfg)
+	if cfg.SessionDefaults == nil {
+		t.Fatal("SessionDefaults not set")
+	}
+
+	deps := newDependencies(cfg)
+	sc := deps.SessionDefaults
+	if sc == nil {
+		t.Fatal("Dependencies.SessionDefaults not set")
+	}
+	if sc.InboundQuantity != 5 || sc.InboundLength != 0 || sc.OutboundBackupQuantity != 2 {
+		t.Errorf("session config = %+v, want configured tunnel defaults", sc)
+	}
+	if sc.CloseIdleTime != 1800 {
+		t.Errorf("CloseIdleTime = %d, want 1800 seconds", sc.CloseIdleTime)
+	}
+}
+
+func TestConfigValidateSessionDefaults(t *testing.T) {
+	d := DefaultSessionDefaults()
+	d.OutboundLength = -1
+
+	cfg := DefaultConfig()
+	WithSessionDefaults(d)(cfg)
+	if err := cfg.Validate(); !errors.Is(err, ErrInvalidSessionDefaults) {
+		t.Errorf("Validate() error = %v, want ErrInvalidSessionDefaults", err)
+	}
+}
+
+func TestConfigValidateSessionDefaultsBounds(t *testing.T) {
+	d := DefaultSessionDefaults()
+	d.InboundQuantity = session.MaxTunnelQuantity + 1
+
+	cfg := DefaultConfig()
+	WithSessionDefaults(d)(cfg)
+	err := cfg.Validate()
+	if !errors.Is(err, ErrInvalidSessionDefaults) || !strings.Contains(err.Error(), "inbound.quantity") {
+		t.Errorf("Validate() error = %v, want ErrInvalidSessionDefaults naming inbound.quantity", err)
+	}
+}
@@ -1,8 +1,12 @@
 package embedding
 
 import (
+	"time"
+
 	"github.com/go-i2p/go-sam-bridge/lib/destination"
+	"github.com/go-i2p/go-sam-bridge/lib/handler"
 	"github.com/go-i2p/go-sam-bridge/lib/session"
+	"github.com/go-i2p/go-sam-bridge/lib/util"
 	"github.com/sirupsen/logrus"
 )
 
@@ -21,16 +25,87 @@ type Dependencies struct {
 
 	// Logger is the structured logger for all components.
 	Logger *logrus.Logger
+
+	// AuthFunc validates SAM credentials during HELLO if non-nil.
+	AuthFunc func(user, password string) bool
+
+	// SessionDefaults is the configuration SESSION CREATE starts from.
+	// Nil uses session.DefaultSessionConfig.
+	SessionDefaults *session.SessionConfig
+
+	// KeyStore stores the private keys of SESSION CREATE
+	// DESTINATION=file:$name. Nil disables file destinations.
+	KeyStore *destination.KeyStore
+
+	// SocketOptions are the TCP options for connections STREAM FORWARD
+	// makes to local targets.
+	SocketOptions util.SocketOptions
+
+	// ForwardRetry controls how STREAM FORWARD retries connecting to its
+	// local target. The zero value uses handler.DefaultForwardRetry.
+	ForwardRetry handler.ForwardRetry
+
+	// ForwardStats counts STREAM FORWARD connections to local targets
+	// across all forwarders created by NewStreamHandlers.
+	ForwardStats *handler.ForwardStats
+
+	// AcceptBacklog and AcceptBacklogTimeout configure the backlog of
+	// inbound streams held for STREAM ACCEPT.
+	AcceptBacklog        int
+	AcceptBacklogTimeout time.Duration
+
+	// ReportError delivers a background failure to Bridge.Errors.
+	// Set by New; custom handlers may use it to report their own failures.
+	ReportError func(source string, err error)
+
+	// logSampler limits hot-path log records, per WithLogSampling.
+	logSampler *logSampler
 }
 
 // newDependencies creates a Dependencies struct from the configuration.
 // It initializes any nil dependencies with their default implementations.
 func newDependencies(cfg *Config) *Dependencies {
 	deps := &Dependencies{
-		Registry:     cfg.Registry,
-		I2CPProvider: cfg.I2CPProvider,
-		DestManager:  destination.NewManager(),
-		Logger:       cfg.Logger,
+		Registry:      cfg.Registry,
+		I2CPProvider:  cfg.I2CPProvider,
+		DestManager:   destination.NewManager(),
+		Logger:        cfg.Logger,
+		AuthFunc:      cfg.AuthFunc,
+		SocketOptions: cfg.SocketOptions,
+
+		AcceptBacklog:        cfg.AcceptBacklog,
+		AcceptBacklogTimeout: cfg.AcceptBacklogTimeout,
+
+		ForwardRetry: cfg.ForwardRetry,
+		ForwardStats: &handler.ForwardStats{},
+
+		logSampler: newLogSampler(cfg.LogSampleBurst, cfg.LogSampleInterval),
+	}
+
+	if cfg.SessionDefaults != nil {
+		deps.SessionDefaults = cfg.SessionDefaults.sessionConfig()
+	}
+	if cfg.StreamReadTimeout > 0 || cfg.StreamWriteTimeout > 0 || cfg.MaxOutboundStreams > 0 || cfg.MaxInboundStreams > 0 ||
+		cfg.SendQueueSize > 0 {
+		if deps.SessionDefaults == nil {
+			deps.SessionDefaults = session.DefaultSessionConfig()
+		}
+		deps.SessionDefaults.StreamReadTimeout = cfg.StreamReadTimeout
+		deps.SessionDefaults.StreamWriteTimeout = cfg.StreamWriteTimeout
+		deps.SessionDefaults.MaxOutboundStreams = cfg.MaxOutboundStreams
+		deps.SessionDefaults.MaxInboundStreams = cfg.MaxInboundStreams
+		deps.SessionDefaults.SendQueueSize = cfg.SendQueueSize
+		deps.SessionDefaults.SendRetries = cfg.SendRetries
+		deps.SessionDefaults.SendExpiry = cfg.SendExpiry
+	}
+
+	deps.KeyStore = cfg.KeyStore
+	if deps.KeyStore == nil && cfg.KeyStoreDir != "" {
+		deps.KeyStore = destination.NewKeyStore(cfg.KeyStoreDir, destination.NewPassphraseWrapper(cfg.KeyStorePassphrase))
+	}
+
+	if cfg.SharedI2CP != nil {
+		deps.I2CPProvider = cfg.SharedI2CP.Provider()
 	}
 
 	// Create default registry if not provided
@@ -48,5 +123,10 @@ func newDependencies(cfg *Config) *Dependencies {
 		}
 	}
 
+	// Validate has already checked the format name
+	if formatter, err := NewLogFormatter(cfg.LogFormat); err == nil {
+		deps.Logger.SetFormatter(formatter)
+	}
+
 	return deps
 }
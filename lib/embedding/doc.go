@@ -45,15 +45,112 @@
 //   - WithI2CPAddr: Set I2CP router address (default "127.0.0.1:7654")
 //   - WithDatagramPort: Set UDP datagram port (default 7655)
 //   - WithListener: Provide custom net.Listener
+//   - WithEndpoints: Serve additional TCP, TLS, or Unix socket endpoints
+//   - WithProxyProtocol: Read PROXY protocol headers on the listen address
+//   - WithDatagramPacketConn: Provide pre-bound UDP socket for datagrams
 //   - WithRegistry: Provide custom session.Registry
+//   - WithSessionObserver: Observe sessions being registered and unregistered
 //   - WithI2CPProvider: Provide custom I2CP session provider
+//   - WithSharedI2CP: Share one I2CP provider between bridges
 //   - WithLogger: Provide custom logrus.Logger
+//   - WithLogFormat: Log as text or JSON
+//   - WithLogSampling: Limit hot-path log records per interval
 //   - WithTLS: Enable TLS with custom config
+//   - WithTLSFiles: Enable TLS from cert/key files (reloadable)
+//   - WithTLSPolicy: Set the minimum TLS version, cipher suites, and ALPN
 //   - WithAuth: Set SAM authentication users
+//   - WithAuthFunc: Validate SAM credentials with a callback
+//   - WithSessionDefaults: Set default tunnel parameters for SESSION CREATE
+//   - WithKeyStore: Store DESTINATION=file: keys in a custom key store
+//   - WithKeyStoreDir: Store DESTINATION=file: keys in a directory
+//   - WithKeyStorePassphrase: Set the passphrase for WithKeyStoreDir
+//   - WithAuditLog: Write command audit records to an io.Writer
+//   - WithAuditLogFile: Append command audit records to a file
+//   - WithAccessLog: Write connection access records to an io.Writer
+//   - WithAccessLogFile: Append connection access records to a file
+//   - WithAdminAddr: Serve the JSON admin API over HTTP
+//   - WithMetricsAddr: Serve Prometheus metrics over HTTP
+//   - WithPprofAddr: Serve net/http/pprof profiles on a loopback address
+//   - WithI2PControlAddr: Serve the I2PControl JSON-RPC API over HTTP
+//   - WithI2PControlPassword: Password for the I2PControl API
+//   - WithSOCKSAddr: Serve a SOCKS5 proxy to .i2p hosts
+//   - WithSOCKSAuth: SAM credentials for the SOCKS5 proxy
+//   - WithHTTPProxyAddr: Serve an HTTP proxy to .i2p hosts
+//   - WithHTTPProxyAuth: SAM credentials for the HTTP proxy
+//   - WithHTTPOutproxy: Send the HTTP proxy's clearnet requests through an outproxy
+//   - WithBOBAddr: Serve a BOB listener for legacy applications
+//   - WithBOBAuth: SAM credentials for the BOB listener
+//   - WithTunnels: Run i2ptunnel-style client and server tunnels
+//   - WithTunnelAuth: SAM credentials for the tunnels
 //   - WithI2CPCredentials: Set I2CP authentication
+//   - WithI2CPFailoverAddrs: Set I2CP routers to fail over to
+//   - WithI2CPTLS: Connect to the I2CP router over TLS
 //   - WithHandlerRegistrar: Custom handler registration
+//   - WithHandshakeTimeout: Set HELLO timeout (default 30s)
+//   - WithCommandTimeout: Set timeout between commands (default 60s)
+//   - WithIdleTimeout: Close connections that never create a session
+//   - WithReadBufferSize: Set command read buffer size (default 8192)
+//   - WithMaxLineLength: Set maximum command line length (default 65536)
+//   - WithStreamBufferSize: Set stream copy buffer size (default 32768)
+//   - WithSocketOptions: Set TCP keepalive, TCP_NODELAY and buffer sizes
+//   - WithForwardRetry: Retry STREAM FORWARD targets that are briefly down
+//   - WithDestinationPoolSize: Generate destinations ahead of time
+//   - WithAcceptBacklog: Hold inbound streams for the next STREAM ACCEPT
+//   - WithAcceptBacklogTimeout: Set how long backlogged streams wait
+//   - WithStreamReadTimeout: Close streams whose peer stays silent
+//   - WithStreamWriteTimeout: Close streams whose writes stall
+//   - WithMaxSessions: Limit the number of open sessions
+//   - WithMaxConnections: Limit and queue concurrent client connections
+//   - WithMaxConnectionsPerIP: Limit concurrent client connections per IP
+//   - WithMaxSessionsPerUser: Limit open sessions per authenticated user
+//   - WithMaxOutboundStreams: Limit concurrent STREAM CONNECTs per session
+//   - WithMaxInboundStreams: Limit concurrent inbound streams per session
+//   - WithSendQueue: Queue and retry datagram sends while tunnels rebuild
+//   - WithDrainTimeout: Drain existing connections on Stop
+//   - WithSessionIdleTimeout: Close sessions without traffic
+//   - WithWriteTimeout: Disconnect clients that stop reading
+//   - WithStrictQuoting: Enforce SAM 3.2 quoting rules for 3.2 clients
+//   - WithReplaceInvalidUTF8: Accept commands with invalid UTF-8
+//   - WithOnStart: Callback after the bridge starts serving
+//   - WithOnStop: Callback when the bridge stops serving
+//   - WithOnConnection: Callback when client connections open and close
+//   - WithTrace: Callback for every SAM line sent and received
+//   - WithTraceWriter: Write every SAM line sent and received to an io.Writer
+//   - WithPanicHandler: Callback for panics recovered on client connections
 //   - WithDebug: Enable debug logging
 //
+// # Configuration Files
+//
+// ConfigFromFile loads listen addresses, I2CP settings, auth users,
+// timeouts, limits, and TLS paths from a YAML, TOML, or JSON file:
+//
+//	opts, err := embedding.ConfigFromFile("sam-bridge.yaml")
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	bridge, err := embedding.New(opts...)
+//
+// ConfigFromEnv reads the same settings from environment variables
+// (SAM_LISTEN, I2CP_ADDR, SAM_AUTH_USERS, SAM_TLS_CERT, ...) for
+// containerized deployments.
+//
+// TLS certificate paths from either source are applied with WithTLSFiles,
+// so ReloadTLS can rotate the certificate without restarting the bridge.
+// The tls section's min_version, cipher_suites and alpn keys (or
+// SAM_TLS_MIN_VERSION, SAM_TLS_CIPHER_SUITES and SAM_TLS_ALPN) are
+// applied with WithTLSPolicy.
+//
+// Reload applies a reread configuration to a running bridge. Auth users,
+// the debug log level, log sampling, and TLS certificates take effect
+// immediately; other changed settings are reported as requiring a
+// restart:
+//
+//	opts, _ := embedding.ConfigFromFile("sam-bridge.yaml")
+//	result, err := bridge.Reload(opts...)
+//	if err == nil && len(result.RestartRequired) > 0 {
+//	    log.Printf("restart to apply %v", result.RestartRequired)
+//	}
+//
 // # Custom Handlers
 //
 // Register custom handlers alongside or instead of default handlers:
@@ -70,6 +167,19 @@
 //	    embedding.WithHandlerRegistrar(customRegistrar),
 //	)
 //
+// # Session Log Fields
+//
+// Log records about a session, such as tunnel events, STREAM FORWARD
+// failures and recovered panics, carry the fields sessionID, style, user
+// (the authenticated SAM user that created it), remote (its control
+// connection's address) and label. SessionFields returns them for custom
+// handlers. Failures that repeat for every stream can be kept cheap with
+// WithLogSampling:
+//
+//	bridge, err := embedding.New(
+//	    embedding.WithLogSampling(10, time.Second),
+//	)
+//
 // # Lifecycle Management
 //
 // The Bridge implements the Lifecycle interface:
@@ -77,10 +187,336 @@
 //   - Start(ctx): Begin serving (non-blocking)
 //   - Stop(ctx): Graceful shutdown
 //   - Wait(): Block until stopped
+//   - WaitContext(ctx): Block until stopped or ctx is done
 //   - Running(): Check if bridge is active
 //
 // Context cancellation in Start() triggers automatic shutdown.
 //
+// Drain(ctx) stops accepting new connections and lets existing streams
+// finish until ctx is done, then force-closes the rest. Configuring
+// WithDrainTimeout makes Stop drain with that timeout.
+//
+// WithOnStart, WithOnStop, and WithOnConnection register callbacks for
+// integrating with a service manager or connection accounting:
+//
+//	bridge, _ := embedding.New(
+//	    embedding.WithOnStart(func() { notifyReady() }),
+//	    embedding.WithOnStop(func(err error) { notifyStopping(err) }),
+//	    embedding.WithOnConnection(func(conn net.Conn, state bridge.ConnectionState) {
+//	        log.Printf("%s %s", conn.RemoteAddr(), state)
+//	    }),
+//	)
+//
+// WithSessionObserver subscribes to the session registry, so components
+// such as metrics exporters learn about new and closed sessions without
+// polling:
+//
+//	bridge, _ := embedding.New(
+//	    embedding.WithSessionObserver(session.ObserverFuncs{
+//	        Registered:   func(s session.Session) { log.Printf("+%s", s.ID()) },
+//	        Unregistered: func(s session.Session) { log.Printf("-%s", s.ID()) },
+//	    }),
+//	)
+//
+// # Background Errors
+//
+// Errors() returns a channel of *BackgroundError values for failures that
+// happen outside any call: accept loop errors, UDP listener failures,
+// I2CP disconnects, and STREAM FORWARD connection failures:
+//
+//	go func() {
+//	    for err := range bridge.Errors() {
+//	        var bgErr *embedding.BackgroundError
+//	        if errors.As(err, &bgErr) && bgErr.Source == embedding.SourceI2CP {
+//	            // reconnect, alert, ...
+//	        }
+//	    }
+//	}()
+//
+// I2CP disconnects are reported when the I2CPProvider implements
+// ErrorNotifier.
+//
+// # Multiple Endpoints
+//
+// WithEndpoints serves the same bridge on further control sockets. TLS
+// applies to the listen address whenever it is configured, while each
+// endpoint opts in separately, so a TLS port for remote clients can sit
+// beside plain loopback and Unix sockets for local ones:
+//
+//	bridge, _ := embedding.New(
+//	    embedding.WithListenAddr(":7667"),
+//	    embedding.WithTLSFiles("cert.pem", "key.pem"),
+//	    embedding.WithEndpoints(
+//	        embedding.Endpoint{Network: embedding.NetworkTCP, Address: "127.0.0.1:7656"},
+//	        embedding.Endpoint{Network: embedding.NetworkUnix, Address: "/run/sam/sam.sock"},
+//	    ),
+//	)
+//
+// Addrs() reports the bound addresses, listen address first.
+//
+// Behind a TCP load balancer, set ProxyProtocol on an endpoint, or use
+// WithProxyProtocol for the listen address, to take each client's
+// address from the PROXY protocol header the balancer sends.
+//
+// # Sharing an I2CP Connection
+//
+// Bridges in the same process can share one router connection through
+// SharedI2CP. The provider is closed when the last bridge stops:
+//
+//	client := i2cp.NewClient(nil)
+//	if err := client.Connect(ctx); err != nil {
+//	    log.Fatal(err)
+//	}
+//	shared := embedding.NewSharedI2CP(i2cp.NewSessionProviderAdapter(client))
+//	a, _ := embedding.New(embedding.WithSharedI2CP(shared), embedding.WithListenAddr(":7656"))
+//	b, _ := embedding.New(embedding.WithSharedI2CP(shared), embedding.WithListenAddr(":7666"))
+//	shared.Close() // drop the creator's reference
+//
+// # Router Information
+//
+// RouterInfo() reports the connected router's version and capabilities
+// when the I2CPProvider implements session.RouterInfoProvider, as
+// i2cp.SessionProviderAdapter does:
+//
+//	if info, ok := bridge.RouterInfo(); ok && !info.MultiSession {
+//	    // avoid PRIMARY sessions
+//	}
+//
+// # Health
+//
+// Health() returns nil while the bridge is serving and the I2CP provider
+// is connected, and suits liveness probes and service watchdogs:
+//
+//	if err := bridge.Health(); err != nil {
+//	    log.Printf("unhealthy: %v", err)
+//	}
+//
+// With i2cp.SessionProviderAdapter, the provider also times the router's
+// answers to I2CP requests. I2CPHealth() reports the round-trip latency,
+// message errors and disconnect count, and Health() returns
+// ErrI2CPUnresponsive once a request has gone unanswered for the client's
+// UnresponsiveTimeout. Such stalls are also reported to Errors().
+//
+// # Admin API
+//
+// WithAdminAddr serves read-only JSON at /health, /status, /sessions and
+// /sessions/{id}, covering bridge status, the session list, per-session
+// statistics, and I2CP health. AdminHandler returns the same handler for
+// applications that mount it on their own HTTP server. The API has no
+// authentication, so bind it to a loopback address.
+//
+// # Metrics
+//
+// WithMetricsAddr serves Prometheus text-format metrics: whether the
+// bridge is up and healthy, the I2CP connection state, round-trip latency,
+// errors and disconnects, uptime, open and total connections, commands
+// processed and the time each command took, sessions by style,
+// per-session traffic counters labelled by session ID and, if set,
+// session label, and the size, hits, misses and evictions of the parsed
+// destination cache. MetricsHandler returns the same handler for mounting
+// elsewhere.
+//
+// # Profiling
+//
+// WithPprofAddr serves the net/http/pprof handlers under /debug/pprof/,
+// so operators can capture CPU and heap profiles of a running bridge:
+//
+//	go tool pprof http://127.0.0.1:6060/debug/pprof/heap
+//
+// The handlers are unauthenticated and profiles reveal memory contents,
+// so Validate rejects addresses that are not loopback.
+//
+// # I2PControl API
+//
+// WithI2PControlAddr serves a subset of the I2PControl JSON-RPC API, so
+// monitoring tools written for I2P routers can watch the bridge.
+// Clients call Authenticate with API version 1 and the password (see
+// WithI2PControlPassword; DefaultI2PControlPassword otherwise), then pass
+// the returned token to Echo, RouterInfo and GetRate. RouterInfo reports
+// the bridge's status, uptime, router version and I2CP connectivity;
+// GetRate reports SAM statistics such as sam.sessions, sam.connections
+// and sam.bytes.sent. The API is served over plain HTTP, so bind it to a
+// loopback address; Validate rejects other addresses unless a password
+// other than the default is set. Each handler holds at most 256 tokens,
+// evicting the oldest beyond that. I2PControlHandler returns the handler
+// for mounting elsewhere.
+//
+// # SOCKS5 Proxy
+//
+// WithSOCKSAddr serves a SOCKS5 proxy for applications that cannot speak
+// SAM. It supports CONNECT to .i2p and .b32.i2p hostnames only, without
+// authentication, so bind it to a loopback address. The proxy is itself
+// a client of the bridge: it creates one STREAM session over an
+// in-process SAM connection and issues a STREAM CONNECT per proxied
+// connection, so authentication (see WithSOCKSAuth), session and
+// connection limits, and the audit and access logs apply to it as to any
+// other client. SOCKSAddr reports the listening address.
+//
+// # HTTP Proxy
+//
+// WithHTTPProxyAddr serves an HTTP proxy for browsers and other HTTP
+// clients. It tunnels CONNECT requests and forwards requests with an
+// absolute URI, such as "GET http://example.i2p/", through its own
+// STREAM session in the same way as the SOCKS5 proxy (see
+// WithHTTPProxyAuth). Hop-by-hop headers such as Proxy-Authorization are
+// removed from forwarded requests. Requests for clearnet hosts are
+// rejected with 403 Forbidden unless WithHTTPOutproxy names an HTTP
+// outproxy on I2P to send them through. HTTPProxyAddr reports the
+// listening address.
+//
+// # BOB
+//
+// WithBOBAddr serves a BOB listener (see package bob) for legacy
+// applications that set up tunnels with BOB rather than SAM. Like the
+// proxies it is a client of the bridge over in-process SAM connections
+// (see WithBOBAuth): starting a BOB tunnel creates a STREAM session with
+// the tunnel's keys and I2CP options, issues STREAM FORWARD to its
+// outhost and outport, and opens a STREAM CONNECT for each connection to
+// its inport. BOB hands out private keys without authentication, so bind
+// it to a loopback address. BOBAddr reports the listening address.
+//
+// # Tunnels
+//
+// WithTunnels runs i2ptunnel-style tunnels (see package tunnels) without
+// an external client. A client tunnel listens locally and connects each
+// accepted connection to a fixed destination; a server tunnel publishes
+// the destination in its key file, created on first start, and forwards
+// incoming streams to a local target:
+//
+//	bridge, err := embedding.New(
+//	    embedding.WithTunnels(
+//	        tunnels.Tunnel{Name: "irc", Type: tunnels.TypeClient,
+//	            Listen: "127.0.0.1:6668", Destination: "irc.postman.i2p:6667"},
+//	        tunnels.Tunnel{Name: "web", Type: tunnels.TypeServer,
+//	            Keyfile: "web.dat", Target: "127.0.0.1:8080"},
+//	    ),
+//	)
+//
+// Each tunnel has its own STREAM session, created over an in-process SAM
+// connection like the proxies' (see WithTunnelAuth), with STREAM FORWARD
+// wired up for server tunnels. A tunnel whose session fails to start or
+// is lost is restarted after a delay that doubles up to a minute.
+// TunnelStatus reports each tunnel's state. Tunnels can also be declared
+// in a configuration file; see FileTunnelsConfig.
+//
+// # Bridge Statistics
+//
+// Stats reports how long the bridge has been serving, how many SAM
+// control connections it has served and has open, and how many commands
+// it has processed:
+//
+//	stats := bridge.Stats()
+//	fmt.Println(stats.Uptime, stats.ConnectionsTotal, stats.Commands)
+//
+// The admin /status document and the metrics endpoint report the same
+// counters.
+//
+// CommandLatencies returns a histogram per command of the time from
+// reading its line to writing its response, which the metrics endpoint
+// exports as sam_bridge_command_duration_seconds. Commands are named by
+// the handler key they matched, such as "NAMING LOOKUP", so slow
+// handlers stand out; SESSION CREATE includes the wait for tunnels.
+//
+// # Session Statistics
+//
+// Each session counts bytes sent and received, streams opened, and
+// datagrams sent and received. Counters reset when the session closes:
+//
+//	if stats, ok := bridge.SessionStats("my-session"); ok {
+//	    fmt.Println(stats.BytesSent, stats.BytesReceived)
+//	}
+//
+// SAM clients can read the same counters with the SESSION STATS extension
+// command.
+//
+// Sessions also record when they were created, when their tunnels became
+// ready and when they started closing. The admin API reports each
+// session's uptime and time to ready, metrics export both as gauges, and
+// SESSION STATS returns them as UPTIME (seconds) and TIME_TO_READY
+// (milliseconds).
+//
+// Tunnel builds, expiries and failures reported by the I2CP session are
+// logged per session and counted; SESSION STATS returns the latest as
+// TUNNELS=BUILT|EXPIRED|FAILED with TUNNEL_BUILDS, TUNNEL_EXPIRATIONS and
+// TUNNEL_FAILURES.
+//
+// # Session Labels
+//
+// A SAM client may name its session with the LABEL option of SESSION
+// CREATE; inbound.nickname is used when LABEL is absent. The label appears
+// in the admin session view, in metrics, and in the SESSION LIST extension
+// command, which lists every registered session with its style and label.
+//
+// # Session Ownership
+//
+// While authentication is enabled, each session belongs to the user who
+// created it. Other users' connections cannot see it: STREAM, DATAGRAM and
+// RAW commands naming its ID fail as if it did not exist, and it is left
+// out of SESSION LIST. Sessions created without authentication belong to
+// no one and remain visible to everyone.
+//
+// # Password Hashes
+//
+// A password given to WithAuth, WithI2PControlPassword, SAM_AUTH_USERS or
+// a config file may be a bcrypt hash ("$2a$...") or an Argon2id hash
+// ("$argon2id$...") instead of the plaintext, so configuration files need
+// not hold secrets. bridge.HashPassword, or "sam-bridge passwd" reading
+// the password from stdin, produces Argon2id hashes. Validate rejects
+// hashes that cannot be decoded. Passwords, hashed or not, are compared in
+// constant time, and unknown users are checked against another user's
+// stored password, so they take as long to reject as known ones. At most
+// four Argon2id hashes are computed at once, bounding the memory logins
+// can use.
+//
+// # Diagnostics
+//
+// The DUMP extension command returns a diagnostic snapshot for debugging
+// deployments where a debugger cannot be attached: goroutine counts grouped
+// by stack, the client's sessions, their STREAM FORWARD targets and the
+// forwarding counters, and the I2CP connection's state and health. Each
+// item is a DUMP LINE TEXT="..." line after a DUMP REPLY RESULT=OK COUNT=$n
+// line. Only clients that sent USER and PASSWORD may use it, so DUMP is
+// refused while authentication is disabled.
+//
+// # Idle Sessions
+//
+// WithSessionIdleTimeout closes sessions that have sent and received
+// nothing for the given period. The bound control socket first receives
+// SESSION STATUS RESULT=I2P_ERROR with the session ID, then is closed.
+// A session created with i2cp.closeIdleTime uses that period instead,
+// even when no bridge-wide timeout is set.
+//
+// # Vanity Destinations
+//
+// DEST GENERATE accepts a PATTERN extension option. The bridge generates
+// keys on every CPU until the destination's .b32.i2p address starts with
+// the pattern, for example DEST GENERATE PATTERN=sam finds an address
+// beginning "sam". Each character multiplies the search time by 32, so
+// patterns are limited to six characters and the search gives up with
+// I2P_ERROR after two minutes. The reply carries the address found as a
+// B32 field after PUB and PRIV.
+//
+// # Stored Destinations
+//
+// SESSION CREATE DESTINATION=file:$name uses the private key stored as
+// $name in the key store, generating and storing one on first use, so a
+// client keeps its address across restarts without holding the key
+// itself. Keys are encrypted at rest with AES-256-GCM under a passphrase
+// (WithKeyStoreDir, WithKeyStorePassphrase) or a KMS
+// (WithKeyStore with a destination.KMSWrapper). Names may contain
+// letters, digits, '.', '_' and '-'.
+//
+// # Encrypted Lease Sets
+//
+// SESSION CREATE with i2cp.leaseSetType=5 publishes an encrypted LeaseSet2
+// that is only reachable through the destination's .b33 address. Access
+// can be limited further with i2cp.leaseSetSecret and with per-client keys
+// given as i2cp.leaseSetClient.dh.N or i2cp.leaseSetClient.psk.N, each
+// valued name:base64key. The router must support encrypted lease sets.
+//
+// NAMING LOOKUP resolves .b33 addresses. When the address requires them,
+// clients pass SECRET, and AUTH_KEY with AUTH_TYPE=DH or PSK (default DH).
+//
 // # Thread Safety
 //
 // Bridge methods are safe for concurrent use. The bridge uses atomic operations
@@ -0,0 +1,267 @@
+package embedding
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Environment variables read by ConfigFromEnv.
+const (
+	EnvListen             = "SAM_LISTEN"
+	EnvDatagramPort       = "SAM_DATAGRAM_PORT"
+	EnvDebug              = "SAM_DEBUG"
+	EnvLogFormat          = "SAM_LOG_FORMAT"
+	EnvLogSampleBurst     = "SAM_LOG_SAMPLE_BURST"
+	EnvLogSampleInterval  = "SAM_LOG_SAMPLE_INTERVAL"
+	EnvI2CPAddr           = "I2CP_ADDR"
+	EnvI2CPUser           = "I2CP_USER"
+	EnvI2CPPassword       = "I2CP_PASSWORD"
+	EnvI2CPFailoverAddrs  = "I2CP_FAILOVER_ADDRS"
+	EnvI2CPTLS            = "I2CP_TLS"
+	EnvI2CPTLSCA          = "I2CP_TLS_CA"
+	EnvI2CPTLSCert        = "I2CP_TLS_CERT"
+	EnvI2CPTLSKey         = "I2CP_TLS_KEY"
+	EnvI2CPTLSInsecure    = "I2CP_TLS_INSECURE"
+	EnvAuthUsers          = "SAM_AUTH_USERS"
+	EnvHandshakeTimeout   = "SAM_HANDSHAKE_TIMEOUT"
+	EnvCommandTimeout     = "SAM_COMMAND_TIMEOUT"
+	EnvDrainTimeout       = "SAM_DRAIN_TIMEOUT"
+	EnvIdleTimeout        = "SAM_IDLE_TIMEOUT"
+	EnvSessionIdleTimeout = "SAM_SESSION_IDLE_TIMEOUT"
+	EnvStreamReadTimeout  = "SAM_STREAM_READ_TIMEOUT"
+	EnvStreamWriteTimeout = "SAM_STREAM_WRITE_TIMEOUT"
+	EnvReadBufferSize     = "SAM_READ_BUFFER_SIZE"
+	EnvMaxLineLength      = "SAM_MAX_LINE_LENGTH"
+	EnvMaxSessions        = "SAM_MAX_SESSIONS"
+	EnvMaxSessionsPerUser = "SAM_MAX_SESSIONS_PER_USER"
+	EnvMaxOutboundStreams = "SAM_MAX_OUTBOUND_STREAMS"
+	EnvMaxInboundStreams  = "SAM_MAX_INBOUND_STREAMS"
+	EnvSendQueue          = "SAM_SEND_QUEUE"
+	EnvSendRetries        = "SAM_SEND_RETRIES"
+	EnvSendExpiry         = "SAM_SEND_EXPIRY"
+	EnvStreamBufferSize   = "SAM_STREAM_BUFFER_SIZE"
+	EnvTLSCert            = "SAM_TLS_CERT"
+	EnvTLSKey             = "SAM_TLS_KEY"
+	EnvTLSMinVersion      = "SAM_TLS_MIN_VERSION"
+	EnvTLSCipherSuites    = "SAM_TLS_CIPHER_SUITES"
+	EnvTLSALPN            = "SAM_TLS_ALPN"
+	EnvAuditLog           = "SAM_AUDIT_LOG"
+	EnvAccessLog          = "SAM_ACCESS_LOG"
+	EnvAdminAddr          = "SAM_ADMIN_ADDR"
+	EnvMetricsAddr        = "SAM_METRICS_ADDR"
+	EnvPprofAddr          = "SAM_PPROF_ADDR"
+	EnvI2PControlAddr     = "SAM_I2PCONTROL_ADDR"
+	EnvI2PControlPassword = "SAM_I2PCONTROL_PASSWORD"
+	EnvSOCKSAddr          = "SAM_SOCKS_ADDR"
+	EnvSOCKSUser          = "SAM_SOCKS_USER"
+	EnvSOCKSPassword      = "SAM_SOCKS_PASSWORD"
+	EnvHTTPProxyAddr      = "SAM_HTTP_PROXY_ADDR"
+	EnvHTTPProxyUser      = "SAM_HTTP_PROXY_USER"
+	EnvHTTPProxyPassword  = "SAM_HTTP_PROXY_PASSWORD"
+	EnvHTTPOutproxy       = "SAM_HTTP_OUTPROXY"
+	EnvBOBAddr            = "SAM_BOB_ADDR"
+	EnvBOBUser            = "SAM_BOB_USER"
+	EnvBOBPassword        = "SAM_BOB_PASSWORD"
+	EnvKeyStoreDir        = "SAM_KEYSTORE_DIR"
+	EnvKeyStorePassphrase = "SAM_KEYSTORE_PASSPHRASE"
+)
+
+// ConfigFromEnv reads bridge settings from environment variables and
+// returns the corresponding Options. Unset variables are skipped so
+// defaults and other options still apply. This lets containerized
+// deployments configure the bridge without flags or files.
+//
+// SAM_AUTH_USERS is a comma-separated list of user:password pairs, where
+// a password may be a bcrypt or Argon2id hash, and
+// I2CP_FAILOVER_ADDRS a comma-separated list of router addresses.
+// SAM_TLS_CIPHER_SUITES and SAM_TLS_ALPN are comma-separated too.
+// I2CP_TLS_CA, I2CP_TLS_CERT, I2CP_TLS_KEY and I2CP_TLS_INSECURE only
+// apply when I2CP_TLS is true.
+// Timeouts use time.ParseDuration syntax (e.g. "30s"). SAM_DEBUG
+// enables debug logging unless it parses as a false boolean.
+func ConfigFromEnv() ([]Option, error) {
+	fc, err := fileConfigFromEnv(os.Getenv)
+	if err != nil {
+		return nil, err
+	}
+	return fc.Options()
+}
+
+// fileConfigFromEnv maps environment variables onto a FileConfig so the
+// file and environment loaders share one conversion to Options.
+func fileConfigFromEnv(getenv func(string) string) (*FileConfig, error) {
+	fc := &FileConfig{
+		Listen:    getenv(EnvListen),
+		LogFormat: getenv(EnvLogFormat),
+		LogSampling: FileLogSamplingConfig{
+			Interval: getenv(EnvLogSampleInterval),
+		},
+		I2CP: FileI2CPConfig{
+			Addr:     getenv(EnvI2CPAddr),
+			Username: getenv(EnvI2CPUser),
+			Password: getenv(EnvI2CPPassword),
+			TLS: FileI2CPTLSConfig{
+				CA:   getenv(EnvI2CPTLSCA),
+				Cert: getenv(EnvI2CPTLSCert),
+				Key:  getenv(EnvI2CPTLSKey),
+			},
+		},
+		Timeouts: FileTimeoutConfig{
+			Handshake:   getenv(EnvHandshakeTimeout),
+			Command:     getenv(EnvCommandTimeout),
+			Drain:       getenv(EnvDrainTimeout),
+			Idle:        getenv(EnvIdleTimeout),
+			SessionIdle: getenv(EnvSessionIdleTimeout),
+			StreamRead:  getenv(EnvStreamReadTimeout),
+			StreamWrite: getenv(EnvStreamWriteTimeout),
+			SendExpiry:  getenv(EnvSendExpiry),
+		},
+		TLS: FileTLSConfig{
+			Cert:         getenv(EnvTLSCert),
+			Key:          getenv(EnvTLSKey),
+			MinVersion:   getenv(EnvTLSMinVersion),
+			CipherSuites: splitList(getenv(EnvTLSCipherSuites)),
+			ALPN:         splitList(getenv(EnvTLSALPN)),
+		},
+		KeyStore: FileKeyStoreConfig{
+			Dir:        getenv(EnvKeyStoreDir),
+			Passphrase: getenv(EnvKeyStorePassphrase),
+		},
+		AuditLog:    getenv(EnvAuditLog),
+		AccessLog:   getenv(EnvAccessLog),
+		AdminAddr:   getenv(EnvAdminAddr),
+		MetricsAddr: getenv(EnvMetricsAddr),
+		PprofAddr:   getenv(EnvPprofAddr),
+		I2PControl: FileI2PControlConfig{
+			Addr:     getenv(EnvI2PControlAddr),
+			Password: getenv(EnvI2PControlPassword),
+		},
+		SOCKS: FileSOCKSConfig{
+			Addr:     getenv(EnvSOCKSAddr),
+			User:     getenv(EnvSOCKSUser),
+			Password: getenv(EnvSOCKSPassword),
+		},
+		HTTPProxy: FileHTTPProxyConfig{
+			Addr:     getenv(EnvHTTPProxyAddr),
+			User:     getenv(EnvHTTPProxyUser),
+			Password: getenv(EnvHTTPProxyPassword),
+			Outproxy: getenv(EnvHTTPOutproxy),
+		},
+		BOB: FileBOBConfig{
+			Addr:     getenv(EnvBOBAddr),
+			User:     getenv(EnvBOBUser),
+			Password: getenv(EnvBOBPassword),
+		},
+	}
+
+	if v := getenv(EnvDebug); v != "" {
+		enabled, err := strconv.ParseBool(v)
+		enabled = err != nil || enabled
+		fc.Debug = &enabled
+	}
+
+	if v := getenv(EnvDatagramPort); v != "" {
+		port, err := strconv.Atoi(v)
+		if err != nil {
+			return nil, fmt.Errorf("embedding: invalid %s: %w", EnvDatagramPort, err)
+		}
+		fc.DatagramPort = &port
+	}
+
+	bools := []struct {
+		name string
+		dst  *bool
+	}{
+		{EnvI2CPTLS, &fc.I2CP.TLS.Enabled},
+		{EnvI2CPTLSInsecure, &fc.I2CP.TLS.Insecure},
+	}
+	for _, b := range bools {
+		v := getenv(b.name)
+		if v == "" {
+			continue
+		}
+		enabled, err := strconv.ParseBool(v)
+		if err != nil {
+			return nil, fmt.Errorf("embedding: invalid %s: %w", b.name, err)
+		}
+		*b.dst = enabled
+	}
+
+	ints := []struct {
+		name string
+		dst  *int
+	}{
+		{EnvLogSampleBurst, &fc.LogSampling.Burst},
+		{EnvReadBufferSize, &fc.Limits.ReadBufferSize},
+		{EnvMaxLineLength, &fc.Limits.MaxLineLength},
+		{EnvMaxSessions, &fc.Limits.MaxSessions},
+		{EnvMaxSessionsPerUser, &fc.Limits.MaxSessionsPerUser},
+		{EnvMaxOutboundStreams, &fc.Limits.MaxOutboundStreams},
+		{EnvMaxInboundStreams, &fc.Limits.MaxInboundStreams},
+		{EnvSendQueue, &fc.Limits.SendQueue},
+		{EnvSendRetries, &fc.Limits.SendRetries},
+		{EnvStreamBufferSize, &fc.Limits.StreamBufferSize},
+	}
+	for _, i := range ints {
+		v := getenv(i.name)
+		if v == "" {
+			continue
+		}
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return nil, fmt.Errorf("embedding: invalid %s: %w", i.name, err)
+		}
+		*i.dst = n
+	}
+
+	fc.I2CP.FailoverAddrs = splitList(getenv(EnvI2CPFailoverAddrs))
+
+	if v := getenv(EnvAuthUsers); v != "" {
+		users, err := parseAuthUsers(v)
+		if err != nil {
+			return nil, err
+		}
+		fc.Auth.Users = users
+	}
+
+	return fc, nil
+}
+
+// parseAuthUsers parses "user1:pass1,user2:pass2" into a map. The commas
+// inside an Argon2id hash's parameters ("m=19456,t=2,p=1") do not split
+// entries.
+func parseAuthUsers(s string) (map[string]string, error) {
+	users := make(map[string]string)
+	var last string
+	for _, entry := range strings.Split(s, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		if !strings.Contains(entry, ":") && last != "" && strings.HasPrefix(users[last], "$argon2id$") {
+			users[last] += "," + entry
+			continue
+		}
+		user, pass, ok := strings.Cut(entry, ":")
+		if !ok || user == "" {
+			return nil, fmt.Errorf("embedding: invalid %s entry %q: want user:password", EnvAuthUsers, entry)
+		}
+		users[user] = pass
+		last = user
+	}
+	return users, nil
+}
+
+// splitList splits a comma-separated list, dropping empty entries.
+// It returns nil for an empty string.
+func splitList(s string) []string {
+	var list []string
+	for _, v := range strings.Split(s, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			list = append(list, v)
+		}
+	}
+	return list
+}
@@ -0,0 +1,185 @@
+package embedding
+
+import (
+	"maps"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestConfigFromEnv(t *testing.T) {
+	t.Setenv(EnvListen, "127.0.0.1:9656")
+	t.Setenv(EnvDatagramPort, "9655")
+	t.Setenv(EnvDebug, "1")
+	t.Setenv(EnvI2CPAddr, "10.0.0.1:7654")
+	t.Setenv(EnvI2CPUser, "i2cpuser")
+	t.Setenv(EnvI2CPPassword, "i2cppass")
+	t.Setenv(EnvI2CPFailoverAddrs, "10.0.0.2:7654, 10.0.0.3:7654")
+	t.Setenv(EnvI2CPTLS, "true")
+	t.Setenv(EnvI2CPTLSCA, "/etc/i2p/router-ca.pem")
+	t.Setenv(EnvI2CPTLSInsecure, "false")
+	t.Setenv(EnvAuthUsers, "alice:secret, bob:hunter2")
+	t.Setenv(EnvHandshakeTimeout, "5s")
+	t.Setenv(EnvCommandTimeout, "2m")
+	t.Setenv(EnvDrainTimeout, "10s")
+	t.Setenv(EnvIdleTimeout, "3m")
+	t.Setenv(EnvSessionIdleTimeout, "15m")
+	t.Setenv(EnvReadBufferSize, "4096")
+	t.Setenv(EnvMaxLineLength, "1024")
+	t.Setenv(EnvMaxSessions, "50")
+	t.Setenv(EnvMaxSessionsPerUser, "5")
+	t.Setenv(EnvAuditLog, "/var/log/sam-audit.log")
+	t.Setenv(EnvAccessLog, "/var/log/sam-access.log")
+	t.Setenv(EnvAdminAddr, "127.0.0.1:7657")
+	t.Setenv(EnvMetricsAddr, "127.0.0.1:9100")
+	t.Setenv(EnvPprofAddr, "127.0.0.1:6060")
+	t.Setenv(EnvLogFormat, "json")
+	t.Setenv(EnvLogSampleBurst, "20")
+	t.Setenv(EnvLogSampleInterval, "5s")
+	t.Setenv(EnvKeyStoreDir, "/var/lib/sam-bridge/keys")
+	t.Setenv(EnvKeyStorePassphrase, "correct horse")
+
+	opts, err := ConfigFromEnv()
+	if err != nil {
+		t.Fatalf("ConfigFromEnv() error = %v", err)
+	}
+
+	cfg := DefaultConfig()
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	if cfg.ListenAddr != "127.0.0.1:9656" {
+		t.Errorf("ListenAddr = %q, want %q", cfg.ListenAddr, "127.0.0.1:9656")
+	}
+	if cfg.DatagramPort != 9655 {
+		t.Errorf("DatagramPort = %d, want 9655", cfg.DatagramPort)
+	}
+	if !cfg.Debug {
+		t.Error("Debug should be true")
+	}
+	if cfg.I2CPAddr != "10.0.0.1:7654" {
+		t.Errorf("I2CPAddr = %q, want %q", cfg.I2CPAddr, "10.0.0.1:7654")
+	}
+	if cfg.I2CPUsername != "i2cpuser" || cfg.I2CPPassword != "i2cppass" {
+		t.Errorf("I2CP credentials = %q/%q, want i2cpuser/i2cppass", cfg.I2CPUsername, cfg.I2CPPassword)
+	}
+	if want := []string{"10.0.0.2:7654", "10.0.0.3:7654"}; !slices.Equal(cfg.I2CPFailoverAddrs, want) {
+		t.Errorf("I2CPFailoverAddrs = %v, want %v", cfg.I2CPFailoverAddrs, want)
+	}
+	if want := (I2CPTLSConfig{CAFile: "/etc/i2p/router-ca.pem"}); cfg.I2CPTLS == nil || *cfg.I2CPTLS != want {
+		t.Errorf("I2CPTLS = %+v, want %+v", cfg.I2CPTLS, want)
+	}
+	if len(cfg.AuthUsers) != 2 || cfg.AuthUsers["alice"] != "secret" || cfg.AuthUsers["bob"] != "hunter2" {
+		t.Errorf("AuthUsers = %v, want alice and bob", cfg.AuthUsers)
+	}
+	if cfg.HandshakeTimeout != 5*time.Second || cfg.CommandTimeout != 2*time.Minute || cfg.DrainTimeout != 10*time.Second {
+		t.Errorf("timeouts = %v/%v/%v, want 5s/2m/10s", cfg.HandshakeTimeout, cfg.CommandTimeout, cfg.DrainTimeout)
+	}
+	if cfg.IdleTimeout != 3*time.Minute {
+		t.Errorf("IdleTimeout = %v, want 3m", cfg.IdleTimeout)
+	}
+	if cfg.SessionIdleTimeout != 15*time.Minute {
+		t.Errorf("SessionIdleTimeout = %v, want 15m", cfg.SessionIdleTimeout)
+	}
+	if cfg.ReadBufferSize != 4096 || cfg.MaxLineLength != 1024 {
+		t.Errorf("limits = %d/%d, want 4096/1024", cfg.ReadBufferSize, cfg.MaxLineLength)
+	}
+	if cfg.MaxSessions != 50 || cfg.MaxSessionsPerUser != 5 {
+		t.Errorf("session limits = %d/%d, want 50/5", cfg.MaxSessions, cfg.MaxSessionsPerUser)
+	}
+	if cfg.AuditLogFile != "/var/log/sam-audit.log" {
+		t.Errorf("AuditLogFile = %q, want %q", cfg.AuditLogFile, "/var/log/sam-audit.log")
+	}
+	if cfg.AccessLogFile != "/var/log/sam-access.log" {
+		t.Errorf("AccessLogFile = %q, want %q", cfg.AccessLogFile, "/var/log/sam-access.log")
+	}
+	if cfg.KeyStoreDir != "/var/lib/sam-bridge/keys" || cfg.KeyStorePassphrase != "correct horse" {
+		t.Errorf("key store = %q/%q, want /var/lib/sam-bridge/keys/correct horse", cfg.KeyStoreDir, cfg.KeyStorePassphrase)
+	}
+	if cfg.AdminAddr != "127.0.0.1:7657" {
+		t.Errorf("AdminAddr = %q, want %q", cfg.AdminAddr, "127.0.0.1:7657")
+	}
+	if cfg.MetricsAddr != "127.0.0.1:9100" {
+		t.Errorf("MetricsAddr = %q, want %q", cfg.MetricsAddr, "127.0.0.1:9100")
+	}
+	if cfg.PprofAddr != "127.0.0.1:6060" {
+		t.Errorf("PprofAddr = %q, want %q", cfg.PprofAddr, "127.0.0.1:6060")
+	}
+	if cfg.LogFormat != LogFormatJSON {
+		t.Errorf("LogFormat = %q, want %q", cfg.LogFormat, LogFormatJSON)
+	}
+	if cfg.LogSampleBurst != 20 || cfg.LogSampleInterval != 5*time.Second {
+		t.Errorf("log sampling = %d/%v, want 20/5s", cfg.LogSampleBurst, cfg.LogSampleInterval)
+	}
+}
+
+func TestConfigFromEnv_Unset(t *testing.T) {
+	fc, err := fileConfigFromEnv(func(string) string { return "" })
+	if err != nil {
+		t.Fatalf("fileConfigFromEnv() error = %v", err)
+	}
+	opts, err := fc.Options()
+	if err != nil {
+		t.Fatalf("Options() error = %v", err)
+	}
+	if len(opts) != 0 {
+		t.Errorf("len(opts) = %d, want 0 when no variables are set", len(opts))
+	}
+}
+
+func TestParseAuthUsers_Hashes(t *testing.T) {
+	argon := "$argon2id$v=19$m=19456,t=2,p=1$c2FsdA$a2V5"
+	bcrypt := "$2a$10$N9qo8uLOickgx2ZMRZoMyeIjZAgcfl7p92ldGxad68LJZdL17lhWy"
+	users, err := parseAuthUsers("alice:" + argon + ", bob:" + bcrypt + ",carol:plain")
+	if err != nil {
+		t.Fatalf("parseAuthUsers() error = %v", err)
+	}
+	want := map[string]string{"alice": argon, "bob": bcrypt, "carol": "plain"}
+	if !maps.Equal(users, want) {
+		t.Errorf("parseAuthUsers() = %v, want %v", users, want)
+	}
+}
+
+func TestConfigFromEnv_DebugFalse(t *testing.T) {
+	t.Setenv(EnvDebug, "false")
+
+	opts, err := ConfigFromEnv()
+	if err != nil {
+		t.Fatalf("ConfigFromEnv() error = %v", err)
+	}
+	// SAM_DEBUG=false turns off debug enabled by -debug or a config file
+	cfg := DefaultConfig()
+	cfg.Debug = true
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	if cfg.Debug {
+		t.Error("Debug should be false when SAM_DEBUG=false")
+	}
+}
+
+func TestConfigFromEnv_Errors(t *testing.T) {
+	tests := []struct {
+		name  string
+		key   string
+		value string
+	}{
+		{"bad datagram port", EnvDatagramPort, "udp"},
+		{"bad line length", EnvMaxLineLength, "long"},
+		{"bad session limit", EnvMaxSessions, "many"},
+		{"bad auth entry", EnvAuthUsers, "alice"},
+		{"bad timeout", EnvCommandTimeout, "later"},
+		{"cert without key", EnvTLSCert, "/tmp/cert.pem"},
+		{"bad I2CP TLS flag", EnvI2CPTLS, "maybe"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv(tt.key, tt.value)
+			if _, err := ConfigFromEnv(); err == nil {
+				t.Errorf("ConfigFromEnv() with %s=%q error = nil, want error", tt.key, tt.value)
+			}
+		})
+	}
+}
@@ -10,12 +10,85 @@ var (
 	// ErrMissingI2CPAddr is returned when no I2CP address or provider is provided.
 	ErrMissingI2CPAddr = errors.New("embedding: I2CP address or provider required")
 
+	// ErrConflictingI2CPProvider is returned when both an I2CP provider and
+	// a shared I2CP provider are configured.
+	ErrConflictingI2CPProvider = errors.New("embedding: I2CP provider and shared I2CP provider are mutually exclusive")
+
+	// ErrSharedI2CPClosed is returned by New when the shared I2CP provider
+	// has already been closed.
+	ErrSharedI2CPClosed = errors.New("embedding: shared I2CP provider is closed")
+
+	// ErrInvalidTimeout is returned when a configured timeout is negative.
+	ErrInvalidTimeout = errors.New("embedding: timeout cannot be negative")
+
+	// ErrInvalidLimit is returned when a configured buffer, line, or session limit is negative.
+	ErrInvalidLimit = errors.New("embedding: limit cannot be negative")
+
+	// ErrInvalidSessionDefaults is returned when a session default is
+	// negative or exceeds the tunnel limits.
+	ErrInvalidSessionDefaults = errors.New("embedding: invalid session defaults")
+
+	// ErrMissingKeyStorePassphrase is returned when a key store directory
+	// is configured without a passphrase.
+	ErrMissingKeyStorePassphrase = errors.New("embedding: key store directory requires a passphrase")
+
+	// ErrRegistryNotObservable is returned by New when session observers
+	// are configured but the registry does not implement session.Observable.
+	ErrRegistryNotObservable = errors.New("embedding: registry does not support session observers")
+
+	// ErrUnknownConfigFormat is returned when a config file extension is not
+	// one of .yaml, .yml, .toml, or .json.
+	ErrUnknownConfigFormat = errors.New("embedding: unknown config file format")
+
+	// ErrUnknownLogFormat is returned when the log format is not "text" or "json".
+	ErrUnknownLogFormat = errors.New("embedding: unknown log format")
+
+	// ErrInvalidEndpoint is returned when an Endpoint has an unknown
+	// network or an empty address.
+	ErrInvalidEndpoint = errors.New("embedding: invalid endpoint")
+
+	// ErrEndpointTLSUnavailable is returned when a TLS Endpoint is
+	// configured without a TLS configuration or certificate files.
+	ErrEndpointTLSUnavailable = errors.New("embedding: TLS endpoint requires TLS configuration")
+
+	// ErrInvalidOutproxy is returned when the HTTP outproxy is not a
+	// host:port on I2P.
+	ErrInvalidOutproxy = errors.New("embedding: HTTP outproxy must be an .i2p host:port")
+
+	// ErrPprofNotLoopback is returned when the pprof address is not a
+	// loopback host:port.
+	ErrPprofNotLoopback = errors.New("embedding: pprof address must be a loopback host:port")
+
+	// ErrI2PControlDefaultPassword is returned when the I2PControl API is
+	// served on an address that is not loopback with the default password.
+	ErrI2PControlDefaultPassword = errors.New("embedding: I2PControl on a non-loopback address requires a password other than the default")
+
+	// ErrIncompleteTLSConfig is returned when only one of the TLS
+	// certificate and key paths is configured.
+	ErrIncompleteTLSConfig = errors.New("embedding: TLS requires both certificate and key")
+
+	// ErrInvalidTLSPolicy is returned when a TLS policy names an unknown
+	// protocol version or cipher suite.
+	ErrInvalidTLSPolicy = errors.New("embedding: invalid TLS policy")
+
+	// ErrTLSReloadUnavailable is returned by ReloadTLS when TLS was not
+	// configured from certificate and key files.
+	ErrTLSReloadUnavailable = errors.New("embedding: TLS is not configured from files")
+
 	// ErrBridgeAlreadyRunning is returned when Start is called on a running bridge.
 	ErrBridgeAlreadyRunning = errors.New("embedding: bridge is already running")
 
 	// ErrBridgeNotRunning is returned when Stop is called on a stopped bridge.
 	ErrBridgeNotRunning = errors.New("embedding: bridge is not running")
 
+	// ErrI2CPDisconnected is returned by Health when the I2CP provider has
+	// lost its connection to the I2P router.
+	ErrI2CPDisconnected = errors.New("embedding: I2CP provider is not connected")
+
+	// ErrI2CPUnresponsive is returned by Health when the I2CP provider is
+	// connected but the router has stopped answering requests.
+	ErrI2CPUnresponsive = errors.New("embedding: I2P router is not answering I2CP requests")
+
 	// ErrI2CPConnectFailed is returned when connection to I2P router fails.
 	ErrI2CPConnectFailed = errors.New("embedding: failed to connect to I2P router")
 )
@@ -0,0 +1,672 @@
+package embedding
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/go-i2p/go-sam-bridge/lib/handler"
+	"github.com/go-i2p/go-sam-bridge/lib/tunnels"
+	"github.com/go-i2p/go-sam-bridge/lib/util"
+	"github.com/pelletier/go-toml/v2"
+	"go.yaml.in/yaml/v3"
+)
+
+// FileConfig is the structured configuration file format.
+// The same layout is accepted as YAML, TOML, or JSON; the format is
+// selected from the file extension (.yaml/.yml, .toml, .json).
+// Durations are strings parsed with time.ParseDuration (e.g. "30s").
+//
+// Example (YAML):
+//
+//	listen: ":7656"
+//	datagram_port: 7655
+//	i2cp:
+//	  addr: "127.0.0.1:7654"
+//	auth:
+//	  users:
+//	    alice: secret
+//	timeouts:
+//	  handshake: 30s
+//	  command: 60s
+//	limits:
+//	  max_line_length: 65536
+//	tls:
+//	  cert: /etc/sam-bridge/cert.pem
+//	  key: /etc/sam-bridge/key.pem
+//	keystore:
+//	  dir: /var/lib/sam-bridge/keys
+type FileConfig struct {
+	// Listen is the SAM TCP listen address.
+	Listen string `json:"listen" yaml:"listen" toml:"listen"`
+
+	// DatagramPort is the UDP datagram port. Nil keeps the default;
+	// 0 disables the UDP listener.
+	DatagramPort *int `json:"datagram_port" yaml:"datagram_port" toml:"datagram_port"`
+
+	// Debug enables or disables debug logging. Nil keeps the current
+	// setting.
+	Debug *bool `json:"debug" yaml:"debug" toml:"debug"`
+
+	// LogFormat is "text" or "json".
+	LogFormat string `json:"log_format" yaml:"log_format" toml:"log_format"`
+
+	// LogSampling limits hot-path log records.
+	LogSampling FileLogSamplingConfig `json:"log_sampling" yaml:"log_sampling" toml:"log_sampling"`
+
+	// I2CP holds the router connection settings.
+	I2CP FileI2CPConfig `json:"i2cp" yaml:"i2cp" toml:"i2cp"`
+
+	// Auth holds SAM authentication settings.
+	Auth FileAuthConfig `json:"auth" yaml:"auth" toml:"auth"`
+
+	// Timeouts holds connection timeouts.
+	Timeouts FileTimeoutConfig `json:"timeouts" yaml:"timeouts" toml:"timeouts"`
+
+	// Limits holds buffer and line length limits.
+	Limits FileLimitConfig `json:"limits" yaml:"limits" toml:"limits"`
+
+	// Socket holds TCP options for client and forwarded connections.
+	Socket FileSocketConfig `json:"socket" yaml:"socket" toml:"socket"`
+
+	// ForwardRetry controls retrying STREAM FORWARD targets that are down.
+	ForwardRetry FileForwardRetryConfig `json:"forward_retry" yaml:"forward_retry" toml:"forward_retry"`
+
+	// TLS holds certificate paths for the SAM control socket.
+	TLS FileTLSConfig `json:"tls" yaml:"tls" toml:"tls"`
+
+	// KeyStore holds the encrypted key store for DESTINATION=file:.
+	KeyStore FileKeyStoreConfig `json:"keystore" yaml:"keystore" toml:"keystore"`
+
+	// StrictQuoting enforces SAM 3.2 quoting rules for 3.2 clients.
+	StrictQuoting bool `json:"strict_quoting" yaml:"strict_quoting" toml:"strict_quoting"`
+
+	// ReplaceInvalidUTF8 replaces invalid UTF-8 in commands with U+FFFD.
+	ReplaceInvalidUTF8 bool `json:"replace_invalid_utf8" yaml:"replace_invalid_utf8" toml:"replace_invalid_utf8"`
+
+	// AuditLog is a file path for command audit records.
+	AuditLog string `json:"audit_log" yaml:"audit_log" toml:"audit_log"`
+
+	// AccessLog is a file path for connection access records.
+	AccessLog string `json:"access_log" yaml:"access_log" toml:"access_log"`
+
+	// AdminAddr is the admin HTTP API listen address.
+	AdminAddr string `json:"admin_addr" yaml:"admin_addr" toml:"admin_addr"`
+
+	// MetricsAddr is the Prometheus metrics listen address.
+	MetricsAddr string `json:"metrics_addr" yaml:"metrics_addr" toml:"metrics_addr"`
+
+	// PprofAddr is the loopback listen address for profiling handlers.
+	PprofAddr string `json:"pprof_addr" yaml:"pprof_addr" toml:"pprof_addr"`
+
+	// I2PControl configures the I2PControl JSON-RPC API.
+	I2PControl FileI2PControlConfig `json:"i2pcontrol" yaml:"i2pcontrol" toml:"i2pcontrol"`
+
+	// SOCKS configures the SOCKS5 proxy frontend.
+	SOCKS FileSOCKSConfig `json:"socks" yaml:"socks" toml:"socks"`
+
+	// HTTPProxy configures the HTTP proxy frontend.
+	HTTPProxy FileHTTPProxyConfig `json:"http_proxy" yaml:"http_proxy" toml:"http_proxy"`
+
+	// BOB configures the BOB listener.
+	BOB FileBOBConfig `json:"bob" yaml:"bob" toml:"bob"`
+
+	// Tunnels declares client and server tunnels.
+	Tunnels FileTunnelsConfig `json:"tunnels" yaml:"tunnels" toml:"tunnels"`
+}
+
+// FileLogSamplingConfig holds log sampling settings in a configuration
+// file. See WithLogSampling.
+type FileLogSamplingConfig struct {
+	// Burst is the number of records each hot-path log site writes per
+	// interval; 0 disables sampling.
+	Burst int `json:"burst" yaml:"burst" toml:"burst"`
+
+	// Interval is the sampling window (e.g. "1s").
+	Interval string `json:"interval" yaml:"interval" toml:"interval"`
+}
+
+// FileI2CPConfig holds I2CP settings in a configuration file.
+type FileI2CPConfig struct {
+	Addr     string `json:"addr" yaml:"addr" toml:"addr"`
+	Username string `json:"username" yaml:"username" toml:"username"`
+	Password string `json:"password" yaml:"password" toml:"password"`
+
+	// FailoverAddrs are routers to fail over to, in order.
+	FailoverAddrs []string `json:"failover_addrs" yaml:"failover_addrs" toml:"failover_addrs"`
+
+	// TLS holds settings for an SSL-enabled I2CP port.
+	TLS FileI2CPTLSConfig `json:"tls" yaml:"tls" toml:"tls"`
+}
+
+// FileI2CPTLSConfig holds I2CP TLS settings in a configuration file.
+// The other fields only apply when Enabled is set.
+type FileI2CPTLSConfig struct {
+	Enabled  bool   `json:"enabled" yaml:"enabled" toml:"enabled"`
+	CA       string `json:"ca" yaml:"ca" toml:"ca"`
+	Cert     string `json:"cert" yaml:"cert" toml:"cert"`
+	Key      string `json:"key" yaml:"key" toml:"key"`
+	Insecure bool   `json:"insecure" yaml:"insecure" toml:"insecure"`
+}
+
+// FileAuthConfig holds SAM authentication settings in a configuration file.
+type FileAuthConfig struct {
+	// Users maps usernames to passwords.
+	Users map[string]string `json:"users" yaml:"users" toml:"users"`
+}
+
+// FileTimeoutConfig holds timeouts in a configuration file.
+type FileTimeoutConfig struct {
+	Handshake string `json:"handshake" yaml:"handshake" toml:"handshake"`
+	Command   string `json:"command" yaml:"command" toml:"command"`
+	Drain     string `json:"drain" yaml:"drain" toml:"drain"`
+
+	// Idle closes connections without a session or commands for this long.
+	Idle string `json:"idle" yaml:"idle" toml:"idle"`
+
+	// SessionIdle closes sessions without traffic for this long.
+	SessionIdle string `json:"session_idle" yaml:"session_idle" toml:"session_idle"`
+
+	// Write disconnects clients whose writes block for this long.
+	Write string `json:"write" yaml:"write" toml:"write"`
+
+	// AcceptBacklog is how long a stream waits in the accept backlog.
+	AcceptBacklog string `json:"accept_backlog" yaml:"accept_backlog" toml:"accept_backlog"`
+
+	// StreamRead and StreamWrite close streams whose peer is silent, or
+	// whose writes stall, for this long.
+	StreamRead  string `json:"stream_read" yaml:"stream_read" toml:"stream_read"`
+	StreamWrite string `json:"stream_write" yaml:"stream_write" toml:"stream_write"`
+
+	// SendExpiry drops queued datagrams not sent within this long.
+	SendExpiry string `json:"send_expiry" yaml:"send_expiry" toml:"send_expiry"`
+}
+
+// FileLimitConfig holds buffer and line limits in a configuration file.
+type FileLimitConfig struct {
+	ReadBufferSize int `json:"read_buffer_size" yaml:"read_buffer_size" toml:"read_buffer_size"`
+	MaxLineLength  int `json:"max_line_length" yaml:"max_line_length" toml:"max_line_length"`
+
+	// MaxSessions and MaxSessionsPerUser limit open sessions in total
+	// and per authenticated user.
+	MaxSessions        int `json:"max_sessions" yaml:"max_sessions" toml:"max_sessions"`
+	MaxSessionsPerUser int `json:"max_sessions_per_user" yaml:"max_sessions_per_user" toml:"max_sessions_per_user"`
+
+	// MaxOutboundStreams and MaxInboundStreams limit concurrent streams
+	// per session in each direction.
+	MaxOutboundStreams int `json:"max_outbound_streams" yaml:"max_outbound_streams" toml:"max_outbound_streams"`
+	MaxInboundStreams  int `json:"max_inbound_streams" yaml:"max_inbound_streams" toml:"max_inbound_streams"`
+
+	// SendQueue and SendRetries size the per-session datagram send queue
+	// and limit retries of each queued send.
+	SendQueue   int `json:"send_queue" yaml:"send_queue" toml:"send_queue"`
+	SendRetries int `json:"send_retries" yaml:"send_retries" toml:"send_retries"`
+
+	// MaxConnections and MaxQueuedConnections limit concurrently handled
+	// client connections and those waiting for a free slot.
+	MaxConnections       int `json:"max_connections" yaml:"max_connections" toml:"max_connections"`
+	MaxQueuedConnections int `json:"max_queued_connections" yaml:"max_queued_connections" toml:"max_queued_connections"`
+
+	// MaxConnectionsPerIP limits concurrent connections per remote IP.
+	MaxConnectionsPerIP int `json:"max_connections_per_ip" yaml:"max_connections_per_ip" toml:"max_connections_per_ip"`
+
+	// StreamBufferSize is the stream copy buffer size per direction.
+	StreamBufferSize int `json:"stream_buffer_size" yaml:"stream_buffer_size" toml:"stream_buffer_size"`
+
+	// DestinationPoolSize is the number of destinations generated ahead.
+	DestinationPoolSize int `json:"destination_pool_size" yaml:"destination_pool_size" toml:"destination_pool_size"`
+
+	// AcceptBacklog is the number of inbound streams held per session
+	// for STREAM ACCEPT.
+	AcceptBacklog int `json:"accept_backlog" yaml:"accept_backlog" toml:"accept_backlog"`
+}
+
+// FileSocketConfig holds TCP socket options in a configuration file.
+type FileSocketConfig struct {
+	// KeepAlive is the TCP keepalive period, e.g. "30s"; "-1s" disables it.
+	KeepAlive string `json:"keepalive" yaml:"keepalive" toml:"keepalive"`
+
+	// DisableNoDelay clears TCP_NODELAY.
+	DisableNoDelay bool `json:"disable_no_delay" yaml:"disable_no_delay" toml:"disable_no_delay"`
+
+	// ReadBuffer and WriteBuffer are the socket buffer sizes in bytes.
+	ReadBuffer  int `json:"read_buffer" yaml:"read_buffer" toml:"read_buffer"`
+	WriteBuffer int `json:"write_buffer" yaml:"write_buffer" toml:"write_buffer"`
+}
+
+// FileI2PControlConfig holds the I2PControl API settings in a
+// configuration file.
+type FileI2PControlConfig struct {
+	// Addr is the API listen address; empty disables it.
+	Addr string `json:"addr" yaml:"addr" toml:"addr"`
+
+	// Password authenticates I2PControl clients.
+	Password string `json:"password" yaml:"password" toml:"password"`
+}
+
+// FileSOCKSConfig holds the SOCKS5 proxy settings in a configuration
+// file.
+type FileSOCKSConfig struct {
+	// Addr is the proxy listen address; empty disables the proxy.
+	Addr string `json:"addr" yaml:"addr" toml:"addr"`
+
+	// User and Password authenticate the proxy's SAM connections.
+	User     string `json:"user" yaml:"user" toml:"user"`
+	Password string `json:"password" yaml:"password" toml:"password"`
+}
+
+// FileHTTPProxyConfig holds the HTTP proxy settings in a configuration
+// file.
+type FileHTTPProxyConfig struct {
+	// Addr is the proxy listen address; empty disables the proxy.
+	Addr string `json:"addr" yaml:"addr" toml:"addr"`
+
+	// User and Password authenticate the proxy's SAM connections.
+	User     string `json:"user" yaml:"user" toml:"user"`
+	Password string `json:"password" yaml:"password" toml:"password"`
+
+	// Outproxy is the .i2p host:port of an HTTP proxy for clearnet hosts;
+	// empty rejects them.
+	Outproxy string `json:"outproxy" yaml:"outproxy" toml:"outproxy"`
+}
+
+// FileBOBConfig holds the BOB listener settings in a configuration file.
+type FileBOBConfig struct {
+	// Addr is the BOB listen address; empty disables the listener.
+	Addr string `json:"addr" yaml:"addr" toml:"addr"`
+
+	// User and Password authenticate the listener's SAM connections.
+	User     string `json:"user" yaml:"user" toml:"user"`
+	Password string `json:"password" yaml:"password" toml:"password"`
+}
+
+// FileTunnelsConfig holds the tunnels in a configuration file.
+//
+// Example (YAML):
+//
+//	tunnels:
+//	  client:
+//	    - name: irc
+//	      listen: 127.0.0.1:6668
+//	      destination: irc.postman.i2p:6667
+//	  server:
+//	    - name: web
+//	      keyfile: /var/lib/sam-bridge/web.dat
+//	      target: 127.0.0.1:8080
+//	      options:
+//	        inbound.length: "2"
+type FileTunnelsConfig struct {
+	// User and Password authenticate the tunnels' SAM connections.
+	User     string `json:"user" yaml:"user" toml:"user"`
+	Password string `json:"password" yaml:"password" toml:"password"`
+
+	// Client and Server list the tunnels of each type.
+	Client []FileTunnelConfig `json:"client" yaml:"client" toml:"client"`
+	Server []FileTunnelConfig `json:"server" yaml:"server" toml:"server"`
+}
+
+// FileTunnelConfig holds one tunnel in a configuration file. Listen and
+// Destination apply to client tunnels, Target to server tunnels.
+type FileTunnelConfig struct {
+	Name        string            `json:"name" yaml:"name" toml:"name"`
+	Listen      string            `json:"listen" yaml:"listen" toml:"listen"`
+	Destination string            `json:"destination" yaml:"destination" toml:"destination"`
+	Target      string            `json:"target" yaml:"target" toml:"target"`
+	Keyfile     string            `json:"keyfile" yaml:"keyfile" toml:"keyfile"`
+	Options     map[string]string `json:"options" yaml:"options" toml:"options"`
+}
+
+// tunnel converts ft to a tunnel of the given type.
+func (ft FileTunnelConfig) tunnel(typ string) tunnels.Tunnel {
+	return tunnels.Tunnel{
+		Name:        ft.Name,
+		Type:        typ,
+		Listen:      ft.Listen,
+		Destination: ft.Destination,
+		Target:      ft.Target,
+		Keyfile:     ft.Keyfile,
+		Options:     ft.Options,
+	}
+}
+
+// FileForwardRetryConfig holds the STREAM FORWARD retry policy in a
+// configuration file. Unset fields keep handler.DefaultForwardRetry.
+type FileForwardRetryConfig struct {
+	// Attempts is the number of connection attempts per inbound stream.
+	Attempts int `json:"attempts" yaml:"attempts" toml:"attempts"`
+
+	// Backoff and MaxBackoff are the first and longest wait between
+	// attempts, e.g. "250ms".
+	Backoff    string `json:"backoff" yaml:"backoff" toml:"backoff"`
+	MaxBackoff string `json:"max_backoff" yaml:"max_backoff" toml:"max_backoff"`
+}
+
+// FileTLSConfig holds TLS certificate paths and policy in a configuration
+// file. TLS is enabled when both Cert and Key are set.
+type FileTLSConfig struct {
+	Cert string `json:"cert" yaml:"cert" toml:"cert"`
+	Key  string `json:"key" yaml:"key" toml:"key"`
+
+	// MinVersion is the lowest accepted TLS version, e.g. "1.3".
+	MinVersion string `json:"min_version" yaml:"min_version" toml:"min_version"`
+
+	// CipherSuites lists cipher suite names allowed for TLS 1.2 and
+	// earlier, e.g. "TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384".
+	CipherSuites []string `json:"cipher_suites" yaml:"cipher_suites" toml:"cipher_suites"`
+
+	// ALPN lists the application protocols offered, in preference order.
+	ALPN []string `json:"alpn" yaml:"alpn" toml:"alpn"`
+}
+
+// FileKeyStoreConfig holds key store settings in a configuration file.
+// The passphrase is better supplied through SAM_KEYSTORE_PASSPHRASE than
+// written to the file.
+type FileKeyStoreConfig struct {
+	Dir        string `json:"dir" yaml:"dir" toml:"dir"`
+	Passphrase string `json:"passphrase" yaml:"passphrase" toml:"passphrase"`
+}
+
+// ConfigFromFile reads a YAML, TOML, or JSON configuration file and
+// returns the Options it describes. Unset fields are omitted so the
+// defaults (or options applied later) still take effect:
+//
+//	opts, err := embedding.ConfigFromFile("/etc/sam-bridge.yaml")
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	bridge, err := embedding.New(append(opts, embedding.WithLogger(log))...)
+func ConfigFromFile(path string) ([]Option, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("embedding: reading config file: %w", err)
+	}
+
+	fc, err := ParseFileConfig(data, filepath.Ext(path))
+	if err != nil {
+		return nil, fmt.Errorf("embedding: parsing %s: %w", path, err)
+	}
+	return fc.Options()
+}
+
+// ParseFileConfig decodes configuration data in the format named by ext
+// (".yaml", ".yml", ".toml", or ".json"). Unknown fields are rejected.
+func ParseFileConfig(data []byte, ext string) (*FileConfig, error) {
+	fc := &FileConfig{}
+
+	switch strings.ToLower(ext) {
+	case ".yaml", ".yml":
+		dec := yaml.NewDecoder(bytes.NewReader(data))
+		dec.KnownFields(true)
+		if err := dec.Decode(fc); err != nil && !errors.Is(err, io.EOF) {
+			return nil, err
+		}
+	case ".toml":
+		dec := toml.NewDecoder(bytes.NewReader(data))
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(fc); err != nil {
+			return nil, err
+		}
+	case ".json":
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(fc); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnknownConfigFormat, ext)
+	}
+
+	return fc, nil
+}
+
+// Options converts the file configuration into functional options.
+// Returns an error if a duration cannot be parsed or the TLS settings
+// are incomplete. The TLS key pair itself is loaded by New.
+func (fc *FileConfig) Options() ([]Option, error) {
+	var opts []Option
+
+	if fc.Listen != "" {
+		opts = append(opts, WithListenAddr(fc.Listen))
+	}
+	if fc.DatagramPort != nil {
+		opts = append(opts, WithDatagramPort(*fc.DatagramPort))
+	}
+	if fc.Debug != nil {
+		opts = append(opts, WithDebug(*fc.Debug))
+	}
+	if fc.LogFormat != "" {
+		opts = append(opts, WithLogFormat(fc.LogFormat))
+	}
+	if s := fc.LogSampling; s.Burst != 0 || s.Interval != "" {
+		var interval time.Duration
+		if s.Interval != "" {
+			d, err := time.ParseDuration(s.Interval)
+			if err != nil {
+				return nil, fmt.Errorf("embedding: invalid log_sampling.interval: %w", err)
+			}
+			interval = d
+		}
+		opts = append(opts, WithLogSampling(s.Burst, interval))
+	}
+
+	if fc.I2CP.Addr != "" {
+		opts = append(opts, WithI2CPAddr(fc.I2CP.Addr))
+	}
+	if fc.I2CP.Username != "" || fc.I2CP.Password != "" {
+		opts = append(opts, WithI2CPCredentials(fc.I2CP.Username, fc.I2CP.Password))
+	}
+	if t := fc.I2CP.TLS; t.Enabled {
+		if (t.Cert == "") != (t.Key == "") {
+			return nil, ErrIncompleteTLSConfig
+		}
+		opts = append(opts, WithI2CPTLS(I2CPTLSConfig{CAFile: t.CA, CertFile: t.Cert, KeyFile: t.Key, Insecure: t.Insecure}))
+	}
+	if len(fc.I2CP.FailoverAddrs) > 0 {
+		opts = append(opts, WithI2CPFailoverAddrs(fc.I2CP.FailoverAddrs...))
+	}
+
+	if len(fc.Auth.Users) > 0 {
+		opts = append(opts, WithAuth(fc.Auth.Users))
+	}
+
+	timeouts := []struct {
+		name  string
+		value string
+		opt   func(time.Duration) Option
+	}{
+		{"timeouts.handshake", fc.Timeouts.Handshake, WithHandshakeTimeout},
+		{"timeouts.command", fc.Timeouts.Command, WithCommandTimeout},
+		{"timeouts.drain", fc.Timeouts.Drain, WithDrainTimeout},
+		{"timeouts.idle", fc.Timeouts.Idle, WithIdleTimeout},
+		{"timeouts.session_idle", fc.Timeouts.SessionIdle, WithSessionIdleTimeout},
+		{"timeouts.write", fc.Timeouts.Write, WithWriteTimeout},
+		{"timeouts.accept_backlog", fc.Timeouts.AcceptBacklog, WithAcceptBacklogTimeout},
+		{"timeouts.stream_read", fc.Timeouts.StreamRead, WithStreamReadTimeout},
+		{"timeouts.stream_write", fc.Timeouts.StreamWrite, WithStreamWriteTimeout},
+	}
+	for _, t := range timeouts {
+		if t.value == "" {
+			continue
+		}
+		d, err := time.ParseDuration(t.value)
+		if err != nil {
+			return nil, fmt.Errorf("embedding: invalid %s: %w", t.name, err)
+		}
+		opts = append(opts, t.opt(d))
+	}
+
+	if fc.Limits.ReadBufferSize != 0 {
+		opts = append(opts, WithReadBufferSize(fc.Limits.ReadBufferSize))
+	}
+	if fc.Limits.MaxLineLength != 0 {
+		opts = append(opts, WithMaxLineLength(fc.Limits.MaxLineLength))
+	}
+	if fc.Limits.MaxSessions != 0 {
+		opts = append(opts, WithMaxSessions(fc.Limits.MaxSessions))
+	}
+	if fc.Limits.MaxSessionsPerUser != 0 {
+		opts = append(opts, WithMaxSessionsPerUser(fc.Limits.MaxSessionsPerUser))
+	}
+	if fc.Limits.MaxOutboundStreams != 0 {
+		opts = append(opts, WithMaxOutboundStreams(fc.Limits.MaxOutboundStreams))
+	}
+	if fc.Limits.MaxInboundStreams != 0 {
+		opts = append(opts, WithMaxInboundStreams(fc.Limits.MaxInboundStreams))
+	}
+	if fc.Limits.SendQueue != 0 {
+		var expiry time.Duration
+		if fc.Timeouts.SendExpiry != "" {
+			d, err := time.ParseDuration(fc.Timeouts.SendExpiry)
+			if err != nil {
+				return nil, fmt.Errorf("embedding: invalid timeouts.send_expiry: %w", err)
+			}
+			expiry = d
+		}
+		opts = append(opts, WithSendQueue(fc.Limits.SendQueue, fc.Limits.SendRetries, expiry))
+	}
+	if fc.Limits.MaxConnections != 0 || fc.Limits.MaxQueuedConnections != 0 {
+		opts = append(opts, WithMaxConnections(fc.Limits.MaxConnections, fc.Limits.MaxQueuedConnections))
+	}
+	if fc.Limits.MaxConnectionsPerIP != 0 {
+		opts = append(opts, WithMaxConnectionsPerIP(fc.Limits.MaxConnectionsPerIP))
+	}
+	if fc.Limits.StreamBufferSize != 0 {
+		opts = append(opts, WithStreamBufferSize(fc.Limits.StreamBufferSize))
+	}
+	if fc.Limits.DestinationPoolSize != 0 {
+		opts = append(opts, WithDestinationPoolSize(fc.Limits.DestinationPoolSize))
+	}
+	if fc.Limits.AcceptBacklog != 0 {
+		opts = append(opts, WithAcceptBacklog(fc.Limits.AcceptBacklog))
+	}
+
+	sockopts := util.SocketOptions{
+		DisableNoDelay: fc.Socket.DisableNoDelay,
+		ReadBuffer:     fc.Socket.ReadBuffer,
+		WriteBuffer:    fc.Socket.WriteBuffer,
+	}
+	if fc.Socket.KeepAlive != "" {
+		d, err := time.ParseDuration(fc.Socket.KeepAlive)
+		if err != nil {
+			return nil, fmt.Errorf("embedding: invalid socket.keepalive: %w", err)
+		}
+		sockopts.KeepAlive = d
+	}
+	if !sockopts.IsZero() {
+		opts = append(opts, WithSocketOptions(sockopts))
+	}
+
+	retry := handler.ForwardRetry{Attempts: fc.ForwardRetry.Attempts}
+	for _, d := range []struct {
+		name  string
+		value string
+		dst   *time.Duration
+	}{
+		{"forward_retry.backoff", fc.ForwardRetry.Backoff, &retry.Backoff},
+		{"forward_retry.max_backoff", fc.ForwardRetry.MaxBackoff, &retry.MaxBackoff},
+	} {
+		if d.value == "" {
+			continue
+		}
+		v, err := time.ParseDuration(d.value)
+		if err != nil {
+			return nil, fmt.Errorf("embedding: invalid %s: %w", d.name, err)
+		}
+		*d.dst = v
+	}
+	if !retry.IsZero() {
+		opts = append(opts, WithForwardRetry(retry))
+	}
+
+	if fc.TLS.Cert != "" || fc.TLS.Key != "" {
+		if fc.TLS.Cert == "" || fc.TLS.Key == "" {
+			return nil, ErrIncompleteTLSConfig
+		}
+		opts = append(opts, WithTLSFiles(fc.TLS.Cert, fc.TLS.Key))
+	}
+	var policy TLSPolicy
+	var err error
+	if policy.MinVersion, err = ParseTLSVersion(fc.TLS.MinVersion); err != nil {
+		return nil, err
+	}
+	if policy.CipherSuites, err = ParseCipherSuites(fc.TLS.CipherSuites); err != nil {
+		return nil, err
+	}
+	policy.NextProtos = fc.TLS.ALPN
+	if !policy.IsZero() {
+		opts = append(opts, WithTLSPolicy(policy))
+	}
+
+	if fc.KeyStore.Dir != "" {
+		opts = append(opts, WithKeyStoreDir(fc.KeyStore.Dir))
+	}
+	if fc.KeyStore.Passphrase != "" {
+		opts = append(opts, WithKeyStorePassphrase(fc.KeyStore.Passphrase))
+	}
+
+	if fc.StrictQuoting {
+		opts = append(opts, WithStrictQuoting(true))
+	}
+	if fc.ReplaceInvalidUTF8 {
+		opts = append(opts, WithReplaceInvalidUTF8(true))
+	}
+	if fc.AuditLog != "" {
+		opts = append(opts, WithAuditLogFile(fc.AuditLog))
+	}
+	if fc.AccessLog != "" {
+		opts = append(opts, WithAccessLogFile(fc.AccessLog))
+	}
+	if fc.AdminAddr != "" {
+		opts = append(opts, WithAdminAddr(fc.AdminAddr))
+	}
+	if fc.MetricsAddr != "" {
+		opts = append(opts, WithMetricsAddr(fc.MetricsAddr))
+	}
+	if fc.PprofAddr != "" {
+		opts = append(opts, WithPprofAddr(fc.PprofAddr))
+	}
+	if fc.I2PControl.Addr != "" {
+		opts = append(opts, WithI2PControlAddr(fc.I2PControl.Addr))
+	}
+	if fc.I2PControl.Password != "" {
+		opts = append(opts, WithI2PControlPassword(fc.I2PControl.Password))
+	}
+	if fc.SOCKS.Addr != "" {
+		opts = append(opts, WithSOCKSAddr(fc.SOCKS.Addr))
+	}
+	if fc.SOCKS.User != "" {
+		opts = append(opts, WithSOCKSAuth(fc.SOCKS.User, fc.SOCKS.Password))
+	}
+	if fc.HTTPProxy.Addr != "" {
+		opts = append(opts, WithHTTPProxyAddr(fc.HTTPProxy.Addr))
+	}
+	if fc.HTTPProxy.User != "" {
+		opts = append(opts, WithHTTPProxyAuth(fc.HTTPProxy.User, fc.HTTPProxy.Password))
+	}
+	if fc.HTTPProxy.Outproxy != "" {
+		opts = append(opts, WithHTTPOutproxy(fc.HTTPProxy.Outproxy))
+	}
+	if fc.BOB.Addr != "" {
+		opts = append(opts, WithBOBAddr(fc.BOB.Addr))
+	}
+	if fc.BOB.User != "" {
+		opts = append(opts, WithBOBAuth(fc.BOB.User, fc.BOB.Password))
+	}
+	for _, ft := range fc.Tunnels.Client {
+		opts = append(opts, WithTunnels(ft.tunnel(tunnels.TypeClient)))
+	}
+	for _, ft := range fc.Tunnels.Server {
+		opts = append(opts, WithTunnels(ft.tunnel(tunnels.TypeServer)))
+	}
+	if fc.Tunnels.User != "" {
+		opts = append(opts, WithTunnelAuth(fc.Tunnels.User, fc.Tunnels.Password))
+	}
+
+	return opts, nil
+}
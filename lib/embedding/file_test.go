@@ -0,0 +1,359 @@
+package embedding
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/go-i2p/go-sam-bridge/lib/handler"
+	"github.com/go-i2p/go-sam-bridge/lib/tunnels"
+)
+
+const testYAMLConfig = `
+listen: "127.0.0.1:9656"
+datagram_port: 0
+debug: true
+i2cp:
+  addr: "10.0.0.1:7654"
+  username: i2cpuser
+  password: i2cppass
+  failover_addrs: ["10.0.0.2:7654", "10.0.0.3:7654"]
+  tls:
+    enabled: true
+    ca: /etc/i2p/router-ca.pem
+auth:
+  users:
+    alice: secret
+timeouts:
+  handshake: 5s
+  command: 2m
+  drain: 10s
+  session_idle: 15m
+limits:
+  read_buffer_size: 4096
+  max_line_length: 1024
+  max_sessions: 50
+  max_sessions_per_user: 5
+keystore:
+  dir: /var/lib/sam-bridge/keys
+audit_log: /var/log/sam-audit.log
+admin_addr: 127.0.0.1:7657
+metrics_addr: 127.0.0.1:9100
+pprof_addr: 127.0.0.1:6060
+log_format: json
+`
+
+const testTOMLConfig = `
+listen = "127.0.0.1:9656"
+datagram_port = 0
+debug = true
+audit_log = "/var/log/sam-audit.log"
+admin_addr = "127.0.0.1:7657"
+metrics_addr = "127.0.0.1:9100"
+pprof_addr = "127.0.0.1:6060"
+log_format = "json"
+
+[i2cp]
+addr = "10.0.0.1:7654"
+username = "i2cpuser"
+password = "i2cppass"
+failover_addrs = ["10.0.0.2:7654", "10.0.0.3:7654"]
+
+[i2cp.tls]
+enabled = true
+ca = "/etc/i2p/router-ca.pem"
+
+[auth.users]
+alice = "secret"
+
+[timeouts]
+handshake = "5s"
+command = "2m"
+drain = "10s"
+session_idle = "15m"
+
+[limits]
+read_buffer_size = 4096
+max_line_length = 1024
+max_sessions = 50
+max_sessions_per_user = 5
+
+[keystore]
+dir = "/var/lib/sam-bridge/keys"
+`
+
+const testJSONConfig = `{
+  "listen": "127.0.0.1:9656",
+  "datagram_port": 0,
+  "debug": true,
+  "i2cp": {"addr": "10.0.0.1:7654", "username": "i2cpuser", "password": "i2cppass", "failover_addrs": ["10.0.0.2:7654", "10.0.0.3:7654"], "tls": {"enabled": true, "ca": "/etc/i2p/router-ca.pem"}},
+  "auth": {"users": {"alice": "secret"}},
+  "timeouts": {"handshake": "5s", "command": "2m", "drain": "10s", "session_idle": "15m"},
+  "limits": {"read_buffer_size": 4096, "max_line_length": 1024, "max_sessions": 50, "max_sessions_per_user": 5},
+  "keystore": {"dir": "/var/lib/sam-bridge/keys"},
+  "audit_log": "/var/log/sam-audit.log",
+  "admin_addr": "127.0.0.1:7657",
+  "metrics_addr": "127.0.0.1:9100",
+  "pprof_addr": "127.0.0.1:6060",
+  "log_format": "json"
+}`
+
+func writeTestConfig(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+	return path
+}
+
+func TestConfigFromFile(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		content string
+	}{
+		{"yaml", "bridge.yaml", testYAMLConfig},
+		{"yml", "bridge.yml", testYAMLConfig},
+		{"toml", "bridge.toml", testTOMLConfig},
+		{"json", "bridge.json", testJSONConfig},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts, err := ConfigFromFile(writeTestConfig(t, tt.file, tt.content))
+			if err != nil {
+				t.Fatalf("ConfigFromFile() error = %v", err)
+			}
+
+			cfg := DefaultConfig()
+			for _, opt := range opts {
+				opt(cfg)
+			}
+
+			if cfg.ListenAddr != "127.0.0.1:9656" {
+				t.Errorf("ListenAddr = %q, want %q", cfg.ListenAddr, "127.0.0.1:9656")
+			}
+			if cfg.DatagramPort != 0 {
+				t.Errorf("DatagramPort = %d, want 0", cfg.DatagramPort)
+			}
+			if !cfg.Debug {
+				t.Error("Debug should be true")
+			}
+			if cfg.I2CPAddr != "10.0.0.1:7654" {
+				t.Errorf("I2CPAddr = %q, want %q", cfg.I2CPAddr, "10.0.0.1:7654")
+			}
+			if cfg.I2CPUsername != "i2cpuser" || cfg.I2CPPassword != "i2cppass" {
+				t.Errorf("I2CP credentials = %q/%q, want i2cpuser/i2cppass", cfg.I2CPUsername, cfg.I2CPPassword)
+			}
+			if want := []string{"10.0.0.2:7654", "10.0.0.3:7654"}; !slices.Equal(cfg.I2CPFailoverAddrs, want) {
+				t.Errorf("I2CPFailoverAddrs = %v, want %v", cfg.I2CPFailoverAddrs, want)
+			}
+			if want := (I2CPTLSConfig{CAFile: "/etc/i2p/router-ca.pem"}); cfg.I2CPTLS == nil || *cfg.I2CPTLS != want {
+				t.Errorf("I2CPTLS = %+v, want %+v", cfg.I2CPTLS, want)
+			}
+			if cfg.AuthUsers["alice"] != "secret" {
+				t.Errorf("AuthUsers[alice] = %q, want %q", cfg.AuthUsers["alice"], "secret")
+			}
+			if cfg.HandshakeTimeout != 5*time.Second {
+				t.Errorf("HandshakeTimeout = %v, want 5s", cfg.HandshakeTimeout)
+			}
+			if cfg.CommandTimeout != 2*time.Minute {
+				t.Errorf("CommandTimeout = %v, want 2m", cfg.CommandTimeout)
+			}
+			if cfg.DrainTimeout != 10*time.Second {
+				t.Errorf("DrainTimeout = %v, want 10s", cfg.DrainTimeout)
+			}
+			if cfg.SessionIdleTimeout != 15*time.Minute {
+				t.Errorf("SessionIdleTimeout = %v, want 15m", cfg.SessionIdleTimeout)
+			}
+			if cfg.ReadBufferSize != 4096 {
+				t.Errorf("ReadBufferSize = %d, want 4096", cfg.ReadBufferSize)
+			}
+			if cfg.MaxLineLength != 1024 {
+				t.Errorf("MaxLineLength = %d, want 1024", cfg.MaxLineLength)
+			}
+			if cfg.MaxSessions != 50 || cfg.MaxSessionsPerUser != 5 {
+				t.Errorf("session limits = %d/%d, want 50/5", cfg.MaxSessions, cfg.MaxSessionsPerUser)
+			}
+			if cfg.KeyStoreDir != "/var/lib/sam-bridge/keys" {
+				t.Errorf("KeyStoreDir = %q, want %q", cfg.KeyStoreDir, "/var/lib/sam-bridge/keys")
+			}
+			if cfg.AuditLogFile != "/var/log/sam-audit.log" {
+				t.Errorf("AuditLogFile = %q, want %q", cfg.AuditLogFile, "/var/log/sam-audit.log")
+			}
+			if cfg.AdminAddr != "127.0.0.1:7657" {
+				t.Errorf("AdminAddr = %q, want %q", cfg.AdminAddr, "127.0.0.1:7657")
+			}
+			if cfg.MetricsAddr != "127.0.0.1:9100" {
+				t.Errorf("MetricsAddr = %q, want %q", cfg.MetricsAddr, "127.0.0.1:9100")
+			}
+			if cfg.PprofAddr != "127.0.0.1:6060" {
+				t.Errorf("PprofAddr = %q, want %q", cfg.PprofAddr, "127.0.0.1:6060")
+			}
+			if cfg.LogFormat != LogFormatJSON {
+				t.Errorf("LogFormat = %q, want %q", cfg.LogFormat, LogFormatJSON)
+			}
+		})
+	}
+}
+
+func TestConfigFromFile_PartialKeepsDefaults(t *testing.T) {
+	opts, err := ConfigFromFile(writeTestConfig(t, "bridge.yaml", `listen: ":9000"`))
+	if err != nil {
+		t.Fatalf("ConfigFromFile() error = %v", err)
+	}
+
+	cfg := DefaultConfig()
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	if cfg.ListenAddr != ":9000" {
+		t.Errorf("ListenAddr = %q, want %q", cfg.ListenAddr, ":9000")
+	}
+	if cfg.I2CPAddr != DefaultI2CPAddr {
+		t.Errorf("I2CPAddr = %q, want default %q", cfg.I2CPAddr, DefaultI2CPAddr)
+	}
+	if cfg.DatagramPort != DefaultDatagramPort {
+		t.Errorf("DatagramPort = %d, want default %d", cfg.DatagramPort, DefaultDatagramPort)
+	}
+}
+
+func TestConfigFromFile_ForwardRetry(t *testing.T) {
+	opts, err := ConfigFromFile(writeTestConfig(t, "bridge.yaml", "forward_retry:\n  attempts: 10\n  backoff: 100ms\n"))
+	if err != nil {
+		t.Fatalf("ConfigFromFile() error = %v", err)
+	}
+
+	cfg := DefaultConfig()
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	want := handler.ForwardRetry{Attempts: 10, Backoff: 100 * time.Millisecond, MaxBackoff: handler.DefaultForwardRetry.MaxBackoff}
+	if cfg.ForwardRetry != want {
+		t.Errorf("ForwardRetry = %+v, want %+v", cfg.ForwardRetry, want)
+	}
+}
+
+func TestConfigFromFile_HTTPProxy(t *testing.T) {
+	content := "http_proxy:\n  addr: 127.0.0.1:4444\n  user: proxy\n  password: secret\n  outproxy: exit.example.i2p:4444\n"
+	opts, err := ConfigFromFile(writeTestConfig(t, "bridge.yaml", content))
+	if err != nil {
+		t.Fatalf("ConfigFromFile() error = %v", err)
+	}
+
+	cfg := DefaultConfig()
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	if cfg.HTTPProxyAddr != "127.0.0.1:4444" {
+		t.Errorf("HTTPProxyAddr = %q, want %q", cfg.HTTPProxyAddr, "127.0.0.1:4444")
+	}
+	if cfg.HTTPProxyUser != "proxy" || cfg.HTTPProxyPassword != "secret" {
+		t.Errorf("HTTP proxy credentials = %q/%q, want proxy/secret", cfg.HTTPProxyUser, cfg.HTTPProxyPassword)
+	}
+	if cfg.HTTPOutproxy != "exit.example.i2p:4444" {
+		t.Errorf("HTTPOutproxy = %q, want %q", cfg.HTTPOutproxy, "exit.example.i2p:4444")
+	}
+}
+
+func TestConfigFromFile_BOB(t *testing.T) {
+	content := "bob:\n  addr: 127.0.0.1:2827\n  user: bob\n  password: secret\n"
+	opts, err := ConfigFromFile(writeTestConfig(t, "bridge.yaml", content))
+	if err != nil {
+		t.Fatalf("ConfigFromFile() error = %v", err)
+	}
+
+	cfg := DefaultConfig()
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	if cfg.BOBAddr != "127.0.0.1:2827" {
+		t.Errorf("BOBAddr = %q, want %q", cfg.BOBAddr, "127.0.0.1:2827")
+	}
+	if cfg.BOBUser != "bob" || cfg.BOBPassword != "secret" {
+		t.Errorf("BOB credentials = %q/%q, want bob/secret", cfg.BOBUser, cfg.BOBPassword)
+	}
+}
+
+func TestConfigFromFile_Tunnels(t *testing.T) {
+	content := `tunnels:
+  user: tunnel
+  password: secret
+  client:
+    - name: irc
+      listen: 127.0.0.1:6668
+      destination: irc.postman.i2p:6667
+  server:
+    - name: web
+      keyfile: /var/lib/sam-bridge/web.dat
+      target: 127.0.0.1:8080
+      options:
+        inbound.length: "2"
+`
+	opts, err := ConfigFromFile(writeTestConfig(t, "bridge.yaml", content))
+	if err != nil {
+		t.Fatalf("ConfigFromFile() error = %v", err)
+	}
+
+	cfg := DefaultConfig()
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	want := []tunnels.Tunnel{
+		{Name: "irc", Type: tunnels.TypeClient, Listen: "127.0.0.1:6668", Destination: "irc.postman.i2p:6667"},
+		{Name: "web", Type: tunnels.TypeServer, Target: "127.0.0.1:8080", Keyfile: "/var/lib/sam-bridge/web.dat",
+			Options: map[string]string{"inbound.length": "2"}},
+	}
+	if !reflect.DeepEqual(cfg.Tunnels, want) {
+		t.Errorf("Tunnels = %+v, want %+v", cfg.Tunnels, want)
+	}
+	if cfg.TunnelUser != "tunnel" || cfg.TunnelPassword != "secret" {
+		t.Errorf("tunnel credentials = %q/%q, want tunnel/secret", cfg.TunnelUser, cfg.TunnelPassword)
+	}
+}
+
+func TestConfigFromFile_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		content string
+		wantErr error
+	}{
+		{"unknown extension", "bridge.ini", "listen=:7656", ErrUnknownConfigFormat},
+		{"incomplete tls", "bridge.yaml", "tls:\n  cert: /tmp/cert.pem\n", ErrIncompleteTLSConfig},
+		{"incomplete i2cp tls", "bridge.yaml", "i2cp:\n  tls:\n    enabled: true\n    key: /tmp/key.pem\n", ErrIncompleteTLSConfig},
+		{"unknown field", "bridge.json", `{"listne": ":7656"}`, nil},
+		{"bad duration", "bridge.yaml", "timeouts:\n  command: soon\n", nil},
+		{"bad forward backoff", "bridge.yaml", "forward_retry:\n  backoff: soon\n", nil},
+		{"bad tls version", "bridge.yaml", "tls:\n  min_version: \"1.4\"\n", ErrInvalidTLSPolicy},
+		{"insecure cipher suite", "bridge.yaml", "tls:\n  cipher_suites: [TLS_RSA_WITH_RC4_128_SHA]\n", ErrInvalidTLSPolicy},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ConfigFromFile(writeTestConfig(t, tt.file, tt.content))
+			if err == nil {
+				t.Fatal("ConfigFromFile() error = nil, want error")
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Errorf("ConfigFromFile() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestConfigFromFile_Missing(t *testing.T) {
+	if _, err := ConfigFromFile(filepath.Join(t.TempDir(), "missing.yaml")); err == nil {
+		t.Error("ConfigFromFile() on missing file should return error")
+	}
+}
@@ -0,0 +1,406 @@
+package embedding
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-i2p/go-sam-bridge/lib/protocol"
+)
+
+// frontendCommandTimeout bounds each SAM command a proxy frontend sends,
+// including SESSION CREATE while tunnels build and STREAM CONNECT while
+// the destination is looked up and reached.
+const frontendCommandTimeout = 2 * time.Minute
+
+// streamDialer opens I2P streams for a proxy frontend by speaking SAM to
+// the bridge's own server over in-process pipes, so the frontend is
+// subject to the same authentication, limits and logging as any SAM
+// client. All streams share one STREAM session, created on first use and
+// again after it is lost.
+type streamDialer struct {
+	// name prefixes the ID of the shared session, such as "socks".
+	name string
+
+	// serve hands the bridge end of each pipe to the SAM server.
+	serve func(net.Conn)
+
+	// remote is reported as the peer address of the proxy's SAM
+	// connections.
+	remote net.Addr
+
+	// user and password are sent with HELLO when set.
+	user     string
+	password string
+
+	// keys are the private keys of the session's destination. A transient
+	// destination is used when empty.
+	keys string
+
+	// options are I2CP options added to SESSION CREATE.
+	options map[string]string
+
+	// lost, if set, is called when the shared session's control socket
+	// closes or the session is otherwise found to be gone.
+	lost func()
+
+	mu      sync.Mutex
+	id      string
+	version string
+	control *samPipe
+}
+
+// dial opens a stream to port on host through the shared session.
+func (d *streamDialer) dial(ctx context.Context, host string, port int) (net.Conn, error) {
+	id, version, err := d.session(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	c, _, err := d.connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	cmd := protocol.NewCommand(protocol.VerbStream, protocol.ActionConnect).
+		WithOption("ID", id).
+		WithOption("DESTINATION", host).
+		WithOption("SILENT", "false")
+	if port > 0 && protocol.VersionSupportsPortInfo(version) {
+		cmd.WithOption("TO_PORT", strconv.Itoa(port))
+	}
+	reply, err := c.send(ctx, cmd)
+	if err == nil {
+		err = samResultError(reply)
+	}
+	if err != nil {
+		c.Close()
+		if reply != nil && reply.Get("RESULT") == protocol.ResultInvalidID {
+			d.reset(id)
+		}
+		return nil, fmt.Errorf("STREAM CONNECT: %w", err)
+	}
+	if err := c.SetDeadline(time.Time{}); err != nil {
+		c.Close()
+		return nil, err
+	}
+	return c, nil
+}
+
+// session returns the ID and SAM version of the shared STREAM session,
+// creating it if there is none.
+func (d *streamDialer) session(ctx context.Context) (id, version string, err error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.control != nil {
+		return d.id, d.version, nil
+	}
+
+	var suffix [4]byte
+	if _, err := rand.Read(suffix[:]); err != nil {
+		return "", "", err
+	}
+	id = d.name + "-" + hex.EncodeToString(suffix[:])
+
+	c, version, err := d.connect(ctx)
+	if err != nil {
+		return "", "", err
+	}
+	create := protocol.NewCommand(protocol.VerbSession, protocol.ActionCreate).
+		WithOption("STYLE", "STREAM").
+		WithOption("ID", id)
+	if d.keys != "" {
+		create.WithOption("DESTINATION", d.keys)
+	} else {
+		create.WithOption("DESTINATION", "TRANSIENT").WithOption("SIGNATURE_TYPE", "7")
+	}
+	names := make([]string, 0, len(d.options))
+	for name := range d.options {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		create.WithOption(name, d.options[name])
+	}
+	reply, err := c.send(ctx, create)
+	if err == nil {
+		err = samResultError(reply)
+	}
+	if err != nil {
+		c.Close()
+		return "", "", fmt.Errorf("SESSION CREATE: %w", err)
+	}
+	if err := c.SetDeadline(time.Time{}); err != nil {
+		c.Close()
+		return "", "", err
+	}
+
+	d.id, d.version, d.control = id, version, c
+	go d.watch(c, id)
+	return id, version, nil
+}
+
+// forward asks the shared session to deliver incoming streams to port on
+// host, prefixing each with the peer's destination unless silent. The
+// returned connection keeps the forward in place until it is closed;
+// losing it resets the session.
+func (d *streamDialer) forward(ctx context.Context, host string, port int, silent bool) (*samPipe, error) {
+	id, _, err := d.session(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	c, _, err := d.connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	reply, err := c.send(ctx, protocol.NewCommand(protocol.VerbStream, protocol.ActionForward).
+		WithOption("ID", id).
+		WithOption("PORT", strconv.Itoa(port)).
+		WithOption("HOST", host).
+		WithOption("SILENT", strconv.FormatBool(silent)))
+	if err == nil {
+		err = samResultError(reply)
+	}
+	if err != nil {
+		c.Close()
+		return nil, fmt.Errorf("STREAM FORWARD: %w", err)
+	}
+	if err := c.SetDeadline(time.Time{}); err != nil {
+		c.Close()
+		return nil, err
+	}
+	go func() {
+		keepalive(c)
+		d.reset(id)
+	}()
+	return c, nil
+}
+
+// lookup resolves name to a Base64 destination with NAMING LOOKUP.
+func (d *streamDialer) lookup(ctx context.Context, name string) (string, error) {
+	c, _, err := d.connect(ctx)
+	if err != nil {
+		return "", err
+	}
+	defer c.Close()
+
+	reply, err := c.send(ctx, protocol.NewCommand(protocol.VerbNaming, protocol.ActionLookup).
+		WithOption("NAME", name))
+	if err == nil {
+		err = samResultError(reply)
+	}
+	if err != nil {
+		return "", fmt.Errorf("NAMING LOOKUP: %w", err)
+	}
+	return reply.Get("VALUE"), nil
+}
+
+// watch answers keepalive PINGs on the session's control socket and
+// forgets the session once the socket closes.
+func (d *streamDialer) watch(c *samPipe, id string) {
+	defer d.reset(id)
+	keepalive(c)
+}
+
+// keepalive answers PINGs on an idle SAM connection until it closes.
+func keepalive(c *samPipe) {
+	for {
+		line, err := c.reader.ReadString('\n')
+		if err != nil {
+			return
+		}
+		if strings.HasPrefix(line, protocol.VerbPing) {
+			pong := "PONG" + strings.TrimPrefix(strings.TrimRight(line, "\r\n"), "PING")
+			if _, err := c.Write([]byte(pong + "\n")); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// reset closes the session id, if it is still the shared session, so the
+// next dial creates a new one.
+func (d *streamDialer) reset(id string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.control != nil && d.id == id {
+		d.control.Close()
+		d.control = nil
+		if d.lost != nil {
+			d.lost()
+		}
+	}
+}
+
+// close closes the shared session, if any.
+func (d *streamDialer) close() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.control != nil {
+		d.control.Close()
+		d.control = nil
+	}
+}
+
+// tunnelSession is a STREAM session with its own keys and options, and
+// optionally a STREAM FORWARD, as run by BOB and configured tunnels.
+type tunnelSession struct {
+	dialer  *streamDialer
+	forward *samPipe
+	done    chan struct{}
+	once    sync.Once
+}
+
+// startTunnelSession creates the session for a tunnel named name on the
+// connection settings of base. When port is non-zero, incoming streams
+// are forwarded to port on host, prefixed by the peer's destination
+// unless silent.
+func startTunnelSession(ctx context.Context, base *streamDialer, name, keys string, options map[string]string, host string, port int, silent bool) (*tunnelSession, error) {
+	t := &tunnelSession{done: make(chan struct{})}
+	t.dialer = &streamDialer{
+		name:     base.name + "-" + name,
+		serve:    base.serve,
+		remote:   base.remote,
+		user:     base.user,
+		password: base.password,
+		keys:     keys,
+		options:  options,
+		lost:     t.lose,
+	}
+	if _, _, err := t.dialer.session(ctx); err != nil {
+		return nil, err
+	}
+	if port != 0 {
+		forward, err := t.dialer.forward(ctx, host, port, silent)
+		if err != nil {
+			t.Close()
+			return nil, err
+		}
+		t.forward = forward
+	}
+	return t, nil
+}
+
+// Dial opens a stream to port on host through the session.
+func (t *tunnelSession) Dial(ctx context.Context, host string, port int) (net.Conn, error) {
+	return t.dialer.dial(ctx, host, port)
+}
+
+// Done is closed once the session or its forward is lost.
+func (t *tunnelSession) Done() <-chan struct{} {
+	return t.done
+}
+
+// Close ends the forward and the session.
+func (t *tunnelSession) Close() error {
+	if t.forward != nil {
+		t.forward.Close()
+	}
+	t.dialer.close()
+	t.lose()
+	return nil
+}
+
+// lose closes done.
+func (t *tunnelSession) lose() {
+	t.once.Do(func() { close(t.done) })
+}
+
+// connect opens a SAM connection to the bridge and completes HELLO,
+// returning the negotiated version.
+func (d *streamDialer) connect(ctx context.Context) (*samPipe, string, error) {
+	client, server := net.Pipe()
+	d.serve(&remoteAddrConn{Conn: server, remote: d.remote})
+	c := &samPipe{Conn: client, reader: bufio.NewReader(client), parser: protocol.NewParser()}
+
+	hello := protocol.NewCommand(protocol.VerbHello, protocol.ActionVersion).
+		WithOption("MIN", protocol.SAMVersionMin).
+		WithOption("MAX", protocol.SAMVersionMax)
+	if d.user != "" {
+		hello.WithOption("USER", d.user).WithOption("PASSWORD", d.password)
+	}
+	reply, err := c.send(ctx, hello)
+	if err == nil {
+		err = samResultError(reply)
+	}
+	if err != nil {
+		c.Close()
+		return nil, "", fmt.Errorf("HELLO: %w", err)
+	}
+	return c, reply.Get("VERSION"), nil
+}
+
+// samPipe is the client end of an in-process SAM connection. After
+// STREAM CONNECT succeeds it carries the stream, with reads served from
+// the buffered reader first so no data is lost.
+type samPipe struct {
+	net.Conn
+	reader *bufio.Reader
+	parser *protocol.Parser
+}
+
+// Read reads stream data, starting with any the reply reader buffered.
+func (c *samPipe) Read(p []byte) (int, error) {
+	return c.reader.Read(p)
+}
+
+// send writes cmd and returns the parsed reply, answering keepalive PINGs
+// while waiting. It gives up after frontendCommandTimeout or when ctx is
+// done.
+func (c *samPipe) send(ctx context.Context, cmd *protocol.Command) (*protocol.Command, error) {
+	if err := c.SetDeadline(time.Now().Add(frontendCommandTimeout)); err != nil {
+		return nil, err
+	}
+	stop := context.AfterFunc(ctx, func() { c.SetDeadline(time.Now()) })
+	defer stop()
+
+	if _, err := c.Write(cmd.Bytes()); err != nil {
+		return nil, err
+	}
+	for {
+		text, err := c.reader.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		reply, err := c.parser.Parse(strings.TrimRight(text, "\r\n"))
+		if err != nil || reply.Verb != protocol.VerbPing {
+			return reply, err
+		}
+		pong := "PONG" + strings.TrimPrefix(reply.Raw, "PING")
+		if _, err := c.Write([]byte(pong + "\n")); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// remoteAddrConn reports remote as its peer address, so the SAM server
+// attributes in-process connections to the frontend that made them.
+type remoteAddrConn struct {
+	net.Conn
+	remote net.Addr
+}
+
+// RemoteAddr returns the frontend's address.
+func (c *remoteAddrConn) RemoteAddr() net.Addr {
+	return c.remote
+}
+
+// samResultError returns an error describing reply unless RESULT=OK.
+func samResultError(reply *protocol.Command) error {
+	result := reply.Get("RESULT")
+	if result == protocol.ResultOK {
+		return nil
+	}
+	if msg := reply.Get("MESSAGE"); msg != "" {
+		return fmt.Errorf("%s: %s", result, msg)
+	}
+	return fmt.Errorf("%s", result)
+}
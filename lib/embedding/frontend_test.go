@@ -0,0 +1,102 @@
+package embedding
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeSAMServer answers the commands a streamDialer sends with RESULT=OK
+// and echoes stream data after STREAM CONNECT. It records every command.
+type fakeSAMServer struct {
+	mu       sync.Mutex
+	commands []string
+}
+
+func (f *fakeSAMServer) serve(conn net.Conn) {
+	go func() {
+		defer conn.Close()
+		r := bufio.NewReader(conn)
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				return
+			}
+			line = strings.TrimSpace(line)
+			f.mu.Lock()
+			f.commands = append(f.commands, line)
+			f.mu.Unlock()
+
+			switch {
+			case strings.HasPrefix(line, "HELLO"):
+				io.WriteString(conn, "HELLO REPLY RESULT=OK VERSION=3.3\n")
+			case strings.HasPrefix(line, "SESSION CREATE"):
+				io.WriteString(conn, "SESSION STATUS RESULT=OK DESTINATION=priv\n")
+			case strings.HasPrefix(line, "STREAM FORWARD"):
+				io.WriteString(conn, "STREAM STATUS RESULT=OK\n")
+			case strings.HasPrefix(line, "NAMING LOOKUP"):
+				io.WriteString(conn, "NAMING REPLY RESULT=OK NAME=example.i2p VALUE=exampledest\n")
+			case strings.HasPrefix(line, "STREAM CONNECT"):
+				io.WriteString(conn, "STREAM STATUS RESULT=OK\n")
+				io.Copy(conn, r)
+				return
+			}
+		}
+	}()
+}
+
+func (f *fakeSAMServer) count(prefix string) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	n := 0
+	for _, c := range f.commands {
+		if strings.HasPrefix(c, prefix) {
+			n++
+		}
+	}
+	return n
+}
+
+func TestStreamDialer(t *testing.T) {
+	sam := &fakeSAMServer{}
+	d := &streamDialer{name: "socks", serve: sam.serve, remote: &net.TCPAddr{IP: net.IPv4(127, 0, 0, 1)}, user: "socks", password: "secret"}
+	defer d.close()
+
+	for i := 0; i < 2; i++ {
+		conn, err := d.dial(context.Background(), "example.i2p", 80)
+		if err != nil {
+			t.Fatalf("dial() error = %v", err)
+		}
+		conn.Write([]byte("hi"))
+		buf := make([]byte, 2)
+		if _, err := io.ReadFull(conn, buf); err != nil || string(buf) != "hi" {
+			t.Errorf("echo = %q, %v; want %q", buf, err, "hi")
+		}
+		conn.Close()
+	}
+
+	// Both streams share one session
+	if n := sam.count("SESSION CREATE"); n != 1 {
+		t.Errorf("SESSION CREATE sent %d times, want 1", n)
+	}
+	if n := sam.count("STREAM CONNECT"); n != 2 {
+		t.Errorf("STREAM CONNECT sent %d times, want 2", n)
+	}
+	sam.mu.Lock()
+	defer sam.mu.Unlock()
+	for _, c := range sam.commands {
+		if strings.HasPrefix(c, "HELLO") && !strings.Contains(c, "USER=socks") {
+			t.Errorf("HELLO = %q, want USER=socks", c)
+		}
+		if strings.HasPrefix(c, "SESSION CREATE") && !strings.Contains(c, "ID=socks-") {
+			t.Errorf("SESSION CREATE = %q, want ID=socks-...", c)
+		}
+		if strings.HasPrefix(c, "STREAM CONNECT") && (!strings.Contains(c, "DESTINATION=example.i2p") || !strings.Contains(c, "TO_PORT=80")) {
+			t.Errorf("STREAM CONNECT = %q, want DESTINATION=example.i2p TO_PORT=80", c)
+		}
+	}
+}
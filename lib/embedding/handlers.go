@@ -2,8 +2,10 @@ package embedding
 
 import (
 	"github.com/go-i2p/go-sam-bridge/lib/bridge"
+	"github.com/go-i2p/go-sam-bridge/lib/destination"
 	"github.com/go-i2p/go-sam-bridge/lib/handler"
 	"github.com/go-i2p/go-sam-bridge/lib/session"
+	"github.com/sirupsen/logrus"
 )
 
 // DefaultHandlerRegistrar returns a HandlerRegistrarFunc that registers
@@ -12,7 +14,7 @@ import (
 //
 // This registers handlers for:
 //   - HELLO VERSION (handshake)
-//   - SESSION CREATE/ADD/REMOVE
+//   - SESSION CREATE/ADD/REMOVE/STATS
 //   - STREAM CONNECT/ACCEPT/FORWARD
 //   - DATAGRAM SEND
 //   - RAW SEND
@@ -21,6 +23,7 @@ import (
 //   - PING
 //   - QUIT/STOP/EXIT
 //   - HELP
+//   - DUMP (diagnostics, for authenticated users)
 //   - AUTH ENABLE/DISABLE/ADD/REMOVE (if authentication enabled)
 func DefaultHandlerRegistrar() HandlerRegistrarFunc {
 	return func(router *handler.Router, deps *Dependencies) {
@@ -28,29 +31,36 @@ func DefaultHandlerRegistrar() HandlerRegistrarFunc {
 
 		// Register HELLO handler (must be first command per SAMv3.md)
 		helloConfig := handler.DefaultHelloConfig()
+		if deps.AuthFunc != nil {
+			helloConfig.RequireAuth = true
+			helloConfig.AuthFunc = deps.AuthFunc
+		}
 		helloHandler := handler.NewHelloHandler(helloConfig)
 		router.Register("HELLO VERSION", helloHandler)
 		log.Debug("Registered HELLO VERSION handler")
 
 		// Create STREAM handlers
-		streamConnector := handler.NewStreamingConnector()
-		streamAcceptor := handler.NewStreamingAcceptor()
-		streamForwarder := handler.NewStreamingForwarder()
+		streamConnector, streamAcceptor, streamForwarder := NewStreamHandlers(deps)
 
 		// Register SESSION handler with I2CP provider for tunnel waiting
 		sessionHandler := handler.NewSessionHandler(deps.DestManager)
 		if deps.I2CPProvider != nil {
 			sessionHandler.SetI2CPProvider(deps.I2CPProvider)
 		}
+		sessionHandler.SetSessionDefaults(deps.SessionDefaults)
+		sessionHandler.SetKeyStore(deps.KeyStore)
 
 		// Set session created callback to wire StreamManager per session
 		sessionHandler.SetSessionCreatedCallback(createStreamManagerCallback(
 			deps, streamConnector, streamAcceptor, streamForwarder,
 		))
+		sessionHandler.SetTunnelEventCallback(TunnelEventLogger(deps))
 
 		router.Register("SESSION CREATE", sessionHandler)
 		router.Register("SESSION ADD", sessionHandler)
 		router.Register("SESSION REMOVE", sessionHandler)
+		router.Register("SESSION STATS", sessionHandler)
+		router.Register("SESSION LIST", sessionHandler)
 		log.Debug("Registered SESSION handlers")
 
 		// Register STREAM handlers
@@ -88,10 +98,42 @@ func DefaultHandlerRegistrar() HandlerRegistrarFunc {
 		handler.RegisterHelpHandler(router)
 		log.Debug("Registered utility handlers")
 
+		// Register DUMP handler
+		dumpHandler := handler.NewDumpHandler()
+		dumpHandler.SetForwarder(streamForwarder)
+		if deps.I2CPProvider != nil {
+			dumpHandler.SetI2CPProvider(deps.I2CPProvider)
+		}
+		handler.RegisterDumpHandler(router, dumpHandler)
+		log.Debug("Registered DUMP handler")
+
 		log.WithField("count", router.Count()).Info("All SAM command handlers registered")
 	}
 }
 
+// NewStreamHandlers creates the STREAM CONNECT, ACCEPT and FORWARD
+// implementations configured from deps: the accept backlog, the socket
+// options and retry policy for forwarding targets, forwarding counters
+// and error reporting. Custom registrars that wire their own stream
+// managers should create their handlers here so these settings apply.
+func NewStreamHandlers(deps *Dependencies) (*handler.StreamingConnector, *handler.StreamingAcceptor, *handler.StreamingForwarder) {
+	connector := handler.NewStreamingConnector()
+
+	acceptor := handler.NewStreamingAcceptor()
+	acceptor.SetAcceptBacklog(deps.AcceptBacklog, deps.AcceptBacklogTimeout)
+
+	forwarder := handler.NewStreamingForwarder()
+	forwarder.SetSocketOptions(deps.SocketOptions)
+	forwarder.SetForwardRetry(deps.ForwardRetry)
+	forwarder.SetStats(deps.ForwardStats)
+	if deps.ReportError != nil {
+		forwarder.SetErrorHandler(func(err error) {
+			deps.ReportError(SourceForwarder, err)
+		})
+	}
+	return connector, acceptor, forwarder
+}
+
 // RegisterAuthHandlers adds authentication handlers to a router.
 // Call this separately when authentication is enabled.
 func RegisterAuthHandlers(router *handler.Router, authStore *bridge.AuthStore, deps *Dependencies) {
@@ -115,6 +157,42 @@ func createStreamManagerCallback(
 
 		// StreamManager creation would happen here if we had access to go-streaming
 		// For now, this is a placeholder that can be extended when I2CP integration is available
-		deps.Logger.WithField("sessionID", sess.ID()).Debug("STREAM session created")
+		deps.Logger.WithFields(SessionFields(sess)).
+			WithField("b32", sessionB32(sess)).
+			Debug("STREAM session created")
+	}
+}
+
+// TunnelEventLogger returns the SESSION handler's tunnel event callback
+// configured from deps: builds are logged at info level and expiries and
+// failures as warnings, with SessionFields, sampled per event type as set
+// by WithLogSampling. Custom registrars that create their own SESSION
+// handler should install it so tunnel logs match the default.
+func TunnelEventLogger(deps *Dependencies) handler.TunnelEventCallback {
+	log, sampler := deps.Logger, deps.logSampler
+	return func(sess session.Session, ev session.TunnelEvent) {
+		entry := sampler.entry("tunnels."+ev.Type.String(), log.WithFields(SessionFields(sess)).WithFields(logrus.Fields{
+			"tunnels": ev.Type.String(),
+			"reason":  ev.Reason,
+		}))
+		if entry == nil {
+			return
+		}
+		if ev.Type == session.TunnelBuilt {
+			entry.Info("Session tunnels built")
+		} else {
+			entry.Warn("Session tunnels lost")
+		}
+	}
+}
+
+// sessionB32 returns the .b32.i2p address of sess for logging, or the
+// empty string if it has no parseable destination.
+func sessionB32(sess session.Session) string {
+	dest := sess.Destination()
+	if dest == nil {
+		return ""
 	}
+	b32, _ := destination.Base32FromBase64(string(dest.PublicKey))
+	return b32
 }
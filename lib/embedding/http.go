@@ -0,0 +1,71 @@
+package embedding
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"time"
+)
+
+// httpReadHeaderTimeout bounds how long the bridge's HTTP endpoints wait
+// for request headers.
+const httpReadHeaderTimeout = 10 * time.Second
+
+// httpEndpoint is an auxiliary HTTP server run alongside the bridge,
+// such as the admin API or the metrics endpoint.
+type httpEndpoint struct {
+	server   *http.Server
+	listener net.Listener
+
+	// stop, if set, ends background work tied to the endpoint when it
+	// closes.
+	stop func()
+}
+
+// startHTTPEndpoint listens on addr and serves handler in the background.
+// Serve failures are reported to Bridge.Errors under source.
+func (b *Bridge) startHTTPEndpoint(addr string, handler http.Handler, source string) (*httpEndpoint, error) {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	e := &httpEndpoint{
+		server: &http.Server{
+			Handler:           handler,
+			ReadHeaderTimeout: httpReadHeaderTimeout,
+		},
+		listener: ln,
+	}
+
+	go func() {
+		if err := e.server.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			b.deps.ReportError(source, err)
+		}
+	}()
+
+	b.deps.Logger.WithField("addr", ln.Addr()).WithField("endpoint", source).Info("HTTP endpoint started")
+	return e, nil
+}
+
+// addr returns the endpoint's listen address, or "" if e is nil.
+func (e *httpEndpoint) addr() string {
+	if e == nil {
+		return ""
+	}
+	return e.listener.Addr().String()
+}
+
+// close shuts the endpoint down. It is a no-op if e is nil.
+// The listener is closed directly because Server.Close only closes
+// listeners that Serve has already begun tracking.
+func (e *httpEndpoint) close() error {
+	if e == nil {
+		return nil
+	}
+	err := e.server.Close()
+	e.listener.Close()
+	if e.stop != nil {
+		e.stop()
+	}
+	return err
+}
@@ -0,0 +1,75 @@
+package embedding
+
+import (
+	"net"
+
+	"github.com/go-i2p/go-sam-bridge/lib/httpproxy"
+)
+
+// httpProxyFrontend is the HTTP proxy started by WithHTTPProxyAddr.
+type httpProxyFrontend struct {
+	server   *httpproxy.Server
+	listener net.Listener
+	dialer   *streamDialer
+}
+
+// HTTPProxyAddr returns the address the HTTP proxy is listening on, or an
+// empty string if it is not running.
+func (b *Bridge) HTTPProxyAddr() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.httpProxy == nil {
+		return ""
+	}
+	return b.httpProxy.listener.Addr().String()
+}
+
+// startHTTPProxy serves the HTTP proxy on Config.HTTPProxyAddr.
+// It is a no-op when no HTTP proxy address is configured.
+// Callers must hold b.mu.
+func (b *Bridge) startHTTPProxy() error {
+	if b.config.HTTPProxyAddr == "" {
+		return nil
+	}
+	ln, err := net.Listen("tcp", b.config.HTTPProxyAddr)
+	if err != nil {
+		return err
+	}
+
+	dialer := &streamDialer{
+		name:     "httpproxy",
+		serve:    b.server.ServeConn,
+		remote:   ln.Addr(),
+		user:     b.config.HTTPProxyUser,
+		password: b.config.HTTPProxyPassword,
+	}
+	server := httpproxy.NewServer(dialer.dial)
+	server.Outproxy = b.config.HTTPOutproxy
+	server.ReadHeaderTimeout = b.config.HandshakeTimeout
+	server.ErrorHandler = func(err error) {
+		b.deps.ReportError(SourceHTTPProxy, err)
+	}
+	go func() {
+		if err := server.Serve(ln); err != nil {
+			b.deps.ReportError(SourceHTTPProxy, err)
+		}
+	}()
+
+	b.httpProxy = &httpProxyFrontend{server: server, listener: ln, dialer: dialer}
+	b.deps.Logger.WithField("addr", ln.Addr()).Info("HTTP proxy started")
+	return nil
+}
+
+// stopHTTPProxy closes the HTTP proxy and its STREAM session, if running.
+func (b *Bridge) stopHTTPProxy() {
+	b.mu.Lock()
+	p := b.httpProxy
+	b.httpProxy = nil
+	b.mu.Unlock()
+
+	if p == nil {
+		return
+	}
+	p.server.Close()
+	p.dialer.close()
+}
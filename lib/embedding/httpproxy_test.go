@@ -0,0 +1,47 @@
+package embedding
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestBridgeWithHTTPProxyAddr(t *testing.T) {
+	b, err := New(WithListenAddr("127.0.0.1:0"), WithI2CPProvider(&mockI2CPProvider{}), WithDatagramPort(0), WithHTTPProxyAddr("127.0.0.1:0"))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if addr := b.HTTPProxyAddr(); addr != "" {
+		t.Errorf("HTTPProxyAddr() before Start = %q, want empty", addr)
+	}
+
+	if err := b.Start(context.Background()); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	addr := b.HTTPProxyAddr()
+	if addr == "" {
+		t.Fatal("HTTPProxyAddr() while running is empty")
+	}
+
+	// Without an outproxy, clearnet hosts are refused before any SAM
+	// traffic.
+	client := &http.Client{
+		Transport: &http.Transport{Proxy: http.ProxyURL(&url.URL{Scheme: "http", Host: addr})},
+		Timeout:   2 * time.Second,
+	}
+	resp, err := client.Get("http://example.com/")
+	if err != nil {
+		t.Fatalf("GET through proxy error = %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusForbidden {
+		t.Errorf("clearnet GET status = %d, want %d", resp.StatusCode, http.StatusForbidden)
+	}
+
+	b.Stop(context.Background())
+	if addr := b.HTTPProxyAddr(); addr != "" {
+		t.Errorf("HTTPProxyAddr() after Stop = %q, want empty", addr)
+	}
+}
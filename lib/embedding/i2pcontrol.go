@@ -0,0 +1,389 @@
+package embedding
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/go-i2p/go-sam-bridge/lib/bridge"
+	"github.com/go-i2p/go-sam-bridge/lib/session"
+)
+
+// DefaultI2PControlPassword is the I2PControl password used when
+// Config.I2PControlPassword is empty. It is the well-known default of
+// I2PControl clients, so Validate rejects it on anything but a loopback
+// address.
+const DefaultI2PControlPassword = "itoopie"
+
+// i2pControlAPIVersion is the only I2PControl API version served.
+const i2pControlAPIVersion = 1
+
+// i2pControlTokenTTL is how long a token from Authenticate stays valid.
+const i2pControlTokenTTL = 24 * time.Hour
+
+// i2pControlMaxTokens bounds the tokens a handler holds; Authenticate
+// evicts the oldest beyond it.
+const i2pControlMaxTokens = 256
+
+// i2pControlSweepInterval is how often the served API drops expired
+// tokens.
+const i2pControlSweepInterval = 10 * time.Minute
+
+// i2pControlMaxRequestLength bounds the size of a request body.
+const i2pControlMaxRequestLength = 64 * 1024
+
+// JSON-RPC 2.0 and I2PControl error codes.
+const (
+	i2pControlParseError      = -32700
+	i2pControlInvalidRequest  = -32600
+	i2pControlMethodNotFound  = -32601
+	i2pControlInvalidParams   = -32602
+	i2pControlInternalError   = -32603
+	i2pControlInvalidPassword = -32001
+	i2pControlNoToken         = -32002
+	i2pControlUnknownToken    = -32003
+	i2pControlExpiredToken    = -32004
+	i2pControlNoAPIVersion    = -32005
+	i2pControlUnsupportedAPI  = -32006
+)
+
+// I2PControl i2p.router.net.status values.
+const (
+	i2pControlNetStatusOK        = 0
+	i2pControlNetStatusI2CPError = 8
+)
+
+// i2pControlRates are the statistics served by GetRate, keyed by the Stat
+// parameter. Session totals cover the sessions open at the time of the
+// request.
+var i2pControlRates = map[string]func(b *Bridge) float64{
+	"sam.connections":        func(b *Bridge) float64 { return float64(b.Stats().ConnectionsActive) },
+	"sam.connections.total":  func(b *Bridge) float64 { return float64(b.Stats().ConnectionsTotal) },
+	"sam.commands.total":     func(b *Bridge) float64 { return float64(b.Stats().Commands) },
+	"sam.sessions":           func(b *Bridge) float64 { return float64(b.deps.Registry.Count()) },
+	"sam.bytes.sent":         sessionTotal(func(s session.StatsSnapshot) uint64 { return s.BytesSent }),
+	"sam.bytes.received":     sessionTotal(func(s session.StatsSnapshot) uint64 { return s.BytesReceived }),
+	"sam.streams":            sessionTotal(func(s session.StatsSnapshot) uint64 { return s.Streams }),
+	"sam.datagrams.sent":     sessionTotal(func(s session.StatsSnapshot) uint64 { return s.DatagramsSent }),
+	"sam.datagrams.received": sessionTotal(func(s session.StatsSnapshot) uint64 { return s.DatagramsReceived }),
+	"sam.datagrams.dropped":  sessionTotal(func(s session.StatsSnapshot) uint64 { return s.DatagramsDropped }),
+}
+
+// sessionTotal returns a GetRate statistic summing value over the open
+// sessions.
+func sessionTotal(value func(session.StatsSnapshot) uint64) func(b *Bridge) float64 {
+	return func(b *Bridge) float64 {
+		var total uint64
+		for _, id := range b.deps.Registry.All() {
+			if stats, ok := b.SessionStats(id); ok {
+				total += value(stats)
+			}
+		}
+		return float64(total)
+	}
+}
+
+// I2PControlHandler returns an http.Handler serving a subset of the
+// I2PControl JSON-RPC 2.0 API (API version 1), so I2P monitoring tools
+// can report on the bridge. Requests are POSTed to / or /jsonrpc:
+//
+//	Authenticate  API, Password        -> API, Token
+//	Echo          Token, Echo          -> Result
+//	GetRate       Token, Stat, Period  -> Result
+//	RouterInfo    Token, requested keys -> the keys with their values
+//
+// RouterInfo answers i2p.router.status, i2p.router.uptime (milliseconds),
+// i2p.router.version and i2p.router.net.status (0 when the I2CP router
+// is connected, 8 otherwise); other keys are returned as null. GetRate
+// serves the current value of a SAM statistic such as sam.sessions or
+// sam.bytes.sent; Period is ignored since the bridge keeps no history.
+//
+// Authenticate checks password, or DefaultI2PControlPassword if empty,
+// and returns a token valid for 24 hours. password may be a bcrypt or
+// Argon2id hash (see bridge.HashPassword). Each handler keeps its own
+// tokens, at most 256, evicting the oldest. WithI2PControlAddr serves it
+// over plain HTTP while the bridge runs.
+func (b *Bridge) I2PControlHandler(password string) http.Handler {
+	return b.newI2PControlHandler(password)
+}
+
+// newI2PControlHandler creates the handler behind I2PControlHandler.
+func (b *Bridge) newI2PControlHandler(password string) *i2pControlHandler {
+	if password == "" {
+		password = DefaultI2PControlPassword
+	}
+	return &i2pControlHandler{
+		b:        b,
+		password: password,
+		tokens:   make(map[string]time.Time),
+	}
+}
+
+// I2PControlAddr returns the address the I2PControl API is listening on,
+// or an empty string if it is not running.
+func (b *Bridge) I2PControlAddr() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.i2pControl.addr()
+}
+
+// startI2PControl serves I2PControlHandler on Config.I2PControlAddr.
+// It is a no-op when no I2PControl address is configured.
+// Callers must hold b.mu.
+func (b *Bridge) startI2PControl() error {
+	if b.config.I2PControlAddr == "" {
+		return nil
+	}
+	handler := b.newI2PControlHandler(b.config.I2PControlPassword)
+	mux := http.NewServeMux()
+	mux.Handle("POST /", handler)
+	mux.Handle("POST /jsonrpc", handler)
+	i2pControl, err := b.startHTTPEndpoint(b.config.I2PControlAddr, mux, SourceI2PControl)
+	if err != nil {
+		return err
+	}
+
+	done := make(chan struct{})
+	go handler.runSweeper(done)
+	i2pControl.stop = func() { close(done) }
+	b.i2pControl = i2pControl
+	return nil
+}
+
+// stopI2PControl closes the I2PControl HTTP server, if running.
+func (b *Bridge) stopI2PControl() {
+	b.mu.Lock()
+	i2pControl := b.i2pControl
+	b.i2pControl = nil
+	b.mu.Unlock()
+
+	if err := i2pControl.close(); err != nil {
+		b.deps.Logger.WithError(err).Warn("Error closing I2PControl HTTP server")
+	}
+}
+
+// i2pControlHandler serves I2PControl requests and holds the tokens it
+// has issued.
+type i2pControlHandler struct {
+	b        *Bridge
+	password string
+
+	mu     sync.Mutex
+	tokens map[string]time.Time // token -> expiry
+}
+
+// i2pControlRequest is a JSON-RPC 2.0 request.
+type i2pControlRequest struct {
+	JSONRPC string                     `json:"jsonrpc"`
+	ID      json.RawMessage            `json:"id"`
+	Method  string                     `json:"method"`
+	Params  map[string]json.RawMessage `json:"params"`
+}
+
+// i2pControlResponse is a JSON-RPC 2.0 response.
+type i2pControlResponse struct {
+	JSONRPC string           `json:"jsonrpc"`
+	ID      json.RawMessage  `json:"id"`
+	Result  any              `json:"result,omitempty"`
+	Error   *i2pControlError `json:"error,omitempty"`
+}
+
+// i2pControlError is a JSON-RPC 2.0 error object.
+type i2pControlError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (h *i2pControlHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	resp := i2pControlResponse{JSONRPC: "2.0", ID: json.RawMessage("null")}
+
+	var req i2pControlRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, i2pControlMaxRequestLength)).Decode(&req); err != nil {
+		resp.Error = &i2pControlError{i2pControlParseError, "Parse error"}
+	} else {
+		if req.ID != nil {
+			resp.ID = req.ID
+		}
+		if req.JSONRPC != "2.0" || req.Method == "" {
+			resp.Error = &i2pControlError{i2pControlInvalidRequest, "Invalid Request"}
+		} else {
+			resp.Result, resp.Error = h.call(req.Method, req.Params)
+		}
+	}
+	writeAdminJSON(w, resp)
+}
+
+// call runs method with params, returning its result or an error.
+func (h *i2pControlHandler) call(method string, params map[string]json.RawMessage) (any, *i2pControlError) {
+	if method == "Authenticate" {
+		return h.authenticate(params)
+	}
+
+	var run func(map[string]json.RawMessage) (any, *i2pControlError)
+	switch method {
+	case "Echo":
+		run = h.echo
+	case "GetRate":
+		run = h.getRate
+	case "RouterInfo":
+		run = h.routerInfo
+	default:
+		return nil, &i2pControlError{i2pControlMethodNotFound, "Method not found"}
+	}
+	if err := h.checkToken(params); err != nil {
+		return nil, err
+	}
+	delete(params, "Token")
+	return run(params)
+}
+
+// authenticate checks the API version and password and issues a token.
+func (h *i2pControlHandler) authenticate(params map[string]json.RawMessage) (any, *i2pControlError) {
+	var api int
+	if raw, ok := params["API"]; !ok {
+		return nil, &i2pControlError{i2pControlNoAPIVersion, "The version of the I2PControl API wasn't specified."}
+	} else if err := json.Unmarshal(raw, &api); err != nil || api != i2pControlAPIVersion {
+		return nil, &i2pControlError{i2pControlUnsupportedAPI, "The version of the I2PControl API specified is not supported by I2PControl."}
+	}
+	var password string
+	if err := json.Unmarshal(params["Password"], &password); err != nil || !bridge.VerifyPassword(h.password, password) {
+		return nil, &i2pControlError{i2pControlInvalidPassword, "Invalid password provided."}
+	}
+
+	var raw [16]byte
+	if _, err := rand.Read(raw[:]); err != nil {
+		return nil, &i2pControlError{i2pControlInternalError, "Internal error"}
+	}
+	token := hex.EncodeToString(raw[:])
+
+	now := time.Now()
+	h.mu.Lock()
+	h.sweepLocked(now)
+	for len(h.tokens) >= i2pControlMaxTokens {
+		h.evictOldestLocked()
+	}
+	h.tokens[token] = now.Add(i2pControlTokenTTL)
+	h.mu.Unlock()
+
+	return map[string]any{"API": i2pControlAPIVersion, "Token": token}, nil
+}
+
+// runSweeper drops expired tokens every i2pControlSweepInterval until
+// done is closed.
+func (h *i2pControlHandler) runSweeper(done <-chan struct{}) {
+	ticker := time.NewTicker(i2pControlSweepInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-done:
+			return
+		case now := <-ticker.C:
+			h.mu.Lock()
+			h.sweepLocked(now)
+			h.mu.Unlock()
+		}
+	}
+}
+
+// sweepLocked drops the tokens expired at now. Callers must hold h.mu.
+func (h *i2pControlHandler) sweepLocked(now time.Time) {
+	for t, expiry := range h.tokens {
+		if now.After(expiry) {
+			delete(h.tokens, t)
+		}
+	}
+}
+
+// evictOldestLocked drops the token issued first, which expires first
+// since all tokens share one lifetime. Callers must hold h.mu.
+func (h *i2pControlHandler) evictOldestLocked() {
+	var oldest string
+	var oldestExpiry time.Time
+	for t, expiry := range h.tokens {
+		if oldest == "" || expiry.Before(oldestExpiry) {
+			oldest, oldestExpiry = t, expiry
+		}
+	}
+	delete(h.tokens, oldest)
+}
+
+// checkToken verifies the Token parameter of an authenticated method.
+func (h *i2pControlHandler) checkToken(params map[string]json.RawMessage) *i2pControlError {
+	var token string
+	if raw, ok := params["Token"]; !ok || json.Unmarshal(raw, &token) != nil || token == "" {
+		return &i2pControlError{i2pControlNoToken, "No authentication token presented."}
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	expiry, ok := h.tokens[token]
+	if !ok {
+		return &i2pControlError{i2pControlUnknownToken, "Authentication token doesn't exist."}
+	}
+	if time.Now().After(expiry) {
+		delete(h.tokens, token)
+		return &i2pControlError{i2pControlExpiredToken, "Provided authentication token was expired and will be removed."}
+	}
+	return nil
+}
+
+// echo returns the Echo parameter.
+func (h *i2pControlHandler) echo(params map[string]json.RawMessage) (any, *i2pControlError) {
+	raw, ok := params["Echo"]
+	if !ok {
+		return nil, &i2pControlError{i2pControlInvalidParams, "Invalid params: Echo is required"}
+	}
+	return map[string]json.RawMessage{"Result": raw}, nil
+}
+
+// getRate returns the current value of the Stat parameter.
+func (h *i2pControlHandler) getRate(params map[string]json.RawMessage) (any, *i2pControlError) {
+	var stat string
+	if err := json.Unmarshal(params["Stat"], &stat); err != nil {
+		return nil, &i2pControlError{i2pControlInvalidParams, "Invalid params: Stat is required"}
+	}
+	rate, ok := i2pControlRates[stat]
+	if !ok {
+		return nil, &i2pControlError{i2pControlInvalidParams, "Invalid params: unknown Stat " + stat}
+	}
+	return map[string]float64{"Result": rate(h.b)}, nil
+}
+
+// routerInfo returns the requested keys, with null for those the bridge
+// cannot answer.
+func (h *i2pControlHandler) routerInfo(params map[string]json.RawMessage) (any, *i2pControlError) {
+	result := make(map[string]any, len(params))
+	for key := range params {
+		result[key] = h.routerInfoValue(key)
+	}
+	return result, nil
+}
+
+// routerInfoValue returns the value of one RouterInfo key, or nil.
+func (h *i2pControlHandler) routerInfoValue(key string) any {
+	b := h.b
+	switch key {
+	case "i2p.router.status":
+		if err := b.Health(); err != nil {
+			return err.Error()
+		}
+		return "OK"
+	case "i2p.router.uptime":
+		return b.Stats().Uptime.Milliseconds()
+	case "i2p.router.version":
+		if info, ok := b.RouterInfo(); ok {
+			return info.Version
+		}
+		return nil
+	case "i2p.router.net.status":
+		if b.deps.I2CPProvider.IsConnected() {
+			return i2pControlNetStatusOK
+		}
+		return i2pControlNetStatusI2CPError
+	}
+	return nil
+}
@@ -0,0 +1,251 @@
+package embedding
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-i2p/go-sam-bridge/lib/bridge"
+	"github.com/go-i2p/go-sam-bridge/lib/session"
+)
+
+// i2pControlReply is a decoded JSON-RPC response.
+type i2pControlReply struct {
+	ID     json.RawMessage            `json:"id"`
+	Result map[string]json.RawMessage `json:"result"`
+	Error  *i2pControlError           `json:"error"`
+}
+
+// callI2PControl posts a JSON-RPC request with params to h.
+func callI2PControl(t *testing.T, h http.Handler, method string, params map[string]any) i2pControlReply {
+	t.Helper()
+	body, _ := json.Marshal(map[string]any{"jsonrpc": "2.0", "id": 7, "method": method, "params": params})
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/jsonrpc", strings.NewReader(string(body))))
+
+	var reply i2pControlReply
+	if err := json.Unmarshal(rec.Body.Bytes(), &reply); err != nil {
+		t.Fatalf("decoding %s reply %q: %v", method, rec.Body.String(), err)
+	}
+	return reply
+}
+
+// i2pControlToken authenticates to h with password and returns the token.
+func i2pControlToken(t *testing.T, h http.Handler, password string) string {
+	t.Helper()
+	reply := callI2PControl(t, h, "Authenticate", map[string]any{"API": 1, "Password": password})
+	if reply.Error != nil {
+		t.Fatalf("Authenticate error = %+v", reply.Error)
+	}
+	var token string
+	if err := json.Unmarshal(reply.Result["Token"], &token); err != nil || token == "" {
+		t.Fatalf("Authenticate token = %s, want a token", reply.Result["Token"])
+	}
+	return token
+}
+
+func TestBridgeI2PControlHandler(t *testing.T) {
+	b, err := New(WithListenAddr("127.0.0.1:0"), WithI2CPProvider(&mockI2CPProvider{}), WithDatagramPort(0))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if err := b.Start(context.Background()); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	defer b.Stop(context.Background())
+
+	sess := session.NewBaseSession("rpc-1", session.StyleRaw, &session.Destination{PublicKey: []byte("pubkey")}, nil, session.DefaultSessionConfig())
+	if err := b.Dependencies().Registry.Register(sess); err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+	sess.Stats().AddDatagramSent(32)
+
+	h := b.I2PControlHandler("secret")
+	token := i2pControlToken(t, h, "secret")
+
+	reply := callI2PControl(t, h, "Echo", map[string]any{"Token": token, "Echo": "hello"})
+	if reply.Error != nil || string(reply.Result["Result"]) != `"hello"` {
+		t.Errorf("Echo = %s, %+v; want \"hello\"", reply.Result["Result"], reply.Error)
+	}
+	if string(reply.ID) != "7" {
+		t.Errorf("reply id = %s, want 7", reply.ID)
+	}
+
+	reply = callI2PControl(t, h, "RouterInfo", map[string]any{
+		"Token":                       token,
+		"i2p.router.status":           nil,
+		"i2p.router.net.status":       nil,
+		"i2p.router.uptime":           nil,
+		"i2p.router.netdb.knownpeers": nil,
+	})
+	if reply.Error != nil {
+		t.Fatalf("RouterInfo error = %+v", reply.Error)
+	}
+	if got := string(reply.Result["i2p.router.status"]); got != `"OK"` {
+		t.Errorf("i2p.router.status = %s, want \"OK\"", got)
+	}
+	if got := string(reply.Result["i2p.router.net.status"]); got != "0" {
+		t.Errorf("i2p.router.net.status = %s, want 0", got)
+	}
+	if _, ok := reply.Result["i2p.router.uptime"]; !ok {
+		t.Error("i2p.router.uptime missing")
+	}
+	if got, ok := reply.Result["i2p.router.netdb.knownpeers"]; !ok || string(got) != "null" {
+		t.Errorf("unsupported key = %s, want null", got)
+	}
+	if _, ok := reply.Result["Token"]; ok {
+		t.Error("RouterInfo echoed the token")
+	}
+
+	for stat, want := range map[string]string{"sam.sessions": "1", "sam.datagrams.sent": "1", "sam.bytes.sent": "32"} {
+		reply = callI2PControl(t, h, "GetRate", map[string]any{"Token": token, "Stat": stat, "Period": 60000})
+		if reply.Error != nil || string(reply.Result["Result"]) != want {
+			t.Errorf("GetRate(%s) = %s, %+v; want %s", stat, reply.Result["Result"], reply.Error, want)
+		}
+	}
+}
+
+func TestI2PControlHandler_Errors(t *testing.T) {
+	b, err := New(WithListenAddr("127.0.0.1:0"), WithI2CPProvider(&mockI2CPProvider{}), WithDatagramPort(0))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	h := b.I2PControlHandler("")
+	token := i2pControlToken(t, h, DefaultI2PControlPassword)
+
+	tests := []struct {
+		name     string
+		method   string
+		params   map[string]any
+		wantCode int
+	}{
+		{"wrong password", "Authenticate", map[string]any{"API": 1, "Password": "guess"}, i2pControlInvalidPassword},
+		{"missing API version", "Authenticate", map[string]any{"Password": DefaultI2PControlPassword}, i2pControlNoAPIVersion},
+		{"unsupported API version", "Authenticate", map[string]any{"API": 2, "Password": DefaultI2PControlPassword}, i2pControlUnsupportedAPI},
+		{"missing token", "Echo", map[string]any{"Echo": "hi"}, i2pControlNoToken},
+		{"unknown token", "Echo", map[string]any{"Token": "forged", "Echo": "hi"}, i2pControlUnknownToken},
+		{"unknown method", "RouterManager", map[string]any{"Token": token}, i2pControlMethodNotFound},
+		{"unknown stat", "GetRate", map[string]any{"Token": token, "Stat": "bw.sendBps", "Period": 60000}, i2pControlInvalidParams},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reply := callI2PControl(t, h, tt.method, tt.params)
+			if reply.Error == nil || reply.Error.Code != tt.wantCode {
+				t.Errorf("%s error = %+v, want code %d", tt.method, reply.Error, tt.wantCode)
+			}
+		})
+	}
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/", strings.NewReader("{not json")))
+	if !strings.Contains(rec.Body.String(), fmt.Sprint(i2pControlParseError)) {
+		t.Errorf("malformed request reply = %s, want parse error", rec.Body.String())
+	}
+}
+
+func TestI2PControlHandler_HashedPassword(t *testing.T) {
+	hash, err := bridge.HashPassword("secret")
+	if err != nil {
+		t.Fatalf("HashPassword() error = %v", err)
+	}
+	b, err := New(WithListenAddr("127.0.0.1:0"), WithI2CPProvider(&mockI2CPProvider{}), WithDatagramPort(0))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	h := b.I2PControlHandler(hash)
+	i2pControlToken(t, h, "secret")
+
+	reply := callI2PControl(t, h, "Authenticate", map[string]any{"API": 1, "Password": hash})
+	if reply.Error == nil || reply.Error.Code != i2pControlInvalidPassword {
+		t.Errorf("Authenticate with the hash error = %+v, want code %d", reply.Error, i2pControlInvalidPassword)
+	}
+}
+
+func TestI2PControlHandler_TokenLimit(t *testing.T) {
+	b, err := New(WithListenAddr("127.0.0.1:0"), WithI2CPProvider(&mockI2CPProvider{}), WithDatagramPort(0))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	h := b.newI2PControlHandler("secret")
+	first := i2pControlToken(t, h, "secret")
+	var last string
+	for i := 0; i < i2pControlMaxTokens; i++ {
+		last = i2pControlToken(t, h, "secret")
+	}
+
+	if len(h.tokens) != i2pControlMaxTokens {
+		t.Errorf("handler holds %d tokens, want %d", len(h.tokens), i2pControlMaxTokens)
+	}
+	if reply := callI2PControl(t, h, "Echo", map[string]any{"Token": first, "Echo": "hi"}); reply.Error == nil || reply.Error.Code != i2pControlUnknownToken {
+		t.Errorf("Echo with the oldest token error = %+v, want code %d", reply.Error, i2pControlUnknownToken)
+	}
+	if reply := callI2PControl(t, h, "Echo", map[string]any{"Token": last, "Echo": "hi"}); reply.Error != nil {
+		t.Errorf("Echo with the newest token error = %+v", reply.Error)
+	}
+}
+
+func TestI2PControlHandler_Sweep(t *testing.T) {
+	b, err := New(WithListenAddr("127.0.0.1:0"), WithI2CPProvider(&mockI2CPProvider{}), WithDatagramPort(0))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	h := b.newI2PControlHandler("secret")
+	i2pControlToken(t, h, "secret")
+	live := i2pControlToken(t, h, "secret")
+
+	h.mu.Lock()
+	h.sweepLocked(time.Now().Add(i2pControlTokenTTL + time.Second))
+	n := len(h.tokens)
+	h.mu.Unlock()
+	if n != 0 {
+		t.Errorf("%d tokens left after they expired, want 0", n)
+	}
+
+	h.tokens[live] = time.Now().Add(time.Hour)
+	h.sweepLocked(time.Now())
+	if _, ok := h.tokens[live]; !ok {
+		t.Error("sweep dropped a token that has not expired")
+	}
+}
+
+func TestBridgeWithI2PControlAddr(t *testing.T) {
+	b, err := New(WithListenAddr("127.0.0.1:0"), WithI2CPProvider(&mockI2CPProvider{}), WithDatagramPort(0),
+		WithI2PControlAddr("127.0.0.1:0"), WithI2PControlPassword("secret"))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if b.I2PControlAddr() != "" {
+		t.Error("I2PControlAddr() should be empty before Start")
+	}
+
+	if err := b.Start(context.Background()); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	addr := b.I2PControlAddr()
+	if addr == "" {
+		t.Fatal("I2PControlAddr() should be set while running")
+	}
+
+	body := `{"jsonrpc":"2.0","id":1,"method":"Authenticate","params":{"API":1,"Password":"secret"}}`
+	resp, err := http.Post("http://"+addr+"/jsonrpc", "application/json", strings.NewReader(body))
+	if err != nil {
+		t.Fatalf("POST /jsonrpc error = %v", err)
+	}
+	var reply i2pControlReply
+	err = json.NewDecoder(resp.Body).Decode(&reply)
+	resp.Body.Close()
+	if err != nil || reply.Error != nil || reply.Result["Token"] == nil {
+		t.Errorf("Authenticate over HTTP = %+v, %v; want a token", reply, err)
+	}
+
+	b.Stop(context.Background())
+	if b.I2PControlAddr() != "" {
+		t.Error("I2PControlAddr() should be empty after Stop")
+	}
+}
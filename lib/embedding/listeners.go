@@ -0,0 +1,178 @@
+package embedding
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"time"
+
+	"github.com/go-i2p/go-sam-bridge/lib/bridge"
+)
+
+// Endpoint networks.
+const (
+	// NetworkTCP serves SAM on a TCP address.
+	NetworkTCP = "tcp"
+
+	// NetworkUnix serves SAM on a Unix domain socket path.
+	NetworkUnix = "unix"
+)
+
+// staleSocketDialTimeout bounds the probe for a live server on an
+// existing Unix socket path.
+const staleSocketDialTimeout = time.Second
+
+// Endpoint describes an additional SAM control socket served alongside
+// ListenAddr or Listener.
+type Endpoint struct {
+	// Network is NetworkTCP or NetworkUnix.
+	Network string
+
+	// Address is the TCP host:port or the Unix socket path.
+	Address string
+
+	// TLS serves the endpoint with the bridge's TLS configuration
+	// (TLSConfig or TLSCertFile/TLSKeyFile).
+	TLS bool
+
+	// ProxyProtocol requires every connection to begin with a PROXY
+	// protocol v1 or v2 header, as sent by a TCP load balancer, and takes
+	// the client address from it. See bridge.ProxyListener.
+	ProxyProtocol bool
+
+	// Listener, if set, is a pre-opened socket, e.g. from systemd socket
+	// activation, served instead of listening on Network and Address.
+	// The bridge closes it when it stops.
+	Listener net.Listener
+}
+
+// String returns the endpoint as network://address, with a "+tls" suffix
+// on the network for TLS endpoints.
+func (e Endpoint) String() string {
+	network, address := e.Network, e.Address
+	if e.Listener != nil {
+		network, address = e.Listener.Addr().Network(), e.Listener.Addr().String()
+	}
+	if e.TLS {
+		network += "+tls"
+	}
+	return network + "://" + address
+}
+
+// validate checks the endpoint's network and address.
+func (e Endpoint) validate() error {
+	if e.Listener != nil {
+		return nil
+	}
+	if e.Network != NetworkTCP && e.Network != NetworkUnix {
+		return fmt.Errorf("%w: %s: unknown network %q", ErrInvalidEndpoint, e, e.Network)
+	}
+	if e.Address == "" {
+		return fmt.Errorf("%w: %s: address required", ErrInvalidEndpoint, e)
+	}
+	return nil
+}
+
+// Addrs returns the addresses of all SAM control sockets while the
+// bridge is running: ListenAddr or Listener first, then each Endpoint
+// in order. It returns nil when the bridge is not running.
+func (b *Bridge) Addrs() []string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if len(b.listeners) == 0 {
+		return nil
+	}
+	addrs := make([]string, len(b.listeners))
+	for i, l := range b.listeners {
+		addrs[i] = l.Addr().String()
+	}
+	return addrs
+}
+
+// listen opens the control sockets: Config.Listener, or ListenAddr
+// wrapped in TLS if configured, followed by each Config.Endpoint.
+// On error, any sockets already opened are closed.
+func (b *Bridge) listen() ([]net.Listener, error) {
+	primary := b.config.Listener
+	if primary == nil {
+		l, err := net.Listen(NetworkTCP, b.config.ListenAddr)
+		if err != nil {
+			return nil, err
+		}
+		if b.config.ProxyProtocol {
+			l = bridge.NewProxyListener(l)
+		}
+		if b.config.TLSConfig != nil {
+			l = tls.NewListener(l, b.config.TLSConfig)
+		}
+		primary = l
+	} else if b.config.ProxyProtocol {
+		primary = bridge.NewProxyListener(primary)
+	}
+
+	listeners := []net.Listener{primary}
+	for _, e := range b.config.Endpoints {
+		l, err := listenEndpoint(e, b.config.TLSConfig)
+		if err != nil {
+			closeListeners(listeners)
+			return nil, fmt.Errorf("embedding: listen on %s: %w", e, err)
+		}
+		listeners = append(listeners, l)
+	}
+	return listeners, nil
+}
+
+// listenEndpoint opens the socket for e, or uses e.Listener, wrapping it
+// in a PROXY protocol reader if e.ProxyProtocol and in TLS if e.TLS.
+func listenEndpoint(e Endpoint, tlsConfig *tls.Config) (net.Listener, error) {
+	l := e.Listener
+	if l == nil {
+		if e.Network == NetworkUnix {
+			if err := removeStaleSocket(e.Address); err != nil {
+				return nil, err
+			}
+		}
+		var err error
+		if l, err = net.Listen(e.Network, e.Address); err != nil {
+			return nil, err
+		}
+	}
+	if e.ProxyProtocol {
+		l = bridge.NewProxyListener(l)
+	}
+	if e.TLS {
+		l = tls.NewListener(l, tlsConfig)
+	}
+	return l, nil
+}
+
+// removeStaleSocket removes a Unix socket left behind by a process that
+// exited without closing it. A socket some server still accepts on is
+// left in place, so the following Listen fails instead of stealing it.
+func removeStaleSocket(path string) error {
+	fi, err := os.Lstat(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if fi.Mode()&os.ModeSocket == 0 {
+		return nil
+	}
+	conn, err := net.DialTimeout(NetworkUnix, path, staleSocketDialTimeout)
+	if err == nil {
+		conn.Close()
+		return nil
+	}
+	return os.Remove(path)
+}
+
+// closeListeners closes every listener in ls.
+func closeListeners(ls []net.Listener) {
+	for _, l := range ls {
+		l.Close()
+	}
+}
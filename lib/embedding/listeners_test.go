@@ -0,0 +1,244 @@
+package embedding
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/go-i2p/go-sam-bridge/lib/bridge"
+)
+
+// helloOver sends HELLO on conn and returns the reply line.
+func helloOver(t *testing.T, conn net.Conn) string {
+	t.Helper()
+	defer conn.Close()
+	if _, err := conn.Write([]byte("HELLO VERSION MIN=3.0 MAX=3.3\n")); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	line, err := bufio.NewReader(conn).ReadString('\n')
+	if err != nil {
+		t.Fatalf("ReadString() error = %v", err)
+	}
+	return line
+}
+
+func TestBridgeEndpoints(t *testing.T) {
+	socket := filepath.Join(t.TempDir(), "sam.sock")
+	b, err := New(
+		WithListenAddr("127.0.0.1:0"),
+		WithI2CPProvider(&mockI2CPProvider{}),
+		WithDatagramPort(0),
+		WithEndpoints(
+			Endpoint{Network: NetworkTCP, Address: "127.0.0.1:0"},
+			Endpoint{Network: NetworkUnix, Address: socket},
+		),
+	)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if b.Addrs() != nil {
+		t.Error("Addrs() should be nil before Start")
+	}
+
+	if err := b.Start(context.Background()); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	addrs := b.Addrs()
+	if len(addrs) != 3 || addrs[2] != socket {
+		t.Fatalf("Addrs() = %v, want two TCP addresses and %s", addrs, socket)
+	}
+
+	for i, network := range []string{NetworkTCP, NetworkTCP, NetworkUnix} {
+		conn, err := net.Dial(network, addrs[i])
+		if err != nil {
+			t.Fatalf("Dial(%s, %s) error = %v", network, addrs[i], err)
+		}
+		if reply := helloOver(t, conn); !strings.Contains(reply, "RESULT=OK") {
+			t.Errorf("HELLO over %s = %q, want RESULT=OK", addrs[i], reply)
+		}
+	}
+
+	b.Stop(context.Background())
+	if b.Addrs() != nil {
+		t.Error("Addrs() should be nil after Stop")
+	}
+	if _, err := os.Stat(socket); !os.IsNotExist(err) {
+		t.Errorf("Unix socket should be removed after Stop, Stat() error = %v", err)
+	}
+}
+
+func TestBridgeEndpointListener(t *testing.T) {
+	ln, err := net.Listen(NetworkTCP, "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("net.Listen() error = %v", err)
+	}
+	b, err := New(
+		WithListenAddr("127.0.0.1:0"),
+		WithI2CPProvider(&mockI2CPProvider{}),
+		WithDatagramPort(0),
+		WithEndpoints(Endpoint{Listener: ln}),
+	)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if err := b.Start(context.Background()); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+
+	if addrs := b.Addrs(); len(addrs) != 2 || addrs[1] != ln.Addr().String() {
+		t.Errorf("Addrs() = %v, want %s second", addrs, ln.Addr())
+	}
+	conn, err := net.Dial(NetworkTCP, ln.Addr().String())
+	if err != nil {
+		t.Fatalf("Dial() error = %v", err)
+	}
+	if reply := helloOver(t, conn); !strings.Contains(reply, "RESULT=OK") {
+		t.Errorf("HELLO over pre-opened listener = %q, want RESULT=OK", reply)
+	}
+
+	b.Stop(context.Background())
+	if _, err := net.Dial(NetworkTCP, ln.Addr().String()); err == nil {
+		t.Error("Stop should close the pre-opened listener")
+	}
+}
+
+func TestBridgeEndpointProxyProtocol(t *testing.T) {
+	remotes := make(chan string, 1)
+	b, err := New(
+		WithListenAddr("127.0.0.1:0"),
+		WithI2CPProvider(&mockI2CPProvider{}),
+		WithDatagramPort(0),
+		WithEndpoints(Endpoint{Network: NetworkTCP, Address: "127.0.0.1:0", ProxyProtocol: true}),
+		WithOnConnection(func(conn net.Conn, state bridge.ConnectionState) {
+			if state == bridge.StateNew {
+				remotes <- conn.RemoteAddr().String()
+			}
+		}),
+	)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if err := b.Start(context.Background()); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	defer b.Stop(context.Background())
+
+	conn, err := net.Dial(NetworkTCP, b.Addrs()[1])
+	if err != nil {
+		t.Fatalf("Dial() error = %v", err)
+	}
+	if _, err := conn.Write([]byte("PROXY TCP4 192.0.2.1 198.51.100.1 56324 7656\r\n")); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	if reply := helloOver(t, conn); !strings.Contains(reply, "RESULT=OK") {
+		t.Errorf("HELLO after PROXY header = %q, want RESULT=OK", reply)
+	}
+	if remote := <-remotes; remote != "192.0.2.1:56324" {
+		t.Errorf("connection RemoteAddr() = %q, want the address from the PROXY header", remote)
+	}
+}
+
+func TestBridgeEndpointsTLS(t *testing.T) {
+	certFile, keyFile := writeTestKeyPair(t, t.TempDir(), "endpoint")
+	b, err := New(
+		WithListenAddr("127.0.0.1:0"),
+		WithI2CPProvider(&mockI2CPProvider{}),
+		WithDatagramPort(0),
+		WithTLSFiles(certFile, keyFile),
+		WithEndpoints(Endpoint{Network: NetworkTCP, Address: "127.0.0.1:0", TLS: true}),
+	)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if err := b.Start(context.Background()); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	defer b.Stop(context.Background())
+
+	conn, err := tls.Dial(NetworkTCP, b.Addrs()[1], &tls.Config{InsecureSkipVerify: true})
+	if err != nil {
+		t.Fatalf("tls.Dial() error = %v", err)
+	}
+	if reply := helloOver(t, conn); !strings.Contains(reply, "RESULT=OK") {
+		t.Errorf("HELLO over TLS endpoint = %q, want RESULT=OK", reply)
+	}
+}
+
+func TestBridgeEndpointsListenError(t *testing.T) {
+	taken, err := net.Listen(NetworkTCP, "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("net.Listen() error = %v", err)
+	}
+	defer taken.Close()
+
+	b, err := New(
+		WithListenAddr("127.0.0.1:0"),
+		WithI2CPProvider(&mockI2CPProvider{}),
+		WithDatagramPort(0),
+		WithEndpoints(Endpoint{Network: NetworkTCP, Address: taken.Addr().String()}),
+	)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if err := b.Start(context.Background()); err == nil {
+		b.Stop(context.Background())
+		t.Fatal("Start() with an endpoint in use should return error")
+	}
+	if b.Running() {
+		t.Error("bridge should not be running after a failed Start")
+	}
+}
+
+func TestRemoveStaleSocket(t *testing.T) {
+	dir := t.TempDir()
+
+	live := filepath.Join(dir, "live.sock")
+	l, err := net.Listen(NetworkUnix, live)
+	if err != nil {
+		t.Fatalf("net.Listen() error = %v", err)
+	}
+	defer l.Close()
+	if err := removeStaleSocket(live); err != nil {
+		t.Fatalf("removeStaleSocket(live) error = %v", err)
+	}
+	if _, err := os.Stat(live); err != nil {
+		t.Error("removeStaleSocket must not remove a socket in use")
+	}
+
+	stale := filepath.Join(dir, "stale.sock")
+	ul, err := net.ListenUnix(NetworkUnix, &net.UnixAddr{Name: stale, Net: NetworkUnix})
+	if err != nil {
+		t.Fatalf("net.ListenUnix() error = %v", err)
+	}
+	ul.SetUnlinkOnClose(false)
+	ul.Close()
+	if err := removeStaleSocket(stale); err != nil {
+		t.Fatalf("removeStaleSocket(stale) error = %v", err)
+	}
+	if _, err := os.Stat(stale); !os.IsNotExist(err) {
+		t.Error("removeStaleSocket should remove a stale socket")
+	}
+
+	regular := filepath.Join(dir, "regular")
+	if err := os.WriteFile(regular, nil, 0o600); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+	if err := removeStaleSocket(regular); err != nil {
+		t.Fatalf("removeStaleSocket(regular) error = %v", err)
+	}
+	if _, err := os.Stat(regular); err != nil {
+		t.Error("removeStaleSocket must not remove a regular file")
+	}
+}
+
+func TestEndpointString(t *testing.T) {
+	e := Endpoint{Network: NetworkTCP, Address: ":7666", TLS: true}
+	if got := e.String(); got != "tcp+tls://:7666" {
+		t.Errorf("String() = %q, want %q", got, "tcp+tls://:7666")
+	}
+}
@@ -0,0 +1,104 @@
+package embedding
+
+import (
+	"sync"
+	"time"
+
+	"github.com/go-i2p/go-sam-bridge/lib/session"
+	"github.com/sirupsen/logrus"
+)
+
+// DefaultLogSampleInterval is the sampling window used when
+// WithLogSampling is given a burst but no interval.
+const DefaultLogSampleInterval = time.Second
+
+// SessionFields returns the log fields that identify sess: "sessionID",
+// "style", "user" (the SAM user that created it, empty without
+// authentication), "remote" (the address of its control connection) and
+// "label". Every log record about a session carries these fields, so
+// custom handlers should log with them too.
+func SessionFields(sess session.Session) logrus.Fields {
+	remote := ""
+	if conn := sess.ControlConn(); conn != nil && conn.RemoteAddr() != nil {
+		remote = conn.RemoteAddr().String()
+	}
+	return logrus.Fields{
+		"sessionID": sess.ID(),
+		"style":     string(sess.Style()),
+		"user":      session.User(sess),
+		"remote":    remote,
+		"label":     session.Label(sess),
+	}
+}
+
+// logSampler limits how many records each hot-path log site writes, such
+// as forwarding failures that repeat for every inbound stream while a
+// target is down. Each key may log burst records per interval; the rest
+// are dropped and their number is added to the key's next record as
+// "suppressed". Keys name log sites, not individual events, so the set
+// stays small. A nil logSampler or a zero burst logs everything.
+type logSampler struct {
+	mu       sync.Mutex
+	burst    int
+	interval time.Duration
+	windows  map[string]*sampleWindow
+}
+
+// sampleWindow counts one key's records in the current interval.
+type sampleWindow struct {
+	start      time.Time
+	logged     int
+	suppressed int
+}
+
+// newLogSampler creates a logSampler; see set.
+func newLogSampler(burst int, interval time.Duration) *logSampler {
+	s := &logSampler{windows: make(map[string]*sampleWindow)}
+	s.set(burst, interval)
+	return s
+}
+
+// set changes the limits and starts new windows. A zero interval means
+// DefaultLogSampleInterval.
+func (s *logSampler) set(burst int, interval time.Duration) {
+	if interval <= 0 {
+		interval = DefaultLogSampleInterval
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.burst, s.interval = burst, interval
+	clear(s.windows)
+}
+
+// entry returns e for a record logged at key, with the number of records
+// suppressed since the previous one, or nil if the record is dropped.
+func (s *logSampler) entry(key string, e *logrus.Entry) *logrus.Entry {
+	if s == nil {
+		return e
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.burst <= 0 {
+		return e
+	}
+
+	now := time.Now()
+	w := s.windows[key]
+	if w == nil {
+		w = &sampleWindow{start: now}
+		s.windows[key] = w
+	}
+	if now.Sub(w.start) >= s.interval {
+		w.start, w.logged = now, 0
+	}
+	if w.logged >= s.burst {
+		w.suppressed++
+		return nil
+	}
+	w.logged++
+	if w.suppressed > 0 {
+		e = e.WithField("suppressed", w.suppressed)
+		w.suppressed = 0
+	}
+	return e
+}
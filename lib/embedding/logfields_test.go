@@ -0,0 +1,208 @@
+package embedding
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-i2p/go-sam-bridge/lib/handler"
+	"github.com/go-i2p/go-sam-bridge/lib/i2cp/i2cptest"
+	"github.com/go-i2p/go-sam-bridge/lib/session"
+	"github.com/sirupsen/logrus"
+)
+
+// jsonLogger returns a logger writing JSON records to buf.
+func jsonLogger(buf *bytes.Buffer) *logrus.Logger {
+	log := logrus.New()
+	log.SetOutput(buf)
+	log.SetFormatter(&logrus.JSONFormatter{})
+	return log
+}
+
+// logRecords decodes the JSON records in buf.
+func logRecords(t *testing.T, buf *bytes.Buffer) []map[string]any {
+	t.Helper()
+	var records []map[string]any
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		if line == "" {
+			continue
+		}
+		var rec map[string]any
+		if err := json.Unmarshal([]byte(line), &rec); err != nil {
+			t.Fatalf("decoding log record %q: %v", line, err)
+		}
+		records = append(records, rec)
+	}
+	return records
+}
+
+func TestSessionFields(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("net.Listen() error = %v", err)
+	}
+	defer ln.Close()
+	conn, err := net.Dial("tcp", ln.Addr().String())
+	if err != nil {
+		t.Fatalf("net.Dial() error = %v", err)
+	}
+	defer conn.Close()
+
+	cfg := session.DefaultSessionConfig()
+	cfg.User = "alice"
+	cfg.Label = "web"
+	sess := session.NewBaseSession("s1", session.StyleStream, nil, conn, cfg)
+
+	want := logrus.Fields{
+		"sessionID": "s1",
+		"style":     "STREAM",
+		"user":      "alice",
+		"remote":    conn.RemoteAddr().String(),
+		"label":     "web",
+	}
+	got := SessionFields(sess)
+	for k, v := range want {
+		if got[k] != v {
+			t.Errorf("SessionFields()[%q] = %v, want %v", k, got[k], v)
+		}
+	}
+
+	// Unknown values are present but empty, so every record has the keys.
+	got = SessionFields(session.NewBaseSession("s2", session.StyleRaw, nil, nil, nil))
+	if got["user"] != "" || got["remote"] != "" {
+		t.Errorf("SessionFields() without user or connection = %v", got)
+	}
+}
+
+func TestLogSampler(t *testing.T) {
+	var buf bytes.Buffer
+	log := jsonLogger(&buf)
+	s := newLogSampler(2, time.Hour)
+
+	for i := 0; i < 5; i++ {
+		if e := s.entry("forwarder", log.WithField("n", i)); e != nil {
+			e.Warn("failure")
+		}
+	}
+	if e := s.entry("other", log.WithField("n", 9)); e != nil {
+		e.Warn("failure")
+	}
+	if n := len(logRecords(t, &buf)); n != 3 {
+		t.Fatalf("logged %d records, want 2 for forwarder and 1 for other", n)
+	}
+
+	// A new window reports how many records were dropped.
+	buf.Reset()
+	s.windows["forwarder"].start = time.Now().Add(-2 * time.Hour)
+	s.entry("forwarder", log.WithField("n", 5)).Warn("failure")
+	records := logRecords(t, &buf)
+	if len(records) != 1 || records[0]["suppressed"] != float64(3) {
+		t.Errorf("records = %v, want one with suppressed=3", records)
+	}
+
+	// A zero burst, or no sampler at all, logs everything.
+	s.set(0, 0)
+	var none *logSampler
+	for i := 0; i < 5; i++ {
+		if s.entry("forwarder", log.WithField("n", i)) == nil || none.entry("forwarder", log.WithField("n", i)) == nil {
+			t.Fatal("record dropped with sampling disabled")
+		}
+	}
+}
+
+func TestErrorReporter_SessionFields(t *testing.T) {
+	var buf bytes.Buffer
+	cfg := session.DefaultSessionConfig()
+	cfg.User = "alice"
+	sess := session.NewBaseSession("fwd", session.StyleStream, nil, nil, cfg)
+
+	errs := make(chan error, 4)
+	report := newErrorReporter(errs, jsonLogger(&buf), newLogSampler(1, time.Hour))
+	for i := 0; i < 3; i++ {
+		report(SourceForwarder, &handler.SessionError{Session: sess, Err: errors.New("connection refused")})
+	}
+
+	records := logRecords(t, &buf)
+	if len(records) != 1 {
+		t.Fatalf("logged %d records, want 1 after sampling", len(records))
+	}
+	if rec := records[0]; rec["sessionID"] != "fwd" || rec["style"] != "STREAM" || rec["user"] != "alice" || rec["source"] != SourceForwarder {
+		t.Errorf("record = %v, want the session's fields", rec)
+	}
+	if len(errs) != 3 {
+		t.Errorf("delivered %d errors, want all 3 despite sampling", len(errs))
+	}
+}
+
+func TestBridgeSessionUser(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to create test listener: %v", err)
+	}
+	b, err := New(
+		WithListener(ln),
+		WithI2CPProvider(i2cptest.NewProvider()),
+		WithDatagramPort(0),
+		WithAuth(map[string]string{"alice": "secret"}),
+	)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if err := b.Start(context.Background()); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	defer b.Stop(context.Background())
+
+	conn, err := net.Dial("tcp", ln.Addr().String())
+	if err != nil {
+		t.Fatalf("Dial() error = %v", err)
+	}
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	conn.Write([]byte("HELLO VERSION MIN=3.0 MAX=3.3 USER=alice PASSWORD=secret\n"))
+	r.ReadString('\n')
+	conn.Write([]byte("SESSION CREATE STYLE=RAW ID=owned DESTINATION=TRANSIENT\n"))
+	if line, err := r.ReadString('\n'); err != nil || !strings.Contains(line, "RESULT=OK") {
+		t.Fatalf("SESSION CREATE reply = %q, %v", line, err)
+	}
+
+	sess := b.Dependencies().Registry.Get("owned")
+	if sess == nil {
+		t.Fatal("session not registered")
+	}
+	fields := SessionFields(sess)
+	if fields["user"] != "alice" || fields["remote"] != conn.LocalAddr().String() {
+		t.Errorf("SessionFields() = %v, want user alice and the client's address", fields)
+	}
+}
+
+func TestTunnelEventLogger(t *testing.T) {
+	var buf bytes.Buffer
+	cfg := session.DefaultSessionConfig()
+	cfg.User = "alice"
+	sess := session.NewBaseSession("tun", session.StyleStream, nil, nil, cfg)
+
+	deps := &Dependencies{Logger: jsonLogger(&buf), logSampler: newLogSampler(1, time.Hour)}
+	logEvent := TunnelEventLogger(deps)
+	for i := 0; i < 3; i++ {
+		logEvent(sess, session.TunnelEvent{Type: session.TunnelBuilt})
+	}
+	logEvent(sess, session.TunnelEvent{Type: session.TunnelFailed, Reason: "timeout"})
+
+	records := logRecords(t, &buf)
+	if len(records) != 2 {
+		t.Fatalf("logged %d records, want 2 after sampling per event type", len(records))
+	}
+	if rec := records[0]; rec["sessionID"] != "tun" || rec["user"] != "alice" || rec["level"] != "info" {
+		t.Errorf("built record = %v, want the session's fields at info level", rec)
+	}
+	if rec := records[1]; rec["reason"] != "timeout" || rec["level"] != "warning" {
+		t.Errorf("failed record = %v, want the reason at warning level", rec)
+	}
+}
@@ -0,0 +1,30 @@
+package embedding
+
+import (
+	"fmt"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Log formats accepted by WithLogFormat.
+const (
+	// LogFormatText is logrus's human-readable key=value format.
+	LogFormatText = "text"
+
+	// LogFormatJSON writes one JSON object per log entry, for structured
+	// log pipelines.
+	LogFormatJSON = "json"
+)
+
+// NewLogFormatter returns the logrus formatter for a log format name
+// ("text" or "json"). Returns ErrUnknownLogFormat for any other name.
+func NewLogFormatter(format string) (logrus.Formatter, error) {
+	switch format {
+	case LogFormatText:
+		return &logrus.TextFormatter{FullTimestamp: true}, nil
+	case LogFormatJSON:
+		return &logrus.JSONFormatter{}, nil
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnknownLogFormat, format)
+	}
+}
@@ -0,0 +1,65 @@
+package embedding
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestNewLogFormatter(t *testing.T) {
+	if f, err := NewLogFormatter(LogFormatText); err != nil {
+		t.Errorf("NewLogFormatter(text) error = %v", err)
+	} else if _, ok := f.(*logrus.TextFormatter); !ok {
+		t.Errorf("NewLogFormatter(text) = %T, want *logrus.TextFormatter", f)
+	}
+
+	if f, err := NewLogFormatter(LogFormatJSON); err != nil {
+		t.Errorf("NewLogFormatter(json) error = %v", err)
+	} else if _, ok := f.(*logrus.JSONFormatter); !ok {
+		t.Errorf("NewLogFormatter(json) = %T, want *logrus.JSONFormatter", f)
+	}
+
+	if _, err := NewLogFormatter("xml"); !errors.Is(err, ErrUnknownLogFormat) {
+		t.Errorf("NewLogFormatter(xml) error = %v, want ErrUnknownLogFormat", err)
+	}
+}
+
+func TestBridgeWithLogFormatJSON(t *testing.T) {
+	var buf bytes.Buffer
+	log := logrus.New()
+	log.SetOutput(&buf)
+
+	b, err := New(
+		WithListenAddr("127.0.0.1:0"),
+		WithI2CPProvider(&mockI2CPProvider{}),
+		WithDatagramPort(0),
+		WithLogger(log),
+		WithLogFormat(LogFormatJSON),
+	)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	b.Dependencies().Logger.Info("hello")
+
+	var entry map[string]any
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	for _, line := range lines {
+		if err := json.Unmarshal([]byte(line), &entry); err != nil {
+			t.Fatalf("log line %q is not JSON: %v", line, err)
+		}
+	}
+	if entry["msg"] != "hello" {
+		t.Errorf("last msg = %v, want hello", entry["msg"])
+	}
+}
+
+func TestNewWithUnknownLogFormat(t *testing.T) {
+	_, err := New(WithListenAddr("127.0.0.1:0"), WithI2CPProvider(&mockI2CPProvider{}), WithLogFormat("xml"))
+	if !errors.Is(err, ErrUnknownLogFormat) {
+		t.Errorf("New() error = %v, want ErrUnknownLogFormat", err)
+	}
+}
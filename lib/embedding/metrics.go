@@ -0,0 +1,314 @@
+package embedding
+
+import (
+	"bufio"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/go-i2p/go-sam-bridge/lib/bridge"
+	"github.com/go-i2p/go-sam-bridge/lib/destination"
+	"github.com/go-i2p/go-sam-bridge/lib/session"
+)
+
+// metricsContentType is the Prometheus text exposition format version.
+const metricsContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// MetricsHandler returns an http.Handler serving bridge metrics in the
+// Prometheus text exposition format:
+//
+//	sam_bridge_up                                      1 while the bridge is running
+//	sam_bridge_healthy                                 1 when Health returns nil
+//	sam_bridge_i2cp_connected                          1 when the router is connected
+//	sam_bridge_i2cp_round_trip_seconds                 latest router round trip
+//	sam_bridge_i2cp_round_trip_average_seconds         mean router round trip
+//	sam_bridge_i2cp_pending_requests                   requests awaiting the router
+//	sam_bridge_i2cp_responsive                         0 when the router stopped answering
+//	sam_bridge_i2cp_errors_total{type}                 I2CP message errors by type
+//	sam_bridge_i2cp_disconnects_total                  router connection drops
+//	sam_bridge_uptime_seconds                          time since the bridge started serving
+//	sam_bridge_connections                             open SAM control connections
+//	sam_bridge_connections_total                       SAM control connections served
+//	sam_bridge_commands_total                          SAM commands processed
+//	sam_bridge_command_duration_seconds{command}       histogram of time per command
+//	sam_bridge_connections_queued                      connections waiting for a slot
+//	sam_bridge_connections_rejected_total              connections closed by the limit
+//	sam_bridge_forward_connections_total               STREAM FORWARD streams connected to their target
+//	sam_bridge_forward_dial_errors_total               failed attempts to reach a forward target
+//	sam_bridge_forward_failures_total                  forwarded streams dropped after every attempt failed
+//	sam_bridge_sessions{style}                         registered sessions by style
+//	sam_bridge_session_*_total{session}                per-session traffic counters
+//	sam_bridge_session_uptime_seconds{session}         time since the session was created
+//	sam_bridge_session_time_to_ready_seconds{session}  time its tunnels took to build
+//	sam_bridge_destination_cache_*                     parsed destination cache size and counters
+//
+// The sam_bridge_i2cp_* metrics other than sam_bridge_i2cp_connected
+// are present when the I2CP provider implements
+// session.I2CPHealthProvider. Per-session counters also carry a "label" label when the session has
+// one. They disappear when the session closes. Like
+// AdminHandler, the handler performs no authentication. WithMetricsAddr
+// serves it at /metrics while the bridge runs.
+func (b *Bridge) MetricsHandler() http.Handler {
+	return http.HandlerFunc(b.serveMetrics)
+}
+
+// MetricsAddr returns the address the metrics HTTP server is listening
+// on, or an empty string if it is not running.
+func (b *Bridge) MetricsAddr() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.metrics.addr()
+}
+
+// startMetrics serves MetricsHandler at /metrics on Config.MetricsAddr.
+// It is a no-op when no metrics address is configured.
+// Callers must hold b.mu.
+func (b *Bridge) startMetrics() error {
+	if b.config.MetricsAddr == "" {
+		return nil
+	}
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", b.MetricsHandler())
+	metrics, err := b.startHTTPEndpoint(b.config.MetricsAddr, mux, SourceMetrics)
+	if err != nil {
+		return err
+	}
+	b.metrics = metrics
+	return nil
+}
+
+// stopMetrics closes the metrics HTTP server, if running.
+func (b *Bridge) stopMetrics() {
+	b.mu.Lock()
+	metrics := b.metrics
+	b.metrics = nil
+	b.mu.Unlock()
+
+	if err := metrics.close(); err != nil {
+		b.deps.Logger.WithError(err).Warn("Error closing metrics HTTP server")
+	}
+}
+
+// sessionCounter describes one per-session traffic counter.
+type sessionCounter struct {
+	name  string
+	help  string
+	value func(session.StatsSnapshot) uint64
+}
+
+var sessionCounters = []sessionCounter{
+	{"sam_bridge_session_bytes_sent_total", "Payload bytes sent to I2P.", func(s session.StatsSnapshot) uint64 { return s.BytesSent }},
+	{"sam_bridge_session_bytes_received_total", "Payload bytes received from I2P.", func(s session.StatsSnapshot) uint64 { return s.BytesReceived }},
+	{"sam_bridge_session_streams_total", "Streams opened via CONNECT, ACCEPT and FORWARD.", func(s session.StatsSnapshot) uint64 { return s.Streams }},
+	{"sam_bridge_session_datagrams_sent_total", "Datagrams sent to I2P.", func(s session.StatsSnapshot) uint64 { return s.DatagramsSent }},
+	{"sam_bridge_session_datagrams_received_total", "Datagrams received from I2P.", func(s session.StatsSnapshot) uint64 { return s.DatagramsReceived }},
+	{"sam_bridge_session_datagrams_dropped_total", "Received datagrams dropped because the receive queue was full.", func(s session.StatsSnapshot) uint64 { return s.DatagramsDropped }},
+	{"sam_bridge_session_sends_dropped_total", "Outgoing datagrams dropped by the send queue.", func(s session.StatsSnapshot) uint64 { return s.SendsDropped }},
+	{"sam_bridge_session_send_retries_total", "Retried sends of queued datagrams.", func(s session.StatsSnapshot) uint64 { return s.SendRetries }},
+	{"sam_bridge_session_replays_dropped_total", "Received DATAGRAM2 messages discarded as replays.", func(s session.StatsSnapshot) uint64 { return s.ReplaysDropped }},
+}
+
+func (b *Bridge) serveMetrics(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	ids := b.deps.Registry.All()
+	sort.Strings(ids)
+
+	now := time.Now()
+	styles := make(map[string]int)
+	var stats []sessionStats
+	var timelines []sessionTimeline
+	for _, id := range ids {
+		// The session may close between All and Get
+		sess := b.deps.Registry.Get(id)
+		if sess == nil {
+			continue
+		}
+		styles[string(sess.Style())]++
+		if sp, ok := sess.(session.StatsProvider); ok {
+			stats = append(stats, sessionStats{id: id, label: session.Label(sess), snapshot: sp.Stats().Snapshot()})
+		}
+		if tp, ok := sess.(session.TimelineProvider); ok {
+			timelines = append(timelines, sessionTimeline{id: id, label: session.Label(sess), timeline: tp.Timeline()})
+		}
+	}
+
+	w.Header().Set("Content-Type", metricsContentType)
+	out := bufio.NewWriter(w)
+	defer out.Flush()
+
+	writeGauge(out, "sam_bridge_up", "Whether the bridge is running.", boolGauge(b.Running()))
+	writeGauge(out, "sam_bridge_healthy", "Whether the bridge is running and connected to the router.", boolGauge(b.Health() == nil))
+	writeGauge(out, "sam_bridge_i2cp_connected", "Whether the I2CP router connection is up.", boolGauge(b.deps.I2CPProvider.IsConnected()))
+	if health, ok := b.I2CPHealth(); ok {
+		writeI2CPHealth(out, health)
+	}
+	bridgeStats := b.Stats()
+	writeHeader(out, "sam_bridge_uptime_seconds", "Time since the bridge started serving.", "gauge")
+	fmt.Fprintf(out, "sam_bridge_uptime_seconds %g\n", bridgeStats.Uptime.Seconds())
+	writeGauge(out, "sam_bridge_connections", "Open SAM control connections.", bridgeStats.ConnectionsActive)
+	writeCounter(out, "sam_bridge_connections_total", "SAM control connections served.", bridgeStats.ConnectionsTotal)
+	writeCounter(out, "sam_bridge_commands_total", "SAM commands processed.", bridgeStats.Commands)
+	writeCommandLatencies(out, b.CommandLatencies())
+	accept := b.server.AcceptStats()
+	writeGauge(out, "sam_bridge_connections_queued", "SAM connections waiting for a free connection slot.", accept.Queued)
+	writeCounter(out, "sam_bridge_connections_rejected_total", "SAM connections closed unserved by the connection limit.", accept.Rejected)
+	writeCounter(out, "sam_bridge_connections_rejected_per_ip_total", "SAM connections closed unserved by the per-IP connection limit.", accept.RejectedPerIP)
+	forward := b.ForwardStats()
+	writeCounter(out, "sam_bridge_forward_connections_total", "STREAM FORWARD streams connected to their local target.", forward.Forwarded)
+	writeCounter(out, "sam_bridge_forward_dial_errors_total", "Failed attempts to connect to a STREAM FORWARD target, including retried ones.", forward.DialErrors)
+	writeCounter(out, "sam_bridge_forward_failures_total", "STREAM FORWARD streams dropped because every attempt to reach the target failed.", forward.Failures)
+
+	writeHeader(out, "sam_bridge_sessions", "Registered sessions by style.", "gauge")
+	styleNames := make([]string, 0, len(styles))
+	for style := range styles {
+		styleNames = append(styleNames, style)
+	}
+	sort.Strings(styleNames)
+	for _, style := range styleNames {
+		fmt.Fprintf(out, "sam_bridge_sessions{style=\"%s\"} %d\n", escapeLabel(style), styles[style])
+	}
+
+	for _, c := range sessionCounters {
+		writeHeader(out, c.name, c.help, "counter")
+		for _, s := range stats {
+			fmt.Fprintf(out, "%s{%s} %d\n", c.name, s.labels(), c.value(s.snapshot))
+		}
+	}
+
+	writeHeader(out, "sam_bridge_session_uptime_seconds", "Time since the session was created.", "gauge")
+	for _, s := range timelines {
+		fmt.Fprintf(out, "sam_bridge_session_uptime_seconds{%s} %g\n", s.labels(), s.timeline.Uptime(now).Seconds())
+	}
+	writeHeader(out, "sam_bridge_session_time_to_ready_seconds", "Time the session's tunnels took to build.", "gauge")
+	for _, s := range timelines {
+		if ready, ok := s.timeline.TimeToReady(); ok {
+			fmt.Fprintf(out, "sam_bridge_session_time_to_ready_seconds{%s} %g\n", s.labels(), ready.Seconds())
+		}
+	}
+
+	if cp, ok := b.deps.DestManager.(destination.CacheStatsProvider); ok {
+		cache := cp.CacheStats()
+		writeGauge(out, "sam_bridge_destination_cache_size", "Parsed destinations in the cache.", cache.Size)
+		writeGauge(out, "sam_bridge_destination_cache_capacity", "Most parsed destinations the cache holds.", cache.Capacity)
+		writeCounter(out, "sam_bridge_destination_cache_hits_total", "Destination parses answered from the cache.", cache.Hits)
+		writeCounter(out, "sam_bridge_destination_cache_misses_total", "Destination parses that missed the cache.", cache.Misses)
+		writeCounter(out, "sam_bridge_destination_cache_evictions_total", "Destinations evicted from the full cache.", cache.Evictions)
+	}
+	if pp, ok := b.deps.DestManager.(destination.PoolStatsProvider); ok && b.config.DestinationPoolSize > 0 {
+		pool := pp.PoolStats()
+		writeGauge(out, "sam_bridge_destination_pool_size", "Pre-generated destinations ready in the pool.", pool.Size)
+		writeGauge(out, "sam_bridge_destination_pool_capacity", "Most pre-generated destinations the pool holds.", pool.Capacity)
+		writeCounter(out, "sam_bridge_destination_pool_hits_total", "Destination generations answered from the pool.", pool.Hits)
+		writeCounter(out, "sam_bridge_destination_pool_misses_total", "Destination generations that found the pool empty.", pool.Misses)
+	}
+}
+
+// writeI2CPHealth writes the I2CP router connection health metrics.
+func writeI2CPHealth(out *bufio.Writer, health session.I2CPHealth) {
+	writeHeader(out, "sam_bridge_i2cp_round_trip_seconds", "Latest time the router took to answer a request.", "gauge")
+	fmt.Fprintf(out, "sam_bridge_i2cp_round_trip_seconds %g\n", health.RoundTrip.Seconds())
+	writeHeader(out, "sam_bridge_i2cp_round_trip_average_seconds", "Mean time the router took to answer a request.", "gauge")
+	fmt.Fprintf(out, "sam_bridge_i2cp_round_trip_average_seconds %g\n", health.AverageRoundTrip.Seconds())
+	writeGauge(out, "sam_bridge_i2cp_pending_requests", "I2CP requests awaiting the router's answer.", health.Pending)
+	writeGauge(out, "sam_bridge_i2cp_responsive", "Whether the router is answering I2CP requests.", boolGauge(!health.Unresponsive))
+
+	writeHeader(out, "sam_bridge_i2cp_errors_total", "I2CP message errors by type.", "counter")
+	types := make([]string, 0, len(health.Errors))
+	for typ := range health.Errors {
+		types = append(types, typ)
+	}
+	sort.Strings(types)
+	for _, typ := range types {
+		fmt.Fprintf(out, "sam_bridge_i2cp_errors_total{type=\"%s\"} %d\n", escapeLabel(typ), health.Errors[typ])
+	}
+	writeCounter(out, "sam_bridge_i2cp_disconnects_total", "Times the router connection dropped.", health.Disconnects)
+}
+
+// writeCommandLatencies writes the command latency histograms.
+func writeCommandLatencies(out *bufio.Writer, latencies []bridge.CommandLatency) {
+	const name = "sam_bridge_command_duration_seconds"
+	writeHeader(out, name, "Time from reading a SAM command to writing its response.", "histogram")
+	for _, lat := range latencies {
+		command := escapeLabel(lat.Command)
+		for i, bound := range bridge.LatencyBuckets {
+			fmt.Fprintf(out, "%s_bucket{command=\"%s\",le=\"%g\"} %d\n", name, command, bound.Seconds(), lat.Buckets[i])
+		}
+		fmt.Fprintf(out, "%s_bucket{command=\"%s\",le=\"+Inf\"} %d\n", name, command, lat.Count)
+		fmt.Fprintf(out, "%s_sum{command=\"%s\"} %g\n", name, command, lat.Sum.Seconds())
+		fmt.Fprintf(out, "%s_count{command=\"%s\"} %d\n", name, command, lat.Count)
+	}
+}
+
+// sessionStats pairs a session ID and label with its counters.
+type sessionStats struct {
+	id       string
+	label    string
+	snapshot session.StatsSnapshot
+}
+
+// labels returns the metric labels identifying the session.
+func (s sessionStats) labels() string {
+	return sessionLabels(s.id, s.label)
+}
+
+// sessionTimeline pairs a session ID and label with its status times.
+type sessionTimeline struct {
+	id       string
+	label    string
+	timeline session.Timeline
+}
+
+// labels returns the metric labels identifying the session.
+func (s sessionTimeline) labels() string {
+	return sessionLabels(s.id, s.label)
+}
+
+// sessionLabels formats the metric labels identifying a session. The
+// label label is omitted when the session has none.
+func sessionLabels(id, label string) string {
+	labels := fmt.Sprintf("session=\"%s\"", escapeLabel(id))
+	if label != "" {
+		labels += fmt.Sprintf(",label=\"%s\"", escapeLabel(label))
+	}
+	return labels
+}
+
+// writeHeader writes the HELP and TYPE lines for a metric family.
+func writeHeader(out *bufio.Writer, name, help, typ string) {
+	fmt.Fprintf(out, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, typ)
+}
+
+// writeGauge writes an unlabelled gauge.
+func writeGauge(out *bufio.Writer, name, help string, value int) {
+	writeHeader(out, name, help, "gauge")
+	fmt.Fprintf(out, "%s %d\n", name, value)
+}
+
+// writeCounter writes an unlabelled counter.
+func writeCounter(out *bufio.Writer, name, help string, value uint64) {
+	writeHeader(out, name, help, "counter")
+	fmt.Fprintf(out, "%s %d\n", name, value)
+}
+
+// boolGauge converts v to 1 or 0.
+func boolGauge(v bool) int {
+	if v {
+		return 1
+	}
+	return 0
+}
+
+// labelEscaper escapes label values per the text exposition format.
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// escapeLabel escapes a label value for the text exposition format.
+func escapeLabel(v string) string {
+	return labelEscaper.Replace(v)
+}
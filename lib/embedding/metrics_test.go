@@ -0,0 +1,174 @@
+package embedding
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-i2p/go-sam-bridge/lib/bridge"
+	"github.com/go-i2p/go-sam-bridge/lib/session"
+)
+
+func TestBridgeMetricsHandler(t *testing.T) {
+	b, err := New(WithListenAddr("127.0.0.1:0"), WithI2CPProvider(&mockI2CPProvider{}), WithDatagramPort(0))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if err := b.Start(context.Background()); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	defer b.Stop(context.Background())
+
+	sess := session.NewBaseSession(`metrics"1`, session.StyleRaw, nil, nil, nil)
+	if err := b.Dependencies().Registry.Register(sess); err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+	sess.Stats().AddDatagramSent(32)
+	sess.Stats().AddSendDropped()
+
+	cfg := session.DefaultSessionConfig()
+	cfg.Label = "web"
+	labelled := session.NewBaseSession("metrics-2", session.StyleStream, nil, nil, cfg)
+	if err := b.Dependencies().Registry.Register(labelled); err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+
+	rec := getAdmin(t, b.MetricsHandler(), "/metrics")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("/metrics = %d, want %d", rec.Code, http.StatusOK)
+	}
+	if ct := rec.Header().Get("Content-Type"); ct != metricsContentType {
+		t.Errorf("Content-Type = %q, want %q", ct, metricsContentType)
+	}
+
+	body := rec.Body.String()
+	for _, want := range []string{
+		"# TYPE sam_bridge_up gauge\nsam_bridge_up 1\n",
+		"sam_bridge_i2cp_connected 1\n",
+		"# TYPE sam_bridge_uptime_seconds gauge\nsam_bridge_uptime_seconds ",
+		"sam_bridge_connections 0\n",
+		"# TYPE sam_bridge_connections_total counter\nsam_bridge_connections_total 0\n",
+		"# TYPE sam_bridge_commands_total counter\nsam_bridge_commands_total 0\n",
+		"sam_bridge_connections_queued 0\n",
+		"# TYPE sam_bridge_connections_rejected_total counter\nsam_bridge_connections_rejected_total 0\n",
+		"# TYPE sam_bridge_forward_failures_total counter\nsam_bridge_forward_failures_total 0\n",
+		`sam_bridge_sessions{style="RAW"} 1` + "\n",
+		"# TYPE sam_bridge_session_bytes_sent_total counter\n",
+		`sam_bridge_session_bytes_sent_total{session="metrics\"1"} 32` + "\n",
+		`sam_bridge_session_datagrams_sent_total{session="metrics\"1"} 1` + "\n",
+		`sam_bridge_session_sends_dropped_total{session="metrics\"1"} 1` + "\n",
+		`sam_bridge_session_streams_total{session="metrics-2",label="web"} 0` + "\n",
+		`sam_bridge_session_uptime_seconds{session="metrics-2",label="web"} `,
+		"# TYPE sam_bridge_session_time_to_ready_seconds gauge\n",
+		"sam_bridge_destination_cache_capacity 1000\n",
+		"# TYPE sam_bridge_destination_cache_hits_total counter\nsam_bridge_destination_cache_hits_total 0\n",
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("/metrics missing %q:\n%s", want, body)
+		}
+	}
+}
+
+func TestBridgeMetricsHandler_I2CPHealth(t *testing.T) {
+	provider := &healthProvider{health: session.I2CPHealth{
+		RoundTrip:        250 * time.Millisecond,
+		AverageRoundTrip: 500 * time.Millisecond,
+		Errors:           map[string]uint64{"protocol": 2, "network": 1},
+		Disconnects:      3,
+		Pending:          1,
+	}}
+	b, err := New(WithListenAddr("127.0.0.1:0"), WithI2CPProvider(provider), WithDatagramPort(0))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	body := getAdmin(t, b.MetricsHandler(), "/metrics").Body.String()
+	for _, want := range []string{
+		"sam_bridge_i2cp_round_trip_seconds 0.25\n",
+		"sam_bridge_i2cp_round_trip_average_seconds 0.5\n",
+		"sam_bridge_i2cp_pending_requests 1\n",
+		"sam_bridge_i2cp_responsive 1\n",
+		`sam_bridge_i2cp_errors_total{type="network"} 1` + "\n" + `sam_bridge_i2cp_errors_total{type="protocol"} 2` + "\n",
+		"# TYPE sam_bridge_i2cp_disconnects_total counter\nsam_bridge_i2cp_disconnects_total 3\n",
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("/metrics missing %q:\n%s", want, body)
+		}
+	}
+}
+
+func TestBridgeWithMetricsAddr(t *testing.T) {
+	b, err := New(
+		WithListenAddr("127.0.0.1:0"),
+		WithI2CPProvider(&mockI2CPProvider{}),
+		WithDatagramPort(0),
+		WithMetricsAddr("127.0.0.1:0"),
+	)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if err := b.Start(context.Background()); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	addr := b.MetricsAddr()
+	if addr == "" {
+		t.Fatal("MetricsAddr() should be set while running")
+	}
+
+	resp, err := http.Get("http://" + addr + "/metrics")
+	if err != nil {
+		t.Fatalf("GET /metrics error = %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if !strings.Contains(string(body), "sam_bridge_up 1") {
+		t.Errorf("GET /metrics body = %q, want sam_bridge_up 1", body)
+	}
+
+	b.Stop(context.Background())
+	if b.MetricsAddr() != "" {
+		t.Error("MetricsAddr() should be empty after Stop")
+	}
+}
+
+func TestEscapeLabel(t *testing.T) {
+	if got := escapeLabel("a\\b\"c\nd"); got != `a\\b\"c\nd` {
+		t.Errorf("escapeLabel() = %q", got)
+	}
+}
+
+func TestWriteCommandLatencies(t *testing.T) {
+	buckets := make([]uint64, len(bridge.LatencyBuckets))
+	for i, bound := range bridge.LatencyBuckets {
+		if bound >= 10*time.Second {
+			buckets[i] = 1
+		}
+	}
+	var buf bytes.Buffer
+	out := bufio.NewWriter(&buf)
+	writeCommandLatencies(out, []bridge.CommandLatency{
+		{Command: "SESSION CREATE", Buckets: buckets, Count: 2, Sum: 90 * time.Second},
+	})
+	out.Flush()
+
+	body := buf.String()
+	for _, want := range []string{
+		"# TYPE sam_bridge_command_duration_seconds histogram\n",
+		`sam_bridge_command_duration_seconds_bucket{command="SESSION CREATE",le="0.001"} 0` + "\n",
+		`sam_bridge_command_duration_seconds_bucket{command="SESSION CREATE",le="5"} 0` + "\n",
+		`sam_bridge_command_duration_seconds_bucket{command="SESSION CREATE",le="10"} 1` + "\n",
+		`sam_bridge_command_duration_seconds_bucket{command="SESSION CREATE",le="120"} 1` + "\n",
+		`sam_bridge_command_duration_seconds_bucket{command="SESSION CREATE",le="+Inf"} 2` + "\n",
+		`sam_bridge_command_duration_seconds_sum{command="SESSION CREATE"} 90` + "\n",
+		`sam_bridge_command_duration_seconds_count{command="SESSION CREATE"} 2` + "\n",
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("histogram missing %q:\n%s", want, body)
+		}
+	}
+}
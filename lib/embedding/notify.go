@@ -0,0 +1,109 @@
+package embedding
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/go-i2p/go-sam-bridge/lib/handler"
+	"github.com/sirupsen/logrus"
+)
+
+// errorBufferSize is the capacity of the channel returned by Bridge.Errors.
+// Errors reported while the buffer is full are dropped after logging.
+const errorBufferSize = 16
+
+// Sources of background errors reported through Bridge.Errors.
+const (
+	// SourceServer identifies the SAM control socket accept loop.
+	SourceServer = "server"
+
+	// SourceUDP identifies the UDP datagram listener.
+	SourceUDP = "udp"
+
+	// SourceI2CP identifies the I2CP router connection.
+	SourceI2CP = "i2cp"
+
+	// SourceForwarder identifies STREAM FORWARD connections.
+	SourceForwarder = "forwarder"
+
+	// SourceAdmin identifies the admin HTTP server.
+	SourceAdmin = "admin"
+
+	// SourceMetrics identifies the metrics HTTP server.
+	SourceMetrics = "metrics"
+
+	// SourcePprof identifies the pprof HTTP server.
+	SourcePprof = "pprof"
+
+	// SourceI2PControl identifies the I2PControl HTTP server.
+	SourceI2PControl = "i2pcontrol"
+
+	// SourceSOCKS identifies the SOCKS5 proxy and the streams it opens.
+	SourceSOCKS = "socks"
+
+	// SourceHTTPProxy identifies the HTTP proxy and the streams it opens.
+	SourceHTTPProxy = "http_proxy"
+
+	// SourceBOB identifies the BOB listener and its tunnels.
+	SourceBOB = "bob"
+
+	// SourceTunnels identifies the configured client and server tunnels.
+	SourceTunnels = "tunnels"
+)
+
+// BackgroundError is a failure that happened outside any caller's request,
+// such as an accept loop error or an I2CP disconnect.
+type BackgroundError struct {
+	// Source identifies the failing component (SourceServer, SourceI2CP, ...).
+	Source string
+
+	// Err is the underlying error.
+	Err error
+}
+
+// Error implements the error interface.
+func (e *BackgroundError) Error() string {
+	return fmt.Sprintf("embedding: %s: %v", e.Source, e.Err)
+}
+
+// Unwrap returns the underlying error.
+func (e *BackgroundError) Unwrap() error {
+	return e.Err
+}
+
+// ErrorNotifier is implemented by dependencies that detect failures in
+// the background, such as an I2CP provider noticing a router disconnect.
+// If the configured I2CPProvider implements it, New registers a handler
+// that forwards those failures to Bridge.Errors.
+type ErrorNotifier interface {
+	SetErrorHandler(fn func(error))
+}
+
+// newErrorReporter returns a function that logs err and delivers it to
+// errs as a BackgroundError without blocking. Errors about a session, a
+// handler.SessionError, are logged with its SessionFields. Log records
+// are sampled per source; every error still reaches errs if there is
+// room.
+func newErrorReporter(errs chan<- error, log *logrus.Logger, sampler *logSampler) func(source string, err error) {
+	return func(source string, err error) {
+		if err == nil {
+			return
+		}
+		bgErr := &BackgroundError{Source: source, Err: err}
+		entry := log.WithError(err).WithField("source", source)
+		if sessErr := (*handler.SessionError)(nil); errors.As(err, &sessErr) {
+			entry = entry.WithFields(SessionFields(sessErr.Session))
+		}
+		if entry = sampler.entry(source, entry); entry != nil {
+			entry.Warn("Background error")
+		}
+
+		select {
+		case errs <- bgErr:
+		default:
+			if entry := sampler.entry(source+".dropped", log.WithField("source", source)); entry != nil {
+				entry.Debug("Error channel full; dropping error")
+			}
+		}
+	}
+}
@@ -0,0 +1,117 @@
+package embedding
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestBackgroundError(t *testing.T) {
+	cause := errors.New("connection reset")
+	err := &BackgroundError{Source: SourceI2CP, Err: cause}
+
+	if err.Error() != "embedding: i2cp: connection reset" {
+		t.Errorf("Error() = %q", err.Error())
+	}
+	if !errors.Is(err, cause) {
+		t.Error("errors.Is should match the underlying error")
+	}
+}
+
+func TestErrorReporter_DropsWhenFull(t *testing.T) {
+	errs := make(chan error, 1)
+	report := newErrorReporter(errs, logrus.New(), nil)
+
+	report(SourceServer, errors.New("first"))
+	report(SourceServer, errors.New("second")) // must not block
+	report(SourceServer, nil)                  // ignored
+
+	if len(errs) != 1 {
+		t.Fatalf("len(errs) = %d, want 1", len(errs))
+	}
+	var bgErr *BackgroundError
+	if err := <-errs; !errors.As(err, &bgErr) || bgErr.Err.Error() != "first" {
+		t.Errorf("got %v, want first error", err)
+	}
+}
+
+// notifyingProvider is an I2CP provider that implements ErrorNotifier.
+type notifyingProvider struct {
+	mockI2CPProvider
+	handler func(error)
+}
+
+func (p *notifyingProvider) SetErrorHandler(fn func(error)) { p.handler = fn }
+
+func TestBridgeErrors_I2CPProvider(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to create test listener: %v", err)
+	}
+	defer ln.Close()
+
+	provider := &notifyingProvider{}
+	b, err := New(WithListener(ln), WithI2CPProvider(provider))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if provider.handler == nil {
+		t.Fatal("New() should register an error handler on the provider")
+	}
+
+	cause := errors.New("router went away")
+	provider.handler(cause)
+
+	select {
+	case err := <-b.Errors():
+		var bgErr *BackgroundError
+		if !errors.As(err, &bgErr) || bgErr.Source != SourceI2CP || !errors.Is(err, cause) {
+			t.Errorf("Errors() got %v, want i2cp BackgroundError", err)
+		}
+	default:
+		t.Fatal("Errors() should deliver the reported failure")
+	}
+}
+
+// failingListener returns a permanent error from Accept.
+type failingListener struct {
+	net.Listener
+}
+
+func (l *failingListener) Accept() (net.Conn, error) {
+	return nil, errors.New("accept failed")
+}
+
+func TestBridgeErrors_AcceptLoop(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to create test listener: %v", err)
+	}
+	defer ln.Close()
+
+	b, err := New(
+		WithListener(&failingListener{Listener: ln}),
+		WithI2CPProvider(&mockI2CPProvider{}),
+		WithDatagramPort(0),
+	)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if err := b.Start(context.Background()); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+
+	select {
+	case err := <-b.Errors():
+		var bgErr *BackgroundError
+		if !errors.As(err, &bgErr) || bgErr.Source != SourceServer {
+			t.Errorf("Errors() got %v, want server BackgroundError", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Errors() did not deliver the accept loop failure")
+	}
+}
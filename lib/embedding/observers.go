@@ -0,0 +1,18 @@
+package embedding
+
+import "github.com/go-i2p/go-sam-bridge/lib/session"
+
+// addSessionObservers subscribes the configured observers to registry.
+func addSessionObservers(cfg *Config, registry session.Registry) error {
+	if len(cfg.SessionObservers) == 0 {
+		return nil
+	}
+	observable, ok := registry.(session.Observable)
+	if !ok {
+		return ErrRegistryNotObservable
+	}
+	for _, o := range cfg.SessionObservers {
+		observable.AddObserver(o)
+	}
+	return nil
+}
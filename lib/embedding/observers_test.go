@@ -0,0 +1,51 @@
+package embedding
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/go-i2p/go-sam-bridge/lib/session"
+)
+
+func TestSessionObserver(t *testing.T) {
+	var registered, unregistered []string
+	b, err := New(
+		WithListenAddr("127.0.0.1:0"),
+		WithI2CPProvider(&mockI2CPProvider{}),
+		WithDatagramPort(0),
+		WithSessionObserver(session.ObserverFuncs{
+			Registered:   func(s session.Session) { registered = append(registered, s.ID()) },
+			Unregistered: func(s session.Session) { unregistered = append(unregistered, s.ID()) },
+		}),
+	)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	sess := session.NewBaseSession("obs1", session.StyleStream, nil, nil, nil)
+	if err := b.deps.Registry.Register(sess); err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+	if err := b.deps.Registry.Unregister("obs1"); err != nil {
+		t.Fatalf("Unregister() error = %v", err)
+	}
+
+	if len(registered) != 1 || registered[0] != "obs1" {
+		t.Errorf("registered = %v, want [obs1]", registered)
+	}
+	if len(unregistered) != 1 || unregistered[0] != "obs1" {
+		t.Errorf("unregistered = %v, want [obs1]", unregistered)
+	}
+}
+
+func TestSessionObserverRequiresObservableRegistry(t *testing.T) {
+	_, err := New(
+		WithListenAddr("127.0.0.1:0"),
+		WithI2CPProvider(&mockI2CPProvider{}),
+		WithRegistry(&mockRegistry{}),
+		WithSessionObserver(session.ObserverFuncs{}),
+	)
+	if !errors.Is(err, ErrRegistryNotObservable) {
+		t.Errorf("New() error = %v, want ErrRegistryNotObservable", err)
+	}
+}
@@ -2,9 +2,16 @@ package embedding
 
 import (
 	"crypto/tls"
+	"io"
 	"net"
+	"time"
 
+	"github.com/go-i2p/go-sam-bridge/lib/bridge"
+	"github.com/go-i2p/go-sam-bridge/lib/destination"
+	"github.com/go-i2p/go-sam-bridge/lib/handler"
 	"github.com/go-i2p/go-sam-bridge/lib/session"
+	"github.com/go-i2p/go-sam-bridge/lib/tunnels"
+	"github.com/go-i2p/go-sam-bridge/lib/util"
 	"github.com/sirupsen/logrus"
 )
 
@@ -35,6 +42,16 @@ func WithDatagramPort(port int) Option {
 	}
 }
 
+// WithDatagramPacketConn sets a pre-bound UDP socket for datagram
+// forwarding, e.g. from systemd socket activation or a test harness.
+// When provided, the datagram port is ignored. The bridge takes ownership
+// of conn and closes it when it stops.
+func WithDatagramPacketConn(conn net.PacketConn) Option {
+	return func(c *Config) {
+		c.DatagramPacketConn = conn
+	}
+}
+
 // WithListener sets a custom net.Listener for the SAM server.
 // When provided, ListenAddr is ignored and the bridge uses this listener.
 func WithListener(l net.Listener) Option {
@@ -43,6 +60,24 @@ func WithListener(l net.Listener) Option {
 	}
 }
 
+// WithEndpoints adds control sockets served alongside the listen address.
+// It may be given more than once; endpoints accumulate.
+func WithEndpoints(endpoints ...Endpoint) Option {
+	return func(c *Config) {
+		c.Endpoints = append(c.Endpoints, endpoints...)
+	}
+}
+
+// WithProxyProtocol makes the listen address or listener expect a PROXY
+// protocol v1 or v2 header on every connection, so that authentication,
+// connection limits and audit records see the client behind a TCP load
+// balancer. Only enable it where the load balancer alone can connect.
+func WithProxyProtocol(enabled bool) Option {
+	return func(c *Config) {
+		c.ProxyProtocol = enabled
+	}
+}
+
 // WithRegistry sets a custom session registry.
 // When provided, the bridge uses this registry instead of creating its own.
 func WithRegistry(r session.Registry) Option {
@@ -51,6 +86,16 @@ func WithRegistry(r session.Registry) Option {
 	}
 }
 
+// WithSessionObserver adds an observer notified when sessions are
+// registered and unregistered. It may be given more than once.
+// New returns ErrRegistryNotObservable if a custom registry set with
+// WithRegistry does not implement session.Observable.
+func WithSessionObserver(o session.Observer) Option {
+	return func(c *Config) {
+		c.SessionObservers = append(c.SessionObservers, o)
+	}
+}
+
 // WithI2CPProvider sets a custom I2CP session provider.
 // When provided, the bridge uses this provider instead of creating its own.
 func WithI2CPProvider(p session.I2CPSessionProvider) Option {
@@ -67,6 +112,15 @@ func WithLogger(l *logrus.Logger) Option {
 	}
 }
 
+// WithSharedI2CP uses an I2CP provider shared with other bridges, so that
+// bridges in the same process share one router connection. The bridge
+// holds a reference to shared until it stops. See SharedI2CP.
+func WithSharedI2CP(shared *SharedI2CP) Option {
+	return func(c *Config) {
+		c.SharedI2CP = shared
+	}
+}
+
 // WithTLS enables TLS on the SAM control socket.
 // Per SAM 3.2, optional SSL/TLS support may be offered.
 func WithTLS(cfg *tls.Config) Option {
@@ -75,8 +129,36 @@ func WithTLS(cfg *tls.Config) Option {
 	}
 }
 
+// WithTLSFiles enables TLS using a certificate and key loaded from disk.
+// The pair can be reloaded without a restart via Bridge.ReloadTLS.
+func WithTLSFiles(certFile, keyFile string) Option {
+	return func(c *Config) {
+		c.TLSCertFile = certFile
+		c.TLSKeyFile = keyFile
+	}
+}
+
+// WithTLSPolicy sets the minimum TLS version, cipher suites and ALPN
+// protocols of the SAM control socket, on top of WithTLS or WithTLSFiles.
+// Zero fields of policy keep the current setting, so a later option can
+// override part of an earlier one.
+func WithTLSPolicy(policy TLSPolicy) Option {
+	return func(c *Config) {
+		if policy.MinVersion != 0 {
+			c.TLSPolicy.MinVersion = policy.MinVersion
+		}
+		if policy.CipherSuites != nil {
+			c.TLSPolicy.CipherSuites = policy.CipherSuites
+		}
+		if policy.NextProtos != nil {
+			c.TLSPolicy.NextProtos = policy.NextProtos
+		}
+	}
+}
+
 // WithAuth sets the SAM authentication users.
 // Per SAM 3.2, optional authorization with USER/PASSWORD is supported.
+// Passwords may be bcrypt or Argon2id hashes (see bridge.HashPassword).
 func WithAuth(users map[string]string) Option {
 	return func(c *Config) {
 		c.AuthUsers = make(map[string]string, len(users))
@@ -86,6 +168,225 @@ func WithAuth(users map[string]string) Option {
 	}
 }
 
+// WithAuthFunc sets a callback that validates SAM USER/PASSWORD
+// credentials, for applications with their own identity store.
+// It enables authentication and takes precedence over WithAuth users.
+// HELLO is rejected unless the callback accepts the credentials.
+func WithAuthFunc(fn func(user, password string) bool) Option {
+	return func(c *Config) {
+		c.AuthFunc = fn
+	}
+}
+
+// WithSessionDefaults sets the tunnel quantity, length, backup quantity,
+// and idle behavior applied to every SESSION CREATE that does not specify
+// them. Start from DefaultSessionDefaults:
+//
+//	d := embedding.DefaultSessionDefaults()
+//	d.InboundQuantity, d.OutboundQuantity = 5, 5
+//	embedding.WithSessionDefaults(d)
+func WithSessionDefaults(d SessionDefaults) Option {
+	return func(c *Config) {
+		c.SessionDefaults = &d
+	}
+}
+
+// WithKeyStore enables SESSION CREATE DESTINATION=file:$name, loading and
+// saving private keys through store. Use it to protect keys with a KMS:
+//
+//	kms := destination.KMSWrapper{Encrypt: wrap, Decrypt: unwrap}
+//	embedding.WithKeyStore(destination.NewKeyStore("/var/lib/sam-bridge/keys", kms))
+func WithKeyStore(store *destination.KeyStore) Option {
+	return func(c *Config) {
+		c.KeyStore = store
+	}
+}
+
+// WithKeyStoreDir enables SESSION CREATE DESTINATION=file:$name with keys
+// stored in dir, encrypted under the WithKeyStorePassphrase passphrase.
+func WithKeyStoreDir(dir string) Option {
+	return func(c *Config) {
+		c.KeyStoreDir = dir
+	}
+}
+
+// WithKeyStorePassphrase sets the passphrase for WithKeyStoreDir.
+func WithKeyStorePassphrase(passphrase string) Option {
+	return func(c *Config) {
+		c.KeyStorePassphrase = passphrase
+	}
+}
+
+// WithAuditLog writes an audit record for every processed SAM command
+// to w: timestamp, client address, user, command, and result code.
+// Passwords and private key material are redacted.
+func WithAuditLog(w io.Writer) Option {
+	return func(c *Config) {
+		c.AuditLog = w
+	}
+}
+
+// WithAuditLogFile appends audit records to the file at path.
+// The file is created with mode 0600 if it does not exist and is
+// closed when the bridge stops.
+func WithAuditLogFile(path string) Option {
+	return func(c *Config) {
+		c.AuditLogFile = path
+	}
+}
+
+// WithAccessLog writes an access record for every SAM control connection
+// to w when it closes: connect and disconnect times, client address,
+// negotiated SAM version, user, and bytes transferred. It is separate
+// from the debug log and from WithAuditLog, which records commands.
+func WithAccessLog(w io.Writer) Option {
+	return func(c *Config) {
+		c.AccessLog = w
+	}
+}
+
+// WithAccessLogFile appends access records to the file at path.
+// The file is created with mode 0600 if it does not exist and is
+// closed when the bridge stops.
+func WithAccessLogFile(path string) Option {
+	return func(c *Config) {
+		c.AccessLogFile = path
+	}
+}
+
+// WithLogFormat sets the logger's output format: LogFormatText or
+// LogFormatJSON. It applies to loggers given with WithLogger too.
+func WithLogFormat(format string) Option {
+	return func(c *Config) {
+		c.LogFormat = format
+	}
+}
+
+// WithAdminAddr serves the JSON admin API (see Bridge.AdminHandler) on
+// addr while the bridge runs. The API is unauthenticated, so addr should
+// be a loopback address.
+func WithAdminAddr(addr string) Option {
+	return func(c *Config) {
+		c.AdminAddr = addr
+	}
+}
+
+// WithI2PControlAddr serves the I2PControl JSON-RPC API (see
+// Bridge.I2PControlHandler) on addr while the bridge runs.
+func WithI2PControlAddr(addr string) Option {
+	return func(c *Config) {
+		c.I2PControlAddr = addr
+	}
+}
+
+// WithI2PControlPassword sets the password I2PControl clients
+// authenticate with, replacing DefaultI2PControlPassword. It may be a
+// bcrypt or Argon2id hash.
+func WithI2PControlPassword(password string) Option {
+	return func(c *Config) {
+		c.I2PControlPassword = password
+	}
+}
+
+// WithSOCKSAddr serves a SOCKS5 proxy on addr while the bridge runs,
+// connecting clients to .i2p and .b32.i2p hosts through a shared STREAM
+// session. The proxy has no authentication of its own; bind it to a
+// loopback address.
+func WithSOCKSAddr(addr string) Option {
+	return func(c *Config) {
+		c.SOCKSAddr = addr
+	}
+}
+
+// WithSOCKSAuth sets the SAM credentials the SOCKS5 proxy uses when the
+// bridge requires authentication.
+func WithSOCKSAuth(user, password string) Option {
+	return func(c *Config) {
+		c.SOCKSUser = user
+		c.SOCKSPassword = password
+	}
+}
+
+// WithHTTPProxyAddr serves an HTTP proxy on addr while the bridge runs,
+// tunneling CONNECT requests and forwarding absolute-URI requests to .i2p
+// and .b32.i2p hosts through a shared STREAM session. The proxy has no
+// authentication of its own; bind it to a loopback address.
+func WithHTTPProxyAddr(addr string) Option {
+	return func(c *Config) {
+		c.HTTPProxyAddr = addr
+	}
+}
+
+// WithHTTPProxyAuth sets the SAM credentials the HTTP proxy uses when the
+// bridge requires authentication.
+func WithHTTPProxyAuth(user, password string) Option {
+	return func(c *Config) {
+		c.HTTPProxyUser = user
+		c.HTTPProxyPassword = password
+	}
+}
+
+// WithHTTPOutproxy sends the HTTP proxy's requests for clearnet hosts
+// through the HTTP proxy at addr, an .i2p host:port. Without it such
+// requests are rejected.
+func WithHTTPOutproxy(addr string) Option {
+	return func(c *Config) {
+		c.HTTPOutproxy = addr
+	}
+}
+
+// WithBOBAddr serves a BOB listener on addr while the bridge runs. Each
+// tunnel a BOB client starts is carried by its own STREAM session. BOB
+// has no authentication of its own; bind it to a loopback address.
+func WithBOBAddr(addr string) Option {
+	return func(c *Config) {
+		c.BOBAddr = addr
+	}
+}
+
+// WithBOBAuth sets the SAM credentials the BOB listener uses when the
+// bridge requires authentication.
+func WithBOBAuth(user, password string) Option {
+	return func(c *Config) {
+		c.BOBUser = user
+		c.BOBPassword = password
+	}
+}
+
+// WithTunnels runs the given client and server tunnels while the bridge
+// runs. It may be used more than once; tunnels accumulate.
+func WithTunnels(list ...tunnels.Tunnel) Option {
+	return func(c *Config) {
+		c.Tunnels = append(c.Tunnels, list...)
+	}
+}
+
+// WithTunnelAuth sets the SAM credentials tunnels use when the bridge
+// requires authentication.
+func WithTunnelAuth(user, password string) Option {
+	return func(c *Config) {
+		c.TunnelUser = user
+		c.TunnelPassword = password
+	}
+}
+
+// WithMetricsAddr serves Prometheus metrics (see Bridge.MetricsHandler)
+// at /metrics on addr while the bridge runs.
+func WithMetricsAddr(addr string) Option {
+	return func(c *Config) {
+		c.MetricsAddr = addr
+	}
+}
+
+// WithPprofAddr serves the net/http/pprof handlers at /debug/pprof/ on
+// addr while the bridge runs, so operators can capture CPU and heap
+// profiles. addr must be a loopback address.
+func WithPprofAddr(addr string) Option {
+	return func(c *Config) {
+		c.PprofAddr = addr
+	}
+}
+
 // WithI2CPCredentials sets I2CP authentication credentials.
 func WithI2CPCredentials(username, password string) Option {
 	return func(c *Config) {
@@ -94,6 +395,22 @@ func WithI2CPCredentials(username, password string) Option {
 	}
 }
 
+// WithI2CPTLS connects to the I2CP router over TLS, for routers exposing
+// an SSL-enabled I2CP port (i2cp.SSL=true).
+func WithI2CPTLS(tlsConfig I2CPTLSConfig) Option {
+	return func(c *Config) {
+		c.I2CPTLS = &tlsConfig
+	}
+}
+
+// WithI2CPFailoverAddrs sets further I2CP router addresses, tried in
+// order when the router at the I2CP address is unreachable or drops.
+func WithI2CPFailoverAddrs(addrs ...string) Option {
+	return func(c *Config) {
+		c.I2CPFailoverAddrs = addrs
+	}
+}
+
 // WithHandlerRegistrar sets a custom handler registration function.
 // This allows embedders to customize which handlers are registered
 // or add custom handlers to the router.
@@ -103,6 +420,321 @@ func WithHandlerRegistrar(fn HandlerRegistrarFunc) Option {
 	}
 }
 
+// WithHandshakeTimeout sets the maximum time to wait for HELLO after a
+// client connects. Per SAM 3.2, servers may implement timeouts for HELLO.
+// Default is 30 seconds.
+func WithHandshakeTimeout(d time.Duration) Option {
+	return func(c *Config) {
+		c.HandshakeTimeout = d
+	}
+}
+
+// WithCommandTimeout sets the maximum time to wait between commands
+// after HELLO. Per SAM 3.2, servers may implement timeouts for
+// subsequent commands. Default is 60 seconds.
+func WithCommandTimeout(d time.Duration) Option {
+	return func(c *Config) {
+		c.CommandTimeout = d
+	}
+}
+
+// WithIdleTimeout closes control connections that completed HELLO but
+// have not created a session or sent a command for d, after a SESSION
+// STATUS RESULT=I2P_ERROR line. Connections with a session are not
+// affected. Zero, the default, disables it.
+func WithIdleTimeout(d time.Duration) Option {
+	return func(c *Config) {
+		c.IdleTimeout = d
+	}
+}
+
+// WithReadBufferSize sets the buffer size used to read client commands.
+// Default is 8192 bytes.
+func WithReadBufferSize(size int) Option {
+	return func(c *Config) {
+		c.ReadBufferSize = size
+	}
+}
+
+// WithMaxLineLength sets the maximum allowed command line length.
+// Longer lines close the connection to prevent memory exhaustion.
+// Default is 65536 bytes.
+func WithMaxLineLength(n int) Option {
+	return func(c *Config) {
+		c.MaxLineLength = n
+	}
+}
+
+// WithMaxConnections limits how many SAM client connections the bridge
+// handles at once, and how many more may queue for a free slot until the
+// handshake timeout. Connections beyond both are closed unserved and
+// counted in sam_bridge_connections_rejected_total.
+func WithMaxConnections(limit, queue int) Option {
+	return func(c *Config) {
+		c.MaxConnections = limit
+		c.MaxQueuedConnections = queue
+	}
+}
+
+// WithMaxConnectionsPerIP limits how many SAM client connections from
+// one remote IP address the bridge handles at once, containing clients
+// that leak connections. Connections beyond the limit get a HELLO REPLY
+// RESULT=I2P_ERROR line and are closed, and are counted in
+// sam_bridge_connections_rejected_per_ip_total. Zero means no limit.
+func WithMaxConnectionsPerIP(limit int) Option {
+	return func(c *Config) {
+		c.MaxConnectionsPerIP = limit
+	}
+}
+
+// WithStreamBufferSize sets the size of the buffers stream data is
+// copied through between SAM clients and I2P, per direction. Buffers are
+// pooled, so larger sizes cost memory only per active copy. Default is
+// 32768 bytes.
+func WithStreamBufferSize(size int) Option {
+	return func(c *Config) {
+		c.StreamBufferSize = size
+	}
+}
+
+// WithForwardRetry sets how STREAM FORWARD retries connecting to a local
+// target that refuses, waiting r.Backoff before the second attempt and
+// doubling the wait up to r.MaxBackoff. Streams whose attempts all fail
+// are dropped and reported on Bridge.Errors. Zero fields keep their
+// current value, which starts as handler.DefaultForwardRetry; Attempts
+// of 1 disables retrying.
+func WithForwardRetry(r handler.ForwardRetry) Option {
+	return func(c *Config) {
+		if c.ForwardRetry.IsZero() {
+			c.ForwardRetry = handler.DefaultForwardRetry
+		}
+		if r.Attempts != 0 {
+			c.ForwardRetry.Attempts = r.Attempts
+		}
+		if r.Backoff != 0 {
+			c.ForwardRetry.Backoff = r.Backoff
+		}
+		if r.MaxBackoff != 0 {
+			c.ForwardRetry.MaxBackoff = r.MaxBackoff
+		}
+	}
+}
+
+// WithSocketOptions sets TCP keepalive, TCP_NODELAY and socket buffer
+// sizes on SAM client connections and on the connections STREAM FORWARD
+// makes to local targets. A keepalive period below a NAT's idle timeout
+// keeps long-lived idle streams from being dropped silently.
+func WithSocketOptions(opts util.SocketOptions) Option {
+	return func(c *Config) {
+		c.SocketOptions = opts
+	}
+}
+
+// WithAcceptBacklog makes each STREAM session accept inbound streams as
+// they arrive and hold up to size of them for the next STREAM ACCEPT,
+// so streams arriving between ACCEPTs are not dropped. Streams arriving
+// while the backlog is full are closed.
+func WithAcceptBacklog(size int) Option {
+	return func(c *Config) {
+		c.AcceptBacklog = size
+	}
+}
+
+// WithStreamReadTimeout closes streams whose peer sends nothing for d, so
+// dead I2P peers do not hold goroutines and sockets open. Sessions may
+// override it with the sam.streamReadTimeout option. Zero disables it.
+func WithStreamReadTimeout(d time.Duration) Option {
+	return func(c *Config) {
+		c.StreamReadTimeout = d
+	}
+}
+
+// WithStreamWriteTimeout closes streams whose writes to the peer stall
+// for d. Sessions may override it with the sam.streamWriteTimeout option.
+// Zero disables it.
+func WithStreamWriteTimeout(d time.Duration) Option {
+	return func(c *Config) {
+		c.StreamWriteTimeout = d
+	}
+}
+
+// WithAcceptBacklogTimeout sets how long a stream waits in the accept
+// backlog before it is closed. Zero uses
+// handler.DefaultAcceptBacklogTimeout.
+func WithAcceptBacklogTimeout(d time.Duration) Option {
+	return func(c *Config) {
+		c.AcceptBacklogTimeout = d
+	}
+}
+
+// WithDestinationPoolSize keeps up to size destinations generated in the
+// background while the bridge runs, so DEST GENERATE and SESSION CREATE
+// DESTINATION=TRANSIENT need not wait for key generation. Zero, the
+// default, disables the pool.
+func WithDestinationPoolSize(size int) Option {
+	return func(c *Config) {
+		c.DestinationPoolSize = size
+	}
+}
+
+// WithMaxSessions limits the number of sessions the bridge keeps open.
+// SESSION CREATE beyond the limit fails with RESULT=I2P_ERROR and a quota
+// message. Zero means no limit.
+func WithMaxSessions(n int) Option {
+	return func(c *Config) {
+		c.MaxSessions = n
+	}
+}
+
+// WithMaxSessionsPerUser limits the sessions each authenticated SAM user
+// may have open, so one client cannot exhaust the router's tunnels.
+// Zero means no limit.
+func WithMaxSessionsPerUser(n int) Option {
+	return func(c *Config) {
+		c.MaxSessionsPerUser = n
+	}
+}
+
+// WithMaxOutboundStreams limits the concurrent STREAM CONNECT streams of
+// each session. A CONNECT beyond the limit fails with RESULT=I2P_ERROR
+// and a quota message. Zero means no limit.
+func WithMaxOutboundStreams(n int) Option {
+	return func(c *Config) {
+		c.MaxOutboundStreams = n
+	}
+}
+
+// WithMaxInboundStreams limits the concurrent inbound streams of each
+// session, counting pending STREAM ACCEPTs and forwarded streams. An
+// ACCEPT beyond the limit fails with RESULT=I2P_ERROR; streams arriving
+// for STREAM FORWARD beyond it are dropped. Zero means no limit.
+func WithMaxInboundStreams(n int) Option {
+	return func(c *Config) {
+		c.MaxInboundStreams = n
+	}
+}
+
+// WithSendQueue queues up to size outgoing datagrams per session, so that
+// DATAGRAM and RAW sends survive a brief tunnel rebuild instead of failing
+// at once. A queued send failing with a transient I2CP error is retried up
+// to retries times, waiting 250ms and doubling, and dropped once it has
+// been queued longer than expiry. Zero retries or expiry use
+// session.DefaultSendRetries and session.DefaultSendExpiry. A size of zero
+// disables the queue.
+func WithSendQueue(size, retries int, expiry time.Duration) Option {
+	return func(c *Config) {
+		c.SendQueueSize = size
+		c.SendRetries = retries
+		c.SendExpiry = expiry
+	}
+}
+
+// WithDrainTimeout enables drain mode for Stop.
+// Stop waits up to d for existing connections to close on their own
+// before force-closing them. Zero disables draining.
+func WithDrainTimeout(d time.Duration) Option {
+	return func(c *Config) {
+		c.DrainTimeout = d
+	}
+}
+
+// WithSessionIdleTimeout closes sessions with no traffic for d, after
+// sending SESSION STATUS RESULT=I2P_ERROR to their control socket.
+// A session's own i2cp.closeIdleTime takes precedence. Zero disables it.
+func WithSessionIdleTimeout(d time.Duration) Option {
+	return func(c *Config) {
+		c.SessionIdleTimeout = d
+	}
+}
+
+// WithWriteTimeout disconnects SAM clients whose writes block for longer
+// than d, such as clients that stop reading DATAGRAM RECEIVED messages.
+// Zero disables it.
+func WithWriteTimeout(d time.Duration) Option {
+	return func(c *Config) {
+		c.WriteTimeout = d
+	}
+}
+
+// WithStrictQuoting parses commands from SAM 3.2 and later clients
+// exactly as the specification's quoting and escaping rules require.
+func WithStrictQuoting(strict bool) Option {
+	return func(c *Config) {
+		c.StrictQuoting = strict
+	}
+}
+
+// WithReplaceInvalidUTF8 accepts commands containing invalid UTF-8,
+// replacing the invalid sequences with U+FFFD, instead of rejecting them.
+func WithReplaceInvalidUTF8(replace bool) Option {
+	return func(c *Config) {
+		c.ReplaceInvalidUTF8 = replace
+	}
+}
+
+// WithOnStart sets a callback invoked after the bridge starts serving,
+// for example to notify a service manager that the bridge is ready.
+func WithOnStart(fn func()) Option {
+	return func(c *Config) {
+		c.OnStart = fn
+	}
+}
+
+// WithOnStop sets a callback invoked once when the bridge stops serving.
+// err is nil after Stop or Drain, or the error that ended the accept loop.
+func WithOnStop(fn func(err error)) Option {
+	return func(c *Config) {
+		c.OnStop = fn
+	}
+}
+
+// WithOnConnection sets a callback invoked when a SAM client connection
+// is accepted (bridge.StateNew) and when it closes (bridge.StateClosed).
+// It runs on the connection's goroutine and must not block.
+func WithOnConnection(fn func(conn net.Conn, state bridge.ConnectionState)) Option {
+	return func(c *Config) {
+		c.OnConnection = fn
+	}
+}
+
+// WithTrace sets a callback invoked with every SAM command line received
+// and reply line sent, with passwords and private keys redacted, for
+// debugging third-party clients. It must not block.
+func WithTrace(fn func(bridge.TraceRecord)) Option {
+	return func(c *Config) {
+		c.Trace = fn
+	}
+}
+
+// WithTraceWriter writes a line of text to w for every SAM command line
+// received and reply line sent. See WithTrace and bridge.TraceWriter.
+func WithTraceWriter(w io.Writer) Option {
+	return WithTrace(bridge.TraceWriter(w))
+}
+
+// WithPanicHandler sets a callback invoked with every panic recovered
+// while serving a SAM client connection, after it has been logged with
+// its stack trace. The command being handled gets RESULT=I2P_ERROR and
+// the rest of the bridge keeps running. It must not block.
+func WithPanicHandler(fn func(bridge.PanicRecord)) Option {
+	return func(c *Config) {
+		c.PanicHandler = fn
+	}
+}
+
+// WithLogSampling limits hot-path log records, such as forwarding
+// failures and tunnel events, to burst per log site per interval, so a
+// failure repeated for every stream stays cheap to log. The number of
+// records dropped is logged with the next one as "suppressed". A zero
+// interval means DefaultLogSampleInterval; a zero burst disables
+// sampling.
+func WithLogSampling(burst int, interval time.Duration) Option {
+	return func(c *Config) {
+		c.LogSampleBurst = burst
+		c.LogSampleInterval = interval
+	}
+}
+
 // WithDebug enables debug logging.
 func WithDebug(enabled bool) Option {
 	return func(c *Config) {
@@ -1,11 +1,17 @@
 package embedding
 
 import (
+	"bytes"
 	"crypto/tls"
 	"net"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/go-i2p/go-sam-bridge/lib/bridge"
 	"github.com/go-i2p/go-sam-bridge/lib/handler"
+	"github.com/go-i2p/go-sam-bridge/lib/session"
+	"github.com/go-i2p/go-sam-bridge/lib/util"
 	"github.com/sirupsen/logrus"
 )
 
@@ -56,6 +62,16 @@ func TestWithRegistry(t *testing.T) {
 	}
 }
 
+func TestWithSessionObserver(t *testing.T) {
+	cfg := DefaultConfig()
+	WithSessionObserver(session.ObserverFuncs{})(cfg)
+	WithSessionObserver(session.ObserverFuncs{})(cfg)
+
+	if len(cfg.SessionObservers) != 2 {
+		t.Errorf("SessionObservers len = %d, want 2", len(cfg.SessionObservers))
+	}
+}
+
 func TestWithI2CPProvider(t *testing.T) {
 	cfg := DefaultConfig()
 	mockProv := &mockI2CPProvider{}
@@ -86,6 +102,15 @@ func TestWithTLS(t *testing.T) {
 	}
 }
 
+func TestWithTLSFiles(t *testing.T) {
+	cfg := DefaultConfig()
+	WithTLSFiles("/etc/sam/cert.pem", "/etc/sam/key.pem")(cfg)
+
+	if cfg.TLSCertFile != "/etc/sam/cert.pem" || cfg.TLSKeyFile != "/etc/sam/key.pem" {
+		t.Errorf("TLS files = %q/%q, want /etc/sam/cert.pem and /etc/sam/key.pem", cfg.TLSCertFile, cfg.TLSKeyFile)
+	}
+}
+
 func TestWithAuth(t *testing.T) {
 	cfg := DefaultConfig()
 	users := map[string]string{
@@ -109,6 +134,96 @@ func TestWithAuth(t *testing.T) {
 	}
 }
 
+func TestWithAuthFunc(t *testing.T) {
+	cfg := DefaultConfig()
+	WithAuthFunc(func(user, password string) bool { return user == "alice" })(cfg)
+
+	if cfg.AuthFunc == nil {
+		t.Fatal("AuthFunc not set")
+	}
+	if !cfg.AuthFunc("alice", "") || cfg.AuthFunc("bob", "") {
+		t.Error("AuthFunc not set correctly")
+	}
+}
+
+func TestWithAuditLog(t *testing.T) {
+	cfg := DefaultConfig()
+	var buf bytes.Buffer
+	WithAuditLog(&buf)(cfg)
+
+	if cfg.AuditLog != &buf {
+		t.Error("AuditLog not set correctly")
+	}
+}
+
+func TestWithLogFormat(t *testing.T) {
+	cfg := DefaultConfig()
+	WithLogFormat(LogFormatJSON)(cfg)
+
+	if cfg.LogFormat != LogFormatJSON {
+		t.Errorf("LogFormat = %q, want %q", cfg.LogFormat, LogFormatJSON)
+	}
+}
+
+func TestWithAdminAddr(t *testing.T) {
+	cfg := DefaultConfig()
+	WithAdminAddr("127.0.0.1:7657")(cfg)
+
+	if cfg.AdminAddr != "127.0.0.1:7657" {
+		t.Errorf("AdminAddr = %q, want %q", cfg.AdminAddr, "127.0.0.1:7657")
+	}
+}
+
+func TestWithEndpoints(t *testing.T) {
+	cfg := DefaultConfig()
+	WithEndpoints(Endpoint{Network: NetworkTCP, Address: ":7666"})(cfg)
+	WithEndpoints(Endpoint{Network: NetworkUnix, Address: "/run/sam.sock"})(cfg)
+
+	if len(cfg.Endpoints) != 2 || cfg.Endpoints[1].Address != "/run/sam.sock" {
+		t.Errorf("Endpoints = %v, want both endpoints in order", cfg.Endpoints)
+	}
+}
+
+func TestWithMetricsAddr(t *testing.T) {
+	cfg := DefaultConfig()
+	WithMetricsAddr("127.0.0.1:9100")(cfg)
+
+	if cfg.MetricsAddr != "127.0.0.1:9100" {
+		t.Errorf("MetricsAddr = %q, want %q", cfg.MetricsAddr, "127.0.0.1:9100")
+	}
+}
+
+func TestWithPprofAddr(t *testing.T) {
+	cfg := DefaultConfig()
+	WithPprofAddr("127.0.0.1:6060")(cfg)
+
+	if cfg.PprofAddr != "127.0.0.1:6060" {
+		t.Errorf("PprofAddr = %q, want %q", cfg.PprofAddr, "127.0.0.1:6060")
+	}
+}
+
+func TestWithAuditLogFile(t *testing.T) {
+	cfg := DefaultConfig()
+	WithAuditLogFile("/var/log/sam-audit.log")(cfg)
+
+	if cfg.AuditLogFile != "/var/log/sam-audit.log" {
+		t.Errorf("AuditLogFile = %q, want %q", cfg.AuditLogFile, "/var/log/sam-audit.log")
+	}
+}
+
+func TestWithKeyStoreDir(t *testing.T) {
+	cfg := DefaultConfig()
+	WithKeyStoreDir("/var/lib/sam-bridge/keys")(cfg)
+	WithKeyStorePassphrase("correct horse")(cfg)
+
+	if cfg.KeyStoreDir != "/var/lib/sam-bridge/keys" || cfg.KeyStorePassphrase != "correct horse" {
+		t.Errorf("key store = %q/%q, want /var/lib/sam-bridge/keys/correct horse", cfg.KeyStoreDir, cfg.KeyStorePassphrase)
+	}
+	if deps := newDependencies(cfg); deps.KeyStore == nil || deps.KeyStore.Dir() != "/var/lib/sam-bridge/keys" {
+		t.Errorf("Dependencies.KeyStore = %v, want a store in /var/lib/sam-bridge/keys", deps.KeyStore)
+	}
+}
+
 func TestWithI2CPCredentials(t *testing.T) {
 	cfg := DefaultConfig()
 	WithI2CPCredentials("user", "pass")(cfg)
@@ -151,6 +266,298 @@ func TestWithHandlerRegistrar(t *testing.T) {
 	}
 }
 
+func TestWithHandshakeTimeout(t *testing.T) {
+	cfg := DefaultConfig()
+	WithHandshakeTimeout(5 * time.Second)(cfg)
+
+	if cfg.HandshakeTimeout != 5*time.Second {
+		t.Errorf("HandshakeTimeout = %v, want %v", cfg.HandshakeTimeout, 5*time.Second)
+	}
+}
+
+func TestWithCommandTimeout(t *testing.T) {
+	cfg := DefaultConfig()
+	WithCommandTimeout(2 * time.Minute)(cfg)
+
+	if cfg.CommandTimeout != 2*time.Minute {
+		t.Errorf("CommandTimeout = %v, want %v", cfg.CommandTimeout, 2*time.Minute)
+	}
+}
+
+func TestWithReadBufferSize(t *testing.T) {
+	cfg := DefaultConfig()
+	WithReadBufferSize(4096)(cfg)
+
+	if cfg.ReadBufferSize != 4096 {
+		t.Errorf("ReadBufferSize = %d, want %d", cfg.ReadBufferSize, 4096)
+	}
+}
+
+func TestWithMaxLineLength(t *testing.T) {
+	cfg := DefaultConfig()
+	WithMaxLineLength(1024)(cfg)
+
+	if cfg.MaxLineLength != 1024 {
+		t.Errorf("MaxLineLength = %d, want %d", cfg.MaxLineLength, 1024)
+	}
+}
+
+func TestWithDatagramPacketConn(t *testing.T) {
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("ListenPacket() error = %v", err)
+	}
+	defer pc.Close()
+
+	cfg := DefaultConfig()
+	WithDatagramPacketConn(pc)(cfg)
+	if cfg.DatagramPacketConn != pc {
+		t.Error("DatagramPacketConn not set correctly")
+	}
+}
+
+func TestWithLifecycleHooks(t *testing.T) {
+	cfg := DefaultConfig()
+	WithOnStart(func() {})(cfg)
+	WithOnStop(func(error) {})(cfg)
+	WithOnConnection(func(net.Conn, bridge.ConnectionState) {})(cfg)
+
+	if cfg.OnStart == nil || cfg.OnStop == nil || cfg.OnConnection == nil {
+		t.Error("lifecycle hooks not set")
+	}
+	if cfg.toBridgeConfig().ConnState == nil {
+		t.Error("OnConnection should be passed to bridge.Config.ConnState")
+	}
+}
+
+func TestWithDrainTimeout(t *testing.T) {
+	cfg := DefaultConfig()
+	WithDrainTimeout(5 * time.Second)(cfg)
+
+	if cfg.DrainTimeout != 5*time.Second {
+		t.Errorf("DrainTimeout = %v, want %v", cfg.DrainTimeout, 5*time.Second)
+	}
+}
+
+func TestWithMaxSessions(t *testing.T) {
+	cfg := DefaultConfig()
+	WithMaxSessions(50)(cfg)
+	WithMaxSessionsPerUser(5)(cfg)
+
+	limits := cfg.toBridgeConfig().Limits
+	if limits.MaxSessions != 50 || limits.MaxSessionsPerUser != 5 {
+		t.Errorf("bridge Limits = %d/%d, want 50/5", limits.MaxSessions, limits.MaxSessionsPerUser)
+	}
+}
+
+func TestWithSessionIdleTimeout(t *testing.T) {
+	cfg := DefaultConfig()
+	WithSessionIdleTimeout(10 * time.Minute)(cfg)
+
+	if cfg.SessionIdleTimeout != 10*time.Minute {
+		t.Errorf("SessionIdleTimeout = %v, want %v", cfg.SessionIdleTimeout, 10*time.Minute)
+	}
+	if got := cfg.toBridgeConfig().Timeouts.SessionIdle; got != 10*time.Minute {
+		t.Errorf("bridge Timeouts.SessionIdle = %v, want %v", got, 10*time.Minute)
+	}
+}
+
+func TestWithWriteTimeout(t *testing.T) {
+	cfg := DefaultConfig()
+	WithWriteTimeout(time.Minute)(cfg)
+
+	if got := cfg.toBridgeConfig().Timeouts.Write; got != time.Minute {
+		t.Errorf("bridge Timeouts.Write = %v, want %v", got, time.Minute)
+	}
+}
+
+func TestWithStrictQuoting(t *testing.T) {
+	cfg := DefaultConfig()
+	WithStrictQuoting(true)(cfg)
+
+	if !cfg.toBridgeConfig().StrictQuoting {
+		t.Error("bridge StrictQuoting = false, want true")
+	}
+}
+
+func TestWithReplaceInvalidUTF8(t *testing.T) {
+	cfg := DefaultConfig()
+	WithReplaceInvalidUTF8(true)(cfg)
+
+	if !cfg.toBridgeConfig().ReplaceInvalidUTF8 {
+		t.Error("bridge ReplaceInvalidUTF8 = false, want true")
+	}
+}
+
+func TestWithTraceWriter(t *testing.T) {
+	var buf bytes.Buffer
+	cfg := DefaultConfig()
+	WithTraceWriter(&buf)(cfg)
+
+	trace := cfg.toBridgeConfig().Trace
+	if trace == nil {
+		t.Fatal("bridge Trace = nil, want the trace writer")
+	}
+	trace(bridge.TraceRecord{Direction: bridge.TraceIn, RemoteAddr: "127.0.0.1:1", Line: "PING"})
+	if !strings.HasSuffix(buf.String(), "127.0.0.1:1 > PING\n") {
+		t.Errorf("trace output = %q", buf.String())
+	}
+}
+
+func TestWithPanicHandler(t *testing.T) {
+	var got []bridge.PanicRecord
+	cfg := DefaultConfig()
+	WithPanicHandler(func(rec bridge.PanicRecord) { got = append(got, rec) })(cfg)
+
+	var buf bytes.Buffer
+	log := logrus.New()
+	log.SetOutput(&buf)
+	panicLogger(log, cfg.PanicHandler)(bridge.PanicRecord{Command: "DEST GENERATE", Value: "boom", Stack: []byte("goroutine 1")})
+
+	if len(got) != 1 || got[0].Command != "DEST GENERATE" {
+		t.Errorf("PanicHandler got %+v", got)
+	}
+	if out := buf.String(); !strings.Contains(out, "boom") || !strings.Contains(out, "goroutine 1") {
+		t.Errorf("log output = %q, want panic value and stack", out)
+	}
+}
+
+func TestWithMaxConnections(t *testing.T) {
+	cfg := DefaultConfig()
+	WithMaxConnections(100, 10)(cfg)
+
+	limits := cfg.toBridgeConfig().Limits
+	if limits.MaxConnections != 100 || limits.MaxQueuedConnections != 10 {
+		t.Errorf("bridge limits = %d/%d, want 100/10", limits.MaxConnections, limits.MaxQueuedConnections)
+	}
+}
+
+func TestWithMaxConnectionsPerIP(t *testing.T) {
+	cfg := DefaultConfig()
+	WithMaxConnectionsPerIP(4)(cfg)
+
+	if got := cfg.toBridgeConfig().Limits.MaxConnectionsPerIP; got != 4 {
+		t.Errorf("bridge Limits.MaxConnectionsPerIP = %d, want 4", got)
+	}
+}
+
+func TestWithSocketOptions(t *testing.T) {
+	cfg := DefaultConfig()
+	opts := util.SocketOptions{KeepAlive: 30 * time.Second, DisableNoDelay: true}
+	WithSocketOptions(opts)(cfg)
+
+	if got := cfg.toBridgeConfig().Limits.Socket; got != opts {
+		t.Errorf("bridge Limits.Socket = %+v, want %+v", got, opts)
+	}
+
+	WithSocketOptions(util.SocketOptions{ReadBuffer: -1})(cfg)
+	if err := cfg.Validate(); err != ErrInvalidLimit {
+		t.Errorf("Validate() = %v, want ErrInvalidLimit", err)
+	}
+}
+
+func TestWithStreamBufferSize(t *testing.T) {
+	cfg := DefaultConfig()
+	WithStreamBufferSize(64 * 1024)(cfg)
+
+	if got := cfg.toBridgeConfig().Limits.StreamBufferSize; got != 64*1024 {
+		t.Errorf("bridge Limits.StreamBufferSize = %d, want %d", got, 64*1024)
+	}
+
+	WithStreamBufferSize(-1)(cfg)
+	if err := cfg.Validate(); err != ErrInvalidLimit {
+		t.Errorf("Validate() = %v, want ErrInvalidLimit", err)
+	}
+}
+
+func TestWithAcceptBacklog(t *testing.T) {
+	cfg := DefaultConfig()
+	WithAcceptBacklog(16)(cfg)
+	WithAcceptBacklogTimeout(5 * time.Second)(cfg)
+
+	deps := newDependencies(cfg)
+	if deps.AcceptBacklog != 16 || deps.AcceptBacklogTimeout != 5*time.Second {
+		t.Errorf("deps backlog = %d/%v, want 16/5s", deps.AcceptBacklog, deps.AcceptBacklogTimeout)
+	}
+
+	WithAcceptBacklog(-1)(cfg)
+	if err := cfg.Validate(); err != ErrInvalidLimit {
+		t.Errorf("Validate() = %v, want ErrInvalidLimit", err)
+	}
+
+	WithAcceptBacklog(16)(cfg)
+	WithAcceptBacklogTimeout(-time.Second)(cfg)
+	if err := cfg.Validate(); err != ErrInvalidTimeout {
+		t.Errorf("Validate() = %v, want ErrInvalidTimeout", err)
+	}
+}
+
+func TestWithStreamTimeouts(t *testing.T) {
+	cfg := DefaultConfig()
+	WithStreamReadTimeout(5 * time.Minute)(cfg)
+	WithStreamWriteTimeout(time.Minute)(cfg)
+
+	defaults := newDependencies(cfg).SessionDefaults
+	if defaults == nil || defaults.StreamReadTimeout != 5*time.Minute || defaults.StreamWriteTimeout != time.Minute {
+		t.Errorf("SessionDefaults = %+v, want stream timeouts 5m/1m", defaults)
+	}
+
+	WithStreamReadTimeout(-time.Second)(cfg)
+	if err := cfg.Validate(); err != ErrInvalidTimeout {
+		t.Errorf("Validate() = %v, want ErrInvalidTimeout", err)
+	}
+}
+
+func TestWithMaxStreams(t *testing.T) {
+	cfg := DefaultConfig()
+	WithMaxOutboundStreams(8)(cfg)
+	WithMaxInboundStreams(16)(cfg)
+
+	defaults := newDependencies(cfg).SessionDefaults
+	if defaults == nil || defaults.MaxOutboundStreams != 8 || defaults.MaxInboundStreams != 16 {
+		t.Errorf("SessionDefaults = %+v, want stream limits 8/16", defaults)
+	}
+
+	WithMaxInboundStreams(-1)(cfg)
+	if err := cfg.Validate(); err != ErrInvalidLimit {
+		t.Errorf("Validate() = %v, want ErrInvalidLimit", err)
+	}
+}
+
+func TestWithForwardRetry(t *testing.T) {
+	cfg := DefaultConfig()
+	WithForwardRetry(handler.ForwardRetry{Attempts: 1})(cfg)
+
+	want := handler.DefaultForwardRetry
+	want.Attempts = 1
+	if cfg.ForwardRetry != want {
+		t.Errorf("ForwardRetry = %+v, want %+v", cfg.ForwardRetry, want)
+	}
+	if got := newDependencies(cfg).ForwardRetry; got != want {
+		t.Errorf("deps ForwardRetry = %+v, want %+v", got, want)
+	}
+
+	WithForwardRetry(handler.ForwardRetry{Backoff: -time.Second})(cfg)
+	if err := cfg.Validate(); err != ErrInvalidTimeout {
+		t.Errorf("Validate() = %v, want ErrInvalidTimeout", err)
+	}
+}
+
+func TestWithSendQueue(t *testing.T) {
+	cfg := DefaultConfig()
+	WithSendQueue(32, 2, 5*time.Second)(cfg)
+
+	defaults := newDependencies(cfg).SessionDefaults
+	if defaults == nil || defaults.SendQueueSize != 32 || defaults.SendRetries != 2 || defaults.SendExpiry != 5*time.Second {
+		t.Errorf("SessionDefaults = %+v, want send queue 32/2/5s", defaults)
+	}
+
+	WithSendQueue(session.MaxSendQueueSize+1, 0, 0)(cfg)
+	if err := cfg.Validate(); err != ErrInvalidLimit {
+		t.Errorf("Validate() = %v, want ErrInvalidLimit", err)
+	}
+}
+
 // mockListener implements net.Listener for testing.
 type mockListener struct{}
 
@@ -0,0 +1,62 @@
+package embedding
+
+import (
+	"net"
+	"net/http"
+	"net/http/pprof"
+)
+
+// PprofAddr returns the address the pprof HTTP server is listening on,
+// or an empty string if it is not running.
+func (b *Bridge) PprofAddr() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.pprof.addr()
+}
+
+// startPprof serves net/http/pprof at /debug/pprof/ on Config.PprofAddr.
+// It is a no-op when no pprof address is configured.
+// Callers must hold b.mu.
+func (b *Bridge) startPprof() error {
+	if b.config.PprofAddr == "" {
+		return nil
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	endpoint, err := b.startHTTPEndpoint(b.config.PprofAddr, mux, SourcePprof)
+	if err != nil {
+		return err
+	}
+	b.pprof = endpoint
+	return nil
+}
+
+// stopPprof closes the pprof HTTP server, if running.
+func (b *Bridge) stopPprof() {
+	b.mu.Lock()
+	endpoint := b.pprof
+	b.pprof = nil
+	b.mu.Unlock()
+
+	if err := endpoint.close(); err != nil {
+		b.deps.Logger.WithError(err).Warn("Error closing pprof HTTP server")
+	}
+}
+
+// isLoopbackAddr reports whether addr is a host:port whose host is
+// "localhost" or a loopback IP.
+func isLoopbackAddr(addr string) bool {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return false
+	}
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
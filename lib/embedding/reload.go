@@ -0,0 +1,178 @@
+package embedding
+
+import (
+	"errors"
+	"maps"
+	"reflect"
+	"slices"
+
+	"github.com/sirupsen/logrus"
+)
+
+// ReloadResult reports what Bridge.Reload changed.
+type ReloadResult struct {
+	// Applied lists the settings that took effect, e.g. "auth_users".
+	Applied []string
+
+	// RestartRequired lists changed settings that only take effect when
+	// the bridge is recreated, e.g. "listen".
+	RestartRequired []string
+}
+
+// Reload applies a new configuration to the running bridge. The options
+// are applied to a default configuration, as in New, so pass the full
+// set used to create the bridge with the updated values.
+//
+// Reload applies these settings in place:
+//
+//   - AuthUsers ("auth_users") replaces all SAM users, including users
+//     added with AUTH ADD, and enables or disables authentication
+//   - Debug ("debug") switches the logger between debug and info level
+//   - LogFormat ("log_format") switches between text and JSON logs
+//   - LogSampleBurst and LogSampleInterval ("log_sampling") change how
+//     hot-path log records are sampled
+//   - TLS certificate and key files are reread ("tls"), as by ReloadTLS
+//
+// Any other setting that differs from the running configuration is
+// reported in RestartRequired and ignored. Returns an error, and changes
+// nothing, if the options do not form a valid configuration.
+func (b *Bridge) Reload(opts ...Option) (*ReloadResult, error) {
+	cfg, err := buildConfig(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	result := &ReloadResult{RestartRequired: restartRequired(b.config, cfg)}
+
+	if !maps.Equal(b.config.AuthUsers, cfg.AuthUsers) {
+		b.reloadAuthUsers(cfg.AuthUsers)
+		result.Applied = append(result.Applied, "auth_users")
+	}
+
+	if b.config.Debug != cfg.Debug {
+		if cfg.Debug {
+			b.deps.Logger.SetLevel(logrus.DebugLevel)
+		} else {
+			b.deps.Logger.SetLevel(logrus.InfoLevel)
+		}
+		b.config.Debug = cfg.Debug
+		result.Applied = append(result.Applied, "debug")
+	}
+
+	if next := cfg.LogFormat; next != "" && next != b.config.LogFormat {
+		// buildConfig has already validated the format name
+		formatter, _ := NewLogFormatter(next)
+		b.deps.Logger.SetFormatter(formatter)
+		b.config.LogFormat = next
+		result.Applied = append(result.Applied, "log_format")
+	}
+
+	if b.config.LogSampleBurst != cfg.LogSampleBurst || b.config.LogSampleInterval != cfg.LogSampleInterval {
+		b.deps.logSampler.set(cfg.LogSampleBurst, cfg.LogSampleInterval)
+		b.config.LogSampleBurst = cfg.LogSampleBurst
+		b.config.LogSampleInterval = cfg.LogSampleInterval
+		result.Applied = append(result.Applied, "log_sampling")
+	}
+
+	switch err := b.ReloadTLS(); {
+	case err == nil:
+		result.Applied = append(result.Applied, "tls")
+	case !errors.Is(err, ErrTLSReloadUnavailable):
+		return result, err
+	}
+
+	return result, nil
+}
+
+// reloadAuthUsers replaces the SAM users and updates whether
+// authentication is required. Callers must hold b.mu.
+func (b *Bridge) reloadAuthUsers(users map[string]string) {
+	authStore := b.server.AuthStore()
+	authStore.SetUsers(users)
+	authStore.SetAuthEnabled(len(users) > 0 || b.config.AuthFunc != nil)
+	b.config.AuthUsers = maps.Clone(users)
+}
+
+// restartRequired returns the names of settings that differ between the
+// running configuration and next but cannot be applied in place.
+func restartRequired(running, next *Config) []string {
+	settings := []struct {
+		name    string
+		changed bool
+	}{
+		{"listen", running.ListenAddr != next.ListenAddr},
+		{"endpoints", !slices.Equal(running.Endpoints, next.Endpoints)},
+		{"proxy_protocol", running.ProxyProtocol != next.ProxyProtocol},
+		{"i2cp.addr", running.I2CPAddr != next.I2CPAddr},
+		{"i2cp.credentials", running.I2CPUsername != next.I2CPUsername || running.I2CPPassword != next.I2CPPassword},
+		{"i2cp.failover_addrs", !slices.Equal(running.I2CPFailoverAddrs, next.I2CPFailoverAddrs)},
+		{"i2cp.tls", !equalI2CPTLS(running.I2CPTLS, next.I2CPTLS)},
+		{"datagram_port", running.DatagramPort != next.DatagramPort},
+		{"tls.files", running.TLSCertFile != next.TLSCertFile || running.TLSKeyFile != next.TLSKeyFile},
+		{"tls.policy", !equalTLSPolicy(running.TLSPolicy, next.TLSPolicy)},
+		{"timeouts.handshake", running.HandshakeTimeout != next.HandshakeTimeout},
+		{"timeouts.command", running.CommandTimeout != next.CommandTimeout},
+		{"timeouts.idle", running.IdleTimeout != next.IdleTimeout},
+		{"timeouts.drain", running.DrainTimeout != next.DrainTimeout},
+		{"timeouts.session_idle", running.SessionIdleTimeout != next.SessionIdleTimeout},
+		{"timeouts.write", running.WriteTimeout != next.WriteTimeout},
+		{"limits.read_buffer_size", running.ReadBufferSize != next.ReadBufferSize},
+		{"limits.max_line_length", running.MaxLineLength != next.MaxLineLength},
+		{"limits.max_sessions", running.MaxSessions != next.MaxSessions},
+		{"limits.max_sessions_per_user", running.MaxSessionsPerUser != next.MaxSessionsPerUser},
+		{"limits.max_outbound_streams", running.MaxOutboundStreams != next.MaxOutboundStreams},
+		{"limits.max_inbound_streams", running.MaxInboundStreams != next.MaxInboundStreams},
+		{"limits.send_queue", running.SendQueueSize != next.SendQueueSize || running.SendRetries != next.SendRetries || running.SendExpiry != next.SendExpiry},
+		{"limits.max_connections", running.MaxConnections != next.MaxConnections || running.MaxQueuedConnections != next.MaxQueuedConnections},
+		{"limits.max_connections_per_ip", running.MaxConnectionsPerIP != next.MaxConnectionsPerIP},
+		{"limits.stream_buffer_size", running.StreamBufferSize != next.StreamBufferSize},
+		{"socket", running.SocketOptions != next.SocketOptions},
+		{"forward_retry", running.ForwardRetry != next.ForwardRetry},
+		{"limits.destination_pool_size", running.DestinationPoolSize != next.DestinationPoolSize},
+		{"limits.accept_backlog", running.AcceptBacklog != next.AcceptBacklog},
+		{"timeouts.accept_backlog", running.AcceptBacklogTimeout != next.AcceptBacklogTimeout},
+		{"timeouts.stream", running.StreamReadTimeout != next.StreamReadTimeout || running.StreamWriteTimeout != next.StreamWriteTimeout},
+		{"keystore", running.KeyStoreDir != next.KeyStoreDir || running.KeyStorePassphrase != next.KeyStorePassphrase},
+		{"strict_quoting", running.StrictQuoting != next.StrictQuoting},
+		{"replace_invalid_utf8", running.ReplaceInvalidUTF8 != next.ReplaceInvalidUTF8},
+		{"audit_log", running.AuditLogFile != next.AuditLogFile},
+		{"access_log", running.AccessLogFile != next.AccessLogFile},
+		{"admin_addr", running.AdminAddr != next.AdminAddr},
+		{"metrics_addr", running.MetricsAddr != next.MetricsAddr},
+		{"pprof_addr", running.PprofAddr != next.PprofAddr},
+		{"i2pcontrol", running.I2PControlAddr != next.I2PControlAddr || running.I2PControlPassword != next.I2PControlPassword},
+		{"socks", running.SOCKSAddr != next.SOCKSAddr || running.SOCKSUser != next.SOCKSUser || running.SOCKSPassword != next.SOCKSPassword},
+		{"http_proxy", running.HTTPProxyAddr != next.HTTPProxyAddr || running.HTTPProxyUser != next.HTTPProxyUser ||
+			running.HTTPProxyPassword != next.HTTPProxyPassword || running.HTTPOutproxy != next.HTTPOutproxy},
+		{"bob", running.BOBAddr != next.BOBAddr || running.BOBUser != next.BOBUser || running.BOBPassword != next.BOBPassword},
+		{"tunnels", !reflect.DeepEqual(running.Tunnels, next.Tunnels) || running.TunnelUser != next.TunnelUser ||
+			running.TunnelPassword != next.TunnelPassword},
+	}
+
+	var names []string
+	for _, s := range settings {
+		if s.changed {
+			names = append(names, s.name)
+		}
+	}
+	return names
+}
+
+// equalTLSPolicy reports whether a and b set the same TLS policy.
+func equalTLSPolicy(a, b TLSPolicy) bool {
+	return a.MinVersion == b.MinVersion &&
+		slices.Equal(a.CipherSuites, b.CipherSuites) &&
+		slices.Equal(a.NextProtos, b.NextProtos)
+}
+
+// equalI2CPTLS reports whether a and b configure the same I2CP TLS
+// settings, with nil meaning TLS is off.
+func equalI2CPTLS(a, b *I2CPTLSConfig) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
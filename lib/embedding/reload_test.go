@@ -0,0 +1,122 @@
+package embedding
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestBridgeReload(t *testing.T) {
+	log := logrus.New()
+	base := []Option{
+		WithListenAddr("127.0.0.1:0"),
+		WithI2CPProvider(&mockI2CPProvider{}),
+		WithDatagramPort(0),
+		WithLogger(log),
+	}
+
+	b, err := New(base...)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if err := b.Start(context.Background()); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	defer b.Stop(context.Background())
+
+	result, err := b.Reload(append(base,
+		WithAuth(map[string]string{"alice": "secret"}),
+		WithDebug(true),
+		WithLogSampling(5, time.Second),
+		WithListenAddr("127.0.0.1:1"),
+	)...)
+	if err != nil {
+		t.Fatalf("Reload() error = %v", err)
+	}
+
+	if !slices.Equal(result.Applied, []string{"auth_users", "debug", "log_sampling"}) {
+		t.Errorf("Applied = %v, want [auth_users debug log_sampling]", result.Applied)
+	}
+	if b.deps.logSampler.burst != 5 {
+		t.Errorf("log sample burst = %d, want 5", b.deps.logSampler.burst)
+	}
+	if !slices.Equal(result.RestartRequired, []string{"listen"}) {
+		t.Errorf("RestartRequired = %v, want [listen]", result.RestartRequired)
+	}
+
+	authStore := b.Server().AuthStore()
+	if !authStore.IsAuthEnabled() || !authStore.CheckPassword("alice", "secret") {
+		t.Error("Reload() should enable authentication with the new users")
+	}
+	if log.GetLevel() != logrus.DebugLevel {
+		t.Errorf("log level = %v, want debug", log.GetLevel())
+	}
+
+	// Reloading the original options reverts the reloadable settings
+	result, err = b.Reload(base...)
+	if err != nil {
+		t.Fatalf("Reload() error = %v", err)
+	}
+	if len(result.RestartRequired) != 0 {
+		t.Errorf("RestartRequired = %v, want none", result.RestartRequired)
+	}
+	if authStore.IsAuthEnabled() || authStore.UserCount() != 0 {
+		t.Error("Reload() without users should disable authentication")
+	}
+	if log.GetLevel() != logrus.InfoLevel {
+		t.Errorf("log level = %v, want info", log.GetLevel())
+	}
+}
+
+func TestBridgeReload_InvalidConfig(t *testing.T) {
+	b, err := New(WithListenAddr("127.0.0.1:0"), WithI2CPProvider(&mockI2CPProvider{}), WithDatagramPort(0))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	if _, err := b.Reload(WithListenAddr(""), WithAuth(map[string]string{"alice": "secret"})); err == nil {
+		t.Fatal("Reload() with invalid config should return error")
+	}
+	if b.Server().AuthStore().IsAuthEnabled() {
+		t.Error("failed Reload() must not change authentication")
+	}
+}
+
+func TestBridgeReload_TLS(t *testing.T) {
+	certFile, keyFile := writeTestKeyPair(t, t.TempDir(), "test")
+	opts := []Option{
+		WithListenAddr("127.0.0.1:0"),
+		WithI2CPProvider(&mockI2CPProvider{}),
+		WithDatagramPort(0),
+		WithTLSFiles(certFile, keyFile),
+	}
+	b, err := New(opts...)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	result, err := b.Reload(opts...)
+	if err != nil {
+		t.Fatalf("Reload() error = %v", err)
+	}
+	if !slices.Equal(result.Applied, []string{"tls"}) {
+		t.Errorf("Applied = %v, want [tls]", result.Applied)
+	}
+}
+
+func TestRestartRequired_I2CPTLS(t *testing.T) {
+	running := DefaultConfig()
+	next := DefaultConfig()
+	WithI2CPTLS(I2CPTLSConfig{CAFile: "ca.pem"})(next)
+	if got := restartRequired(running, next); !slices.Equal(got, []string{"i2cp.tls"}) {
+		t.Errorf("restartRequired() = %v, want [i2cp.tls]", got)
+	}
+
+	WithI2CPTLS(I2CPTLSConfig{CAFile: "ca.pem"})(running)
+	if got := restartRequired(running, next); len(got) != 0 {
+		t.Errorf("restartRequired() = %v, want none for equal TLS settings", got)
+	}
+}
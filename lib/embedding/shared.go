@@ -0,0 +1,117 @@
+package embedding
+
+import (
+	"io"
+	"sync"
+
+	"github.com/go-i2p/go-sam-bridge/lib/session"
+)
+
+// SharedI2CP lets several bridges in one process share a single I2CP
+// provider, and therefore a single router connection.
+//
+// The creator holds one reference, and each bridge built with
+// WithSharedI2CP holds another until it stops. When the last reference is
+// released, the provider is closed if it implements io.Closer.
+// Router disconnects are delivered to every bridge sharing the provider.
+type SharedI2CP struct {
+	provider session.I2CPSessionProvider
+
+	mu            sync.Mutex
+	refs          int
+	creatorClosed bool
+	nextHandlerID int
+	errorHandlers map[int]func(error)
+}
+
+// NewSharedI2CP wraps provider for sharing between bridges.
+// For an i2cp.Client, pass i2cp.NewSessionProviderAdapter(client).
+// Call Close once all bridges have been created.
+func NewSharedI2CP(provider session.I2CPSessionProvider) *SharedI2CP {
+	s := &SharedI2CP{
+		provider:      provider,
+		refs:          1,
+		errorHandlers: make(map[int]func(error)),
+	}
+	if notifier, ok := provider.(ErrorNotifier); ok {
+		notifier.SetErrorHandler(s.notifyError)
+	}
+	return s
+}
+
+// Provider returns the shared I2CP provider.
+func (s *SharedI2CP) Provider() session.I2CPSessionProvider {
+	return s.provider
+}
+
+// Close releases the creator's reference. The provider is closed once
+// every bridge using it has stopped. Calling Close more than once has no
+// further effect.
+func (s *SharedI2CP) Close() error {
+	s.mu.Lock()
+	if s.creatorClosed {
+		s.mu.Unlock()
+		return nil
+	}
+	s.creatorClosed = true
+	s.mu.Unlock()
+	return s.release()
+}
+
+// acquire takes a reference for a bridge and registers onError for
+// router disconnects. The returned function undoes both and is safe to
+// call more than once.
+func (s *SharedI2CP) acquire(onError func(error)) (func() error, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.refs == 0 {
+		return nil, ErrSharedI2CPClosed
+	}
+	s.refs++
+	id := s.nextHandlerID
+	s.nextHandlerID++
+	s.errorHandlers[id] = onError
+
+	var once sync.Once
+	return func() error {
+		var err error
+		once.Do(func() {
+			s.mu.Lock()
+			delete(s.errorHandlers, id)
+			s.mu.Unlock()
+			err = s.release()
+		})
+		return err
+	}, nil
+}
+
+// release drops one reference and closes the provider when none remain.
+func (s *SharedI2CP) release() error {
+	s.mu.Lock()
+	s.refs--
+	last := s.refs == 0
+	s.mu.Unlock()
+
+	if !last {
+		return nil
+	}
+	if closer, ok := s.provider.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
+
+// notifyError forwards a provider failure to every registered bridge.
+func (s *SharedI2CP) notifyError(err error) {
+	s.mu.Lock()
+	handlers := make([]func(error), 0, len(s.errorHandlers))
+	for _, fn := range s.errorHandlers {
+		handlers = append(handlers, fn)
+	}
+	s.mu.Unlock()
+
+	for _, fn := range handlers {
+		fn(err)
+	}
+}
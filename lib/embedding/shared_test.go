@@ -0,0 +1,99 @@
+package embedding
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+)
+
+// closingProvider is an I2CP provider that records Close calls and
+// implements ErrorNotifier.
+type closingProvider struct {
+	mockI2CPProvider
+	closed  int
+	handler func(error)
+}
+
+func (p *closingProvider) Close() error                   { p.closed++; return nil }
+func (p *closingProvider) SetErrorHandler(fn func(error)) { p.handler = fn }
+
+func newSharedTestBridge(t *testing.T, shared *SharedI2CP) *Bridge {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to create test listener: %v", err)
+	}
+	b, err := New(WithListener(ln), WithSharedI2CP(shared), WithDatagramPort(0))
+	if err != nil {
+		ln.Close()
+		t.Fatalf("New() error = %v", err)
+	}
+	return b
+}
+
+func TestSharedI2CP_RefCounting(t *testing.T) {
+	provider := &closingProvider{}
+	shared := NewSharedI2CP(provider)
+
+	b1 := newSharedTestBridge(t, shared)
+	b2 := newSharedTestBridge(t, shared)
+	if b1.Dependencies().I2CPProvider != provider || b2.Dependencies().I2CPProvider != provider {
+		t.Fatal("bridges should use the shared provider")
+	}
+
+	for _, b := range []*Bridge{b1, b2} {
+		if err := b.Start(context.Background()); err != nil {
+			t.Fatalf("Start() error = %v", err)
+		}
+	}
+
+	shared.Close()
+	shared.Close()
+	b1.Stop(context.Background())
+	if provider.closed != 0 {
+		t.Fatal("provider closed while a bridge still uses it")
+	}
+
+	b2.Stop(context.Background())
+	if provider.closed != 1 {
+		t.Errorf("provider closed %d times, want 1", provider.closed)
+	}
+
+	if _, err := New(WithListenAddr("127.0.0.1:0"), WithSharedI2CP(shared)); !errors.Is(err, ErrSharedI2CPClosed) {
+		t.Errorf("New() with closed SharedI2CP error = %v, want ErrSharedI2CPClosed", err)
+	}
+}
+
+func TestSharedI2CP_ErrorsReachEveryBridge(t *testing.T) {
+	provider := &closingProvider{}
+	shared := NewSharedI2CP(provider)
+	defer shared.Close()
+
+	b1 := newSharedTestBridge(t, shared)
+	b2 := newSharedTestBridge(t, shared)
+
+	provider.handler(errors.New("router gone"))
+
+	for _, b := range []*Bridge{b1, b2} {
+		select {
+		case err := <-b.Errors():
+			var bgErr *BackgroundError
+			if !errors.As(err, &bgErr) || bgErr.Source != SourceI2CP {
+				t.Errorf("got %v, want I2CP BackgroundError", err)
+			}
+		default:
+			t.Error("every bridge should receive the disconnect")
+		}
+	}
+}
+
+func TestWithSharedI2CPConflictsWithProvider(t *testing.T) {
+	shared := NewSharedI2CP(&mockI2CPProvider{})
+	defer shared.Close()
+
+	_, err := New(WithListenAddr("127.0.0.1:0"), WithSharedI2CP(shared), WithI2CPProvider(&mockI2CPProvider{}))
+	if !errors.Is(err, ErrConflictingI2CPProvider) {
+		t.Errorf("New() error = %v, want ErrConflictingI2CPProvider", err)
+	}
+}
@@ -0,0 +1,74 @@
+package embedding
+
+import (
+	"net"
+
+	"github.com/go-i2p/go-sam-bridge/lib/socks"
+)
+
+// socksFrontend is the SOCKS5 proxy started by WithSOCKSAddr.
+type socksFrontend struct {
+	server   *socks.Server
+	listener net.Listener
+	dialer   *streamDialer
+}
+
+// SOCKSAddr returns the address the SOCKS5 proxy is listening on, or an
+// empty string if it is not running.
+func (b *Bridge) SOCKSAddr() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.socks == nil {
+		return ""
+	}
+	return b.socks.listener.Addr().String()
+}
+
+// startSOCKS serves the SOCKS5 proxy on Config.SOCKSAddr.
+// It is a no-op when no SOCKS address is configured.
+// Callers must hold b.mu.
+func (b *Bridge) startSOCKS() error {
+	if b.config.SOCKSAddr == "" {
+		return nil
+	}
+	ln, err := net.Listen("tcp", b.config.SOCKSAddr)
+	if err != nil {
+		return err
+	}
+
+	dialer := &streamDialer{
+		name:     "socks",
+		serve:    b.server.ServeConn,
+		remote:   ln.Addr(),
+		user:     b.config.SOCKSUser,
+		password: b.config.SOCKSPassword,
+	}
+	server := socks.NewServer(dialer.dial)
+	server.HandshakeTimeout = b.config.HandshakeTimeout
+	server.ErrorHandler = func(err error) {
+		b.deps.ReportError(SourceSOCKS, err)
+	}
+	go func() {
+		if err := server.Serve(ln); err != nil {
+			b.deps.ReportError(SourceSOCKS, err)
+		}
+	}()
+
+	b.socks = &socksFrontend{server: server, listener: ln, dialer: dialer}
+	b.deps.Logger.WithField("addr", ln.Addr()).Info("SOCKS5 proxy started")
+	return nil
+}
+
+// stopSOCKS closes the SOCKS5 proxy and its STREAM session, if running.
+func (b *Bridge) stopSOCKS() {
+	b.mu.Lock()
+	s := b.socks
+	b.socks = nil
+	b.mu.Unlock()
+
+	if s == nil {
+		return
+	}
+	s.server.Close()
+	s.dialer.close()
+}
@@ -0,0 +1,35 @@
+package embedding
+
+import (
+	"context"
+	"net"
+	"testing"
+)
+
+func TestBridgeWithSOCKSAddr(t *testing.T) {
+	b, err := New(WithListenAddr("127.0.0.1:0"), WithI2CPProvider(&mockI2CPProvider{}), WithDatagramPort(0), WithSOCKSAddr("127.0.0.1:0"))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if addr := b.SOCKSAddr(); addr != "" {
+		t.Errorf("SOCKSAddr() before Start = %q, want empty", addr)
+	}
+
+	if err := b.Start(context.Background()); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	addr := b.SOCKSAddr()
+	if addr == "" {
+		t.Fatal("SOCKSAddr() while running is empty")
+	}
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatalf("net.Dial(%s) error = %v", addr, err)
+	}
+	conn.Close()
+
+	b.Stop(context.Background())
+	if addr := b.SOCKSAddr(); addr != "" {
+		t.Errorf("SOCKSAddr() after Stop = %q, want empty", addr)
+	}
+}
@@ -0,0 +1,49 @@
+package embedding
+
+import (
+	"github.com/go-i2p/go-sam-bridge/lib/bridge"
+	"github.com/go-i2p/go-sam-bridge/lib/handler"
+	"github.com/go-i2p/go-sam-bridge/lib/session"
+)
+
+// Stats returns the bridge's uptime, the number of SAM control
+// connections served and open, and the number of commands processed.
+// Counters start from zero when the bridge is created.
+func (b *Bridge) Stats() bridge.Stats {
+	return b.server.Stats()
+}
+
+// CommandLatencies returns a histogram, per command, of the time from
+// reading a command to writing its response, for spotting slow handlers
+// such as NAMING LOOKUP or SESSION CREATE waiting for tunnels.
+func (b *Bridge) CommandLatencies() []bridge.CommandLatency {
+	return b.server.CommandLatencies()
+}
+
+// ForwardStats returns how many STREAM FORWARD streams reached their local
+// target, how many connection attempts failed, and how many streams were
+// dropped after their retries ran out. See WithForwardRetry.
+func (b *Bridge) ForwardStats() handler.ForwardStatsSnapshot {
+	if b.deps == nil || b.deps.ForwardStats == nil {
+		return handler.ForwardStatsSnapshot{}
+	}
+	return b.deps.ForwardStats.Snapshot()
+}
+
+// SessionStats returns the traffic counters of the session with the given ID.
+// The second result is false if no such session is registered or the
+// session does not track statistics. Counters reset when the session closes.
+func (b *Bridge) SessionStats(id string) (session.StatsSnapshot, bool) {
+	if b.deps == nil || b.deps.Registry == nil {
+		return session.StatsSnapshot{}, false
+	}
+	sess := b.deps.Registry.Get(id)
+	if sess == nil {
+		return session.StatsSnapshot{}, false
+	}
+	sp, ok := sess.(session.StatsProvider)
+	if !ok {
+		return session.StatsSnapshot{}, false
+	}
+	return sp.Stats().Snapshot(), true
+}
@@ -0,0 +1,76 @@
+package embedding
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/go-i2p/go-sam-bridge/lib/session"
+)
+
+func TestBridgeSessionStats(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to create test listener: %v", err)
+	}
+	defer ln.Close()
+
+	b, err := New(WithListener(ln), WithI2CPProvider(&mockI2CPProvider{}), WithDatagramPort(0))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	if _, ok := b.SessionStats("missing"); ok {
+		t.Error("SessionStats() for unknown session should return false")
+	}
+
+	sess := session.NewBaseSession("stats-1", session.StyleRaw, nil, nil, nil)
+	if err := b.Dependencies().Registry.Register(sess); err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+	sess.Stats().AddDatagramSent(64)
+
+	stats, ok := b.SessionStats("stats-1")
+	if !ok {
+		t.Fatal("SessionStats() should find registered session")
+	}
+	if stats.DatagramsSent != 1 || stats.BytesSent != 64 {
+		t.Errorf("SessionStats() = %+v, want 1 datagram and 64 bytes sent", stats)
+	}
+}
+
+func TestBridgeStats(t *testing.T) {
+	b, err := New(WithListenAddr("127.0.0.1:0"), WithI2CPProvider(&mockI2CPProvider{}), WithDatagramPort(0))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if stats := b.Stats(); stats.ConnectionsTotal != 0 || !stats.StartedAt.IsZero() {
+		t.Errorf("Stats() before Start = %+v, want zero", stats)
+	}
+
+	if err := b.Start(context.Background()); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	defer b.Stop(context.Background())
+
+	conn, err := net.Dial("tcp", b.Addrs()[0])
+	if err != nil {
+		t.Fatalf("net.Dial() error = %v", err)
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(2 * time.Second))
+	conn.Write([]byte("HELLO VERSION MIN=3.0 MAX=3.3\n"))
+	if _, err := bufio.NewReader(conn).ReadString('\n'); err != nil {
+		t.Fatalf("reading HELLO reply: %v", err)
+	}
+
+	stats := b.Stats()
+	if stats.StartedAt.IsZero() {
+		t.Error("Stats().StartedAt is zero after Start")
+	}
+	if stats.ConnectionsTotal != 1 || stats.ConnectionsActive != 1 || stats.Commands != 1 {
+		t.Errorf("Stats() = %+v, want 1 connection and 1 command", stats)
+	}
+}
@@ -0,0 +1,152 @@
+package embedding
+
+import (
+	"crypto/tls"
+	"fmt"
+	"strings"
+
+	"github.com/go-i2p/go-sam-bridge/lib/bridge"
+)
+
+// TLSPolicy hardens the TLS settings of the SAM control socket. Its zero
+// value keeps the settings of Config.TLSConfig, or Go's defaults.
+type TLSPolicy struct {
+	// MinVersion is the lowest accepted protocol version, such as
+	// tls.VersionTLS13. Zero keeps the default of TLS 1.2.
+	MinVersion uint16
+
+	// CipherSuites lists the cipher suite IDs allowed for TLS 1.2 and
+	// earlier. Nil keeps Go's defaults. TLS 1.3 suites are not
+	// configurable.
+	CipherSuites []uint16
+
+	// NextProtos lists the ALPN protocols offered, in order of preference.
+	NextProtos []string
+}
+
+// IsZero reports whether p keeps every default.
+func (p TLSPolicy) IsZero() bool {
+	return p.MinVersion == 0 && p.CipherSuites == nil && p.NextProtos == nil
+}
+
+// validate returns ErrInvalidTLSPolicy if p names an unknown version or
+// cipher suite.
+func (p TLSPolicy) validate() error {
+	switch p.MinVersion {
+	case 0, tls.VersionTLS10, tls.VersionTLS11, tls.VersionTLS12, tls.VersionTLS13:
+	default:
+		return fmt.Errorf("%w: unknown TLS version 0x%04x", ErrInvalidTLSPolicy, p.MinVersion)
+	}
+	for _, id := range p.CipherSuites {
+		if cipherSuiteName(id) == "" {
+			return fmt.Errorf("%w: unknown cipher suite 0x%04x", ErrInvalidTLSPolicy, id)
+		}
+	}
+	return nil
+}
+
+// apply returns a copy of cfg with the policy's settings, or cfg itself
+// if p is zero or cfg is nil.
+func (p TLSPolicy) apply(cfg *tls.Config) *tls.Config {
+	if cfg == nil || p.IsZero() {
+		return cfg
+	}
+	cfg = cfg.Clone()
+	if p.MinVersion != 0 {
+		cfg.MinVersion = p.MinVersion
+	}
+	if p.CipherSuites != nil {
+		cfg.CipherSuites = p.CipherSuites
+	}
+	if p.NextProtos != nil {
+		cfg.NextProtos = p.NextProtos
+	}
+	return cfg
+}
+
+// ParseTLSVersion parses a TLS protocol version such as "1.2" or "1.3".
+// An empty string returns zero, keeping the default.
+func ParseTLSVersion(s string) (uint16, error) {
+	switch strings.TrimPrefix(strings.ToLower(s), "tls") {
+	case "":
+		return 0, nil
+	case "1.0":
+		return tls.VersionTLS10, nil
+	case "1.1":
+		return tls.VersionTLS11, nil
+	case "1.2":
+		return tls.VersionTLS12, nil
+	case "1.3":
+		return tls.VersionTLS13, nil
+	default:
+		return 0, fmt.Errorf("%w: unknown TLS version %q", ErrInvalidTLSPolicy, s)
+	}
+}
+
+// ParseCipherSuites parses cipher suite names as listed by
+// tls.CipherSuites, such as "TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256".
+// Suites Go considers insecure are rejected.
+func ParseCipherSuites(names []string) ([]uint16, error) {
+	var ids []uint16
+	for _, name := range names {
+		id, ok := secureCipherSuite(name)
+		if !ok {
+			return nil, fmt.Errorf("%w: unknown or insecure cipher suite %q", ErrInvalidTLSPolicy, name)
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+// secureCipherSuite returns the ID of the cipher suite named name, if
+// Go implements it and considers it secure.
+func secureCipherSuite(name string) (uint16, bool) {
+	for _, suite := range tls.CipherSuites() {
+		if suite.Name == name {
+			return suite.ID, true
+		}
+	}
+	return 0, false
+}
+
+// cipherSuiteName returns the name of the cipher suite id, or an empty
+// string if Go does not implement it.
+func cipherSuiteName(id uint16) string {
+	for _, suites := range [][]*tls.CipherSuite{tls.CipherSuites(), tls.InsecureCipherSuites()} {
+		for _, suite := range suites {
+			if suite.ID == id {
+				return suite.Name
+			}
+		}
+	}
+	return ""
+}
+
+// loadTLSFiles loads the configured TLS certificate and key, if any, and
+// points cfg.TLSConfig at a reloader serving them.
+func loadTLSFiles(cfg *Config) (*bridge.CertReloader, error) {
+	if cfg.TLSCertFile == "" {
+		return nil, nil
+	}
+	reloader, err := bridge.NewCertReloader(cfg.TLSCertFile, cfg.TLSKeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("embedding: %w", err)
+	}
+	cfg.TLSConfig = reloader.TLSConfig(cfg.TLSConfig)
+	return reloader, nil
+}
+
+// ReloadTLS reloads the TLS certificate and key configured with
+// WithTLSFiles. New connections use the reloaded certificate; existing
+// connections are unaffected. If loading fails the previous certificate
+// stays in use. Returns ErrTLSReloadUnavailable if TLS was not configured
+// from files.
+func (b *Bridge) ReloadTLS() error {
+	if b.certReloader == nil {
+		return ErrTLSReloadUnavailable
+	}
+	if err := b.certReloader.Reload(); err != nil {
+		return fmt.Errorf("embedding: %w", err)
+	}
+	return nil
+}
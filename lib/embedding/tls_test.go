@@ -0,0 +1,216 @@
+package embedding
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// writeTestKeyPair writes a self-signed certificate with the given common
+// name to dir and returns the certificate and key paths.
+func writeTestKeyPair(t *testing.T, dir, commonName string) (string, string) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey() error = %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: commonName},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("CreateCertificate() error = %v", err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatalf("MarshalECPrivateKey() error = %v", err)
+	}
+
+	certFile := filepath.Join(dir, "cert.pem")
+	keyFile := filepath.Join(dir, "key.pem")
+	if err := os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+	if err := os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+	return certFile, keyFile
+}
+
+func servedCommonName(t *testing.T, cfg *tls.Config) string {
+	t.Helper()
+	cert, err := cfg.GetCertificate(&tls.ClientHelloInfo{})
+	if err != nil {
+		t.Fatalf("GetCertificate() error = %v", err)
+	}
+	leaf, err := x509.ParseCertificate(cert.Certificate[0])
+	if err != nil {
+		t.Fatalf("ParseCertificate() error = %v", err)
+	}
+	return leaf.Subject.CommonName
+}
+
+func TestBridgeReloadTLS(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := writeTestKeyPair(t, dir, "first")
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to create test listener: %v", err)
+	}
+	defer ln.Close()
+
+	b, err := New(
+		WithListener(ln),
+		WithI2CPProvider(&mockI2CPProvider{}),
+		WithTLS(&tls.Config{MinVersion: tls.VersionTLS13}),
+		WithTLSFiles(certFile, keyFile),
+	)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	tlsCfg := b.Config().TLSConfig
+	if tlsCfg == nil || tlsCfg.GetCertificate == nil {
+		t.Fatal("TLSConfig should serve certificates through GetCertificate")
+	}
+	if tlsCfg.MinVersion != tls.VersionTLS13 {
+		t.Errorf("MinVersion = %x, want settings from WithTLS kept", tlsCfg.MinVersion)
+	}
+	if name := servedCommonName(t, tlsCfg); name != "first" {
+		t.Errorf("CommonName = %q, want %q", name, "first")
+	}
+
+	writeTestKeyPair(t, dir, "second")
+	if err := b.ReloadTLS(); err != nil {
+		t.Fatalf("ReloadTLS() error = %v", err)
+	}
+	if name := servedCommonName(t, tlsCfg); name != "second" {
+		t.Errorf("CommonName after ReloadTLS() = %q, want %q", name, "second")
+	}
+}
+
+func TestBridgeReloadTLS_NotConfigured(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to create test listener: %v", err)
+	}
+	defer ln.Close()
+
+	b, err := New(WithListener(ln), WithI2CPProvider(&mockI2CPProvider{}))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if err := b.ReloadTLS(); !errors.Is(err, ErrTLSReloadUnavailable) {
+		t.Errorf("ReloadTLS() error = %v, want %v", err, ErrTLSReloadUnavailable)
+	}
+}
+
+func TestNewWithMissingTLSFiles(t *testing.T) {
+	dir := t.TempDir()
+	_, err := New(
+		WithListenAddr("127.0.0.1:0"),
+		WithI2CPProvider(&mockI2CPProvider{}),
+		WithTLSFiles(filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem")),
+	)
+	if err == nil {
+		t.Error("New() with missing TLS files should return error")
+	}
+}
+
+func TestBridgeTLSPolicy(t *testing.T) {
+	certFile, keyFile := writeTestKeyPair(t, t.TempDir(), "policy")
+	suites, err := ParseCipherSuites([]string{"TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384"})
+	if err != nil {
+		t.Fatalf("ParseCipherSuites() error = %v", err)
+	}
+	base := &tls.Config{MinVersion: tls.VersionTLS12, ServerName: "kept"}
+
+	b, err := New(
+		WithListenAddr("127.0.0.1:0"),
+		WithI2CPProvider(&mockI2CPProvider{}),
+		WithTLS(base),
+		WithTLSFiles(certFile, keyFile),
+		WithTLSPolicy(TLSPolicy{CipherSuites: suites, NextProtos: []string{"sam"}}),
+		WithTLSPolicy(TLSPolicy{MinVersion: tls.VersionTLS13}),
+	)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	tlsCfg := b.Config().TLSConfig
+	if tlsCfg.MinVersion != tls.VersionTLS13 {
+		t.Errorf("MinVersion = %x, want TLS 1.3", tlsCfg.MinVersion)
+	}
+	if len(tlsCfg.CipherSuites) != 1 || tlsCfg.CipherSuites[0] != tls.TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384 {
+		t.Errorf("CipherSuites = %x, want the policy's suite", tlsCfg.CipherSuites)
+	}
+	if len(tlsCfg.NextProtos) != 1 || tlsCfg.NextProtos[0] != "sam" {
+		t.Errorf("NextProtos = %q, want [sam]", tlsCfg.NextProtos)
+	}
+	if tlsCfg.ServerName != "kept" || base.MinVersion != tls.VersionTLS12 {
+		t.Error("TLS policy should apply to a copy of the WithTLS config")
+	}
+	if name := servedCommonName(t, tlsCfg); name != "policy" {
+		t.Errorf("CommonName = %q, want certificates from WithTLSFiles", name)
+	}
+}
+
+func TestParseTLSVersion(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    uint16
+		wantErr bool
+	}{
+		{"", 0, false},
+		{"1.2", tls.VersionTLS12, false},
+		{"1.3", tls.VersionTLS13, false},
+		{"TLS1.3", tls.VersionTLS13, false},
+		{"1.4", 0, true},
+		{"ssl3", 0, true},
+	}
+	for _, tt := range tests {
+		got, err := ParseTLSVersion(tt.in)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("ParseTLSVersion(%q) = %x, %v; want %x, error %v", tt.in, got, err, tt.want, tt.wantErr)
+		}
+		if err != nil && !errors.Is(err, ErrInvalidTLSPolicy) {
+			t.Errorf("ParseTLSVersion(%q) error = %v, want ErrInvalidTLSPolicy", tt.in, err)
+		}
+	}
+}
+
+func TestParseCipherSuites(t *testing.T) {
+	if _, err := ParseCipherSuites([]string{"TLS_RSA_WITH_RC4_128_SHA"}); !errors.Is(err, ErrInvalidTLSPolicy) {
+		t.Errorf("ParseCipherSuites(insecure) error = %v, want ErrInvalidTLSPolicy", err)
+	}
+	if _, err := ParseCipherSuites([]string{"TLS_NO_SUCH_SUITE"}); !errors.Is(err, ErrInvalidTLSPolicy) {
+		t.Errorf("ParseCipherSuites(unknown) error = %v, want ErrInvalidTLSPolicy", err)
+	}
+	if ids, err := ParseCipherSuites(nil); err != nil || ids != nil {
+		t.Errorf("ParseCipherSuites(nil) = %v, %v; want nil, nil", ids, err)
+	}
+}
+
+func TestConfigValidate_TLSPolicy(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.TLSPolicy.MinVersion = 0x0200
+	if err := cfg.Validate(); !errors.Is(err, ErrInvalidTLSPolicy) {
+		t.Errorf("Validate() = %v, want ErrInvalidTLSPolicy", err)
+	}
+}
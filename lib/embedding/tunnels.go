@@ -0,0 +1,146 @@
+package embedding
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/go-i2p/go-sam-bridge/lib/destination"
+	"github.com/go-i2p/go-sam-bridge/lib/tunnels"
+)
+
+// TunnelStatus returns the state of each configured tunnel, or nil if the
+// bridge runs no tunnels.
+func (b *Bridge) TunnelStatus() []tunnels.Status {
+	b.mu.Lock()
+	m := b.tunnels
+	b.mu.Unlock()
+	if m == nil {
+		return nil
+	}
+	return m.Status()
+}
+
+// TunnelAddr returns the address client tunnel name is listening on, or
+// an empty string if there is no such running tunnel.
+func (b *Bridge) TunnelAddr(name string) string {
+	b.mu.Lock()
+	m := b.tunnels
+	b.mu.Unlock()
+	if m == nil {
+		return ""
+	}
+	return m.ListenAddr(name)
+}
+
+// startTunnels runs the tunnels in Config.Tunnels.
+// It is a no-op when none are configured.
+// Callers must hold b.mu.
+func (b *Bridge) startTunnels() error {
+	if len(b.config.Tunnels) == 0 {
+		return nil
+	}
+
+	backend := &tunnelBackend{
+		dests: b.deps.DestManager,
+		sam: &streamDialer{
+			name:     "tunnel",
+			serve:    b.server.ServeConn,
+			remote:   &net.TCPAddr{IP: net.IPv4(127, 0, 0, 1)},
+			user:     b.config.TunnelUser,
+			password: b.config.TunnelPassword,
+		},
+	}
+	m, err := tunnels.NewManager(backend, b.config.Tunnels)
+	if err != nil {
+		return err
+	}
+	m.ErrorHandler = func(err error) {
+		b.deps.ReportError(SourceTunnels, err)
+	}
+	if err := m.Start(); err != nil {
+		return err
+	}
+
+	b.tunnels = m
+	b.deps.Logger.WithField("count", len(b.config.Tunnels)).Info("Tunnels started")
+	return nil
+}
+
+// stopTunnels stops the tunnels, if running.
+func (b *Bridge) stopTunnels() {
+	b.mu.Lock()
+	m := b.tunnels
+	b.tunnels = nil
+	b.mu.Unlock()
+
+	if m != nil {
+		m.Close()
+	}
+}
+
+// tunnelBackend runs configured tunnels on SAM STREAM sessions on the
+// bridge, one per tunnel, named after it.
+type tunnelBackend struct {
+	dests destination.Manager
+
+	// sam holds the connection settings tunnel sessions are created with.
+	sam *streamDialer
+}
+
+// LoadKeys reads the private keys in keyfile, which holds either a binary
+// PrivateKeyFile as written by Java I2P and i2pd or SAM's Base64 private
+// key form. A missing keyfile is created with a new Ed25519 destination
+// in the binary form.
+func (tb *tunnelBackend) LoadKeys(keyfile string) (string, error) {
+	data, err := os.ReadFile(keyfile)
+	if errors.Is(err, fs.ErrNotExist) {
+		return tb.createKeys(keyfile)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	if dest, priv, err := destination.DecodePrivateKeyFile(data); err == nil {
+		return tb.dests.Encode(dest, priv)
+	}
+	keys := strings.TrimSpace(string(data))
+	if _, _, err := tb.dests.Parse(keys); err != nil {
+		return "", err
+	}
+	return keys, nil
+}
+
+// createKeys generates a destination and writes it to keyfile.
+func (tb *tunnelBackend) createKeys(keyfile string) (string, error) {
+	dest, priv, err := tb.dests.Generate(7)
+	if err != nil {
+		return "", err
+	}
+	data, err := destination.EncodePrivateKeyFile(dest, priv)
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(filepath.Dir(keyfile), 0o700); err != nil {
+		return "", err
+	}
+	if err := os.WriteFile(keyfile, data, 0o600); err != nil {
+		return "", err
+	}
+	return tb.dests.Encode(dest, priv)
+}
+
+// Start creates the tunnel's STREAM session. Server tunnels forward
+// incoming streams silently, so the target sees only the stream data as
+// it would behind i2ptunnel.
+func (tb *tunnelBackend) Start(ctx context.Context, spec tunnels.Spec) (tunnels.Session, error) {
+	t, err := startTunnelSession(ctx, tb.sam, spec.Name, spec.Keys, spec.Options, spec.ForwardHost, spec.ForwardPort, true)
+	if err != nil {
+		return nil, err
+	}
+	return t, nil
+}
@@ -0,0 +1,84 @@
+package embedding
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-i2p/go-sam-bridge/lib/destination"
+	"github.com/go-i2p/go-sam-bridge/lib/i2cp/i2cptest"
+	"github.com/go-i2p/go-sam-bridge/lib/tunnels"
+)
+
+func TestTunnelBackend_LoadKeys(t *testing.T) {
+	dests := destination.NewManager()
+	tb := &tunnelBackend{dests: dests}
+	dir := t.TempDir()
+
+	// A missing key file is created and then reused.
+	keyfile := filepath.Join(dir, "keys", "web.dat")
+	keys, err := tb.LoadKeys(keyfile)
+	if err != nil {
+		t.Fatalf("LoadKeys() error = %v", err)
+	}
+	info, err := os.Stat(keyfile)
+	if err != nil {
+		t.Fatalf("key file not written: %v", err)
+	}
+	if info.Mode().Perm() != 0o600 {
+		t.Errorf("key file mode = %v, want 0600", info.Mode().Perm())
+	}
+	if again, err := tb.LoadKeys(keyfile); err != nil || again != keys {
+		t.Errorf("LoadKeys() again = %v; want the same keys", err)
+	}
+
+	// SAM's Base64 form is accepted too.
+	b64 := filepath.Join(dir, "web.b64")
+	os.WriteFile(b64, []byte(keys+"\n"), 0o600)
+	if got, err := tb.LoadKeys(b64); err != nil || got != keys {
+		t.Errorf("LoadKeys(base64) error = %v; want the same keys", err)
+	}
+
+	bad := filepath.Join(dir, "bad.dat")
+	os.WriteFile(bad, []byte("not keys"), 0o600)
+	if _, err := tb.LoadKeys(bad); err == nil {
+		t.Error("LoadKeys() accepted an invalid key file")
+	}
+}
+
+func TestBridgeWithTunnels(t *testing.T) {
+	b, err := New(WithListenAddr("127.0.0.1:0"), WithI2CPProvider(i2cptest.NewProvider()), WithDatagramPort(0),
+		WithTunnels(tunnels.Tunnel{Name: "irc", Type: tunnels.TypeClient, Listen: "127.0.0.1:0", Destination: "irc.postman.i2p:6667"}))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if status := b.TunnelStatus(); status != nil {
+		t.Errorf("TunnelStatus() before Start = %+v, want nil", status)
+	}
+
+	if err := b.Start(context.Background()); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	if addr := b.TunnelAddr("irc"); addr == "" {
+		t.Error("TunnelAddr(irc) while running is empty")
+	}
+
+	// The tunnel's session is created on the bridge.
+	deadline := time.Now().Add(5 * time.Second)
+	for !b.TunnelStatus()[0].Running {
+		if time.Now().After(deadline) {
+			t.Fatalf("tunnel never started: %+v", b.TunnelStatus())
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if n := b.Dependencies().Registry.Count(); n != 1 {
+		t.Errorf("registry holds %d sessions, want 1", n)
+	}
+
+	b.Stop(context.Background())
+	if status := b.TunnelStatus(); status != nil {
+		t.Errorf("TunnelStatus() after Stop = %+v, want nil", status)
+	}
+}
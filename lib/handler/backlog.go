@@ -0,0 +1,88 @@
+package handler
+
+import (
+	"net"
+	"time"
+)
+
+// DefaultAcceptBacklogTimeout is how long a stream waits in an accept
+// backlog for STREAM ACCEPT when no timeout is configured.
+const DefaultAcceptBacklogTimeout = 10 * time.Second
+
+// backlogListener accepts inbound streams from an I2P listener as they
+// arrive and holds up to size of them until the next Accept, each for
+// at most timeout. Streams arriving while the backlog is full, or
+// waiting longer than timeout, are closed.
+type backlogListener struct {
+	net.Listener
+	queue   chan pendingStream
+	timeout time.Duration
+
+	// err is the error that stopped the underlying listener. It is set
+	// before queue is closed.
+	err error
+}
+
+// pendingStream is a stream waiting in the backlog. expire closes it
+// once the backlog timeout has passed.
+type pendingStream struct {
+	conn   net.Conn
+	expire *time.Timer
+}
+
+// newBacklogListener starts accepting streams from l into a backlog of
+// size streams.
+func newBacklogListener(l net.Listener, size int, timeout time.Duration) *backlogListener {
+	if timeout <= 0 {
+		timeout = DefaultAcceptBacklogTimeout
+	}
+	b := &backlogListener{
+		Listener: l,
+		queue:    make(chan pendingStream, size),
+		timeout:  timeout,
+	}
+	go b.run()
+	return b
+}
+
+// run moves streams from the underlying listener into the backlog until
+// the listener is closed, then closes the streams still waiting.
+func (b *backlogListener) run() {
+	for {
+		conn, err := b.Listener.Accept()
+		if err != nil {
+			b.err = err
+			close(b.queue)
+			break
+		}
+		p := pendingStream{conn: conn}
+		p.expire = time.AfterFunc(b.timeout, func() { conn.Close() })
+		select {
+		case b.queue <- p:
+		default:
+			if p.expire.Stop() {
+				conn.Close()
+			}
+		}
+	}
+
+	for p := range b.queue {
+		if p.expire.Stop() {
+			p.conn.Close()
+		}
+	}
+}
+
+// Accept returns the oldest stream in the backlog that has not expired,
+// waiting for one if the backlog is empty.
+func (b *backlogListener) Accept() (net.Conn, error) {
+	for {
+		p, ok := <-b.queue
+		if !ok {
+			return nil, b.err
+		}
+		if p.expire.Stop() {
+			return p.conn, nil
+		}
+	}
+}
@@ -0,0 +1,148 @@
+package handler
+
+import (
+	"errors"
+	"io"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-i2p/go-sam-bridge/lib/session"
+)
+
+// chanListener is a net.Listener accepting the connections sent on conns.
+type chanListener struct {
+	conns     chan net.Conn
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+func newChanListener() *chanListener {
+	return &chanListener{conns: make(chan net.Conn), done: make(chan struct{})}
+}
+
+func (l *chanListener) Accept() (net.Conn, error) {
+	select {
+	case conn := <-l.conns:
+		return conn, nil
+	case <-l.done:
+		return nil, net.ErrClosed
+	}
+}
+
+func (l *chanListener) Close() error {
+	l.closeOnce.Do(func() { close(l.done) })
+	return nil
+}
+
+func (l *chanListener) Addr() net.Addr {
+	return &net.TCPAddr{IP: net.ParseIP("127.0.0.1")}
+}
+
+// arrive delivers a new inbound stream to l and returns its remote end.
+func (l *chanListener) arrive(t *testing.T) net.Conn {
+	t.Helper()
+	local, remote := net.Pipe()
+	t.Cleanup(func() { remote.Close() })
+	select {
+	case l.conns <- local:
+	case <-time.After(time.Second):
+		t.Fatal("stream was not accepted from the listener")
+	}
+	return remote
+}
+
+// isClosed reports whether the peer of remote has been closed.
+func isClosed(remote net.Conn) bool {
+	remote.SetReadDeadline(time.Now().Add(time.Second))
+	_, err := remote.Read(make([]byte, 1))
+	return errors.Is(err, io.EOF)
+}
+
+func TestBacklogListener_HoldsStreams(t *testing.T) {
+	inner := newChanListener()
+	l := newBacklogListener(inner, 2, time.Minute)
+	defer l.Close()
+
+	first := inner.arrive(t)
+	second := inner.arrive(t)
+	third := inner.arrive(t)
+	if !isClosed(third) {
+		t.Error("stream arriving while the backlog is full should be closed")
+	}
+
+	for _, remote := range []net.Conn{first, second} {
+		conn, err := l.Accept()
+		if err != nil {
+			t.Fatalf("Accept() error = %v", err)
+		}
+		go conn.Write([]byte("x"))
+		remote.SetReadDeadline(time.Now().Add(time.Second))
+		if _, err := remote.Read(make([]byte, 1)); err != nil {
+			t.Errorf("Accept() returned streams out of order: %v", err)
+		}
+		conn.Close()
+	}
+}
+
+func TestBacklogListener_Expires(t *testing.T) {
+	inner := newChanListener()
+	l := newBacklogListener(inner, 4, 20*time.Millisecond)
+	defer l.Close()
+
+	stale := inner.arrive(t)
+	if !isClosed(stale) {
+		t.Fatal("stream should be closed after the backlog timeout")
+	}
+
+	inner.arrive(t)
+	conn, err := l.Accept()
+	if err != nil {
+		t.Fatalf("Accept() error = %v", err)
+	}
+	conn.Close()
+}
+
+func TestBacklogListener_Close(t *testing.T) {
+	inner := newChanListener()
+	l := newBacklogListener(inner, 4, time.Minute)
+
+	waiting := inner.arrive(t)
+	l.Close()
+
+	if !isClosed(waiting) {
+		t.Error("waiting stream should be closed with the listener")
+	}
+	if _, err := l.Accept(); !errors.Is(err, net.ErrClosed) {
+		t.Errorf("Accept() after Close error = %v, want net.ErrClosed", err)
+	}
+}
+
+// chanStreamManager is a StreamManager whose listener is a chanListener.
+type chanStreamManager struct {
+	mockStreamManager
+	listener *chanListener
+}
+
+func (m *chanStreamManager) Listen(port uint16, mtu int) (net.Listener, error) {
+	return m.listener, nil
+}
+
+func TestStreamingAcceptor_SetAcceptBacklog(t *testing.T) {
+	inner := newChanListener()
+	acceptor := NewStreamingAcceptor()
+	acceptor.SetAcceptBacklog(8, time.Second)
+	if err := acceptor.RegisterManager("backlog", &chanStreamManager{listener: inner}); err != nil {
+		t.Fatalf("RegisterManager() error = %v", err)
+	}
+	defer acceptor.UnregisterManager("backlog")
+
+	// The stream arrives before STREAM ACCEPT
+	inner.arrive(t)
+	conn, _, err := acceptor.Accept(&streamMockSession{id: "backlog", style: session.StyleStream})
+	if err != nil {
+		t.Fatalf("Accept() error = %v", err)
+	}
+	conn.Close()
+}
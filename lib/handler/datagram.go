@@ -88,13 +88,13 @@ func (h *DatagramHandler) handleSend(ctx *Context, cmd *protocol.Command) (*prot
 	}
 
 	// Parse port options (SAM 3.2+)
-	fromPort, toPort, resp := h.parseDatagramPortOptions(cmd)
+	fromPort, toPort, resp := h.parseDatagramPortOptions(cmd, ctx.Version)
 	if resp != nil {
 		return resp, nil
 	}
 
 	// Parse SAM 3.3 options
-	sam33Opts, resp := h.parseDatagramSAM33Options(cmd)
+	sam33Opts, resp := h.parseDatagramSAM33Options(cmd, ctx.Version)
 	if resp != nil {
 		return resp, nil
 	}
@@ -165,10 +165,15 @@ func (h *DatagramHandler) parseDatagramRequiredParams(cmd *protocol.Command) (st
 }
 
 // parseDatagramPortOptions extracts FROM_PORT and TO_PORT from the command (SAM 3.2+).
-func (h *DatagramHandler) parseDatagramPortOptions(cmd *protocol.Command) (int, int, *protocol.Response) {
+// They are ignored on connections that negotiated an earlier version.
+func (h *DatagramHandler) parseDatagramPortOptions(cmd *protocol.Command, version string) (int, int, *protocol.Response) {
 	var fromPort, toPort int
 	var err error
 
+	if !protocol.VersionSupportsPortInfo(version) {
+		return 0, 0, nil
+	}
+
 	if fromPortStr := cmd.Get("FROM_PORT"); fromPortStr != "" {
 		fromPort, err = parseDatagramPort(fromPortStr, "FROM_PORT")
 		if err != nil {
@@ -195,12 +200,17 @@ type datagramSAM33Options struct {
 }
 
 // parseDatagramSAM33Options extracts SAM 3.3 specific options from the command.
-func (h *DatagramHandler) parseDatagramSAM33Options(cmd *protocol.Command) (*datagramSAM33Options, *protocol.Response) {
+// They are ignored on connections that negotiated an earlier version.
+func (h *DatagramHandler) parseDatagramSAM33Options(cmd *protocol.Command, version string) (*datagramSAM33Options, *protocol.Response) {
 	opts := &datagramSAM33Options{
 		SendLeaseset: true, // Default per SAMv3.md
 	}
 	var err error
 
+	if !protocol.VersionSupportsSendOptions(version) {
+		return opts, nil
+	}
+
 	if sendTagsStr := cmd.Get("SEND_TAGS"); sendTagsStr != "" {
 		opts.SendTags, err = parseSAM33Option(sendTagsStr, "SEND_TAGS", 0, 15)
 		if err != nil {
@@ -315,11 +325,19 @@ func FormatDatagramReceived(dg session.ReceivedDatagram, version string) string
 //	$destination\n
 //	<datagram_payload>
 //
+// As of SAM 3.2, the header line also carries the ports:
+//
+//	$destination FROM_PORT=nnn TO_PORT=nnn\n
+//
 // Parameters:
-//   - dg: The received datagram containing source destination
+//   - dg: The received datagram containing source destination and ports
+//   - version: The negotiated SAM version (e.g., "3.2", "3.3")
 //
-// Returns the header line (just the destination, without trailing newline).
-func FormatDatagramForward(dg session.ReceivedDatagram) string {
+// Returns the header line (without trailing newline).
+func FormatDatagramForward(dg session.ReceivedDatagram, version string) string {
+	if protocol.VersionSupportsPortInfo(version) {
+		return fmt.Sprintf("%s FROM_PORT=%d TO_PORT=%d", dg.Source, dg.FromPort, dg.ToPort)
+	}
 	return dg.Source
 }
 
@@ -471,6 +471,52 @@ func TestDatagramHandler_HandleSend_SAM33OptionsDefaults(t *testing.T) {
 	}
 }
 
+func TestDatagramHandler_HandleSend_VersionGatedOptions(t *testing.T) {
+	tests := []struct {
+		version      string
+		wantFromPort int
+		wantSendTags int
+	}{
+		{"3.1", 0, 0},
+		{"3.2", 1234, 0},
+		{"3.3", 1234, 10},
+	}
+
+	for _, tt := range tests {
+		t.Run("SAM "+tt.version, func(t *testing.T) {
+			handler := NewDatagramHandler()
+			mockSess := newMockDatagramSession("test-datagram")
+
+			cmd := &protocol.Command{
+				Verb:   protocol.VerbDatagram,
+				Action: protocol.ActionSend,
+				Options: map[string]string{
+					"DESTINATION": "test.i2p",
+					"SIZE":        "5",
+					"FROM_PORT":   "1234",
+					"SEND_TAGS":   "10",
+				},
+				Payload: []byte("hello"),
+			}
+
+			ctx := NewContext(&mockConn{}, newMockRegistry())
+			ctx.HandshakeComplete = true
+			ctx.Version = tt.version
+			ctx.BindSession(mockSess)
+
+			if resp, err := handler.Handle(ctx, cmd); err != nil || resp != nil {
+				t.Fatalf("Handle() = %v, %v, want nil, nil", resp, err)
+			}
+			if mockSess.lastSendOpts.FromPort != tt.wantFromPort {
+				t.Errorf("Send() FromPort = %d, want %d", mockSess.lastSendOpts.FromPort, tt.wantFromPort)
+			}
+			if mockSess.lastSendOpts.SendTags != tt.wantSendTags {
+				t.Errorf("Send() SendTags = %d, want %d", mockSess.lastSendOpts.SendTags, tt.wantSendTags)
+			}
+		})
+	}
+}
+
 func TestDatagramHandler_HandleSend_SAM33OptionsValidation(t *testing.T) {
 	handler := NewDatagramHandler()
 
@@ -796,9 +842,10 @@ func TestFormatDatagramReceived(t *testing.T) {
 
 func TestFormatDatagramForward(t *testing.T) {
 	tests := []struct {
-		name string
-		dg   session.ReceivedDatagram
-		want string
+		name    string
+		dg      session.ReceivedDatagram
+		version string
+		want    string
 	}{
 		{
 			name: "basic forward",
@@ -808,7 +855,8 @@ func TestFormatDatagramForward(t *testing.T) {
 				FromPort: 1234,
 				ToPort:   5678,
 			},
-			want: "sender-destination.i2p",
+			version: "3.1",
+			want:    "sender-destination.i2p",
 		},
 		{
 			name: "long destination",
@@ -818,13 +866,34 @@ func TestFormatDatagramForward(t *testing.T) {
 				FromPort: 0,
 				ToPort:   0,
 			},
-			want: "verylong-destination-name-for-testing-purposes.i2p",
+			version: "3.0",
+			want:    "verylong-destination-name-for-testing-purposes.i2p",
+		},
+		{
+			name: "SAM 3.2 includes ports",
+			dg: session.ReceivedDatagram{
+				Source:   "sender-destination.i2p",
+				Data:     []byte("test"),
+				FromPort: 1234,
+				ToPort:   5678,
+			},
+			version: "3.2",
+			want:    "sender-destination.i2p FROM_PORT=1234 TO_PORT=5678",
+		},
+		{
+			name: "empty version includes ports",
+			dg: session.ReceivedDatagram{
+				Source: "sender-destination.i2p",
+				Data:   []byte("test"),
+			},
+			version: "",
+			want:    "sender-destination.i2p FROM_PORT=0 TO_PORT=0",
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got := FormatDatagramForward(tt.dg)
+			got := FormatDatagramForward(tt.dg, tt.version)
 			if got != tt.want {
 				t.Errorf("FormatDatagramForward() = %q, want %q", got, tt.want)
 			}
@@ -2,7 +2,12 @@
 package handler
 
 import (
+	"context"
+	"errors"
 	"strconv"
+	"time"
+
+	commondest "github.com/go-i2p/common/destination"
 
 	"github.com/go-i2p/go-sam-bridge/lib/destination"
 	"github.com/go-i2p/go-sam-bridge/lib/protocol"
@@ -11,22 +16,42 @@ import (
 // DestHandler handles DEST GENERATE commands per SAM 3.0-3.3.
 // Generates new I2P destinations with configurable signature types.
 type DestHandler struct {
-	manager destination.Manager
+	manager       destination.Manager
+	vanityTimeout time.Duration
 }
 
+// DefaultVanityTimeout is the default time limit for DEST GENERATE PATTERN.
+const DefaultVanityTimeout = 2 * time.Minute
+
 // NewDestHandler creates a new DEST handler with the given destination manager.
 func NewDestHandler(manager destination.Manager) *DestHandler {
-	return &DestHandler{manager: manager}
+	return &DestHandler{manager: manager, vanityTimeout: DefaultVanityTimeout}
+}
+
+// SetVanityTimeout sets how long DEST GENERATE PATTERN may search for a
+// matching destination before failing.
+func (h *DestHandler) SetVanityTimeout(timeout time.Duration) {
+	if timeout > 0 {
+		h.vanityTimeout = timeout
+	}
 }
 
 // Handle processes a DEST GENERATE command.
 // Per SAMv3.md, DEST GENERATE creates a new destination keypair.
 // DEST GENERATE cannot be used to create a destination with offline signatures.
 //
-// Request: DEST GENERATE [SIGNATURE_TYPE=value]
-// Response: DEST REPLY PUB=$destination PRIV=$privkey
+// Request: DEST GENERATE [SIGNATURE_TYPE=value] [PATTERN=prefix]
+// Response: DEST REPLY PUB=$destination PRIV=$privkey [B32=$address]
 //
 //	DEST REPLY RESULT=I2P_ERROR MESSAGE="..."
+//
+// PATTERN is a go-sam-bridge extension: keys are generated until the
+// .b32.i2p address starts with prefix (a-z and 2-7, case-insensitive, at
+// most destination.MaxVanityPrefixLength characters). The search fails
+// with I2P_ERROR after the vanity timeout. A reply to a PATTERN request
+// also carries the matching .b32.i2p address as B32. Without PATTERN the
+// reply is the plain SAM 3.3 one, since some client libraries reject any
+// field other than PUB and PRIV.
 func (h *DestHandler) Handle(ctx *Context, cmd *protocol.Command) (*protocol.Response, error) {
 	// Per SAM spec: DEST GENERATE cannot be used to create a destination with
 	// offline signatures. Reject any offline signature-related parameters.
@@ -49,7 +74,7 @@ func (h *DestHandler) Handle(ctx *Context, cmd *protocol.Command) (*protocol.Res
 	}
 
 	// Generate the destination
-	dest, privateKey, err := h.manager.Generate(sigType)
+	dest, privateKey, err := h.generate(ctx, cmd, sigType)
 	if err != nil {
 		return destError("key generation failed: " + err.Error()), nil
 	}
@@ -66,7 +91,37 @@ func (h *DestHandler) Handle(ctx *Context, cmd *protocol.Command) (*protocol.Res
 		return destError("encoding failed: " + err.Error()), nil
 	}
 
-	return destReply(pubBase64, privBase64), nil
+	// Only PATTERN requests get the B32 extension field; it is omitted if
+	// the address cannot be derived.
+	var b32 string
+	if cmd.Get("PATTERN") != "" {
+		b32, _ = destination.Base32FromBase64(pubBase64)
+	}
+
+	return destReply(pubBase64, privBase64, b32), nil
+}
+
+// generate creates the destination, searching for a vanity address if
+// the command carries a PATTERN option.
+func (h *DestHandler) generate(ctx *Context, cmd *protocol.Command, sigType int) (*commondest.Destination, []byte, error) {
+	pattern := cmd.Get("PATTERN")
+	if pattern == "" {
+		return h.manager.Generate(sigType)
+	}
+
+	vanity, ok := h.manager.(destination.VanityGenerator)
+	if !ok {
+		return nil, nil, errors.New("PATTERN is not supported")
+	}
+
+	parent := context.Background()
+	if ctx != nil && ctx.Ctx != nil {
+		parent = ctx.Ctx
+	}
+	searchCtx, cancel := context.WithTimeout(parent, h.vanityTimeout)
+	defer cancel()
+
+	return vanity.GenerateVanity(searchCtx, sigType, pattern, 0)
 }
 
 // parseSignatureType extracts and validates the SIGNATURE_TYPE option.
@@ -195,12 +250,17 @@ func toLower(c byte) byte {
 	return c
 }
 
-// destReply returns a successful DEST REPLY response.
-func destReply(pub, priv string) *protocol.Response {
-	return protocol.NewResponse(protocol.VerbDest).
+// destReply returns a successful DEST REPLY response. The B32 field is
+// left out when b32 is empty.
+func destReply(pub, priv, b32 string) *protocol.Response {
+	resp := protocol.NewResponse(protocol.VerbDest).
 		WithAction(protocol.ActionReply).
 		WithOption("PUB", pub).
 		WithOption("PRIV", priv)
+	if b32 != "" {
+		resp.WithOption("B32", b32)
+	}
+	return resp
 }
 
 // destError returns an I2P_ERROR response with a message.
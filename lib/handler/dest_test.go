@@ -1,6 +1,7 @@
 package handler
 
 import (
+	"context"
 	"errors"
 	"strings"
 	"testing"
@@ -284,6 +285,100 @@ func TestDestHandler_Handle(t *testing.T) {
 	}
 }
 
+func TestDestHandler_HandleB32(t *testing.T) {
+	tests := []struct {
+		name    string
+		options map[string]string
+		wantB32 bool
+	}{
+		{"plain request", map[string]string{}, false},
+		{"pattern request", map[string]string{"PATTERN": "a"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd := &protocol.Command{Verb: "DEST", Action: "GENERATE", Options: tt.options}
+			resp, err := NewDestHandler(destination.NewManager()).Handle(NewContext(&mockConn{}, nil), cmd)
+			if err != nil {
+				t.Fatalf("Handle() error = %v", err)
+			}
+			reply, err := protocol.NewParser().Parse(resp.String())
+			if err != nil {
+				t.Fatalf("Parse(%q) error = %v", resp.String(), err)
+			}
+
+			if !tt.wantB32 {
+				// The plain reply must stay byte-identical to SAM 3.3.
+				want := "DEST REPLY PUB=" + reply.Get("PUB") + " PRIV=" + reply.Get("PRIV") + "\n"
+				if got := resp.String(); got != want {
+					t.Errorf("Handle() = %q, want %q", got, want)
+				}
+				return
+			}
+			want, err := destination.Base32FromBase64(reply.Get("PUB"))
+			if err != nil {
+				t.Fatalf("Base32FromBase64(PUB) error = %v", err)
+			}
+			if got := reply.Get("B32"); got != want {
+				t.Errorf("B32 = %q, want %q", got, want)
+			}
+		})
+	}
+}
+
+// mockVanityManager adds destination.VanityGenerator to mockManager.
+type mockVanityManager struct {
+	mockManager
+	pattern   string
+	vanityErr error
+}
+
+func (m *mockVanityManager) GenerateVanity(ctx context.Context, signatureType int, prefix string, workers int) (*commondest.Destination, []byte, error) {
+	m.pattern = prefix
+	if m.vanityErr != nil {
+		return nil, nil, m.vanityErr
+	}
+	return m.dest, m.privateKey, nil
+}
+
+func TestDestHandler_HandlePattern(t *testing.T) {
+	cmd := &protocol.Command{
+		Verb:    "DEST",
+		Action:  "GENERATE",
+		Options: map[string]string{"PATTERN": "abc"},
+	}
+
+	t.Run("vanity generator", func(t *testing.T) {
+		manager := &mockVanityManager{mockManager: mockManager{
+			dest:        &commondest.Destination{},
+			pubEncoded:  "test-pub-base64",
+			privEncoded: "test-priv-base64",
+		}}
+		resp, _ := NewDestHandler(manager).Handle(NewContext(&mockConn{}, nil), cmd)
+		if respStr := resp.String(); !strings.Contains(respStr, "PUB=test-pub-base64") {
+			t.Errorf("Handle() = %q, want PUB=test-pub-base64", respStr)
+		}
+		if manager.pattern != "abc" {
+			t.Errorf("GenerateVanity() prefix = %q, want abc", manager.pattern)
+		}
+	})
+
+	t.Run("search failure", func(t *testing.T) {
+		manager := &mockVanityManager{vanityErr: context.DeadlineExceeded}
+		resp, _ := NewDestHandler(manager).Handle(NewContext(&mockConn{}, nil), cmd)
+		if respStr := resp.String(); !strings.Contains(respStr, "RESULT="+protocol.ResultI2PError) {
+			t.Errorf("Handle() = %q, want RESULT=%s", respStr, protocol.ResultI2PError)
+		}
+	})
+
+	t.Run("manager without vanity support", func(t *testing.T) {
+		resp, _ := NewDestHandler(&mockManager{}).Handle(NewContext(&mockConn{}, nil), cmd)
+		if respStr := resp.String(); !strings.Contains(respStr, "not supported") {
+			t.Errorf("Handle() = %q, want PATTERN not supported", respStr)
+		}
+	})
+}
+
 func TestParseSignatureType(t *testing.T) {
 	tests := []struct {
 		name    string
@@ -420,7 +515,7 @@ func TestEqualFold(t *testing.T) {
 
 func TestDestResponses(t *testing.T) {
 	t.Run("destReply", func(t *testing.T) {
-		resp := destReply("pub123", "priv456")
+		resp := destReply("pub123", "priv456", "abc.b32.i2p")
 		got := resp.String()
 		if !strings.Contains(got, "DEST REPLY") {
 			t.Errorf("destReply() = %q, want 'DEST REPLY'", got)
@@ -431,6 +526,12 @@ func TestDestResponses(t *testing.T) {
 		if !strings.Contains(got, "PRIV=priv456") {
 			t.Errorf("destReply() = %q, want 'PRIV=priv456'", got)
 		}
+		if !strings.Contains(got, "B32=abc.b32.i2p") {
+			t.Errorf("destReply() = %q, want 'B32=abc.b32.i2p'", got)
+		}
+		if got := destReply("pub123", "priv456", "").String(); strings.Contains(got, "B32=") {
+			t.Errorf("destReply() without b32 = %q, want no B32", got)
+		}
 	})
 
 	t.Run("destError", func(t *testing.T) {
@@ -0,0 +1,226 @@
+package handler
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"runtime"
+	"runtime/pprof"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/go-i2p/go-sam-bridge/lib/protocol"
+	"github.com/go-i2p/go-sam-bridge/lib/session"
+)
+
+// maxDumpGoroutineGroups limits how many goroutine groups DUMP lists.
+const maxDumpGoroutineGroups = 20
+
+// DumpHandler handles the DUMP command, a bridge extension that returns a
+// diagnostic snapshot of the bridge for debugging deployments where a
+// debugger cannot be attached. Only clients that authenticated with USER
+// and PASSWORD may use it, so it is unavailable when authentication is
+// disabled.
+//
+// Request: DUMP
+// Response: DUMP REPLY RESULT=OK COUNT=$n
+//
+//	DUMP LINE TEXT="$section $details"
+//	...
+//
+// Sections are, in order:
+//   - runtime: goroutine count, GOMAXPROCS and heap statistics
+//   - goroutines: goroutines grouped by stack, largest groups first,
+//     each named after its innermost non-runtime function
+//   - session: one line per session visible to the client
+//   - forward: one line per STREAM FORWARD of those sessions, then the
+//     forwarding counters
+//   - i2cp: router connection state, version and health
+type DumpHandler struct {
+	forwarder    *StreamingForwarder
+	i2cpProvider session.I2CPSessionProvider
+}
+
+// NewDumpHandler creates a new DUMP handler.
+func NewDumpHandler() *DumpHandler {
+	return &DumpHandler{}
+}
+
+// SetForwarder sets the forwarder whose forwards and counters are dumped.
+func (h *DumpHandler) SetForwarder(f *StreamingForwarder) {
+	h.forwarder = f
+}
+
+// SetI2CPProvider sets the I2CP provider whose connection is dumped.
+func (h *DumpHandler) SetI2CPProvider(provider session.I2CPSessionProvider) {
+	h.i2cpProvider = provider
+}
+
+// Handle processes a DUMP command.
+func (h *DumpHandler) Handle(ctx *Context, cmd *protocol.Command) (*protocol.Response, error) {
+	if !ctx.HandshakeComplete {
+		return dumpError("handshake not complete"), nil
+	}
+	if ctx.User == "" {
+		return dumpError("DUMP requires an authenticated user"), nil
+	}
+
+	var lines []string
+	lines = append(lines, dumpRuntime()...)
+	lines = append(lines, dumpGoroutines()...)
+	lines = append(lines, dumpSessions(ctx)...)
+	lines = append(lines, h.dumpForwards(ctx)...)
+	lines = append(lines, h.dumpI2CP()...)
+
+	resp := protocol.NewResponse("DUMP").
+		WithAction("REPLY").
+		WithResult(protocol.ResultOK).
+		WithOption("COUNT", strconv.Itoa(len(lines)))
+	for _, line := range lines {
+		// Every line holds a space after the section name, so TEXT is
+		// always quoted.
+		text := protocol.NewResponse("DUMP").WithAction("LINE").WithOption("TEXT", line)
+		resp.WithAdditionalLine(strings.TrimSuffix(text.String(), "\n"))
+	}
+	return resp, nil
+}
+
+// dumpError returns a DUMP REPLY with RESULT=I2P_ERROR.
+func dumpError(msg string) *protocol.Response {
+	return protocol.NewResponse("DUMP").
+		WithAction("REPLY").
+		WithResult(protocol.ResultI2PError).
+		WithMessage(msg)
+}
+
+// dumpRuntime describes the Go runtime.
+func dumpRuntime() []string {
+	var m runtime.MemStats
+	runtime.ReadMemStats(&m)
+	return []string{fmt.Sprintf("runtime goroutines=%d gomaxprocs=%d heap_alloc=%d heap_objects=%d num_gc=%d",
+		runtime.NumGoroutine(), runtime.GOMAXPROCS(0), m.HeapAlloc, m.HeapObjects, m.NumGC)}
+}
+
+// dumpGoroutines groups goroutines by stack from the goroutine profile.
+func dumpGoroutines() []string {
+	var buf bytes.Buffer
+	if err := pprof.Lookup("goroutine").WriteTo(&buf, 1); err != nil {
+		return nil
+	}
+
+	type group struct {
+		count int
+		fn    string
+	}
+	var groups []group
+	scanner := bufio.NewScanner(&buf)
+	for scanner.Scan() {
+		line := scanner.Text()
+		// A group starts with "$count @ $pcs", followed by one
+		// "#\t$pc\t$func+$off\t$file:$line" line per frame.
+		if count, _, ok := strings.Cut(line, " @ "); ok {
+			n, err := strconv.Atoi(count)
+			if err != nil {
+				continue
+			}
+			groups = append(groups, group{count: n})
+			continue
+		}
+		if len(groups) == 0 || !strings.HasPrefix(line, "#\t") {
+			continue
+		}
+		g := &groups[len(groups)-1]
+		fields := strings.Fields(line)
+		if g.fn != "" || len(fields) < 3 {
+			continue
+		}
+		fn, _, _ := strings.Cut(fields[2], "+")
+		if !strings.HasPrefix(fn, "runtime.") {
+			g.fn = fn
+		}
+	}
+
+	sort.SliceStable(groups, func(i, j int) bool { return groups[i].count > groups[j].count })
+	if len(groups) > maxDumpGoroutineGroups {
+		groups = groups[:maxDumpGoroutineGroups]
+	}
+	lines := make([]string, 0, len(groups))
+	for _, g := range groups {
+		fn := g.fn
+		if fn == "" {
+			fn = "runtime"
+		}
+		lines = append(lines, fmt.Sprintf("goroutines count=%d func=%s", g.count, fn))
+	}
+	return lines
+}
+
+// dumpSessions describes the sessions in the client's registry, sorted by
+// ID.
+func dumpSessions(ctx *Context) []string {
+	if ctx.Registry == nil {
+		return nil
+	}
+	ids := ctx.Registry.All()
+	sort.Strings(ids)
+	var lines []string
+	for _, id := range ids {
+		// The session may close between All and Get
+		sess := ctx.Registry.Get(id)
+		if sess == nil {
+			continue
+		}
+		remote := ""
+		if conn := sess.ControlConn(); conn != nil && conn.RemoteAddr() != nil {
+			remote = conn.RemoteAddr().String()
+		}
+		lines = append(lines, fmt.Sprintf("session id=%s style=%s status=%s user=%q label=%q remote=%s",
+			id, sess.Style(), sess.Status(), session.User(sess), session.Label(sess), remote))
+	}
+	return lines
+}
+
+// dumpForwards describes the forwards of sessions in the client's
+// registry and the forwarding counters.
+func (h *DumpHandler) dumpForwards(ctx *Context) []string {
+	if h.forwarder == nil {
+		return nil
+	}
+	var lines []string
+	for _, fwd := range h.forwarder.Forwards() {
+		if ctx.Registry == nil || ctx.Registry.Get(fwd.SessionID) == nil {
+			continue
+		}
+		lines = append(lines, fmt.Sprintf("forward id=%s target=%s ssl=%t",
+			fwd.SessionID, fwd.Target(), fwd.SSL))
+	}
+	stats := h.forwarder.Stats().Snapshot()
+	lines = append(lines, fmt.Sprintf("forward forwarded=%d dial_errors=%d failures=%d",
+		stats.Forwarded, stats.DialErrors, stats.Failures))
+	return lines
+}
+
+// dumpI2CP describes the router connection.
+func (h *DumpHandler) dumpI2CP() []string {
+	if h.i2cpProvider == nil {
+		return []string{"i2cp connected=false provider=none"}
+	}
+	line := fmt.Sprintf("i2cp connected=%t", h.i2cpProvider.IsConnected())
+	if rp, ok := h.i2cpProvider.(session.RouterInfoProvider); ok {
+		if info, ok := rp.RouterInfo(); ok {
+			line += " router_version=" + info.Version
+		}
+	}
+	if hp, ok := h.i2cpProvider.(session.I2CPHealthProvider); ok {
+		health := hp.I2CPHealth()
+		line += fmt.Sprintf(" round_trip=%s pending=%d oldest_pending=%s unresponsive=%t disconnects=%d",
+			health.RoundTrip, health.Pending, health.OldestPending, health.Unresponsive, health.Disconnects)
+	}
+	return []string{line}
+}
+
+// RegisterDumpHandler registers the DUMP handler with a router.
+func RegisterDumpHandler(router *Router, h *DumpHandler) {
+	router.Register("DUMP", h)
+}
@@ -0,0 +1,94 @@
+package handler
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/go-i2p/go-sam-bridge/lib/protocol"
+	"github.com/go-i2p/go-sam-bridge/lib/session"
+)
+
+func TestDumpHandler_RequiresAuth(t *testing.T) {
+	tests := []struct {
+		name string
+		ctx  *Context
+	}{
+		{"before handshake", &Context{User: "alice"}},
+		{"without user", &Context{HandshakeComplete: true, Authenticated: true}},
+	}
+
+	h := NewDumpHandler()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := h.Handle(tt.ctx, &protocol.Command{Verb: "DUMP"})
+			if err != nil {
+				t.Fatalf("Handle() error = %v", err)
+			}
+			if got := resp.FullString(); !strings.HasPrefix(got, "DUMP REPLY RESULT=I2P_ERROR") || resp.HasAdditionalLines() {
+				t.Errorf("Handle() = %q, want a lone I2P_ERROR reply", got)
+			}
+		})
+	}
+}
+
+func TestDumpHandler_Handle(t *testing.T) {
+	cfg := session.DefaultSessionConfig()
+	cfg.User = "alice"
+	cfg.Label = "my app"
+	registry := newMockRegistry()
+	sess := session.NewBaseSession("web", session.StyleStream, nil, nil, cfg)
+	registry.sessions["web"] = sess
+
+	forwarder := NewStreamingForwarder()
+	forwarder.RegisterManager("web", &mockStreamManager{})
+	if _, err := forwarder.Forward(sess, "127.0.0.1", 8080, false); err != nil {
+		t.Fatalf("Forward() error = %v", err)
+	}
+	defer forwarder.UnregisterManager("web")
+	// Forwards of sessions the client cannot see are left out.
+	other := &streamMockSession{id: "other", style: session.StyleStream}
+	forwarder.RegisterManager("other", &mockStreamManager{})
+	if _, err := forwarder.Forward(other, "127.0.0.1", 9090, false); err != nil {
+		t.Fatalf("Forward() error = %v", err)
+	}
+	defer forwarder.UnregisterManager("other")
+
+	h := NewDumpHandler()
+	h.SetForwarder(forwarder)
+	h.SetI2CPProvider(disconnectedProvider{})
+
+	ctx := &Context{HandshakeComplete: true, Authenticated: true, User: "alice", Registry: registry}
+	resp, err := h.Handle(ctx, &protocol.Command{Verb: "DUMP"})
+	if err != nil {
+		t.Fatalf("Handle() error = %v", err)
+	}
+
+	first := resp.String()
+	if !strings.HasPrefix(first, "DUMP REPLY RESULT=OK COUNT="+strconv.Itoa(len(resp.AdditionalLines))+"\n") {
+		t.Errorf("Handle() = %q, want RESULT=OK with the line count", first)
+	}
+	for _, line := range resp.AdditionalLines {
+		if !strings.HasPrefix(line, `DUMP LINE TEXT="`) || !strings.HasSuffix(line, `"`) {
+			t.Errorf("line %q is not a quoted DUMP LINE", line)
+		}
+	}
+
+	all := resp.FullString()
+	for _, want := range []string{
+		`TEXT="runtime goroutines=`,
+		`TEXT="goroutines count=`,
+		`TEXT="session id=web style=STREAM status=`,
+		`user=\"alice\" label=\"my app\"`,
+		`TEXT="forward id=web target=127.0.0.1:8080 ssl=false"`,
+		`TEXT="forward forwarded=0 dial_errors=0 failures=0"`,
+		`TEXT="i2cp connected=false"`,
+	} {
+		if !strings.Contains(all, want) {
+			t.Errorf("Handle() missing %q in %q", want, all)
+		}
+	}
+	if strings.Contains(all, "id=other") {
+		t.Errorf("Handle() lists another client's forward: %q", all)
+	}
+}
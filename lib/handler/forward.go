@@ -0,0 +1,112 @@
+package handler
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-i2p/go-sam-bridge/lib/session"
+)
+
+// SessionError is a failure in background work done for a session, such
+// as forwarding one of its inbound streams, passed to an error handler
+// so that it can be logged with the session's details.
+type SessionError struct {
+	Session session.Session
+	Err     error
+}
+
+// Error returns the underlying error prefixed with the session ID.
+func (e *SessionError) Error() string {
+	return "session " + e.Session.ID() + ": " + e.Err.Error()
+}
+
+// Unwrap returns the underlying error.
+func (e *SessionError) Unwrap() error {
+	return e.Err
+}
+
+// ForwardRetry controls how STREAM FORWARD retries connecting to its local
+// target, so that inbound streams survive a brief restart of the local
+// service. The wait between attempts starts at Backoff and doubles after
+// each failure, up to MaxBackoff.
+type ForwardRetry struct {
+	// Attempts is the number of connection attempts per inbound stream,
+	// including the first. Values below 1 mean a single attempt.
+	Attempts int
+
+	// Backoff is the wait before the second attempt.
+	Backoff time.Duration
+
+	// MaxBackoff caps the wait between attempts. Zero means no cap.
+	MaxBackoff time.Duration
+}
+
+// DefaultForwardRetry tries the local target five times over about four
+// seconds before dropping the inbound stream.
+var DefaultForwardRetry = ForwardRetry{
+	Attempts:   5,
+	Backoff:    250 * time.Millisecond,
+	MaxBackoff: 2 * time.Second,
+}
+
+// IsZero reports whether r is the zero value, which SetForwardRetry
+// treats as DefaultForwardRetry.
+func (r ForwardRetry) IsZero() bool {
+	return r == ForwardRetry{}
+}
+
+// do calls dial until it succeeds, the attempts are used up or ctx is
+// done, returning the last error.
+func (r ForwardRetry) do(ctx context.Context, dial func() error) error {
+	backoff := r.Backoff
+	for attempt := 1; ; attempt++ {
+		err := dial()
+		if err == nil || attempt >= r.Attempts {
+			return err
+		}
+
+		timer := time.NewTimer(backoff)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+		if backoff *= 2; r.MaxBackoff > 0 && backoff > r.MaxBackoff {
+			backoff = r.MaxBackoff
+		}
+	}
+}
+
+// ForwardStats counts the outcomes of STREAM FORWARD connections to local
+// targets. All methods are safe for concurrent use; a single ForwardStats
+// may be shared by several forwarders.
+type ForwardStats struct {
+	forwarded  atomic.Uint64
+	dialErrors atomic.Uint64
+	failures   atomic.Uint64
+}
+
+// ForwardStatsSnapshot is a point-in-time copy of ForwardStats.
+type ForwardStatsSnapshot struct {
+	// Forwarded counts inbound streams connected to their local target.
+	Forwarded uint64 `json:"forwarded"`
+
+	// DialErrors counts failed connection attempts, including those that
+	// were retried.
+	DialErrors uint64 `json:"dial_errors"`
+
+	// Failures counts inbound streams dropped because every attempt to
+	// reach the local target failed.
+	Failures uint64 `json:"failures"`
+}
+
+// Snapshot returns the current counter values.
+func (s *ForwardStats) Snapshot() ForwardStatsSnapshot {
+	return ForwardStatsSnapshot{
+		Forwarded:  s.forwarded.Load(),
+		DialErrors: s.dialErrors.Load(),
+		Failures:   s.failures.Load(),
+	}
+}
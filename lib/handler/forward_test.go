@@ -0,0 +1,58 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestForwardRetry_Do(t *testing.T) {
+	errDown := errors.New("connection refused")
+
+	tests := []struct {
+		name      string
+		retry     ForwardRetry
+		failures  int
+		wantCalls int
+		wantErr   bool
+	}{
+		{"first attempt succeeds", ForwardRetry{Attempts: 3}, 0, 1, false},
+		{"succeeds after retries", ForwardRetry{Attempts: 3, Backoff: time.Millisecond}, 2, 3, false},
+		{"attempts used up", ForwardRetry{Attempts: 3, Backoff: time.Millisecond}, 5, 3, true},
+		{"zero attempts tries once", ForwardRetry{}, 5, 1, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			err := tt.retry.do(context.Background(), func() error {
+				calls++
+				if calls <= tt.failures {
+					return errDown
+				}
+				return nil
+			})
+			if calls != tt.wantCalls {
+				t.Errorf("dial called %d times, want %d", calls, tt.wantCalls)
+			}
+			if (err != nil) != tt.wantErr {
+				t.Errorf("do() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestForwardRetry_DoCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	calls := 0
+	retry := ForwardRetry{Attempts: 5, Backoff: time.Hour}
+	err := retry.do(ctx, func() error {
+		calls++
+		return errors.New("connection refused")
+	})
+	if err == nil || calls != 1 {
+		t.Errorf("do() = %v after %d calls, want the first error after 1 call", err, calls)
+	}
+}
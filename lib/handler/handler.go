@@ -5,11 +5,11 @@ package handler
 
 import (
 	"context"
-	"io"
 	"net"
 
 	"github.com/go-i2p/go-sam-bridge/lib/protocol"
 	"github.com/go-i2p/go-sam-bridge/lib/session"
+	"github.com/go-i2p/go-sam-bridge/lib/util"
 )
 
 // Handler processes a SAM command and returns a response.
@@ -57,11 +57,24 @@ type Context struct {
 	// Always true if authentication is disabled on the bridge.
 	Authenticated bool
 
+	// User is the SAM username the client authenticated as, empty if it
+	// did not authenticate with USER and PASSWORD.
+	User string
+
 	// HandshakeComplete indicates if HELLO has been received.
 	HandshakeComplete bool
 
 	// Ctx is the request context for cancellation and timeouts.
 	Ctx context.Context
+
+	// Writer buffers messages to Conn, such as DATAGRAM RECEIVED. If nil,
+	// receivers write through a ResponseWriter of their own.
+	Writer *protocol.ResponseWriter
+
+	// CopyBufferSize is the size of the pooled buffers ForwardData copies
+	// stream data through, per direction. Zero means
+	// util.DefaultCopyBufferSize.
+	CopyBufferSize int
 }
 
 // NewContext creates a new handler context with the given connection.
@@ -130,7 +143,8 @@ func (c *Context) StartForwarding() {
 }
 
 // ForwardData performs bidirectional data forwarding between the control
-// socket (Conn) and the I2P stream connection (i2pConn).
+// socket (Conn) and the I2P stream connection (i2pConn), through buffers
+// of CopyBufferSize bytes taken from a shared pool.
 // This function runs until either connection is closed or encounters an error.
 func (c *Context) ForwardData(i2pConn net.Conn) error {
 	if c.Conn == nil {
@@ -142,13 +156,13 @@ func (c *Context) ForwardData(i2pConn net.Conn) error {
 
 	// Forward: control socket -> I2P stream
 	go func() {
-		_, err := io.Copy(i2pConn, c.Conn)
+		_, err := util.Copy(i2pConn, c.Conn, c.CopyBufferSize)
 		done <- err
 	}()
 
 	// Forward: I2P stream -> control socket
 	go func() {
-		_, err := io.Copy(c.Conn, i2pConn)
+		_, err := util.Copy(c.Conn, i2pConn, c.CopyBufferSize)
 		done <- err
 	}()
 
@@ -188,23 +202,44 @@ func (c *Context) StartDatagramReceiver() {
 	go c.receiveDatagrams(dgSess.Receive())
 }
 
+// StartSendErrorReplies reports each queued datagram the session drops,
+// because it expired or its retries ran out, with a DATAGRAM STATUS or
+// RAW STATUS error reply on the control socket. It does nothing unless
+// the session was created with sam.sendErrors=true.
+func (c *Context) StartSendErrorReplies() {
+	sess, ok := c.Session.(interface {
+		Config() *session.SessionConfig
+		SetSendErrorHandler(fn func(dest string, err error))
+	})
+	if !ok || sess.Config() == nil || !sess.Config().SendErrors {
+		return
+	}
+
+	reply := datagramError
+	if c.Session.Style() == session.StyleRaw {
+		reply = rawError
+	}
+	w := c.responseWriter()
+	sess.SetSendErrorHandler(func(dest string, err error) {
+		if w.WriteResponse(reply("send failed: "+err.Error())) == nil {
+			_ = w.Flush()
+		}
+	})
+}
+
 // receiveDatagrams reads datagrams from the channel and writes them to the control socket.
+// Datagrams arriving in a burst are flushed together once the channel is drained.
 func (c *Context) receiveDatagrams(ch <-chan session.ReceivedDatagram) {
+	w := c.responseWriter()
 	for dg := range ch {
-		// Format the DATAGRAM RECEIVED header
-		header := FormatDatagramReceived(dg, c.Version)
-
-		// Write header line followed by newline
-		_, err := c.Conn.Write([]byte(header + "\n"))
-		if err != nil {
+		if err := w.WriteMessage(FormatDatagramReceived(dg, c.Version), dg.Data); err != nil {
 			// Connection closed, stop receiving
 			return
 		}
-
-		// Write the data payload
-		_, err = c.Conn.Write(dg.Data)
-		if err != nil {
-			return
+		if len(ch) == 0 {
+			if err := w.Flush(); err != nil {
+				return
+			}
 		}
 	}
 }
@@ -231,22 +266,27 @@ func (c *Context) StartRawReceiver() {
 }
 
 // receiveRawDatagrams reads raw datagrams from the channel and writes them to the control socket.
+// Datagrams arriving in a burst are flushed together once the channel is drained.
 func (c *Context) receiveRawDatagrams(ch <-chan session.ReceivedRawDatagram) {
+	w := c.responseWriter()
 	for dg := range ch {
-		// Format the RAW RECEIVED header
-		header := FormatRawReceived(dg, c.Version)
-
-		// Write header line followed by newline
-		_, err := c.Conn.Write([]byte(header + "\n"))
-		if err != nil {
+		if err := w.WriteMessage(FormatRawReceived(dg, c.Version), dg.Data); err != nil {
 			// Connection closed, stop receiving
 			return
 		}
-
-		// Write the data payload
-		_, err = c.Conn.Write(dg.Data)
-		if err != nil {
-			return
+		if len(ch) == 0 {
+			if err := w.Flush(); err != nil {
+				return
+			}
 		}
 	}
 }
+
+// responseWriter returns Writer, or a new ResponseWriter for Conn if it
+// is nil.
+func (c *Context) responseWriter() *protocol.ResponseWriter {
+	if c.Writer != nil {
+		return c.Writer
+	}
+	return protocol.NewResponseWriter(c.Conn, 0)
+}
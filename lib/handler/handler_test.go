@@ -2,6 +2,7 @@ package handler
 
 import (
 	"context"
+	"io"
 	"net"
 	"testing"
 	"time"
@@ -158,3 +159,34 @@ func TestHandlerFunc(t *testing.T) {
 		t.Error("HandlerFunc was not called")
 	}
 }
+
+func TestContext_ForwardData(t *testing.T) {
+	client, control := net.Pipe()
+	remote, i2pConn := net.Pipe()
+	defer client.Close()
+	defer remote.Close()
+
+	ctx := NewContext(control, nil)
+	ctx.CopyBufferSize = 16
+	done := make(chan error, 1)
+	go func() { done <- ctx.ForwardData(i2pConn) }()
+
+	msg := []byte("more than sixteen bytes of stream data")
+	go client.Write(msg)
+	buf := make([]byte, len(msg))
+	if _, err := io.ReadFull(remote, buf); err != nil || string(buf) != string(msg) {
+		t.Fatalf("remote read %q, %v; want %q", buf, err, msg)
+	}
+
+	go remote.Write(msg)
+	if _, err := io.ReadFull(client, buf); err != nil || string(buf) != string(msg) {
+		t.Fatalf("client read %q, %v; want %q", buf, err, msg)
+	}
+
+	client.Close()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("ForwardData did not return after the client closed")
+	}
+}
@@ -152,10 +152,7 @@ func (h *HelloHandler) authenticate(cmd *protocol.Command) bool {
 
 // helloOK returns a successful HELLO REPLY.
 func helloOK(version string) *protocol.Response {
-	return protocol.NewResponse(protocol.VerbHello).
-		WithAction(protocol.ActionReply).
-		WithResult(protocol.ResultOK).
-		WithVersion(version)
+	return protocol.HelloReplyOK(version)
 }
 
 // helloNoVersion returns a NOVERSION response.
@@ -103,6 +103,9 @@ func (h *NamingHandler) SetResolveTimeout(timeout time.Duration) {
 // Per SAMv3.md, NAMING LOOKUP resolves names to destinations.
 //
 // Request: NAMING LOOKUP NAME=$name [OPTIONS=true]
+//
+//	NAMING LOOKUP NAME=$b33 [SECRET=$secret] [AUTH_TYPE=DH|PSK AUTH_KEY=$key]
+//
 // Response: NAMING REPLY RESULT=OK NAME=$name VALUE=$destination [OPTION:key=value...]
 //
 //	NAMING REPLY RESULT=KEY_NOT_FOUND NAME=$name
@@ -132,6 +135,11 @@ func (h *NamingHandler) Handle(ctx *Context, cmd *protocol.Command) (*protocol.R
 		return h.handleOptionsLookup(name)
 	}
 
+	// Blinded (.b33) addresses may carry credentials for the lookup
+	if destination.IsB33Address(name) {
+		return h.handleB33Lookup(name, cmd)
+	}
+
 	// Standard name resolution without options
 	dest, err := h.resolveName(name)
 	if err != nil {
@@ -0,0 +1,79 @@
+package handler
+
+import (
+	"context"
+	"sync"
+)
+
+// DefaultBatchParallelism is the number of lookups ResolveBatch runs at
+// once when no limit is given.
+const DefaultBatchParallelism = 8
+
+// LookupResult is the outcome of resolving one name of a batch.
+type LookupResult struct {
+	// Name is the name that was looked up.
+	Name string
+
+	// Destination is the Base64-encoded destination, empty if Err is set.
+	Destination string
+
+	// Err is the lookup error, such as a not-found error from the resolver
+	// or ctx.Err() for lookups that never started.
+	Err error
+}
+
+// BatchResolver is implemented by resolvers that can look up many names
+// at once, such as for a batch NAMING LOOKUP or an address book refresh.
+type BatchResolver interface {
+	// ResolveBatch looks up every name and returns one result per name,
+	// in the same order.
+	ResolveBatch(ctx context.Context, names []string) []LookupResult
+}
+
+// ResolveBatch looks up names with resolver, running at most parallelism
+// lookups at once, or DefaultBatchParallelism if parallelism <= 0.
+// Results are in the order of names; a name listed more than once is
+// looked up once. When ctx is done, lookups not yet started fail with
+// ctx.Err().
+func ResolveBatch(ctx context.Context, resolver DestinationResolver, names []string, parallelism int) []LookupResult {
+	if parallelism <= 0 {
+		parallelism = DefaultBatchParallelism
+	}
+	results := make([]LookupResult, len(names))
+	first := make(map[string]int, len(names))
+	sem := make(chan struct{}, parallelism)
+	var wg sync.WaitGroup
+
+	for i, name := range names {
+		results[i].Name = name
+		if _, dup := first[name]; dup {
+			continue
+		}
+		first[name] = i
+
+		if err := ctx.Err(); err != nil {
+			results[i].Err = err
+			continue
+		}
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			results[i].Err = ctx.Err()
+			continue
+		}
+		wg.Add(1)
+		go func(r *LookupResult) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			r.Destination, r.Err = resolver.Resolve(ctx, r.Name)
+		}(&results[i])
+	}
+	wg.Wait()
+
+	for i, name := range names {
+		if j := first[name]; j != i {
+			results[i] = results[j]
+		}
+	}
+	return results
+}
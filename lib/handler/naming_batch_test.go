@@ -0,0 +1,84 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// countingResolver records how many lookups run at once.
+type countingResolver struct {
+	mu      sync.Mutex
+	calls   map[string]int
+	running atomic.Int32
+	peak    atomic.Int32
+}
+
+func (r *countingResolver) Resolve(ctx context.Context, name string) (string, error) {
+	n := r.running.Add(1)
+	defer r.running.Add(-1)
+	for {
+		peak := r.peak.Load()
+		if n <= peak || r.peak.CompareAndSwap(peak, n) {
+			break
+		}
+	}
+
+	r.mu.Lock()
+	r.calls[name]++
+	r.mu.Unlock()
+
+	time.Sleep(5 * time.Millisecond)
+	if name == "missing.i2p" {
+		return "", errors.New("not found")
+	}
+	return "dest-" + name, nil
+}
+
+func TestResolveBatch(t *testing.T) {
+	resolver := &countingResolver{calls: make(map[string]int)}
+	names := []string{"missing.i2p", "dup.i2p"}
+	for i := range 10 {
+		names = append(names, fmt.Sprintf("host%d.i2p", i))
+	}
+	names = append(names, "dup.i2p")
+
+	results := ResolveBatch(context.Background(), resolver, names, 3)
+	if len(results) != len(names) {
+		t.Fatalf("got %d results, want %d", len(results), len(names))
+	}
+	for i, r := range results {
+		if r.Name != names[i] {
+			t.Errorf("results[%d].Name = %q, want %q", i, r.Name, names[i])
+		}
+		if r.Name == "missing.i2p" {
+			if r.Err == nil {
+				t.Error("missing.i2p resolved, want an error")
+			}
+		} else if r.Err != nil || r.Destination != "dest-"+r.Name {
+			t.Errorf("results[%d] = %+v, want dest-%s", i, r, r.Name)
+		}
+	}
+	if peak := resolver.peak.Load(); peak > 3 {
+		t.Errorf("%d lookups ran at once, want at most 3", peak)
+	}
+	if n := resolver.calls["dup.i2p"]; n != 1 {
+		t.Errorf("dup.i2p looked up %d times, want 1", n)
+	}
+}
+
+func TestResolveBatch_Canceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	results := ResolveBatch(ctx, &mockDestinationResolver{}, []string{"a.i2p", "b.i2p"}, 0)
+	for _, r := range results {
+		if !errors.Is(r.Err, context.Canceled) {
+			t.Errorf("%s: err = %v, want context.Canceled", r.Name, r.Err)
+		}
+	}
+}
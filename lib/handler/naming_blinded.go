@@ -0,0 +1,118 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/go-i2p/go-sam-bridge/lib/destination"
+	"github.com/go-i2p/go-sam-bridge/lib/protocol"
+	"github.com/go-i2p/go-sam-bridge/lib/session"
+)
+
+// BlindedCredentials are what a client needs to look up an encrypted
+// lease set through its .b33 address, as far as the address requires.
+type BlindedCredentials struct {
+	// Secret is the lookup secret (the server's i2cp.leaseSetSecret).
+	Secret string
+
+	// AuthType is session.LeaseSetAuthDH or session.LeaseSetAuthPSK when
+	// Key is set, and session.LeaseSetAuthNone otherwise.
+	AuthType int
+
+	// Key is the client's X25519 private key (DH) or the pre-shared key
+	// (PSK).
+	Key []byte
+}
+
+// empty reports whether no credentials were given.
+func (c BlindedCredentials) empty() bool {
+	return c.Secret == "" && len(c.Key) == 0
+}
+
+// BlindedResolver is implemented by DestinationResolvers that can hand
+// credentials to the router before looking up a .b33 address. Resolvers
+// without it can still look up .b33 addresses that need no credentials.
+type BlindedResolver interface {
+	// ResolveBlinded looks up the destination behind addr, which was
+	// parsed from name, using creds to decrypt its lease set.
+	ResolveBlinded(ctx context.Context, addr *destination.BlindedAddress, name string, creds BlindedCredentials) (string, error)
+}
+
+// handleB33Lookup resolves a .b33 address. Credentials come from the
+// bridge extension options SECRET, AUTH_TYPE (DH or PSK, default DH) and
+// AUTH_KEY (Base64, 32 bytes). A malformed address, or one whose flags
+// demand credentials the command lacks, is reported as INVALID_KEY.
+func (h *NamingHandler) handleB33Lookup(name string, cmd *protocol.Command) (*protocol.Response, error) {
+	addr, err := destination.ParseB33(name)
+	if err != nil {
+		return namingInvalidKey(name, err.Error()), nil
+	}
+	creds, err := parseBlindedCredentials(cmd, addr)
+	if err != nil {
+		return namingInvalidKey(name, err.Error()), nil
+	}
+
+	dest, err := h.resolveB33(name, addr, creds)
+	if err != nil {
+		return namingKeyNotFound(name), nil
+	}
+	return namingOK(name, dest), nil
+}
+
+// resolveB33 looks up a .b33 address with the configured resolver.
+func (h *NamingHandler) resolveB33(name string, addr *destination.BlindedAddress, creds BlindedCredentials) (string, error) {
+	if h.resolver == nil {
+		return "", &namingErr{msg: "b33 lookup not available: no resolver configured"}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), h.resolveTimeout)
+	defer cancel()
+
+	var dest string
+	var err error
+	if br, ok := h.resolver.(BlindedResolver); ok {
+		dest, err = br.ResolveBlinded(ctx, addr, name, creds)
+	} else if !creds.empty() {
+		return "", &namingErr{msg: "b33 lookup with credentials not supported by resolver"}
+	} else {
+		dest, err = h.resolver.Resolve(ctx, name)
+	}
+	if err != nil {
+		return "", &namingErr{msg: "b33 lookup failed: " + err.Error()}
+	}
+	if dest == "" {
+		return "", &namingErr{msg: "b33 address not found"}
+	}
+	return dest, nil
+}
+
+// parseBlindedCredentials reads the SECRET, AUTH_TYPE and AUTH_KEY
+// options and checks them against the requirements encoded in addr.
+func parseBlindedCredentials(cmd *protocol.Command, addr *destination.BlindedAddress) (BlindedCredentials, error) {
+	creds := BlindedCredentials{Secret: cmd.Get("SECRET")}
+
+	if v := cmd.Get("AUTH_KEY"); v != "" {
+		key, err := destination.Base64Decode(v)
+		if err != nil || len(key) != session.LeaseSetClientKeyLength {
+			return creds, fmt.Errorf("AUTH_KEY must be %d Base64-encoded bytes", session.LeaseSetClientKeyLength)
+		}
+		creds.Key = key
+		switch strings.ToUpper(cmd.Get("AUTH_TYPE")) {
+		case "", "DH":
+			creds.AuthType = session.LeaseSetAuthDH
+		case "PSK":
+			creds.AuthType = session.LeaseSetAuthPSK
+		default:
+			return creds, fmt.Errorf("invalid AUTH_TYPE %q: must be DH or PSK", cmd.Get("AUTH_TYPE"))
+		}
+	}
+
+	if addr.SecretRequired && creds.Secret == "" {
+		return creds, fmt.Errorf("address requires SECRET")
+	}
+	if addr.PerClientAuth && creds.Key == nil {
+		return creds, fmt.Errorf("address requires AUTH_KEY")
+	}
+	return creds, nil
+}
@@ -0,0 +1,143 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/go-i2p/go-sam-bridge/lib/destination"
+	"github.com/go-i2p/go-sam-bridge/lib/protocol"
+	"github.com/go-i2p/go-sam-bridge/lib/session"
+)
+
+// mockBlindedResolver records the credentials of the last .b33 lookup.
+type mockBlindedResolver struct {
+	mockDestinationResolver
+	name  string
+	creds BlindedCredentials
+}
+
+func (m *mockBlindedResolver) ResolveBlinded(ctx context.Context, addr *destination.BlindedAddress, name string, creds BlindedCredentials) (string, error) {
+	m.name = name
+	m.creds = creds
+	return m.Resolve(ctx, name)
+}
+
+func TestNamingHandler_B33Lookup(t *testing.T) {
+	destB64 := strings.Repeat("B", 516)
+	key := bytes.Repeat([]byte{7}, session.LeaseSetClientKeyLength)
+	b33 := func(secret, perClient bool) string {
+		return (&destination.BlindedAddress{
+			SigType:        destination.SigTypeEd25519,
+			BlindedSigType: destination.SigTypeRedDSA_SHA512_Ed25519,
+			PublicKey:      bytes.Repeat([]byte{1}, 32),
+			SecretRequired: secret,
+			PerClientAuth:  perClient,
+		}).String()
+	}
+
+	tests := []struct {
+		name       string
+		address    string
+		options    map[string]string
+		plain      bool
+		wantResult string
+		wantCreds  BlindedCredentials
+	}{
+		{
+			name:       "no credentials needed",
+			address:    b33(false, false),
+			wantResult: protocol.ResultOK,
+		},
+		{
+			name:       "plain resolver without credentials",
+			address:    b33(false, false),
+			plain:      true,
+			wantResult: protocol.ResultOK,
+		},
+		{
+			name:       "secret and PSK key",
+			address:    b33(true, true),
+			options:    map[string]string{"SECRET": "hunter2", "AUTH_TYPE": "psk", "AUTH_KEY": destination.Base64Encode(key)},
+			wantResult: protocol.ResultOK,
+			wantCreds:  BlindedCredentials{Secret: "hunter2", AuthType: session.LeaseSetAuthPSK, Key: key},
+		},
+		{
+			name:       "DH is the default auth type",
+			address:    b33(false, true),
+			options:    map[string]string{"AUTH_KEY": destination.Base64Encode(key)},
+			wantResult: protocol.ResultOK,
+			wantCreds:  BlindedCredentials{AuthType: session.LeaseSetAuthDH, Key: key},
+		},
+		{
+			name:       "missing secret",
+			address:    b33(true, false),
+			wantResult: protocol.ResultInvalidKey,
+		},
+		{
+			name:       "missing client key",
+			address:    b33(false, true),
+			options:    map[string]string{"SECRET": "hunter2"},
+			wantResult: protocol.ResultInvalidKey,
+		},
+		{
+			name:       "short client key",
+			address:    b33(false, true),
+			options:    map[string]string{"AUTH_KEY": destination.Base64Encode(key[:16])},
+			wantResult: protocol.ResultInvalidKey,
+		},
+		{
+			name:       "malformed address",
+			address:    strings.Repeat("a", 60) + ".b32.i2p",
+			wantResult: protocol.ResultInvalidKey,
+		},
+		{
+			name:       "plain resolver with credentials",
+			address:    b33(true, false),
+			options:    map[string]string{"SECRET": "hunter2"},
+			plain:      true,
+			wantResult: protocol.ResultKeyNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			plain := mockDestinationResolver{destinations: map[string]string{tt.address: destB64}}
+			blinded := &mockBlindedResolver{mockDestinationResolver: plain}
+			handler := NewNamingHandler(&mockManager{})
+			if tt.plain {
+				handler.SetDestinationResolver(&plain)
+			} else {
+				handler.SetDestinationResolver(blinded)
+			}
+
+			options := map[string]string{"NAME": tt.address}
+			for k, v := range tt.options {
+				options[k] = v
+			}
+			cmd := &protocol.Command{Verb: "NAMING", Action: "LOOKUP", Options: options}
+
+			resp, err := handler.Handle(NewContext(&mockConn{}, nil), cmd)
+			if err != nil {
+				t.Fatalf("Handle() error = %v", err)
+			}
+			if got := resp.String(); !strings.Contains(got, "RESULT="+tt.wantResult) {
+				t.Fatalf("Handle() = %q, want RESULT=%s", got, tt.wantResult)
+			}
+			if tt.wantResult == protocol.ResultOK && !strings.Contains(resp.String(), "VALUE="+destB64) {
+				t.Errorf("Handle() = %q, want VALUE=%s", resp.String(), destB64)
+			}
+			if tt.plain || tt.wantResult != protocol.ResultOK {
+				return
+			}
+			if blinded.name != tt.address {
+				t.Errorf("ResolveBlinded() name = %q, want %q", blinded.name, tt.address)
+			}
+			if blinded.creds.Secret != tt.wantCreds.Secret || blinded.creds.AuthType != tt.wantCreds.AuthType ||
+				!bytes.Equal(blinded.creds.Key, tt.wantCreds.Key) {
+				t.Errorf("ResolveBlinded() creds = %+v, want %+v", blinded.creds, tt.wantCreds)
+			}
+		})
+	}
+}
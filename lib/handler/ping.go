@@ -54,13 +54,7 @@ func extractPingText(raw string) string {
 // buildPongResponse creates a PONG response with optional text.
 // Per SAM 3.2, PONG mirrors the format of PING.
 func buildPongResponse(text string) *protocol.Response {
-	resp := protocol.NewResponse("PONG")
-	if text != "" {
-		// PONG with arbitrary text - append directly as the response
-		// We use a custom format since PONG doesn't use standard KEY=VALUE
-		resp.Options = append(resp.Options, text)
-	}
-	return resp
+	return protocol.Pong(text)
 }
 
 // RegisterPingHandler registers the PING handler with a router.
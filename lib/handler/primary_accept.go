@@ -0,0 +1,167 @@
+package handler
+
+import (
+	"errors"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/go-i2p/go-sam-bridge/lib/session"
+)
+
+// streamingProtocol is the I2CP protocol number of I2P streaming.
+const streamingProtocol = 6
+
+// errPrimaryAccept is returned by Accept on a PRIMARY session's own
+// listener; its streams are accepted through its STREAM subsessions.
+var errPrimaryAccept = errors.New("PRIMARY sessions accept streams through their STREAM subsessions")
+
+// primaryListener accepts the inbound streams of a PRIMARY session and
+// routes each to the STREAM subsession chosen by primary.Route for the
+// port the stream was sent to. It listens on port 0 and on the
+// LISTEN_PORT of each subsession that has asked for a stream. A routed
+// stream waits up to timeout for STREAM ACCEPT on its subsession and is
+// closed if none comes, as are streams no subsession listens for.
+type primaryListener struct {
+	primary session.PrimarySession
+	listen  func(port uint16) (net.Listener, error)
+	timeout time.Duration
+
+	mu        sync.Mutex
+	listeners map[int]net.Listener
+	queues    map[string]chan net.Conn
+	closed    bool
+
+	// done is closed by Close.
+	done chan struct{}
+}
+
+// newPrimaryListener starts routing the streams listen accepts on port 0
+// to the subsessions of primary.
+func newPrimaryListener(primary session.PrimarySession, listen func(port uint16) (net.Listener, error), timeout time.Duration) (*primaryListener, error) {
+	if timeout <= 0 {
+		timeout = DefaultAcceptBacklogTimeout
+	}
+	p := &primaryListener{
+		primary:   primary,
+		listen:    listen,
+		timeout:   timeout,
+		listeners: make(map[int]net.Listener),
+		queues:    make(map[string]chan net.Conn),
+		done:      make(chan struct{}),
+	}
+	if err := p.listenOn(0); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// listenOn starts routing the streams sent to port, unless it already
+// does.
+func (p *primaryListener) listenOn(port int) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.closed {
+		return net.ErrClosed
+	}
+	if _, ok := p.listeners[port]; ok {
+		return nil
+	}
+	l, err := p.listen(uint16(port))
+	if err != nil {
+		return err
+	}
+	p.listeners[port] = l
+	go p.run(l, port)
+	return nil
+}
+
+// run routes the streams l accepts on port until l is closed.
+func (p *primaryListener) run(l net.Listener, port int) {
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		sub := p.primary.Route(streamingProtocol, port)
+		if sub == nil {
+			conn.Close()
+			continue
+		}
+		go p.handOff(p.queue(sub.ID()), conn)
+	}
+}
+
+// handOff passes conn to the next Accept on queue, or closes it after
+// the timeout or once the listener is closed.
+func (p *primaryListener) handOff(queue chan net.Conn, conn net.Conn) {
+	timer := time.NewTimer(p.timeout)
+	defer timer.Stop()
+	select {
+	case queue <- conn:
+	case <-timer.C:
+		conn.Close()
+	case <-p.done:
+		conn.Close()
+	}
+}
+
+// queue returns the hand-off channel of the subsession id.
+func (p *primaryListener) queue(id string) chan net.Conn {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	q, ok := p.queues[id]
+	if !ok {
+		q = make(chan net.Conn)
+		p.queues[id] = q
+	}
+	return q
+}
+
+// acceptFor waits for a stream routed to the subsession sub, listening
+// on its LISTEN_PORT first.
+func (p *primaryListener) acceptFor(sub session.Session) (net.Conn, error) {
+	port := 0
+	if cp, ok := sub.(interface{ Config() *session.SessionConfig }); ok && cp.Config() != nil {
+		port = cp.Config().ListenPort
+	}
+	if err := p.listenOn(port); err != nil {
+		return nil, err
+	}
+	select {
+	case conn := <-p.queue(sub.ID()):
+		return conn, nil
+	case <-p.done:
+		return nil, net.ErrClosed
+	}
+}
+
+// Accept refuses: streams are accepted per subsession with acceptFor.
+func (p *primaryListener) Accept() (net.Conn, error) {
+	return nil, errPrimaryAccept
+}
+
+// Close stops listening on every port and closes waiting streams.
+func (p *primaryListener) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.closed {
+		return nil
+	}
+	p.closed = true
+	close(p.done)
+	for _, l := range p.listeners {
+		l.Close()
+	}
+	return nil
+}
+
+// Addr returns the address of the port 0 listener.
+func (p *primaryListener) Addr() net.Addr {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if l, ok := p.listeners[0]; ok {
+		return l.Addr()
+	}
+	return nil
+}
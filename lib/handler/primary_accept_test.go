@@ -0,0 +1,168 @@
+package handler
+
+import (
+	"io"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-i2p/go-sam-bridge/lib/session"
+)
+
+// portStreamManager is a StreamManager whose listeners are fed by the
+// test, one per port.
+type portStreamManager struct {
+	mockStreamManager
+
+	mu        sync.Mutex
+	listeners map[uint16]*portListener
+}
+
+func (m *portStreamManager) Listen(port uint16, mtu int) (net.Listener, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	l := &portListener{conns: make(chan net.Conn), done: make(chan struct{})}
+	m.listeners[port] = l
+	return l, nil
+}
+
+// send delivers an inbound stream to port and returns the peer's end.
+func (m *portStreamManager) send(t *testing.T, port uint16) net.Conn {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		m.mu.Lock()
+		l := m.listeners[port]
+		m.mu.Unlock()
+		if l != nil {
+			local, remote := net.Pipe()
+			l.conns <- local
+			return remote
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("no listener on port %d", port)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+// portListener accepts the connections sent on conns.
+type portListener struct {
+	conns chan net.Conn
+	done  chan struct{}
+	once  sync.Once
+}
+
+func (l *portListener) Accept() (net.Conn, error) {
+	select {
+	case c := <-l.conns:
+		return c, nil
+	case <-l.done:
+		return nil, net.ErrClosed
+	}
+}
+
+func (l *portListener) Close() error {
+	l.once.Do(func() { close(l.done) })
+	return nil
+}
+
+func (l *portListener) Addr() net.Addr { return &net.TCPAddr{} }
+
+// acceptAsync runs acceptor.Accept(sess) and returns its result channel.
+func acceptAsync(acceptor *StreamingAcceptor, sess session.Session) <-chan net.Conn {
+	ch := make(chan net.Conn, 1)
+	go func() {
+		conn, _, err := acceptor.Accept(sess)
+		if err != nil {
+			conn = nil
+		}
+		ch <- conn
+	}()
+	return ch
+}
+
+func TestStreamingAcceptor_RegisterPrimary(t *testing.T) {
+	primary := session.NewPrimarySession("primary", nil, nil, nil)
+	primary.SetStatus(session.StatusActive)
+	defer primary.Close()
+	web, err := primary.AddSubsession("web", session.StyleStream, session.SubsessionOptions{ListenPort: 80})
+	if err != nil {
+		t.Fatalf("AddSubsession(web) error = %v", err)
+	}
+	fallback, err := primary.AddSubsession("any", session.StyleStream, session.SubsessionOptions{})
+	if err != nil {
+		t.Fatalf("AddSubsession(any) error = %v", err)
+	}
+
+	manager := &portStreamManager{listeners: make(map[uint16]*portListener)}
+	acceptor := NewStreamingAcceptor()
+	if err := acceptor.RegisterPrimary(primary, manager); err != nil {
+		t.Fatalf("RegisterPrimary() error = %v", err)
+	}
+
+	// A stream to port 80 reaches the subsession listening on it
+	accepted := acceptAsync(acceptor, web)
+	remote := manager.send(t, 80)
+	defer remote.Close()
+	select {
+	case conn := <-accepted:
+		if conn == nil {
+			t.Fatal("Accept(web) failed")
+		}
+		conn.Close()
+	case <-time.After(2 * time.Second):
+		t.Fatal("Accept(web) did not return")
+	}
+
+	// Other ports fall through to the default subsession
+	accepted = acceptAsync(acceptor, fallback)
+	remote = manager.send(t, 0)
+	defer remote.Close()
+	select {
+	case conn := <-accepted:
+		if conn == nil {
+			t.Fatal("Accept(any) failed")
+		}
+		conn.Close()
+	case <-time.After(2 * time.Second):
+		t.Fatal("Accept(any) did not return")
+	}
+
+	// Unregistering the PRIMARY session ends pending accepts
+	accepted = acceptAsync(acceptor, web)
+	acceptor.UnregisterManager("primary")
+	select {
+	case conn := <-accepted:
+		if conn != nil {
+			t.Error("Accept(web) after UnregisterManager returned a stream")
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Accept(web) did not return after UnregisterManager")
+	}
+}
+
+func TestStreamingAcceptor_RegisterPrimary_Unrouted(t *testing.T) {
+	primary := session.NewPrimarySession("primary", nil, nil, nil)
+	primary.SetStatus(session.StatusActive)
+	defer primary.Close()
+	if _, err := primary.AddSubsession("raw", session.StyleRaw, session.SubsessionOptions{}); err != nil {
+		t.Fatalf("AddSubsession(raw) error = %v", err)
+	}
+
+	manager := &portStreamManager{listeners: make(map[uint16]*portListener)}
+	acceptor := NewStreamingAcceptor()
+	if err := acceptor.RegisterPrimary(primary, manager); err != nil {
+		t.Fatalf("RegisterPrimary() error = %v", err)
+	}
+	defer acceptor.UnregisterManager("primary")
+
+	// Streams never route to RAW, so no subsession takes it
+	remote := manager.send(t, 0)
+	defer remote.Close()
+	remote.SetReadDeadline(time.Now().Add(2 * time.Second))
+	if _, err := remote.Read(make([]byte, 1)); err != io.EOF {
+		t.Errorf("Read() on unrouted stream error = %v, want EOF", err)
+	}
+}
@@ -85,19 +85,19 @@ func (h *RawHandler) handleSend(ctx *Context, cmd *protocol.Command) (*protocol.
 	}
 
 	// Parse port options (SAM 3.2+)
-	fromPort, toPort, resp := h.parsePortOptions(cmd)
+	fromPort, toPort, resp := h.parsePortOptions(cmd, ctx.Version)
 	if resp != nil {
 		return resp, nil
 	}
 
 	// Parse protocol option
-	protocolNum, resp := h.parseProtocolOption(cmd, rawSess.Protocol())
+	protocolNum, resp := h.parseProtocolOption(cmd, rawSess.Protocol(), ctx.Version)
 	if resp != nil {
 		return resp, nil
 	}
 
 	// Parse SAM 3.3 options
-	sam33Opts, resp := h.parseSAM33Options(cmd)
+	sam33Opts, resp := h.parseSAM33Options(cmd, ctx.Version)
 	if resp != nil {
 		return resp, nil
 	}
@@ -168,10 +168,15 @@ func (h *RawHandler) parseRequiredParams(cmd *protocol.Command) (string, int, *p
 }
 
 // parsePortOptions extracts FROM_PORT and TO_PORT from the command (SAM 3.2+).
-func (h *RawHandler) parsePortOptions(cmd *protocol.Command) (int, int, *protocol.Response) {
+// They are ignored on connections that negotiated an earlier version.
+func (h *RawHandler) parsePortOptions(cmd *protocol.Command, version string) (int, int, *protocol.Response) {
 	var fromPort, toPort int
 	var err error
 
+	if !protocol.VersionSupportsPortInfo(version) {
+		return 0, 0, nil
+	}
+
 	if fromPortStr := cmd.Get("FROM_PORT"); fromPortStr != "" {
 		fromPort, err = parsePort(fromPortStr, "FROM_PORT")
 		if err != nil {
@@ -189,9 +194,10 @@ func (h *RawHandler) parsePortOptions(cmd *protocol.Command) (int, int, *protoco
 }
 
 // parseProtocolOption extracts PROTOCOL from the command (SAM 3.2+).
-func (h *RawHandler) parseProtocolOption(cmd *protocol.Command, defaultProtocol int) (int, *protocol.Response) {
+// Connections that negotiated an earlier version use defaultProtocol.
+func (h *RawHandler) parseProtocolOption(cmd *protocol.Command, defaultProtocol int, version string) (int, *protocol.Response) {
 	protoStr := cmd.Get("PROTOCOL")
-	if protoStr == "" {
+	if protoStr == "" || !protocol.VersionSupportsPortInfo(version) {
 		return defaultProtocol, nil
 	}
 	protocolNum, err := parseProtocol(protoStr)
@@ -211,12 +217,17 @@ type rawSAM33Options struct {
 }
 
 // parseSAM33Options extracts SAM 3.3 specific options from the command.
-func (h *RawHandler) parseSAM33Options(cmd *protocol.Command) (*rawSAM33Options, *protocol.Response) {
+// They are ignored on connections that negotiated an earlier version.
+func (h *RawHandler) parseSAM33Options(cmd *protocol.Command, version string) (*rawSAM33Options, *protocol.Response) {
 	opts := &rawSAM33Options{
 		SendLeaseset: true, // Default per SAMv3.md
 	}
 	var err error
 
+	if !protocol.VersionSupportsSendOptions(version) {
+		return opts, nil
+	}
+
 	if sendTagsStr := cmd.Get("SEND_TAGS"); sendTagsStr != "" {
 		opts.SendTags, err = parseRawSAM33Option(sendTagsStr, "SEND_TAGS", 0, 15)
 		if err != nil {
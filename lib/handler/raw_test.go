@@ -783,6 +783,53 @@ func TestRawHandler_HandleSend_SAM33OptionsDefaults(t *testing.T) {
 	}
 }
 
+func TestRawHandler_HandleSend_VersionGatedOptions(t *testing.T) {
+	tests := []struct {
+		version      string
+		wantProtocol int
+		wantSendTags int
+	}{
+		{"3.1", 18, 0},
+		{"3.2", 42, 0},
+		{"3.3", 42, 10},
+	}
+
+	for _, tt := range tests {
+		t.Run("SAM "+tt.version, func(t *testing.T) {
+			handler := NewRawHandler()
+			mockSess := newMockRawSession("test-raw")
+			mockSess.protocol = 18
+
+			cmd := &protocol.Command{
+				Verb:   protocol.VerbRaw,
+				Action: protocol.ActionSend,
+				Options: map[string]string{
+					"DESTINATION": "test.i2p",
+					"SIZE":        "5",
+					"PROTOCOL":    "42",
+					"SEND_TAGS":   "10",
+				},
+				Payload: []byte("hello"),
+			}
+
+			ctx := NewContext(&mockConn{}, newMockRegistry())
+			ctx.HandshakeComplete = true
+			ctx.Version = tt.version
+			ctx.BindSession(mockSess)
+
+			if resp, err := handler.Handle(ctx, cmd); err != nil || resp != nil {
+				t.Fatalf("Handle() = %v, %v, want nil, nil", resp, err)
+			}
+			if mockSess.lastSendOpts.Protocol != tt.wantProtocol {
+				t.Errorf("Send() Protocol = %d, want %d", mockSess.lastSendOpts.Protocol, tt.wantProtocol)
+			}
+			if mockSess.lastSendOpts.SendTags != tt.wantSendTags {
+				t.Errorf("Send() SendTags = %d, want %d", mockSess.lastSendOpts.SendTags, tt.wantSendTags)
+			}
+		})
+	}
+}
+
 func TestRawHandler_HandleSend_SAM33OptionsValidation(t *testing.T) {
 	handler := NewRawHandler()
 
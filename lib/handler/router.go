@@ -3,6 +3,7 @@ package handler
 import (
 	"strings"
 	"sync"
+	"sync/atomic"
 
 	"github.com/go-i2p/go-sam-bridge/lib/protocol"
 )
@@ -10,9 +11,15 @@ import (
 // Router dispatches SAM commands to appropriate handlers.
 // Per SAMv3.md, it is recommended that servers map commands to upper case
 // for ease in testing via telnet.
+//
+// Handlers are registered almost entirely at startup, so the handler map
+// is copied on each Register and published atomically: Route and the
+// other lookups take no lock.
 type Router struct {
-	mu       sync.RWMutex
-	handlers map[string]Handler
+	// mu serializes Register. handlers is never modified after it is
+	// stored.
+	mu       sync.Mutex
+	handlers atomic.Pointer[map[string]Handler]
 
 	// CaseInsensitive enables case-insensitive verb/action matching.
 	// Recommended per SAM 3.2 specification.
@@ -25,10 +32,9 @@ type Router struct {
 
 // NewRouter creates a new command router with case-insensitive matching enabled.
 func NewRouter() *Router {
-	return &Router{
-		handlers:        make(map[string]Handler),
-		CaseInsensitive: true,
-	}
+	r := &Router{CaseInsensitive: true}
+	r.handlers.Store(&map[string]Handler{})
+	return r
 }
 
 // Register adds a handler for a command key.
@@ -41,7 +47,21 @@ func (r *Router) Register(key string, handler Handler) {
 	if r.CaseInsensitive {
 		key = strings.ToUpper(key)
 	}
-	r.handlers[key] = handler
+	old := r.handlerMap()
+	handlers := make(map[string]Handler, len(old)+1)
+	for k, h := range old {
+		handlers[k] = h
+	}
+	handlers[key] = handler
+	r.handlers.Store(&handlers)
+}
+
+// handlerMap returns the current handler map, which must not be modified.
+func (r *Router) handlerMap() map[string]Handler {
+	if m := r.handlers.Load(); m != nil {
+		return *m
+	}
+	return nil
 }
 
 // RegisterFunc is a convenience method to register a HandlerFunc.
@@ -56,8 +76,27 @@ func (r *Router) RegisterFunc(key string, fn HandlerFunc) {
 // 3. UnknownHandler (if set)
 // 4. nil (no handler found)
 func (r *Router) Route(cmd *protocol.Command) Handler {
-	r.mu.RLock()
-	defer r.mu.RUnlock()
+	if _, h := r.match(cmd); h != nil {
+		return h
+	}
+
+	// Fall back to unknown handler
+	return r.UnknownHandler
+}
+
+// RouteKey returns the registered key that cmd is routed by, such as
+// "SESSION CREATE" or "PING", or the empty string if no registered
+// handler matches. Unlike the verbs clients send, the keys form a small
+// fixed set, so they suit labelling per-command metrics.
+func (r *Router) RouteKey(cmd *protocol.Command) string {
+	key, _ := r.match(cmd)
+	return key
+}
+
+// match returns the key and handler cmd matches, trying "VERB ACTION"
+// before "VERB".
+func (r *Router) match(cmd *protocol.Command) (string, Handler) {
+	handlers := r.handlerMap()
 
 	verb := cmd.Verb
 	action := cmd.Action
@@ -70,18 +109,16 @@ func (r *Router) Route(cmd *protocol.Command) Handler {
 	// Try "VERB ACTION" first
 	if action != "" {
 		key := verb + " " + action
-		if h, ok := r.handlers[key]; ok {
-			return h
+		if h, ok := handlers[key]; ok {
+			return key, h
 		}
 	}
 
 	// Try "VERB" only
-	if h, ok := r.handlers[verb]; ok {
-		return h
+	if h, ok := handlers[verb]; ok {
+		return verb, h
 	}
-
-	// Fall back to unknown handler
-	return r.UnknownHandler
+	return "", nil
 }
 
 // Handle dispatches the command to the appropriate handler.
@@ -110,23 +147,18 @@ func (r *Router) unknownCommandResponse(cmd *protocol.Command) *protocol.Respons
 
 // HasHandler returns true if a handler is registered for the given key.
 func (r *Router) HasHandler(key string) bool {
-	r.mu.RLock()
-	defer r.mu.RUnlock()
-
 	if r.CaseInsensitive {
 		key = strings.ToUpper(key)
 	}
-	_, ok := r.handlers[key]
+	_, ok := r.handlerMap()[key]
 	return ok
 }
 
 // Keys returns all registered handler keys.
 func (r *Router) Keys() []string {
-	r.mu.RLock()
-	defer r.mu.RUnlock()
-
-	keys := make([]string, 0, len(r.handlers))
-	for k := range r.handlers {
+	handlers := r.handlerMap()
+	keys := make([]string, 0, len(handlers))
+	for k := range handlers {
 		keys = append(keys, k)
 	}
 	return keys
@@ -134,7 +166,5 @@ func (r *Router) Keys() []string {
 
 // Count returns the number of registered handlers.
 func (r *Router) Count() int {
-	r.mu.RLock()
-	defer r.mu.RUnlock()
-	return len(r.handlers)
+	return len(r.handlerMap())
 }
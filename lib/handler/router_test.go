@@ -1,6 +1,8 @@
 package handler
 
 import (
+	"strconv"
+	"sync"
 	"testing"
 
 	"github.com/go-i2p/go-sam-bridge/lib/protocol"
@@ -15,7 +17,7 @@ func TestNewRouter(t *testing.T) {
 	if !r.CaseInsensitive {
 		t.Error("CaseInsensitive should be true by default")
 	}
-	if r.handlers == nil {
+	if r.handlers.Load() == nil {
 		t.Error("handlers map should be initialized")
 	}
 	if r.Count() != 0 {
@@ -312,6 +314,32 @@ func TestRouter_Keys(t *testing.T) {
 	}
 }
 
+func TestRouter_RouteKey(t *testing.T) {
+	r := NewRouter()
+	noop := func(ctx *Context, cmd *protocol.Command) (*protocol.Response, error) { return nil, nil }
+	r.RegisterFunc("SESSION CREATE", noop)
+	r.RegisterFunc("PING", noop)
+	r.UnknownHandler = HandlerFunc(noop)
+
+	tests := []struct {
+		verb, action string
+		want         string
+	}{
+		{"SESSION", "CREATE", "SESSION CREATE"},
+		{"session", "create", "SESSION CREATE"},
+		{"PING", "", "PING"},
+		{"PING", "EXTRA", "PING"},
+		{"SESSION", "BOGUS", ""},
+		{"BOGUS", "", ""},
+	}
+
+	for _, tt := range tests {
+		if got := r.RouteKey(&protocol.Command{Verb: tt.verb, Action: tt.action}); got != tt.want {
+			t.Errorf("RouteKey(%q %q) = %q, want %q", tt.verb, tt.action, got, tt.want)
+		}
+	}
+}
+
 func TestRouter_HasHandler(t *testing.T) {
 	r := NewRouter()
 
@@ -351,3 +379,50 @@ func containsHelper(s, substr string) bool {
 	}
 	return false
 }
+
+func TestRouter_ConcurrentRegisterAndRoute(t *testing.T) {
+	r := NewRouter()
+	pong := func(ctx *Context, cmd *protocol.Command) (*protocol.Response, error) {
+		return protocol.NewResponse("PONG"), nil
+	}
+	r.RegisterFunc("PING", pong)
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 100; i++ {
+			r.RegisterFunc("VERB"+strconv.Itoa(i), pong)
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 100; i++ {
+			if r.Route(&protocol.Command{Verb: "PING"}) == nil {
+				t.Error("Route(PING) = nil during concurrent Register")
+				return
+			}
+		}
+	}()
+	wg.Wait()
+
+	if got := r.Count(); got != 101 {
+		t.Errorf("Count() = %d, want 101", got)
+	}
+}
+
+func BenchmarkRouter_Route(b *testing.B) {
+	r := NewRouter()
+	r.RegisterFunc("STREAM CONNECT", func(ctx *Context, cmd *protocol.Command) (*protocol.Response, error) {
+		return nil, nil
+	})
+	cmd := &protocol.Command{Verb: "STREAM", Action: "CONNECT"}
+
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			if r.Route(cmd) == nil {
+				b.Fatal("Route() = nil")
+			}
+		}
+	})
+}
@@ -3,8 +3,10 @@ package handler
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -27,6 +29,9 @@ type SessionHandler struct {
 	i2cpProvider       session.I2CPSessionProvider
 	tunnelBuildTimeout time.Duration
 	onSessionCreated   SessionCreatedCallback
+	onTunnelEvent      TunnelEventCallback
+	sessionDefaults    *session.SessionConfig
+	keyStore           *destination.KeyStore
 }
 
 // SessionCreatedCallback is called after a session is successfully created.
@@ -34,6 +39,10 @@ type SessionHandler struct {
 // The callback receives the session and the I2CP handle (may be nil if no I2CP provider).
 type SessionCreatedCallback func(sess session.Session, i2cpHandle session.I2CPSessionHandle)
 
+// TunnelEventCallback is called when the tunnels of a session with an
+// I2CP session are built, expire or fail.
+type TunnelEventCallback func(sess session.Session, ev session.TunnelEvent)
+
 // NewSessionHandler creates a new SESSION handler with the given destination manager.
 func NewSessionHandler(destManager destination.Manager) *SessionHandler {
 	return &SessionHandler{
@@ -43,7 +52,9 @@ func NewSessionHandler(destManager destination.Manager) *SessionHandler {
 }
 
 // SetI2CPProvider sets the I2CP session provider for creating I2CP sessions.
-// ISSUE-003: When set, SESSION CREATE will wait for tunnels before responding.
+// ISSUE-003: When set, SESSION CREATE will wait for tunnels before responding,
+// and for the lease set when the handle implements session.LeaseSetWaiter.
+// While the provider is not connected, SESSION CREATE fails with I2P_ERROR.
 func (h *SessionHandler) SetI2CPProvider(provider session.I2CPSessionProvider) {
 	h.i2cpProvider = provider
 }
@@ -60,9 +71,30 @@ func (h *SessionHandler) SetSessionCreatedCallback(cb SessionCreatedCallback) {
 	h.onSessionCreated = cb
 }
 
+// SetTunnelEventCallback sets the callback called for the tunnel events
+// of sessions whose I2CP handle implements session.TunnelEventSource,
+// starting with the latest event seen while the session was created.
+func (h *SessionHandler) SetTunnelEventCallback(cb TunnelEventCallback) {
+	h.onTunnelEvent = cb
+}
+
+// SetSessionDefaults sets the configuration SESSION CREATE starts from
+// before applying the options given in the command, replacing the built-in
+// tunnel defaults. Nil restores session.DefaultSessionConfig.
+func (h *SessionHandler) SetSessionDefaults(cfg *session.SessionConfig) {
+	h.sessionDefaults = cfg.Clone()
+}
+
+// SetKeyStore enables DESTINATION=file:$name in SESSION CREATE, which
+// loads the private key stored as $name, or generates one and stores it
+// there if there is none. Nil disables it.
+func (h *SessionHandler) SetKeyStore(store *destination.KeyStore) {
+	h.keyStore = store
+}
+
 // Handle processes a SESSION command.
 // Per SAMv3.md, SESSION commands manage SAM sessions.
-// Dispatches to handleCreate, handleAdd, or handleRemove based on action.
+// Dispatches to handleCreate, handleAdd, handleRemove, handleStats, or handleList based on action.
 func (h *SessionHandler) Handle(ctx *Context, cmd *protocol.Command) (*protocol.Response, error) {
 	switch cmd.Action {
 	case protocol.ActionCreate:
@@ -71,6 +103,10 @@ func (h *SessionHandler) Handle(ctx *Context, cmd *protocol.Command) (*protocol.
 		return h.handleAdd(ctx, cmd)
 	case protocol.ActionRemove:
 		return h.handleRemove(ctx, cmd)
+	case protocol.ActionStats:
+		return h.handleStats(ctx, cmd)
+	case protocol.ActionList:
+		return h.handleList(ctx, cmd)
 	default:
 		return sessionError("unknown SESSION action: " + cmd.Action), nil
 	}
@@ -79,13 +115,16 @@ func (h *SessionHandler) Handle(ctx *Context, cmd *protocol.Command) (*protocol.
 // handleCreate processes a SESSION CREATE command.
 // Per SAMv3.md, SESSION CREATE establishes a new SAM session.
 //
-// Request: SESSION CREATE STYLE=STREAM ID=$nickname DESTINATION={$privkey,TRANSIENT} [options...]
+// Request: SESSION CREATE STYLE=STREAM ID=$nickname DESTINATION={$privkey,TRANSIENT,file:$name} [options...]
 // Response: SESSION STATUS RESULT=OK DESTINATION=$privkey
 //
 //	SESSION STATUS RESULT=DUPLICATED_ID
 //	SESSION STATUS RESULT=DUPLICATED_DEST
 //	SESSION STATUS RESULT=INVALID_KEY
 //	SESSION STATUS RESULT=I2P_ERROR MESSAGE="..."
+//
+// The reply carries no extension fields, since some client libraries
+// reject anything after DESTINATION; SESSION STATS reports the MTU.
 func (h *SessionHandler) handleCreate(ctx *Context, cmd *protocol.Command) (*protocol.Response, error) {
 	// Validate preconditions
 	if resp := h.validateCreatePreconditions(ctx); resp != nil {
@@ -114,6 +153,12 @@ func (h *SessionHandler) handleCreate(ctx *Context, cmd *protocol.Command) (*pro
 	if err != nil {
 		return sessionError(err.Error()), nil
 	}
+	config.OfflineSignature = dest.OfflineSignatureConfig()
+	config.SAMVersion = ctx.Version
+	config.User = ctx.User
+	if err := config.ValidateCreate(id, style); err != nil {
+		return sessionError(err.Error()), nil
+	}
 
 	// Create the session based on style
 	newSession, err := h.createSession(id, style, dest, ctx.Conn, config, cmd)
@@ -154,17 +199,14 @@ func (h *SessionHandler) parseCreateRequiredParams(cmd *protocol.Command) (sessi
 	}
 
 	id := cmd.Get("ID")
-	if id == "" {
-		return "", "", sessionError("missing ID")
-	}
-
-	if containsWhitespace(id) {
-		return "", "", sessionError("ID may not contain whitespace")
+	if err := session.ValidateID(id); err != nil {
+		return "", "", sessionError(err.Error())
 	}
 	return style, id, nil
 }
 
-// parseCreateDestination parses DESTINATION option (TRANSIENT or existing key).
+// parseCreateDestination parses DESTINATION option (TRANSIENT, existing
+// key, or file:$name from the key store).
 func (h *SessionHandler) parseCreateDestination(cmd *protocol.Command) (*session.Destination, string, *protocol.Response) {
 	destSpec := cmd.Get("DESTINATION")
 	if destSpec == "" {
@@ -177,6 +219,8 @@ func (h *SessionHandler) parseCreateDestination(cmd *protocol.Command) (*session
 
 	if destSpec == "TRANSIENT" {
 		dest, privKeyBase64, err = h.createTransientDest(cmd)
+	} else if name, ok := strings.CutPrefix(destSpec, "file:"); ok {
+		dest, privKeyBase64, err = h.loadOrCreateStoredDest(cmd, name)
 	} else {
 		dest, privKeyBase64, err = h.parseExistingDest(destSpec)
 	}
@@ -189,9 +233,22 @@ func (h *SessionHandler) parseCreateDestination(cmd *protocol.Command) (*session
 
 // setupI2CPSession creates I2CP session and waits for tunnels if provider is set.
 func (h *SessionHandler) setupI2CPSession(ctx *Context, id string, config *session.SessionConfig, newSession session.Session) (session.I2CPSessionHandle, *protocol.Response) {
-	if h.i2cpProvider == nil || !h.i2cpProvider.IsConnected() {
+	if h.i2cpProvider == nil {
 		return nil, nil
 	}
+	if !h.i2cpProvider.IsConnected() {
+		newSession.Close()
+		return nil, sessionI2PError("not connected to I2P router")
+	}
+
+	if config.LeaseSetType == session.LeaseSetTypeEncrypted {
+		if rp, ok := h.i2cpProvider.(session.RouterInfoProvider); ok {
+			if info, ok := rp.RouterInfo(); ok && !info.EncryptedLeaseSet {
+				newSession.Close()
+				return nil, sessionI2PError("router does not support encrypted lease sets (i2cp.leaseSetType=5)")
+			}
+		}
+	}
 
 	handle, err := h.createI2CPSession(ctx.Ctx, id, config)
 	if err != nil {
@@ -203,6 +260,25 @@ func (h *SessionHandler) setupI2CPSession(ctx *Context, id string, config *sessi
 	if baseSession, ok := newSession.(*session.BaseSession); ok {
 		baseSession.SetI2CPSession(handle)
 	}
+	if src, ok := handle.(session.TunnelEventSource); ok {
+		recorder, _ := newSession.(interface{ RecordTunnelEvent(session.TunnelEvent) })
+		src.OnTunnelEvent(func(ev session.TunnelEvent) {
+			if recorder != nil {
+				recorder.RecordTunnelEvent(ev)
+			}
+			if h.onTunnelEvent != nil {
+				h.onTunnelEvent(newSession, ev)
+			}
+		})
+	}
+
+	// Hand datagrams received on the destination to the session; a
+	// PRIMARY session routes each to one of its subsessions
+	if src, ok := handle.(session.MessageSource); ok {
+		src.OnMessage(func(msg session.IncomingMessage) {
+			session.Deliver(newSession, msg)
+		})
+	}
 
 	// Wait for tunnels to be built before returning success
 	tunnelCtx, cancel := context.WithTimeout(ctx.Ctx, h.tunnelBuildTimeout)
@@ -212,6 +288,18 @@ func (h *SessionHandler) setupI2CPSession(ctx *Context, id string, config *sessi
 		newSession.Close()
 		return nil, sessionI2PError(fmt.Sprintf("tunnel build failed: %v", err))
 	}
+	if tr, ok := newSession.(interface{ MarkTunnelsReady() }); ok {
+		tr.MarkTunnelsReady()
+	}
+
+	// Tunnels alone do not make the destination reachable; hold the OK
+	// until the lease set is on its way to the network database
+	if lw, ok := handle.(session.LeaseSetWaiter); ok {
+		if err := lw.WaitForLeaseSet(tunnelCtx); err != nil {
+			newSession.Close()
+			return nil, sessionI2PError(fmt.Sprintf("lease set not published: %v", err))
+		}
+	}
 	return handle, nil
 }
 
@@ -239,8 +327,10 @@ func (h *SessionHandler) registerAndFinalizeSession(ctx *Context, newSession ses
 	switch newSession.Style() {
 	case session.StyleDatagram, session.StyleDatagram2, session.StyleDatagram3:
 		ctx.StartDatagramReceiver()
+		ctx.StartSendErrorReplies()
 	case session.StyleRaw:
 		ctx.StartRawReceiver()
+		ctx.StartSendErrorReplies()
 	}
 
 	// Invoke session created callback
@@ -296,9 +386,47 @@ func (h *SessionHandler) createTransientDest(cmd *protocol.Command) (*session.De
 	return sessionDest, privKeyBase64, nil
 }
 
-// parseExistingDest parses an existing private key destination.
-// Per SAM 3.3, this also detects and parses offline signatures.
-// If the signing private key is all zeros, the offline signature section follows.
+// loadOrCreateStoredDest loads the destination stored as name in the key
+// store. If there is none, it generates one as for TRANSIENT, honoring
+// SIGNATURE_TYPE, and stores it under name.
+func (h *SessionHandler) loadOrCreateStoredDest(cmd *protocol.Command, name string) (*session.Destination, string, error) {
+	if h.keyStore == nil {
+		return nil, "", &sessionErr{msg: "DESTINATION=file: requires a key store"}
+	}
+
+	privKey, err := h.keyStore.Load(name)
+	if errors.Is(err, destination.ErrKeyNotFound) {
+		if hasOfflineSignatureOptions(cmd) {
+			return nil, "", &sessionErr{msg: "offline signatures may not be created with a new DESTINATION=file:"}
+		}
+		dest, privKeyBase64, genErr := h.createTransientDest(cmd)
+		if genErr != nil {
+			return nil, "", genErr
+		}
+		raw, decErr := destination.Base64Decode(privKeyBase64)
+		if decErr != nil {
+			return nil, "", decErr
+		}
+		err = h.keyStore.Save(name, raw)
+		if err == nil {
+			return dest, privKeyBase64, nil
+		}
+		if !errors.Is(err, destination.ErrKeyExists) {
+			return nil, "", err
+		}
+		// Another SESSION CREATE stored a key under name first; use it.
+		privKey, err = h.keyStore.Load(name)
+	}
+	if err != nil {
+		return nil, "", err
+	}
+	return h.parseExistingDest(destination.Base64Encode(privKey))
+}
+
+// parseExistingDest parses and validates an existing private key
+// destination. Per SAM 3.3, this also detects and parses offline
+// signatures. If the signing private key is all zeros, the offline
+// signature section follows.
 func (h *SessionHandler) parseExistingDest(privKeyBase64 string) (*session.Destination, string, error) {
 	result, err := h.destManager.ParseWithOffline(privKeyBase64)
 	if err != nil {
@@ -328,6 +456,12 @@ func (h *SessionHandler) parseExistingDest(privKeyBase64 string) (*session.Desti
 		}
 	}
 
+	// Reject keys that do not belong to the destination before the
+	// router sees them
+	if err := sessionDest.Validate(); err != nil {
+		return nil, "", err
+	}
+
 	return sessionDest, privKeyBase64, nil
 }
 
@@ -627,11 +761,14 @@ func (h *SessionHandler) createDatagram3Session(
 // Unparsed i2cp.* and streaming.* options are stored for passthrough to I2CP.
 // Returns an error if validation fails.
 func (h *SessionHandler) parseConfig(cmd *protocol.Command, style session.Style) (*session.SessionConfig, error) {
-	config := session.DefaultSessionConfig()
+	config := h.defaultConfig()
 	parsedOptions := make(map[string]bool)
 
 	// Parse tunnel configuration
-	h.parseTunnelOptions(cmd, config, parsedOptions)
+	if err := h.parseTunnelOptions(cmd, config, parsedOptions); err != nil {
+		return nil, err
+	}
+	parseBackupAndIdleOptions(cmd, config)
 
 	// Parse port options (SAM 3.2+)
 	if err := h.parseConfigPortOptions(cmd, config, parsedOptions); err != nil {
@@ -648,38 +785,99 @@ func (h *SessionHandler) parseConfig(cmd *protocol.Command, style session.Style)
 		return nil, err
 	}
 
+	// Parse datagram receive queue options (bridge extension)
+	if err := parseReceiveOptions(cmd, config, parsedOptions); err != nil {
+		return nil, err
+	}
+
+	// Parse datagram send queue options (bridge extension)
+	if err := parseSendQueueOptions(cmd, config, parsedOptions); err != nil {
+		return nil, err
+	}
+
+	// Parse stream inactivity timeouts (bridge extension)
+	if err := parseStreamTimeoutOptions(cmd, config, parsedOptions); err != nil {
+		return nil, err
+	}
+
+	// Parse per-session stream limits (bridge extension)
+	if err := parseStreamLimitOptions(cmd, config, parsedOptions); err != nil {
+		return nil, err
+	}
+
+	// Parse session label (bridge extension)
+	if err := parseLabelOption(cmd, config, parsedOptions); err != nil {
+		return nil, err
+	}
+
+	// Parse encrypted lease set options
+	if err := parseLeaseSetOptions(cmd, config, parsedOptions); err != nil {
+		return nil, err
+	}
+
 	// Collect unparsed I2CP options for passthrough
 	h.collectI2CPOptions(cmd, config, parsedOptions)
 
 	return config, nil
 }
 
-// parseTunnelOptions extracts tunnel quantity and length options.
-func (h *SessionHandler) parseTunnelOptions(cmd *protocol.Command, config *session.SessionConfig, parsed map[string]bool) {
-	if v := cmd.Get("inbound.quantity"); v != "" {
-		parsed["inbound.quantity"] = true
-		if qty, err := strconv.Atoi(v); err == nil && qty >= 0 {
-			config.InboundQuantity = qty
-		}
+// defaultConfig returns a fresh copy of the configuration that
+// SESSION CREATE options are applied to.
+func (h *SessionHandler) defaultConfig() *session.SessionConfig {
+	if h.sessionDefaults == nil {
+		return session.DefaultSessionConfig()
 	}
-	if v := cmd.Get("outbound.quantity"); v != "" {
-		parsed["outbound.quantity"] = true
-		if qty, err := strconv.Atoi(v); err == nil && qty >= 0 {
-			config.OutboundQuantity = qty
-		}
+	config := h.sessionDefaults.Clone()
+	if config.I2CPOptions == nil {
+		config.I2CPOptions = make(map[string]string)
 	}
-	if v := cmd.Get("inbound.length"); v != "" {
-		parsed["inbound.length"] = true
-		if len, err := strconv.Atoi(v); err == nil && len >= 0 {
-			config.InboundLength = len
+	return config
+}
+
+// parseBackupAndIdleOptions overrides the configured backup tunnel and
+// idle close defaults with values from the command. The options are not
+// marked as parsed, so they are still passed through to I2CP unchanged.
+// Idle times are given in milliseconds per the I2CP specification.
+func parseBackupAndIdleOptions(cmd *protocol.Command, config *session.SessionConfig) {
+	setInt := func(key string, scale int, dst *int) {
+		if n, err := strconv.Atoi(cmd.Get(key)); err == nil && n >= 0 {
+			*dst = n / scale
 		}
 	}
-	if v := cmd.Get("outbound.length"); v != "" {
-		parsed["outbound.length"] = true
-		if len, err := strconv.Atoi(v); err == nil && len >= 0 {
-			config.OutboundLength = len
+	setInt("inbound.backupQuantity", 1, &config.InboundBackupQuantity)
+	setInt("outbound.backupQuantity", 1, &config.OutboundBackupQuantity)
+	setInt("i2cp.closeIdleTime", 1000, &config.CloseIdleTime)
+
+	if cmd.Get("i2cp.closeOnIdle") == "false" {
+		config.CloseIdleTime = 0
+	}
+}
+
+// parseTunnelOptions extracts tunnel quantity and length options.
+// Values that are not integers are rejected here; range checks are left
+// to SessionConfig.Validate so the error names the allowed bounds.
+func (h *SessionHandler) parseTunnelOptions(cmd *protocol.Command, config *session.SessionConfig, parsed map[string]bool) error {
+	for _, opt := range []struct {
+		key string
+		dst *int
+	}{
+		{"inbound.quantity", &config.InboundQuantity},
+		{"outbound.quantity", &config.OutboundQuantity},
+		{"inbound.length", &config.InboundLength},
+		{"outbound.length", &config.OutboundLength},
+	} {
+		v := cmd.Get(opt.key)
+		if v == "" {
+			continue
 		}
+		parsed[opt.key] = true
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return fmt.Errorf("invalid %s: %q is not an integer", opt.key, v)
+		}
+		*opt.dst = n
 	}
+	return nil
 }
 
 // parseConfigPortOptions extracts and validates FROM_PORT and TO_PORT (SAM 3.2+).
@@ -744,6 +942,208 @@ func (h *SessionHandler) parseConfigUDPOptions(cmd *protocol.Command, config *se
 	return nil
 }
 
+// parseReceiveOptions extracts sam.receiveBuffer and sam.dropPolicy, bridge
+// extensions setting the depth of the received datagram queue and which
+// datagram is discarded when it is full.
+func parseReceiveOptions(cmd *protocol.Command, config *session.SessionConfig, parsed map[string]bool) error {
+	if v := cmd.Get("sam.receiveBuffer"); v != "" {
+		parsed["sam.receiveBuffer"] = true
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > session.MaxReceiveBufferSize {
+			return fmt.Errorf("invalid sam.receiveBuffer: %q: %w", v, session.ErrInvalidReceiveBuffer)
+		}
+		config.ReceiveBufferSize = n
+	}
+	if v := cmd.Get("sam.dropPolicy"); v != "" {
+		parsed["sam.dropPolicy"] = true
+		policy, err := session.ParseDropPolicy(strings.ToLower(v))
+		if err != nil {
+			return fmt.Errorf("invalid sam.dropPolicy: %w", err)
+		}
+		config.DropPolicy = policy
+	}
+	return nil
+}
+
+// parseSendQueueOptions extracts sam.sendQueue, sam.sendRetries,
+// sam.sendExpiry and sam.sendErrors, bridge extensions that queue
+// outgoing datagrams for retry while tunnels rebuild. sam.sendExpiry is
+// given in milliseconds.
+func parseSendQueueOptions(cmd *protocol.Command, config *session.SessionConfig, parsed map[string]bool) error {
+	if v := cmd.Get("sam.sendQueue"); v != "" {
+		parsed["sam.sendQueue"] = true
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 || n > session.MaxSendQueueSize {
+			return fmt.Errorf("invalid sam.sendQueue: %q: %w", v, session.ErrInvalidSendQueue)
+		}
+		config.SendQueueSize = n
+	}
+	if v := cmd.Get("sam.sendRetries"); v != "" {
+		parsed["sam.sendRetries"] = true
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			return fmt.Errorf("invalid sam.sendRetries: %q: %w", v, session.ErrInvalidSendQueue)
+		}
+		config.SendRetries = n
+	}
+	if v := cmd.Get("sam.sendExpiry"); v != "" {
+		parsed["sam.sendExpiry"] = true
+		ms, err := strconv.Atoi(v)
+		if err != nil || ms < 0 {
+			return fmt.Errorf("invalid sam.sendExpiry: %q: %w", v, session.ErrInvalidSendQueue)
+		}
+		config.SendExpiry = time.Duration(ms) * time.Millisecond
+	}
+	if v := cmd.Get("sam.sendErrors"); v != "" {
+		parsed["sam.sendErrors"] = true
+		enabled, err := parseBoolOption(v, "sam.sendErrors")
+		if err != nil {
+			return fmt.Errorf("invalid %w", err)
+		}
+		config.SendErrors = enabled
+	}
+	return nil
+}
+
+// parseStreamTimeoutOptions extracts sam.streamReadTimeout and
+// sam.streamWriteTimeout, bridge extensions giving the inactivity
+// timeouts of the session's streams in milliseconds.
+func parseStreamTimeoutOptions(cmd *protocol.Command, config *session.SessionConfig, parsed map[string]bool) error {
+	for _, opt := range []struct {
+		key string
+		dst *time.Duration
+	}{
+		{"sam.streamReadTimeout", &config.StreamReadTimeout},
+		{"sam.streamWriteTimeout", &config.StreamWriteTimeout},
+	} {
+		v := cmd.Get(opt.key)
+		if v == "" {
+			continue
+		}
+		parsed[opt.key] = true
+		ms, err := strconv.Atoi(v)
+		if err != nil || ms < 0 {
+			return fmt.Errorf("invalid %s: %q: %w", opt.key, v, session.ErrInvalidStreamTimeout)
+		}
+		*opt.dst = time.Duration(ms) * time.Millisecond
+	}
+	return nil
+}
+
+// parseStreamLimitOptions extracts sam.maxOutboundStreams and
+// sam.maxInboundStreams, bridge extensions capping the session's
+// concurrent streams. Where the bridge configures a limit, a session may
+// only lower it.
+func parseStreamLimitOptions(cmd *protocol.Command, config *session.SessionConfig, parsed map[string]bool) error {
+	for _, opt := range []struct {
+		key string
+		dst *int
+	}{
+		{"sam.maxOutboundStreams", &config.MaxOutboundStreams},
+		{"sam.maxInboundStreams", &config.MaxInboundStreams},
+	} {
+		v := cmd.Get(opt.key)
+		if v == "" {
+			continue
+		}
+		parsed[opt.key] = true
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			return fmt.Errorf("invalid %s: %q: %w", opt.key, v, session.ErrInvalidStreamLimit)
+		}
+		if *opt.dst > 0 && (n == 0 || n > *opt.dst) {
+			return fmt.Errorf("invalid %s: %q exceeds the bridge limit of %d", opt.key, v, *opt.dst)
+		}
+		*opt.dst = n
+	}
+	return nil
+}
+
+// parseLabelOption sets the session label from the LABEL option, a bridge
+// extension, or else from inbound.nickname. LABEL is consumed by the
+// bridge; inbound.nickname is still passed through to I2CP.
+func parseLabelOption(cmd *protocol.Command, config *session.SessionConfig, parsed map[string]bool) error {
+	label := cmd.Get("LABEL")
+	if label != "" {
+		parsed["LABEL"] = true
+	} else {
+		label = cmd.Get("inbound.nickname")
+	}
+	if err := session.ValidateLabel(label); err != nil {
+		return fmt.Errorf("invalid LABEL: %w", err)
+	}
+	config.Label = label
+	return nil
+}
+
+// parseLeaseSetOptions extracts the encrypted lease set options:
+// i2cp.leaseSetType, i2cp.leaseSetAuthType, i2cp.leaseSetSecret, and the
+// per-client keys i2cp.leaseSetClient.dh.N and i2cp.leaseSetClient.psk.N
+// with values of name:base64key. The secret and client keys are consumed
+// by the bridge so they are not copied into the I2CP passthrough options.
+// If i2cp.leaseSetAuthType is absent it is inferred from the client keys.
+func parseLeaseSetOptions(cmd *protocol.Command, config *session.SessionConfig, parsed map[string]bool) error {
+	for _, opt := range []struct {
+		key string
+		dst *int
+	}{
+		{"i2cp.leaseSetType", &config.LeaseSetType},
+		{"i2cp.leaseSetAuthType", &config.LeaseSetAuthType},
+	} {
+		v := cmd.Get(opt.key)
+		if v == "" {
+			continue
+		}
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return fmt.Errorf("invalid %s: %q is not an integer", opt.key, v)
+		}
+		*opt.dst = n
+	}
+
+	if v := cmd.Get("i2cp.leaseSetSecret"); v != "" {
+		parsed["i2cp.leaseSetSecret"] = true
+		config.LeaseSetSecret = v
+	}
+
+	var keys []string
+	for key := range cmd.Options {
+		if strings.HasPrefix(key, "i2cp.leaseSetClient.") {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		parsed[key] = true
+		var authType int
+		switch {
+		case strings.HasPrefix(key, "i2cp.leaseSetClient.dh."):
+			authType = session.LeaseSetAuthDH
+		case strings.HasPrefix(key, "i2cp.leaseSetClient.psk."):
+			authType = session.LeaseSetAuthPSK
+		default:
+			return fmt.Errorf("invalid %s: expected i2cp.leaseSetClient.dh.N or i2cp.leaseSetClient.psk.N", key)
+		}
+		if cmd.Get("i2cp.leaseSetAuthType") == "" && config.LeaseSetAuthType == session.LeaseSetAuthNone {
+			config.LeaseSetAuthType = authType
+		}
+		if authType != config.LeaseSetAuthType {
+			return fmt.Errorf("invalid %s: does not match i2cp.leaseSetAuthType=%d", key, config.LeaseSetAuthType)
+		}
+
+		name, encoded, ok := strings.Cut(cmd.Get(key), ":")
+		if !ok {
+			name, encoded = key[strings.LastIndex(key, ".")+1:], name
+		}
+		clientKey, err := destination.Base64Decode(encoded)
+		if err != nil {
+			return fmt.Errorf("invalid %s: key is not Base64", key)
+		}
+		config.LeaseSetClients = append(config.LeaseSetClients, session.LeaseSetClient{Name: name, Key: clientKey})
+	}
+	return nil
+}
+
 // collectI2CPOptions gathers unparsed i2cp.* and streaming.* options for I2CP passthrough.
 func (h *SessionHandler) collectI2CPOptions(cmd *protocol.Command, config *session.SessionConfig, parsed map[string]bool) {
 	for key, value := range cmd.Options {
@@ -816,6 +1216,16 @@ func sessionOK(destination string) *protocol.Response {
 		WithDestination(destination)
 }
 
+// withMaxPayload adds MTU, the largest datagram payload sess accepts, to
+// resp for sessions that send datagrams. This is a bridge extension, not
+// part of SAMv3.md; see session.MaxPayload.
+func withMaxPayload(resp *protocol.Response, sess session.Session) *protocol.Response {
+	if mtu := session.MaxPayload(sess); mtu > 0 {
+		resp.WithOption("MTU", strconv.Itoa(mtu))
+	}
+	return resp
+}
+
 // sessionDuplicatedID returns a DUPLICATED_ID response.
 func sessionDuplicatedID() *protocol.Response {
 	return protocol.NewResponse(protocol.VerbSession).
@@ -970,6 +1380,140 @@ func (h *SessionHandler) handleRemove(ctx *Context, cmd *protocol.Command) (*pro
 	return sessionOK(destBase64), nil
 }
 
+// handleStats processes a SESSION STATS command.
+// This is a bridge extension, not part of SAMv3.md.
+//
+// Request: SESSION STATS [ID=$nickname]
+// Response: SESSION STATUS RESULT=OK ID=$nickname BYTES_SENT=$n BYTES_RECEIVED=$n
+//
+//	STREAMS=$n DATAGRAMS_SENT=$n DATAGRAMS_RECEIVED=$n
+//	DATAGRAMS_DROPPED=$n [MTU=$bytes] [UPTIME=$seconds] [TIME_TO_READY=$milliseconds]
+//	[TUNNELS=BUILT|EXPIRED|FAILED TUNNEL_BUILDS=$n TUNNEL_EXPIRATIONS=$n
+//	TUNNEL_FAILURES=$n]
+//
+// ID defaults to the session bound to this connection. TIME_TO_READY is
+// how long the session's tunnels took to build, if known. TUNNELS is the
+// latest tunnel event, present once the I2CP session has reported one.
+// MTU is the largest datagram payload the session accepts for SEND; it
+// is omitted for STREAM and PRIMARY sessions.
+func (h *SessionHandler) handleStats(ctx *Context, cmd *protocol.Command) (*protocol.Response, error) {
+	// Require handshake completion
+	if !ctx.HandshakeComplete {
+		return sessionError("handshake not complete"), nil
+	}
+
+	id := cmd.Get("ID")
+	if id == "" && ctx.Session != nil {
+		id = ctx.Session.ID()
+	}
+	if id == "" {
+		return sessionError("missing ID"), nil
+	}
+
+	var sess session.Session
+	if ctx.Registry != nil {
+		sess = ctx.Registry.Get(id)
+	}
+	if sess == nil {
+		return sessionInvalidID("session not found: " + id), nil
+	}
+
+	sp, ok := sess.(session.StatsProvider)
+	if !ok {
+		return sessionError("statistics not available for session: " + id), nil
+	}
+	stats := sp.Stats().Snapshot()
+
+	resp := protocol.NewResponse(protocol.VerbSession).
+		WithAction(protocol.ActionStatus).
+		WithResult(protocol.ResultOK).
+		WithOption("ID", id).
+		WithOption("BYTES_SENT", strconv.FormatUint(stats.BytesSent, 10)).
+		WithOption("BYTES_RECEIVED", strconv.FormatUint(stats.BytesReceived, 10)).
+		WithOption("STREAMS", strconv.FormatUint(stats.Streams, 10)).
+		WithOption("DATAGRAMS_SENT", strconv.FormatUint(stats.DatagramsSent, 10)).
+		WithOption("DATAGRAMS_RECEIVED", strconv.FormatUint(stats.DatagramsReceived, 10)).
+		WithOption("DATAGRAMS_DROPPED", strconv.FormatUint(stats.DatagramsDropped, 10))
+	withMaxPayload(resp, sess)
+
+	if tp, ok := sess.(session.TimelineProvider); ok {
+		timeline := tp.Timeline()
+		resp.WithOption("UPTIME", strconv.FormatInt(int64(timeline.Uptime(time.Now())/time.Second), 10))
+		if ready, ok := timeline.TimeToReady(); ok {
+			resp.WithOption("TIME_TO_READY", strconv.FormatInt(ready.Milliseconds(), 10))
+		}
+	}
+	if tp, ok := sess.(session.TunnelHealthProvider); ok {
+		if tunnels, ok := tp.TunnelHealth(); ok {
+			resp.WithOption("TUNNELS", tunnels.Last.Type.String()).
+				WithOption("TUNNEL_BUILDS", strconv.FormatUint(tunnels.Builds, 10)).
+				WithOption("TUNNEL_EXPIRATIONS", strconv.FormatUint(tunnels.Expirations, 10)).
+				WithOption("TUNNEL_FAILURES", strconv.FormatUint(tunnels.Failures, 10))
+		}
+	}
+	return resp, nil
+}
+
+// handleList processes a SESSION LIST command.
+// This is a bridge extension, not part of SAMv3.md.
+//
+// Request: SESSION LIST
+// Response: SESSION STATUS RESULT=OK COUNT=$n
+//
+//	SESSION INFO ID=$nickname STYLE=$style [LABEL=$label]
+//	...
+//
+// One SESSION INFO line follows for each registered session, sorted by ID.
+func (h *SessionHandler) handleList(ctx *Context, cmd *protocol.Command) (*protocol.Response, error) {
+	// Require handshake completion
+	if !ctx.HandshakeComplete {
+		return sessionError("handshake not complete"), nil
+	}
+
+	var infos []string
+	if ctx.Registry != nil {
+		ids := ctx.Registry.All()
+		sort.Strings(ids)
+		for _, id := range ids {
+			// The session may close between All and Get
+			sess := ctx.Registry.Get(id)
+			if sess == nil {
+				continue
+			}
+			infos = append(infos, sessionInfoLine(sess))
+		}
+	}
+
+	resp := protocol.NewResponse(protocol.VerbSession).
+		WithAction(protocol.ActionStatus).
+		WithResult(protocol.ResultOK).
+		WithOption("COUNT", strconv.Itoa(len(infos)))
+	for _, line := range infos {
+		resp.WithAdditionalLine(line)
+	}
+	return resp, nil
+}
+
+// sessionInfoLine formats the SESSION INFO line describing sess.
+func sessionInfoLine(sess session.Session) string {
+	info := protocol.NewResponse(protocol.VerbSession).
+		WithAction("INFO").
+		WithOption("ID", sess.ID()).
+		WithOption("STYLE", string(sess.Style()))
+	if label := session.Label(sess); label != "" {
+		info.WithOption("LABEL", label)
+	}
+	return strings.TrimSuffix(info.String(), "\n")
+}
+
+// sessionInvalidID returns an INVALID_ID response.
+func sessionInvalidID(msg string) *protocol.Response {
+	return protocol.NewResponse(protocol.VerbSession).
+		WithAction(protocol.ActionStatus).
+		WithResult(protocol.ResultInvalidID).
+		WithMessage(msg)
+}
+
 // parseSubsessionOptions parses subsession options from SESSION ADD command.
 // Per SAMv3.md, options include PORT, HOST, FROM_PORT, TO_PORT, PROTOCOL,
 // LISTEN_PORT, LISTEN_PROTOCOL, HEADER.
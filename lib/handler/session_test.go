@@ -1,11 +1,14 @@
 package handler
 
 import (
+	"context"
 	"errors"
 	"strings"
 	"testing"
+	"time"
 
 	commondest "github.com/go-i2p/common/destination"
+	"github.com/go-i2p/go-sam-bridge/lib/destination"
 	"github.com/go-i2p/go-sam-bridge/lib/protocol"
 	"github.com/go-i2p/go-sam-bridge/lib/session"
 	"github.com/go-i2p/go-sam-bridge/lib/util"
@@ -223,6 +226,23 @@ func TestSessionHandler_Handle(t *testing.T) {
 			handshakeDone: true,
 			wantResult:    protocol.ResultI2PError,
 		},
+		{
+			name: "tunnel length out of range",
+			command: &protocol.Command{
+				Verb:   "SESSION",
+				Action: "CREATE",
+				Options: map[string]string{
+					"STYLE":          "STREAM",
+					"ID":             "test-session",
+					"DESTINATION":    "TRANSIENT",
+					"inbound.length": "9",
+				},
+			},
+			manager:       successManager,
+			registry:      newMockRegistry(),
+			handshakeDone: true,
+			wantResult:    protocol.ResultI2PError,
+		},
 		{
 			name: "missing DESTINATION",
 			command: &protocol.Command{
@@ -741,6 +761,11 @@ func TestSessionHandler_Handle(t *testing.T) {
 			if tt.wantSession && !strings.Contains(respStr, "DESTINATION=") {
 				t.Errorf("Handle() = %q, want DESTINATION=", respStr)
 			}
+
+			// Clients such as go-i2p/sam3 reject fields after DESTINATION.
+			if tt.wantSession && len(strings.Fields(respStr)) != 4 {
+				t.Errorf("Handle() = %q, want only RESULT and DESTINATION", respStr)
+			}
 		})
 	}
 }
@@ -984,6 +1009,101 @@ func TestSessionHandler_ParseConfig(t *testing.T) {
 			wantErr:   true,
 			errSubstr: "sam.udp.port",
 		},
+		{
+			name: "receive queue options",
+			options: map[string]string{
+				"sam.receiveBuffer": "500",
+				"sam.dropPolicy":    "oldest",
+			},
+			style: session.StyleDatagram,
+			check: func(c *session.SessionConfig) bool {
+				return c.ReceiveBufferSize == 500 && c.DropPolicy == session.DropOldest &&
+					len(c.I2CPOptions) == 0
+			},
+		},
+		{
+			name: "sam.receiveBuffer invalid - zero",
+			options: map[string]string{
+				"sam.receiveBuffer": "0",
+			},
+			style:     session.StyleDatagram,
+			wantErr:   true,
+			errSubstr: "sam.receiveBuffer",
+		},
+		{
+			name: "sam.dropPolicy invalid",
+			options: map[string]string{
+				"sam.dropPolicy": "random",
+			},
+			style:     session.StyleRaw,
+			wantErr:   true,
+			errSubstr: "sam.dropPolicy",
+		},
+		{
+			name: "stream timeout options",
+			options: map[string]string{
+				"sam.streamReadTimeout":  "300000",
+				"sam.streamWriteTimeout": "60000",
+			},
+			style: session.StyleStream,
+			check: func(c *session.SessionConfig) bool {
+				return c.StreamReadTimeout == 5*time.Minute && c.StreamWriteTimeout == time.Minute &&
+					len(c.I2CPOptions) == 0
+			},
+		},
+		{
+			name: "sam.streamReadTimeout invalid - negative",
+			options: map[string]string{
+				"sam.streamReadTimeout": "-1",
+			},
+			style:     session.StyleStream,
+			wantErr:   true,
+			errSubstr: "sam.streamReadTimeout",
+		},
+		{
+			name: "stream limit options",
+			options: map[string]string{
+				"sam.maxOutboundStreams": "10",
+				"sam.maxInboundStreams":  "20",
+			},
+			style: session.StyleStream,
+			check: func(c *session.SessionConfig) bool {
+				return c.MaxOutboundStreams == 10 && c.MaxInboundStreams == 20 &&
+					len(c.I2CPOptions) == 0
+			},
+		},
+		{
+			name: "sam.maxInboundStreams invalid",
+			options: map[string]string{
+				"sam.maxInboundStreams": "many",
+			},
+			style:     session.StyleStream,
+			wantErr:   true,
+			errSubstr: "sam.maxInboundStreams",
+		},
+		{
+			name: "send queue options",
+			options: map[string]string{
+				"sam.sendQueue":   "64",
+				"sam.sendRetries": "5",
+				"sam.sendExpiry":  "3000",
+				"sam.sendErrors":  "true",
+			},
+			style: session.StyleDatagram,
+			check: func(c *session.SessionConfig) bool {
+				return c.SendQueueSize == 64 && c.SendRetries == 5 && c.SendExpiry == 3*time.Second &&
+					c.SendErrors && len(c.I2CPOptions) == 0
+			},
+		},
+		{
+			name: "sam.sendQueue invalid - too large",
+			options: map[string]string{
+				"sam.sendQueue": "100000",
+			},
+			style:     session.StyleDatagram,
+			wantErr:   true,
+			errSubstr: "sam.sendQueue",
+		},
 		{
 			name: "inbound.backupQuantity passthrough (not explicitly parsed)",
 			options: map[string]string{
@@ -1030,6 +1150,88 @@ func TestSessionHandler_ParseConfig(t *testing.T) {
 					len(c.I2CPOptions) == 1
 			},
 		},
+		{
+			name: "non-numeric tunnel quantity",
+			options: map[string]string{
+				"inbound.quantity": "many",
+			},
+			style:     session.StyleStream,
+			wantErr:   true,
+			errSubstr: "invalid inbound.quantity",
+		},
+		// Session label tests
+		{
+			name: "LABEL sets label and is not passed through",
+			options: map[string]string{
+				"LABEL":            "web server",
+				"inbound.nickname": "ignored",
+			},
+			style: session.StyleStream,
+			check: func(c *session.SessionConfig) bool {
+				return c.Label == "web server" &&
+					c.I2CPOptions["LABEL"] == "" &&
+					c.I2CPOptions["inbound.nickname"] == "ignored"
+			},
+		},
+		{
+			name: "inbound.nickname used as label",
+			options: map[string]string{
+				"inbound.nickname": "mynick",
+			},
+			style: session.StyleStream,
+			check: func(c *session.SessionConfig) bool {
+				return c.Label == "mynick" && c.I2CPOptions["inbound.nickname"] == "mynick"
+			},
+		},
+		{
+			name: "LABEL too long",
+			options: map[string]string{
+				"LABEL": strings.Repeat("x", session.MaxLabelLength+1),
+			},
+			style:     session.StyleStream,
+			wantErr:   true,
+			errSubstr: "invalid LABEL",
+		},
+		{
+			name: "encrypted lease set with PSK clients",
+			options: map[string]string{
+				"i2cp.leaseSetType":         "5",
+				"i2cp.leaseSetSecret":       "hunter2",
+				"i2cp.leaseSetClient.psk.0": "alice:" + strings.Repeat("A", 43) + "=",
+				"i2cp.leaseSetClient.psk.1": strings.Repeat("B", 43) + "=",
+			},
+			style: session.StyleStream,
+			check: func(c *session.SessionConfig) bool {
+				return c.LeaseSetType == session.LeaseSetTypeEncrypted &&
+					c.LeaseSetAuthType == session.LeaseSetAuthPSK &&
+					c.LeaseSetSecret == "hunter2" &&
+					len(c.LeaseSetClients) == 2 &&
+					c.LeaseSetClients[0].Name == "alice" &&
+					c.LeaseSetClients[1].Name == "1" &&
+					c.I2CPOptions["i2cp.leaseSetSecret"] == "" &&
+					c.I2CPOptions["i2cp.leaseSetType"] == "5"
+			},
+		},
+		{
+			name: "lease set client does not match auth type",
+			options: map[string]string{
+				"i2cp.leaseSetType":         "5",
+				"i2cp.leaseSetAuthType":     "1",
+				"i2cp.leaseSetClient.psk.0": strings.Repeat("A", 43) + "=",
+			},
+			style:     session.StyleStream,
+			wantErr:   true,
+			errSubstr: "does not match i2cp.leaseSetAuthType",
+		},
+		{
+			name: "non-integer lease set type",
+			options: map[string]string{
+				"i2cp.leaseSetType": "encrypted",
+			},
+			style:     session.StyleStream,
+			wantErr:   true,
+			errSubstr: "invalid i2cp.leaseSetType",
+		},
 	}
 
 	for _, tt := range tests {
@@ -1933,6 +2135,169 @@ func TestParseSubsessionOptions(t *testing.T) {
 	}
 }
 
+// TestSessionHandler_SessionDefaults tests that configured defaults apply
+// to options the command does not specify.
+func TestSessionHandler_SessionDefaults(t *testing.T) {
+	defaults := session.DefaultSessionConfig()
+	defaults.InboundQuantity = 5
+	defaults.OutboundQuantity = 4
+	defaults.InboundBackupQuantity = 1
+	defaults.CloseIdleTime = 300
+
+	handler := NewSessionHandler(nil)
+	handler.SetSessionDefaults(defaults)
+	defaults.InboundQuantity = 9 // must not affect the handler
+
+	cmd := &protocol.Command{
+		Verb:   "SESSION",
+		Action: "CREATE",
+		Options: map[string]string{
+			"outbound.quantity":      "2",
+			"inbound.backupQuantity": "0",
+			"i2cp.closeOnIdle":       "false",
+		},
+	}
+	config, err := handler.parseConfig(cmd, session.StyleStream)
+	if err != nil {
+		t.Fatalf("parseConfig() error = %v", err)
+	}
+
+	if config.InboundQuantity != 5 {
+		t.Errorf("InboundQuantity = %d, want default 5", config.InboundQuantity)
+	}
+	if config.OutboundQuantity != 2 {
+		t.Errorf("OutboundQuantity = %d, want command value 2", config.OutboundQuantity)
+	}
+	if config.InboundBackupQuantity != 0 {
+		t.Errorf("InboundBackupQuantity = %d, want command value 0", config.InboundBackupQuantity)
+	}
+	if config.CloseIdleTime != 0 {
+		t.Errorf("CloseIdleTime = %d, want 0 after i2cp.closeOnIdle=false", config.CloseIdleTime)
+	}
+	if config.I2CPOptions["inbound.backupQuantity"] != "0" {
+		t.Error("inbound.backupQuantity should still be passed through to I2CP")
+	}
+
+	// A second command must start from the defaults again.
+	config, _ = handler.parseConfig(&protocol.Command{Verb: "SESSION", Action: "CREATE"}, session.StyleStream)
+	if config.OutboundQuantity != 4 || config.InboundBackupQuantity != 1 || config.CloseIdleTime != 300 {
+		t.Errorf("second config = %+v, want unmodified defaults", config)
+	}
+
+	handler.SetSessionDefaults(nil)
+	config, _ = handler.parseConfig(&protocol.Command{Verb: "SESSION", Action: "CREATE"}, session.StyleStream)
+	if config.InboundQuantity != session.DefaultTunnelQuantity {
+		t.Errorf("InboundQuantity = %d, want built-in default after SetSessionDefaults(nil)", config.InboundQuantity)
+	}
+}
+
+// TestSessionHandler_HandleStats tests the SESSION STATS extension command.
+func TestSessionHandler_HandleStats(t *testing.T) {
+	sess := session.NewBaseSession("stats-1", session.StyleDatagram, nil, nil, nil)
+	sess.Stats().AddDatagramSent(100)
+	sess.Stats().AddDatagramReceived(40)
+	sess.Stats().AddStream()
+
+	registry := newMockRegistry()
+	registry.sessions["stats-1"] = sess
+
+	tests := []struct {
+		name       string
+		options    map[string]string
+		ctx        *Context
+		wantResult string
+		wantOpts   []string
+	}{
+		{
+			name:       "handshake not complete",
+			options:    map[string]string{"ID": "stats-1"},
+			ctx:        &Context{Registry: registry},
+			wantResult: protocol.ResultI2PError,
+		},
+		{
+			name:       "by ID",
+			options:    map[string]string{"ID": "stats-1"},
+			ctx:        &Context{HandshakeComplete: true, Registry: registry},
+			wantResult: protocol.ResultOK,
+			wantOpts: []string{
+				"ID=stats-1", "BYTES_SENT=100", "BYTES_RECEIVED=40",
+				"STREAMS=1", "DATAGRAMS_SENT=1", "DATAGRAMS_RECEIVED=1",
+				"MTU=31744", "UPTIME=0",
+			},
+		},
+		{
+			name:       "defaults to bound session",
+			options:    map[string]string{},
+			ctx:        &Context{HandshakeComplete: true, Registry: registry, Session: sess},
+			wantResult: protocol.ResultOK,
+			wantOpts:   []string{"ID=stats-1", "BYTES_SENT=100"},
+		},
+		{
+			name:       "missing ID",
+			options:    map[string]string{},
+			ctx:        &Context{HandshakeComplete: true, Registry: registry},
+			wantResult: protocol.ResultI2PError,
+		},
+		{
+			name:       "unknown ID",
+			options:    map[string]string{"ID": "nope"},
+			ctx:        &Context{HandshakeComplete: true, Registry: registry},
+			wantResult: protocol.ResultInvalidID,
+		},
+	}
+
+	handler := NewSessionHandler(nil)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd := &protocol.Command{Verb: "SESSION", Action: "STATS", Options: tt.options}
+			resp, err := handler.Handle(tt.ctx, cmd)
+			if err != nil {
+				t.Fatalf("Handle() error = %v", err)
+			}
+			got := resp.String()
+			if !strings.Contains(got, "RESULT="+tt.wantResult) {
+				t.Errorf("Handle() = %q, want RESULT=%s", got, tt.wantResult)
+			}
+			for _, opt := range tt.wantOpts {
+				if !strings.Contains(got, opt) {
+					t.Errorf("Handle() = %q, want %s", got, opt)
+				}
+			}
+		})
+	}
+}
+
+// TestSessionHandler_HandleList tests the SESSION LIST extension command.
+func TestSessionHandler_HandleList(t *testing.T) {
+	cfg := session.DefaultSessionConfig()
+	cfg.Label = "my app"
+	registry := newMockRegistry()
+	registry.sessions["b"] = session.NewBaseSession("b", session.StyleStream, nil, nil, cfg)
+	registry.sessions["a"] = session.NewBaseSession("a", session.StyleRaw, nil, nil, nil)
+
+	handler := NewSessionHandler(nil)
+	cmd := &protocol.Command{Verb: "SESSION", Action: "LIST"}
+
+	resp, err := handler.Handle(&Context{Registry: registry}, cmd)
+	if err != nil {
+		t.Fatalf("Handle() error = %v", err)
+	}
+	if got := resp.String(); !strings.Contains(got, "RESULT=I2P_ERROR") {
+		t.Errorf("Handle() before handshake = %q, want RESULT=I2P_ERROR", got)
+	}
+
+	resp, err = handler.Handle(&Context{HandshakeComplete: true, Registry: registry}, cmd)
+	if err != nil {
+		t.Fatalf("Handle() error = %v", err)
+	}
+	want := "SESSION STATUS RESULT=OK COUNT=2\n" +
+		"SESSION INFO ID=a STYLE=RAW\n" +
+		"SESSION INFO ID=b STYLE=STREAM LABEL=\"my app\"\n"
+	if got := resp.FullString(); got != want {
+		t.Errorf("Handle() = %q, want %q", got, want)
+	}
+}
+
 // TestSessionHandler_UnknownAction tests unknown SESSION action handling.
 func TestSessionHandler_UnknownAction(t *testing.T) {
 	handler := NewSessionHandler(nil)
@@ -1957,3 +2322,264 @@ func TestSessionHandler_UnknownAction(t *testing.T) {
 		t.Errorf("Handle() = %q, want 'unknown SESSION action' in message", got)
 	}
 }
+
+func TestSessionHandler_FileDestination(t *testing.T) {
+	// A pass-through KMS keeps the test fast; the encryption itself is
+	// covered in lib/destination.
+	kms := destination.KMSWrapper{
+		Encrypt: func(k []byte) ([]byte, error) { return k, nil },
+		Decrypt: func(k []byte) ([]byte, error) { return k, nil },
+	}
+	store := destination.NewKeyStore(t.TempDir(), kms)
+
+	create := func(h *SessionHandler, id, dest string) *protocol.Response {
+		t.Helper()
+		ctx := NewContext(&mockConn{}, newMockRegistry())
+		ctx.HandshakeComplete = true
+		cmd := &protocol.Command{
+			Verb:   "SESSION",
+			Action: "CREATE",
+			Options: map[string]string{
+				"STYLE":       "STREAM",
+				"ID":          id,
+				"DESTINATION": dest,
+			},
+		}
+		resp, err := h.Handle(ctx, cmd)
+		if err != nil {
+			t.Fatalf("Handle() error = %v", err)
+		}
+		return resp
+	}
+
+	t.Run("no key store", func(t *testing.T) {
+		h := NewSessionHandler(destination.NewManager())
+		resp := create(h, "s1", "file:site")
+		if !strings.Contains(resp.String(), "RESULT="+protocol.ResultInvalidKey) {
+			t.Errorf("Handle() = %q, want RESULT=INVALID_KEY", resp.String())
+		}
+	})
+
+	t.Run("generate then load", func(t *testing.T) {
+		h := NewSessionHandler(destination.NewManager())
+		h.SetKeyStore(store)
+
+		first, err := protocol.NewParser().Parse(create(h, "s1", "file:site").String())
+		if err != nil {
+			t.Fatalf("Parse() error = %v", err)
+		}
+		if first.Get("RESULT") != protocol.ResultOK {
+			t.Fatalf("first SESSION CREATE = %v, want RESULT=OK", first.Options)
+		}
+		stored, err := store.Load("site")
+		if err != nil {
+			t.Fatalf("Load() error = %v", err)
+		}
+		if got := destination.Base64Encode(stored); got != first.Get("DESTINATION") {
+			t.Errorf("stored key = %q, want the returned DESTINATION", got)
+		}
+
+		second, err := protocol.NewParser().Parse(create(h, "s2", "file:site").String())
+		if err != nil {
+			t.Fatalf("Parse() error = %v", err)
+		}
+		if second.Get("RESULT") != protocol.ResultOK || second.Get("DESTINATION") != first.Get("DESTINATION") {
+			t.Errorf("second SESSION CREATE = %v, want the stored DESTINATION", second.Options)
+		}
+	})
+
+	t.Run("invalid name", func(t *testing.T) {
+		h := NewSessionHandler(destination.NewManager())
+		h.SetKeyStore(store)
+		resp := create(h, "s1", "file:../site")
+		if !strings.Contains(resp.String(), "RESULT="+protocol.ResultInvalidKey) {
+			t.Errorf("Handle() = %q, want RESULT=INVALID_KEY", resp.String())
+		}
+	})
+}
+
+func TestSessionHandler_RejectsMismatchedKeys(t *testing.T) {
+	m := destination.NewManager()
+	dest, priv, err := m.Generate(destination.SigTypeEd25519)
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+	other, _, err := m.Generate(destination.SigTypeEd25519)
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+
+	for name, tt := range map[string]struct {
+		dest *commondest.Destination
+		want string
+	}{
+		"own keys":   {dest, protocol.ResultOK},
+		"other keys": {other, protocol.ResultInvalidKey},
+	} {
+		t.Run(name, func(t *testing.T) {
+			encoded, err := m.Encode(tt.dest, priv)
+			if err != nil {
+				t.Fatalf("Encode() error = %v", err)
+			}
+			ctx := NewContext(&mockConn{}, newMockRegistry())
+			ctx.HandshakeComplete = true
+			cmd := &protocol.Command{
+				Verb:   "SESSION",
+				Action: "CREATE",
+				Options: map[string]string{
+					"STYLE":       "STREAM",
+					"ID":          "keys",
+					"DESTINATION": encoded,
+				},
+			}
+			resp, err := NewSessionHandler(m).Handle(ctx, cmd)
+			if err != nil {
+				t.Fatalf("Handle() error = %v", err)
+			}
+			if !strings.Contains(resp.String(), "RESULT="+tt.want) {
+				t.Errorf("Handle() = %q, want RESULT=%s", resp.String(), tt.want)
+			}
+		})
+	}
+}
+
+// disconnectedProvider is an I2CP provider whose router is not connected.
+type disconnectedProvider struct{}
+
+func (disconnectedProvider) CreateSessionForSAM(context.Context, string, *session.SessionConfig) (session.I2CPSessionHandle, error) {
+	return nil, errors.New("not connected")
+}
+
+func (disconnectedProvider) IsConnected() bool { return false }
+
+func TestSessionHandler_Create_NotConnected(t *testing.T) {
+	reg := newMockRegistry()
+	ctx := NewContext(&mockConn{}, reg)
+	ctx.HandshakeComplete = true
+	h := NewSessionHandler(destination.NewManager())
+	h.SetI2CPProvider(disconnectedProvider{})
+
+	resp, err := h.Handle(ctx, &protocol.Command{
+		Verb:    "SESSION",
+		Action:  "CREATE",
+		Options: map[string]string{"STYLE": "STREAM", "ID": "early", "DESTINATION": "TRANSIENT"},
+	})
+	if err != nil {
+		t.Fatalf("Handle() error = %v", err)
+	}
+	if !strings.Contains(resp.String(), "RESULT="+protocol.ResultI2PError) {
+		t.Errorf("Handle() = %q, want RESULT=%s", resp.String(), protocol.ResultI2PError)
+	}
+	if reg.Get("early") != nil {
+		t.Error("session registered without an I2CP connection")
+	}
+}
+
+// leaseSetHandle is an I2CP session handle with ready tunnels whose
+// lease set wait returns err.
+type leaseSetHandle struct {
+	err    error
+	waited bool
+}
+
+func (h *leaseSetHandle) WaitForTunnels(context.Context) error { return nil }
+func (h *leaseSetHandle) IsTunnelReady() bool                  { return true }
+func (h *leaseSetHandle) Close() error                         { return nil }
+func (h *leaseSetHandle) DestinationBase64() string            { return "" }
+
+func (h *leaseSetHandle) WaitForLeaseSet(context.Context) error {
+	h.waited = true
+	return h.err
+}
+
+// handleProvider is a connected I2CP provider that returns handle.
+type handleProvider struct {
+	handle session.I2CPSessionHandle
+}
+
+func (p handleProvider) CreateSessionForSAM(context.Context, string, *session.SessionConfig) (session.I2CPSessionHandle, error) {
+	return p.handle, nil
+}
+
+func (handleProvider) IsConnected() bool { return true }
+
+func TestSessionHandler_Create_WaitsForLeaseSet(t *testing.T) {
+	for name, tt := range map[string]struct {
+		err  error
+		want string
+	}{
+		"published":     {nil, protocol.ResultOK},
+		"not published": {context.DeadlineExceeded, protocol.ResultI2PError},
+	} {
+		t.Run(name, func(t *testing.T) {
+			reg := newMockRegistry()
+			ctx := NewContext(&mockConn{}, reg)
+			ctx.HandshakeComplete = true
+			handle := &leaseSetHandle{err: tt.err}
+			h := NewSessionHandler(destination.NewManager())
+			h.SetI2CPProvider(handleProvider{handle: handle})
+
+			resp, err := h.Handle(ctx, &protocol.Command{
+				Verb:    "SESSION",
+				Action:  "CREATE",
+				Options: map[string]string{"STYLE": "STREAM", "ID": "ls", "DESTINATION": "TRANSIENT"},
+			})
+			if err != nil {
+				t.Fatalf("Handle() error = %v", err)
+			}
+			if !handle.waited {
+				t.Error("SESSION CREATE did not wait for the lease set")
+			}
+			if !strings.Contains(resp.String(), "RESULT="+tt.want) {
+				t.Errorf("Handle() = %q, want RESULT=%s", resp.String(), tt.want)
+			}
+		})
+	}
+}
+
+// tunnelHandle is an I2CP handle that reports a tunnel build.
+type tunnelHandle struct {
+	leaseSetHandle
+}
+
+func (h *tunnelHandle) OnTunnelEvent(fn func(session.TunnelEvent)) {
+	fn(session.TunnelEvent{Type: session.TunnelBuilt, Time: time.Now(), Reason: "session created"})
+}
+
+func TestSessionHandler_TunnelEvents(t *testing.T) {
+	reg := newMockRegistry()
+	ctx := NewContext(&mockConn{}, reg)
+	ctx.HandshakeComplete = true
+	h := NewSessionHandler(destination.NewManager())
+	h.SetI2CPProvider(handleProvider{handle: &tunnelHandle{}})
+
+	var events []session.TunnelEvent
+	h.SetTunnelEventCallback(func(sess session.Session, ev session.TunnelEvent) {
+		if sess.ID() != "tunnels" {
+			t.Errorf("tunnel event for session %q, want tunnels", sess.ID())
+		}
+		events = append(events, ev)
+	})
+
+	resp, err := h.Handle(ctx, &protocol.Command{
+		Verb:    "SESSION",
+		Action:  "CREATE",
+		Options: map[string]string{"STYLE": "STREAM", "ID": "tunnels", "DESTINATION": "TRANSIENT"},
+	})
+	if err != nil || !strings.Contains(resp.String(), "RESULT=OK") {
+		t.Fatalf("Handle() = %v, %v; want RESULT=OK", resp, err)
+	}
+	if len(events) != 1 || events[0].Type != session.TunnelBuilt {
+		t.Errorf("tunnel events = %+v, want one BUILT", events)
+	}
+
+	resp, err = h.Handle(ctx, &protocol.Command{Verb: "SESSION", Action: "STATS"})
+	if err != nil {
+		t.Fatalf("Handle() error = %v", err)
+	}
+	for _, want := range []string{"TUNNELS=BUILT", "TUNNEL_BUILDS=1", "TUNNEL_FAILURES=0"} {
+		if !strings.Contains(resp.String(), want) {
+			t.Errorf("SESSION STATS = %q, want %s", resp.String(), want)
+		}
+	}
+}
@@ -171,18 +171,28 @@ func (h *StreamHandler) executeConnect(ctx *Context, params *connectParams) (*pr
 		return streamError("connector not available"), nil
 	}
 
+	release, err := session.ReserveStream(params.sess, session.StreamOutbound)
+	if err != nil {
+		if params.silent {
+			return nil, util.NewSilentCloseError("connect", err)
+		}
+		return streamError(err.Error()), nil
+	}
+
 	conn, err := h.Connector.Connect(params.sess, params.dest, params.fromPort, params.toPort)
 	if err != nil {
+		release()
 		if params.silent {
 			return nil, util.NewSilentCloseError("connect", err)
 		}
 		return h.connectError(err), nil
 	}
+	conn = session.ReleaseOnClose(conn, release)
 
 	// Store the I2P stream connection in context for data forwarding.
 	// Per SAMv3.md: "all remaining data passing through the current socket
 	// is forwarded from and to the connected I2P destination peer."
-	ctx.SetStreamConn(conn)
+	ctx.SetStreamConn(session.LimitStream(params.sess, session.TrackStream(params.sess, conn)))
 
 	if params.silent {
 		return nil, nil
@@ -277,16 +287,28 @@ func (h *StreamHandler) executeAccept(ctx *Context, sess session.Session, silent
 		return streamError("acceptor not available"), nil
 	}
 
+	// A pending ACCEPT holds an inbound stream slot, so a session at its
+	// limit fails fast instead of accepting a stream it must drop
+	release, err := session.ReserveStream(sess, session.StreamInbound)
+	if err != nil {
+		if silent {
+			return nil, util.NewSilentCloseError("accept", err)
+		}
+		return streamError(err.Error()), nil
+	}
+
 	conn, info, err := h.Acceptor.Accept(sess)
 	if err != nil {
+		release()
 		if silent {
 			return nil, util.NewSilentCloseError("accept", err)
 		}
 		return streamError(err.Error()), nil
 	}
+	conn = session.ReleaseOnClose(conn, release)
 
 	// Store the I2P stream connection for forwarding
-	ctx.StreamConn = conn
+	ctx.StreamConn = session.LimitStream(sess, session.TrackStream(sess, conn))
 
 	if silent {
 		return nil, nil
@@ -489,9 +511,7 @@ func extractHost(addr string) string {
 
 // streamOK returns a successful STREAM STATUS response.
 func streamOK() *protocol.Response {
-	return protocol.NewResponse(protocol.VerbStream).
-		WithAction(protocol.ActionStatus).
-		WithResult(protocol.ResultOK)
+	return protocol.StreamStatusOK()
 }
 
 // streamInvalidID returns an INVALID_ID error response.
@@ -8,12 +8,14 @@ import (
 	"context"
 	"crypto/tls"
 	"fmt"
-	"io"
 	"net"
+	"sort"
+	"strconv"
 	"sync"
 	"time"
 
 	"github.com/go-i2p/go-sam-bridge/lib/session"
+	"github.com/go-i2p/go-sam-bridge/lib/util"
 )
 
 // StreamingConnector implements StreamConnector using go-streaming.
@@ -152,6 +154,11 @@ type StreamingAcceptor struct {
 
 	// defaultMTU is the default MTU for listeners.
 	defaultMTU int
+
+	// backlog is the number of inbound streams held for the next
+	// ACCEPT, each for up to backlogTimeout (0 = no backlog).
+	backlog        int
+	backlogTimeout time.Duration
 }
 
 // NewStreamingAcceptor creates a new StreamingAcceptor.
@@ -176,11 +183,60 @@ func (a *StreamingAcceptor) RegisterManager(sessionID string, manager StreamMana
 	if err != nil {
 		return fmt.Errorf("failed to create listener: %w", err)
 	}
+	if a.backlog > 0 {
+		listener = newBacklogListener(listener, a.backlog, a.backlogTimeout)
+	}
 	a.listeners[sessionID] = listener
 
 	return nil
 }
 
+// RegisterPrimary registers the StreamManager of a PRIMARY session. Its
+// inbound streams are routed with primary.Route to the STREAM subsession
+// listening on their destination port, where STREAM ACCEPT on that
+// subsession picks them up. A routed stream waits for an ACCEPT as long
+// as the accept backlog timeout, or DefaultAcceptBacklogTimeout.
+// UnregisterManager with the PRIMARY session's ID stops the routing.
+func (a *StreamingAcceptor) RegisterPrimary(primary session.PrimarySession, manager StreamManager) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	mtu := a.defaultMTU
+	listener, err := newPrimaryListener(primary, func(port uint16) (net.Listener, error) {
+		return manager.Listen(port, mtu)
+	}, a.backlogTimeout)
+	if err != nil {
+		return fmt.Errorf("failed to create listener: %w", err)
+	}
+	a.managers[primary.ID()] = manager
+	a.listeners[primary.ID()] = listener
+	return nil
+}
+
+// primaryListenerFor returns the listener of the PRIMARY session that
+// sess is a subsession of, or nil. a.mu must be held.
+func (a *StreamingAcceptor) primaryListenerFor(sess session.Session) *primaryListener {
+	for _, l := range a.listeners {
+		if p, ok := l.(*primaryListener); ok && p.primary.Subsession(sess.ID()) == sess {
+			return p
+		}
+	}
+	return nil
+}
+
+// SetAcceptBacklog makes sessions registered afterwards accept inbound
+// streams as soon as they arrive and hold up to size of them for the
+// next STREAM ACCEPT, each for at most timeout, or
+// DefaultAcceptBacklogTimeout if timeout is zero. Streams arriving while
+// the backlog is full are closed. Zero size, the default, leaves streams
+// to the streaming library's own queue.
+func (a *StreamingAcceptor) SetAcceptBacklog(size int, timeout time.Duration) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.backlog = size
+	a.backlogTimeout = timeout
+}
+
 // UnregisterManager removes a StreamManager for a session.
 func (a *StreamingAcceptor) UnregisterManager(sessionID string) {
 	a.mu.Lock()
@@ -200,9 +256,13 @@ func (a *StreamingAcceptor) UnregisterManager(sessionID string) {
 func (a *StreamingAcceptor) Accept(sess session.Session) (net.Conn, *AcceptInfo, error) {
 	a.mu.RLock()
 	listener, ok := a.listeners[sess.ID()]
+	var primary *primaryListener
+	if !ok {
+		primary = a.primaryListenerFor(sess)
+	}
 	a.mu.RUnlock()
 
-	if !ok || listener == nil {
+	if (!ok || listener == nil) && primary == nil {
 		return nil, nil, fmt.Errorf("no listener for session %s", sess.ID())
 	}
 
@@ -210,15 +270,19 @@ func (a *StreamingAcceptor) Accept(sess session.Session) (net.Conn, *AcceptInfo,
 	var conn net.Conn
 	var err error
 
-	if a.acceptTimeout > 0 {
-		// Use a deadline for timeout
-		if dl, ok := listener.(interface{ SetDeadline(time.Time) error }); ok {
-			dl.SetDeadline(time.Now().Add(a.acceptTimeout))
-			defer dl.SetDeadline(time.Time{})
+	if primary != nil {
+		// Streams to a subsession arrive through its PRIMARY's listener
+		conn, err = primary.acceptFor(sess)
+	} else {
+		if a.acceptTimeout > 0 {
+			// Use a deadline for timeout
+			if dl, ok := listener.(interface{ SetDeadline(time.Time) error }); ok {
+				dl.SetDeadline(time.Now().Add(a.acceptTimeout))
+				defer dl.SetDeadline(time.Time{})
+			}
 		}
+		conn, err = listener.Accept()
 	}
-
-	conn, err = listener.Accept()
 	if err != nil {
 		return nil, nil, fmt.Errorf("accept failed: %w", err)
 	}
@@ -251,10 +315,23 @@ type StreamingForwarder struct {
 
 	// managers maps session ID to stream manager.
 	managers map[string]StreamManager
+
+	// onError receives forwarding failures if non-nil.
+	onError func(error)
+
+	// sockopts is applied to connections to local targets.
+	sockopts util.SocketOptions
+
+	// retry controls reconnecting to local targets that refuse.
+	retry ForwardRetry
+
+	// stats counts connections to local targets.
+	stats *ForwardStats
 }
 
 // forwardState tracks the state of a forwarding listener.
 type forwardState struct {
+	sess       session.Session
 	listener   net.Listener
 	targetHost string
 	targetPort int
@@ -267,9 +344,99 @@ func NewStreamingForwarder() *StreamingForwarder {
 	return &StreamingForwarder{
 		forwarders: make(map[string]*forwardState),
 		managers:   make(map[string]StreamManager),
+		retry:      DefaultForwardRetry,
+		stats:      &ForwardStats{},
 	}
 }
 
+// SetErrorHandler sets a function that receives forwarding failures:
+// inbound streams dropped because every connection attempt to the local
+// target failed, and forwarding goroutines that panic. Failures are
+// otherwise silent per SAM spec.
+func (f *StreamingForwarder) SetErrorHandler(fn func(error)) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.onError = fn
+}
+
+// SetSocketOptions sets the TCP options applied to connections to local
+// forwarding targets, such as a keepalive period that keeps long idle
+// streams alive through NATs.
+func (f *StreamingForwarder) SetSocketOptions(opts util.SocketOptions) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.sockopts = opts
+}
+
+// SetForwardRetry sets how connections to local targets are retried.
+// The zero ForwardRetry restores DefaultForwardRetry.
+func (f *StreamingForwarder) SetForwardRetry(r ForwardRetry) {
+	if r.IsZero() {
+		r = DefaultForwardRetry
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.retry = r
+}
+
+// SetStats makes the forwarder count into s, so that several forwarders
+// can share one set of counters. Nil is ignored.
+func (f *StreamingForwarder) SetStats(s *ForwardStats) {
+	if s == nil {
+		return
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.stats = s
+}
+
+// Stats returns the forwarder's connection counters.
+func (f *StreamingForwarder) Stats() *ForwardStats {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	return f.stats
+}
+
+// ForwardInfo describes an active STREAM FORWARD.
+type ForwardInfo struct {
+	SessionID string
+	Host      string
+	Port      int
+	SSL       bool
+}
+
+// Target returns the forward's local target as host:port.
+func (i ForwardInfo) Target() string {
+	return net.JoinHostPort(i.Host, strconv.Itoa(i.Port))
+}
+
+// Forwards returns the active forwards, sorted by session ID.
+func (f *StreamingForwarder) Forwards() []ForwardInfo {
+	f.mu.RLock()
+	infos := make([]ForwardInfo, 0, len(f.forwarders))
+	for id, state := range f.forwarders {
+		infos = append(infos, ForwardInfo{SessionID: id, Host: state.targetHost, Port: state.targetPort, SSL: state.ssl})
+	}
+	f.mu.RUnlock()
+	sort.Slice(infos, func(i, j int) bool { return infos[i].SessionID < infos[j].SessionID })
+	return infos
+}
+
+// reportError passes err to the error handler, if one is set, as a
+// SessionError when it concerns a session.
+func (f *StreamingForwarder) reportError(sess session.Session, err error) {
+	f.mu.RLock()
+	fn := f.onError
+	f.mu.RUnlock()
+	if fn == nil {
+		return
+	}
+	if sess != nil {
+		err = &SessionError{Session: sess, Err: err}
+	}
+	fn(err)
+}
+
 // RegisterManager registers a StreamManager for a session.
 func (f *StreamingForwarder) RegisterManager(sessionID string, manager StreamManager) {
 	f.mu.Lock()
@@ -317,6 +484,7 @@ func (f *StreamingForwarder) Forward(sess session.Session, host string, port int
 	ctx, cancel := context.WithCancel(context.Background())
 
 	state := &forwardState{
+		sess:       sess,
 		listener:   listener,
 		targetHost: host,
 		targetPort: port,
@@ -357,36 +525,71 @@ func (f *StreamingForwarder) forwardLoop(ctx context.Context, state *forwardStat
 // handleForward handles a single forwarded connection.
 func (f *StreamingForwarder) handleForward(ctx context.Context, i2pConn net.Conn, state *forwardState) {
 	defer i2pConn.Close()
+	if state.sess != nil {
+		// Streams beyond the session's inbound limit are dropped silently,
+		// as the peer would see a failed connection to the target
+		release, err := session.ReserveStream(state.sess, session.StreamInbound)
+		if err != nil {
+			return
+		}
+		defer release()
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			f.reportError(state.sess, fmt.Errorf("forward to %s panicked: %v", net.JoinHostPort(state.targetHost, strconv.Itoa(state.targetPort)), r))
+		}
+	}()
 
-	// Connect to local target
-	addr := fmt.Sprintf("%s:%d", state.targetHost, state.targetPort)
-	var localConn net.Conn
-	var err error
+	f.mu.RLock()
+	sockopts, retry, stats := f.sockopts, f.retry, f.stats
+	f.mu.RUnlock()
 
-	if state.ssl {
-		// Use TLS for local connection per SAM 3.2+ SSL option
-		localConn, err = tls.Dial("tcp", addr, &tls.Config{
-			InsecureSkipVerify: true, // Local connection, often self-signed
-		})
-	} else {
-		localConn, err = net.Dial("tcp", addr)
-	}
+	// Connect to local target, retrying while it is briefly down. The
+	// inbound stream stays open meanwhile.
+	addr := net.JoinHostPort(state.targetHost, strconv.Itoa(state.targetPort))
+	var localConn net.Conn
+	err := retry.do(ctx, func() error {
+		var err error
+		if state.ssl {
+			// Use TLS for local connection per SAM 3.2+ SSL option
+			localConn, err = tls.Dial("tcp", addr, &tls.Config{
+				InsecureSkipVerify: true, // Local connection, often self-signed
+			})
+		} else {
+			localConn, err = net.Dial("tcp", addr)
+		}
+		if err != nil {
+			stats.dialErrors.Add(1)
+		}
+		return err
+	})
 
 	if err != nil {
-		return // Silent failure per SAM spec
+		// Silent to the client per SAM spec
+		stats.failures.Add(1)
+		f.reportError(state.sess, fmt.Errorf("forward to %s: %w", addr, err))
+		return
 	}
 	defer localConn.Close()
+	stats.forwarded.Add(1)
+	if err := sockopts.Apply(localConn); err != nil {
+		f.reportError(state.sess, fmt.Errorf("forward to %s: socket options: %w", addr, err))
+	}
+
+	if state.sess != nil {
+		i2pConn = session.LimitStream(state.sess, session.TrackStream(state.sess, i2pConn))
+	}
 
 	// Bidirectional copy
 	done := make(chan struct{}, 2)
 
 	go func() {
-		io.Copy(localConn, i2pConn)
+		util.Copy(localConn, i2pConn, 0)
 		done <- struct{}{}
 	}()
 
 	go func() {
-		io.Copy(i2pConn, localConn)
+		util.Copy(i2pConn, localConn, 0)
 		done <- struct{}{}
 	}()
 
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"net"
 	"testing"
+	"time"
 
 	"github.com/go-i2p/go-sam-bridge/lib/session"
 )
@@ -289,6 +290,103 @@ func TestStreamingForwarder_Forward(t *testing.T) {
 	})
 }
 
+// TestStreamingForwarder_ErrorHandler tests that failed local connections
+// are reported to the error handler, naming their session.
+func TestStreamingForwarder_ErrorHandler(t *testing.T) {
+	// Find a port with nothing listening on it
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("net.Listen() error = %v", err)
+	}
+	port := ln.Addr().(*net.TCPAddr).Port
+	ln.Close()
+
+	forwarder := NewStreamingForwarder()
+	forwarder.SetForwardRetry(ForwardRetry{Attempts: 2, Backoff: time.Millisecond})
+	var reported error
+	forwarder.SetErrorHandler(func(err error) { reported = err })
+
+	i2pConn, remote := net.Pipe()
+	defer remote.Close()
+	sess := session.NewBaseSession("fwd", session.StyleStream, nil, nil, nil)
+	state := &forwardState{sess: sess, targetHost: "127.0.0.1", targetPort: port}
+	forwarder.handleForward(context.Background(), i2pConn, state)
+
+	if reported == nil {
+		t.Fatal("expected error handler to receive dial failure")
+	}
+	var opErr *net.OpError
+	if !errors.As(reported, &opErr) {
+		t.Errorf("reported error = %v, want wrapped *net.OpError", reported)
+	}
+	var sessErr *SessionError
+	if !errors.As(reported, &sessErr) || sessErr.Session != sess {
+		t.Errorf("reported error = %v, want a SessionError for session fwd", reported)
+	}
+	if got := forwarder.Stats().Snapshot(); got != (ForwardStatsSnapshot{DialErrors: 2, Failures: 1}) {
+		t.Errorf("Stats() = %+v, want 2 dial errors and 1 failure", got)
+	}
+}
+
+// TestStreamingForwarder_Retry tests that a target coming up while the
+// forwarder backs off receives the stream.
+func TestStreamingForwarder_Retry(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("net.Listen() error = %v", err)
+	}
+	addr := ln.Addr().String()
+	port := ln.Addr().(*net.TCPAddr).Port
+	ln.Close()
+
+	// Restart the target after the first attempt has failed
+	accepted := make(chan net.Conn, 1)
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		ln, err := net.Listen("tcp", addr)
+		if err != nil {
+			close(accepted)
+			return
+		}
+		defer ln.Close()
+		conn, err := ln.Accept()
+		if err != nil {
+			close(accepted)
+			return
+		}
+		accepted <- conn
+	}()
+
+	forwarder := NewStreamingForwarder()
+	forwarder.SetForwardRetry(ForwardRetry{Attempts: 20, Backoff: 20 * time.Millisecond, MaxBackoff: 50 * time.Millisecond})
+	var reported error
+	forwarder.SetErrorHandler(func(err error) { reported = err })
+
+	i2pConn, remote := net.Pipe()
+	state := &forwardState{targetHost: "127.0.0.1", targetPort: port}
+	done := make(chan struct{})
+	go func() {
+		forwarder.handleForward(context.Background(), i2pConn, state)
+		close(done)
+	}()
+
+	conn, ok := <-accepted
+	if !ok {
+		t.Fatal("target never received the forwarded stream")
+	}
+	conn.Close()
+	remote.Close()
+	<-done
+
+	if reported != nil {
+		t.Errorf("reported error = %v, want none", reported)
+	}
+	stats := forwarder.Stats().Snapshot()
+	if stats.Forwarded != 1 || stats.DialErrors == 0 || stats.Failures != 0 {
+		t.Errorf("Stats() = %+v, want 1 forwarded after dial errors", stats)
+	}
+}
+
 // TestIsHostnameOrB32 tests the hostname/b32 detection.
 func TestIsHostnameOrB32(t *testing.T) {
 	tests := []struct {
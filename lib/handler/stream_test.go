@@ -1205,3 +1205,49 @@ func TestStreamHandler_AlreadyAccepting(t *testing.T) {
 		}
 	})
 }
+
+func TestStreamHandler_StreamLimits(t *testing.T) {
+	cfg := session.DefaultSessionConfig()
+	cfg.MaxOutboundStreams = 1
+	cfg.MaxInboundStreams = 1
+	sess := session.NewBaseSession("limited", session.StyleStream, nil, nil, cfg)
+	registry := newMockStreamRegistry()
+	registry.Register(sess)
+
+	handler := NewStreamHandler(
+		&mockStreamConnector{conn: &mockConn{}},
+		&mockStreamAcceptor{conn: &mockConn{}, info: &AcceptInfo{Destination: "AAAA"}},
+		nil,
+	)
+	newCtx := func() *Context {
+		ctx := NewContext(nil, registry)
+		ctx.HandshakeComplete = true
+		ctx.Version = "3.3"
+		return ctx
+	}
+
+	for _, action := range []string{"CONNECT", "ACCEPT"} {
+		t.Run(action, func(t *testing.T) {
+			cmd := &protocol.Command{Verb: "STREAM", Action: action, Options: map[string]string{
+				"ID":          "limited",
+				"DESTINATION": "AAAA...",
+			}}
+
+			first := newCtx()
+			if resp, _ := handler.Handle(first, cmd); resp == nil || !strings.Contains(resp.String(), "RESULT=OK") {
+				t.Fatalf("first %s = %v, want RESULT=OK", action, resp)
+			}
+
+			resp, _ := handler.Handle(newCtx(), cmd)
+			if resp == nil || !strings.Contains(resp.String(), "RESULT=I2P_ERROR") || !strings.Contains(resp.String(), "limit") {
+				t.Errorf("second %s = %v, want I2P_ERROR with a limit message", action, resp)
+			}
+
+			// Closing the first stream frees its slot
+			first.StreamConn.Close()
+			if resp, _ := handler.Handle(newCtx(), cmd); resp == nil || !strings.Contains(resp.String(), "RESULT=OK") {
+				t.Errorf("%s after close = %v, want RESULT=OK", action, resp)
+			}
+		})
+	}
+}
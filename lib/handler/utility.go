@@ -35,6 +35,9 @@ func NewUtilityHandler() *UtilityHandler {
 func (h *UtilityHandler) Handle(ctx *Context, cmd *protocol.Command) (*protocol.Response, error) {
 	// Close bound session if any
 	if ctx != nil && ctx.Session != nil {
+		if ctx.Registry != nil {
+			_ = ctx.Registry.Unregister(ctx.Session.ID())
+		}
 		ctx.Session.Close()
 		ctx.UnbindSession()
 	}
@@ -76,6 +79,8 @@ var samCommands = []string{
 	"SESSION CREATE",
 	"SESSION ADD",
 	"SESSION REMOVE",
+	"SESSION STATS",
+	"SESSION LIST",
 	"STREAM CONNECT",
 	"STREAM ACCEPT",
 	"STREAM FORWARD",
@@ -89,6 +94,7 @@ var samCommands = []string{
 	"AUTH LIST",
 	"AUTH ENABLE",
 	"AUTH DISABLE",
+	"DUMP",
 	"QUIT",
 	"STOP",
 	"EXIT",
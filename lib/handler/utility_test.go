@@ -44,10 +44,12 @@ func TestUtilityHandler_Handle_QUIT(t *testing.T) {
 
 			var ctx *Context
 			var mockSess *mockUtilitySession
+			registry := newMockRegistry()
 
 			if tt.hasSession {
 				mockSess = &mockUtilitySession{id: "test-session"}
-				ctx = &Context{Session: mockSess}
+				registry.sessions[mockSess.id] = mockSess
+				ctx = &Context{Session: mockSess, Registry: registry}
 			} else {
 				ctx = &Context{}
 			}
@@ -85,6 +87,9 @@ func TestUtilityHandler_Handle_QUIT(t *testing.T) {
 			if tt.hasSession && !mockSess.closed {
 				t.Error("session was not closed")
 			}
+			if tt.hasSession && registry.Get(mockSess.id) != nil {
+				t.Error("session was not unregistered")
+			}
 		})
 	}
 }
@@ -157,6 +162,8 @@ func TestHelpHandler_Handle(t *testing.T) {
 		"SESSION CREATE",
 		"SESSION ADD",
 		"SESSION REMOVE",
+		"SESSION STATS",
+		"SESSION LIST",
 		"STREAM CONNECT",
 		"STREAM ACCEPT",
 		"STREAM FORWARD",
@@ -190,6 +197,8 @@ func TestSamCommandsCompleteness(t *testing.T) {
 		"SESSION CREATE",
 		"SESSION ADD",
 		"SESSION REMOVE",
+		"SESSION STATS",
+		"SESSION LIST",
 		"STREAM CONNECT",
 		"STREAM ACCEPT",
 		"STREAM FORWARD",
@@ -203,6 +212,7 @@ func TestSamCommandsCompleteness(t *testing.T) {
 		"AUTH LIST",
 		"AUTH ENABLE",
 		"AUTH DISABLE",
+		"DUMP",
 		"QUIT",
 		"STOP",
 		"EXIT",
@@ -0,0 +1,354 @@
+// Package httpproxy implements an HTTP proxy frontend that lets browsers
+// and other HTTP clients reach I2P hosts. It tunnels CONNECT requests and
+// forwards requests with an absolute URI, such as "GET http://example.i2p/",
+// to .i2p and .b32.i2p hosts through a DialFunc, which the embedding
+// package backs with a shared SAM STREAM session. Requests for clearnet
+// hosts are rejected with 403 Forbidden unless an outproxy is configured.
+package httpproxy
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/go-i2p/go-sam-bridge/lib/socks"
+	"github.com/go-i2p/go-sam-bridge/lib/util"
+)
+
+// DefaultReadHeaderTimeout bounds how long a client may take to send the
+// headers of each request.
+const DefaultReadHeaderTimeout = 30 * time.Second
+
+// ErrClearnetHost is returned for requests to hosts outside .i2p when no
+// outproxy is configured.
+var ErrClearnetHost = errors.New("httpproxy: clearnet hosts are not allowed without an outproxy")
+
+// DialFunc opens a stream to port on the I2P host, which is a .i2p or
+// .b32.i2p hostname. The context is cancelled when the request is
+// abandoned or the proxy closes.
+type DialFunc func(ctx context.Context, host string, port int) (net.Conn, error)
+
+// Server is an HTTP proxy that connects clients to I2P hosts through a
+// DialFunc. A Server may serve several listeners at once.
+type Server struct {
+	dial DialFunc
+
+	// Outproxy, if set, is the host:port of an HTTP proxy on I2P, such as
+	// "exit.example.i2p:4444", that requests for clearnet hosts are sent
+	// through. When empty, such requests are rejected.
+	Outproxy string
+
+	// ReadHeaderTimeout bounds how long a client may take to send request
+	// headers. Zero means DefaultReadHeaderTimeout.
+	ReadHeaderTimeout time.Duration
+
+	// ErrorHandler, if set, is called with errors dialing hosts and
+	// rejected clearnet requests. It must not block.
+	ErrorHandler func(error)
+
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	direct   *http.Transport
+	outproxy *http.Transport
+
+	mu      sync.Mutex
+	servers map[*http.Server]struct{}
+	tunnels map[net.Conn]struct{}
+	closed  bool
+	wg      sync.WaitGroup
+}
+
+// NewServer creates a Server that opens streams with dial.
+func NewServer(dial DialFunc) *Server {
+	ctx, cancel := context.WithCancel(context.Background())
+	s := &Server{
+		dial:    dial,
+		ctx:     ctx,
+		cancel:  cancel,
+		servers: make(map[*http.Server]struct{}),
+		tunnels: make(map[net.Conn]struct{}),
+	}
+	s.direct = &http.Transport{DialContext: s.dialAddr}
+	s.outproxy = &http.Transport{
+		DialContext: s.dialAddr,
+		Proxy: func(*http.Request) (*url.URL, error) {
+			return &url.URL{Scheme: "http", Host: s.Outproxy}, nil
+		},
+	}
+	return s
+}
+
+// Serve accepts HTTP proxy clients on ln until ln fails or the server is
+// closed. It returns nil after Close.
+func (s *Server) Serve(ln net.Listener) error {
+	timeout := s.ReadHeaderTimeout
+	if timeout <= 0 {
+		timeout = DefaultReadHeaderTimeout
+	}
+	srv := &http.Server{
+		Handler:           s,
+		ReadHeaderTimeout: timeout,
+		BaseContext:       func(net.Listener) context.Context { return s.ctx },
+	}
+
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		ln.Close()
+		return nil
+	}
+	s.servers[srv] = struct{}{}
+	s.mu.Unlock()
+
+	err := srv.Serve(ln)
+	if errors.Is(err, http.ErrServerClosed) {
+		return nil
+	}
+	return err
+}
+
+// Close stops all listeners and closes every proxied connection,
+// including CONNECT tunnels. Safe to call multiple times.
+func (s *Server) Close() error {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return nil
+	}
+	s.closed = true
+	s.cancel()
+	for srv := range s.servers {
+		srv.Close()
+	}
+	for conn := range s.tunnels {
+		conn.Close()
+	}
+	s.mu.Unlock()
+
+	s.wg.Wait()
+	s.direct.CloseIdleConnections()
+	s.outproxy.CloseIdleConnections()
+	return nil
+}
+
+// ServeHTTP tunnels CONNECT requests and forwards requests with an
+// absolute URI. Anything else is not a proxy request and fails with 400.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !s.begin() {
+		http.Error(w, "proxy is shutting down", http.StatusServiceUnavailable)
+		return
+	}
+	defer s.wg.Done()
+
+	switch {
+	case r.Method == http.MethodConnect:
+		s.connect(w, r)
+	case r.URL.IsAbs() && r.URL.Host != "":
+		s.forward(w, r)
+	default:
+		http.Error(w, "not a proxy request", http.StatusBadRequest)
+	}
+}
+
+// begin counts a request in progress, or returns false once closed.
+func (s *Server) begin() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return false
+	}
+	s.wg.Add(1)
+	return true
+}
+
+// reportError passes err to ErrorHandler, if set.
+func (s *Server) reportError(err error) {
+	if s.ErrorHandler != nil {
+		s.ErrorHandler(err)
+	}
+}
+
+// allowed reports whether host can be reached, either directly on I2P or
+// through the outproxy. A rejected host is reported and answered with 403.
+func (s *Server) allowed(w http.ResponseWriter, host string) bool {
+	if socks.IsI2PHost(host) || s.Outproxy != "" {
+		return true
+	}
+	s.reportError(fmt.Errorf("%w: %s", ErrClearnetHost, host))
+	http.Error(w, fmt.Sprintf("%s is not an I2P host and no outproxy is configured", host), http.StatusForbidden)
+	return false
+}
+
+// connect opens a stream to the CONNECT target and relays the hijacked
+// client connection over it.
+func (s *Server) connect(w http.ResponseWriter, r *http.Request) {
+	host, port, err := splitHostPort(r.Host)
+	if err != nil {
+		http.Error(w, "invalid CONNECT target", http.StatusBadRequest)
+		return
+	}
+	if !s.allowed(w, host) {
+		return
+	}
+
+	var stream net.Conn
+	if socks.IsI2PHost(host) {
+		stream, err = s.dial(r.Context(), host, port)
+	} else {
+		stream, err = s.dialOutproxy(r.Context(), r.Host)
+	}
+	if err != nil {
+		s.reportError(fmt.Errorf("httpproxy: connect to %s: %w", r.Host, err))
+		http.Error(w, "cannot reach "+r.Host, http.StatusBadGateway)
+		return
+	}
+	defer stream.Close()
+
+	hj, ok := w.(http.Hijacker)
+	if !ok {
+		http.Error(w, "connection cannot be tunneled", http.StatusInternalServerError)
+		return
+	}
+	conn, rw, err := hj.Hijack()
+	if err != nil {
+		return
+	}
+	if !s.track(conn) {
+		conn.Close()
+		return
+	}
+	defer s.untrack(conn)
+
+	if _, err := conn.Write([]byte("HTTP/1.1 200 Connection Established\r\n\r\n")); err != nil {
+		return
+	}
+	// Pass on anything the client sent after its request, such as a TLS
+	// ClientHello, which the HTTP server may already have buffered.
+	if n := rw.Reader.Buffered(); n > 0 {
+		data, _ := rw.Reader.Peek(n)
+		if _, err := stream.Write(data); err != nil {
+			return
+		}
+	}
+	util.Relay(conn, stream)
+}
+
+// forward sends a request with an absolute URI to its host and copies
+// back the response. Hop-by-hop headers, including Proxy-Authorization,
+// are removed, and no X-Forwarded-For header is added.
+func (s *Server) forward(w http.ResponseWriter, r *http.Request) {
+	host := r.URL.Hostname()
+	if !s.allowed(w, host) {
+		return
+	}
+	transport := s.direct
+	if !socks.IsI2PHost(host) {
+		transport = s.outproxy
+	}
+	proxy := &httputil.ReverseProxy{
+		Rewrite:   func(*httputil.ProxyRequest) {},
+		Transport: transport,
+		ErrorHandler: func(w http.ResponseWriter, r *http.Request, err error) {
+			s.reportError(fmt.Errorf("httpproxy: forward to %s: %w", r.URL.Host, err))
+			http.Error(w, "cannot reach "+r.URL.Host, http.StatusBadGateway)
+		},
+	}
+	proxy.ServeHTTP(w, r)
+}
+
+// dialAddr opens a stream to addr, which must name an I2P host, for the
+// forwarding transports.
+func (s *Server) dialAddr(ctx context.Context, _, addr string) (net.Conn, error) {
+	host, port, err := splitHostPort(addr)
+	if err != nil {
+		return nil, err
+	}
+	if !socks.IsI2PHost(host) {
+		return nil, fmt.Errorf("%w: %s", ErrClearnetHost, host)
+	}
+	return s.dial(ctx, host, port)
+}
+
+// dialOutproxy opens a tunnel to target through the outproxy with a
+// CONNECT request of its own.
+func (s *Server) dialOutproxy(ctx context.Context, target string) (net.Conn, error) {
+	conn, err := s.dialAddr(ctx, "tcp", s.Outproxy)
+	if err != nil {
+		return nil, err
+	}
+	req := &http.Request{
+		Method: http.MethodConnect,
+		URL:    &url.URL{Opaque: target},
+		Host:   target,
+		Header: make(http.Header),
+	}
+	if err := req.Write(conn); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	br := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(br, req)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		conn.Close()
+		return nil, fmt.Errorf("outproxy refused CONNECT: %s", resp.Status)
+	}
+	return &bufferedConn{Conn: conn, reader: br}, nil
+}
+
+// track records a hijacked tunnel, or returns false once closed.
+func (s *Server) track(conn net.Conn) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return false
+	}
+	s.tunnels[conn] = struct{}{}
+	return true
+}
+
+// untrack closes and forgets a hijacked tunnel.
+func (s *Server) untrack(conn net.Conn) {
+	conn.Close()
+	s.mu.Lock()
+	delete(s.tunnels, conn)
+	s.mu.Unlock()
+}
+
+// splitHostPort splits addr into a host and a numeric port.
+func splitHostPort(addr string) (string, int, error) {
+	host, portStr, err := net.SplitHostPort(addr)
+	if err != nil {
+		return "", 0, err
+	}
+	port, err := strconv.Atoi(portStr)
+	if err != nil || port < 0 || port > 65535 {
+		return "", 0, fmt.Errorf("invalid port %q", portStr)
+	}
+	return host, port, nil
+}
+
+// bufferedConn is a connection whose reads start with data already
+// buffered by the reader that parsed the outproxy's reply.
+type bufferedConn struct {
+	net.Conn
+	reader *bufio.Reader
+}
+
+// Read reads through the buffered reader.
+func (c *bufferedConn) Read(p []byte) (int, error) {
+	return c.reader.Read(p)
+}
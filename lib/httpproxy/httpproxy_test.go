@@ -0,0 +1,251 @@
+package httpproxy
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+)
+
+// startTestServer serves s on a loopback listener and returns its address.
+func startTestServer(t *testing.T, s *Server) string {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("net.Listen() error = %v", err)
+	}
+	go s.Serve(ln)
+	t.Cleanup(func() { s.Close() })
+	return ln.Addr().String()
+}
+
+// dialRecorder is a DialFunc that connects every stream to target and
+// records the hosts and ports dialed.
+type dialRecorder struct {
+	target string
+
+	mu     sync.Mutex
+	dialed []string
+}
+
+func (d *dialRecorder) dial(ctx context.Context, host string, port int) (net.Conn, error) {
+	d.mu.Lock()
+	d.dialed = append(d.dialed, fmt.Sprintf("%s:%d", host, port))
+	d.mu.Unlock()
+	if d.target == "" {
+		return nil, errors.New("unreachable")
+	}
+	var dialer net.Dialer
+	return dialer.DialContext(ctx, "tcp", d.target)
+}
+
+func (d *dialRecorder) dialedAddrs() []string {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return append([]string(nil), d.dialed...)
+}
+
+// proxyClient returns an HTTP client that uses the proxy at addr.
+func proxyClient(addr string) *http.Client {
+	return &http.Client{
+		Transport: &http.Transport{Proxy: http.ProxyURL(&url.URL{Scheme: "http", Host: addr})},
+		Timeout:   2 * time.Second,
+	}
+}
+
+func TestServer_Forward(t *testing.T) {
+	var gotURI, gotAuth string
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotURI, gotAuth = r.RequestURI, r.Header.Get("Proxy-Authorization")
+		io.WriteString(w, "hello from i2p")
+	}))
+	defer backend.Close()
+
+	d := &dialRecorder{target: backend.Listener.Addr().String()}
+	addr := startTestServer(t, NewServer(d.dial))
+
+	req, _ := http.NewRequest(http.MethodGet, "http://example.i2p/path?q=1", nil)
+	req.Header.Set("Proxy-Authorization", "Basic c2VjcmV0")
+	resp, err := proxyClient(addr).Do(req)
+	if err != nil {
+		t.Fatalf("GET error = %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK || string(body) != "hello from i2p" {
+		t.Errorf("response = %d %q, want 200 %q", resp.StatusCode, body, "hello from i2p")
+	}
+	if got := d.dialedAddrs(); len(got) != 1 || got[0] != "example.i2p:80" {
+		t.Errorf("dialed %v, want [example.i2p:80]", got)
+	}
+	if gotURI != "/path?q=1" {
+		t.Errorf("request URI = %q, want %q", gotURI, "/path?q=1")
+	}
+	if gotAuth != "" {
+		t.Errorf("Proxy-Authorization forwarded as %q", gotAuth)
+	}
+}
+
+func TestServer_Connect(t *testing.T) {
+	var gotHost string
+	var gotPort int
+	addr := startTestServer(t, NewServer(func(ctx context.Context, host string, port int) (net.Conn, error) {
+		gotHost, gotPort = host, port
+		local, remote := net.Pipe()
+		go io.Copy(remote, remote) // echo
+		return local, nil
+	}))
+
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatalf("net.Dial() error = %v", err)
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(2 * time.Second))
+
+	io.WriteString(conn, "CONNECT example.i2p:443 HTTP/1.1\r\nHost: example.i2p:443\r\n\r\n")
+	br := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(br, &http.Request{Method: http.MethodConnect})
+	if err != nil {
+		t.Fatalf("reading CONNECT response: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("CONNECT status = %d, want 200", resp.StatusCode)
+	}
+	if gotHost != "example.i2p" || gotPort != 443 {
+		t.Errorf("dialed %s:%d, want example.i2p:443", gotHost, gotPort)
+	}
+
+	conn.Write([]byte("ping"))
+	buf := make([]byte, 4)
+	if _, err := io.ReadFull(br, buf); err != nil || string(buf) != "ping" {
+		t.Errorf("echo = %q, %v; want %q", buf, err, "ping")
+	}
+}
+
+func TestServer_Rejects(t *testing.T) {
+	tests := []struct {
+		name       string
+		request    string
+		wantStatus int
+		wantErr    error
+	}{
+		{"clearnet GET", "GET http://example.com/ HTTP/1.1\r\nHost: example.com\r\n\r\n", http.StatusForbidden, ErrClearnetHost},
+		{"clearnet CONNECT", "CONNECT example.com:443 HTTP/1.1\r\nHost: example.com:443\r\n\r\n", http.StatusForbidden, ErrClearnetHost},
+		{"origin-form request", "GET / HTTP/1.1\r\nHost: example.i2p\r\n\r\n", http.StatusBadRequest, nil},
+		{"CONNECT without port", "CONNECT example.i2p HTTP/1.1\r\nHost: example.i2p\r\n\r\n", http.StatusBadRequest, nil},
+		{"unreachable host", "GET http://example.i2p/ HTTP/1.1\r\nHost: example.i2p\r\n\r\n", http.StatusBadGateway, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var mu sync.Mutex
+			var reported []error
+			s := NewServer((&dialRecorder{}).dial)
+			s.ErrorHandler = func(err error) {
+				mu.Lock()
+				reported = append(reported, err)
+				mu.Unlock()
+			}
+			addr := startTestServer(t, s)
+
+			conn, err := net.Dial("tcp", addr)
+			if err != nil {
+				t.Fatalf("net.Dial() error = %v", err)
+			}
+			defer conn.Close()
+			conn.SetDeadline(time.Now().Add(2 * time.Second))
+
+			io.WriteString(conn, tt.request)
+			resp, err := http.ReadResponse(bufio.NewReader(conn), nil)
+			if err != nil {
+				t.Fatalf("reading response: %v", err)
+			}
+			resp.Body.Close()
+			if resp.StatusCode != tt.wantStatus {
+				t.Errorf("status = %d, want %d", resp.StatusCode, tt.wantStatus)
+			}
+			if tt.wantErr != nil {
+				mu.Lock()
+				defer mu.Unlock()
+				if len(reported) != 1 || !errors.Is(reported[0], tt.wantErr) {
+					t.Errorf("reported errors = %v, want %v", reported, tt.wantErr)
+				}
+			}
+		})
+	}
+}
+
+func TestServer_Outproxy(t *testing.T) {
+	var gotURI string
+	outproxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotURI = r.RequestURI
+		io.WriteString(w, "from the outproxy")
+	}))
+	defer outproxy.Close()
+
+	d := &dialRecorder{target: outproxy.Listener.Addr().String()}
+	s := NewServer(d.dial)
+	s.Outproxy = "exit.example.i2p:4444"
+	addr := startTestServer(t, s)
+
+	resp, err := proxyClient(addr).Get("http://example.com/page")
+	if err != nil {
+		t.Fatalf("GET error = %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK || string(body) != "from the outproxy" {
+		t.Errorf("response = %d %q, want 200 %q", resp.StatusCode, body, "from the outproxy")
+	}
+	if got := d.dialedAddrs(); len(got) != 1 || got[0] != "exit.example.i2p:4444" {
+		t.Errorf("dialed %v, want [exit.example.i2p:4444]", got)
+	}
+	if gotURI != "http://example.com/page" {
+		t.Errorf("outproxy request URI = %q, want absolute URI", gotURI)
+	}
+}
+
+func TestServer_Close(t *testing.T) {
+	s := NewServer(func(ctx context.Context, host string, port int) (net.Conn, error) {
+		local, _ := net.Pipe()
+		return local, nil
+	})
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("net.Listen() error = %v", err)
+	}
+	served := make(chan error, 1)
+	go func() { served <- s.Serve(ln) }()
+
+	conn, err := net.Dial("tcp", ln.Addr().String())
+	if err != nil {
+		t.Fatalf("net.Dial() error = %v", err)
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(2 * time.Second))
+	io.WriteString(conn, "CONNECT example.i2p:443 HTTP/1.1\r\nHost: example.i2p:443\r\n\r\n")
+	br := bufio.NewReader(conn)
+	if _, err := http.ReadResponse(br, &http.Request{Method: http.MethodConnect}); err != nil {
+		t.Fatalf("reading CONNECT response: %v", err)
+	}
+
+	s.Close()
+	s.Close()
+	if err := <-served; err != nil {
+		t.Errorf("Serve() after Close = %v, want nil", err)
+	}
+	if _, err := br.ReadByte(); err == nil {
+		t.Error("tunnel still open after Close")
+	}
+}
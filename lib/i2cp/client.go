@@ -12,12 +12,14 @@ package i2cp
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net"
 	"sync"
 	"time"
 
 	go_i2cp "github.com/go-i2p/go-i2cp"
+	"github.com/go-i2p/go-sam-bridge/lib/session"
 )
 
 // Ensure interface is not nil at compile time
@@ -28,7 +30,8 @@ var _ = (*go_i2cp.Client)(nil)
 //
 // Thread-safety: All methods are safe for concurrent use.
 // The Client maintains a single connection to the I2P router and
-// multiplexes all SAM sessions over it.
+// multiplexes all SAM sessions over it. With ClientConfig.FailoverAddrs
+// set, it moves to the next router when the connection drops.
 type Client struct {
 	mu sync.RWMutex
 
@@ -46,6 +49,20 @@ type Client struct {
 
 	// callbacks holds the client-level callbacks.
 	callbacks *ClientCallbacks
+
+	// addrIndex is the position in the router address list of the
+	// router connected to, or tried last.
+	addrIndex int
+
+	// closed is set by Close so a dropped connection is not failed over.
+	closed bool
+
+	// failoverCancel stops a failover in progress; nil when none is.
+	failoverCancel context.CancelFunc
+
+	// health measures router round trips and counts errors and
+	// disconnects across connections.
+	health *healthMonitor
 }
 
 // ClientConfig holds configuration for connecting to the I2P router.
@@ -53,6 +70,15 @@ type ClientConfig struct {
 	// RouterAddr is the I2CP router address (default: 127.0.0.1:7654).
 	RouterAddr string
 
+	// FailoverAddrs are further router addresses. Connect tries them in
+	// order when RouterAddr cannot be reached, and a dropped connection
+	// fails over to the next address in the list, wrapping around.
+	FailoverAddrs []string
+
+	// FailoverRetryInterval is the pause between rounds of failover
+	// attempts once every address has failed (default: 5s).
+	FailoverRetryInterval time.Duration
+
 	// Username is the optional I2CP username for authentication.
 	Username string
 
@@ -65,11 +91,24 @@ type ClientConfig struct {
 	// TLSInsecure allows insecure TLS connections (for testing).
 	TLSInsecure bool
 
+	// TLSCAFile verifies the router certificate against this CA bundle
+	// instead of the system pool.
+	TLSCAFile string
+
+	// TLSCertFile and TLSKeyFile hold a client certificate presented to
+	// routers that authenticate clients by certificate.
+	TLSCertFile string
+	TLSKeyFile  string
+
 	// ConnectTimeout is the timeout for connecting to the router.
 	ConnectTimeout time.Duration
 
 	// SessionTimeout is the timeout for session creation.
 	SessionTimeout time.Duration
+
+	// UnresponsiveTimeout is how long a request may wait for the router's
+	// answer before the connection is reported unresponsive (default: 30s).
+	UnresponsiveTimeout time.Duration
 }
 
 // DefaultClientConfig returns a ClientConfig with sensible defaults.
@@ -92,6 +131,15 @@ type ClientCallbacks struct {
 
 	// OnRouterInfo is called when router info is received.
 	OnRouterInfo func(version string)
+
+	// OnFailover is called when the client has reconnected to addr after
+	// the connection dropped, once the I2CP sessions are re-created.
+	OnFailover func(addr string)
+
+	// OnUnresponsive is called when a request has waited
+	// UnresponsiveTimeout for the router's answer while the connection is
+	// up. It is not called again until the router answers.
+	OnUnresponsive func(waited time.Duration)
 }
 
 // NewClient creates a new I2CP client with the given configuration.
@@ -104,10 +152,30 @@ func NewClient(config *ClientConfig) *Client {
 		config = DefaultClientConfig()
 	}
 
-	return &Client{
+	c := &Client{
 		config:   config,
 		sessions: make(map[string]*I2CPSession),
 	}
+	c.health = newHealthMonitor(config.UnresponsiveTimeout, c.onUnresponsive)
+	return c
+}
+
+// DefaultFailoverRetryInterval is the default pause between rounds of
+// failover attempts.
+const DefaultFailoverRetryInterval = 5 * time.Second
+
+// routerAddrs returns RouterAddr followed by FailoverAddrs, without
+// empty or repeated addresses.
+func (c *ClientConfig) routerAddrs() []string {
+	addrs := make([]string, 0, 1+len(c.FailoverAddrs))
+	seen := make(map[string]bool)
+	for _, addr := range append([]string{c.RouterAddr}, c.FailoverAddrs...) {
+		if addr != "" && !seen[addr] {
+			seen[addr] = true
+			addrs = append(addrs, addr)
+		}
+	}
+	return addrs
 }
 
 // Connect establishes a connection to the I2P router.
@@ -119,7 +187,8 @@ func NewClient(config *ClientConfig) *Client {
 //  3. Establish TCP/TLS connection to router
 //  4. Start I2CP message processing
 //
-// Returns an error if the connection fails or times out.
+// With FailoverAddrs configured, each address is tried in turn until one
+// connects. Returns an error if every address fails or times out.
 func (c *Client) Connect(ctx context.Context) error {
 	c.mu.Lock()
 	defer c.mu.Unlock()
@@ -127,11 +196,70 @@ func (c *Client) Connect(ctx context.Context) error {
 	if c.connected {
 		return nil // Already connected
 	}
+	c.closed = false
+	return c.connectLocked(ctx)
+}
 
-	// Parse host and port from RouterAddr
-	host, port, err := net.SplitHostPort(c.config.RouterAddr)
+// ConnectWithRetry calls Connect until it succeeds or ctx is done,
+// waiting interval between attempts. Each failed attempt is passed to
+// onError, which may be nil. It returns ctx.Err() if ctx ends first.
+//
+// This lets a bridge start serving before the router is up; SAM commands
+// that need the router fail until the connection is made.
+func (c *Client) ConnectWithRetry(ctx context.Context, interval time.Duration, onError func(error)) error {
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		err := c.Connect(ctx)
+		if err == nil {
+			return nil
+		}
+		if onError != nil {
+			onError(err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(interval):
+		}
+	}
+}
+
+// connectLocked tries each router address, starting with the one at
+// addrIndex, and keeps the first connection that succeeds.
+// Callers must hold c.mu.
+func (c *Client) connectLocked(ctx context.Context) error {
+	addrs := c.config.routerAddrs()
+	if len(addrs) == 0 {
+		return errors.New("no I2P router address configured")
+	}
+
+	var errs []error
+	for i := range addrs {
+		idx := (c.addrIndex + i) % len(addrs)
+		i2cpClient, err := c.dial(ctx, addrs[idx])
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		c.addrIndex = idx
+		c.i2cpClient = i2cpClient
+		c.health.reset()
+		c.connected = true
+		return nil
+	}
+	return errors.Join(errs...)
+}
+
+// dial connects a new go-i2cp client to the router at addr and starts
+// its message processing loop.
+func (c *Client) dial(ctx context.Context, addr string) (*go_i2cp.Client, error) {
+	// Parse host and port from the router address
+	host, port, err := net.SplitHostPort(addr)
 	if err != nil {
-		return fmt.Errorf("invalid router address %q: %w", c.config.RouterAddr, err)
+		return nil, fmt.Errorf("invalid router address %q: %w", addr, err)
 	}
 
 	// Create go-i2cp client with our callbacks
@@ -142,6 +270,15 @@ func (c *Client) Connect(ctx context.Context) error {
 
 	i2cpClient := go_i2cp.NewClient(callbacks)
 
+	// State tracking records when lease sets are sent to the router,
+	// which I2CPSession.WaitForLeaseSet reports on.
+	if err := i2cpClient.EnableDebugging(&go_i2cp.DebugConfig{EnableStateTracking: true}); err != nil {
+		return nil, fmt.Errorf("failed to enable I2CP session state tracking: %w", err)
+	}
+
+	// Health is measured from the handshake on
+	i2cpClient.SetMetrics(c.health)
+
 	// Configure I2CP properties
 	i2cpClient.SetProperty("i2cp.tcp.host", host)
 	i2cpClient.SetProperty("i2cp.tcp.port", port)
@@ -158,6 +295,13 @@ func (c *Client) Connect(ctx context.Context) error {
 	if c.config.TLSInsecure {
 		i2cpClient.SetProperty("i2cp.SSL.insecure", "true")
 	}
+	if c.config.TLSCAFile != "" {
+		i2cpClient.SetProperty("i2cp.SSL.caFile", c.config.TLSCAFile)
+	}
+	if c.config.TLSCertFile != "" {
+		i2cpClient.SetProperty("i2cp.SSL.certFile", c.config.TLSCertFile)
+		i2cpClient.SetProperty("i2cp.SSL.keyFile", c.config.TLSKeyFile)
+	}
 
 	// Apply timeout to context if not already set
 	connectCtx := ctx
@@ -169,7 +313,7 @@ func (c *Client) Connect(ctx context.Context) error {
 
 	// Connect to the I2P router
 	if err := i2cpClient.Connect(connectCtx); err != nil {
-		return fmt.Errorf("failed to connect to I2P router at %s: %w", c.config.RouterAddr, err)
+		return nil, fmt.Errorf("failed to connect to I2P router at %s: %w", addr, err)
 	}
 
 	// Start the I2CP message processing loop in a background goroutine
@@ -178,10 +322,20 @@ func (c *Client) Connect(ctx context.Context) error {
 		_ = i2cpClient.ProcessIO(context.Background())
 	}()
 
-	c.i2cpClient = i2cpClient
-	c.connected = true
+	return i2cpClient, nil
+}
 
-	return nil
+// RouterAddr returns the address of the router the client is connected
+// to, or tried last. It differs from ClientConfig.RouterAddr after a
+// failover.
+func (c *Client) RouterAddr() string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	addrs := c.config.routerAddrs()
+	if len(addrs) == 0 {
+		return ""
+	}
+	return addrs[c.addrIndex%len(addrs)]
 }
 
 // Close closes the connection to the I2P router and all sessions.
@@ -190,6 +344,12 @@ func (c *Client) Close() error {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
+	c.closed = true
+	c.health.reset()
+	if c.failoverCancel != nil {
+		c.failoverCancel()
+		c.failoverCancel = nil
+	}
 	if !c.connected {
 		return nil
 	}
@@ -278,6 +438,33 @@ func (c *Client) RouterVersion() string {
 	return v.String()
 }
 
+// RouterInfo returns the connected router's version, clock, and
+// capabilities as reported during the I2CP handshake.
+// The second result is false if not connected.
+func (c *Client) RouterInfo() (session.RouterInfo, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if c.i2cpClient == nil || !c.connected {
+		return session.RouterInfo{}, false
+	}
+	client := c.i2cpClient
+	version := client.RouterVersion()
+	info := session.RouterInfo{
+		Version:              version.String(),
+		HostLookup:           client.SupportsHostLookup(),
+		FastReceive:          client.SupportsVersion(go_i2cp.VersionFastReceive),
+		MultiSession:         client.SupportsMultiSession(),
+		LeaseSet2:            client.SupportsVersion(go_i2cp.VersionCreateLeaseSet2),
+		EncryptedLeaseSet:    client.SupportsVersion(go_i2cp.VersionBlindingInfo),
+		LeaseSetLookupErrors: client.SupportsVersion(go_i2cp.VersionProposal167),
+	}
+	if date := client.RouterDate(); date > 0 {
+		info.Date = time.UnixMilli(int64(date))
+	}
+	return info, true
+}
+
 // onConnect is called when the I2CP connection is established.
 // Matches go-i2cp ClientCallBacks.OnConnect signature.
 func (c *Client) onConnect(client *go_i2cp.Client) {
@@ -288,17 +475,58 @@ func (c *Client) onConnect(client *go_i2cp.Client) {
 
 // onDisconnect is called when the I2CP connection is lost.
 // Matches go-i2cp ClientCallBacks.OnDisconnect signature.
+// With FailoverAddrs configured it starts failing over, unless the
+// client was closed.
 func (c *Client) onDisconnect(client *go_i2cp.Client, reason string, opaque *interface{}) {
 	c.mu.Lock()
+	// Connections already replaced by a failover report late
+	if client != nil && client != c.i2cpClient {
+		c.mu.Unlock()
+		return
+	}
 	c.connected = false
+	c.health.disconnected()
+	sessions := make([]*I2CPSession, 0, len(c.sessions))
+	for _, sess := range c.sessions {
+		sessions = append(sessions, sess)
+	}
+	callbacks := c.callbacks
+	var failoverCtx context.Context
+	if !c.closed && len(c.config.FailoverAddrs) > 0 && c.failoverCancel == nil {
+		failoverCtx, c.failoverCancel = context.WithCancel(context.Background())
+	}
 	c.mu.Unlock()
 
-	if c.callbacks != nil && c.callbacks.OnDisconnected != nil {
+	for _, sess := range sessions {
+		sess.recordTunnelEvent(session.TunnelFailed, "router connection lost")
+	}
+	if failoverCtx != nil {
+		go c.failover(failoverCtx)
+	}
+
+	if callbacks != nil && callbacks.OnDisconnected != nil {
 		var err error
 		if reason != "" {
 			err = fmt.Errorf("disconnected: %s", reason)
 		}
-		c.callbacks.OnDisconnected(err)
+		callbacks.OnDisconnected(err)
+	}
+}
+
+// Health returns round-trip, error and disconnect statistics for the
+// router connection. Counters accumulate across reconnects.
+func (c *Client) Health() session.I2CPHealth {
+	return c.health.snapshot()
+}
+
+// onUnresponsive is called by the health monitor when the router has not
+// answered a request within UnresponsiveTimeout.
+func (c *Client) onUnresponsive(waited time.Duration) {
+	c.mu.RLock()
+	connected, callbacks := c.connected, c.callbacks
+	c.mu.RUnlock()
+	if connected && callbacks != nil && callbacks.OnUnresponsive != nil {
+		callbacks.OnUnresponsive(waited)
 	}
 }
 
@@ -336,6 +564,11 @@ func (c *Client) CreateSessionForSAM(ctx context.Context, samSessionID string, c
 		i2cpConfig.FastReceive = config.FastReceive
 		i2cpConfig.ReduceIdleTime = config.ReduceIdleTime
 		i2cpConfig.CloseIdleTime = config.CloseIdleTime
+		i2cpConfig.LeaseSetType = config.LeaseSetType
+		i2cpConfig.LeaseSetAuthType = config.LeaseSetAuthType
+		i2cpConfig.LeaseSetSecret = config.LeaseSetSecret
+		i2cpConfig.LeaseSetClients = config.LeaseSetClients
+		i2cpConfig.OfflineSignature = config.OfflineSignature
 	}
 
 	// Create the I2CP session
@@ -357,6 +590,11 @@ type SessionConfigFromSession = struct {
 	FastReceive            bool
 	ReduceIdleTime         int
 	CloseIdleTime          int
+	LeaseSetType           int
+	LeaseSetAuthType       int
+	LeaseSetSecret         string
+	LeaseSetClients        []session.LeaseSetClient
+	OfflineSignature       *session.OfflineSignature
 }
 
 // I2CPSessionHandleFromSession is an alias for the session.I2CPSessionHandle interface.
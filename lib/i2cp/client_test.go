@@ -1,8 +1,11 @@
 package i2cp
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"testing"
+	"time"
 )
 
 func TestDefaultClientConfig(t *testing.T) {
@@ -89,6 +92,16 @@ func TestClient_RouterVersion(t *testing.T) {
 	}
 }
 
+func TestClient_RouterInfo_NotConnected(t *testing.T) {
+	client := NewClient(nil)
+	if _, ok := client.RouterInfo(); ok {
+		t.Error("RouterInfo() should report false when not connected")
+	}
+	if _, ok := NewSessionProviderAdapter(client).RouterInfo(); ok {
+		t.Error("adapter RouterInfo() should report false when not connected")
+	}
+}
+
 func TestClient_SessionManagement(t *testing.T) {
 	client := NewClient(nil)
 
@@ -287,3 +300,21 @@ func TestClient_SessionManagement_Concurrent(t *testing.T) {
 		<-done
 	}
 }
+
+func TestClient_ConnectWithRetry(t *testing.T) {
+	client := NewClient(&ClientConfig{RouterAddr: closedAddr(t), ConnectTimeout: time.Second})
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+
+	attempts := 0
+	err := client.ConnectWithRetry(ctx, 20*time.Millisecond, func(error) { attempts++ })
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("ConnectWithRetry() error = %v, want context.DeadlineExceeded", err)
+	}
+	if attempts < 2 {
+		t.Errorf("ConnectWithRetry() made %d attempts, want a retry", attempts)
+	}
+	if client.IsConnected() {
+		t.Error("IsConnected() = true without a router")
+	}
+}
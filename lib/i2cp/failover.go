@@ -0,0 +1,66 @@
+package i2cp
+
+import (
+	"context"
+	"time"
+)
+
+// failover replaces a dropped router connection. It tries the router
+// addresses in turn, starting after the one that dropped, pausing
+// FailoverRetryInterval after every full round, until one connects or
+// ctx is cancelled by Close. The registered sessions are then re-created
+// on the new connection under their SAM session IDs; sessions the new
+// router refuses are closed.
+func (c *Client) failover(ctx context.Context) {
+	c.mu.Lock()
+	old := c.i2cpClient
+	c.i2cpClient = nil
+	c.addrIndex++
+	interval := c.config.FailoverRetryInterval
+	c.mu.Unlock()
+
+	if old != nil {
+		old.Close()
+	}
+	if interval <= 0 {
+		interval = DefaultFailoverRetryInterval
+	}
+
+	for {
+		c.mu.Lock()
+		if ctx.Err() != nil {
+			c.mu.Unlock()
+			return
+		}
+		err := c.connectLocked(ctx)
+		if err == nil {
+			c.failoverCancel = nil
+			break
+		}
+		c.mu.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(interval):
+		}
+	}
+	client := c.i2cpClient
+	sessions := make([]*I2CPSession, 0, len(c.sessions))
+	for _, sess := range c.sessions {
+		sessions = append(sessions, sess)
+	}
+	addr := c.config.routerAddrs()[c.addrIndex]
+	callbacks := c.callbacks
+	c.mu.Unlock()
+
+	for _, sess := range sessions {
+		if err := sess.reopen(ctx, client); err != nil {
+			_ = sess.Close()
+		}
+	}
+
+	if callbacks != nil && callbacks.OnFailover != nil {
+		callbacks.OnFailover(addr)
+	}
+}
@@ -0,0 +1,112 @@
+package i2cp
+
+import (
+	"context"
+	"net"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// closedAddr returns a local address nothing listens on.
+func closedAddr(t *testing.T) string {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := ln.Addr().String()
+	ln.Close()
+	return addr
+}
+
+func TestClientConfig_routerAddrs(t *testing.T) {
+	config := &ClientConfig{
+		RouterAddr:    "127.0.0.1:7654",
+		FailoverAddrs: []string{"10.0.0.2:7654", "", "127.0.0.1:7654", "10.0.0.3:7654"},
+	}
+	want := []string{"127.0.0.1:7654", "10.0.0.2:7654", "10.0.0.3:7654"}
+	if got := config.routerAddrs(); !reflect.DeepEqual(got, want) {
+		t.Errorf("routerAddrs() = %v, want %v", got, want)
+	}
+}
+
+func TestClient_Connect_TriesEveryAddress(t *testing.T) {
+	primary, backup := closedAddr(t), closedAddr(t)
+	client := NewClient(&ClientConfig{
+		RouterAddr:     primary,
+		FailoverAddrs:  []string{backup},
+		ConnectTimeout: 2 * time.Second,
+	})
+
+	err := client.Connect(context.Background())
+	if err == nil {
+		client.Close()
+		t.Fatal("Connect() succeeded with no router listening")
+	}
+	for _, addr := range []string{primary, backup} {
+		if !strings.Contains(err.Error(), addr) {
+			t.Errorf("error %q does not mention %s", err, addr)
+		}
+	}
+	if client.IsConnected() {
+		t.Error("IsConnected() = true after failed Connect")
+	}
+	if got := client.RouterAddr(); got != primary {
+		t.Errorf("RouterAddr() = %q, want %q", got, primary)
+	}
+}
+
+func TestClient_onDisconnect_Failover(t *testing.T) {
+	t.Run("starts failover with failover addresses", func(t *testing.T) {
+		client := NewClient(&ClientConfig{
+			RouterAddr:            closedAddr(t),
+			FailoverAddrs:         []string{closedAddr(t)},
+			ConnectTimeout:        time.Second,
+			FailoverRetryInterval: 10 * time.Millisecond,
+		})
+		client.connected = true
+
+		client.onDisconnect(nil, "router restarted", nil)
+
+		client.mu.RLock()
+		started := client.failoverCancel != nil
+		client.mu.RUnlock()
+		if !started {
+			t.Fatal("failover not started")
+		}
+
+		client.Close()
+		client.mu.RLock()
+		defer client.mu.RUnlock()
+		if client.failoverCancel != nil {
+			t.Error("Close() did not stop failover")
+		}
+	})
+
+	t.Run("no failover without failover addresses", func(t *testing.T) {
+		client := NewClient(&ClientConfig{RouterAddr: closedAddr(t)})
+		client.connected = true
+
+		client.onDisconnect(nil, "router restarted", nil)
+
+		if client.failoverCancel != nil {
+			t.Error("failover started without failover addresses")
+		}
+	})
+
+	t.Run("no failover after Close", func(t *testing.T) {
+		client := NewClient(&ClientConfig{
+			RouterAddr:    closedAddr(t),
+			FailoverAddrs: []string{closedAddr(t)},
+		})
+		client.Close()
+
+		client.onDisconnect(nil, "closed", nil)
+
+		if client.failoverCancel != nil {
+			t.Error("failover started after Close")
+		}
+	})
+}
@@ -0,0 +1,221 @@
+package i2cp
+
+import (
+	"maps"
+	"sync"
+	"time"
+
+	go_i2cp "github.com/go-i2p/go-i2cp"
+	"github.com/go-i2p/go-sam-bridge/lib/session"
+)
+
+// DefaultUnresponsiveTimeout is how long a request may wait for the
+// router's answer before the connection is reported unresponsive.
+const DefaultUnresponsiveTimeout = 30 * time.Second
+
+// maxPendingRequests bounds the send times kept per reply type, so a
+// router that never answers cannot grow them without limit.
+const maxPendingRequests = 1024
+
+// replyTypes maps the I2CP requests the router always answers to the
+// message type of the answer. Requests sharing a reply type are matched
+// to replies in the order they were sent.
+var replyTypes = map[uint8]uint8{
+	go_i2cp.I2CP_MSG_GET_DATE:        go_i2cp.I2CP_MSG_SET_DATE,
+	go_i2cp.I2CP_MSG_CREATE_SESSION:  go_i2cp.I2CP_MSG_SESSION_STATUS,
+	go_i2cp.I2CP_MSG_DESTROY_SESSION: go_i2cp.I2CP_MSG_SESSION_STATUS,
+	go_i2cp.I2CP_MSG_DEST_LOOKUP:     go_i2cp.I2CP_MSG_DEST_REPLY,
+	go_i2cp.I2CP_MSG_HOST_LOOKUP:     go_i2cp.I2CP_MSG_HOST_REPLY,
+}
+
+// healthMonitor implements go_i2cp.MetricsCollector to measure router
+// round trips and count errors and disconnects. It outlives the go-i2cp
+// clients of a Client, so counters survive reconnects and failovers.
+//
+// When the oldest unanswered request has waited for timeout, it calls
+// onUnresponsive once, and again only after the router has answered.
+type healthMonitor struct {
+	timeout        time.Duration
+	onUnresponsive func(waited time.Duration)
+
+	mu          sync.Mutex
+	pending     map[uint8][]time.Time
+	roundTrip   time.Duration
+	totalRTT    time.Duration
+	roundTrips  int64
+	errors      map[string]uint64
+	disconnects uint64
+	warned      bool
+	timer       *time.Timer
+}
+
+// newHealthMonitor returns a monitor reporting requests unanswered for
+// timeout to onUnresponsive, which may be nil.
+func newHealthMonitor(timeout time.Duration, onUnresponsive func(time.Duration)) *healthMonitor {
+	if timeout <= 0 {
+		timeout = DefaultUnresponsiveTimeout
+	}
+	return &healthMonitor{
+		timeout:        timeout,
+		onUnresponsive: onUnresponsive,
+		pending:        make(map[uint8][]time.Time),
+		errors:         make(map[string]uint64),
+	}
+}
+
+// IncrementMessageSent implements go_i2cp.MetricsCollector. It records
+// when requests that expect an answer were sent.
+func (m *healthMonitor) IncrementMessageSent(messageType uint8) {
+	reply, ok := replyTypes[messageType]
+	if !ok {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if len(m.pending[reply]) < maxPendingRequests {
+		m.pending[reply] = append(m.pending[reply], time.Now())
+	}
+	m.armLocked(time.Now())
+}
+
+// IncrementMessageReceived implements go_i2cp.MetricsCollector. It
+// matches replies to the oldest request awaiting them.
+func (m *healthMonitor) IncrementMessageReceived(messageType uint8) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	queue := m.pending[messageType]
+	if len(queue) == 0 {
+		return
+	}
+	now := time.Now()
+	m.roundTrip = now.Sub(queue[0])
+	m.totalRTT += m.roundTrip
+	m.roundTrips++
+	m.pending[messageType] = queue[1:]
+
+	if m.warned && m.oldestLocked(now) < m.timeout {
+		m.warned = false
+		m.armLocked(now)
+	}
+}
+
+// IncrementError implements go_i2cp.MetricsCollector.
+func (m *healthMonitor) IncrementError(errorType string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.errors[errorType]++
+}
+
+// SetActiveSessions implements go_i2cp.MetricsCollector.
+func (m *healthMonitor) SetActiveSessions(int) {}
+
+// RecordMessageLatency implements go_i2cp.MetricsCollector.
+func (m *healthMonitor) RecordMessageLatency(uint8, time.Duration) {}
+
+// SetConnectionState implements go_i2cp.MetricsCollector.
+func (m *healthMonitor) SetConnectionState(string) {}
+
+// AddBytesSent implements go_i2cp.MetricsCollector.
+func (m *healthMonitor) AddBytesSent(uint64) {}
+
+// AddBytesReceived implements go_i2cp.MetricsCollector.
+func (m *healthMonitor) AddBytesReceived(uint64) {}
+
+// disconnected counts a dropped connection. Requests sent over it will
+// not be answered, so they are forgotten.
+func (m *healthMonitor) disconnected() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.disconnects++
+	m.resetLocked()
+}
+
+// reset forgets pending requests, for a new or closed connection.
+func (m *healthMonitor) reset() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.resetLocked()
+}
+
+// resetLocked forgets pending requests and stops the timer.
+// Callers must hold m.mu.
+func (m *healthMonitor) resetLocked() {
+	clear(m.pending)
+	m.warned = false
+	if m.timer != nil {
+		m.timer.Stop()
+		m.timer = nil
+	}
+}
+
+// snapshot returns the current health.
+func (m *healthMonitor) snapshot() session.I2CPHealth {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	now := time.Now()
+	health := session.I2CPHealth{
+		RoundTrip:   m.roundTrip,
+		Errors:      maps.Clone(m.errors),
+		Disconnects: m.disconnects,
+	}
+	if m.roundTrips > 0 {
+		health.AverageRoundTrip = m.totalRTT / time.Duration(m.roundTrips)
+	}
+	for _, queue := range m.pending {
+		health.Pending += len(queue)
+	}
+	health.OldestPending = m.oldestLocked(now)
+	health.Unresponsive = health.Pending > 0 && health.OldestPending >= m.timeout
+	return health
+}
+
+// oldestLocked returns how long the oldest pending request has waited
+// at now, or zero if none is pending. Callers must hold m.mu.
+func (m *healthMonitor) oldestLocked(now time.Time) time.Duration {
+	var oldest time.Duration
+	for _, queue := range m.pending {
+		if len(queue) > 0 {
+			oldest = max(oldest, now.Sub(queue[0]))
+		}
+	}
+	return oldest
+}
+
+// armLocked starts the timer to fire when the oldest pending request
+// reaches the timeout, unless it is running or a warning is already
+// out. Callers must hold m.mu.
+func (m *healthMonitor) armLocked(now time.Time) {
+	if m.timer != nil || m.warned {
+		return
+	}
+	for _, queue := range m.pending {
+		if len(queue) > 0 {
+			m.timer = time.AfterFunc(m.timeout-m.oldestLocked(now), m.check)
+			return
+		}
+	}
+}
+
+// check warns if the oldest pending request has reached the timeout,
+// and otherwise waits for it to.
+func (m *healthMonitor) check() {
+	m.mu.Lock()
+	m.timer = nil
+	now := time.Now()
+	waited := m.oldestLocked(now)
+	if waited < m.timeout {
+		m.armLocked(now)
+		m.mu.Unlock()
+		return
+	}
+	m.warned = true
+	notify := m.onUnresponsive
+	m.mu.Unlock()
+
+	if notify != nil {
+		notify(waited)
+	}
+}
+
+// Compile-time check that healthMonitor implements go_i2cp.MetricsCollector.
+var _ go_i2cp.MetricsCollector = (*healthMonitor)(nil)
@@ -0,0 +1,76 @@
+package i2cp
+
+import (
+	"testing"
+	"time"
+
+	go_i2cp "github.com/go-i2p/go-i2cp"
+)
+
+func TestHealthMonitor_RoundTrip(t *testing.T) {
+	m := newHealthMonitor(time.Minute, nil)
+
+	m.IncrementMessageSent(go_i2cp.I2CP_MSG_HOST_LOOKUP)
+	m.IncrementMessageSent(go_i2cp.I2CP_MSG_SEND_MESSAGE) // not answered
+	if h := m.snapshot(); h.Pending != 1 || h.Unresponsive {
+		t.Errorf("after lookup sent: %+v, want 1 pending and responsive", h)
+	}
+
+	time.Sleep(5 * time.Millisecond)
+	m.IncrementMessageReceived(go_i2cp.I2CP_MSG_HOST_REPLY)
+	m.IncrementMessageReceived(go_i2cp.I2CP_MSG_HOST_REPLY) // unsolicited
+	h := m.snapshot()
+	if h.Pending != 0 {
+		t.Errorf("Pending = %d after reply, want 0", h.Pending)
+	}
+	if h.RoundTrip < 5*time.Millisecond || h.AverageRoundTrip != h.RoundTrip {
+		t.Errorf("RoundTrip = %v, AverageRoundTrip = %v; want equal and at least 5ms", h.RoundTrip, h.AverageRoundTrip)
+	}
+
+	m.IncrementError("network")
+	m.IncrementError("network")
+	m.disconnected()
+	h = m.snapshot()
+	if h.Errors["network"] != 2 || h.Disconnects != 1 {
+		t.Errorf("Errors = %v, Disconnects = %d; want network 2, 1", h.Errors, h.Disconnects)
+	}
+}
+
+func TestHealthMonitor_Unresponsive(t *testing.T) {
+	warned := make(chan time.Duration, 2)
+	m := newHealthMonitor(20*time.Millisecond, func(waited time.Duration) { warned <- waited })
+
+	m.IncrementMessageSent(go_i2cp.I2CP_MSG_GET_DATE)
+	select {
+	case waited := <-warned:
+		if waited < 20*time.Millisecond {
+			t.Errorf("warned after %v, want at least 20ms", waited)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("no warning for an unanswered request")
+	}
+	if h := m.snapshot(); !h.Unresponsive {
+		t.Errorf("snapshot() = %+v, want Unresponsive", h)
+	}
+
+	// Only one warning until the router answers
+	m.IncrementMessageSent(go_i2cp.I2CP_MSG_GET_DATE)
+	time.Sleep(50 * time.Millisecond)
+	if len(warned) != 0 {
+		t.Error("warned again before the router answered")
+	}
+
+	m.IncrementMessageReceived(go_i2cp.I2CP_MSG_SET_DATE)
+	m.IncrementMessageReceived(go_i2cp.I2CP_MSG_SET_DATE)
+	if h := m.snapshot(); h.Unresponsive || h.Pending != 0 {
+		t.Errorf("after replies: %+v, want responsive with nothing pending", h)
+	}
+
+	m.IncrementMessageSent(go_i2cp.I2CP_MSG_GET_DATE)
+	select {
+	case <-warned:
+	case <-time.After(time.Second):
+		t.Fatal("no warning after the router stalled again")
+	}
+	m.reset()
+}
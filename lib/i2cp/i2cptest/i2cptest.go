@@ -0,0 +1,154 @@
+// Package i2cptest provides an in-memory I2CP session provider for
+// testing SAM clients against the bridge without an I2P router.
+//
+// A Provider accepts every SESSION CREATE at once, as if tunnels were
+// already built, and records each session until its handle is closed. No
+// traffic reaches I2P: STREAM CONNECT, ACCEPT and FORWARD and datagram
+// sends have no peer to reach, though Session.Receive hands the bridge a
+// datagram as if one arrived. It is enough to exercise HELLO, DEST
+// GENERATE, SESSION CREATE and NAMING LOOKUP NAME=ME:
+//
+//	provider := i2cptest.NewProvider()
+//	bridge, err := embedding.New(
+//		embedding.WithListenAddr("127.0.0.1:0"),
+//		embedding.WithI2CPProvider(provider),
+//	)
+package i2cptest
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+
+	"github.com/go-i2p/go-sam-bridge/lib/session"
+)
+
+// ErrDisconnected is returned by CreateSessionForSAM while the provider
+// is marked disconnected.
+var ErrDisconnected = errors.New("i2cptest: provider disconnected")
+
+// Provider is a session.I2CPSessionProvider whose sessions exist only in
+// memory. The zero value is not usable; call NewProvider.
+type Provider struct {
+	mu           sync.Mutex
+	sessions     map[string]*Session
+	disconnected bool
+}
+
+// NewProvider returns a connected Provider with no sessions.
+func NewProvider() *Provider {
+	return &Provider{sessions: make(map[string]*Session)}
+}
+
+// CreateSessionForSAM returns a Session whose tunnels are ready at once.
+func (p *Provider) CreateSessionForSAM(ctx context.Context, samSessionID string, config *session.SessionConfig) (session.I2CPSessionHandle, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.disconnected {
+		return nil, ErrDisconnected
+	}
+	s := &Session{ID: samSessionID, Config: config, provider: p}
+	p.sessions[samSessionID] = s
+	return s, nil
+}
+
+// IsConnected reports whether the provider is connected, which it is
+// unless SetConnected(false) was called.
+func (p *Provider) IsConnected() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return !p.disconnected
+}
+
+// SetConnected marks the provider connected or disconnected. While it is
+// disconnected the bridge refuses to create sessions.
+func (p *Provider) SetConnected(connected bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.disconnected = !connected
+}
+
+// Sessions returns the IDs of the sessions whose handles are not yet
+// closed, sorted.
+func (p *Provider) Sessions() []string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	ids := make([]string, 0, len(p.sessions))
+	for id := range p.sessions {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// Session returns the session created for the SAM session ID, or nil if
+// there is none or its handle was closed.
+func (p *Provider) Session(id string) *Session {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.sessions[id]
+}
+
+// Session is the session.I2CPSessionHandle a Provider creates.
+type Session struct {
+	// ID is the SAM session ID the session was created for.
+	ID string
+
+	// Config is the configuration it was created with.
+	Config *session.SessionConfig
+
+	provider *Provider
+
+	mu        sync.Mutex
+	onMessage func(session.IncomingMessage)
+}
+
+// OnMessage sets the function Receive passes datagrams to. The bridge
+// sets it when it creates the SAM session.
+// Implements session.MessageSource.
+func (s *Session) OnMessage(fn func(session.IncomingMessage)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.onMessage = fn
+}
+
+// Receive delivers msg to the bridge as a datagram received on the
+// session's destination. It returns false if the bridge has not asked
+// for datagrams.
+func (s *Session) Receive(msg session.IncomingMessage) bool {
+	s.mu.Lock()
+	fn := s.onMessage
+	s.mu.Unlock()
+	if fn == nil {
+		return false
+	}
+	fn(msg)
+	return true
+}
+
+// WaitForTunnels returns at once; the tunnels are always ready.
+func (s *Session) WaitForTunnels(ctx context.Context) error {
+	return nil
+}
+
+// IsTunnelReady returns true.
+func (s *Session) IsTunnelReady() bool {
+	return true
+}
+
+// Close removes the session from its provider.
+func (s *Session) Close() error {
+	s.provider.mu.Lock()
+	defer s.provider.mu.Unlock()
+	if s.provider.sessions[s.ID] == s {
+		delete(s.provider.sessions, s.ID)
+	}
+	return nil
+}
+
+// DestinationBase64 returns an empty string; the SAM session holds the
+// destination.
+func (s *Session) DestinationBase64() string {
+	return ""
+}
@@ -0,0 +1,192 @@
+package i2cptest_test
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"io"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-i2p/go-sam-bridge/lib/embedding"
+	"github.com/go-i2p/go-sam-bridge/lib/i2cp/i2cptest"
+	"github.com/go-i2p/go-sam-bridge/lib/session"
+)
+
+// startBridge runs a bridge on provider and returns it with its SAM
+// address.
+func startBridge(t *testing.T, provider *i2cptest.Provider) (*embedding.Bridge, string) {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen() error = %v", err)
+	}
+	b, err := embedding.New(embedding.WithListener(ln), embedding.WithI2CPProvider(provider), embedding.WithDatagramPort(0))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if err := b.Start(context.Background()); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	t.Cleanup(func() { b.Stop(context.Background()) })
+	return b, ln.Addr().String()
+}
+
+// dial connects to the bridge at addr and completes the handshake.
+func dial(t *testing.T, addr string) (net.Conn, *bufio.Reader) {
+	t.Helper()
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatalf("Dial() error = %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	conn.SetDeadline(time.Now().Add(10 * time.Second))
+	r := bufio.NewReader(conn)
+	if reply := command(t, conn, r, "HELLO VERSION MIN=3.0 MAX=3.3"); !strings.Contains(reply, "RESULT=OK") {
+		t.Fatalf("HELLO = %q", reply)
+	}
+	return conn, r
+}
+
+// command sends line and returns the reply line.
+func command(t *testing.T, conn net.Conn, r *bufio.Reader, line string) string {
+	t.Helper()
+	if _, err := conn.Write([]byte(line + "\n")); err != nil {
+		t.Fatalf("sending %q: %v", line, err)
+	}
+	reply, err := r.ReadString('\n')
+	if err != nil {
+		t.Fatalf("reading reply to %q: %v", line, err)
+	}
+	return strings.TrimSpace(reply)
+}
+
+func TestProvider(t *testing.T) {
+	provider := i2cptest.NewProvider()
+	_, addr := startBridge(t, provider)
+
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatalf("Dial() error = %v", err)
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(10 * time.Second))
+	r := bufio.NewReader(conn)
+
+	if reply := command(t, conn, r, "HELLO VERSION MIN=3.0 MAX=3.3"); !strings.Contains(reply, "RESULT=OK") {
+		t.Fatalf("HELLO = %q", reply)
+	}
+	if reply := command(t, conn, r, "SESSION CREATE STYLE=STREAM ID=compat DESTINATION=TRANSIENT SIGNATURE_TYPE=7 inbound.length=1"); !strings.Contains(reply, "RESULT=OK") {
+		t.Fatalf("SESSION CREATE = %q", reply)
+	}
+	if got := provider.Sessions(); len(got) != 1 || got[0] != "compat" {
+		t.Errorf("Sessions() = %v, want [compat]", got)
+	}
+	if s := provider.Session("compat"); s == nil || s.Config == nil || s.Config.InboundLength != 1 {
+		t.Errorf("Session(compat) = %+v, want inbound length 1", s)
+	}
+	if reply := command(t, conn, r, "NAMING LOOKUP NAME=ME"); !strings.Contains(reply, "RESULT=OK") {
+		t.Errorf("NAMING LOOKUP NAME=ME = %q", reply)
+	}
+
+}
+
+func TestProvider_Disconnected(t *testing.T) {
+	provider := i2cptest.NewProvider()
+	provider.SetConnected(false)
+	if provider.IsConnected() {
+		t.Error("IsConnected() = true after SetConnected(false)")
+	}
+	if _, err := provider.CreateSessionForSAM(context.Background(), "compat", nil); !errors.Is(err, i2cptest.ErrDisconnected) {
+		t.Errorf("CreateSessionForSAM() error = %v, want ErrDisconnected", err)
+	}
+
+	provider.SetConnected(true)
+	handle, err := provider.CreateSessionForSAM(context.Background(), "compat", nil)
+	if err != nil {
+		t.Fatalf("CreateSessionForSAM() error = %v", err)
+	}
+	if got := provider.Sessions(); len(got) != 1 || got[0] != "compat" {
+		t.Errorf("Sessions() = %v, want [compat]", got)
+	}
+	handle.Close()
+	if got := provider.Sessions(); len(got) != 0 {
+		t.Errorf("Sessions() after Close = %v, want none", got)
+	}
+}
+
+func TestSession_Receive(t *testing.T) {
+	provider := i2cptest.NewProvider()
+	_, addr := startBridge(t, provider)
+	conn, r := dial(t, addr)
+
+	if reply := command(t, conn, r, "SESSION CREATE STYLE=DATAGRAM ID=dg DESTINATION=TRANSIENT SIGNATURE_TYPE=7"); !strings.Contains(reply, "RESULT=OK") {
+		t.Fatalf("SESSION CREATE = %q", reply)
+	}
+	s := provider.Session("dg")
+	if s == nil {
+		t.Fatal("Session(dg) = nil")
+	}
+
+	// A DATAGRAM2 message is not for a DATAGRAM session and is dropped
+	s.Receive(session.IncomingMessage{Source: "other", Protocol: 19, Data: []byte("dropped")})
+	if !s.Receive(session.IncomingMessage{Source: "sender", Protocol: 17, FromPort: 1, ToPort: 2, Data: []byte("hello")}) {
+		t.Fatal("Receive() = false, want the bridge to take datagrams")
+	}
+
+	reply, err := r.ReadString('\n')
+	if err != nil {
+		t.Fatalf("reading DATAGRAM RECEIVED: %v", err)
+	}
+	if !strings.HasPrefix(reply, "DATAGRAM RECEIVED DESTINATION=sender ") || !strings.Contains(reply, "SIZE=5") {
+		t.Errorf("received %q, want DATAGRAM RECEIVED from sender with SIZE=5", reply)
+	}
+	data := make([]byte, 5)
+	if _, err := io.ReadFull(r, data); err != nil || string(data) != "hello" {
+		t.Errorf("payload = %q, %v; want hello", data, err)
+	}
+}
+
+func TestSession_ReceivePrimary(t *testing.T) {
+	provider := i2cptest.NewProvider()
+	b, addr := startBridge(t, provider)
+	conn, r := dial(t, addr)
+
+	if reply := command(t, conn, r, "SESSION CREATE STYLE=PRIMARY ID=primary DESTINATION=TRANSIENT SIGNATURE_TYPE=7"); !strings.Contains(reply, "RESULT=OK") {
+		t.Fatalf("SESSION CREATE = %q", reply)
+	}
+	if reply := command(t, conn, r, "SESSION ADD STYLE=DATAGRAM ID=web LISTEN_PORT=80"); !strings.Contains(reply, "RESULT=OK") {
+		t.Fatalf("SESSION ADD web = %q", reply)
+	}
+	if reply := command(t, conn, r, "SESSION ADD STYLE=DATAGRAM ID=any"); !strings.Contains(reply, "RESULT=OK") {
+		t.Fatalf("SESSION ADD any = %q", reply)
+	}
+
+	primary, ok := b.Dependencies().Registry.Get("primary").(session.PrimarySession)
+	if !ok {
+		t.Fatal("Registry.Get(primary) is not a PRIMARY session")
+	}
+	s := provider.Session("primary")
+	if s == nil {
+		t.Fatal("Session(primary) = nil")
+	}
+	s.Receive(session.IncomingMessage{Source: "sender", Protocol: 17, ToPort: 80, Data: []byte("web")})
+	s.Receive(session.IncomingMessage{Source: "sender", Protocol: 17, ToPort: 443, Data: []byte("any")})
+
+	for _, id := range []string{"web", "any"} {
+		sub, ok := primary.Subsession(id).(session.DatagramSession)
+		if !ok {
+			t.Fatalf("Subsession(%s) is not a DATAGRAM session", id)
+		}
+		select {
+		case dg := <-sub.Receive():
+			if string(dg.Data) != id {
+				t.Errorf("Subsession(%s) received %q", id, dg.Data)
+			}
+		case <-time.After(2 * time.Second):
+			t.Errorf("Subsession(%s) received nothing", id)
+		}
+	}
+}
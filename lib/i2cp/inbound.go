@@ -0,0 +1,195 @@
+// Package i2cp provides I2CP integration for the SAM bridge.
+// This file decodes the datagrams received on a session's destination.
+package i2cp
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	"github.com/go-i2p/go-datagrams"
+	go_i2cp "github.com/go-i2p/go-i2cp"
+
+	"github.com/go-i2p/go-sam-bridge/lib/session"
+)
+
+// Datagram envelope flags, per the I2P datagram specification.
+const (
+	datagramFlagVersionMask = 0x0F
+	datagramFlagOptions     = 0x10
+	datagramFlagOfflineSig  = 0x20
+
+	datagram2Version = 0x02
+	datagram3Version = 0x03
+
+	datagramHashSize = 32
+)
+
+// ErrMalformedDatagram is returned for datagram envelopes that cannot be
+// parsed or whose signature does not verify.
+var ErrMalformedDatagram = errors.New("malformed datagram")
+
+// OnMessage sets fn to be called with each datagram received on the
+// session, decoded and, for DATAGRAM and DATAGRAM2, verified. Messages
+// that fail to decode are dropped. Streaming traffic goes to the handler
+// set with OnStreamMessage instead.
+// Implements session.MessageSource.
+func (sess *I2CPSession) OnMessage(fn func(session.IncomingMessage)) {
+	sess.mu.Lock()
+	defer sess.mu.Unlock()
+	sess.onDatagram = fn
+}
+
+// OnStreamMessage sets fn to receive the session's streaming (protocol 6)
+// messages, such as a go-streaming StreamManager's OnMessage callback.
+func (sess *I2CPSession) OnStreamMessage(fn func(*go_i2cp.Session, *go_i2cp.Destination, uint8, uint16, uint16, *go_i2cp.Stream)) {
+	sess.mu.Lock()
+	defer sess.mu.Unlock()
+	sess.onStream = fn
+}
+
+// Compile-time check that I2CPSession reports received datagrams.
+var _ session.MessageSource = (*I2CPSession)(nil)
+
+// decodeDatagram decodes an I2CP payload of the given protocol into an
+// IncomingMessage. local is the receiving destination, whose hash
+// DATAGRAM2 signatures cover.
+func decodeDatagram(local *go_i2cp.Destination, protocol uint8, srcPort, destPort uint16, payload []byte) (session.IncomingMessage, error) {
+	msg := session.IncomingMessage{
+		Protocol: int(protocol),
+		FromPort: int(srcPort),
+		ToPort:   int(destPort),
+	}
+	var err error
+	switch protocol {
+	case datagrams.ProtocolDatagram1:
+		msg.Source, msg.Data, err = decodeDatagram1(payload)
+	case datagrams.ProtocolDatagram2:
+		msg.Source, msg.Nonce, msg.Data, err = decodeDatagram2(local, payload)
+	case datagrams.ProtocolDatagram3:
+		msg.Source, msg.Data, err = decodeDatagram3(payload)
+	default:
+		msg.Data = payload
+	}
+	return msg, err
+}
+
+// readSender parses the sender destination at the start of a DATAGRAM or
+// DATAGRAM2 envelope and returns it with its length on the wire.
+func readSender(data []byte) (*go_i2cp.Destination, int, error) {
+	from, err := go_i2cp.NewDestinationFromMessage(go_i2cp.NewStream(data), go_i2cp.NewCrypto())
+	if err != nil {
+		return nil, 0, fmt.Errorf("%w: sender destination: %v", ErrMalformedDatagram, err)
+	}
+	wire := go_i2cp.NewStream(nil)
+	if err := from.WriteToMessage(wire); err != nil {
+		return nil, 0, fmt.Errorf("%w: sender destination: %v", ErrMalformedDatagram, err)
+	}
+	return from, wire.Len(), nil
+}
+
+// decodeDatagram1 decodes a DATAGRAM envelope: the sender destination,
+// its signature and the payload the signature covers.
+func decodeDatagram1(data []byte) (string, []byte, error) {
+	from, n, err := readSender(data)
+	if err != nil {
+		return "", nil, err
+	}
+	if len(data) < n+datagrams.Ed25519SignatureLength {
+		return "", nil, fmt.Errorf("%w: DATAGRAM too short for signature", ErrMalformedDatagram)
+	}
+	sig := data[n : n+datagrams.Ed25519SignatureLength]
+	payload := data[n+datagrams.Ed25519SignatureLength:]
+	if !from.VerifySignature(payload, sig) {
+		return "", nil, fmt.Errorf("%w: DATAGRAM signature does not verify", ErrMalformedDatagram)
+	}
+	return from.Base64(), payload, nil
+}
+
+// decodeDatagram2 decodes a DATAGRAM2 envelope: the sender destination,
+// flags, optional options and offline signature, the payload and a
+// signature over the receiver's hash and everything after the sender.
+// The nonce is taken from the signature, so a resent datagram repeats it
+// and DATAGRAM2 sessions drop it as a replay.
+func decodeDatagram2(local *go_i2cp.Destination, data []byte) (string, uint64, []byte, error) {
+	if local == nil {
+		return "", 0, nil, fmt.Errorf("%w: DATAGRAM2 received before the session has a destination", ErrMalformedDatagram)
+	}
+	from, n, err := readSender(data)
+	if err != nil {
+		return "", 0, nil, err
+	}
+	if len(data) < n+2+datagrams.Ed25519SignatureLength {
+		return "", 0, nil, fmt.Errorf("%w: DATAGRAM2 too short", ErrMalformedDatagram)
+	}
+	flags := data[n : n+2]
+	if flags[0] != 0 || flags[1]&0xC0 != 0 || flags[1]&datagramFlagVersionMask != datagram2Version {
+		return "", 0, nil, fmt.Errorf("%w: DATAGRAM2 flags 0x%02x%02x", ErrMalformedDatagram, flags[0], flags[1])
+	}
+
+	offset := n + 2
+	if flags[1]&datagramFlagOptions != 0 {
+		_, optLen, err := datagrams.OptionsFromBytes(data[offset:])
+		if err != nil {
+			return "", 0, nil, fmt.Errorf("%w: DATAGRAM2 options: %v", ErrMalformedDatagram, err)
+		}
+		offset += optLen
+	}
+	var offline *datagrams.OfflineSignature
+	if flags[1]&datagramFlagOfflineSig != 0 {
+		var offLen int
+		offline, offLen, err = datagrams.OfflineSignatureFromBytes(data[offset:], uint16(go_i2cp.ED25519_SHA256))
+		if err != nil {
+			return "", 0, nil, fmt.Errorf("%w: DATAGRAM2 offline signature: %v", ErrMalformedDatagram, err)
+		}
+		if offline.IsExpired() {
+			return "", 0, nil, fmt.Errorf("%w: DATAGRAM2 offline signature expired", ErrMalformedDatagram)
+		}
+		if err := offline.Verify(from); err != nil {
+			return "", 0, nil, fmt.Errorf("%w: DATAGRAM2 offline signature: %v", ErrMalformedDatagram, err)
+		}
+		offset += offLen
+	}
+	end := len(data) - datagrams.Ed25519SignatureLength
+	if end < offset {
+		return "", 0, nil, fmt.Errorf("%w: DATAGRAM2 too short for signature", ErrMalformedDatagram)
+	}
+	payload, sig := data[offset:end], data[end:]
+
+	target := local.Hash()
+	signed := make([]byte, 0, datagramHashSize+end-n)
+	signed = append(signed, target[:]...)
+	signed = append(signed, data[n:end]...)
+	valid := false
+	if offline != nil {
+		valid = offline.VerifyPayloadSignature(signed, sig)
+	} else {
+		valid = from.VerifySignature(signed, sig)
+	}
+	if !valid {
+		return "", 0, nil, fmt.Errorf("%w: DATAGRAM2 signature does not verify", ErrMalformedDatagram)
+	}
+	return from.Base64(), binary.BigEndian.Uint64(sig[:8]), payload, nil
+}
+
+// decodeDatagram3 decodes a DATAGRAM3 envelope: the sender's hash, flags,
+// optional options and the payload. The source is the Base64 hash.
+func decodeDatagram3(data []byte) (string, []byte, error) {
+	if len(data) < datagramHashSize+2 {
+		return "", nil, fmt.Errorf("%w: DATAGRAM3 too short", ErrMalformedDatagram)
+	}
+	flags := data[datagramHashSize : datagramHashSize+2]
+	if flags[0] != 0 || flags[1]&0xE0 != 0 || flags[1]&datagramFlagVersionMask != datagram3Version {
+		return "", nil, fmt.Errorf("%w: DATAGRAM3 flags 0x%02x%02x", ErrMalformedDatagram, flags[0], flags[1])
+	}
+	offset := datagramHashSize + 2
+	if flags[1]&datagramFlagOptions != 0 {
+		_, optLen, err := datagrams.OptionsFromBytes(data[offset:])
+		if err != nil {
+			return "", nil, fmt.Errorf("%w: DATAGRAM3 options: %v", ErrMalformedDatagram, err)
+		}
+		offset += optLen
+	}
+	return base64.StdEncoding.EncodeToString(data[:datagramHashSize]), data[offset:], nil
+}
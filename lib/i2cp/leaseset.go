@@ -9,7 +9,9 @@ import (
 	"time"
 
 	go_i2cp "github.com/go-i2p/go-i2cp"
+	"github.com/go-i2p/go-sam-bridge/lib/destination"
 	"github.com/go-i2p/go-sam-bridge/lib/handler"
+	"github.com/go-i2p/go-sam-bridge/lib/session"
 )
 
 // LeasesetAdapter implements handler.LeasesetLookupProvider using go-i2cp.
@@ -136,9 +138,14 @@ func LeasesetAdapterFromClient(client *Client, sessionID string) (*LeasesetAdapt
 // Per SAMv3.md, NAMING LOOKUP should resolve:
 //   - .b32.i2p addresses (base32-encoded destination hashes)
 //   - .i2p hostnames (resolved via the I2P router's address book / network database)
+//
+// Lookups are cached as configured by DefaultLookupCacheConfig until
+// SetLookupCache changes it.
 type DestinationResolverAdapter struct {
-	session *go_i2cp.Session
-	timeout time.Duration
+	session     *go_i2cp.Session
+	timeout     time.Duration
+	cache       *lookupCache
+	parallelism int
 }
 
 // NewDestinationResolverAdapter creates a DestinationResolver adapter for the given session.
@@ -149,9 +156,16 @@ func NewDestinationResolverAdapter(session *go_i2cp.Session, timeout time.Durati
 	return &DestinationResolverAdapter{
 		session: session,
 		timeout: timeout,
+		cache:   newLookupCache(DefaultLookupCacheConfig()),
 	}, nil
 }
 
+// SetLookupCache replaces the lookup cache with an empty one sized by
+// config. It must not be called concurrently with Resolve.
+func (a *DestinationResolverAdapter) SetLookupCache(config LookupCacheConfig) {
+	a.cache = newLookupCache(config)
+}
+
 // Resolve looks up an I2P destination by name.
 // Implements handler.DestinationResolver interface.
 //
@@ -159,35 +173,61 @@ func NewDestinationResolverAdapter(session *go_i2cp.Session, timeout time.Durati
 //   - A .b32.i2p address (e.g., "abcd...wxyz.b32.i2p")
 //   - A .i2p hostname (e.g., "example.i2p")
 //
-// Returns the full Base64-encoded destination on success.
+// Returns the full Base64-encoded destination on success, or an error
+// wrapping ErrDestinationNotFound if the router has none.
 func (a *DestinationResolverAdapter) Resolve(ctx context.Context, name string) (string, error) {
 	if a.session == nil {
 		return "", fmt.Errorf("session not available")
 	}
+	return a.cache.resolve(name, func() (string, error) {
+		return lookupDestination(ctx, a.session, a.timeout, name)
+	})
+}
 
-	dest, err := a.session.LookupDestinationWithContext(ctx, name, a.timeout)
-	if err != nil {
-		return "", err
-	}
+// SetBatchParallelism limits how many lookups ResolveBatch runs at once.
+// Zero or less uses handler.DefaultBatchParallelism. It must not be
+// called concurrently with ResolveBatch.
+func (a *DestinationResolverAdapter) SetBatchParallelism(n int) {
+	a.parallelism = n
+}
 
-	if dest == nil {
-		return "", fmt.Errorf("destination not found: %s", name)
-	}
+// ResolveBatch looks up names concurrently, through the lookup cache.
+// Implements handler.BatchResolver interface.
+func (a *DestinationResolverAdapter) ResolveBatch(ctx context.Context, names []string) []handler.LookupResult {
+	return handler.ResolveBatch(ctx, a, names, a.parallelism)
+}
 
-	return dest.Base64(), nil
+// ResolveBlinded looks up the destination behind a .b33 address.
+// Implements handler.BlindedResolver interface.
+func (a *DestinationResolverAdapter) ResolveBlinded(ctx context.Context, addr *destination.BlindedAddress, name string, creds handler.BlindedCredentials) (string, error) {
+	if a.session == nil {
+		return "", fmt.Errorf("session not available")
+	}
+	return resolveBlinded(ctx, a.session, a.timeout, addr, name, creds)
 }
 
 // Compile-time check that DestinationResolverAdapter implements handler.DestinationResolver.
 var _ handler.DestinationResolver = (*DestinationResolverAdapter)(nil)
 
+// Compile-time check that DestinationResolverAdapter implements handler.BlindedResolver.
+var _ handler.BlindedResolver = (*DestinationResolverAdapter)(nil)
+
+// Compile-time check that DestinationResolverAdapter implements handler.BatchResolver.
+var _ handler.BatchResolver = (*DestinationResolverAdapter)(nil)
+
 // ClientDestinationResolverAdapter implements handler.DestinationResolver using the I2CP client.
 // It uses the first available I2CP session for lookups, making it suitable for global resolver use.
 //
 // This adapter is useful for NAMING LOOKUP commands that may occur before or after
 // specific session creation.
+//
+// Lookups are cached as configured by DefaultLookupCacheConfig until
+// SetLookupCache changes it.
 type ClientDestinationResolverAdapter struct {
-	client  *Client
-	timeout time.Duration
+	client      *Client
+	timeout     time.Duration
+	cache       *lookupCache
+	parallelism int
 }
 
 // NewClientDestinationResolverAdapter creates a DestinationResolver adapter using the I2CP client.
@@ -198,44 +238,150 @@ func NewClientDestinationResolverAdapter(client *Client, timeout time.Duration)
 	return &ClientDestinationResolverAdapter{
 		client:  client,
 		timeout: timeout,
+		cache:   newLookupCache(DefaultLookupCacheConfig()),
 	}, nil
 }
 
+// SetLookupCache replaces the lookup cache with an empty one sized by
+// config. It must not be called concurrently with Resolve.
+func (a *ClientDestinationResolverAdapter) SetLookupCache(config LookupCacheConfig) {
+	a.cache = newLookupCache(config)
+}
+
 // Resolve looks up an I2P destination by name using any available session.
 // Implements handler.DestinationResolver interface.
 func (a *ClientDestinationResolverAdapter) Resolve(ctx context.Context, name string) (string, error) {
+	return a.cache.resolve(name, func() (string, error) {
+		underlyingSession, err := a.lookupSession()
+		if err != nil {
+			return "", err
+		}
+		return lookupDestination(ctx, underlyingSession, a.timeout, name)
+	})
+}
+
+// SetBatchParallelism limits how many lookups ResolveBatch runs at once.
+// Zero or less uses handler.DefaultBatchParallelism. It must not be
+// called concurrently with ResolveBatch.
+func (a *ClientDestinationResolverAdapter) SetBatchParallelism(n int) {
+	a.parallelism = n
+}
+
+// ResolveBatch looks up names concurrently using any available session,
+// through the lookup cache.
+// Implements handler.BatchResolver interface.
+func (a *ClientDestinationResolverAdapter) ResolveBatch(ctx context.Context, names []string) []handler.LookupResult {
+	return handler.ResolveBatch(ctx, a, names, a.parallelism)
+}
+
+// ResolveBlinded looks up the destination behind a .b33 address using any
+// available session.
+// Implements handler.BlindedResolver interface.
+func (a *ClientDestinationResolverAdapter) ResolveBlinded(ctx context.Context, addr *destination.BlindedAddress, name string, creds handler.BlindedCredentials) (string, error) {
+	underlyingSession, err := a.lookupSession()
+	if err != nil {
+		return "", err
+	}
+	return resolveBlinded(ctx, underlyingSession, a.timeout, addr, name, creds)
+}
+
+// lookupSession returns the go-i2cp session used for lookups.
+func (a *ClientDestinationResolverAdapter) lookupSession() (*go_i2cp.Session, error) {
 	if a.client == nil {
-		return "", fmt.Errorf("client not available")
+		return nil, fmt.Errorf("client not available")
 	}
 
 	// Get the underlying go-i2cp client
 	i2cpClient := a.client.I2CPClient()
 	if i2cpClient == nil {
-		return "", fmt.Errorf("I2CP client not connected")
+		return nil, fmt.Errorf("I2CP client not connected")
 	}
 
 	// Get the first available session for lookup
-	session := a.client.GetFirstSession()
-	if session == nil {
-		return "", fmt.Errorf("no active session available for lookup")
+	sess := a.client.GetFirstSession()
+	if sess == nil {
+		return nil, fmt.Errorf("no active session available for lookup")
 	}
 
-	underlyingSession := session.Session()
+	underlyingSession := sess.Session()
 	if underlyingSession == nil {
-		return "", fmt.Errorf("underlying I2CP session not available")
+		return nil, fmt.Errorf("underlying I2CP session not available")
 	}
+	return underlyingSession, nil
+}
+
+// Compile-time check that ClientDestinationResolverAdapter implements handler.DestinationResolver.
+var _ handler.DestinationResolver = (*ClientDestinationResolverAdapter)(nil)
 
-	dest, err := underlyingSession.LookupDestinationWithContext(ctx, name, a.timeout)
+// Compile-time check that ClientDestinationResolverAdapter implements handler.BlindedResolver.
+var _ handler.BlindedResolver = (*ClientDestinationResolverAdapter)(nil)
+
+// Compile-time check that ClientDestinationResolverAdapter implements handler.BatchResolver.
+var _ handler.BatchResolver = (*ClientDestinationResolverAdapter)(nil)
+
+// lookupDestination looks name up through sess and returns the
+// Base64-encoded destination.
+func lookupDestination(ctx context.Context, sess *go_i2cp.Session, timeout time.Duration, name string) (string, error) {
+	dest, err := sess.LookupDestinationWithContext(ctx, name, timeout)
 	if err != nil {
 		return "", err
 	}
-
 	if dest == nil {
-		return "", fmt.Errorf("destination not found: %s", name)
+		return "", notFound(name)
 	}
+	return dest.Base64(), nil
+}
+
+// blindingInfoTTL is how long the router keeps the credentials sent for a
+// .b33 lookup.
+const blindingInfoTTL = 24 * time.Hour
 
+// resolveBlinded sends the blinding info for addr to the router, so it can
+// decrypt the lease set, and then looks the address up.
+func resolveBlinded(ctx context.Context, sess *go_i2cp.Session, timeout time.Duration, addr *destination.BlindedAddress, name string, creds handler.BlindedCredentials) (string, error) {
+	info, err := blindingInfo(addr, creds, time.Now().Add(blindingInfoTTL))
+	if err != nil {
+		return "", err
+	}
+	if err := sess.SendBlindingInfo(info); err != nil {
+		return "", fmt.Errorf("failed to send blinding info: %w", err)
+	}
+
+	dest, err := sess.LookupDestinationWithContext(ctx, name, timeout)
+	if err != nil {
+		return "", err
+	}
+	if dest == nil {
+		return "", notFound(name)
+	}
 	return dest.Base64(), nil
 }
 
-// Compile-time check that ClientDestinationResolverAdapter implements handler.DestinationResolver.
-var _ handler.DestinationResolver = (*ClientDestinationResolverAdapter)(nil)
+// blindingInfo builds the I2CP BlindingInfo for a .b33 lookup, valid
+// until expires.
+func blindingInfo(addr *destination.BlindedAddress, creds handler.BlindedCredentials, expires time.Time) (*go_i2cp.BlindingInfo, error) {
+	info, err := go_i2cp.NewBlindingInfoWithSigningKey(uint16(addr.SigType), addr.PublicKey, uint16(addr.BlindedSigType), uint32(expires.Unix()))
+	if err != nil {
+		return nil, fmt.Errorf("invalid blinded address: %w", err)
+	}
+	if len(creds.Key) > 0 {
+		var auth *go_i2cp.PerClientAuthConfig
+		switch creds.AuthType {
+		case session.LeaseSetAuthPSK:
+			auth, err = go_i2cp.NewPerClientAuthPSK(creds.Key)
+		default:
+			auth, err = go_i2cp.NewPerClientAuthDH(creds.Key)
+		}
+		if err == nil {
+			err = info.SetPerClientAuth(auth)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid client key: %w", err)
+		}
+	}
+	// Set after SetPerClientAuth, which replaces the lookup password.
+	if creds.Secret != "" {
+		info.SetLookupPassword(creds.Secret)
+	}
+	return info, nil
+}
@@ -1,11 +1,15 @@
 package i2cp
 
 import (
+	"bytes"
 	"context"
 	"testing"
 	"time"
 
+	go_i2cp "github.com/go-i2p/go-i2cp"
+	"github.com/go-i2p/go-sam-bridge/lib/destination"
 	"github.com/go-i2p/go-sam-bridge/lib/handler"
+	"github.com/go-i2p/go-sam-bridge/lib/session"
 )
 
 // TestNewLeasesetAdapter verifies basic adapter creation.
@@ -55,6 +59,86 @@ func TestNewDestinationResolverAdapter(t *testing.T) {
 	})
 }
 
+// TestClientDestinationResolverAdapter_ResolveBatch verifies each name
+// of a batch gets a result when no session is available to look up with.
+func TestClientDestinationResolverAdapter_ResolveBatch(t *testing.T) {
+	resolver, err := NewClientDestinationResolverAdapter(NewClient(nil), time.Second)
+	if err != nil {
+		t.Fatalf("NewClientDestinationResolverAdapter() error = %v", err)
+	}
+	resolver.SetBatchParallelism(2)
+
+	names := []string{"a.i2p", "b.i2p", "c.i2p"}
+	results := resolver.ResolveBatch(context.Background(), names)
+	if len(results) != len(names) {
+		t.Fatalf("got %d results, want %d", len(results), len(names))
+	}
+	for i, r := range results {
+		if r.Name != names[i] || r.Err == nil {
+			t.Errorf("results[%d] = %+v, want an error for %s", i, r, names[i])
+		}
+	}
+}
+
+// TestBlindingInfo verifies the BlindingInfo sent before a .b33 lookup.
+func TestBlindingInfo(t *testing.T) {
+	addr := &destination.BlindedAddress{
+		SigType:        destination.SigTypeEd25519,
+		BlindedSigType: destination.SigTypeRedDSA_SHA512_Ed25519,
+		PublicKey:      bytes.Repeat([]byte{1}, 32),
+	}
+	expires := time.Unix(1700000000, 0)
+	key := bytes.Repeat([]byte{2}, 32)
+
+	t.Run("no credentials", func(t *testing.T) {
+		info, err := blindingInfo(addr, handler.BlindedCredentials{}, expires)
+		if err != nil {
+			t.Fatalf("blindingInfo() error = %v", err)
+		}
+		if info.EndpointType != go_i2cp.BLINDING_ENDPOINT_SIGKEY || info.BlindedSigType != 11 || info.Expiration != 1700000000 {
+			t.Errorf("blindingInfo() = %+v", info)
+		}
+		if !bytes.Equal(info.Endpoint, append([]byte{0, 7}, addr.PublicKey...)) {
+			t.Errorf("blindingInfo() Endpoint = %x", info.Endpoint)
+		}
+		if info.PerClientAuth || info.LookupPassword != "" {
+			t.Errorf("blindingInfo() should carry no credentials, got %+v", info)
+		}
+	})
+
+	t.Run("secret and PSK key", func(t *testing.T) {
+		creds := handler.BlindedCredentials{Secret: "hunter2", AuthType: session.LeaseSetAuthPSK, Key: key}
+		info, err := blindingInfo(addr, creds, expires)
+		if err != nil {
+			t.Fatalf("blindingInfo() error = %v", err)
+		}
+		if !info.PerClientAuth || info.AuthScheme != go_i2cp.BLINDING_AUTH_SCHEME_PSK || !bytes.Equal(info.DecryptionKey, key) {
+			t.Errorf("blindingInfo() auth = %v/%d/%x, want PSK key", info.PerClientAuth, info.AuthScheme, info.DecryptionKey)
+		}
+		if info.LookupPassword != "hunter2" {
+			t.Errorf("blindingInfo() LookupPassword = %q, want hunter2", info.LookupPassword)
+		}
+	})
+
+	t.Run("DH key", func(t *testing.T) {
+		creds := handler.BlindedCredentials{AuthType: session.LeaseSetAuthDH, Key: key}
+		info, err := blindingInfo(addr, creds, expires)
+		if err != nil {
+			t.Fatalf("blindingInfo() error = %v", err)
+		}
+		if !info.PerClientAuth || info.AuthScheme != go_i2cp.BLINDING_AUTH_SCHEME_DH {
+			t.Errorf("blindingInfo() auth = %v/%d, want DH", info.PerClientAuth, info.AuthScheme)
+		}
+	})
+
+	t.Run("short key", func(t *testing.T) {
+		creds := handler.BlindedCredentials{AuthType: session.LeaseSetAuthDH, Key: key[:8]}
+		if _, err := blindingInfo(addr, creds, expires); err == nil {
+			t.Error("blindingInfo() expected error for short key")
+		}
+	})
+}
+
 // TestLeasesetAdapterInterface verifies interface compliance.
 func TestLeasesetAdapterInterface(t *testing.T) {
 	// Compile-time check is in the source file, but let's verify at runtime too
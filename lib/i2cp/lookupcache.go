@@ -0,0 +1,99 @@
+package i2cp
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/golang-lru/v2/expirable"
+)
+
+// ErrDestinationNotFound indicates the router found no destination for a
+// name. Only these failures are cached as negative lookup results.
+var ErrDestinationNotFound = errors.New("destination not found")
+
+// Default lookup cache settings.
+const (
+	DefaultLookupCacheSize         = 1024
+	DefaultLookupCacheTTL          = 10 * time.Minute
+	DefaultNegativeLookupCacheSize = 256
+	DefaultNegativeLookupCacheTTL  = time.Minute
+)
+
+// LookupCacheConfig sizes the host lookup cache of the destination
+// resolvers. Found destinations and names the router could not find are
+// cached separately; a zero size disables that half of the cache.
+type LookupCacheConfig struct {
+	// Size and TTL bound the cache of found destinations.
+	Size int
+	TTL  time.Duration
+
+	// NegativeSize and NegativeTTL bound the cache of names that were
+	// not found. Keep NegativeTTL short so new hosts appear quickly.
+	NegativeSize int
+	NegativeTTL  time.Duration
+}
+
+// DefaultLookupCacheConfig returns the cache settings resolvers start with.
+func DefaultLookupCacheConfig() LookupCacheConfig {
+	return LookupCacheConfig{
+		Size:         DefaultLookupCacheSize,
+		TTL:          DefaultLookupCacheTTL,
+		NegativeSize: DefaultNegativeLookupCacheSize,
+		NegativeTTL:  DefaultNegativeLookupCacheTTL,
+	}
+}
+
+// lookupCache caches destination lookups by lowercased name.
+// It is safe for concurrent use.
+type lookupCache struct {
+	found   *expirable.LRU[string, string]
+	missing *expirable.LRU[string, struct{}]
+}
+
+// newLookupCache creates a cache for config.
+func newLookupCache(config LookupCacheConfig) *lookupCache {
+	c := &lookupCache{}
+	if config.Size > 0 {
+		c.found = expirable.NewLRU[string, string](config.Size, nil, config.TTL)
+	}
+	if config.NegativeSize > 0 {
+		c.missing = expirable.NewLRU[string, struct{}](config.NegativeSize, nil, config.NegativeTTL)
+	}
+	return c
+}
+
+// resolve returns the cached result for name, or calls lookup and caches
+// a found destination or an ErrDestinationNotFound failure. Other errors,
+// such as timeouts, are not cached.
+func (c *lookupCache) resolve(name string, lookup func() (string, error)) (string, error) {
+	if c == nil {
+		return lookup()
+	}
+	key := strings.ToLower(name)
+	if c.found != nil {
+		if dest, ok := c.found.Get(key); ok {
+			return dest, nil
+		}
+	}
+	if c.missing != nil {
+		if _, ok := c.missing.Get(key); ok {
+			return "", notFound(name)
+		}
+	}
+
+	dest, err := lookup()
+	switch {
+	case err == nil && c.found != nil:
+		c.found.Add(key, dest)
+	case errors.Is(err, ErrDestinationNotFound) && c.missing != nil:
+		c.missing.Add(key, struct{}{})
+	}
+	return dest, err
+}
+
+// notFound returns ErrDestinationNotFound for name.
+func notFound(name string) error {
+	return fmt.Errorf("%w: %s", ErrDestinationNotFound, name)
+}
@@ -0,0 +1,100 @@
+package i2cp
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestLookupCache(t *testing.T) {
+	cache := newLookupCache(DefaultLookupCacheConfig())
+	calls := 0
+	lookup := func(dest string, err error) func() (string, error) {
+		return func() (string, error) {
+			calls++
+			return dest, err
+		}
+	}
+
+	t.Run("caches found destinations", func(t *testing.T) {
+		calls = 0
+		for i := 0; i < 2; i++ {
+			dest, err := cache.resolve("Example.i2p", lookup("dest", nil))
+			if err != nil || dest != "dest" {
+				t.Fatalf("resolve() = %q, %v, want dest", dest, err)
+			}
+		}
+		if _, err := cache.resolve("example.i2p", lookup("", errors.New("unreachable"))); err != nil {
+			t.Errorf("resolve() of cached name with other case error = %v", err)
+		}
+		if calls != 1 {
+			t.Errorf("lookup called %d times, want 1", calls)
+		}
+	})
+
+	t.Run("caches not found", func(t *testing.T) {
+		calls = 0
+		for i := 0; i < 2; i++ {
+			_, err := cache.resolve("missing.i2p", lookup("", notFound("missing.i2p")))
+			if !errors.Is(err, ErrDestinationNotFound) {
+				t.Fatalf("resolve() error = %v, want ErrDestinationNotFound", err)
+			}
+		}
+		if calls != 1 {
+			t.Errorf("lookup called %d times, want 1", calls)
+		}
+	})
+
+	t.Run("does not cache other errors", func(t *testing.T) {
+		calls = 0
+		for i := 0; i < 2; i++ {
+			if _, err := cache.resolve("slow.i2p", lookup("", errors.New("timeout"))); err == nil {
+				t.Fatal("resolve() error = nil, want timeout")
+			}
+		}
+		if calls != 2 {
+			t.Errorf("lookup called %d times, want 2", calls)
+		}
+	})
+}
+
+func TestLookupCache_Expiry(t *testing.T) {
+	cache := newLookupCache(LookupCacheConfig{
+		Size:         8,
+		TTL:          time.Hour,
+		NegativeSize: 8,
+		NegativeTTL:  10 * time.Millisecond,
+	})
+	calls := 0
+	lookup := func() (string, error) {
+		calls++
+		return "", notFound("new.i2p")
+	}
+
+	cache.resolve("new.i2p", lookup)
+	time.Sleep(50 * time.Millisecond)
+	cache.resolve("new.i2p", lookup)
+	if calls != 2 {
+		t.Errorf("lookup called %d times, want 2 after the negative entry expired", calls)
+	}
+}
+
+func TestLookupCache_Disabled(t *testing.T) {
+	for name, cache := range map[string]*lookupCache{
+		"nil":        nil,
+		"zero sizes": newLookupCache(LookupCacheConfig{}),
+	} {
+		t.Run(name, func(t *testing.T) {
+			calls := 0
+			for i := 0; i < 2; i++ {
+				cache.resolve("example.i2p", func() (string, error) {
+					calls++
+					return "dest", nil
+				})
+			}
+			if calls != 2 {
+				t.Errorf("lookup called %d times, want 2", calls)
+			}
+		})
+	}
+}
@@ -28,6 +28,10 @@ func MapSAMConfigToI2CP(samConfig *session.SessionConfig) *SessionConfig {
 		InboundBackupQuantity:  samConfig.InboundBackupQuantity,
 		OutboundBackupQuantity: samConfig.OutboundBackupQuantity,
 		FastReceive:            true, // Always enable for better performance
+		LeaseSetType:           samConfig.LeaseSetType,
+		LeaseSetAuthType:       samConfig.LeaseSetAuthType,
+		LeaseSetSecret:         samConfig.LeaseSetSecret,
+		LeaseSetClients:        samConfig.LeaseSetClients,
 	}
 
 	// Map idle handling
@@ -115,6 +119,12 @@ func BuildFromSAMConfig(samConfig *session.SessionConfig) I2CPOptions {
 		}
 		opts.Set("i2cp.leaseSetEncType", strings.Join(encTypes, ","))
 	}
+	if samConfig.LeaseSetType != session.LeaseSetTypeDefault {
+		opts.SetInt("i2cp.leaseSetType", samConfig.LeaseSetType)
+	}
+	if samConfig.LeaseSetAuthType != session.LeaseSetAuthNone {
+		opts.SetInt("i2cp.leaseSetAuthType", samConfig.LeaseSetAuthType)
+	}
 
 	// Performance options
 	opts.SetBool("i2cp.fastReceive", true)
@@ -0,0 +1,107 @@
+// Package i2cp provides I2CP integration for the SAM bridge.
+// This file adapts Client to the session.I2CPSessionProvider interface.
+package i2cp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/go-i2p/go-sam-bridge/lib/session"
+)
+
+// SessionProviderAdapter implements session.I2CPSessionProvider using the I2CP client.
+// It converts SAM session configuration to I2CP session configuration and
+// reports router disconnects to a registered error handler.
+type SessionProviderAdapter struct {
+	client *Client
+}
+
+// NewSessionProviderAdapter creates a session provider backed by client.
+// The client should already be connected.
+func NewSessionProviderAdapter(client *Client) *SessionProviderAdapter {
+	return &SessionProviderAdapter{client: client}
+}
+
+// Client returns the underlying I2CP client.
+func (a *SessionProviderAdapter) Client() *Client {
+	return a.client
+}
+
+// CreateSessionForSAM creates an I2CP session for a SAM session.
+// Implements session.I2CPSessionProvider interface.
+func (a *SessionProviderAdapter) CreateSessionForSAM(ctx context.Context, samSessionID string, config *session.SessionConfig) (session.I2CPSessionHandle, error) {
+	i2cpConfig := &SessionConfigFromSession{
+		SignatureType:          config.SignatureType,
+		EncryptionTypes:        config.EncryptionTypes,
+		InboundQuantity:        config.InboundQuantity,
+		OutboundQuantity:       config.OutboundQuantity,
+		InboundLength:          config.InboundLength,
+		OutboundLength:         config.OutboundLength,
+		InboundBackupQuantity:  config.InboundBackupQuantity,
+		OutboundBackupQuantity: config.OutboundBackupQuantity,
+		FastReceive:            config.FastReceive,
+		ReduceIdleTime:         config.ReduceIdleTime,
+		CloseIdleTime:          config.CloseIdleTime,
+		LeaseSetType:           config.LeaseSetType,
+		LeaseSetAuthType:       config.LeaseSetAuthType,
+		LeaseSetSecret:         config.LeaseSetSecret,
+		LeaseSetClients:        config.LeaseSetClients,
+		OfflineSignature:       config.OfflineSignature,
+	}
+	return a.client.CreateSessionForSAM(ctx, samSessionID, i2cpConfig)
+}
+
+// IsConnected returns true if the client is connected to the I2P router.
+// Implements session.I2CPSessionProvider interface.
+func (a *SessionProviderAdapter) IsConnected() bool {
+	return a.client.IsConnected()
+}
+
+// RouterInfo returns the connected router's information.
+// Implements session.RouterInfoProvider interface.
+func (a *SessionProviderAdapter) RouterInfo() (session.RouterInfo, bool) {
+	return a.client.RouterInfo()
+}
+
+// I2CPHealth returns the health of the router connection.
+// Implements session.I2CPHealthProvider interface.
+func (a *SessionProviderAdapter) I2CPHealth() session.I2CPHealth {
+	return a.client.Health()
+}
+
+// SetErrorHandler reports I2P router disconnects, and routers that stop
+// answering requests, to fn. It replaces OnDisconnected and
+// OnUnresponsive and keeps the client's other callbacks.
+func (a *SessionProviderAdapter) SetErrorHandler(fn func(error)) {
+	a.client.mu.Lock()
+	defer a.client.mu.Unlock()
+
+	var callbacks ClientCallbacks
+	if a.client.callbacks != nil {
+		callbacks = *a.client.callbacks
+	}
+	callbacks.OnDisconnected = func(err error) {
+		if err == nil {
+			err = errors.New("disconnected from I2P router")
+		}
+		fn(err)
+	}
+	callbacks.OnUnresponsive = func(waited time.Duration) {
+		fn(fmt.Errorf("I2P router has not answered a request for %s", waited.Round(time.Second)))
+	}
+	a.client.callbacks = &callbacks
+}
+
+// Close closes the underlying I2CP client and all of its sessions.
+func (a *SessionProviderAdapter) Close() error {
+	return a.client.Close()
+}
+
+// Compile-time check that SessionProviderAdapter implements the session provider interfaces.
+var (
+	_ session.I2CPSessionProvider = (*SessionProviderAdapter)(nil)
+	_ session.RouterInfoProvider  = (*SessionProviderAdapter)(nil)
+	_ session.I2CPHealthProvider  = (*SessionProviderAdapter)(nil)
+)
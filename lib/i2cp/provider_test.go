@@ -0,0 +1,45 @@
+package i2cp
+
+import "testing"
+
+func TestSessionProviderAdapter(t *testing.T) {
+	client := NewClient(nil)
+	adapter := NewSessionProviderAdapter(client)
+
+	if adapter.Client() != client {
+		t.Error("Client() should return the wrapped client")
+	}
+	if adapter.IsConnected() {
+		t.Error("IsConnected() should be false for an unconnected client")
+	}
+	if err := adapter.Close(); err != nil {
+		t.Errorf("Close() error = %v", err)
+	}
+}
+
+func TestSessionProviderAdapter_SetErrorHandler(t *testing.T) {
+	client := NewClient(nil)
+	adapter := NewSessionProviderAdapter(client)
+
+	var got error
+	adapter.SetErrorHandler(func(err error) { got = err })
+
+	if client.callbacks == nil || client.callbacks.OnDisconnected == nil {
+		t.Fatal("SetErrorHandler() should set OnDisconnected")
+	}
+	client.callbacks.OnDisconnected(nil)
+	if got == nil {
+		t.Error("handler should receive an error for a disconnect without cause")
+	}
+}
+
+func TestSessionProviderAdapter_SetErrorHandler_KeepsCallbacks(t *testing.T) {
+	client := NewClient(nil)
+	client.SetCallbacks(&ClientCallbacks{OnFailover: func(string) {}})
+
+	NewSessionProviderAdapter(client).SetErrorHandler(func(error) {})
+
+	if client.callbacks.OnFailover == nil {
+		t.Error("SetErrorHandler() dropped OnFailover")
+	}
+}
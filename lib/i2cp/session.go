@@ -2,12 +2,17 @@
 package i2cp
 
 import (
+	"bytes"
 	"context"
+	"crypto/ed25519"
+	"encoding/binary"
 	"fmt"
 	"sync"
 	"time"
 
 	go_i2cp "github.com/go-i2p/go-i2cp"
+
+	"github.com/go-i2p/go-sam-bridge/lib/session"
 )
 
 // I2CPSession wraps a go-i2cp Session to provide SAM-specific functionality.
@@ -49,6 +54,15 @@ type I2CPSession struct {
 
 	// tunnelReadyOnce ensures tunnelReady is only closed once.
 	tunnelReadyOnce sync.Once
+
+	// tunnels counts tunnel events; onTunnelEvent is told of each.
+	tunnels       session.TunnelHealth
+	onTunnelEvent func(session.TunnelEvent)
+
+	// onDatagram receives decoded datagrams and onStream streaming
+	// messages; see OnMessage and OnStreamMessage.
+	onDatagram func(session.IncomingMessage)
+	onStream   func(*go_i2cp.Session, *go_i2cp.Destination, uint8, uint16, uint16, *go_i2cp.Stream)
 }
 
 // SessionConfig holds configuration for an I2CP session.
@@ -90,6 +104,26 @@ type SessionConfig struct {
 	// ExistingDestination is an existing private key to use.
 	// If nil, a new transient destination is generated.
 	ExistingDestination []byte
+
+	// LeaseSetType is the i2cp.leaseSetType; session.LeaseSetTypeEncrypted
+	// publishes an encrypted LeaseSet2.
+	LeaseSetType int
+
+	// LeaseSetAuthType is the per-client authentication scheme of an
+	// encrypted lease set (session.LeaseSetAuthDH or LeaseSetAuthPSK).
+	LeaseSetAuthType int
+
+	// LeaseSetSecret is the lookup secret of an encrypted lease set.
+	LeaseSetSecret string
+
+	// LeaseSetClients are the clients authorized to read an encrypted
+	// lease set.
+	LeaseSetClients []session.LeaseSetClient
+
+	// OfflineSignature is the offline signature of an offline-signed
+	// destination. Its transient key signs for the session in place of
+	// the destination's offline signing key.
+	OfflineSignature *session.OfflineSignature
 }
 
 // DefaultSessionConfig returns a SessionConfig with recommended defaults.
@@ -166,6 +200,10 @@ func (c *Client) CreateSession(ctx context.Context, samSessionID string, config
 
 	// Configure session properties via the session's config
 	sess.applyConfig(config)
+	sess.applyLeaseSet(config)
+	if err := sess.applyOfflineSignature(config); err != nil {
+		return nil, err
+	}
 
 	// Apply timeout to context
 	sessionCtx := ctx
@@ -194,6 +232,52 @@ func (c *Client) CreateSession(ctx context.Context, samSessionID string, config
 	return sess, nil
 }
 
+// reopen re-creates the session on i2cpClient with the same
+// configuration, after the client failed over to another router. Like
+// CreateSession, the new session has the destination go-i2cp generates
+// for it. Tunnel readiness is not reset: SAM clients were already told
+// the session is ready.
+func (sess *I2CPSession) reopen(ctx context.Context, i2cpClient *go_i2cp.Client) error {
+	sess.mu.Lock()
+	if !sess.active {
+		sess.mu.Unlock()
+		return nil
+	}
+	config := sess.config
+	sess.mu.Unlock()
+
+	i2cpSession := go_i2cp.NewSession(i2cpClient, go_i2cp.SessionCallbacks{
+		OnMessage:       sess.onMessage,
+		OnStatus:        sess.onStatus,
+		OnMessageStatus: sess.onMessageStatus,
+	})
+
+	sess.mu.Lock()
+	sess.session = i2cpSession
+	sess.mu.Unlock()
+
+	sess.applyConfig(config)
+	sess.applyLeaseSet(config)
+	if err := sess.applyOfflineSignature(config); err != nil {
+		return err
+	}
+
+	sessionCtx := ctx
+	if timeout := sess.client.config.SessionTimeout; timeout > 0 {
+		var cancel context.CancelFunc
+		sessionCtx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	if err := i2cpClient.CreateSession(sessionCtx, i2cpSession); err != nil {
+		return fmt.Errorf("failed to re-create I2CP session: %w", err)
+	}
+
+	sess.mu.Lock()
+	sess.destination = i2cpSession.Destination()
+	sess.mu.Unlock()
+	return nil
+}
+
 // applyConfig applies the SessionConfig to the go-i2cp session's config.
 func (sess *I2CPSession) applyConfig(config *SessionConfig) {
 	sessionConfig := sess.session.Config()
@@ -232,6 +316,93 @@ func (sess *I2CPSession) applyConfig(config *SessionConfig) {
 	sessionConfig.SetProperty(go_i2cp.SESSION_CONFIG_PROP_I2CP_MESSAGE_RELIABILITY, "none")
 }
 
+// applyLeaseSet enables go-i2cp's encrypted LeaseSet2 support when the
+// configuration asks for i2cp.leaseSetType=5.
+func (sess *I2CPSession) applyLeaseSet(config *SessionConfig) {
+	if scheme, flags, params, ok := blindingParams(config); ok {
+		sess.session.StoreBlindingInfo(scheme, flags, params)
+	}
+}
+
+// applyOfflineSignature attaches the offline signature and the transient
+// key pair of an offline-signed destination to the go-i2cp session. It
+// does nothing if config has no offline signature. go-i2cp signs only
+// with Ed25519 transient keys, so other transient types are rejected.
+func (sess *I2CPSession) applyOfflineSignature(config *SessionConfig) error {
+	offline := config.OfflineSignature
+	if offline == nil {
+		return nil
+	}
+	keyPair, err := transientKeyPair(offline)
+	if err != nil {
+		return err
+	}
+	sessionConfig := sess.session.Config()
+	if err := sessionConfig.SetOfflineSignature(uint32(offline.Expires), offline.TransientPublicKey, offline.Signature); err != nil {
+		return fmt.Errorf("invalid offline signature: %w", err)
+	}
+	return sessionConfig.SetTransientKeyPair(keyPair)
+}
+
+// transientKeyPair returns the Ed25519 transient key pair of an offline
+// signature. The private key may be the 32-byte seed or the 64-byte
+// expanded key.
+func transientKeyPair(offline *session.OfflineSignature) (*go_i2cp.Ed25519KeyPair, error) {
+	if offline.TransientType != int(go_i2cp.ED25519_SHA256) {
+		return nil, fmt.Errorf("offline signature: unsupported transient signature type %d", offline.TransientType)
+	}
+	if len(offline.TransientPublicKey) != ed25519.PublicKeySize {
+		return nil, fmt.Errorf("offline signature: transient public key is %d bytes, want %d", len(offline.TransientPublicKey), ed25519.PublicKeySize)
+	}
+
+	var private ed25519.PrivateKey
+	switch len(offline.TransientPrivateKey) {
+	case ed25519.SeedSize:
+		private = ed25519.NewKeyFromSeed(offline.TransientPrivateKey)
+		defer clear(private)
+	case ed25519.PrivateKeySize:
+		private = ed25519.PrivateKey(offline.TransientPrivateKey)
+	default:
+		return nil, fmt.Errorf("offline signature: missing or malformed transient private key")
+	}
+	if !bytes.Equal(private.Public().(ed25519.PublicKey), offline.TransientPublicKey) {
+		return nil, fmt.Errorf("offline signature: transient private key does not match its public key")
+	}
+
+	// go-i2cp builds key pairs only from its wire format: the algorithm
+	// type, then the private and public keys.
+	buf := make([]byte, 0, 4+ed25519.PrivateKeySize+ed25519.PublicKeySize)
+	buf = binary.BigEndian.AppendUint32(buf, go_i2cp.ED25519_SHA256)
+	buf = append(buf, private...)
+	buf = append(buf, offline.TransientPublicKey...)
+	defer clear(buf)
+	return go_i2cp.Ed25519KeyPairFromStream(go_i2cp.NewStream(buf))
+}
+
+// blindingParams maps an encrypted lease set configuration to go-i2cp's
+// blinding scheme (1 DH, 2 PSK), flags (bit 0 for per-client
+// authentication) and parameters (the client keys, concatenated). go-i2cp
+// treats scheme 0 as "not encrypted", so a lease set without per-client
+// authentication uses the DH scheme with no flags. It returns false for
+// other lease set types.
+//
+// go-i2cp has no setting for the lookup secret; LeaseSetSecret is checked
+// by the SAM layer but not yet published.
+func blindingParams(config *SessionConfig) (scheme, flags uint16, params []byte, ok bool) {
+	if config.LeaseSetType != session.LeaseSetTypeEncrypted {
+		return 0, 0, nil, false
+	}
+	scheme = session.LeaseSetAuthDH
+	if config.LeaseSetAuthType == session.LeaseSetAuthNone {
+		return scheme, 0, nil, true
+	}
+	scheme = uint16(config.LeaseSetAuthType)
+	for _, client := range config.LeaseSetClients {
+		params = append(params, client.Key...)
+	}
+	return scheme, 1, params, true
+}
+
 // Close closes the I2CP session and releases resources.
 // Safe to call multiple times.
 func (sess *I2CPSession) Close() error {
@@ -329,19 +500,41 @@ func (sess *I2CPSession) SetCallbacks(callbacks *SessionCallbacks) {
 
 // onMessage handles incoming messages from the I2CP session.
 // Matches go-i2cp SessionCallbacks.OnMessage signature.
-func (sess *I2CPSession) onMessage(session *go_i2cp.Session, srcDest *go_i2cp.Destination, protocol uint8, srcPort, destPort uint16, payload *go_i2cp.Stream) {
+//
+// Streaming messages go to the OnStreamMessage handler and datagrams,
+// once decoded, to the OnMessage handler.
+func (sess *I2CPSession) onMessage(i2cpSession *go_i2cp.Session, srcDest *go_i2cp.Destination, protocol uint8, srcPort, destPort uint16, payload *go_i2cp.Stream) {
 	sess.mu.RLock()
 	callbacks := sess.callbacks
+	onDatagram, onStream := sess.onDatagram, sess.onStream
+	local := sess.destination
 	sess.mu.RUnlock()
 
+	// Convert Stream to []byte for our callback interfaces
+	var data []byte
+	if payload != nil {
+		data = payload.Bytes()
+	}
+
 	if callbacks != nil && callbacks.OnMessage != nil {
-		// Convert Stream to []byte for our callback interface
-		var data []byte
-		if payload != nil {
-			data = payload.Bytes()
-		}
 		callbacks.OnMessage(srcDest, protocol, srcPort, destPort, data)
 	}
+
+	if protocol == go_i2cp.ProtoStreaming {
+		if onStream != nil {
+			onStream(i2cpSession, srcDest, protocol, srcPort, destPort, payload)
+		}
+		return
+	}
+	if onDatagram == nil {
+		return
+	}
+	// Datagrams are best-effort; malformed or forged ones are dropped
+	msg, err := decodeDatagram(local, protocol, srcPort, destPort, data)
+	if err != nil {
+		return
+	}
+	onDatagram(msg)
 }
 
 // onStatus handles session status updates from the I2CP session.
@@ -356,6 +549,8 @@ func (sess *I2CPSession) onStatus(session *go_i2cp.Session, status go_i2cp.Sessi
 	dest := sess.destination
 	sess.mu.RUnlock()
 
+	sess.recordTunnelStatus(status)
+
 	// I2CP_SESSION_STATUS_CREATED means session created successfully
 	if status == go_i2cp.I2CP_SESSION_STATUS_CREATED && callbacks != nil && callbacks.OnCreated != nil {
 		callbacks.OnCreated(dest)
@@ -370,6 +565,69 @@ func (sess *I2CPSession) onStatus(session *go_i2cp.Session, status go_i2cp.Sessi
 	}
 }
 
+// recordTunnelStatus records the tunnel event a SessionStatus implies.
+// go-i2cp has no tunnel-level notifications, so tunnel builds are
+// inferred from CREATED and UPDATED, expiry from the router destroying a
+// session we did not close, and failures from INVALID and REFUSED.
+func (sess *I2CPSession) recordTunnelStatus(status go_i2cp.SessionStatus) {
+	switch status {
+	case go_i2cp.I2CP_SESSION_STATUS_CREATED:
+		sess.recordTunnelEvent(session.TunnelBuilt, "session created")
+	case go_i2cp.I2CP_SESSION_STATUS_UPDATED:
+		sess.recordTunnelEvent(session.TunnelBuilt, "session updated")
+	case go_i2cp.I2CP_SESSION_STATUS_DESTROYED:
+		if sess.IsActive() {
+			sess.recordTunnelEvent(session.TunnelExpired, "router destroyed the session")
+		}
+	case go_i2cp.I2CP_SESSION_STATUS_INVALID:
+		sess.recordTunnelEvent(session.TunnelFailed, "session invalid")
+	case go_i2cp.I2CP_SESSION_STATUS_REFUSED:
+		sess.recordTunnelEvent(session.TunnelFailed, "session refused")
+	}
+}
+
+// recordTunnelEvent counts a tunnel event and passes it to the handler
+// set with OnTunnelEvent.
+func (sess *I2CPSession) recordTunnelEvent(typ session.TunnelEventType, reason string) {
+	ev := session.TunnelEvent{Type: typ, Time: time.Now(), Reason: reason}
+	sess.mu.Lock()
+	sess.tunnels.Record(ev)
+	fn := sess.onTunnelEvent
+	sess.mu.Unlock()
+
+	if fn != nil {
+		fn(ev)
+	}
+}
+
+// OnTunnelEvent sets fn to be called for each tunnel event. If events
+// happened before, fn is called at once with the latest.
+// Implements session.TunnelEventSource.
+func (sess *I2CPSession) OnTunnelEvent(fn func(session.TunnelEvent)) {
+	sess.mu.Lock()
+	sess.onTunnelEvent = fn
+	last := sess.tunnels.Last
+	sess.mu.Unlock()
+
+	if fn != nil && !last.Time.IsZero() {
+		fn(last)
+	}
+}
+
+// TunnelHealth returns the session's tunnel events so far.
+// Implements session.TunnelHealthProvider.
+func (sess *I2CPSession) TunnelHealth() (session.TunnelHealth, bool) {
+	sess.mu.RLock()
+	defer sess.mu.RUnlock()
+	return sess.tunnels, true
+}
+
+// Compile-time check that I2CPSession reports tunnel events.
+var (
+	_ session.TunnelEventSource    = (*I2CPSession)(nil)
+	_ session.TunnelHealthProvider = (*I2CPSession)(nil)
+)
+
 // signalTunnelReady signals that tunnels are ready.
 // Safe to call multiple times - only signals once.
 // Safe to call even if tunnelReady channel is nil (e.g., in tests).
@@ -407,6 +665,66 @@ func (sess *I2CPSession) IsTunnelReady() bool {
 	}
 }
 
+// leaseSetPollInterval is how often WaitForLeaseSet checks whether the
+// lease set has been sent.
+const leaseSetPollInterval = 100 * time.Millisecond
+
+// WaitForLeaseSet blocks until go-i2cp has answered the router's lease
+// set request with a CreateLeaseSet2 message, or ctx is done. Tunnels
+// being built does not mean the lease set is out yet, and until it is,
+// peers cannot reach the destination. Returns nil at once when the
+// client does not track session state.
+// Implements session.LeaseSetWaiter.
+func (sess *I2CPSession) WaitForLeaseSet(ctx context.Context) error {
+	ticker := time.NewTicker(leaseSetPollInterval)
+	defer ticker.Stop()
+	for {
+		published, err := sess.leaseSetPublished()
+		if published || err != nil {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// Compile-time check that I2CPSession reports lease set publication.
+var _ session.LeaseSetWaiter = (*I2CPSession)(nil)
+
+// leaseSetPublished reports whether the session's lease set has been
+// sent to the router. Untracked sessions count as published; sessions
+// that ended first return an error.
+func (sess *I2CPSession) leaseSetPublished() (bool, error) {
+	sess.mu.RLock()
+	i2cpSession := sess.session
+	client := sess.client
+	sess.mu.RUnlock()
+	if i2cpSession == nil || client == nil {
+		return true, nil
+	}
+	i2cpClient := client.I2CPClient()
+	if i2cpClient == nil {
+		return false, nil // failing over; the lease set follows reopen
+	}
+	tracker := i2cpClient.GetStateTracker()
+	if tracker == nil || !tracker.IsEnabled() {
+		return true, nil
+	}
+
+	state, _ := tracker.GetState(i2cpSession.ID())
+	switch state {
+	case go_i2cp.SessionStateLeaseSetSent, go_i2cp.SessionStateActive:
+		return true, nil
+	case go_i2cp.SessionStateDestroying, go_i2cp.SessionStateDestroyed,
+		go_i2cp.SessionStateRejected, go_i2cp.SessionStateDisconnected:
+		return false, fmt.Errorf("session %s before its lease set was published", state)
+	}
+	return false, nil
+}
+
 // onMessageStatus handles message delivery status updates.
 // Matches go-i2cp SessionCallbacks.OnMessageStatus signature.
 func (sess *I2CPSession) onMessageStatus(session *go_i2cp.Session, messageId uint32, status go_i2cp.SessionMessageStatus, size, nonce uint32) {
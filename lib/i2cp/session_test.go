@@ -1,9 +1,14 @@
 package i2cp
 
 import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
 	"testing"
+	"time"
 
 	go_i2cp "github.com/go-i2p/go-i2cp"
+	"github.com/go-i2p/go-sam-bridge/lib/session"
 )
 
 func TestDefaultSessionConfig(t *testing.T) {
@@ -331,6 +336,35 @@ func TestI2CPSession_onStatus_WithNonCreatedStatus(t *testing.T) {
 	}
 }
 
+func TestI2CPSession_TunnelEvents(t *testing.T) {
+	sess := &I2CPSession{active: true}
+	sess.onStatus(nil, go_i2cp.I2CP_SESSION_STATUS_CREATED)
+
+	var events []session.TunnelEvent
+	sess.OnTunnelEvent(func(ev session.TunnelEvent) { events = append(events, ev) })
+	sess.onStatus(nil, go_i2cp.I2CP_SESSION_STATUS_INVALID)
+	sess.onStatus(nil, go_i2cp.I2CP_SESSION_STATUS_DESTROYED)
+
+	// Destroyed after our own Close is not an expiry
+	sess.active = false
+	sess.onStatus(nil, go_i2cp.I2CP_SESSION_STATUS_DESTROYED)
+
+	want := []session.TunnelEventType{session.TunnelBuilt, session.TunnelFailed, session.TunnelExpired}
+	if len(events) != len(want) {
+		t.Fatalf("got %d events, want %d (the replayed build and two more)", len(events), len(want))
+	}
+	for i, ev := range events {
+		if ev.Type != want[i] {
+			t.Errorf("events[%d] = %s, want %s", i, ev.Type, want[i])
+		}
+	}
+
+	health, ok := sess.TunnelHealth()
+	if !ok || health.Builds != 1 || health.Failures != 1 || health.Expirations != 1 {
+		t.Errorf("TunnelHealth() = %+v, %v; want one of each", health, ok)
+	}
+}
+
 func TestI2CPSession_SendMessage_InactiveSession(t *testing.T) {
 	sess := &I2CPSession{
 		active: false,
@@ -344,3 +378,121 @@ func TestI2CPSession_SendMessage_InactiveSession(t *testing.T) {
 		t.Errorf("expected 'session is not active' error, got: %v", err)
 	}
 }
+
+func TestBlindingParams(t *testing.T) {
+	key1 := bytes.Repeat([]byte{1}, 32)
+	key2 := bytes.Repeat([]byte{2}, 32)
+
+	tests := []struct {
+		name       string
+		config     *SessionConfig
+		wantOK     bool
+		wantScheme uint16
+		wantFlags  uint16
+		wantParams []byte
+	}{
+		{
+			name:   "not encrypted",
+			config: &SessionConfig{LeaseSetType: session.LeaseSetType2},
+		},
+		{
+			name:       "encrypted without auth",
+			config:     &SessionConfig{LeaseSetType: session.LeaseSetTypeEncrypted},
+			wantOK:     true,
+			wantScheme: session.LeaseSetAuthDH,
+		},
+		{
+			name: "encrypted with PSK clients",
+			config: &SessionConfig{
+				LeaseSetType:     session.LeaseSetTypeEncrypted,
+				LeaseSetAuthType: session.LeaseSetAuthPSK,
+				LeaseSetClients:  []session.LeaseSetClient{{Name: "a", Key: key1}, {Name: "b", Key: key2}},
+			},
+			wantOK:     true,
+			wantScheme: session.LeaseSetAuthPSK,
+			wantFlags:  1,
+			wantParams: append(append([]byte{}, key1...), key2...),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scheme, flags, params, ok := blindingParams(tt.config)
+			if ok != tt.wantOK || scheme != tt.wantScheme || flags != tt.wantFlags || !bytes.Equal(params, tt.wantParams) {
+				t.Errorf("blindingParams() = %d, %d, %x, %v, want %d, %d, %x, %v",
+					scheme, flags, params, ok, tt.wantScheme, tt.wantFlags, tt.wantParams, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestI2CPSession_WaitForLeaseSet_Untracked(t *testing.T) {
+	sess := &I2CPSession{}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	if err := sess.WaitForLeaseSet(ctx); err != nil {
+		t.Errorf("WaitForLeaseSet() error = %v, want nil for an untracked session", err)
+	}
+}
+
+func TestI2CPSession_ApplyOfflineSignature(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	offline := func() *session.OfflineSignature {
+		return &session.OfflineSignature{
+			Expires:             time.Now().Add(time.Hour).Unix(),
+			TransientType:       int(go_i2cp.ED25519_SHA256),
+			TransientPublicKey:  pub,
+			Signature:           bytes.Repeat([]byte{9}, 64),
+			TransientPrivateKey: priv.Seed(),
+		}
+	}
+
+	tests := []struct {
+		name    string
+		modify  func(*session.OfflineSignature)
+		wantErr bool
+	}{
+		{name: "seed", modify: func(*session.OfflineSignature) {}},
+		{name: "expanded key", modify: func(o *session.OfflineSignature) { o.TransientPrivateKey = priv }},
+		{name: "mismatched key", modify: func(o *session.OfflineSignature) { o.TransientPrivateKey = make([]byte, 32) }, wantErr: true},
+		{name: "missing key", modify: func(o *session.OfflineSignature) { o.TransientPrivateKey = nil }, wantErr: true},
+		{name: "unsupported type", modify: func(o *session.OfflineSignature) { o.TransientType = 1 }, wantErr: true},
+		{name: "expired", modify: func(o *session.OfflineSignature) { o.Expires = time.Now().Add(-time.Hour).Unix() }, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o := offline()
+			tt.modify(o)
+			sess := &I2CPSession{session: go_i2cp.NewSession(go_i2cp.NewClient(nil), go_i2cp.SessionCallbacks{})}
+
+			err := sess.applyOfflineSignature(&SessionConfig{OfflineSignature: o})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("applyOfflineSignature() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if !sess.session.IsOffline() {
+				t.Error("session is not offline-signed")
+			}
+			kp, err := sess.session.TransientSigningKeyPair()
+			if err != nil {
+				t.Fatalf("TransientSigningKeyPair() error = %v", err)
+			}
+			sig, err := kp.Sign([]byte("lease set"))
+			if err != nil || !ed25519.Verify(pub, []byte("lease set"), sig) {
+				t.Errorf("transient key pair does not sign for the transient public key (err %v)", err)
+			}
+		})
+	}
+
+	sess := &I2CPSession{session: go_i2cp.NewSession(go_i2cp.NewClient(nil), go_i2cp.SessionCallbacks{})}
+	if err := sess.applyOfflineSignature(&SessionConfig{}); err != nil || sess.session.IsOffline() {
+		t.Errorf("without an offline signature: err = %v, offline = %v; want nil, false", err, sess.session.IsOffline())
+	}
+}
@@ -1,5 +1,11 @@
 package protocol
 
+import (
+	"slices"
+	"strconv"
+	"strings"
+)
+
 // Command represents a parsed SAM protocol command.
 // Per SAMv3.md, commands follow the format:
 //
@@ -28,9 +34,19 @@ type Command struct {
 
 	// Raw is the original command line for debugging and logging.
 	Raw string
+
+	// order holds option keys in the order they were set, so String
+	// writes them as the client or builder gave them.
+	order []string
 }
 
 // NewCommand creates a new Command with initialized Options map.
+// Together with WithOption and WithPayload it builds commands to send:
+//
+//	cmd := NewCommand(VerbSession, ActionCreate).
+//		WithOption("STYLE", "STREAM").
+//		WithOption("ID", "my session")
+//	conn.Write(cmd.Bytes())
 func NewCommand(verb, action string) *Command {
 	return &Command{
 		Verb:    verb,
@@ -77,5 +93,61 @@ func (c *Command) Set(key, value string) {
 	if c.Options == nil {
 		c.Options = make(map[string]string)
 	}
+	if _, ok := c.Options[key]; !ok {
+		c.order = append(c.order, key)
+	}
 	c.Options[key] = value
 }
+
+// WithOption sets an option and returns c for chaining.
+// Values containing spaces, quotes, or backslashes are quoted by String.
+func (c *Command) WithOption(key, value string) *Command {
+	c.Set(key, value)
+	return c
+}
+
+// WithPayload sets the payload sent after the command line, such as for
+// DATAGRAM SEND or RAW SEND, and its SIZE option.
+func (c *Command) WithPayload(payload []byte) *Command {
+	c.Payload = payload
+	return c.WithOption("SIZE", strconv.Itoa(len(payload)))
+}
+
+// String formats the command as a SAM protocol line with newline
+// terminator, without the payload. Options appear in the order they were
+// set, followed by any added to Options directly, sorted by key.
+func (c *Command) String() string {
+	parts := []string{c.Verb}
+	if c.Action != "" {
+		parts = append(parts, c.Action)
+	}
+	for _, key := range c.optionKeys() {
+		parts = append(parts, formatOption(key, c.Options[key]))
+	}
+	return strings.Join(parts, " ") + "\n"
+}
+
+// Bytes returns the command line and payload for writing to a connection.
+func (c *Command) Bytes() []byte {
+	return append([]byte(c.String()), c.Payload...)
+}
+
+// optionKeys returns the option keys in the order String writes them.
+func (c *Command) optionKeys() []string {
+	keys := make([]string, 0, len(c.Options))
+	seen := make(map[string]bool, len(c.Options))
+	for _, key := range c.order {
+		if _, ok := c.Options[key]; ok && !seen[key] {
+			keys = append(keys, key)
+			seen[key] = true
+		}
+	}
+	var rest []string
+	for key := range c.Options {
+		if !seen[key] {
+			rest = append(rest, key)
+		}
+	}
+	slices.Sort(rest)
+	return append(keys, rest...)
+}
@@ -188,3 +188,69 @@ func TestCommand_Set(t *testing.T) {
 		}
 	})
 }
+
+func TestCommand_String(t *testing.T) {
+	tests := []struct {
+		name string
+		cmd  *Command
+		want string
+	}{
+		{
+			name: "verb only",
+			cmd:  NewCommand(VerbQuit, ""),
+			want: "QUIT\n",
+		},
+		{
+			name: "options in order",
+			cmd: NewCommand(VerbSession, ActionCreate).
+				WithOption("STYLE", "STREAM").
+				WithOption("ID", "test").
+				WithOption("DESTINATION", "TRANSIENT"),
+			want: "SESSION CREATE STYLE=STREAM ID=test DESTINATION=TRANSIENT\n",
+		},
+		{
+			name: "quoting",
+			cmd: NewCommand(VerbHello, ActionVersion).
+				WithOption("USER", "a b").
+				WithOption("PASSWORD", `p"w\d`).
+				WithOption("EMPTY", ""),
+			want: `HELLO VERSION USER="a b" PASSWORD="p\"w\\d" EMPTY=` + "\n",
+		},
+		{
+			name: "replaced option keeps position",
+			cmd:  NewCommand(VerbNaming, ActionLookup).WithOption("NAME", "a").WithOption("OPTIONS", "true").WithOption("NAME", "b"),
+			want: "NAMING LOOKUP NAME=b OPTIONS=true\n",
+		},
+		{
+			name: "options set directly are sorted",
+			cmd:  &Command{Verb: VerbDest, Action: ActionGenerate, Options: map[string]string{"Z": "1", "A": "2"}},
+			want: "DEST GENERATE A=2 Z=1\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.cmd.String(); got != tt.want {
+				t.Errorf("String() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCommand_WithPayload(t *testing.T) {
+	cmd := NewCommand(VerbRaw, ActionSend).WithOption("DESTINATION", "test.i2p").WithPayload([]byte("hi\n"))
+
+	if got, want := string(cmd.Bytes()), "RAW SEND DESTINATION=test.i2p SIZE=3\nhi\n"; got != want {
+		t.Errorf("Bytes() = %q, want %q", got, want)
+	}
+}
+
+func TestCommand_StringRoundTrip(t *testing.T) {
+	line := `SESSION CREATE STYLE=STREAM ID="my session" DESTINATION=TRANSIENT inbound.length=2`
+	cmd, err := NewParser().Parse(line)
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if got := cmd.String(); got != line+"\n" {
+		t.Errorf("String() = %q, want %q", got, line+"\n")
+	}
+}
@@ -0,0 +1,157 @@
+package protocol
+
+import (
+	"errors"
+	"maps"
+	"testing"
+)
+
+// quotingCases pins how lenient and strict parsers treat SAM 3.2 quoting
+// and escaping. A nil want map means the parser must fail with the
+// corresponding error.
+var quotingCases = []struct {
+	name       string
+	line       string
+	lenient    map[string]string
+	lenientErr error
+	strict     map[string]string
+	strictErr  error
+}{
+	{
+		name:    "plain",
+		line:    `NAMING LOOKUP NAME=test.i2p`,
+		lenient: map[string]string{"NAME": "test.i2p"},
+		strict:  map[string]string{"NAME": "test.i2p"},
+	},
+	{
+		name:    "base64 padding in value",
+		line:    `NAMING LOOKUP NAME=abc== SIGNATURE_TYPE=7`,
+		lenient: map[string]string{"NAME": "abc==", "SIGNATURE_TYPE": "7"},
+		strict:  map[string]string{"NAME": "abc==", "SIGNATURE_TYPE": "7"},
+	},
+	{
+		name:    "quoted value with spaces",
+		line:    `SESSION CREATE ID=a MESSAGE="hello  world"`,
+		lenient: map[string]string{"ID": "a", "MESSAGE": "hello  world"},
+		strict:  map[string]string{"ID": "a", "MESSAGE": "hello  world"},
+	},
+	{
+		name:    "empty values",
+		line:    `SESSION CREATE A B= C=""`,
+		lenient: map[string]string{"A": "", "B": "", "C": ""},
+		strict:  map[string]string{"A": "", "B": "", "C": ""},
+	},
+	{
+		name:    "escaped quote and backslash",
+		line:    `SESSION CREATE V="a\"b\\c"`,
+		lenient: map[string]string{"V": `a"b\c`},
+		strict:  map[string]string{"V": `a"b\c`},
+	},
+	{
+		name:    "escaped backslash before escaped quote",
+		line:    `SESSION CREATE V="a\\\"b"`,
+		lenient: map[string]string{"V": `a"b`},
+		strict:  map[string]string{"V": `a\"b`},
+	},
+	{
+		name:    "quoted value looking quoted",
+		line:    `SESSION CREATE V="\"x\""`,
+		lenient: map[string]string{"V": `"x"`},
+		strict:  map[string]string{"V": `"x"`},
+	},
+	{
+		name:      "unknown escape in quotes",
+		line:      `SESSION CREATE V="a\nb"`,
+		lenient:   map[string]string{"V": `a\nb`},
+		strictErr: ErrInvalidEscape,
+	},
+	{
+		name:      "backslash outside quotes",
+		line:      `SESSION CREATE PATH=C:\keys`,
+		lenient:   map[string]string{"PATH": `C:\keys`},
+		strictErr: ErrInvalidEscape,
+	},
+	{
+		name:      "quote inside unquoted value",
+		line:      `SESSION CREATE V=a"b c"`,
+		lenient:   map[string]string{"V": `a"b c"`},
+		strictErr: ErrMisplacedQuote,
+	},
+	{
+		name:      "text after closing quote",
+		line:      `SESSION CREATE V="a"b`,
+		lenient:   map[string]string{"V": `"a"b`},
+		strictErr: ErrMisplacedQuote,
+	},
+	{
+		name:      "quoted key",
+		line:      `SESSION CREATE "KEY"=v`,
+		lenient:   map[string]string{`"KEY"`: "v"},
+		strictErr: ErrInvalidKey,
+	},
+	{
+		name:      "equals inside quoted key",
+		line:      `SESSION CREATE "A=B"=v`,
+		lenient:   map[string]string{`"A=B"`: "v"},
+		strictErr: ErrInvalidKey,
+	},
+	{
+		name:      "empty key",
+		line:      `SESSION CREATE =v ID=a`,
+		lenient:   map[string]string{"ID": "a"},
+		strictErr: ErrInvalidKey,
+	},
+	{
+		name:       "unterminated quote",
+		line:       `SESSION CREATE V="abc`,
+		lenientErr: ErrUnterminatedQuote,
+		strictErr:  ErrUnterminatedQuote,
+	},
+	{
+		name:    "ping text is exempt",
+		line:    `PING "free" text\here`,
+		lenient: map[string]string{`"free"`: "", `text\here`: ""},
+		strict:  map[string]string{`"free"`: "", `text\here`: ""},
+	},
+}
+
+func TestParser_QuotingConformance(t *testing.T) {
+	for _, tt := range quotingCases {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, mode := range []struct {
+				name    string
+				strict  bool
+				want    map[string]string
+				wantErr error
+			}{
+				{"lenient", false, tt.lenient, tt.lenientErr},
+				{"strict", true, tt.strict, tt.strictErr},
+			} {
+				p := NewParser()
+				p.Strict = mode.strict
+				cmd, err := p.Parse(tt.line)
+				if mode.wantErr != nil {
+					if !errors.Is(err, mode.wantErr) {
+						t.Errorf("%s: Parse() error = %v, want %v", mode.name, err, mode.wantErr)
+					}
+					continue
+				}
+				if err != nil {
+					t.Errorf("%s: Parse() error = %v", mode.name, err)
+					continue
+				}
+				if !maps.Equal(cmd.Options, mode.want) {
+					t.Errorf("%s: Options = %q, want %q", mode.name, cmd.Options, mode.want)
+				}
+			}
+		})
+	}
+}
+
+func TestVersionRequiresStrictQuoting(t *testing.T) {
+	for version, want := range map[string]bool{"": false, "3.0": false, "3.1": false, "3.2": true, "3.3": true} {
+		if got := VersionRequiresStrictQuoting(version); got != want {
+			t.Errorf("VersionRequiresStrictQuoting(%q) = %v, want %v", version, got, want)
+		}
+	}
+}
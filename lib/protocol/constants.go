@@ -38,6 +38,7 @@ const (
 	ActionLookup   = "LOOKUP"
 	ActionEnable   = "ENABLE"
 	ActionDisable  = "DISABLE"
+	ActionStats    = "STATS"
 )
 
 // SAM Result Codes per SAM 3.0-3.3 specification.
@@ -125,9 +126,24 @@ const (
 	SAMVersionMax = "3.3"
 )
 
+// VersionRequiresStrictQuoting returns true if commands on a connection
+// that negotiated version follow the SAM 3.2 quoting and escaping rules,
+// which Parser.Strict enforces. It is false before HELLO (empty version)
+// and for SAM 3.0 and 3.1, which did not define them.
+func VersionRequiresStrictQuoting(version string) bool {
+	switch version {
+	case "", "3.0", "3.1":
+		return false
+	default:
+		return true
+	}
+}
+
 // VersionSupportsPortInfo returns true if the given SAM version supports
 // FROM_PORT/TO_PORT in received datagrams. Per SAMv3.md, port info is
-// only included in DATAGRAM RECEIVED and RAW RECEIVED for SAM 3.2 or higher.
+// only included in DATAGRAM RECEIVED and RAW RECEIVED for SAM 3.2 or higher,
+// which also added FROM_PORT, TO_PORT and PROTOCOL to DATAGRAM SEND, RAW
+// SEND and the header of datagrams sent to the UDP port.
 func VersionSupportsPortInfo(version string) bool {
 	// Empty version defaults to latest behavior (include ports)
 	if version == "" {
@@ -143,3 +159,16 @@ func VersionSupportsPortInfo(version string) bool {
 		return true
 	}
 }
+
+// VersionSupportsSendOptions returns true if the given SAM version supports
+// the SEND_TAGS, TAG_THRESHOLD, EXPIRES and SEND_LEASESET options of
+// DATAGRAM SEND, RAW SEND and UDP datagram headers, which SAM 3.3 added.
+// As with VersionSupportsPortInfo, an empty version means the latest.
+func VersionSupportsSendOptions(version string) bool {
+	switch version {
+	case "3.0", "3.1", "3.2":
+		return false
+	default:
+		return true
+	}
+}
@@ -111,3 +111,27 @@ func TestVersionSupportsPortInfo(t *testing.T) {
 		})
 	}
 }
+
+func TestVersionSupportsSendOptions(t *testing.T) {
+	tests := []struct {
+		version string
+		want    bool
+	}{
+		// SEND_TAGS and friends were added in SAM 3.3
+		{"3.0", false},
+		{"3.1", false},
+		{"3.2", false},
+		{"3.3", true},
+		// Empty version defaults to the latest behavior
+		{"", true},
+		{"3.4", true},
+	}
+
+	for _, tt := range tests {
+		t.Run("version_"+tt.version, func(t *testing.T) {
+			if got := VersionSupportsSendOptions(tt.version); got != tt.want {
+				t.Errorf("VersionSupportsSendOptions(%q) = %v, want %v", tt.version, got, tt.want)
+			}
+		})
+	}
+}
@@ -13,6 +13,8 @@ var (
 	ErrInvalidUTF8       = errors.New("command contains invalid UTF-8")
 	ErrUnterminatedQuote = errors.New("unterminated quoted value")
 	ErrInvalidEscape     = errors.New("invalid escape sequence")
+	ErrInvalidKey        = errors.New("invalid option key")
+	ErrMisplacedQuote    = errors.New("quote not around a whole value")
 )
 
 // Parser tokenizes SAM protocol commands.
@@ -26,14 +28,57 @@ type Parser struct {
 	// CaseInsensitive enables case-insensitive verb/action matching.
 	// Per SAM spec, this is recommended but not required.
 	CaseInsensitive bool
+
+	// MaxLineLength is the longest command line, in bytes, that ReadLine
+	// reads and Parse accepts. Zero means DefaultMaxLineLength for
+	// ReadLine and no limit for Parse.
+	MaxLineLength int
+
+	// Strict parses quoting and escaping exactly as SAM 3.2 specifies:
+	// quotes may only enclose a whole value, only \" and \\ may be
+	// escaped inside them, and keys must be non-empty and unquoted.
+	// Commands breaking these rules fail with ErrInvalidKey,
+	// ErrMisplacedQuote or ErrInvalidEscape instead of being repaired.
+	// The free text of PING and PONG is exempt.
+	Strict bool
+
+	// ReplaceInvalidUTF8 replaces invalid UTF-8 sequences with U+FFFD
+	// instead of failing with ErrInvalidUTF8. It is ignored in Strict
+	// mode, which always rejects them as SAM 3.2 requires UTF-8.
+	ReplaceInvalidUTF8 bool
+}
+
+// ParserOption configures a Parser created by NewParser.
+type ParserOption func(*Parser)
+
+// WithReplaceInvalidUTF8 sets Parser.ReplaceInvalidUTF8.
+func WithReplaceInvalidUTF8(replace bool) ParserOption {
+	return func(p *Parser) {
+		p.ReplaceInvalidUTF8 = replace
+	}
+}
+
+// WithMaxLineLength sets the longest command line, in bytes, the parser
+// reads or parses. Values <= 0 keep DefaultMaxLineLength.
+func WithMaxLineLength(n int) ParserOption {
+	return func(p *Parser) {
+		if n > 0 {
+			p.MaxLineLength = n
+		}
+	}
 }
 
-// NewParser creates a new parser with default settings.
+// NewParser creates a new parser with default settings, then applies opts.
 // Case-insensitive matching is enabled by default per SAM spec recommendation.
-func NewParser() *Parser {
-	return &Parser{
+func NewParser(opts ...ParserOption) *Parser {
+	p := &Parser{
 		CaseInsensitive: true,
+		MaxLineLength:   DefaultMaxLineLength,
 	}
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p
 }
 
 // Parse parses a SAM command line into a Command struct.
@@ -41,11 +86,21 @@ func NewParser() *Parser {
 func (p *Parser) Parse(line string) (*Command, error) {
 	line = strings.TrimRight(line, "\r\n")
 
-	if err := p.validateLine(line); err != nil {
+	if p.MaxLineLength > 0 && len(line) > p.MaxLineLength {
+		return nil, ErrLineTooLong
+	}
+	line, err := p.validateLine(line)
+	if err != nil {
 		return nil, err
 	}
 
-	tokens, err := p.tokenize(line)
+	strict := p.Strict && !isFreeText(line)
+	var tokens []string
+	if strict {
+		tokens, err = tokenizeStrict(line)
+	} else {
+		tokens, err = p.tokenize(line)
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -54,19 +109,24 @@ func (p *Parser) Parse(line string) (*Command, error) {
 		return nil, ErrEmptyCommand
 	}
 
-	return p.buildCommand(tokens, line)
+	return p.buildCommand(tokens, line, strict)
 }
 
-// validateLine checks if the line is valid UTF-8.
-func (p *Parser) validateLine(line string) error {
-	if !utf8.ValidString(line) {
-		return ErrInvalidUTF8
+// validateLine checks if the line is valid UTF-8, returning it with
+// invalid sequences replaced if ReplaceInvalidUTF8 applies.
+func (p *Parser) validateLine(line string) (string, error) {
+	if utf8.ValidString(line) {
+		return line, nil
 	}
-	return nil
+	if !p.ReplaceInvalidUTF8 || p.Strict {
+		return "", ErrInvalidUTF8
+	}
+	return strings.ToValidUTF8(line, string(utf8.RuneError)), nil
 }
 
-// buildCommand constructs a Command from tokens.
-func (p *Parser) buildCommand(tokens []string, raw string) (*Command, error) {
+// buildCommand constructs a Command from tokens. Strict tokens have
+// their quotes and escapes already resolved.
+func (p *Parser) buildCommand(tokens []string, raw string, strict bool) (*Command, error) {
 	cmd := &Command{
 		Options: make(map[string]string),
 		Raw:     raw,
@@ -74,7 +134,7 @@ func (p *Parser) buildCommand(tokens []string, raw string) (*Command, error) {
 
 	cmd.Verb = p.normalizeToken(tokens[0])
 	tokenIdx := p.extractAction(cmd, tokens)
-	p.extractOptions(cmd, tokens, tokenIdx)
+	p.extractOptions(cmd, tokens, tokenIdx, strict)
 
 	return cmd, nil
 }
@@ -107,11 +167,14 @@ func (p *Parser) extractAction(cmd *Command, tokens []string) int {
 }
 
 // extractOptions parses key=value pairs from remaining tokens.
-func (p *Parser) extractOptions(cmd *Command, tokens []string, startIdx int) {
+func (p *Parser) extractOptions(cmd *Command, tokens []string, startIdx int, strict bool) {
 	for i := startIdx; i < len(tokens); i++ {
 		key, value := p.parseKeyValue(tokens[i])
+		if strict {
+			key, value, _ = strings.Cut(tokens[i], "=")
+		}
 		if key != "" {
-			cmd.Options[key] = value
+			cmd.Set(key, value)
 		}
 	}
 }
@@ -199,6 +262,87 @@ func (t *tokenizer) finishToken() {
 	}
 }
 
+// isFreeText reports whether line is a PING or PONG, whose text is
+// arbitrary rather than options.
+func isFreeText(line string) bool {
+	verb, _, _ := strings.Cut(strings.TrimLeft(line, " \t"), " ")
+	verb, _, _ = strings.Cut(verb, "\t")
+	return strings.EqualFold(verb, VerbPing) || strings.EqualFold(verb, VerbPong)
+}
+
+// tokenizeStrict splits a command line into tokens per SAM 3.2, with
+// quotes removed and escapes resolved.
+func tokenizeStrict(line string) ([]string, error) {
+	var tokens []string
+	for i := 0; i < len(line); {
+		if line[i] == ' ' || line[i] == '\t' {
+			i++
+			continue
+		}
+		token, n, err := strictToken(line[i:])
+		if err != nil {
+			return nil, err
+		}
+		tokens = append(tokens, token)
+		i += n
+	}
+	return tokens, nil
+}
+
+// strictToken reads the KEY, KEY=VALUE or KEY="VALUE" token at the start
+// of s. It returns the token as KEY or KEY=VALUE with the value unquoted,
+// and the number of bytes of s it took.
+func strictToken(s string) (string, int, error) {
+	end := strings.IndexAny(s, "= \t")
+	if end < 0 {
+		end = len(s)
+	}
+	key := s[:end]
+	if key == "" || strings.ContainsAny(key, `"\`) {
+		return "", 0, ErrInvalidKey
+	}
+	if end == len(s) || s[end] != '=' {
+		return key, end, nil
+	}
+
+	i := end + 1
+	if i == len(s) || s[i] != '"' {
+		// Unquoted value, up to the next separator
+		j := i
+		for j < len(s) && s[j] != ' ' && s[j] != '\t' {
+			switch s[j] {
+			case '"':
+				return "", 0, ErrMisplacedQuote
+			case '\\':
+				return "", 0, ErrInvalidEscape
+			}
+			j++
+		}
+		return s[:j], j, nil
+	}
+
+	var value strings.Builder
+	for i++; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			if i+1 == len(s) || (s[i+1] != '"' && s[i+1] != '\\') {
+				return "", 0, ErrInvalidEscape
+			}
+			i++
+			value.WriteByte(s[i])
+		case '"':
+			i++
+			if i < len(s) && s[i] != ' ' && s[i] != '\t' {
+				return "", 0, ErrMisplacedQuote
+			}
+			return key + "=" + value.String(), i, nil
+		default:
+			value.WriteByte(s[i])
+		}
+	}
+	return "", 0, ErrUnterminatedQuote
+}
+
 // parseKeyValue parses a token as a key=value pair.
 // Handles empty values per SAM 3.2 (KEY, KEY=, KEY="").
 func (p *Parser) parseKeyValue(token string) (key, value string) {
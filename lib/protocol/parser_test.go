@@ -372,6 +372,31 @@ func TestParser_Parse_UTF8(t *testing.T) {
 	}
 }
 
+func TestParser_Parse_InvalidUTF8(t *testing.T) {
+	line := "AUTH ADD USER=j\xc3rgen PASSWORD=\xff\xfe"
+
+	if _, err := NewParser().Parse(line); !errors.Is(err, ErrInvalidUTF8) {
+		t.Errorf("Parse() error = %v, want ErrInvalidUTF8", err)
+	}
+
+	cmd, err := NewParser(WithReplaceInvalidUTF8(true)).Parse(line)
+	if err != nil {
+		t.Fatalf("Parse() with replacement error = %v", err)
+	}
+	if got, want := cmd.Get("USER"), "j\uFFFDrgen"; got != want {
+		t.Errorf("USER = %q, want %q", got, want)
+	}
+	if got, want := cmd.Get("PASSWORD"), "\uFFFD"; got != want {
+		t.Errorf("PASSWORD = %q, want %q", got, want)
+	}
+
+	strict := NewParser(WithReplaceInvalidUTF8(true))
+	strict.Strict = true
+	if _, err := strict.Parse(line); !errors.Is(err, ErrInvalidUTF8) {
+		t.Errorf("strict Parse() error = %v, want ErrInvalidUTF8", err)
+	}
+}
+
 func TestParseLine(t *testing.T) {
 	cmd, err := ParseLine("HELLO VERSION")
 	if err != nil {
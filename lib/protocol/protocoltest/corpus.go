@@ -0,0 +1,109 @@
+package protocoltest
+
+import "github.com/go-i2p/go-sam-bridge/lib/protocol"
+
+// Case is one SAM command line of the corpus.
+type Case struct {
+	// Name describes the case.
+	Name string
+
+	// Line is the command line without its newline.
+	Line string
+
+	// Err is the error a parser must return for a malformed line, or nil
+	// if the line is well-formed.
+	Err error
+
+	// Verb, Action and Options are the parsed form of a well-formed line.
+	// Options is compared only if non-nil.
+	Verb    string
+	Action  string
+	Options map[string]string
+}
+
+// Valid holds well-formed command lines, parsed the same way by lenient
+// and strict parsers. Some carry option values a handler must reject,
+// such as unknown styles or out-of-range sizes.
+var Valid = []Case{
+	{Name: "hello", Line: "HELLO VERSION MIN=3.0 MAX=3.3", Verb: protocol.VerbHello, Action: protocol.ActionVersion,
+		Options: map[string]string{"MIN": "3.0", "MAX": "3.3"}},
+	{Name: "hello without versions", Line: "HELLO VERSION", Verb: protocol.VerbHello, Action: protocol.ActionVersion,
+		Options: map[string]string{}},
+	{Name: "hello with credentials", Line: "HELLO VERSION MIN=3.2 MAX=3.3 USER=alice PASSWORD=secret", Verb: protocol.VerbHello, Action: protocol.ActionVersion,
+		Options: map[string]string{"MIN": "3.2", "MAX": "3.3", "USER": "alice", "PASSWORD": "secret"}},
+	{Name: "lower case", Line: "hello version min=3.1", Verb: protocol.VerbHello, Action: protocol.ActionVersion},
+	{Name: "trailing carriage return", Line: "HELLO VERSION MAX=3.3\r", Verb: protocol.VerbHello, Action: protocol.ActionVersion,
+		Options: map[string]string{"MAX": "3.3"}},
+	{Name: "session create stream", Line: "SESSION CREATE STYLE=STREAM ID=test DESTINATION=TRANSIENT SIGNATURE_TYPE=7",
+		Verb: protocol.VerbSession, Action: protocol.ActionCreate,
+		Options: map[string]string{"STYLE": "STREAM", "ID": "test", "DESTINATION": "TRANSIENT", "SIGNATURE_TYPE": "7"}},
+	{Name: "session create i2cp options", Line: "SESSION CREATE STYLE=DATAGRAM ID=dg DESTINATION=TRANSIENT PORT=7655 inbound.length=2 i2cp.leaseSetEncType=4,0",
+		Verb: protocol.VerbSession, Action: protocol.ActionCreate,
+		Options: map[string]string{"STYLE": "DATAGRAM", "ID": "dg", "DESTINATION": "TRANSIENT", "PORT": "7655", "inbound.length": "2", "i2cp.leaseSetEncType": "4,0"}},
+	{Name: "session create unknown style", Line: "SESSION CREATE STYLE=CARRIER_PIGEON ID=x DESTINATION=TRANSIENT",
+		Verb: protocol.VerbSession, Action: protocol.ActionCreate},
+	{Name: "session create missing id", Line: "SESSION CREATE STYLE=STREAM DESTINATION=TRANSIENT",
+		Verb: protocol.VerbSession, Action: protocol.ActionCreate},
+	{Name: "session create bad destination", Line: "SESSION CREATE STYLE=STREAM ID=x DESTINATION=not~base64",
+		Verb: protocol.VerbSession, Action: protocol.ActionCreate},
+	{Name: "session add", Line: "SESSION ADD STYLE=RAW ID=sub PORT=9000 PROTOCOL=18",
+		Verb: protocol.VerbSession, Action: protocol.ActionAdd},
+	{Name: "session remove unknown", Line: "SESSION REMOVE ID=nonexistent",
+		Verb: protocol.VerbSession, Action: protocol.ActionRemove},
+	{Name: "stream connect", Line: "STREAM CONNECT ID=test DESTINATION=example.i2p SILENT=false",
+		Verb: protocol.VerbStream, Action: protocol.ActionConnect,
+		Options: map[string]string{"ID": "test", "DESTINATION": "example.i2p", "SILENT": "false"}},
+	{Name: "stream accept", Line: "STREAM ACCEPT ID=test SILENT=true",
+		Verb: protocol.VerbStream, Action: protocol.ActionAccept},
+	{Name: "stream forward bad port", Line: "STREAM FORWARD ID=test PORT=99999",
+		Verb: protocol.VerbStream, Action: protocol.ActionForward},
+	{Name: "datagram send", Line: "DATAGRAM SEND DESTINATION=example.i2p SIZE=5",
+		Verb: protocol.VerbDatagram, Action: protocol.ActionSend},
+	{Name: "datagram send oversized", Line: "DATAGRAM SEND DESTINATION=example.i2p SIZE=99999999",
+		Verb: protocol.VerbDatagram, Action: protocol.ActionSend},
+	{Name: "raw send negative size", Line: "RAW SEND DESTINATION=example.i2p SIZE=-1",
+		Verb: protocol.VerbRaw, Action: protocol.ActionSend},
+	{Name: "dest generate", Line: "DEST GENERATE SIGNATURE_TYPE=7",
+		Verb: protocol.VerbDest, Action: protocol.ActionGenerate, Options: map[string]string{"SIGNATURE_TYPE": "7"}},
+	{Name: "dest generate unknown type", Line: "DEST GENERATE SIGNATURE_TYPE=ED448",
+		Verb: protocol.VerbDest, Action: protocol.ActionGenerate},
+	{Name: "naming lookup", Line: "NAMING LOOKUP NAME=ME",
+		Verb: protocol.VerbNaming, Action: protocol.ActionLookup, Options: map[string]string{"NAME": "ME"}},
+	{Name: "naming lookup base64 padding", Line: "NAMING LOOKUP NAME=abc== OPTIONS=true",
+		Verb: protocol.VerbNaming, Action: protocol.ActionLookup, Options: map[string]string{"NAME": "abc==", "OPTIONS": "true"}},
+	{Name: "naming lookup empty name", Line: "NAMING LOOKUP NAME=",
+		Verb: protocol.VerbNaming, Action: protocol.ActionLookup, Options: map[string]string{"NAME": ""}},
+	{Name: "quoted value", Line: `SESSION CREATE STYLE=STREAM ID="my session" DESTINATION=TRANSIENT`,
+		Verb: protocol.VerbSession, Action: protocol.ActionCreate,
+		Options: map[string]string{"STYLE": "STREAM", "ID": "my session", "DESTINATION": "TRANSIENT"}},
+	{Name: "escaped quote", Line: `AUTH ADD USER=bob PASSWORD="p\"w\\d"`,
+		Verb: protocol.VerbAuth, Action: protocol.ActionAdd, Options: map[string]string{"USER": "bob", "PASSWORD": `p"w\d`}},
+	{Name: "utf-8 value", Line: "AUTH ADD USER=jürgen PASSWORD=пароль",
+		Verb: protocol.VerbAuth, Action: protocol.ActionAdd, Options: map[string]string{"USER": "jürgen", "PASSWORD": "пароль"}},
+	{Name: "repeated spaces", Line: "NAMING   LOOKUP    NAME=test.i2p",
+		Verb: protocol.VerbNaming, Action: protocol.ActionLookup, Options: map[string]string{"NAME": "test.i2p"}},
+	{Name: "auth enable", Line: "AUTH ENABLE", Verb: protocol.VerbAuth, Action: protocol.ActionEnable},
+	{Name: "ping", Line: "PING 1234", Verb: protocol.VerbPing},
+	{Name: "ping without text", Line: "PING", Verb: protocol.VerbPing},
+	{Name: "pong", Line: "PONG 1234", Verb: protocol.VerbPong},
+	{Name: "quit", Line: "QUIT", Verb: protocol.VerbQuit},
+	{Name: "help", Line: "HELP", Verb: protocol.VerbHelp},
+	{Name: "unknown verb", Line: "FROBNICATE NOW X=1", Verb: "FROBNICATE", Action: "NOW"},
+}
+
+// Malformed holds command lines every parser must reject.
+var Malformed = []Case{
+	{Name: "empty", Line: "", Err: protocol.ErrEmptyCommand},
+	{Name: "blank", Line: "   \t ", Err: protocol.ErrEmptyCommand},
+	{Name: "invalid utf-8", Line: "NAMING LOOKUP NAME=\xff\xfe", Err: protocol.ErrInvalidUTF8},
+	{Name: "truncated utf-8", Line: "AUTH ADD USER=j\xc3", Err: protocol.ErrInvalidUTF8},
+	{Name: "unterminated quote", Line: `SESSION CREATE ID="abc`, Err: protocol.ErrUnterminatedQuote},
+	{Name: "unterminated quote after escape", Line: `SESSION CREATE ID="abc\"`, Err: protocol.ErrUnterminatedQuote},
+}
+
+// Corpus returns Valid followed by Malformed.
+func Corpus() []Case {
+	cases := make([]Case, 0, len(Valid)+len(Malformed))
+	cases = append(cases, Valid...)
+	return append(cases, Malformed...)
+}
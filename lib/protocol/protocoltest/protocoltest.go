@@ -0,0 +1,145 @@
+// Package protocoltest provides a corpus of valid and malformed SAM command
+// lines and helpers to check parsers and handlers against it, for
+// embedders writing custom handlers.
+//
+// A custom handler can be checked with:
+//
+//	func TestMyHandler(t *testing.T) {
+//		protocoltest.RunHandler(t, myHandler, func() *handler.Context {
+//			return handler.NewContext(nil, nil)
+//		})
+//	}
+package protocoltest
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/go-i2p/go-sam-bridge/lib/handler"
+	"github.com/go-i2p/go-sam-bridge/lib/protocol"
+)
+
+// Results lists the RESULT values a SAM response may carry.
+var Results = []string{
+	protocol.ResultOK,
+	protocol.ResultAlreadyAccepting,
+	protocol.ResultCantReachPeer,
+	protocol.ResultDuplicatedDest,
+	protocol.ResultDuplicatedID,
+	protocol.ResultI2PError,
+	protocol.ResultInvalidKey,
+	protocol.ResultInvalidID,
+	protocol.ResultKeyNotFound,
+	protocol.ResultPeerNotFound,
+	protocol.ResultTimeout,
+	protocol.ResultNoVersion,
+	protocol.ResultLeasesetNotFound,
+}
+
+// RunParser parses every corpus line with p, checking that well-formed
+// lines give the expected command and malformed lines the expected error.
+func RunParser(t *testing.T, p *protocol.Parser) {
+	t.Helper()
+	for _, tc := range Corpus() {
+		t.Run(tc.Name, func(t *testing.T) {
+			cmd, err := p.Parse(tc.Line)
+			if tc.Err != nil {
+				if !errors.Is(err, tc.Err) {
+					t.Errorf("Parse(%q) error = %v, want %v", tc.Line, err, tc.Err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Parse(%q) error = %v", tc.Line, err)
+			}
+			if cmd.Verb != tc.Verb || cmd.Action != tc.Action {
+				t.Errorf("Parse(%q) = %s %s, want %s %s", tc.Line, cmd.Verb, cmd.Action, tc.Verb, tc.Action)
+			}
+			if tc.Options != nil && !maps.Equal(cmd.Options, tc.Options) {
+				t.Errorf("Parse(%q) options = %q, want %q", tc.Line, cmd.Options, tc.Options)
+			}
+		})
+	}
+}
+
+// RunHandler passes every well-formed corpus command to h, each with a
+// fresh context from newContext, and checks the responses with
+// CheckResponse. Handlers may return errors, but must not panic.
+func RunHandler(t *testing.T, h handler.Handler, newContext func() *handler.Context) {
+	t.Helper()
+	p := protocol.NewParser()
+	for _, tc := range Valid {
+		t.Run(tc.Name, func(t *testing.T) {
+			cmd, err := p.Parse(tc.Line)
+			if err != nil {
+				t.Fatalf("Parse(%q) error = %v", tc.Line, err)
+			}
+			resp, err := handle(h, newContext(), cmd)
+			var pe *PanicError
+			if errors.As(err, &pe) {
+				t.Fatalf("Handle(%q): %v", tc.Line, err)
+			}
+			if err != nil {
+				t.Logf("Handle(%q) error = %v", tc.Line, err)
+			}
+			if resp != nil {
+				if err := CheckResponse(resp); err != nil {
+					t.Errorf("Handle(%q): %v", tc.Line, err)
+				}
+			}
+		})
+	}
+}
+
+// handle calls h, turning a panic into an error.
+func handle(h handler.Handler, ctx *handler.Context, cmd *protocol.Command) (resp *protocol.Response, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			resp, err = nil, &PanicError{Value: r}
+		}
+	}()
+	return h.Handle(ctx, cmd)
+}
+
+// PanicError reports a handler that panicked.
+type PanicError struct {
+	Value any
+}
+
+// Error implements error.
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("handler panicked: %v", e.Value)
+}
+
+// CheckResponse returns an error if r is not a well-formed SAM reply: a
+// single line with a verb, parseable by protocol.Parser, whose RESULT, if
+// any, is one of Results.
+func CheckResponse(r *protocol.Response) error {
+	if r.Verb == "" {
+		return errors.New("response has no verb")
+	}
+	line := strings.TrimSuffix(r.String(), "\n")
+	if strings.ContainsAny(line, "\r\n") {
+		return fmt.Errorf("response spans several lines: %q", line)
+	}
+	cmd, err := protocol.NewParser().Parse(line)
+	if err != nil {
+		return fmt.Errorf("response does not parse: %w", err)
+	}
+	if result, ok := cmd.Options["RESULT"]; ok && !slices.Contains(Results, result) {
+		return fmt.Errorf("unknown RESULT %q", result)
+	}
+	return nil
+}
+
+// AddSeeds adds every corpus line to the seed corpus of a fuzz test
+// taking a single string argument.
+func AddSeeds(f *testing.F) {
+	for _, tc := range Corpus() {
+		f.Add(tc.Line)
+	}
+}
@@ -0,0 +1,77 @@
+package protocoltest
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/go-i2p/go-sam-bridge/lib/handler"
+	"github.com/go-i2p/go-sam-bridge/lib/protocol"
+)
+
+func TestRunParser(t *testing.T) {
+	RunParser(t, protocol.NewParser())
+
+	strict := protocol.NewParser()
+	strict.Strict = true
+	RunParser(t, strict)
+}
+
+func TestRunHandler(t *testing.T) {
+	router := handler.NewRouter()
+	router.Register("PING", handler.NewPingHandler())
+	router.Register("NAMING LOOKUP", handler.NewNamingHandler(nil))
+	router.UnknownHandler = handler.HandlerFunc(func(ctx *handler.Context, cmd *protocol.Command) (*protocol.Response, error) {
+		return protocol.NewResponse(cmd.Verb).WithAction(protocol.ActionStatus).WithResult(protocol.ResultI2PError), nil
+	})
+
+	RunHandler(t, router, func() *handler.Context {
+		return handler.NewContext(nil, nil)
+	})
+}
+
+func TestCheckResponse(t *testing.T) {
+	tests := []struct {
+		name    string
+		resp    *protocol.Response
+		wantErr bool
+	}{
+		{"ok", protocol.NewResponse("HELLO").WithAction("REPLY").WithResult(protocol.ResultOK).WithVersion("3.3"), false},
+		{"message with spaces", protocol.NewResponse("SESSION").WithAction("STATUS").WithResult(protocol.ResultI2PError).WithMessage("no such session"), false},
+		{"no verb", &protocol.Response{}, true},
+		{"unknown result", protocol.NewResponse("HELLO").WithAction("REPLY").WithResult("MAYBE"), true},
+		{"newline", protocol.NewResponse("HELLO").WithAction("REPLY\nRESULT=OK"), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := CheckResponse(tt.resp); (err != nil) != tt.wantErr {
+				t.Errorf("CheckResponse() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestHandle_Panic(t *testing.T) {
+	h := handler.HandlerFunc(func(ctx *handler.Context, cmd *protocol.Command) (*protocol.Response, error) {
+		panic("boom")
+	})
+	_, err := handle(h, handler.NewContext(nil, nil), &protocol.Command{Verb: "PING"})
+	var pe *PanicError
+	if !errors.As(err, &pe) || pe.Value != "boom" {
+		t.Errorf("handle() error = %v, want a PanicError", err)
+	}
+}
+
+func FuzzParse(f *testing.F) {
+	AddSeeds(f)
+	p := protocol.NewParser()
+	strict := protocol.NewParser()
+	strict.Strict = true
+	f.Fuzz(func(t *testing.T, line string) {
+		for _, p := range []*protocol.Parser{p, strict} {
+			cmd, err := p.Parse(line)
+			if err == nil && cmd.Verb == "" {
+				t.Errorf("Parse(%q) returned a command without a verb", line)
+			}
+		}
+	})
+}
@@ -0,0 +1,97 @@
+package protocol
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// DefaultMaxLineLength is the longest command line, in bytes, a Parser
+// reads when MaxLineLength is not set.
+const DefaultMaxLineLength = 64 * 1024
+
+// MaxPayloadSize is the largest payload, in bytes, a Parser reads after a
+// command line. Per SAMv3.md, RAW SEND payloads are at most 32768 bytes
+// and DATAGRAM SEND payloads are smaller.
+const MaxPayloadSize = 32768
+
+// ErrLineTooLong is returned when a command line exceeds the parser's
+// MaxLineLength. See WithMaxLineLength.
+var ErrLineTooLong = errors.New("command line too long")
+
+// ReadLine reads one command line from r and returns it without the line
+// terminator. It returns ErrLineTooLong if the line is longer than the
+// parser's MaxLineLength.
+func (p *Parser) ReadLine(r *bufio.Reader) (string, error) {
+	maxLen := p.MaxLineLength
+	if maxLen <= 0 {
+		maxLen = DefaultMaxLineLength
+	}
+
+	var line strings.Builder
+	for {
+		part, isPrefix, err := r.ReadLine()
+		if err != nil {
+			return "", err
+		}
+		line.Write(part)
+		if line.Len() > maxLen {
+			return "", ErrLineTooLong
+		}
+		if !isPrefix {
+			return line.String(), nil
+		}
+	}
+}
+
+// ReadPayload reads exactly n payload bytes from r, such as the data
+// following a DATAGRAM SEND or RAW SEND line. It returns
+// io.ErrUnexpectedEOF if the connection ends first.
+func (p *Parser) ReadPayload(r *bufio.Reader, n int) ([]byte, error) {
+	if n < 0 || n > MaxPayloadSize {
+		return nil, fmt.Errorf("payload size %d out of range 0..%d", n, MaxPayloadSize)
+	}
+	payload := make([]byte, n)
+	if _, err := io.ReadFull(r, payload); err != nil {
+		return nil, err
+	}
+	return payload, nil
+}
+
+// ReadCommand reads a command line from r and parses it. For commands
+// carrying a payload (see Command.PayloadSize), it also reads the payload
+// into Command.Payload, leaving r at the start of the next command.
+func (p *Parser) ReadCommand(r *bufio.Reader) (*Command, error) {
+	line, err := p.ReadLine(r)
+	if err != nil {
+		return nil, err
+	}
+	cmd, err := p.Parse(line)
+	if err != nil {
+		return nil, err
+	}
+	if n, ok := cmd.PayloadSize(); ok {
+		if cmd.Payload, err = p.ReadPayload(r, n); err != nil {
+			return nil, err
+		}
+	}
+	return cmd, nil
+}
+
+// PayloadSize returns the number of payload bytes following the command
+// line, the SIZE of DATAGRAM SEND and RAW SEND. It returns false for other
+// commands and for a SIZE that is not a number from 1 to MaxPayloadSize;
+// handlers reject those, and no payload is read.
+func (c *Command) PayloadSize() (int, bool) {
+	if c.Action != ActionSend || (c.Verb != VerbDatagram && c.Verb != VerbRaw) {
+		return 0, false
+	}
+	n, err := strconv.Atoi(c.Get("SIZE"))
+	if err != nil || n < 1 || n > MaxPayloadSize {
+		return 0, false
+	}
+	return n, true
+}
@@ -0,0 +1,89 @@
+package protocol
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestParser_ReadCommand_Payload(t *testing.T) {
+	input := "DATAGRAM SEND DESTINATION=abc SIZE=5\nhelloRAW SEND DESTINATION=abc SIZE=3\n\x00\n\xffPING\n"
+	r := bufio.NewReader(strings.NewReader(input))
+	p := NewParser()
+
+	cmd, err := p.ReadCommand(r)
+	if err != nil {
+		t.Fatalf("ReadCommand() error = %v", err)
+	}
+	if cmd.Verb != VerbDatagram || string(cmd.Payload) != "hello" {
+		t.Errorf("first command = %s with payload %q, want DATAGRAM with %q", cmd.Verb, cmd.Payload, "hello")
+	}
+
+	cmd, err = p.ReadCommand(r)
+	if err != nil {
+		t.Fatalf("ReadCommand() error = %v", err)
+	}
+	if cmd.Verb != VerbRaw || string(cmd.Payload) != "\x00\n\xff" {
+		t.Errorf("second command = %s with payload %q, want RAW with binary payload", cmd.Verb, cmd.Payload)
+	}
+
+	cmd, err = p.ReadCommand(r)
+	if err != nil || cmd.Verb != VerbPing || cmd.Payload != nil {
+		t.Errorf("third command = %+v, %v; want PING without payload", cmd, err)
+	}
+}
+
+func TestParser_ReadCommand_ShortPayload(t *testing.T) {
+	r := bufio.NewReader(strings.NewReader("RAW SEND DESTINATION=abc SIZE=10\nshort"))
+	if _, err := NewParser().ReadCommand(r); !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Errorf("ReadCommand() error = %v, want io.ErrUnexpectedEOF", err)
+	}
+}
+
+func TestParser_ReadLine_MaxLineLength(t *testing.T) {
+	p := NewParser(WithMaxLineLength(8))
+
+	r := bufio.NewReader(strings.NewReader("PING abc\nPING abcdefgh\n"))
+	if line, err := p.ReadLine(r); err != nil || line != "PING abc" {
+		t.Errorf("ReadLine() = %q, %v; want %q", line, err, "PING abc")
+	}
+	if _, err := p.ReadLine(r); !errors.Is(err, ErrLineTooLong) {
+		t.Errorf("ReadLine() error = %v, want ErrLineTooLong", err)
+	}
+	if _, err := p.Parse("PING abcdefgh"); !errors.Is(err, ErrLineTooLong) {
+		t.Errorf("Parse() error = %v, want ErrLineTooLong", err)
+	}
+}
+
+func TestWithMaxLineLength(t *testing.T) {
+	if got := NewParser(WithMaxLineLength(1024)).MaxLineLength; got != 1024 {
+		t.Errorf("MaxLineLength = %d, want 1024", got)
+	}
+	if got := NewParser(WithMaxLineLength(0)).MaxLineLength; got != DefaultMaxLineLength {
+		t.Errorf("MaxLineLength = %d, want DefaultMaxLineLength", got)
+	}
+}
+
+func TestCommand_PayloadSize(t *testing.T) {
+	tests := []struct {
+		line   string
+		want   int
+		wantOK bool
+	}{
+		{"DATAGRAM SEND DESTINATION=x SIZE=100", 100, true},
+		{"RAW SEND DESTINATION=x SIZE=32768", 32768, true},
+		{"RAW SEND DESTINATION=x SIZE=32769", 0, false},
+		{"RAW SEND DESTINATION=x SIZE=0", 0, false},
+		{"RAW SEND DESTINATION=x SIZE=abc", 0, false},
+		{"RAW SEND DESTINATION=x", 0, false},
+		{"STREAM CONNECT ID=x DESTINATION=y SIZE=5", 0, false},
+	}
+	for _, tt := range tests {
+		n, ok := MustParse(tt.line).PayloadSize()
+		if n != tt.want || ok != tt.wantOK {
+			t.Errorf("PayloadSize(%q) = %d, %v; want %d, %v", tt.line, n, ok, tt.want, tt.wantOK)
+		}
+	}
+}
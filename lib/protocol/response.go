@@ -1,6 +1,7 @@
 package protocol
 
 import (
+	"slices"
 	"strings"
 )
 
@@ -21,6 +22,19 @@ type Response struct {
 	// Used for STREAM ACCEPT which sends destination info on a separate line.
 	// Each additional line is sent as-is with newline terminator.
 	AdditionalLines []string
+
+	// cached is the formatted main line of a precomputed response. String
+	// returns it while Verb, Action and the number of Options are still
+	// those it was formatted from.
+	cached *cachedLine
+}
+
+// cachedLine is the formatted main line of a precomputed response and
+// the fields it was formatted from.
+type cachedLine struct {
+	verb, action string
+	options      int
+	text         string
 }
 
 // NewResponse creates a new response builder with the given verb.
@@ -79,13 +93,27 @@ func (r *Response) WithAdditionalLine(line string) *Response {
 // Note: This only returns the main response line. Use FullString() to get
 // all lines including additional lines.
 func (r *Response) String() string {
-	var parts []string
-	parts = append(parts, r.Verb)
+	if c := r.cached; c != nil && c.verb == r.Verb && c.action == r.Action && c.options == len(r.Options) {
+		return c.text
+	}
+
+	n := len(r.Verb) + len(r.Action) + 2
+	for _, opt := range r.Options {
+		n += len(opt) + 1
+	}
+	var b strings.Builder
+	b.Grow(n)
+	b.WriteString(r.Verb)
 	if r.Action != "" {
-		parts = append(parts, r.Action)
+		b.WriteByte(' ')
+		b.WriteString(r.Action)
 	}
-	parts = append(parts, r.Options...)
-	return strings.Join(parts, " ") + "\n"
+	for _, opt := range r.Options {
+		b.WriteByte(' ')
+		b.WriteString(opt)
+	}
+	b.WriteByte('\n')
+	return b.String()
 }
 
 // FullString returns the complete response including all additional lines.
@@ -132,10 +160,55 @@ func escapeValue(s string) string {
 	return s
 }
 
+// precomputed is a response formatted once, for replies sent unchanged
+// on hot paths. Each use gets its own copy.
+type precomputed struct {
+	resp Response
+	line cachedLine
+}
+
+// precompute formats r for repeated use. The Options of the copies share
+// one backing array, clipped so that appending to them copies it.
+func precompute(r *Response) *precomputed {
+	r.Options = slices.Clip(r.Options)
+	return &precomputed{
+		resp: *r,
+		line: cachedLine{verb: r.Verb, action: r.Action, options: len(r.Options), text: r.String()},
+	}
+}
+
+// response returns a copy of the precomputed response.
+func (p *precomputed) response() *Response {
+	r := p.resp
+	r.cached = &p.line
+	return &r
+}
+
+var (
+	helloReplyOK = func() map[string]*precomputed {
+		versions := []string{"3.0", "3.1", "3.2", "3.3"}
+		m := make(map[string]*precomputed, len(versions))
+		for _, v := range versions {
+			m[v] = precompute(newHelloReplyOK(v))
+		}
+		return m
+	}()
+	streamStatusOK = precompute(NewResponse(VerbStream).WithAction(ActionStatus).WithResult(ResultOK))
+	pong           = precompute(NewResponse(VerbPong))
+)
+
 // Helper functions to create common responses
 
 // HelloReplyOK creates a successful HELLO REPLY response with version.
+// Replies for supported versions are precomputed.
 func HelloReplyOK(version string) *Response {
+	if p, ok := helloReplyOK[version]; ok {
+		return p.response()
+	}
+	return newHelloReplyOK(version)
+}
+
+func newHelloReplyOK(version string) *Response {
 	return NewResponse(VerbHello).
 		WithAction(ActionReply).
 		WithResult(ResultOK).
@@ -173,11 +246,10 @@ func SessionStatusError(result, message string) *Response {
 		WithMessage(message)
 }
 
-// StreamStatusOK creates a successful STREAM STATUS response.
+// StreamStatusOK creates a successful STREAM STATUS response. It is
+// precomputed.
 func StreamStatusOK() *Response {
-	return NewResponse(VerbStream).
-		WithAction(ActionStatus).
-		WithResult(ResultOK)
+	return streamStatusOK.response()
 }
 
 // StreamStatusError creates a STREAM STATUS error response.
@@ -214,12 +286,11 @@ func NamingReplyNotFound(name string) *Response {
 }
 
 // Pong creates a PONG response with the original ping data.
+// A PONG without data is precomputed.
 func Pong(data string) *Response {
 	if data == "" {
-		return NewResponse(VerbPong)
+		return pong.response()
 	}
 	// PONG includes arbitrary text directly, not as key=value
-	r := NewResponse(VerbPong)
-	r.Options = append(r.Options, data)
-	return r
+	return &Response{Verb: VerbPong, Options: []string{data}}
 }
@@ -239,3 +239,50 @@ func TestHelperResponses(t *testing.T) {
 		}
 	})
 }
+
+func TestPrecomputedResponses(t *testing.T) {
+	if got, want := HelloReplyOK("3.3").String(), "HELLO REPLY RESULT=OK VERSION=3.3\n"; got != want {
+		t.Errorf("HelloReplyOK(3.3) = %q, want %q", got, want)
+	}
+	if got, want := HelloReplyOK("3.9").String(), "HELLO REPLY RESULT=OK VERSION=3.9\n"; got != want {
+		t.Errorf("HelloReplyOK(3.9) = %q, want %q", got, want)
+	}
+
+	// Changing a copy must not affect its string or later copies.
+	r := StreamStatusOK().WithMessage("changed")
+	if got, want := r.String(), "STREAM STATUS RESULT=OK MESSAGE=changed\n"; got != want {
+		t.Errorf("modified StreamStatusOK() = %q, want %q", got, want)
+	}
+	r = StreamStatusOK()
+	r.Verb = VerbSession
+	if got, want := r.String(), "SESSION STATUS RESULT=OK\n"; got != want {
+		t.Errorf("StreamStatusOK() with new verb = %q, want %q", got, want)
+	}
+	if got, want := StreamStatusOK().String(), "STREAM STATUS RESULT=OK\n"; got != want {
+		t.Errorf("StreamStatusOK() = %q, want %q", got, want)
+	}
+	if got, want := Pong("").String(), "PONG\n"; got != want {
+		t.Errorf("Pong() = %q, want %q", got, want)
+	}
+}
+
+func BenchmarkHelloReplyOK(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		_ = HelloReplyOK("3.3").String()
+	}
+}
+
+func BenchmarkStreamStatusOK(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		_ = StreamStatusOK().String()
+	}
+}
+
+func BenchmarkPong(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		_ = Pong("1234").String()
+	}
+}
@@ -0,0 +1,144 @@
+package protocol
+
+import (
+	"bufio"
+	"io"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultWriteBufferSize is the buffer size of a ResponseWriter when none
+// is given.
+const DefaultWriteBufferSize = 4096
+
+// ResponseWriter buffers SAM responses to a connection so that several
+// replies, such as a burst of DATAGRAM RECEIVED messages, go out in one
+// write. Nothing is sent until Flush is called or the buffer fills.
+//
+// If the underlying writer has a SetWriteDeadline method, as net.Conn
+// does, each write to it is given the write timeout.
+//
+// ResponseWriter is safe for concurrent use. A response and its payload
+// written in one call are never interleaved with other writes.
+type ResponseWriter struct {
+	mu    sync.Mutex
+	buf   *bufio.Writer
+	dw    *deadlineWriter
+	trace func(line string)
+}
+
+// NewResponseWriter returns a ResponseWriter writing to w with a buffer
+// of size bytes, or DefaultWriteBufferSize if size <= 0.
+func NewResponseWriter(w io.Writer, size int) *ResponseWriter {
+	if size <= 0 {
+		size = DefaultWriteBufferSize
+	}
+	dw := &deadlineWriter{w: w}
+	return &ResponseWriter{buf: bufio.NewWriterSize(dw, size), dw: dw}
+}
+
+// SetWriteTimeout sets how long each write to the connection may block,
+// or no limit if d <= 0.
+func (rw *ResponseWriter) SetWriteTimeout(d time.Duration) {
+	rw.mu.Lock()
+	defer rw.mu.Unlock()
+	rw.dw.timeout = d
+}
+
+// SetTrace sets fn to be called with every line written through
+// WriteResponse, WriteLine and WriteMessage, without its newline, in the
+// order written. Payloads and bytes given to Write are not passed. fn is
+// called with the writer locked and must not block. Nil disables it.
+func (rw *ResponseWriter) SetTrace(fn func(line string)) {
+	rw.mu.Lock()
+	defer rw.mu.Unlock()
+	rw.trace = fn
+}
+
+// WriteResponse buffers r and its additional lines.
+func (rw *ResponseWriter) WriteResponse(r *Response) error {
+	rw.mu.Lock()
+	defer rw.mu.Unlock()
+	line := r.String()
+	if rw.trace != nil {
+		rw.trace(strings.TrimSuffix(line, "\n"))
+	}
+	if _, err := rw.buf.WriteString(line); err != nil {
+		return err
+	}
+	for _, line := range r.AdditionalLines {
+		if err := rw.writeLineLocked(line); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WriteLine buffers line followed by a newline.
+func (rw *ResponseWriter) WriteLine(line string) error {
+	rw.mu.Lock()
+	defer rw.mu.Unlock()
+	return rw.writeLineLocked(line)
+}
+
+// WriteMessage buffers a header line followed by its binary payload, as
+// for DATAGRAM RECEIVED and RAW RECEIVED.
+func (rw *ResponseWriter) WriteMessage(header string, payload []byte) error {
+	rw.mu.Lock()
+	defer rw.mu.Unlock()
+	if err := rw.writeLineLocked(header); err != nil {
+		return err
+	}
+	_, err := rw.buf.Write(payload)
+	return err
+}
+
+// Write buffers p. It implements io.Writer.
+func (rw *ResponseWriter) Write(p []byte) (int, error) {
+	rw.mu.Lock()
+	defer rw.mu.Unlock()
+	return rw.buf.Write(p)
+}
+
+// Flush sends everything buffered.
+func (rw *ResponseWriter) Flush() error {
+	rw.mu.Lock()
+	defer rw.mu.Unlock()
+	return rw.buf.Flush()
+}
+
+// Buffered returns the number of bytes waiting for Flush.
+func (rw *ResponseWriter) Buffered() int {
+	rw.mu.Lock()
+	defer rw.mu.Unlock()
+	return rw.buf.Buffered()
+}
+
+// writeLineLocked buffers line and a newline. Callers must hold rw.mu.
+func (rw *ResponseWriter) writeLineLocked(line string) error {
+	if rw.trace != nil {
+		rw.trace(line)
+	}
+	if _, err := rw.buf.WriteString(line); err != nil {
+		return err
+	}
+	return rw.buf.WriteByte('\n')
+}
+
+// deadlineWriter sets the write deadline of w, if it has one, before
+// each write.
+type deadlineWriter struct {
+	w       io.Writer
+	timeout time.Duration
+}
+
+// Write implements io.Writer.
+func (d *deadlineWriter) Write(p []byte) (int, error) {
+	if dl, ok := d.w.(interface{ SetWriteDeadline(time.Time) error }); ok && d.timeout > 0 {
+		if err := dl.SetWriteDeadline(time.Now().Add(d.timeout)); err != nil {
+			return 0, err
+		}
+	}
+	return d.w.Write(p)
+}
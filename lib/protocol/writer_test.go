@@ -0,0 +1,150 @@
+package protocol
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// deadlineBuffer records writes and the write deadline set before each.
+type deadlineBuffer struct {
+	bytes.Buffer
+	writes    int
+	deadlines []time.Time
+	pending   time.Time
+}
+
+func (b *deadlineBuffer) SetWriteDeadline(t time.Time) error {
+	b.pending = t
+	return nil
+}
+
+func (b *deadlineBuffer) Write(p []byte) (int, error) {
+	b.writes++
+	b.deadlines = append(b.deadlines, b.pending)
+	return b.Buffer.Write(p)
+}
+
+func TestResponseWriter_Flush(t *testing.T) {
+	var out deadlineBuffer
+	w := NewResponseWriter(&out, 0)
+
+	resp := NewResponse(VerbStream).WithAction(ActionStatus).WithResult(ResultOK).WithAdditionalLine("DEST FROM_PORT=0")
+	if err := w.WriteResponse(resp); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.WriteMessage("RAW RECEIVED SIZE=3", []byte{0, '\n', 1}); err != nil {
+		t.Fatal(err)
+	}
+	if out.Len() != 0 || w.Buffered() == 0 {
+		t.Fatalf("wrote %d bytes before Flush, buffered %d; want nothing written", out.Len(), w.Buffered())
+	}
+
+	if err := w.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	want := "STREAM STATUS RESULT=OK\nDEST FROM_PORT=0\nRAW RECEIVED SIZE=3\n\x00\n\x01"
+	if out.String() != want {
+		t.Errorf("flushed %q, want %q", out.String(), want)
+	}
+	if out.writes != 1 {
+		t.Errorf("flushed in %d writes, want 1", out.writes)
+	}
+	if !out.deadlines[0].IsZero() {
+		t.Error("write deadline set without a write timeout")
+	}
+}
+
+func TestResponseWriter_WriteTimeout(t *testing.T) {
+	var out deadlineBuffer
+	w := NewResponseWriter(&out, 16)
+	w.SetWriteTimeout(time.Minute)
+
+	// Larger than the buffer, so it is written without Flush
+	if err := w.WriteLine(strings.Repeat("x", 32)); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	if out.writes == 0 {
+		t.Fatal("nothing written")
+	}
+	for i, d := range out.deadlines {
+		if time.Until(d) < 50*time.Second {
+			t.Errorf("write %d has deadline %v, want about a minute ahead", i, d)
+		}
+	}
+}
+
+type failingWriter struct{}
+
+func (failingWriter) Write([]byte) (int, error) { return 0, errors.New("closed") }
+
+func TestResponseWriter_Concurrent(t *testing.T) {
+	var out bytes.Buffer
+	w := NewResponseWriter(&out, 64)
+
+	var wg sync.WaitGroup
+	for i := range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			payload := bytes.Repeat([]byte{byte('a' + i)}, 100)
+			for range 10 {
+				if err := w.WriteMessage("DATAGRAM RECEIVED SIZE=100", payload); err != nil {
+					t.Error(err)
+				}
+				w.Flush()
+			}
+		}()
+	}
+	wg.Wait()
+
+	// Every payload directly follows its header
+	msgs := strings.Split(out.String(), "DATAGRAM RECEIVED SIZE=100\n")
+	if len(msgs) != 81 {
+		t.Fatalf("got %d messages, want 80", len(msgs)-1)
+	}
+	for _, m := range msgs[1:] {
+		if len(m) != 100 || strings.Count(m, m[:1]) != 100 {
+			t.Fatalf("interleaved payload %q", m)
+		}
+	}
+}
+
+func TestResponseWriter_FlushError(t *testing.T) {
+	w := NewResponseWriter(failingWriter{}, 0)
+	if err := w.WriteLine("PING"); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Flush(); err == nil {
+		t.Error("Flush() to a closed connection succeeded")
+	}
+}
+
+func TestResponseWriter_SetTrace(t *testing.T) {
+	var out bytes.Buffer
+	w := NewResponseWriter(&out, 0)
+	var lines []string
+	w.SetTrace(func(line string) { lines = append(lines, line) })
+
+	resp := NewResponse(VerbStream).WithAction(ActionStatus).WithResult(ResultOK).WithAdditionalLine("DEST FROM_PORT=0")
+	if err := w.WriteResponse(resp); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.WriteMessage("RAW RECEIVED SIZE=3", []byte("abc")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.Write([]byte("stream data")); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{"STREAM STATUS RESULT=OK", "DEST FROM_PORT=0", "RAW RECEIVED SIZE=3"}
+	if strings.Join(lines, "|") != strings.Join(want, "|") {
+		t.Errorf("traced %q, want %q", lines, want)
+	}
+}
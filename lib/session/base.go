@@ -5,6 +5,7 @@ import (
 	"context"
 	"net"
 	"sync"
+	"time"
 )
 
 // BaseSession provides common functionality for all session types.
@@ -23,6 +24,27 @@ type BaseSession struct {
 	// i2cpSession holds the I2CP session handle for tunnel management.
 	// ISSUE-003: Used to wait for tunnel readiness and manage I2CP lifecycle.
 	i2cpSession I2CPSessionHandle
+
+	// stats tracks traffic counters. Reset when the session closes.
+	stats Stats
+
+	// timeline records when the session was created, became active and
+	// started closing.
+	timeline Timeline
+
+	// tunnels counts the tunnel events of the I2CP session.
+	tunnels TunnelHealth
+
+	// sharedDestination is set for PRIMARY subsessions, whose destination
+	// belongs to the primary session and must not be zeroed on close.
+	sharedDestination bool
+
+	// sendQueue holds outgoing datagrams for retry, or is nil if
+	// SessionConfig.SendQueueSize disables it. It is set once at creation.
+	sendQueue *sendQueue
+
+	// sendErrorHandler is called for each queued datagram that is dropped.
+	sendErrorHandler func(dest string, err error)
 }
 
 // NewBaseSession creates a new BaseSession with the given parameters.
@@ -31,14 +53,19 @@ func NewBaseSession(id string, style Style, dest *Destination, conn net.Conn, cf
 	if cfg == nil {
 		cfg = DefaultSessionConfig()
 	}
-	return &BaseSession{
+	b := &BaseSession{
 		id:          id,
 		style:       style,
 		destination: dest,
 		status:      StatusCreating,
 		controlConn: conn,
 		config:      cfg,
+		timeline:    Timeline{Created: time.Now()},
 	}
+	b.sendQueue = newSendQueue(cfg, &b.stats, b.reportSendError)
+	// Idle time counts from creation
+	b.stats.Touch()
+	return b
 }
 
 // ID returns the unique session identifier (nickname).
@@ -91,6 +118,17 @@ func (b *BaseSession) SetStatus(s Status) {
 	b.mu.Lock()
 	defer b.mu.Unlock()
 	b.status = s
+	b.timeline.recordStatus(s, time.Now())
+}
+
+// MarkTunnelsReady records that the session's tunnels have been built.
+// Only the first call has an effect.
+func (b *BaseSession) MarkTunnelsReady() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.timeline.Ready.IsZero() {
+		b.timeline.Ready = time.Now()
+	}
 }
 
 // SetDestination updates the session destination.
@@ -101,6 +139,14 @@ func (b *BaseSession) SetDestination(dest *Destination) {
 	b.destination = dest
 }
 
+// shareDestination marks the destination as owned by another session,
+// so Close leaves its keys in place.
+func (b *BaseSession) shareDestination() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.sharedDestination = true
+}
+
 // Activate transitions the session from Creating to Active status.
 // Returns false if the session is not in Creating status.
 func (b *BaseSession) Activate() bool {
@@ -113,9 +159,10 @@ func (b *BaseSession) Activate() bool {
 	return true
 }
 
-// Close terminates the session and releases all resources.
-// Close is safe to call multiple times; subsequent calls are no-ops.
-// Implements the Session interface Close method.
+// Close terminates the session and releases all resources, and zeroes
+// the destination's private keys unless they are shared with a PRIMARY
+// session. Close is safe to call multiple times; subsequent calls are
+// no-ops. Implements the Session interface Close method.
 func (b *BaseSession) Close() error {
 	b.mu.Lock()
 	defer b.mu.Unlock()
@@ -126,9 +173,15 @@ func (b *BaseSession) Close() error {
 	}
 
 	b.status = StatusClosing
+	b.timeline.recordStatus(StatusClosing, time.Now())
 
 	var errs []error
 
+	// Discard queued datagrams before the I2CP session goes away
+	if b.sendQueue != nil {
+		b.sendQueue.close()
+	}
+
 	// Close I2CP session first
 	if b.i2cpSession != nil {
 		if err := b.i2cpSession.Close(); err != nil {
@@ -145,7 +198,12 @@ func (b *BaseSession) Close() error {
 		b.controlConn = nil
 	}
 
+	if !b.sharedDestination {
+		b.destination.Zero()
+	}
+
 	b.status = StatusClosed
+	b.stats.Reset()
 
 	if len(errs) > 0 {
 		return errs[0] // Return first error
@@ -153,6 +211,56 @@ func (b *BaseSession) Close() error {
 	return nil
 }
 
+// SetSendErrorHandler sets fn to be called with the destination and error
+// of each queued datagram that is dropped because it expired or could
+// not be sent. It has no effect unless SessionConfig.SendQueueSize
+// enables the send queue. fn must not block for long, since it delays
+// the datagrams queued behind. Nil disables it.
+func (b *BaseSession) SetSendErrorHandler(fn func(dest string, err error)) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.sendErrorHandler = fn
+}
+
+// reportSendError passes a dropped datagram to the send error handler.
+func (b *BaseSession) reportSendError(dest string, err error) {
+	b.mu.RLock()
+	fn := b.sendErrorHandler
+	b.mu.RUnlock()
+	if fn != nil {
+		fn(dest, err)
+	}
+}
+
+// sendDatagram sends data to dest with send and records it as sent. If
+// the session has a send queue, the datagram is queued instead and send
+// is called, possibly several times, from the queue's goroutine; the
+// error then only reports a full queue.
+func (b *BaseSession) sendDatagram(dest string, data []byte, send func(data []byte) error) error {
+	if b.sendQueue != nil {
+		return b.sendQueue.enqueue(dest, data, send)
+	}
+	if err := send(data); err != nil {
+		return err
+	}
+	b.stats.AddDatagramSent(len(data))
+	return nil
+}
+
+// Stats returns the session's traffic counters.
+// Implements StatsProvider.
+func (b *BaseSession) Stats() *Stats {
+	return &b.stats
+}
+
+// Timeline returns when the session was created, became active and
+// started closing. Implements TimelineProvider.
+func (b *BaseSession) Timeline() Timeline {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return b.timeline
+}
+
 // IsClosed returns true if the session has been closed.
 func (b *BaseSession) IsClosed() bool {
 	b.mu.RLock()
@@ -182,6 +290,22 @@ func (b *BaseSession) I2CPSession() I2CPSessionHandle {
 	return b.i2cpSession
 }
 
+// RecordTunnelEvent records a change in the session's tunnels, as
+// reported by its I2CP session.
+func (b *BaseSession) RecordTunnelEvent(ev TunnelEvent) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.tunnels.Record(ev)
+}
+
+// TunnelHealth returns the tunnel events recorded so far. The second
+// result is false if none have been. Implements TunnelHealthProvider.
+func (b *BaseSession) TunnelHealth() (TunnelHealth, bool) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return b.tunnels, !b.tunnels.Last.Time.IsZero()
+}
+
 // WaitForTunnels blocks until tunnels are built or context is cancelled.
 // Per SAMv3.md: "the router builds tunnels before responding with SESSION STATUS.
 // This could take several seconds."
@@ -195,5 +319,9 @@ func (b *BaseSession) WaitForTunnels(ctx context.Context) error {
 	if i2cp == nil {
 		return nil // No I2CP session, don't block
 	}
-	return i2cp.WaitForTunnels(ctx)
+	if err := i2cp.WaitForTunnels(ctx); err != nil {
+		return err
+	}
+	b.MarkTunnelsReady()
+	return nil
 }
@@ -159,6 +159,19 @@ func TestBaseSession_Close(t *testing.T) {
 		}
 	})
 
+	t.Run("close zeroes private key", func(t *testing.T) {
+		priv := []byte{1, 2, 3}
+		dest := &Destination{PublicKey: []byte("pub"), PrivateKey: priv}
+		session := NewBaseSession("test-id", StyleStream, dest, nil, nil)
+
+		if err := session.Close(); err != nil {
+			t.Errorf("Close() returned error: %v", err)
+		}
+		if dest.PrivateKey != nil || priv[0] != 0 {
+			t.Error("Close() should zero the destination's private key")
+		}
+	})
+
 	t.Run("close without connection", func(t *testing.T) {
 		session := NewBaseSession("test-id", StyleStream, nil, nil, nil)
 		session.SetStatus(StatusActive)
@@ -186,6 +199,17 @@ func TestBaseSession_Close(t *testing.T) {
 		}
 	})
 
+	t.Run("close resets stats", func(t *testing.T) {
+		session := NewBaseSession("test-id", StyleStream, nil, nil, nil)
+		session.SetStatus(StatusActive)
+		session.Stats().AddDatagramSent(10)
+
+		_ = session.Close()
+		if got := session.Stats().Snapshot(); got != (StatsSnapshot{}) {
+			t.Errorf("Stats() after Close() = %+v, want zero", got)
+		}
+	})
+
 	t.Run("close already closing", func(t *testing.T) {
 		session := NewBaseSession("test-id", StyleStream, nil, nil, nil)
 		session.SetStatus(StatusClosing)
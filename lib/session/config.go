@@ -1,7 +1,14 @@
 // Package session implements SAM v3.0-3.3 session management.
 package session
 
-import "time"
+import (
+	"fmt"
+	"net"
+	"time"
+	"unicode"
+
+	"github.com/go-i2p/go-sam-bridge/lib/util"
+)
 
 // SessionConfig holds configuration options for SAM sessions.
 // These options are set during SESSION CREATE and affect tunnel behavior.
@@ -88,6 +95,78 @@ type SessionConfig struct {
 	// Default is 0 (use default 7655).
 	SamUDPPort int
 
+	// Label is a human-readable name for the session, shown in SESSION LIST,
+	// logs and metrics. It is set from the LABEL option of SESSION CREATE,
+	// falling back to inbound.nickname. It has no effect on I2P.
+	Label string
+
+	// SAMVersion is the SAM version negotiated by HELLO on the control
+	// connection that created the session. It selects the options honored
+	// in datagrams the client sends to the UDP port and the headers of
+	// forwarded datagrams. Empty means the latest version.
+	SAMVersion string
+
+	// User is the authenticated SAM user whose control connection created
+	// the session, empty if authentication was not used. It is recorded
+	// for logging.
+	User string
+
+	// ReceiveBufferSize is the number of received datagrams DATAGRAM,
+	// DATAGRAM2, DATAGRAM3 and RAW sessions queue for the client, set by
+	// the sam.receiveBuffer option. 0 means DefaultReceiveBufferSize.
+	ReceiveBufferSize int
+
+	// DropPolicy selects the datagram discarded when the receive queue is
+	// full, set by the sam.dropPolicy option.
+	DropPolicy DropPolicy
+
+	// StreamReadTimeout closes a stream of the session when the peer sends
+	// nothing for this long, and StreamWriteTimeout when a write to the
+	// peer stalls this long. They are set by the sam.streamReadTimeout and
+	// sam.streamWriteTimeout options in milliseconds. Zero disables them.
+	StreamReadTimeout  time.Duration
+	StreamWriteTimeout time.Duration
+
+	// MaxOutboundStreams and MaxInboundStreams cap the concurrent streams
+	// the session opens with STREAM CONNECT and accepts with STREAM ACCEPT
+	// or FORWARD, set by the sam.maxOutboundStreams and
+	// sam.maxInboundStreams options. Zero means no limit.
+	MaxOutboundStreams int
+	MaxInboundStreams  int
+
+	// SendQueueSize is the number of outgoing datagrams a DATAGRAM,
+	// DATAGRAM2, DATAGRAM3 or RAW session queues, so that a send failing
+	// while tunnels rebuild is retried instead of failing at once. It is
+	// set by the sam.sendQueue option; zero disables the queue. Queued
+	// datagrams are retried SendRetries times (sam.sendRetries, 0 means
+	// DefaultSendRetries) and dropped after SendExpiry (sam.sendExpiry in
+	// milliseconds, 0 means DefaultSendExpiry).
+	SendQueueSize int
+	SendRetries   int
+	SendExpiry    time.Duration
+
+	// SendErrors requests a DATAGRAM STATUS or RAW STATUS error reply on
+	// the control socket for each queued datagram that is dropped, set by
+	// the sam.sendErrors option.
+	SendErrors bool
+
+	// LeaseSetType is the i2cp.leaseSetType to publish. Zero leaves the
+	// choice to the I2CP layer; LeaseSetTypeEncrypted publishes an
+	// encrypted LeaseSet2 reachable through the .b33 address.
+	LeaseSetType int
+
+	// LeaseSetAuthType is the per-client authentication scheme of an
+	// encrypted lease set (i2cp.leaseSetAuthType).
+	LeaseSetAuthType int
+
+	// LeaseSetSecret is the optional lookup secret of an encrypted lease
+	// set (i2cp.leaseSetSecret).
+	LeaseSetSecret string
+
+	// LeaseSetClients are the clients authorized to read an encrypted
+	// lease set when LeaseSetAuthType is DH or PSK.
+	LeaseSetClients []LeaseSetClient
+
 	// OfflineSignature contains offline signature data if provided.
 	// Allows transient keys while keeping long-term identity offline.
 	OfflineSignature *OfflineSignature
@@ -117,6 +196,9 @@ type OfflineSignature struct {
 	TransientPublicKey []byte
 	// Signature is the signature from the long-term key.
 	Signature []byte
+	// TransientPrivateKey is the transient signing private key the I2CP
+	// session signs with. It is not part of Bytes.
+	TransientPrivateKey []byte
 }
 
 // Bytes returns the serialized offline signature for transmission.
@@ -319,29 +401,190 @@ type SubsessionOptions struct {
 	Port int
 }
 
+// MaxLabelLength is the maximum length of SessionConfig.Label in bytes.
+const MaxLabelLength = 64
+
+// ValidateLabel checks that label is at most MaxLabelLength bytes of
+// printable characters. The empty label is valid.
+func ValidateLabel(label string) error {
+	if len(label) > MaxLabelLength {
+		return ErrInvalidLabel
+	}
+	for _, r := range label {
+		if !unicode.IsPrint(r) {
+			return ErrInvalidLabel
+		}
+	}
+	return nil
+}
+
+// Label returns the label of sess, or the empty string if it has none.
+func Label(sess Session) string {
+	if cp, ok := sess.(interface{ Config() *SessionConfig }); ok {
+		if cfg := cp.Config(); cfg != nil {
+			return cfg.Label
+		}
+	}
+	return ""
+}
+
+// SAMVersion returns the SAM version sess was created with, or the empty
+// string (the latest version) if it is unknown.
+func SAMVersion(sess Session) string {
+	if cp, ok := sess.(interface{ Config() *SessionConfig }); ok {
+		if cfg := cp.Config(); cfg != nil {
+			return cfg.SAMVersion
+		}
+	}
+	return ""
+}
+
+// User returns the SAM user who created sess, or the empty string if it
+// is unknown or authentication was not used.
+func User(sess Session) string {
+	if cp, ok := sess.(interface{ Config() *SessionConfig }); ok {
+		if cfg := cp.Config(); cfg != nil {
+			return cfg.User
+		}
+	}
+	return ""
+}
+
+// LimitStream wraps conn, a stream of sess, so that it fails after the
+// inactivity timeouts of the session's configuration. If sess has none,
+// conn is returned unchanged.
+func LimitStream(sess Session, conn net.Conn) net.Conn {
+	cp, ok := sess.(interface{ Config() *SessionConfig })
+	if !ok {
+		return conn
+	}
+	cfg := cp.Config()
+	if cfg == nil {
+		return conn
+	}
+	return util.NewIdleConn(conn, cfg.StreamReadTimeout, cfg.StreamWriteTimeout)
+}
+
+// Tunnel parameter bounds enforced by Validate, matching the limits the
+// I2P router applies to the inbound.* and outbound.* options.
+const (
+	// MaxTunnelQuantity is the maximum number of tunnels in each direction.
+	MaxTunnelQuantity = 16
+
+	// MaxTunnelLength is the maximum number of hops per tunnel.
+	MaxTunnelLength = 7
+)
+
 // Validate checks that the session configuration is valid per SAM specification.
-// Returns an error if any option is out of valid range.
+// The error names the offending option as given in SESSION CREATE and
+// wraps one of ErrInvalidPort, ErrInvalidProtocol, ErrInvalidTunnelConfig,
+// ErrInvalidReceiveBuffer, ErrInvalidDropPolicy or ErrInvalidLabel.
 func (c *SessionConfig) Validate() error {
-	if c.FromPort < 0 || c.FromPort > 65535 {
-		return ErrInvalidPort
+	for _, p := range []struct {
+		name string
+		port int
+	}{
+		{"FROM_PORT", c.FromPort},
+		{"TO_PORT", c.ToPort},
+		{"LISTEN_PORT", c.ListenPort},
+		{"sam.udp.port", c.SamUDPPort},
+	} {
+		if p.port < 0 || p.port > 65535 {
+			return fmt.Errorf("%s=%d: %w", p.name, p.port, ErrInvalidPort)
+		}
+	}
+
+	if c.Protocol < 0 || c.Protocol > 255 || isDisallowedProtocol(c.Protocol) {
+		return fmt.Errorf("PROTOCOL=%d: %w", c.Protocol, ErrInvalidProtocol)
+	}
+	if c.ListenProtocol < 0 || c.ListenProtocol > 255 || isDisallowedProtocol(c.ListenProtocol) {
+		return fmt.Errorf("LISTEN_PROTOCOL=%d: %w", c.ListenProtocol, ErrInvalidProtocol)
+	}
+
+	for _, t := range []struct {
+		name       string
+		value, max int
+	}{
+		{"inbound.quantity", c.InboundQuantity, MaxTunnelQuantity},
+		{"outbound.quantity", c.OutboundQuantity, MaxTunnelQuantity},
+		{"inbound.length", c.InboundLength, MaxTunnelLength},
+		{"outbound.length", c.OutboundLength, MaxTunnelLength},
+		{"inbound.backupQuantity", c.InboundBackupQuantity, MaxTunnelQuantity},
+		{"outbound.backupQuantity", c.OutboundBackupQuantity, MaxTunnelQuantity},
+		{"i2cp.reduceQuantity", c.ReduceIdleQuantity, MaxTunnelQuantity},
+	} {
+		if t.value < 0 || t.value > t.max {
+			return fmt.Errorf("%s=%d: %w: must be 0-%d", t.name, t.value, ErrInvalidTunnelConfig, t.max)
+		}
+	}
+	if c.ReduceIdleTime < 0 || c.CloseIdleTime < 0 {
+		return fmt.Errorf("idle time may not be negative: %w", ErrInvalidTunnelConfig)
+	}
+
+	if c.ReceiveBufferSize < 0 || c.ReceiveBufferSize > MaxReceiveBufferSize {
+		return fmt.Errorf("sam.receiveBuffer=%d: %w", c.ReceiveBufferSize, ErrInvalidReceiveBuffer)
+	}
+	if c.DropPolicy != DropNewest && c.DropPolicy != DropOldest {
+		return fmt.Errorf("sam.dropPolicy=%s: %w", c.DropPolicy, ErrInvalidDropPolicy)
+	}
+	if c.StreamReadTimeout < 0 || c.StreamWriteTimeout < 0 {
+		return ErrInvalidStreamTimeout
+	}
+	if c.MaxOutboundStreams < 0 || c.MaxInboundStreams < 0 {
+		return ErrInvalidStreamLimit
+	}
+	if c.SendQueueSize < 0 || c.SendQueueSize > MaxSendQueueSize || c.SendRetries < 0 || c.SendExpiry < 0 {
+		return ErrInvalidSendQueue
+	}
+
+	if err := ValidateLabel(c.Label); err != nil {
+		return fmt.Errorf("LABEL: %w", err)
 	}
-	if c.ToPort < 0 || c.ToPort > 65535 {
-		return ErrInvalidPort
+	return c.validateLeaseSet()
+}
+
+// ValidateCreate checks a SESSION CREATE request: the session ID and
+// style, the configuration itself (see Validate), and options that are
+// not allowed with style. The error is suitable for the MESSAGE of a
+// SESSION STATUS reply.
+func (c *SessionConfig) ValidateCreate(id string, style Style) error {
+	if err := ValidateID(id); err != nil {
+		return err
 	}
-	if c.Protocol < 0 || c.Protocol > 255 {
-		return ErrInvalidProtocol
+	if !style.IsValid() {
+		return fmt.Errorf("STYLE=%s: %w", style, ErrInvalidStyle)
 	}
-	if isDisallowedProtocol(c.Protocol) {
-		return ErrInvalidProtocol
+	if err := c.Validate(); err != nil {
+		return err
 	}
-	if c.ListenPort < 0 || c.ListenPort > 65535 {
-		return ErrInvalidPort
+
+	if style != StyleRaw {
+		if c.HeaderEnabled {
+			return fmt.Errorf("HEADER requires STYLE=RAW: %w", ErrIncompatibleOptions)
+		}
+		if c.ListenProtocol != 0 {
+			return fmt.Errorf("LISTEN_PROTOCOL requires STYLE=RAW: %w", ErrIncompatibleOptions)
+		}
 	}
-	if c.InboundQuantity < 0 || c.OutboundQuantity < 0 {
-		return ErrInvalidTunnelConfig
+	if style.IsPrimary() && (c.FromPort != 0 || c.ToPort != 0 || c.ListenPort != 0) {
+		return fmt.Errorf("FROM_PORT, TO_PORT and LISTEN_PORT apply to subsessions, not STYLE=%s: %w", style, ErrIncompatibleOptions)
 	}
-	if c.InboundLength < 0 || c.OutboundLength < 0 {
-		return ErrInvalidTunnelConfig
+	if style == StyleDatagram && c.OfflineSignature != nil {
+		return fmt.Errorf("offline signatures require STYLE=DATAGRAM2, DATAGRAM3 or RAW: %w", ErrIncompatibleOptions)
+	}
+	return nil
+}
+
+// ValidateID checks a session ID (nickname). Per SAMv3.md the ID is a
+// single token, so it must be non-empty and contain no whitespace.
+func ValidateID(id string) error {
+	if id == "" {
+		return fmt.Errorf("missing ID: %w", ErrInvalidID)
+	}
+	for _, r := range id {
+		if unicode.IsSpace(r) || !unicode.IsPrint(r) {
+			return fmt.Errorf("ID %q: %w", id, ErrInvalidID)
+		}
 	}
 	return nil
 }
@@ -432,8 +675,17 @@ func (c *SessionConfig) Clone() *SessionConfig {
 		if c.OfflineSignature.Signature != nil {
 			offlineCopy.Signature = append([]byte{}, c.OfflineSignature.Signature...)
 		}
+		if c.OfflineSignature.TransientPrivateKey != nil {
+			offlineCopy.TransientPrivateKey = append([]byte{}, c.OfflineSignature.TransientPrivateKey...)
+		}
 		clone.OfflineSignature = &offlineCopy
 	}
+	if c.LeaseSetClients != nil {
+		clone.LeaseSetClients = make([]LeaseSetClient, len(c.LeaseSetClients))
+		for i, client := range c.LeaseSetClients {
+			clone.LeaseSetClients[i] = LeaseSetClient{Name: client.Name, Key: append([]byte{}, client.Key...)}
+		}
+	}
 	if c.I2CPOptions != nil {
 		clone.I2CPOptions = make(map[string]string, len(c.I2CPOptions))
 		for k, v := range c.I2CPOptions {
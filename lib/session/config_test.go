@@ -1,7 +1,10 @@
 package session
 
 import (
+	"errors"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestDefaultSessionConfig(t *testing.T) {
@@ -178,6 +181,132 @@ func TestSessionConfig_Validate(t *testing.T) {
 			},
 			wantErr: ErrInvalidTunnelConfig,
 		},
+		{
+			name: "InboundQuantity above maximum",
+			modify: func(c *SessionConfig) {
+				c.InboundQuantity = MaxTunnelQuantity + 1
+			},
+			wantErr: ErrInvalidTunnelConfig,
+		},
+		{
+			name: "OutboundLength above maximum",
+			modify: func(c *SessionConfig) {
+				c.OutboundLength = MaxTunnelLength + 1
+			},
+			wantErr: ErrInvalidTunnelConfig,
+		},
+		{
+			name: "disallowed ListenProtocol",
+			modify: func(c *SessionConfig) {
+				c.ListenProtocol = 6
+			},
+			wantErr: ErrInvalidProtocol,
+		},
+		{
+			name: "invalid SamUDPPort",
+			modify: func(c *SessionConfig) {
+				c.SamUDPPort = 70000
+			},
+			wantErr: ErrInvalidPort,
+		},
+		{
+			name: "valid Label",
+			modify: func(c *SessionConfig) {
+				c.Label = "web server (prod)"
+			},
+			wantErr: nil,
+		},
+		{
+			name: "Label too long",
+			modify: func(c *SessionConfig) {
+				c.Label = strings.Repeat("x", MaxLabelLength+1)
+			},
+			wantErr: ErrInvalidLabel,
+		},
+		{
+			name: "Label with control character",
+			modify: func(c *SessionConfig) {
+				c.Label = "bad\nlabel"
+			},
+			wantErr: ErrInvalidLabel,
+		},
+		{
+			name: "encrypted lease set with DH clients",
+			modify: func(c *SessionConfig) {
+				c.LeaseSetType = LeaseSetTypeEncrypted
+				c.LeaseSetAuthType = LeaseSetAuthDH
+				c.LeaseSetSecret = "secret"
+				c.LeaseSetClients = []LeaseSetClient{{Name: "alice", Key: make([]byte, LeaseSetClientKeyLength)}}
+			},
+			wantErr: nil,
+		},
+		{
+			name: "invalid lease set type",
+			modify: func(c *SessionConfig) {
+				c.LeaseSetType = 4
+			},
+			wantErr: ErrInvalidLeaseSet,
+		},
+		{
+			name: "lease set secret without encryption",
+			modify: func(c *SessionConfig) {
+				c.LeaseSetType = LeaseSetType2
+				c.LeaseSetSecret = "secret"
+			},
+			wantErr: ErrIncompatibleOptions,
+		},
+		{
+			name: "lease set auth without clients",
+			modify: func(c *SessionConfig) {
+				c.LeaseSetType = LeaseSetTypeEncrypted
+				c.LeaseSetAuthType = LeaseSetAuthPSK
+			},
+			wantErr: ErrInvalidLeaseSet,
+		},
+		{
+			name: "lease set client key wrong length",
+			modify: func(c *SessionConfig) {
+				c.LeaseSetType = LeaseSetTypeEncrypted
+				c.LeaseSetAuthType = LeaseSetAuthPSK
+				c.LeaseSetClients = []LeaseSetClient{{Name: "bob", Key: make([]byte, 16)}}
+			},
+			wantErr: ErrInvalidLeaseSet,
+		},
+		{
+			name: "receive buffer too large",
+			modify: func(c *SessionConfig) {
+				c.ReceiveBufferSize = MaxReceiveBufferSize + 1
+			},
+			wantErr: ErrInvalidReceiveBuffer,
+		},
+		{
+			name: "unknown drop policy",
+			modify: func(c *SessionConfig) {
+				c.DropPolicy = DropPolicy(7)
+			},
+			wantErr: ErrInvalidDropPolicy,
+		},
+		{
+			name: "negative stream timeout",
+			modify: func(c *SessionConfig) {
+				c.StreamWriteTimeout = -time.Second
+			},
+			wantErr: ErrInvalidStreamTimeout,
+		},
+		{
+			name: "negative stream limit",
+			modify: func(c *SessionConfig) {
+				c.MaxInboundStreams = -1
+			},
+			wantErr: ErrInvalidStreamLimit,
+		},
+		{
+			name: "send queue too large",
+			modify: func(c *SessionConfig) {
+				c.SendQueueSize = MaxSendQueueSize + 1
+			},
+			wantErr: ErrInvalidSendQueue,
+		},
 	}
 
 	for _, tt := range tests {
@@ -185,13 +314,57 @@ func TestSessionConfig_Validate(t *testing.T) {
 			cfg := DefaultSessionConfig()
 			tt.modify(cfg)
 			err := cfg.Validate()
-			if err != tt.wantErr {
+			if !errors.Is(err, tt.wantErr) {
 				t.Errorf("Validate() = %v, want %v", err, tt.wantErr)
 			}
 		})
 	}
 }
 
+func TestSessionConfig_ValidateMessage(t *testing.T) {
+	cfg := DefaultSessionConfig()
+	cfg.InboundLength = 9
+	err := cfg.Validate()
+	if err == nil || !strings.Contains(err.Error(), "inbound.length=9") || !strings.Contains(err.Error(), "0-7") {
+		t.Errorf("Validate() = %v, want error naming inbound.length and its bounds", err)
+	}
+}
+
+func TestSessionConfig_ValidateCreate(t *testing.T) {
+	tests := []struct {
+		name    string
+		id      string
+		style   Style
+		modify  func(*SessionConfig)
+		wantErr error
+	}{
+		{"valid stream", "s1", StyleStream, nil, nil},
+		{"valid raw with header", "r1", StyleRaw, func(c *SessionConfig) { c.HeaderEnabled = true }, nil},
+		{"missing ID", "", StyleStream, nil, ErrInvalidID},
+		{"ID with space", "a b", StyleStream, nil, ErrInvalidID},
+		{"unknown style", "s1", Style("BOGUS"), nil, ErrInvalidStyle},
+		{"invalid config", "s1", StyleStream, func(c *SessionConfig) { c.ToPort = -1 }, ErrInvalidPort},
+		{"header on stream", "s1", StyleStream, func(c *SessionConfig) { c.HeaderEnabled = true }, ErrIncompatibleOptions},
+		{"listen protocol on datagram", "s1", StyleDatagram, func(c *SessionConfig) { c.ListenProtocol = 18 }, ErrIncompatibleOptions},
+		{"ports on primary", "p1", StylePrimary, func(c *SessionConfig) { c.FromPort = 80 }, ErrIncompatibleOptions},
+		{"offline signature on datagram", "d1", StyleDatagram, func(c *SessionConfig) { c.OfflineSignature = &OfflineSignature{} }, ErrIncompatibleOptions},
+		{"offline signature on datagram2", "d2", StyleDatagram2, func(c *SessionConfig) { c.OfflineSignature = &OfflineSignature{} }, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := DefaultSessionConfig()
+			if tt.modify != nil {
+				tt.modify(cfg)
+			}
+			err := cfg.ValidateCreate(tt.id, tt.style)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("ValidateCreate() = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
 func TestSessionConfig_Chaining(t *testing.T) {
 	cfg := DefaultSessionConfig().
 		WithFromPort(1234).
@@ -374,6 +547,19 @@ func TestSessionConfig_Clone(t *testing.T) {
 			t.Error("Clone I2CPOptions should be isolated from original")
 		}
 	})
+
+	t.Run("lease set clients clone", func(t *testing.T) {
+		orig := DefaultSessionConfig()
+		orig.LeaseSetClients = []LeaseSetClient{{Name: "alice", Key: []byte("key")}}
+
+		clone := orig.Clone()
+		orig.LeaseSetClients[0].Name = "mallory"
+		orig.LeaseSetClients[0].Key[0] = 'X'
+
+		if clone.LeaseSetClients[0].Name != "alice" || string(clone.LeaseSetClients[0].Key) != "key" {
+			t.Errorf("Clone LeaseSetClients = %+v, should be isolated from original", clone.LeaseSetClients)
+		}
+	})
 }
 
 func TestConnectOptions(t *testing.T) {
@@ -651,3 +837,44 @@ func TestOfflineSignature_Bytes(t *testing.T) {
 		})
 	}
 }
+
+func TestLabel(t *testing.T) {
+	cfg := DefaultSessionConfig()
+	cfg.Label = "my app"
+	sess := NewBaseSession("labelled", StyleStream, nil, nil, cfg)
+	if got := Label(sess); got != "my app" {
+		t.Errorf("Label() = %q, want %q", got, "my app")
+	}
+
+	if got := Label(NewBaseSession("plain", StyleStream, nil, nil, nil)); got != "" {
+		t.Errorf("Label() without config = %q, want empty", got)
+	}
+}
+
+func TestUser(t *testing.T) {
+	cfg := DefaultSessionConfig()
+	cfg.User = "alice"
+	primary := NewPrimarySession("owned", nil, nil, cfg)
+	if got := User(primary); got != "alice" {
+		t.Errorf("User() = %q, want %q", got, "alice")
+	}
+	if got := primary.createSubsessionConfig(SubsessionOptions{}).User; got != "alice" {
+		t.Errorf("subsession User = %q, want %q", got, "alice")
+	}
+
+	if got := User(NewBaseSession("anonymous", StyleStream, nil, nil, nil)); got != "" {
+		t.Errorf("User() without config = %q, want empty", got)
+	}
+}
+
+func TestParseDropPolicy(t *testing.T) {
+	for _, p := range []DropPolicy{DropNewest, DropOldest} {
+		got, err := ParseDropPolicy(p.String())
+		if err != nil || got != p {
+			t.Errorf("ParseDropPolicy(%q) = %v, %v, want %v", p.String(), got, err, p)
+		}
+	}
+	if _, err := ParseDropPolicy("random"); !errors.Is(err, ErrInvalidDropPolicy) {
+		t.Errorf("ParseDropPolicy(random) error = %v, want %v", err, ErrInvalidDropPolicy)
+	}
+}
@@ -11,6 +11,8 @@ import (
 	"sync"
 
 	"github.com/go-i2p/go-datagrams"
+
+	"github.com/go-i2p/go-sam-bridge/lib/protocol"
 )
 
 // DatagramSessionImpl implements the DatagramSession interface.
@@ -78,7 +80,7 @@ func NewDatagramSession(
 
 	return &DatagramSessionImpl{
 		BaseSession: NewBaseSession(id, StyleDatagram, dest, conn, cfg),
-		receiveChan: make(chan ReceivedDatagram, 100),
+		receiveChan: make(chan ReceivedDatagram, receiveBufferSize(cfg)),
 		ctx:         ctx,
 		cancel:      cancel,
 	}
@@ -96,7 +98,8 @@ func NewDatagramSession(
 //   - Session is not active
 //   - Data is empty or too large
 //   - Destination lookup fails
-//   - Send operation fails
+//   - Send operation fails, or the send queue is full if
+//     SessionConfig.SendQueueSize enables it
 //
 // Per SAM specification, DATAGRAM SEND on bridge socket is supported.
 // As of SAM 3.2, FROM_PORT and TO_PORT options are supported.
@@ -138,19 +141,20 @@ func (d *DatagramSessionImpl) Send(dest string, data []byte, opts DatagramSendOp
 
 	// Send the datagram using go-datagrams
 	// DATAGRAM uses ProtocolDatagram1 (17) for repliable authenticated datagrams
-	err := datagramConn.SendTo(data, dest, toPort)
-	if err != nil {
-		return fmt.Errorf("failed to send datagram: %w", err)
-	}
-
-	return nil
+	return d.sendDatagram(dest, data, func(data []byte) error {
+		if err := datagramConn.SendTo(data, dest, toPort); err != nil {
+			return fmt.Errorf("failed to send datagram: %w", err)
+		}
+		return nil
+	})
 }
 
 // Receive returns a channel for incoming repliable datagrams.
 // Each received datagram includes source destination, ports, and data.
 // Implements DatagramSession.Receive() per SAM 3.0 specification.
 //
-// The channel is buffered and will drop datagrams if not consumed.
+// The channel holds SessionConfig.ReceiveBufferSize datagrams; when it is
+// full, datagrams are dropped according to SessionConfig.DropPolicy.
 // The channel is closed when the session is closed.
 func (d *DatagramSessionImpl) Receive() <-chan ReceivedDatagram {
 	d.mu.RLock()
@@ -214,6 +218,8 @@ func (d *DatagramSessionImpl) IsForwarding() bool {
 // This method is called by the UDP listener when a datagram arrives
 // for this session.
 func (d *DatagramSessionImpl) deliverDatagram(dg ReceivedDatagram) {
+	d.Stats().AddDatagramReceived(len(dg.Data))
+
 	d.mu.RLock()
 	forwarding := d.forwardPort > 0
 	d.mu.RUnlock()
@@ -223,17 +229,19 @@ func (d *DatagramSessionImpl) deliverDatagram(dg ReceivedDatagram) {
 		return
 	}
 
-	// Deliver to receive channel (non-blocking, drop if full)
-	select {
-	case d.receiveChan <- dg:
-	default:
-		// Channel full, drop datagram
-		// This is expected behavior per SAM spec - datagrams are unreliable
+	// Deliver to receive channel without blocking. Datagrams are
+	// unreliable per the SAM spec, so one is dropped if it is full.
+	// The lock keeps Close from closing the channel mid-send.
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	if d.receiveChan != nil {
+		enqueue(d.receiveChan, dg, d.Config().DropPolicy, d.Stats())
 	}
 }
 
 // forwardDatagram sends a received datagram to the configured forwarding address.
-// Prepends the source destination as per SAM specification.
+// Prepends the source destination as per SAM specification, followed by
+// the ports for SAM 3.2 and later.
 func (d *DatagramSessionImpl) forwardDatagram(dg ReceivedDatagram) {
 	d.mu.RLock()
 	host := d.forwardHost
@@ -251,9 +259,12 @@ func (d *DatagramSessionImpl) forwardDatagram(dg ReceivedDatagram) {
 		return
 	}
 
-	// Prepend source destination line: $destination\n
-	// Per SAM specification, forwarded datagrams include source destination
+	// Prepend source destination line: $destination\n, or
+	// $destination FROM_PORT=nnn TO_PORT=nnn\n as of SAM 3.2
 	header := dg.Source + "\n"
+	if protocol.VersionSupportsPortInfo(d.Config().SAMVersion) {
+		header = dg.Source + " FROM_PORT=" + itoa(dg.FromPort) + " TO_PORT=" + itoa(dg.ToPort) + "\n"
+	}
 	payload := append([]byte(header), dg.Data...)
 
 	// Send to forwarding address (best effort, ignore errors per SAM spec)
@@ -55,10 +55,8 @@ type Datagram2SessionImpl struct {
 	// receiveWg waits for receive goroutines to complete
 	receiveWg sync.WaitGroup
 
-	// Replay protection: track seen nonces to prevent replay attacks
-	// Map of nonce -> expiration time for cleanup
-	seenNonces   map[uint64]time.Time
-	nonceExpiry  time.Duration
+	// replay remembers recent nonces per source to discard replays.
+	replay       *replayWindow
 	cleanupMu    sync.Mutex
 	cleanupTimer *time.Timer
 
@@ -100,11 +98,10 @@ func NewDatagram2Session(
 
 	d := &Datagram2SessionImpl{
 		BaseSession: NewBaseSession(id, StyleDatagram2, dest, conn, cfg),
-		receiveChan: make(chan ReceivedDatagram, 100),
+		receiveChan: make(chan ReceivedDatagram, receiveBufferSize(cfg)),
 		ctx:         ctx,
 		cancel:      cancel,
-		seenNonces:  make(map[uint64]time.Time),
-		nonceExpiry: DefaultDatagram2NonceExpiry,
+		replay:      newReplayWindow(DefaultDatagram2NonceExpiry),
 	}
 
 	// Start nonce cleanup goroutine
@@ -157,12 +154,12 @@ func (d *Datagram2SessionImpl) Send(dest string, data []byte, opts DatagramSendO
 
 	// Send the datagram using go-datagrams
 	// DATAGRAM2 uses ProtocolDatagram2 (19) for authenticated datagrams with replay prevention
-	err := datagramConn.SendTo(data, dest, toPort)
-	if err != nil {
-		return fmt.Errorf("failed to send datagram2: %w", err)
-	}
-
-	return nil
+	return d.sendDatagram(dest, data, func(data []byte) error {
+		if err := datagramConn.SendTo(data, dest, toPort); err != nil {
+			return fmt.Errorf("failed to send datagram2: %w", err)
+		}
+		return nil
+	})
 }
 
 // Receive returns a channel for incoming datagrams.
@@ -246,21 +243,17 @@ func (d *Datagram2SessionImpl) OfflineSignature() []byte {
 	return sig
 }
 
-// CheckReplay checks if a nonce has been seen before (replay attack).
-// Returns true if the nonce is a replay (should be rejected).
+// CheckReplay checks if source has already sent a datagram with nonce
+// (replay attack). Returns true if the datagram is a replay and should be
+// rejected; otherwise the nonce is remembered for
+// DefaultDatagram2NonceExpiry.
+//
+// Nonces are tracked per source, up to MaxDatagram2ReplayNonces each, so
+// different senders may use the same nonce.
 //
 // Per SAMv3.md, DATAGRAM2 provides replay protection not present in DATAGRAM.
-func (d *Datagram2SessionImpl) CheckReplay(nonce uint64) bool {
-	d.cleanupMu.Lock()
-	defer d.cleanupMu.Unlock()
-
-	if _, exists := d.seenNonces[nonce]; exists {
-		return true // Replay detected
-	}
-
-	// Record the nonce with expiration time
-	d.seenNonces[nonce] = time.Now().Add(d.nonceExpiry)
-	return false
+func (d *Datagram2SessionImpl) CheckReplay(source string, nonce uint64) bool {
+	return d.replay.check(source, nonce)
 }
 
 // DeliverDatagram handles an incoming datagram, checking for replay and
@@ -268,24 +261,29 @@ func (d *Datagram2SessionImpl) CheckReplay(nonce uint64) bool {
 //
 // Parameters:
 //   - dg: The received datagram
-//   - nonce: The nonce from the datagram (for replay protection)
+//   - nonce: The nonce from the datagram (for replay protection); lib/i2cp
+//     takes it from the envelope signature, which a resent datagram repeats
 //
-// Returns true if the datagram was delivered, false if it was a replay.
+// Returns true if the datagram was queued, false if it was a replay or the
+// channel was full and it was dropped under DropNewest. Replays are
+// discarded before delivery and counted in Stats().ReplaysDropped.
 func (d *Datagram2SessionImpl) DeliverDatagram(dg ReceivedDatagram, nonce uint64) bool {
-	// Check for replay
-	if d.CheckReplay(nonce) {
+	if d.CheckReplay(dg.Source, nonce) {
+		d.Stats().AddReplayDropped()
 		return false
 	}
 
-	// Non-blocking send to channel (drop if full)
-	select {
-	case d.receiveChan <- dg:
-		return true
-	default:
-		// Channel full, datagram dropped
-		// This is acceptable per SAM spec (datagrams are best-effort)
+	d.Stats().AddDatagramReceived(len(dg.Data))
+
+	// Non-blocking send to channel; datagrams are best-effort per the
+	// SAM spec, so one is dropped if it is full. The lock keeps Close
+	// from closing the channel mid-send.
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	if d.Status() == StatusClosed {
 		return false
 	}
+	return enqueue(d.receiveChan, dg, d.Config().DropPolicy, d.Stats())
 }
 
 // Close terminates the session and releases all resources.
@@ -346,7 +344,7 @@ func (d *Datagram2SessionImpl) DatagramConn() *datagrams.DatagramConn {
 
 // startNonceCleanup starts a background goroutine to clean up expired nonces.
 func (d *Datagram2SessionImpl) startNonceCleanup() {
-	d.cleanupTimer = time.AfterFunc(d.nonceExpiry/2, func() {
+	d.cleanupTimer = time.AfterFunc(d.replay.expiry/2, func() {
 		d.cleanupExpiredNonces()
 	})
 }
@@ -356,12 +354,7 @@ func (d *Datagram2SessionImpl) cleanupExpiredNonces() {
 	d.cleanupMu.Lock()
 	defer d.cleanupMu.Unlock()
 
-	now := time.Now()
-	for nonce, expiry := range d.seenNonces {
-		if now.After(expiry) {
-			delete(d.seenNonces, nonce)
-		}
-	}
+	d.replay.prune()
 
 	// Reschedule if session is still active
 	d.mu.RLock()
@@ -369,7 +362,7 @@ func (d *Datagram2SessionImpl) cleanupExpiredNonces() {
 	d.mu.RUnlock()
 
 	if status != StatusClosed {
-		d.cleanupTimer = time.AfterFunc(d.nonceExpiry/2, func() {
+		d.cleanupTimer = time.AfterFunc(d.replay.expiry/2, func() {
 			d.cleanupExpiredNonces()
 		})
 	}
@@ -226,20 +226,25 @@ func TestDatagram2Session_CheckReplay(t *testing.T) {
 
 	// First check for a nonce should return false (not a replay)
 	nonce := uint64(12345)
-	if sess.CheckReplay(nonce) {
+	if sess.CheckReplay("alice", nonce) {
 		t.Error("First CheckReplay should return false (not a replay)")
 	}
 
 	// Second check for same nonce should return true (is a replay)
-	if !sess.CheckReplay(nonce) {
+	if !sess.CheckReplay("alice", nonce) {
 		t.Error("Second CheckReplay should return true (is a replay)")
 	}
 
 	// Different nonce should return false
 	nonce2 := uint64(67890)
-	if sess.CheckReplay(nonce2) {
+	if sess.CheckReplay("alice", nonce2) {
 		t.Error("Different nonce should return false (not a replay)")
 	}
+
+	// The same nonce from another source is not a replay
+	if sess.CheckReplay("bob", nonce) {
+		t.Error("Same nonce from a different source should return false (not a replay)")
+	}
 }
 
 func TestDatagram2Session_DeliverDatagram(t *testing.T) {
@@ -276,6 +281,9 @@ func TestDatagram2Session_DeliverDatagram(t *testing.T) {
 	if sess.DeliverDatagram(dg, nonce) {
 		t.Error("Replay DeliverDatagram should return false")
 	}
+	if got := sess.Stats().Snapshot().ReplaysDropped; got != 1 {
+		t.Errorf("ReplaysDropped = %d, want 1", got)
+	}
 
 	// Different nonce should succeed
 	nonce2 := uint64(22222)
@@ -306,17 +314,17 @@ func TestDatagram2Session_Close(t *testing.T) {
 func TestDatagram2Session_NonceCleanup(t *testing.T) {
 	sess := NewDatagram2Session("test-cleanup", nil, nil, nil)
 	// Use a short expiry for testing
-	sess.nonceExpiry = 50 * time.Millisecond
+	sess.replay.expiry = 50 * time.Millisecond
 	defer sess.Close()
 
 	// Add a nonce
 	nonce := uint64(99999)
-	if sess.CheckReplay(nonce) {
+	if sess.CheckReplay("peer", nonce) {
 		t.Error("First CheckReplay should return false")
 	}
 
 	// Immediately, it should be a replay
-	if !sess.CheckReplay(nonce) {
+	if !sess.CheckReplay("peer", nonce) {
 		t.Error("Immediate CheckReplay should return true (replay)")
 	}
 
@@ -327,7 +335,7 @@ func TestDatagram2Session_NonceCleanup(t *testing.T) {
 	sess.cleanupExpiredNonces()
 
 	// After cleanup, the nonce should be removed (not a replay anymore)
-	if sess.CheckReplay(nonce) {
+	if sess.CheckReplay("peer", nonce) {
 		t.Error("After expiry, CheckReplay should return false (nonce expired)")
 	}
 }
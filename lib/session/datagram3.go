@@ -117,7 +117,7 @@ func NewDatagram3Session(
 
 	return &Datagram3SessionImpl{
 		BaseSession: NewBaseSession(id, StyleDatagram3, dest, conn, cfg),
-		receiveChan: make(chan ReceivedDatagram, 100),
+		receiveChan: make(chan ReceivedDatagram, receiveBufferSize(cfg)),
 		ctx:         ctx,
 		cancel:      cancel,
 	}
@@ -167,12 +167,12 @@ func (d *Datagram3SessionImpl) Send(dest string, data []byte, opts DatagramSendO
 
 	// Send the datagram using go-datagrams
 	// DATAGRAM3 uses ProtocolDatagram3 (20) for repliable unauthenticated datagrams
-	err := datagramConn.SendTo(data, dest, toPort)
-	if err != nil {
-		return fmt.Errorf("failed to send datagram3: %w", err)
-	}
-
-	return nil
+	return d.sendDatagram(dest, data, func(data []byte) error {
+		if err := datagramConn.SendTo(data, dest, toPort); err != nil {
+			return fmt.Errorf("failed to send datagram3: %w", err)
+		}
+		return nil
+	})
 }
 
 // Receive returns a channel for incoming datagrams.
@@ -265,17 +265,20 @@ func (d *Datagram3SessionImpl) OfflineSignature() []byte {
 // Parameters:
 //   - dg: The received datagram (Source is 44-byte base64 hash)
 //
-// Returns true if the datagram was delivered, false if channel was full.
+// Returns true if the datagram was queued, false if the channel was full
+// and it was dropped under DropNewest.
 func (d *Datagram3SessionImpl) DeliverDatagram(dg ReceivedDatagram) bool {
-	// Non-blocking send to channel (drop if full)
-	select {
-	case d.receiveChan <- dg:
-		return true
-	default:
-		// Channel full, datagram dropped
-		// This is acceptable per SAM spec (datagrams are best-effort)
+	d.Stats().AddDatagramReceived(len(dg.Data))
+
+	// Non-blocking send to channel; datagrams are best-effort per the
+	// SAM spec, so one is dropped if it is full. The lock keeps Close
+	// from closing the channel mid-send.
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	if d.Status() == StatusClosed {
 		return false
 	}
+	return enqueue(d.receiveChan, dg, d.Config().DropPolicy, d.Stats())
 }
 
 // Close terminates the session and releases all resources.
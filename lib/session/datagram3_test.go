@@ -337,6 +337,32 @@ func TestDatagram3Session_DeliverDropsWhenChannelFull(t *testing.T) {
 	if result {
 		t.Error("DeliverDatagram should return false when channel is full")
 	}
+	if got := sess.Stats().Snapshot().DatagramsDropped; got != 1 {
+		t.Errorf("DatagramsDropped = %d, want 1", got)
+	}
+}
+
+func TestDatagram3Session_DeliverDropOldest(t *testing.T) {
+	cfg := DefaultSessionConfig()
+	cfg.ReceiveBufferSize = 2
+	cfg.DropPolicy = DropOldest
+	sess := NewDatagram3Session("test-drop-oldest", nil, nil, cfg)
+	defer sess.Close()
+
+	for _, src := range []string{"a", "b", "c"} {
+		if !sess.DeliverDatagram(ReceivedDatagram{Source: src, Data: []byte(src)}) {
+			t.Errorf("DeliverDatagram(%s) = false, want true", src)
+		}
+	}
+
+	for _, want := range []string{"b", "c"} {
+		if got := (<-sess.Receive()).Source; got != want {
+			t.Errorf("received %q, want %q", got, want)
+		}
+	}
+	if got := sess.Stats().Snapshot().DatagramsDropped; got != 1 {
+		t.Errorf("DatagramsDropped = %d, want 1", got)
+	}
 }
 
 // Tests for HashToB32Address function
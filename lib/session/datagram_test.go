@@ -513,6 +513,41 @@ func TestDatagramSessionImpl_Close(t *testing.T) {
 	})
 }
 
+func TestDatagramSessionImpl_ForwardDatagram(t *testing.T) {
+	tests := []struct {
+		version string
+		want    string
+	}{
+		{"3.1", "sender.i2p\npayload"},
+		{"3.3", "sender.i2p FROM_PORT=1234 TO_PORT=5678\npayload"},
+	}
+
+	for _, tt := range tests {
+		t.Run("SAM "+tt.version, func(t *testing.T) {
+			session := NewDatagramSession("test-forward-"+tt.version, nil, nil, &SessionConfig{SAMVersion: tt.version})
+			session.SetForwarding("127.0.0.1", 9000)
+
+			mockConn := &mockPacketConn{}
+			session.SetUDPConn(mockConn)
+
+			session.forwardDatagram(ReceivedDatagram{
+				Source:   "sender.i2p",
+				FromPort: 1234,
+				ToPort:   5678,
+				Data:     []byte("payload"),
+			})
+
+			mockConn.mu.Lock()
+			data := mockConn.writtenData
+			mockConn.mu.Unlock()
+
+			if string(data) != tt.want {
+				t.Errorf("forwarded %q, want %q", data, tt.want)
+			}
+		})
+	}
+}
+
 func TestDatagramSessionImpl_InterfaceCompliance(t *testing.T) {
 	t.Run("implements DatagramSession interface", func(t *testing.T) {
 		var _ DatagramSession = (*DatagramSessionImpl)(nil)
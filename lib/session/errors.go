@@ -17,6 +17,52 @@ var (
 	// ErrInvalidTunnelConfig indicates tunnel configuration is invalid.
 	ErrInvalidTunnelConfig = errors.New("invalid tunnel configuration")
 
+	// ErrInvalidID indicates a session ID is empty or not a single token.
+	ErrInvalidID = errors.New("invalid session ID: must be a non-empty token without whitespace")
+
+	// ErrInvalidStyle indicates an unrecognized session style.
+	ErrInvalidStyle = errors.New("invalid session style")
+
+	// ErrIncompatibleOptions indicates options that may not be combined,
+	// or that are not allowed with the session style.
+	ErrIncompatibleOptions = errors.New("incompatible session options")
+
+	// ErrInvalidLeaseSet indicates an unknown lease set type or
+	// authentication scheme, or a malformed per-client key.
+	ErrInvalidLeaseSet = errors.New("invalid lease set configuration")
+
+	// ErrInvalidLabel indicates a session label is too long or contains
+	// non-printable characters.
+	ErrInvalidLabel = errors.New("invalid label: must be at most 64 printable characters")
+
+	// ErrInvalidReceiveBuffer indicates a receive queue depth out of range.
+	ErrInvalidReceiveBuffer = errors.New("invalid receive buffer: must be 0-65536")
+
+	// ErrInvalidDropPolicy indicates an unknown receive queue drop policy.
+	ErrInvalidDropPolicy = errors.New("invalid drop policy: must be newest or oldest")
+
+	// ErrInvalidStreamTimeout indicates a negative stream inactivity timeout.
+	ErrInvalidStreamTimeout = errors.New("invalid stream timeout: may not be negative")
+
+	// ErrInvalidStreamLimit indicates a negative per-session stream limit.
+	ErrInvalidStreamLimit = errors.New("invalid stream limit: may not be negative")
+
+	// ErrInvalidSendQueue indicates a send queue depth out of range or a
+	// negative send retry count or expiry.
+	ErrInvalidSendQueue = errors.New("invalid send queue: depth must be 0-4096, retries and expiry may not be negative")
+
+	// ErrSendQueueFull indicates a datagram was not sent because the
+	// session's send queue was full.
+	ErrSendQueueFull = errors.New("send queue full")
+
+	// ErrSendExpired indicates a queued datagram was dropped because it
+	// could not be sent before SessionConfig.SendExpiry.
+	ErrSendExpired = errors.New("send expired")
+
+	// ErrStreamLimit indicates a session already has as many concurrent
+	// streams in one direction as its configuration allows.
+	ErrStreamLimit = errors.New("stream limit reached")
+
 	// ErrForwardActive indicates FORWARD is already active on the session.
 	ErrForwardActive = errors.New("forward already active")
 
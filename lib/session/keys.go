@@ -0,0 +1,52 @@
+package session
+
+import (
+	"github.com/go-i2p/common/base64"
+
+	"github.com/go-i2p/go-sam-bridge/lib/destination"
+)
+
+// Validate checks that the destination is well formed and that its
+// private keys belong to it, including any offline signature. Handlers
+// call it on client-supplied keys before creating I2CP sessions. See
+// destination.ValidateKeys for the checks made.
+func (d *Destination) Validate() error {
+	pub, err := d.publicBytes()
+	if err != nil {
+		return err
+	}
+	return destination.ValidateKeys(pub, d.PrivateKey)
+}
+
+// Sign signs data with the destination's signing private key. Only
+// Ed25519 destinations holding their signing key are supported;
+// destinations using offline signatures return destination.ErrOfflineKey.
+func (d *Destination) Sign(data []byte) ([]byte, error) {
+	pub, err := d.publicBytes()
+	if err != nil {
+		return nil, err
+	}
+	return destination.Sign(pub, d.PrivateKey, data)
+}
+
+// Verify reports whether sig is a valid signature of data by the
+// destination's signing key.
+func (d *Destination) Verify(data, sig []byte) bool {
+	pub, err := d.publicBytes()
+	if err != nil {
+		return false
+	}
+	return destination.Verify(pub, data, sig)
+}
+
+// publicBytes decodes the Base64 public destination.
+func (d *Destination) publicBytes() ([]byte, error) {
+	if d == nil || len(d.PublicKey) == 0 {
+		return nil, destination.ErrInvalidDestination
+	}
+	pub, err := base64.DecodeString(string(d.PublicKey))
+	if err != nil {
+		return nil, destination.ErrInvalidDestination
+	}
+	return pub, nil
+}
@@ -0,0 +1,45 @@
+package session
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/go-i2p/go-sam-bridge/lib/destination"
+)
+
+func TestDestination_SignVerifyValidate(t *testing.T) {
+	m := destination.NewManager()
+	dest, priv, err := m.Generate(destination.SigTypeEd25519)
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+	pub, err := m.EncodePublic(dest)
+	if err != nil {
+		t.Fatalf("EncodePublic() error = %v", err)
+	}
+	d := &Destination{PublicKey: []byte(pub), PrivateKey: priv, SignatureType: destination.SigTypeEd25519}
+
+	if err := d.Validate(); err != nil {
+		t.Errorf("Validate() error = %v", err)
+	}
+	sig, err := d.Sign([]byte("data"))
+	if err != nil {
+		t.Fatalf("Sign() error = %v", err)
+	}
+	if !d.Verify([]byte("data"), sig) {
+		t.Error("Verify() = false for its own signature")
+	}
+
+	d.Zero()
+	if err := d.Validate(); !errors.Is(err, destination.ErrInvalidPrivateKey) {
+		t.Errorf("Validate() after Zero() error = %v, want ErrInvalidPrivateKey", err)
+	}
+	if !d.Verify([]byte("data"), sig) {
+		t.Error("Verify() needs only the public key")
+	}
+
+	var empty *Destination
+	if err := empty.Validate(); !errors.Is(err, destination.ErrInvalidDestination) {
+		t.Errorf("nil Validate() error = %v, want ErrInvalidDestination", err)
+	}
+}
@@ -0,0 +1,81 @@
+package session
+
+import "fmt"
+
+// Lease set types accepted in the i2cp.leaseSetType option.
+const (
+	// LeaseSetTypeDefault lets the I2CP layer choose, normally LeaseSet2.
+	LeaseSetTypeDefault = 0
+
+	// LeaseSetTypeStandard is the original LeaseSet.
+	LeaseSetTypeStandard = 1
+
+	// LeaseSetType2 is a LeaseSet2.
+	LeaseSetType2 = 3
+
+	// LeaseSetTypeEncrypted is an encrypted LeaseSet2 published under a
+	// blinded key, reachable only through the destination's .b33 address.
+	LeaseSetTypeEncrypted = 5
+)
+
+// Per-client authentication schemes of an encrypted lease set, accepted
+// in the i2cp.leaseSetAuthType option.
+const (
+	// LeaseSetAuthNone lets anyone who knows the .b33 address (and the
+	// secret, if set) read the lease set.
+	LeaseSetAuthNone = 0
+
+	// LeaseSetAuthDH authorizes clients by their X25519 public keys.
+	LeaseSetAuthDH = 1
+
+	// LeaseSetAuthPSK authorizes clients by pre-shared keys.
+	LeaseSetAuthPSK = 2
+)
+
+// LeaseSetClientKeyLength is the length of a per-client key: an X25519
+// public key for DH, or the pre-shared key for PSK.
+const LeaseSetClientKeyLength = 32
+
+// LeaseSetClient is a client authorized to read an encrypted lease set,
+// given as i2cp.leaseSetClient.dh.N or i2cp.leaseSetClient.psk.N with a
+// value of name:base64key.
+type LeaseSetClient struct {
+	// Name identifies the client to the server operator only.
+	Name string
+
+	// Key is the client's X25519 public key (DH) or pre-shared key (PSK).
+	Key []byte
+}
+
+// validateLeaseSet checks the encrypted lease set options. Secrets and
+// per-client keys require i2cp.leaseSetType=5, and an authentication
+// scheme requires at least one client.
+func (c *SessionConfig) validateLeaseSet() error {
+	switch c.LeaseSetType {
+	case LeaseSetTypeDefault, LeaseSetTypeStandard, LeaseSetType2, LeaseSetTypeEncrypted:
+	default:
+		return fmt.Errorf("i2cp.leaseSetType=%d: %w: must be 1, 3 or 5", c.LeaseSetType, ErrInvalidLeaseSet)
+	}
+	switch c.LeaseSetAuthType {
+	case LeaseSetAuthNone, LeaseSetAuthDH, LeaseSetAuthPSK:
+	default:
+		return fmt.Errorf("i2cp.leaseSetAuthType=%d: %w: must be 0, 1 or 2", c.LeaseSetAuthType, ErrInvalidLeaseSet)
+	}
+
+	encrypted := c.LeaseSetType == LeaseSetTypeEncrypted
+	if !encrypted && (c.LeaseSetAuthType != LeaseSetAuthNone || c.LeaseSetSecret != "" || len(c.LeaseSetClients) > 0) {
+		return fmt.Errorf("lease set secrets and client keys require i2cp.leaseSetType=5: %w", ErrIncompatibleOptions)
+	}
+	if c.LeaseSetAuthType == LeaseSetAuthNone && len(c.LeaseSetClients) > 0 {
+		return fmt.Errorf("i2cp.leaseSetClient keys require i2cp.leaseSetAuthType 1 or 2: %w", ErrIncompatibleOptions)
+	}
+	if c.LeaseSetAuthType != LeaseSetAuthNone && len(c.LeaseSetClients) == 0 {
+		return fmt.Errorf("i2cp.leaseSetAuthType=%d: %w: no i2cp.leaseSetClient keys given", c.LeaseSetAuthType, ErrInvalidLeaseSet)
+	}
+	for _, client := range c.LeaseSetClients {
+		if len(client.Key) != LeaseSetClientKeyLength {
+			return fmt.Errorf("i2cp.leaseSetClient %q: %w: key must be %d bytes", client.Name, ErrInvalidLeaseSet, LeaseSetClientKeyLength)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,39 @@
+package session
+
+import "github.com/go-i2p/go-datagrams"
+
+// datagramConnProvider is implemented by sessions that send through a
+// go-datagrams connection.
+type datagramConnProvider interface {
+	DatagramConn() *datagrams.DatagramConn
+}
+
+// MaxPayload returns the largest datagram payload sess accepts for SEND,
+// so clients can size datagrams without guessing at fragmentation limits.
+// It is the style's SAM limit (MaxDatagramSize, MaxDatagram2Size,
+// MaxDatagram3Size or MaxRawDatagramSize), lowered to the limit of the
+// I2CP datagram format once the session has a DatagramConn. It returns 0
+// for styles that do not send datagrams, such as STREAM and PRIMARY.
+func MaxPayload(sess Session) int {
+	var max int
+	switch sess.Style() {
+	case StyleDatagram:
+		max = MaxDatagramSize
+	case StyleDatagram2:
+		max = MaxDatagram2Size
+	case StyleDatagram3:
+		max = MaxDatagram3Size
+	case StyleRaw:
+		max = MaxRawDatagramSize
+	default:
+		return 0
+	}
+	if dc, ok := sess.(datagramConnProvider); ok {
+		if conn := dc.DatagramConn(); conn != nil {
+			if n := conn.MaxPayloadSize(); n < max {
+				max = n
+			}
+		}
+	}
+	return max
+}
@@ -0,0 +1,26 @@
+package session
+
+import "testing"
+
+func TestMaxPayload(t *testing.T) {
+	tests := []struct {
+		name string
+		sess Session
+		want int
+	}{
+		{"stream", NewBaseSession("s", StyleStream, nil, nil, nil), 0},
+		{"datagram", NewDatagramSession("d", nil, nil, nil), MaxDatagramSize},
+		{"datagram2", NewDatagram2Session("d2", nil, nil, nil), MaxDatagram2Size},
+		{"datagram3", NewDatagram3Session("d3", nil, nil, nil), MaxDatagram3Size},
+		{"raw", NewRawSession("r", nil, nil, nil), MaxRawDatagramSize},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer tt.sess.Close()
+			if got := MaxPayload(tt.sess); got != tt.want {
+				t.Errorf("MaxPayload() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,81 @@
+package session
+
+// Observer is notified when sessions enter or leave a Registry.
+// Methods are called after the registry lock is released, on the
+// goroutine that made the change, so they may call back into the
+// registry. They should not block.
+type Observer interface {
+	// SessionRegistered is called after s is added to the registry.
+	SessionRegistered(s Session)
+
+	// SessionUnregistered is called after s is removed from the registry,
+	// either by Unregister or by Close.
+	SessionUnregistered(s Session)
+}
+
+// ObserverFuncs adapts plain functions to the Observer interface.
+// Nil fields are ignored.
+type ObserverFuncs struct {
+	// Registered is called for SessionRegistered.
+	Registered func(Session)
+
+	// Unregistered is called for SessionUnregistered.
+	Unregistered func(Session)
+}
+
+// SessionRegistered implements Observer.
+func (f ObserverFuncs) SessionRegistered(s Session) {
+	if f.Registered != nil {
+		f.Registered(s)
+	}
+}
+
+// SessionUnregistered implements Observer.
+func (f ObserverFuncs) SessionUnregistered(s Session) {
+	if f.Unregistered != nil {
+		f.Unregistered(s)
+	}
+}
+
+// Observable is implemented by registries that accept observers.
+// RegistryImpl implements it; components holding a plain Registry should
+// check for it with a type assertion.
+type Observable interface {
+	// AddObserver registers o for future register and unregister events
+	// and returns a function that removes it again.
+	AddObserver(o Observer) (remove func())
+}
+
+// observerEntry wraps an Observer so it can be removed by identity even
+// when the Observer value itself is not comparable (e.g. ObserverFuncs).
+type observerEntry struct {
+	Observer
+}
+
+// AddObserver registers o for future register and unregister events and
+// returns a function that removes it. The remove function is idempotent.
+func (r *RegistryImpl) AddObserver(o Observer) (remove func()) {
+	entry := &observerEntry{Observer: o}
+
+	r.mu.Lock()
+	r.observers = append(r.observers, entry)
+	r.mu.Unlock()
+
+	return func() {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		for i, e := range r.observers {
+			if e == entry {
+				r.observers = append(r.observers[:i:i], r.observers[i+1:]...)
+				return
+			}
+		}
+	}
+}
+
+// observerSnapshot returns the current observers. Callers must hold r.mu.
+// The result stays valid after the lock is released: AddObserver only
+// appends past its length and removal copies into a new backing array.
+func (r *RegistryImpl) observerSnapshot() []*observerEntry {
+	return r.observers
+}
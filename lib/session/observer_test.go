@@ -0,0 +1,83 @@
+package session
+
+import (
+	"reflect"
+	"testing"
+)
+
+// recordingObserver records register and unregister events as
+// "+id" and "-id" strings.
+type recordingObserver struct {
+	events []string
+}
+
+func (o *recordingObserver) SessionRegistered(s Session)   { o.events = append(o.events, "+"+s.ID()) }
+func (o *recordingObserver) SessionUnregistered(s Session) { o.events = append(o.events, "-"+s.ID()) }
+
+func TestRegistry_AddObserver(t *testing.T) {
+	r := NewRegistry()
+	obs := &recordingObserver{}
+	r.AddObserver(obs)
+
+	_ = r.Register(newTestSession("a", &Destination{PublicKey: []byte("dest-a")}))
+	_ = r.Register(newTestSession("b", &Destination{PublicKey: []byte("dest-a")})) // duplicate dest
+	_ = r.Register(newTestSession("c", nil))
+	_ = r.Unregister("a")
+	_ = r.Unregister("missing")
+
+	want := []string{"+a", "+c", "-a"}
+	if !reflect.DeepEqual(obs.events, want) {
+		t.Errorf("events = %v, want %v", obs.events, want)
+	}
+}
+
+func TestRegistry_AddObserverRemove(t *testing.T) {
+	r := NewRegistry()
+	obs := &recordingObserver{}
+	remove := r.AddObserver(obs)
+
+	_ = r.Register(newTestSession("a", nil))
+	remove()
+	remove()
+	_ = r.Register(newTestSession("b", nil))
+
+	if want := []string{"+a"}; !reflect.DeepEqual(obs.events, want) {
+		t.Errorf("events = %v, want %v", obs.events, want)
+	}
+}
+
+func TestRegistry_ObserverClose(t *testing.T) {
+	r := NewRegistry()
+	obs := &recordingObserver{}
+	_ = r.Register(newTestSession("a", nil))
+	r.AddObserver(obs)
+
+	_ = r.Close()
+
+	if want := []string{"-a"}; !reflect.DeepEqual(obs.events, want) {
+		t.Errorf("events = %v, want %v", obs.events, want)
+	}
+}
+
+func TestRegistry_ObserverMayCallRegistry(t *testing.T) {
+	r := NewRegistry()
+	var count int
+	r.AddObserver(ObserverFuncs{
+		Registered: func(s Session) { count = r.Count() },
+	})
+
+	_ = r.Register(newTestSession("a", nil))
+	if count != 1 {
+		t.Errorf("Count() from observer = %d, want 1", count)
+	}
+}
+
+func TestObserverFuncs_NilFields(t *testing.T) {
+	var f ObserverFuncs
+	s := newTestSession("a", nil)
+	f.SessionRegistered(s)
+	f.SessionUnregistered(s)
+}
+
+// Verify RegistryImpl accepts observers
+var _ Observable = (*RegistryImpl)(nil)
@@ -189,6 +189,11 @@ func (p *PrimarySessionImpl) createSubsession(id string, style Style, opts Subse
 		return nil, ErrInvalidSubsessionStyle
 	}
 
+	// The primary session owns the destination and zeroes it on close
+	if shared, ok := sess.(interface{ shareDestination() }); ok {
+		shared.shareDestination()
+	}
+
 	// Configure forwarding for DATAGRAM/RAW if specified
 	if opts.Port > 0 {
 		if fwd, ok := sess.(forwardable); ok {
@@ -281,7 +286,9 @@ func (p *PrimarySessionImpl) Subsessions() []string {
 }
 
 // RouteIncoming returns the subsession ID for incoming data based on port/protocol.
-// Used for routing incoming traffic to the correct subsession.
+// It matches on the LISTEN_PORT/LISTEN_PROTOCOL keys alone; Route walks
+// the same table but also skips subsessions whose style does not receive
+// the protocol.
 //
 // Routing rules per SAMv3.md:
 //  1. Exact match on (port, protocol) if exists
@@ -295,69 +302,32 @@ func (p *PrimarySessionImpl) RouteIncoming(port, protocol int) string {
 	p.mu.RLock()
 	defer p.mu.RUnlock()
 
-	// Try exact match first
-	if id := p.tryExactMatch(port, protocol); id != "" {
-		return id
-	}
-
-	// Try wildcard matches
-	if id := p.tryWildcardMatches(port, protocol); id != "" {
-		return id
-	}
-
-	// Use default subsession if available
-	return p.tryDefaultSubsession(protocol)
+	return p.lookupLocked(port, protocol, func(sess Session) bool {
+		return protocol != 6 || sess.Style() != StyleRaw
+	})
 }
 
-// tryExactMatch attempts exact port/protocol routing match.
-func (p *PrimarySessionImpl) tryExactMatch(port, protocol int) string {
-	key := p.makeRoutingKey(port, protocol)
-	if id, exists := p.routingTable[key]; exists {
-		return id
-	}
-	return ""
-}
-
-// tryWildcardMatches attempts wildcard routing matches.
-func (p *PrimarySessionImpl) tryWildcardMatches(port, protocol int) string {
-	// Try port wildcard (any protocol)
-	key := p.makeRoutingKey(port, 0)
-	if id, exists := p.routingTable[key]; exists {
-		if !p.isStreamingToRaw(protocol, id) {
+// lookupLocked walks the routing table in RouteIncoming order and returns
+// the first subsession that accept allows, or the empty string. p.mu
+// must be held.
+func (p *PrimarySessionImpl) lookupLocked(port, protocol int, accept func(Session) bool) string {
+	candidates := [...]string{
+		p.routingTable[p.makeRoutingKey(port, protocol)],
+		p.routingTable[p.makeRoutingKey(port, 0)],
+		p.routingTable[p.makeRoutingKey(0, protocol)],
+		p.defaultSubsession,
+	}
+	for _, id := range candidates {
+		if id == "" {
+			continue
+		}
+		if sess := p.subsessions[id]; sess != nil && accept(sess) {
 			return id
 		}
 	}
-
-	// Try protocol wildcard (any port)
-	key = p.makeRoutingKey(0, protocol)
-	if id, exists := p.routingTable[key]; exists {
-		return id
-	}
 	return ""
 }
 
-// tryDefaultSubsession attempts to route to default subsession.
-func (p *PrimarySessionImpl) tryDefaultSubsession(protocol int) string {
-	if p.defaultSubsession == "" {
-		return ""
-	}
-	if p.isStreamingToRaw(protocol, p.defaultSubsession) {
-		return ""
-	}
-	return p.defaultSubsession
-}
-
-// isStreamingToRaw checks if streaming protocol would route to RAW session.
-func (p *PrimarySessionImpl) isStreamingToRaw(protocol int, subsessionID string) bool {
-	if protocol != 6 {
-		return false
-	}
-	if sess := p.subsessions[subsessionID]; sess != nil {
-		return sess.Style() == StyleRaw
-	}
-	return false
-}
-
 // Close terminates the primary session and all subsessions.
 // Safe to call multiple times.
 func (p *PrimarySessionImpl) Close() error {
@@ -402,6 +372,8 @@ func (p *PrimarySessionImpl) createSubsessionConfig(opts SubsessionOptions) *Ses
 	cfg.Protocol = opts.Protocol
 	cfg.ListenPort = opts.ListenPort
 	cfg.HeaderEnabled = opts.HeaderEnabled
+	cfg.SAMVersion = SAMVersion(p)
+	cfg.User = User(p)
 	return cfg
 }
 
@@ -0,0 +1,137 @@
+package session
+
+import (
+	"github.com/go-i2p/go-datagrams"
+)
+
+// IncomingMessage is an I2CP payload received on a session's destination,
+// already decoded into its source and data.
+type IncomingMessage struct {
+	// Source is the sender's Base64 destination for repliable datagrams,
+	// the 44-byte source hash for DATAGRAM3, and empty for RAW.
+	Source string
+
+	// Protocol is the I2CP protocol number (17, 18, 19, 20, ...).
+	Protocol int
+
+	// FromPort and ToPort are the I2CP source and destination ports.
+	FromPort int
+	ToPort   int
+
+	// Nonce is the DATAGRAM2 replay-prevention nonce. Ignored otherwise.
+	Nonce uint64
+
+	// Data is the payload.
+	Data []byte
+}
+
+// MessageSource is implemented by I2CP session handles that report the
+// datagrams received on their destination. lib/i2cp.I2CPSession
+// implements it.
+type MessageSource interface {
+	// OnMessage sets fn to be called for each datagram received,
+	// replacing any earlier fn. Streaming traffic is not reported.
+	OnMessage(fn func(IncomingMessage))
+}
+
+// acceptsProtocol reports whether a session of the given style receives
+// inbound traffic of the I2CP protocol. Per SAMv3.md each style receives
+// a single protocol: STREAM 6, DATAGRAM 17, DATAGRAM2 19 and DATAGRAM3
+// 20. RAW receives anything but streaming; its LISTEN_PROTOCOL is matched
+// by the routing table key.
+func acceptsProtocol(style Style, protocol int) bool {
+	switch style {
+	case StyleStream:
+		return protocol == int(datagrams.ProtocolStreaming)
+	case StyleDatagram:
+		return protocol == int(datagrams.ProtocolDatagram1)
+	case StyleDatagram2:
+		return protocol == int(datagrams.ProtocolDatagram2)
+	case StyleDatagram3:
+		return protocol == int(datagrams.ProtocolDatagram3)
+	case StyleRaw:
+		return protocol != int(datagrams.ProtocolStreaming)
+	default:
+		return false
+	}
+}
+
+// Route returns the subsession that receives inbound traffic of the given
+// I2CP protocol addressed to toPort, or nil if none does.
+//
+// It follows the RouteIncoming rules but skips subsessions whose style
+// does not receive protocol, so a datagram never lands on a STREAM
+// subsession listening on the same port. The source port plays no part
+// in routing: SAMv3.md matches LISTEN_PORT against the destination port
+// only.
+func (p *PrimarySessionImpl) Route(protocol, toPort int) Session {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	id := p.lookupLocked(toPort, protocol, func(sess Session) bool {
+		return acceptsProtocol(sess.Style(), protocol)
+	})
+	if id == "" {
+		return nil
+	}
+	return p.subsessions[id]
+}
+
+// Dispatch delivers an inbound datagram to the subsession chosen by Route
+// and returns that subsession's ID. It returns the empty string if no
+// subsession matches or the subsession dropped the message (for example
+// a replayed DATAGRAM2 nonce). Streams are not datagrams; callers route
+// incoming streams with Route(6, toPort) instead.
+func (p *PrimarySessionImpl) Dispatch(msg IncomingMessage) string {
+	sub := p.Route(msg.Protocol, msg.ToPort)
+	if sub == nil || !deliver(sub, msg) {
+		return ""
+	}
+	return sub.ID()
+}
+
+// Deliver hands an inbound datagram to sess: a PRIMARY session dispatches
+// it to one of its subsessions, and DATAGRAM, DATAGRAM2, DATAGRAM3 and
+// RAW sessions queue or forward it if they receive its protocol. It
+// returns false if the message was dropped, including DATAGRAM2 replays.
+func Deliver(sess Session, msg IncomingMessage) bool {
+	if primary, ok := sess.(*PrimarySessionImpl); ok {
+		return primary.Dispatch(msg) != ""
+	}
+	if raw, ok := sess.(*RawSessionImpl); ok && msg.Protocol != raw.Protocol() {
+		return false
+	}
+	if !acceptsProtocol(sess.Style(), msg.Protocol) {
+		return false
+	}
+	return deliver(sess, msg)
+}
+
+// deliver passes msg to the receive path of sess.
+func deliver(sess Session, msg IncomingMessage) bool {
+	dg := ReceivedDatagram{
+		Source:   msg.Source,
+		FromPort: msg.FromPort,
+		ToPort:   msg.ToPort,
+		Data:     msg.Data,
+	}
+	switch s := sess.(type) {
+	case *DatagramSessionImpl:
+		s.deliverDatagram(dg)
+		return true
+	case *Datagram2SessionImpl:
+		return s.DeliverDatagram(dg, msg.Nonce)
+	case *Datagram3SessionImpl:
+		return s.DeliverDatagram(dg)
+	case *RawSessionImpl:
+		s.deliverDatagram(ReceivedRawDatagram{
+			FromPort: msg.FromPort,
+			ToPort:   msg.ToPort,
+			Protocol: msg.Protocol,
+			Data:     msg.Data,
+		})
+		return true
+	default:
+		return false
+	}
+}
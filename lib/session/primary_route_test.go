@@ -0,0 +1,145 @@
+package session
+
+import (
+	"testing"
+)
+
+func newRoutedPrimary(t *testing.T) *PrimarySessionImpl {
+	t.Helper()
+	primary := NewPrimarySession("test-primary", nil, nil, nil)
+	primary.SetStatus(StatusActive)
+	t.Cleanup(func() { primary.Close() })
+
+	subs := []struct {
+		id    string
+		style Style
+		opts  SubsessionOptions
+	}{
+		{"stream-80", StyleStream, SubsessionOptions{ListenPort: 80}},
+		{"dg-80", StyleDatagram, SubsessionOptions{ListenPort: 80, ListenProtocol: 17}},
+		{"dg-any", StyleDatagram, SubsessionOptions{ListenProtocol: 17}},
+		{"dg2-any", StyleDatagram2, SubsessionOptions{ListenProtocol: 19}},
+		{"raw-any", StyleRaw, SubsessionOptions{}},
+	}
+	for _, s := range subs {
+		if _, err := primary.AddSubsession(s.id, s.style, s.opts); err != nil {
+			t.Fatalf("AddSubsession(%s) error = %v", s.id, err)
+		}
+	}
+	return primary
+}
+
+func TestPrimarySession_Route(t *testing.T) {
+	primary := newRoutedPrimary(t)
+
+	tests := []struct {
+		name     string
+		protocol int
+		toPort   int
+		wantID   string
+	}{
+		{"stream exact port", 6, 80, "stream-80"},
+		{"stream unmatched port", 6, 81, ""},
+		{"datagram exact port beats wildcard", 17, 80, "dg-80"},
+		{"datagram wildcard port", 17, 443, "dg-any"},
+		{"datagram2 wildcard port", 19, 80, "dg2-any"},
+		{"raw default protocol", 18, 9, "raw-any"},
+		{"raw wildcard takes other protocols", 20, 80, "raw-any"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := primary.Route(tt.protocol, tt.toPort)
+			gotID := ""
+			if got != nil {
+				gotID = got.ID()
+			}
+			if gotID != tt.wantID {
+				t.Errorf("Route(%d, %d) = %q, want %q", tt.protocol, tt.toPort, gotID, tt.wantID)
+			}
+		})
+	}
+}
+
+func TestPrimarySession_RouteAfterRemove(t *testing.T) {
+	primary := newRoutedPrimary(t)
+
+	if err := primary.RemoveSubsession("dg-80"); err != nil {
+		t.Fatalf("RemoveSubsession() error = %v", err)
+	}
+	if got := primary.Route(17, 80); got == nil || got.ID() != "dg-any" {
+		t.Errorf("Route(17, 80) after remove = %v, want dg-any", got)
+	}
+}
+
+func TestPrimarySession_Dispatch(t *testing.T) {
+	primary := newRoutedPrimary(t)
+
+	id := primary.Dispatch(IncomingMessage{Source: "peer", Protocol: 17, FromPort: 5, ToPort: 80, Data: []byte("hi")})
+	if id != "dg-80" {
+		t.Fatalf("Dispatch(datagram) = %q, want dg-80", id)
+	}
+	dg := <-primary.Subsession("dg-80").(*DatagramSessionImpl).Receive()
+	if dg.Source != "peer" || dg.FromPort != 5 || dg.ToPort != 80 || string(dg.Data) != "hi" {
+		t.Errorf("received datagram = %+v", dg)
+	}
+
+	if id := primary.Dispatch(IncomingMessage{Protocol: 18, ToPort: 7, Data: []byte("raw")}); id != "raw-any" {
+		t.Fatalf("Dispatch(raw) = %q, want raw-any", id)
+	}
+	raw := <-primary.Subsession("raw-any").(*RawSessionImpl).Receive()
+	if raw.Protocol != 18 || raw.ToPort != 7 || string(raw.Data) != "raw" {
+		t.Errorf("received raw datagram = %+v", raw)
+	}
+}
+
+func TestPrimarySession_DispatchDropped(t *testing.T) {
+	primary := newRoutedPrimary(t)
+
+	if err := primary.RemoveSubsession("raw-any"); err != nil {
+		t.Fatalf("RemoveSubsession() error = %v", err)
+	}
+	if id := primary.Dispatch(IncomingMessage{Protocol: 20, ToPort: 80}); id != "" {
+		t.Errorf("Dispatch(unrouted) = %q, want empty", id)
+	}
+	// Streams are routed, but not delivered as datagrams.
+	if id := primary.Dispatch(IncomingMessage{Protocol: 6, ToPort: 80}); id != "" {
+		t.Errorf("Dispatch(stream) = %q, want empty", id)
+	}
+
+	msg := IncomingMessage{Source: "peer", Protocol: 19, ToPort: 80, Nonce: 42}
+	if id := primary.Dispatch(msg); id != "dg2-any" {
+		t.Fatalf("Dispatch(datagram2) = %q, want dg2-any", id)
+	}
+	if id := primary.Dispatch(msg); id != "" {
+		t.Errorf("Dispatch(replayed datagram2) = %q, want empty", id)
+	}
+}
+
+func TestDeliver(t *testing.T) {
+	dg := NewDatagramSession("dg", nil, nil, nil)
+	dg.SetStatus(StatusActive)
+	defer dg.Close()
+	raw := NewRawSession("raw", nil, nil, nil)
+	raw.SetStatus(StatusActive)
+	defer raw.Close()
+
+	if Deliver(dg, IncomingMessage{Source: "peer", Protocol: 19, Data: []byte("x")}) {
+		t.Error("Deliver(DATAGRAM, protocol 19) = true, want false")
+	}
+	if !Deliver(dg, IncomingMessage{Source: "peer", Protocol: 17, ToPort: 7, Data: []byte("hi")}) {
+		t.Fatal("Deliver(DATAGRAM, protocol 17) = false")
+	}
+	if got := <-dg.Receive(); got.Source != "peer" || got.ToPort != 7 || string(got.Data) != "hi" {
+		t.Errorf("received datagram = %+v", got)
+	}
+
+	if Deliver(raw, IncomingMessage{Protocol: 20}) {
+		t.Error("Deliver(RAW PROTOCOL=18, protocol 20) = true, want false")
+	}
+	if !Deliver(raw, IncomingMessage{Protocol: 18, Data: []byte("raw")}) {
+		t.Fatal("Deliver(RAW, protocol 18) = false")
+	}
+	if got := <-raw.Receive(); got.Protocol != 18 || string(got.Data) != "raw" {
+		t.Errorf("received raw datagram = %+v", got)
+	}
+}
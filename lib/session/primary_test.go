@@ -262,6 +262,27 @@ func TestPrimarySession_RemoveSubsession(t *testing.T) {
 	}
 }
 
+func TestPrimarySession_RemoveSubsession_KeepsKeys(t *testing.T) {
+	dest := &Destination{PublicKey: []byte("pub"), PrivateKey: []byte{1, 2, 3}}
+	primary := NewPrimarySession("test-primary", dest, nil, nil)
+	primary.SetStatus(StatusActive)
+
+	if _, err := primary.AddSubsession("sub1", StyleStream, SubsessionOptions{ListenPort: 1234}); err != nil {
+		t.Fatalf("AddSubsession() error = %v", err)
+	}
+	if err := primary.RemoveSubsession("sub1"); err != nil {
+		t.Fatalf("RemoveSubsession() error = %v", err)
+	}
+	if dest.PrivateKey == nil || dest.PrivateKey[0] != 1 {
+		t.Error("removing a subsession should not zero the shared private key")
+	}
+
+	primary.Close()
+	if dest.PrivateKey != nil {
+		t.Error("closing the primary session should zero the private key")
+	}
+}
+
 func TestPrimarySession_RemoveSubsession_NotFound(t *testing.T) {
 	primary := NewPrimarySession("test-primary", nil, nil, nil)
 	primary.SetStatus(StatusActive)
@@ -10,6 +10,8 @@ import (
 	"sync"
 
 	"github.com/go-i2p/go-datagrams"
+
+	"github.com/go-i2p/go-sam-bridge/lib/protocol"
 )
 
 // RawSessionImpl implements the RawSession interface.
@@ -94,7 +96,7 @@ func NewRawSession(
 		BaseSession:   NewBaseSession(id, StyleRaw, dest, conn, cfg),
 		protocol:      protocol,
 		headerEnabled: cfg.HeaderEnabled,
-		receiveChan:   make(chan ReceivedRawDatagram, 100),
+		receiveChan:   make(chan ReceivedRawDatagram, receiveBufferSize(cfg)),
 		ctx:           ctx,
 		cancel:        cancel,
 	}
@@ -130,7 +132,8 @@ func (r *RawSessionImpl) HeaderEnabled() bool {
 //   - Session is not active
 //   - Data is empty or too large
 //   - Destination lookup fails
-//   - Send operation fails
+//   - Send operation fails, or the send queue is full if
+//     SessionConfig.SendQueueSize enables it
 //
 // Per SAM specification, RAW SEND on bridge socket was added in SAM 3.1.
 // As of SAM 3.2, FROM_PORT, TO_PORT, and PROTOCOL options are supported.
@@ -178,14 +181,17 @@ func (r *RawSessionImpl) Send(dest string, data []byte, opts RawSendOptions) err
 
 	// Send via DatagramConn using SendTo
 	// The DatagramConn handles I2CP protocol framing and destination resolution
-	return datagramConn.SendTo(data, dest, uint16(toPort))
+	return r.sendDatagram(dest, data, func(data []byte) error {
+		return datagramConn.SendTo(data, dest, uint16(toPort))
+	})
 }
 
 // Receive returns a channel for incoming raw datagrams.
 // Each received datagram includes FromPort, ToPort, Protocol, and Data.
 // Implements RawSession.Receive() per SAM 3.1 specification.
 //
-// The channel is buffered and will drop datagrams if not consumed.
+// The channel holds SessionConfig.ReceiveBufferSize datagrams; when it is
+// full, datagrams are dropped according to SessionConfig.DropPolicy.
 // The channel is closed when the session is closed.
 func (r *RawSessionImpl) Receive() <-chan ReceivedRawDatagram {
 	r.mu.RLock()
@@ -248,6 +254,8 @@ func (r *RawSessionImpl) IsForwarding() bool {
 // This method is called by the UDP listener when a datagram arrives
 // for this session.
 func (r *RawSessionImpl) deliverDatagram(dg ReceivedRawDatagram) {
+	r.Stats().AddDatagramReceived(len(dg.Data))
+
 	r.mu.RLock()
 	forwarding := r.forwardPort > 0
 	headerEnabled := r.headerEnabled
@@ -258,17 +266,19 @@ func (r *RawSessionImpl) deliverDatagram(dg ReceivedRawDatagram) {
 		return
 	}
 
-	// Deliver to receive channel (non-blocking, drop if full)
-	select {
-	case r.receiveChan <- dg:
-	default:
-		// Channel full, drop datagram
-		// This is expected behavior per SAM spec - datagrams are unreliable
+	// Deliver to receive channel without blocking. Datagrams are
+	// unreliable per the SAM spec, so one is dropped if it is full.
+	// The lock keeps Close from closing the channel mid-send.
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if r.receiveChan != nil {
+		enqueue(r.receiveChan, dg, r.Config().DropPolicy, r.Stats())
 	}
 }
 
 // forwardDatagram sends a received datagram to the configured forwarding address.
-// If headerEnabled is true, prepends the header line.
+// If headerEnabled is true, prepends the header line, which SAM 3.2 added
+// and earlier versions never receive.
 func (r *RawSessionImpl) forwardDatagram(dg ReceivedRawDatagram, headerEnabled bool) {
 	r.mu.RLock()
 	host := r.forwardHost
@@ -287,7 +297,7 @@ func (r *RawSessionImpl) forwardDatagram(dg ReceivedRawDatagram, headerEnabled b
 	}
 
 	var payload []byte
-	if headerEnabled {
+	if headerEnabled && protocol.VersionSupportsPortInfo(r.Config().SAMVersion) {
 		// Prepend header line: FROM_PORT=nnn TO_PORT=nnn PROTOCOL=nnn\n
 		header := formatRawHeader(dg.FromPort, dg.ToPort, dg.Protocol)
 		payload = append([]byte(header), dg.Data...)
@@ -620,6 +620,27 @@ func TestRawSessionImpl_ForwardDatagram(t *testing.T) {
 			t.Errorf("expected %q, got %q", expected, string(data))
 		}
 	})
+
+	t.Run("omits header before SAM 3.2", func(t *testing.T) {
+		session := NewRawSession("test-forward-v31", nil, nil, &SessionConfig{
+			HeaderEnabled: true,
+			SAMVersion:    "3.1",
+		})
+		session.SetForwarding("127.0.0.1", 9000)
+
+		mockConn := &mockPacketConn{}
+		session.SetUDPConn(mockConn)
+
+		session.forwardDatagram(ReceivedRawDatagram{FromPort: 1234, Data: []byte("payload")}, true)
+
+		mockConn.mu.Lock()
+		data := mockConn.writtenData
+		mockConn.mu.Unlock()
+
+		if string(data) != "payload" {
+			t.Errorf("expected 'payload', got %q", string(data))
+		}
+	})
 }
 
 // Ensure RawSessionImpl implements RawSession interface.
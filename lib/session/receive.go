@@ -0,0 +1,81 @@
+package session
+
+import "fmt"
+
+// DefaultReceiveBufferSize is the number of received datagrams a session
+// queues for its client when SessionConfig.ReceiveBufferSize is 0.
+const DefaultReceiveBufferSize = 100
+
+// MaxReceiveBufferSize is the largest allowed ReceiveBufferSize.
+const MaxReceiveBufferSize = 65536
+
+// DropPolicy selects which datagram a session discards when a datagram
+// arrives and its receive queue is full.
+type DropPolicy int
+
+const (
+	// DropNewest discards the arriving datagram. It is the default.
+	DropNewest DropPolicy = iota
+	// DropOldest discards the longest-queued datagram to make room for
+	// the arriving one.
+	DropOldest
+)
+
+// String returns the policy as given in the sam.dropPolicy option:
+// "newest" or "oldest".
+func (p DropPolicy) String() string {
+	switch p {
+	case DropNewest:
+		return "newest"
+	case DropOldest:
+		return "oldest"
+	default:
+		return fmt.Sprintf("DropPolicy(%d)", int(p))
+	}
+}
+
+// ParseDropPolicy parses a sam.dropPolicy value, "newest" or "oldest".
+func ParseDropPolicy(s string) (DropPolicy, error) {
+	switch s {
+	case "newest":
+		return DropNewest, nil
+	case "oldest":
+		return DropOldest, nil
+	default:
+		return 0, fmt.Errorf("%q: %w", s, ErrInvalidDropPolicy)
+	}
+}
+
+// receiveBufferSize returns the receive queue depth configured in cfg.
+func receiveBufferSize(cfg *SessionConfig) int {
+	if cfg == nil || cfg.ReceiveBufferSize <= 0 {
+		return DefaultReceiveBufferSize
+	}
+	return cfg.ReceiveBufferSize
+}
+
+// enqueue adds v to ch without blocking. If ch is full, it discards v or
+// the oldest queued value according to policy and records the drop in
+// stats. It returns false if v was discarded.
+func enqueue[T any](ch chan T, v T, policy DropPolicy, stats *Stats) bool {
+	select {
+	case ch <- v:
+		return true
+	default:
+	}
+	stats.AddDatagramDropped()
+	if policy != DropOldest {
+		return false
+	}
+	for {
+		select {
+		case <-ch:
+		default:
+		}
+		select {
+		case ch <- v:
+			return true
+		default:
+		}
+	}
+}
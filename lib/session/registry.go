@@ -2,6 +2,7 @@
 package session
 
 import (
+	"hash/maphash"
 	"sync"
 
 	"github.com/go-i2p/go-sam-bridge/lib/util"
@@ -22,7 +23,8 @@ type Registry interface {
 	// Get returns a session by ID, or nil if not found.
 	Get(id string) Session
 
-	// GetByDestination returns a session by destination hash, or nil if not found.
+	// GetByDestination returns a session by destination hash (see
+	// Destination.Hash and DestinationHash), or nil if not found.
 	GetByDestination(destHash string) Session
 
 	// MostRecentByStyle returns the most recently created session of the given style.
@@ -41,26 +43,83 @@ type Registry interface {
 	Close() error
 }
 
+// registryShards is the number of shards a RegistryImpl spreads its
+// sessions over, so that sessions created and closed concurrently rarely
+// contend for the same lock.
+const registryShards = 32
+
+// registryShard holds the sessions whose ID, and the destination index
+// entries whose hash, map to it.
+type registryShard struct {
+	mu       sync.RWMutex
+	sessions map[string]Session // id -> Session
+	dests    map[string]string  // Destination.Hash() -> id
+}
+
 // RegistryImpl is the concrete implementation of Registry.
 // It enforces global uniqueness of session IDs and destinations.
+//
+// Sessions are sharded by ID and the destination index by destination
+// hash, so Get, GetByDestination, Register and Unregister lock only the
+// shards involved. Destination hashes are computed before any lock is
+// taken.
 type RegistryImpl struct {
-	mu       sync.RWMutex
-	sessions map[string]Session // id -> Session
-	dests    map[string]string  // destHash -> id (for uniqueness check)
+	seed   maphash.Seed
+	shards [registryShards]registryShard
+
+	// mu guards mostRecentByStyle and observers.
+	mu sync.Mutex
 
 	// Track most recently created sessions by style for V1/V2 DATAGRAM/RAW commands.
 	// Per SAMv3.md: "DATAGRAM SEND/RAW SEND sends to the most recently created
 	// DATAGRAM- or RAW-style session, as appropriate."
 	mostRecentByStyle map[Style]string // style -> session id
+
+	// observers are notified of register and unregister events.
+	observers []*observerEntry
 }
 
 // NewRegistry creates a new session registry.
 func NewRegistry() *RegistryImpl {
-	return &RegistryImpl{
-		sessions:          make(map[string]Session),
-		dests:             make(map[string]string),
+	r := &RegistryImpl{
+		seed:              maphash.MakeSeed(),
 		mostRecentByStyle: make(map[Style]string),
 	}
+	for i := range r.shards {
+		r.shards[i].sessions = make(map[string]Session)
+		r.shards[i].dests = make(map[string]string)
+	}
+	return r
+}
+
+// shardIndex returns the index of the shard a session ID or destination
+// hash maps to.
+func (r *RegistryImpl) shardIndex(key string) int {
+	return int(maphash.String(r.seed, key) % registryShards)
+}
+
+// shard returns the shard a session ID or destination hash maps to.
+func (r *RegistryImpl) shard(key string) *registryShard {
+	return &r.shards[r.shardIndex(key)]
+}
+
+// lockPair write-locks shards i and j, which may be the same, in index
+// order so that concurrent callers cannot deadlock. It returns the
+// function unlocking them.
+func (r *RegistryImpl) lockPair(i, j int) (unlock func()) {
+	if i == j {
+		r.shards[i].mu.Lock()
+		return r.shards[i].mu.Unlock
+	}
+	if j < i {
+		i, j = j, i
+	}
+	r.shards[i].mu.Lock()
+	r.shards[j].mu.Lock()
+	return func() {
+		r.shards[j].mu.Unlock()
+		r.shards[i].mu.Unlock()
+	}
 }
 
 // Register adds a session to the registry.
@@ -71,32 +130,30 @@ func (r *RegistryImpl) Register(s Session) error {
 		return util.ErrSessionNotFound
 	}
 
-	r.mu.Lock()
-	defer r.mu.Unlock()
+	observers, err := r.register(s)
+	if err != nil {
+		return err
+	}
+	for _, o := range observers {
+		o.SessionRegistered(s)
+	}
+	return nil
+}
 
+// register adds s to its shards and returns the observers to notify.
+func (r *RegistryImpl) register(s Session) ([]*observerEntry, error) {
 	id := s.ID()
 	if id == "" {
-		return util.ErrSessionNotFound
-	}
-
-	// Check ID uniqueness
-	if _, exists := r.sessions[id]; exists {
-		return util.ErrDuplicateID
+		return nil, util.ErrSessionNotFound
 	}
+	destHash := s.Destination().Hash()
 
-	// Check destination uniqueness (if destination is set)
-	dest := s.Destination()
-	if dest != nil {
-		destHash := dest.Hash()
-		if destHash != "" {
-			if _, exists := r.dests[destHash]; exists {
-				return util.ErrDuplicateDest
-			}
-			r.dests[destHash] = id
-		}
+	if err := r.insert(id, destHash, s); err != nil {
+		return nil, err
 	}
 
-	r.sessions[id] = s
+	r.mu.Lock()
+	defer r.mu.Unlock()
 
 	// Track most recently created session by style for V1/V2 DATAGRAM/RAW commands.
 	// Per SAMv3.md: "DATAGRAM SEND/RAW SEND sends to the most recently created
@@ -106,54 +163,106 @@ func (r *RegistryImpl) Register(s Session) error {
 		r.mostRecentByStyle[style] = id
 	}
 
+	return r.observerSnapshot(), nil
+}
+
+// insert adds s under id and, if destHash is not empty, indexes it by
+// destination, checking both are unused.
+func (r *RegistryImpl) insert(id, destHash string, s Session) error {
+	i := r.shardIndex(id)
+	idShard := &r.shards[i]
+	if destHash == "" {
+		idShard.mu.Lock()
+		defer idShard.mu.Unlock()
+		if _, exists := idShard.sessions[id]; exists {
+			return util.ErrDuplicateID
+		}
+		idShard.sessions[id] = s
+		return nil
+	}
+
+	j := r.shardIndex(destHash)
+	destShard := &r.shards[j]
+	defer r.lockPair(i, j)()
+
+	// Check ID uniqueness
+	if _, exists := idShard.sessions[id]; exists {
+		return util.ErrDuplicateID
+	}
+	// Check destination uniqueness
+	if _, exists := destShard.dests[destHash]; exists {
+		return util.ErrDuplicateDest
+	}
+	destShard.dests[destHash] = id
+	idShard.sessions[id] = s
 	return nil
 }
 
 // Unregister removes a session from the registry by ID.
 // Returns util.ErrSessionNotFound if the session does not exist.
 func (r *RegistryImpl) Unregister(id string) error {
-	r.mu.Lock()
-	defer r.mu.Unlock()
+	s, observers, err := r.unregister(id)
+	if err != nil {
+		return err
+	}
+	for _, o := range observers {
+		o.SessionUnregistered(s)
+	}
+	return nil
+}
 
-	s, exists := r.sessions[id]
+// unregister removes the session with the given ID from its shards and
+// returns it with the observers to notify.
+func (r *RegistryImpl) unregister(id string) (Session, []*observerEntry, error) {
+	idShard := r.shard(id)
+	idShard.mu.Lock()
+	s, exists := idShard.sessions[id]
+	delete(idShard.sessions, id)
+	idShard.mu.Unlock()
 	if !exists {
-		return util.ErrSessionNotFound
+		return nil, nil, util.ErrSessionNotFound
 	}
 
 	// Remove destination mapping
-	if dest := s.Destination(); dest != nil {
-		destHash := dest.Hash()
-		if destHash != "" {
-			delete(r.dests, destHash)
+	if destHash := s.Destination().Hash(); destHash != "" {
+		destShard := r.shard(destHash)
+		destShard.mu.Lock()
+		if destShard.dests[destHash] == id {
+			delete(destShard.dests, destHash)
 		}
+		destShard.mu.Unlock()
 	}
 
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
 	// Clean up most recent tracking if this was the most recent for its style
 	style := s.Style()
 	if r.mostRecentByStyle[style] == id {
 		delete(r.mostRecentByStyle, style)
 	}
-
-	delete(r.sessions, id)
-	return nil
+	return s, r.observerSnapshot(), nil
 }
 
 // Get returns a session by ID, or nil if not found.
 func (r *RegistryImpl) Get(id string) Session {
-	r.mu.RLock()
-	defer r.mu.RUnlock()
-	return r.sessions[id]
+	sh := r.shard(id)
+	sh.mu.RLock()
+	defer sh.mu.RUnlock()
+	return sh.sessions[id]
 }
 
 // GetByDestination returns a session by destination hash, or nil if not found.
 func (r *RegistryImpl) GetByDestination(destHash string) Session {
-	r.mu.RLock()
-	defer r.mu.RUnlock()
+	sh := r.shard(destHash)
+	sh.mu.RLock()
+	id, exists := sh.dests[destHash]
+	sh.mu.RUnlock()
 
-	if id, exists := r.dests[destHash]; exists {
-		return r.sessions[id]
+	if !exists {
+		return nil
 	}
-	return nil
+	return r.Get(id)
 }
 
 // MostRecentByStyle returns the most recently created session of the given style.
@@ -161,71 +270,92 @@ func (r *RegistryImpl) GetByDestination(destHash string) Session {
 // DATAGRAM- or RAW-style session, as appropriate."
 // Returns nil if no session of that style exists.
 func (r *RegistryImpl) MostRecentByStyle(style Style) Session {
-	r.mu.RLock()
-	defer r.mu.RUnlock()
+	r.mu.Lock()
+	id, exists := r.mostRecentByStyle[style]
+	r.mu.Unlock()
 
-	if id, exists := r.mostRecentByStyle[style]; exists {
-		return r.sessions[id]
+	if !exists {
+		return nil
 	}
-	return nil
+	return r.Get(id)
 }
 
 // All returns all registered session IDs.
 func (r *RegistryImpl) All() []string {
-	r.mu.RLock()
-	defer r.mu.RUnlock()
-
-	ids := make([]string, 0, len(r.sessions))
-	for id := range r.sessions {
-		ids = append(ids, id)
+	var ids []string
+	for i := range r.shards {
+		sh := &r.shards[i]
+		sh.mu.RLock()
+		for id := range sh.sessions {
+			ids = append(ids, id)
+		}
+		sh.mu.RUnlock()
+	}
+	if ids == nil {
+		ids = []string{}
 	}
 	return ids
 }
 
 // Count returns the number of active sessions.
 func (r *RegistryImpl) Count() int {
-	r.mu.RLock()
-	defer r.mu.RUnlock()
-	return len(r.sessions)
+	n := 0
+	for i := range r.shards {
+		sh := &r.shards[i]
+		sh.mu.RLock()
+		n += len(sh.sessions)
+		sh.mu.RUnlock()
+	}
+	return n
 }
 
-// Close terminates all sessions and clears the registry.
-// Sessions are collected first and the lock is released before closing them
-// to prevent deadlocks if session close callbacks attempt to unregister.
+// Close terminates all sessions, zeroes their destinations' private keys,
+// and clears the registry. Sessions embedding BaseSession zero their keys
+// on Close already; zeroing here also covers other implementations.
+// Sessions are collected first and the locks are released before closing
+// them to prevent deadlocks if session close callbacks attempt to unregister.
 // Errors from individual session closes are ignored.
 func (r *RegistryImpl) Close() error {
-	// Collect sessions while holding the lock
-	r.mu.Lock()
-	sessions := make([]Session, 0, len(r.sessions))
-	for _, s := range r.sessions {
-		sessions = append(sessions, s)
+	// Collect sessions and clear each shard while holding its lock
+	var sessions []Session
+	for i := range r.shards {
+		sh := &r.shards[i]
+		sh.mu.Lock()
+		for _, s := range sh.sessions {
+			sessions = append(sessions, s)
+		}
+		sh.sessions = make(map[string]Session)
+		sh.dests = make(map[string]string)
+		sh.mu.Unlock()
 	}
-	// Clear registry state while still holding the lock
-	r.sessions = make(map[string]Session)
-	r.dests = make(map[string]string)
+
+	r.mu.Lock()
 	r.mostRecentByStyle = make(map[Style]string)
+	observers := r.observerSnapshot()
 	r.mu.Unlock()
 
-	// Close sessions without holding the lock to prevent deadlocks
+	// Close sessions without holding the locks to prevent deadlocks
 	// from session close callbacks that may call Unregister
 	for _, s := range sessions {
 		_ = s.Close()
+		s.Destination().Zero()
+		for _, o := range observers {
+			o.SessionUnregistered(s)
+		}
 	}
 	return nil
 }
 
 // Has returns true if a session with the given ID exists.
 func (r *RegistryImpl) Has(id string) bool {
-	r.mu.RLock()
-	defer r.mu.RUnlock()
-	_, exists := r.sessions[id]
-	return exists
+	return r.Get(id) != nil
 }
 
 // HasDestination returns true if a session with the given destination hash exists.
 func (r *RegistryImpl) HasDestination(destHash string) bool {
-	r.mu.RLock()
-	defer r.mu.RUnlock()
-	_, exists := r.dests[destHash]
+	sh := r.shard(destHash)
+	sh.mu.RLock()
+	defer sh.mu.RUnlock()
+	_, exists := sh.dests[destHash]
 	return exists
 }
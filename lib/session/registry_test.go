@@ -1,7 +1,10 @@
 package session
 
 import (
+	"strconv"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"testing"
 
 	"github.com/go-i2p/go-sam-bridge/lib/util"
@@ -91,6 +94,20 @@ func TestRegistry_Register(t *testing.T) {
 		}
 	})
 
+	t.Run("register destinations sharing a prefix", func(t *testing.T) {
+		r := NewRegistry()
+		prefix := strings.Repeat("A", 64)
+		s1 := newTestSession("session1", &Destination{PublicKey: []byte(prefix + "AAAA")})
+		s2 := newTestSession("session2", &Destination{PublicKey: []byte(prefix + "BBBB")})
+
+		if err := r.Register(s1); err != nil {
+			t.Fatalf("Register(s1) = %v", err)
+		}
+		if err := r.Register(s2); err != nil {
+			t.Errorf("Register(s2) = %v, want nil for a distinct destination", err)
+		}
+	})
+
 	t.Run("register session without destination", func(t *testing.T) {
 		r := NewRegistry()
 		s := newTestSession("session1", nil)
@@ -200,6 +217,28 @@ func TestRegistry_GetByDestination(t *testing.T) {
 		}
 	})
 
+	t.Run("get by base64 destination", func(t *testing.T) {
+		r := NewRegistry()
+		dest := &Destination{PublicKey: []byte("dest1")}
+		s := newTestSession("session1", dest)
+		_ = r.Register(s)
+
+		if got := r.GetByDestination(DestinationHash("dest1")); got != s {
+			t.Error("GetByDestination(DestinationHash()) should return registered session")
+		}
+	})
+
+	t.Run("unregister removes index entry", func(t *testing.T) {
+		r := NewRegistry()
+		dest := &Destination{PublicKey: []byte("dest1")}
+		_ = r.Register(newTestSession("session1", dest))
+		_ = r.Unregister("session1")
+
+		if got := r.GetByDestination(dest.Hash()); got != nil {
+			t.Error("GetByDestination() after Unregister() should return nil")
+		}
+	})
+
 	t.Run("get by non-existent destination", func(t *testing.T) {
 		r := NewRegistry()
 
@@ -411,5 +450,75 @@ func TestRegistry_ConcurrentAccess(t *testing.T) {
 	wg.Wait()
 }
 
+func TestRegistry_ConcurrentDuplicateDestination(t *testing.T) {
+	r := NewRegistry()
+	dest := &Destination{PublicKey: []byte("shared")}
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	registered := 0
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			if r.Register(newTestSession("session"+strconv.Itoa(i), dest)) == nil {
+				mu.Lock()
+				registered++
+				mu.Unlock()
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	if registered != 1 {
+		t.Errorf("%d sessions registered with the same destination, want 1", registered)
+	}
+	if r.GetByDestination(dest.Hash()) == nil {
+		t.Error("GetByDestination() = nil after concurrent registration")
+	}
+}
+
+func BenchmarkRegistry_RegisterUnregister(b *testing.B) {
+	r := NewRegistry()
+	for i := 0; i < 1000; i++ {
+		id := "idle" + strconv.Itoa(i)
+		_ = r.Register(newTestSession(id, &Destination{PublicKey: []byte(id)}))
+	}
+
+	var next atomic.Int64
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			id := "churn" + strconv.FormatInt(next.Add(1), 10)
+			if err := r.Register(newTestSession(id, &Destination{PublicKey: []byte(id)})); err != nil {
+				b.Fatal(err)
+			}
+			if err := r.Unregister(id); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+func BenchmarkRegistry_Get(b *testing.B) {
+	r := NewRegistry()
+	ids := make([]string, 1000)
+	for i := range ids {
+		ids[i] = "session" + strconv.Itoa(i)
+		_ = r.Register(newTestSession(ids[i], &Destination{PublicKey: []byte(ids[i])}))
+	}
+
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		i := 0
+		for pb.Next() {
+			if r.Get(ids[i%len(ids)]) == nil {
+				b.Fatal("Get() = nil")
+			}
+			i++
+		}
+	})
+}
+
 // Verify Registry implements the Registry interface
 var _ Registry = (*RegistryImpl)(nil)
@@ -0,0 +1,122 @@
+package session
+
+import (
+	"sync"
+	"time"
+)
+
+// MaxDatagram2ReplayNonces is the number of recent nonces remembered per
+// source. Once a source exceeds it, its oldest nonce is forgotten.
+const MaxDatagram2ReplayNonces = 1024
+
+// MaxDatagram2ReplaySources is the number of sources whose nonces are
+// remembered at once. Once exceeded, the least recently seen source is
+// forgotten.
+const MaxDatagram2ReplaySources = 4096
+
+// replayWindow remembers the nonces of recently received DATAGRAM2
+// messages per source, so a message resent with a nonce its source has
+// already used is discarded. Each nonce is kept until its expiry and each
+// source keeps at most MaxDatagram2ReplayNonces, so the window is bounded
+// in both time and memory. Keying by source means two senders that happen
+// to pick the same nonce do not reject each other's messages.
+type replayWindow struct {
+	mu      sync.Mutex
+	expiry  time.Duration
+	sources map[string]*replaySource
+}
+
+// replaySource holds the nonces seen from one source, with order listing
+// them oldest first for eviction.
+type replaySource struct {
+	seen     map[uint64]time.Time
+	order    []uint64
+	lastSeen time.Time
+}
+
+// newReplayWindow returns an empty window keeping nonces for expiry.
+func newReplayWindow(expiry time.Duration) *replayWindow {
+	return &replayWindow{
+		expiry:  expiry,
+		sources: make(map[string]*replaySource),
+	}
+}
+
+// check reports whether source has already sent nonce within the window.
+// Otherwise it records the nonce and returns false.
+func (w *replayWindow) check(source string, nonce uint64) bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	now := time.Now()
+	src := w.sources[source]
+	if src == nil {
+		if len(w.sources) >= MaxDatagram2ReplaySources {
+			w.evictSource()
+		}
+		src = &replaySource{seen: make(map[uint64]time.Time)}
+		w.sources[source] = src
+	}
+	src.lastSeen = now
+
+	if expires, ok := src.seen[nonce]; ok {
+		if now.Before(expires) {
+			return true
+		}
+		src.seen[nonce] = now.Add(w.expiry)
+		return false
+	}
+
+	if len(src.order) >= MaxDatagram2ReplayNonces {
+		delete(src.seen, src.order[0])
+		src.order = src.order[1:]
+	}
+	src.seen[nonce] = now.Add(w.expiry)
+	src.order = append(src.order, nonce)
+	return false
+}
+
+// evictSource forgets the least recently seen source. Called with mu held.
+func (w *replayWindow) evictSource() {
+	var oldest string
+	var oldestSeen time.Time
+	for name, src := range w.sources {
+		if oldestSeen.IsZero() || src.lastSeen.Before(oldestSeen) {
+			oldest, oldestSeen = name, src.lastSeen
+		}
+	}
+	delete(w.sources, oldest)
+}
+
+// prune forgets expired nonces and sources left without any.
+func (w *replayWindow) prune() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	now := time.Now()
+	for name, src := range w.sources {
+		kept := src.order[:0]
+		for _, nonce := range src.order {
+			if expires, ok := src.seen[nonce]; ok && now.Before(expires) {
+				kept = append(kept, nonce)
+			} else {
+				delete(src.seen, nonce)
+			}
+		}
+		src.order = kept
+		if len(src.order) == 0 {
+			delete(w.sources, name)
+		}
+	}
+}
+
+// count returns the number of remembered nonces across all sources.
+func (w *replayWindow) count() int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	n := 0
+	for _, src := range w.sources {
+		n += len(src.order)
+	}
+	return n
+}
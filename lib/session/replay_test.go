@@ -0,0 +1,61 @@
+package session
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestReplayWindow_Check(t *testing.T) {
+	w := newReplayWindow(time.Minute)
+
+	if w.check("alice", 1) {
+		t.Error("check() on first use = true, want false")
+	}
+	if !w.check("alice", 1) {
+		t.Error("check() on resend = false, want true")
+	}
+	if w.check("bob", 1) {
+		t.Error("check() for another source = true, want false")
+	}
+}
+
+func TestReplayWindow_Expiry(t *testing.T) {
+	w := newReplayWindow(20 * time.Millisecond)
+	w.check("alice", 1)
+	time.Sleep(40 * time.Millisecond)
+
+	if w.check("alice", 1) {
+		t.Error("check() after expiry = true, want false")
+	}
+
+	time.Sleep(40 * time.Millisecond)
+	w.prune()
+	if n := w.count(); n != 0 {
+		t.Errorf("count() after prune = %d, want 0", n)
+	}
+}
+
+func TestReplayWindow_Bounded(t *testing.T) {
+	w := newReplayWindow(time.Minute)
+	for i := 0; i <= MaxDatagram2ReplayNonces; i++ {
+		w.check("alice", uint64(i))
+	}
+	if n := w.count(); n != MaxDatagram2ReplayNonces {
+		t.Errorf("count() = %d, want %d", n, MaxDatagram2ReplayNonces)
+	}
+	// The oldest nonce was forgotten, the newest is still remembered
+	if !w.check("alice", MaxDatagram2ReplayNonces) {
+		t.Error("check() for newest nonce = false, want true")
+	}
+	if w.check("alice", 0) {
+		t.Error("check() for evicted nonce = true, want false")
+	}
+
+	for i := 0; i <= MaxDatagram2ReplaySources; i++ {
+		w.check(fmt.Sprintf("peer-%d", i), 1)
+	}
+	if n := len(w.sources); n != MaxDatagram2ReplaySources {
+		t.Errorf("tracked sources = %d, want %d", n, MaxDatagram2ReplaySources)
+	}
+}
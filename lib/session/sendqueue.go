@@ -0,0 +1,158 @@
+package session
+
+import (
+	"bytes"
+	"errors"
+	"net"
+	"sync"
+	"time"
+)
+
+// DefaultSendRetries is the number of times a queued datagram is retried
+// when SessionConfig.SendRetries is 0.
+const DefaultSendRetries = 3
+
+// DefaultSendExpiry is how long a datagram may wait in the send queue when
+// SessionConfig.SendExpiry is 0.
+const DefaultSendExpiry = 10 * time.Second
+
+// MaxSendQueueSize is the largest allowed SessionConfig.SendQueueSize.
+const MaxSendQueueSize = 4096
+
+// sendRetryBackoff is the wait before the first retry of a queued
+// datagram. It doubles after each further failure.
+const sendRetryBackoff = 250 * time.Millisecond
+
+// queuedSend is an outgoing datagram waiting in a sendQueue.
+type queuedSend struct {
+	dest   string
+	data   []byte
+	send   func(data []byte) error
+	queued time.Time
+}
+
+// sendQueue holds the outgoing datagrams of a session and sends them in
+// order from one goroutine, retrying sends that fail with a transient
+// error such as I2CP failing while tunnels rebuild. A datagram is
+// dropped when the queue is full, when it has waited longer than the
+// expiry, or when its retries are used up.
+type sendQueue struct {
+	ch      chan queuedSend
+	retries int
+	expiry  time.Duration
+	backoff time.Duration
+	stats   *Stats
+	report  func(dest string, err error)
+
+	start sync.Once
+	stop  sync.Once
+	done  chan struct{}
+}
+
+// newSendQueue returns a send queue configured by cfg, or nil if cfg
+// disables it. Dropped datagrams are counted in stats and passed to
+// report.
+func newSendQueue(cfg *SessionConfig, stats *Stats, report func(dest string, err error)) *sendQueue {
+	if cfg == nil || cfg.SendQueueSize <= 0 {
+		return nil
+	}
+	q := &sendQueue{
+		ch:      make(chan queuedSend, cfg.SendQueueSize),
+		retries: cfg.SendRetries,
+		expiry:  cfg.SendExpiry,
+		backoff: sendRetryBackoff,
+		stats:   stats,
+		report:  report,
+		done:    make(chan struct{}),
+	}
+	if q.retries == 0 {
+		q.retries = DefaultSendRetries
+	}
+	if q.expiry == 0 {
+		q.expiry = DefaultSendExpiry
+	}
+	return q
+}
+
+// enqueue queues a copy of data for sending to dest with send. It returns
+// ErrSendQueueFull if the queue is full, so the caller can report the
+// failure at once.
+func (q *sendQueue) enqueue(dest string, data []byte, send func(data []byte) error) error {
+	q.start.Do(func() { go q.run() })
+	select {
+	case <-q.done:
+		return ErrSessionNotActive
+	default:
+	}
+	select {
+	case q.ch <- queuedSend{dest: dest, data: bytes.Clone(data), send: send, queued: time.Now()}:
+		return nil
+	default:
+		q.stats.AddSendDropped()
+		return ErrSendQueueFull
+	}
+}
+
+// run sends queued datagrams until the queue is closed. Datagrams still
+// queued then are discarded with the session.
+func (q *sendQueue) run() {
+	for {
+		select {
+		case <-q.done:
+			return
+		case s := <-q.ch:
+			q.deliver(s)
+		}
+	}
+}
+
+// deliver sends s, retrying transient failures with a doubling backoff.
+func (q *sendQueue) deliver(s queuedSend) {
+	backoff := q.backoff
+	for attempt := 0; ; attempt++ {
+		if time.Since(s.queued) >= q.expiry {
+			q.drop(s, ErrSendExpired)
+			return
+		}
+		err := s.send(s.data)
+		if err == nil {
+			q.stats.AddDatagramSent(len(s.data))
+			return
+		}
+		if attempt >= q.retries || !isTransientSendError(err) {
+			q.drop(s, err)
+			return
+		}
+		q.stats.AddSendRetry()
+
+		timer := time.NewTimer(backoff)
+		select {
+		case <-q.done:
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+		backoff *= 2
+	}
+}
+
+// drop counts and reports a datagram that could not be sent.
+func (q *sendQueue) drop(s queuedSend, err error) {
+	q.stats.AddSendDropped()
+	if q.report != nil {
+		q.report(s.dest, err)
+	}
+}
+
+// close stops the queue. Safe to call multiple times.
+func (q *sendQueue) close() {
+	q.stop.Do(func() { close(q.done) })
+}
+
+// isTransientSendError reports whether a failed send may succeed when
+// retried. Only a closed session or connection is known to be final;
+// anything else, such as I2CP rejecting a message while tunnels rebuild,
+// is retried until the retries or the expiry run out.
+func isTransientSendError(err error) bool {
+	return !errors.Is(err, net.ErrClosed) && !errors.Is(err, ErrSessionNotActive)
+}
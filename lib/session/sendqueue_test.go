@@ -0,0 +1,135 @@
+package session
+
+import (
+	"errors"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func newTestSendQueue(t *testing.T, size, retries int, expiry time.Duration) (*sendQueue, *Stats, chan error) {
+	t.Helper()
+	stats := &Stats{}
+	reported := make(chan error, 8)
+	cfg := &SessionConfig{SendQueueSize: size, SendRetries: retries, SendExpiry: expiry}
+	q := newSendQueue(cfg, stats, func(dest string, err error) { reported <- err })
+	q.backoff = time.Millisecond
+	t.Cleanup(q.close)
+	return q, stats, reported
+}
+
+func TestNewSendQueue_Disabled(t *testing.T) {
+	if q := newSendQueue(DefaultSessionConfig(), &Stats{}, nil); q != nil {
+		t.Error("newSendQueue() with size 0 should return nil")
+	}
+}
+
+func TestSendQueue_RetriesTransientError(t *testing.T) {
+	q, stats, _ := newTestSendQueue(t, 4, 3, time.Minute)
+
+	var calls atomic.Int32
+	sent := make(chan []byte, 1)
+	err := q.enqueue("dest", []byte("hello"), func(data []byte) error {
+		if calls.Add(1) < 3 {
+			return errors.New("i2cp: no tunnels")
+		}
+		sent <- data
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("enqueue() error = %v", err)
+	}
+
+	select {
+	case data := <-sent:
+		if string(data) != "hello" {
+			t.Errorf("sent %q, want %q", data, "hello")
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("datagram was not sent")
+	}
+	waitFor(t, func() bool { return stats.Snapshot().DatagramsSent == 1 })
+	if s := stats.Snapshot(); s.SendRetries != 2 || s.SendsDropped != 0 {
+		t.Errorf("retries/dropped = %d/%d, want 2/0", s.SendRetries, s.SendsDropped)
+	}
+}
+
+func TestSendQueue_Drops(t *testing.T) {
+	transient := errors.New("i2cp: no tunnels")
+	tests := []struct {
+		name    string
+		retries int
+		expiry  time.Duration
+		err     error
+		wantErr error
+	}{
+		{"retries exhausted", 2, time.Minute, transient, transient},
+		{"permanent error", 5, time.Minute, net.ErrClosed, net.ErrClosed},
+		{"expired", 1000, 20 * time.Millisecond, transient, ErrSendExpired},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q, stats, reported := newTestSendQueue(t, 4, tt.retries, tt.expiry)
+			if err := q.enqueue("dest", []byte("x"), func([]byte) error { return tt.err }); err != nil {
+				t.Fatalf("enqueue() error = %v", err)
+			}
+
+			select {
+			case err := <-reported:
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("reported error = %v, want %v", err, tt.wantErr)
+				}
+			case <-time.After(2 * time.Second):
+				t.Fatal("drop was not reported")
+			}
+			if s := stats.Snapshot(); s.SendsDropped != 1 || s.DatagramsSent != 0 {
+				t.Errorf("dropped/sent = %d/%d, want 1/0", s.SendsDropped, s.DatagramsSent)
+			}
+		})
+	}
+}
+
+func TestSendQueue_Full(t *testing.T) {
+	q, stats, _ := newTestSendQueue(t, 1, 1, time.Minute)
+
+	block := make(chan struct{})
+	defer close(block)
+	send := func([]byte) error { <-block; return nil }
+
+	// The first send occupies the worker and the second fills the queue.
+	if err := q.enqueue("dest", nil, send); err != nil {
+		t.Fatalf("enqueue() error = %v", err)
+	}
+	waitFor(t, func() bool { return len(q.ch) == 0 })
+	if err := q.enqueue("dest", nil, send); err != nil {
+		t.Fatalf("enqueue() error = %v", err)
+	}
+	if err := q.enqueue("dest", nil, send); !errors.Is(err, ErrSendQueueFull) {
+		t.Errorf("enqueue() error = %v, want ErrSendQueueFull", err)
+	}
+	if got := stats.Snapshot().SendsDropped; got != 1 {
+		t.Errorf("SendsDropped = %d, want 1", got)
+	}
+}
+
+func TestSendQueue_Closed(t *testing.T) {
+	q, _, _ := newTestSendQueue(t, 1, 1, time.Minute)
+	q.close()
+	if err := q.enqueue("dest", nil, func([]byte) error { return nil }); !errors.Is(err, ErrSessionNotActive) {
+		t.Errorf("enqueue() after close error = %v, want ErrSessionNotActive", err)
+	}
+}
+
+// waitFor polls cond until it holds or a second has passed.
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition not met within 1s")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
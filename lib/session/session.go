@@ -6,8 +6,12 @@ package session
 
 import (
 	"context"
+	"crypto/sha256"
 	"encoding/hex"
 	"net"
+	"time"
+
+	"github.com/go-i2p/common/base64"
 )
 
 // I2CPSessionHandle represents a handle to an I2CP session.
@@ -30,6 +34,17 @@ type I2CPSessionHandle interface {
 	DestinationBase64() string
 }
 
+// LeaseSetWaiter is implemented by I2CP session handles that can tell
+// when the session's lease set has been handed to the router for
+// publication. Until then, peers cannot look up the destination, so
+// inbound connections fail. lib/i2cp.I2CPSession implements it.
+type LeaseSetWaiter interface {
+	// WaitForLeaseSet blocks until the lease set has been sent to the
+	// router or ctx is done. It returns nil at once if publication
+	// cannot be tracked.
+	WaitForLeaseSet(ctx context.Context) error
+}
+
 // I2CPSessionProvider creates I2CP sessions for SAM sessions.
 // This interface is implemented by lib/i2cp.Client.
 // ISSUE-003: Enables session handler to create I2CP sessions and wait for tunnels.
@@ -43,6 +58,80 @@ type I2CPSessionProvider interface {
 	IsConnected() bool
 }
 
+// RouterInfo describes the I2P router behind an I2CP connection, as
+// reported during the I2CP handshake.
+type RouterInfo struct {
+	// Version is the router's I2CP API version, e.g. "0.9.66".
+	Version string
+
+	// Date is the router's clock at handshake time.
+	Date time.Time
+
+	// HostLookup reports support for hostname lookups (HostLookupMessage).
+	HostLookup bool
+
+	// FastReceive reports support for i2cp.fastReceive (0.9.4+).
+	FastReceive bool
+
+	// MultiSession reports support for multiple sessions per connection,
+	// required for PRIMARY subsessions (0.9.21+).
+	MultiSession bool
+
+	// LeaseSet2 reports support for CreateLeaseSet2Message (0.9.39+).
+	LeaseSet2 bool
+
+	// EncryptedLeaseSet reports support for blinded, encrypted leasesets
+	// (BlindingInfoMessage, 0.9.43+).
+	EncryptedLeaseSet bool
+
+	// LeaseSetLookupErrors reports detailed leaseset lookup failure codes
+	// (0.9.66+).
+	LeaseSetLookupErrors bool
+}
+
+// RouterInfoProvider is implemented by I2CP providers that can describe
+// the connected router.
+type RouterInfoProvider interface {
+	// RouterInfo returns the connected router's information.
+	// The second result is false if the provider is not connected.
+	RouterInfo() (RouterInfo, bool)
+}
+
+// I2CPHealth describes the health of an I2CP router connection.
+type I2CPHealth struct {
+	// RoundTrip is the latest time the router took to answer a request,
+	// such as a host lookup or session creation. Zero until one is seen.
+	RoundTrip time.Duration
+
+	// AverageRoundTrip is the mean of all round trips measured.
+	AverageRoundTrip time.Duration
+
+	// Errors counts I2CP message errors by type, such as "network" or
+	// "protocol".
+	Errors map[string]uint64
+
+	// Disconnects counts how often the router connection dropped.
+	Disconnects uint64
+
+	// Pending is the number of requests awaiting an answer.
+	Pending int
+
+	// OldestPending is how long the oldest pending request has waited.
+	OldestPending time.Duration
+
+	// Unresponsive reports that a request has waited longer than the
+	// provider's unresponsive timeout, so the router has likely stopped
+	// acknowledging messages though the connection is still up.
+	Unresponsive bool
+}
+
+// I2CPHealthProvider is implemented by I2CP providers that monitor the
+// health of their router connection.
+type I2CPHealthProvider interface {
+	// I2CPHealth returns the current health of the router connection.
+	I2CPHealth() I2CPHealth
+}
+
 // Status represents the current state of a session per SAM lifecycle.
 type Status int
 
@@ -132,8 +221,8 @@ type Destination struct {
 	OfflineSignature *ParsedOfflineSignature
 }
 
-// ParsedOfflineSignature mirrors destination.ParsedOfflineSignature for session package use.
-// This avoids circular imports between session and destination packages.
+// ParsedOfflineSignature mirrors destination.ParsedOfflineSignature for session package use,
+// with the expiry as a Unix timestamp.
 type ParsedOfflineSignature struct {
 	// Expires is the Unix timestamp when the offline signature expires.
 	Expires int64
@@ -152,21 +241,62 @@ func (d *Destination) HasOfflineSignature() bool {
 	return d != nil && d.OfflineSignature != nil
 }
 
-// Hash returns a unique identifier for the destination as a hex-encoded string.
-// Uses the first 32 bytes of the public key encoded as hexadecimal.
-// This provides a consistent, valid string identifier for destination uniqueness checking.
+// OfflineSignatureConfig returns the destination's offline signature, with
+// its transient private key, as session configuration. It returns nil if
+// the destination has no offline signature. The transient private key is
+// shared with d, so Zero clears it for both.
+func (d *Destination) OfflineSignatureConfig() *OfflineSignature {
+	if !d.HasOfflineSignature() {
+		return nil
+	}
+	return &OfflineSignature{
+		Expires:             d.OfflineSignature.Expires,
+		TransientType:       d.OfflineSignature.TransientSigType,
+		TransientPublicKey:  d.OfflineSignature.TransientPublicKey,
+		Signature:           d.OfflineSignature.Signature,
+		TransientPrivateKey: d.OfflineSignature.TransientPrivateKey,
+	}
+}
+
+// Zero overwrites the private key, including the signing private key it
+// contains, and any transient offline signing key with zeros and drops
+// them, so key material does not linger in memory after a session
+// closes. The public key is kept for unregistering by hash. Zero is safe
+// on a nil Destination.
+func (d *Destination) Zero() {
+	if d == nil {
+		return
+	}
+	clear(d.PrivateKey)
+	d.PrivateKey = nil
+	if d.OfflineSignature != nil {
+		clear(d.OfflineSignature.TransientPrivateKey)
+		d.OfflineSignature.TransientPrivateKey = nil
+	}
+}
+
+// Hash returns the hex-encoded SHA-256 hash of the destination, used as the
+// registry's destination index key.
+// For a valid I2P Base64 destination this is the I2P destination hash (the
+// value a .b32.i2p address encodes). Other public keys are hashed as-is.
 // Returns empty string for nil or empty destinations.
 func (d *Destination) Hash() string {
 	if d == nil || len(d.PublicKey) == 0 {
 		return ""
 	}
-	// Use hex encoding for reliable string output that's safe for any byte sequence.
-	// Take first 32 bytes (or less if shorter) for a reasonably unique identifier.
-	hashLen := len(d.PublicKey)
-	if hashLen > 32 {
-		hashLen = 32
+	return DestinationHash(string(d.PublicKey))
+}
+
+// DestinationHash returns the hex-encoded SHA-256 hash of a Base64
+// destination, matching Destination.Hash. It lets callers holding only a
+// peer's destination string look it up with Registry.GetByDestination.
+func DestinationHash(dest string) string {
+	data, err := base64.DecodeString(dest)
+	if err != nil || len(data) == 0 {
+		data = []byte(dest)
 	}
-	return hex.EncodeToString(d.PublicKey[:hashLen])
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
 }
 
 // Session defines the base interface for all SAM session types.
@@ -312,4 +442,9 @@ type PrimarySession interface {
 
 	// Subsessions returns all active subsession IDs.
 	Subsessions() []string
+
+	// Route returns the subsession that receives inbound traffic of the
+	// given I2CP protocol addressed to toPort, or nil if none does.
+	// An exact LISTEN_PORT match wins over a wildcard (port 0) one.
+	Route(protocol, toPort int) Session
 }
@@ -1,7 +1,13 @@
 package session
 
 import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
 	"testing"
+
+	"github.com/go-i2p/common/base64"
 )
 
 func TestStatus_String(t *testing.T) {
@@ -71,6 +77,55 @@ func TestStyle_IsPrimary(t *testing.T) {
 	}
 }
 
+func TestDestination_Zero(t *testing.T) {
+	var nilDest *Destination
+	nilDest.Zero()
+
+	priv := []byte{1, 2, 3}
+	transient := []byte{4, 5, 6}
+	d := &Destination{
+		PublicKey:        []byte("pub"),
+		PrivateKey:       priv,
+		OfflineSignature: &ParsedOfflineSignature{TransientPrivateKey: transient},
+	}
+	d.Zero()
+
+	if d.PrivateKey != nil || d.OfflineSignature.TransientPrivateKey != nil {
+		t.Error("Zero() should drop the private keys")
+	}
+	if !bytes.Equal(priv, make([]byte, 3)) || !bytes.Equal(transient, make([]byte, 3)) {
+		t.Errorf("Zero() left key bytes %v, %v; want zeros", priv, transient)
+	}
+	if string(d.PublicKey) != "pub" {
+		t.Error("Zero() should keep the public key")
+	}
+}
+
+func TestDestination_OfflineSignatureConfig(t *testing.T) {
+	if cfg := (&Destination{}).OfflineSignatureConfig(); cfg != nil {
+		t.Errorf("OfflineSignatureConfig() = %+v without an offline signature, want nil", cfg)
+	}
+
+	d := &Destination{OfflineSignature: &ParsedOfflineSignature{
+		Expires:             1234,
+		TransientSigType:    7,
+		TransientPublicKey:  []byte{1},
+		Signature:           []byte{2},
+		TransientPrivateKey: []byte{3},
+	}}
+	cfg := d.OfflineSignatureConfig()
+	if cfg == nil || cfg.Expires != 1234 || cfg.TransientType != 7 ||
+		!bytes.Equal(cfg.TransientPublicKey, []byte{1}) || !bytes.Equal(cfg.Signature, []byte{2}) ||
+		!bytes.Equal(cfg.TransientPrivateKey, []byte{3}) {
+		t.Fatalf("OfflineSignatureConfig() = %+v", cfg)
+	}
+
+	d.Zero()
+	if !bytes.Equal(cfg.TransientPrivateKey, []byte{0}) {
+		t.Error("Zero() should clear the transient private key of the config too")
+	}
+}
+
 func TestDestination_Hash(t *testing.T) {
 	t.Run("nil destination", func(t *testing.T) {
 		var d *Destination
@@ -86,42 +141,39 @@ func TestDestination_Hash(t *testing.T) {
 		}
 	})
 
-	t.Run("short public key returns hex encoded", func(t *testing.T) {
-		d := &Destination{PublicKey: []byte("shortkey")}
-		got := d.Hash()
-		// "shortkey" hex encoded = 73686f72746b6579
-		want := "73686f72746b6579"
-		if got != want {
-			t.Errorf("short PublicKey.Hash() = %q, want %q", got, want)
+	t.Run("i2p base64 destination hashes decoded bytes", func(t *testing.T) {
+		raw := make([]byte, 391)
+		for i := range raw {
+			raw[i] = byte(i)
+		}
+		d := &Destination{PublicKey: []byte(base64.EncodeToString(raw))}
+		sum := sha256.Sum256(raw)
+		if got, want := d.Hash(), hex.EncodeToString(sum[:]); got != want {
+			t.Errorf("Hash() = %q, want %q", got, want)
 		}
 	})
 
-	t.Run("long public key truncated to 32 bytes hex encoded", func(t *testing.T) {
-		longKey := make([]byte, 64)
-		for i := range longKey {
-			longKey[i] = byte(i)
+	t.Run("non-base64 key hashed as-is", func(t *testing.T) {
+		d := &Destination{PublicKey: []byte("not base64!")}
+		sum := sha256.Sum256([]byte("not base64!"))
+		if got, want := d.Hash(), hex.EncodeToString(sum[:]); got != want {
+			t.Errorf("Hash() = %q, want %q", got, want)
 		}
-		d := &Destination{PublicKey: longKey}
-		got := d.Hash()
-		// 32 bytes hex encoded = 64 characters
-		if len(got) != 64 {
-			t.Errorf("long PublicKey.Hash() len = %d, want 64", len(got))
-		}
-		// Verify it contains only hex characters
-		for _, c := range got {
-			if !((c >= '0' && c <= '9') || (c >= 'a' && c <= 'f')) {
-				t.Errorf("Hash contains non-hex character: %c", c)
-			}
+	})
+
+	t.Run("shared prefix does not collide", func(t *testing.T) {
+		prefix := strings.Repeat("A", 64)
+		a := &Destination{PublicKey: []byte(prefix + "AAAA")}
+		b := &Destination{PublicKey: []byte(prefix + "BBBB")}
+		if a.Hash() == b.Hash() {
+			t.Error("destinations with a common prefix should have distinct hashes")
 		}
 	})
 
-	t.Run("binary data produces valid hex", func(t *testing.T) {
-		// Test with bytes that are not valid UTF-8 to verify hex encoding works
-		d := &Destination{PublicKey: []byte{0x00, 0xff, 0x80, 0x7f}}
-		got := d.Hash()
-		want := "00ff807f"
-		if got != want {
-			t.Errorf("binary PublicKey.Hash() = %q, want %q", got, want)
+	t.Run("matches DestinationHash", func(t *testing.T) {
+		d := &Destination{PublicKey: []byte("shortkey")}
+		if d.Hash() != DestinationHash("shortkey") {
+			t.Error("Hash() should equal DestinationHash(PublicKey)")
 		}
 	})
 }
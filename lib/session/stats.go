@@ -0,0 +1,234 @@
+package session
+
+import (
+	"net"
+	"sync/atomic"
+	"time"
+)
+
+// Stats holds traffic counters for a session.
+// All methods are safe for concurrent use. Counters reset when the
+// session closes.
+type Stats struct {
+	bytesSent         atomic.Uint64
+	bytesReceived     atomic.Uint64
+	streams           atomic.Uint64
+	datagramsSent     atomic.Uint64
+	datagramsReceived atomic.Uint64
+	datagramsDropped  atomic.Uint64
+	sendsDropped      atomic.Uint64
+	sendRetries       atomic.Uint64
+	replaysDropped    atomic.Uint64
+
+	// inboundStreams and outboundStreams count the open streams held
+	// against the session's stream limits. See ReserveStream.
+	inboundStreams  atomic.Int64
+	outboundStreams atomic.Int64
+
+	// lastActivity is the time of the last recorded traffic, in Unix
+	// nanoseconds, or 0 if none.
+	lastActivity atomic.Int64
+}
+
+// StatsSnapshot is a point-in-time copy of a session's Stats.
+type StatsSnapshot struct {
+	// BytesSent counts stream and datagram payload bytes sent to I2P.
+	BytesSent uint64 `json:"bytes_sent"`
+
+	// BytesReceived counts stream and datagram payload bytes received from I2P.
+	BytesReceived uint64 `json:"bytes_received"`
+
+	// Streams counts streams opened via CONNECT, ACCEPT, and FORWARD.
+	Streams uint64 `json:"streams"`
+
+	// DatagramsSent counts datagrams (repliable, anonymous, or raw) sent.
+	DatagramsSent uint64 `json:"datagrams_sent"`
+
+	// DatagramsReceived counts datagrams received, whether delivered on the
+	// control socket or forwarded.
+	DatagramsReceived uint64 `json:"datagrams_received"`
+
+	// DatagramsDropped counts received datagrams discarded because the
+	// session's receive queue was full. See SessionConfig.DropPolicy.
+	DatagramsDropped uint64 `json:"datagrams_dropped"`
+
+	// SendsDropped counts outgoing datagrams the send queue discarded
+	// because it was full, they expired or their retries ran out. See
+	// SessionConfig.SendQueueSize.
+	SendsDropped uint64 `json:"sends_dropped"`
+
+	// SendRetries counts retried sends of queued datagrams.
+	SendRetries uint64 `json:"send_retries"`
+
+	// ReplaysDropped counts received DATAGRAM2 messages discarded because
+	// their source had already used the nonce.
+	ReplaysDropped uint64 `json:"replays_dropped"`
+}
+
+// StatsProvider is implemented by sessions that track traffic statistics.
+// All sessions embedding *BaseSession implement it.
+type StatsProvider interface {
+	Stats() *Stats
+}
+
+// AddBytesSent records n payload bytes sent to I2P.
+func (s *Stats) AddBytesSent(n int) {
+	if n > 0 {
+		s.bytesSent.Add(uint64(n))
+		s.Touch()
+	}
+}
+
+// AddBytesReceived records n payload bytes received from I2P.
+func (s *Stats) AddBytesReceived(n int) {
+	if n > 0 {
+		s.bytesReceived.Add(uint64(n))
+		s.Touch()
+	}
+}
+
+// AddStream records a newly opened stream.
+func (s *Stats) AddStream() {
+	s.streams.Add(1)
+	s.Touch()
+}
+
+// AddDatagramSent records a datagram of size bytes sent to I2P.
+func (s *Stats) AddDatagramSent(size int) {
+	s.datagramsSent.Add(1)
+	s.AddBytesSent(size)
+	s.Touch()
+}
+
+// AddDatagramReceived records a datagram of size bytes received from I2P.
+func (s *Stats) AddDatagramReceived(size int) {
+	s.datagramsReceived.Add(1)
+	s.AddBytesReceived(size)
+	s.Touch()
+}
+
+// AddDatagramDropped records a received datagram discarded because the
+// receive queue was full.
+func (s *Stats) AddDatagramDropped() {
+	s.datagramsDropped.Add(1)
+}
+
+// AddSendDropped records an outgoing datagram discarded by the send queue.
+func (s *Stats) AddSendDropped() {
+	s.sendsDropped.Add(1)
+}
+
+// AddSendRetry records a retried send of a queued datagram.
+func (s *Stats) AddSendRetry() {
+	s.sendRetries.Add(1)
+}
+
+// AddReplayDropped records a received datagram discarded as a replay.
+func (s *Stats) AddReplayDropped() {
+	s.replaysDropped.Add(1)
+}
+
+// Touch records activity without traffic, such as session creation.
+func (s *Stats) Touch() {
+	s.lastActivity.Store(time.Now().UnixNano())
+}
+
+// LastActivity returns the time of the last recorded traffic or Touch,
+// or the zero time if there has been none.
+func (s *Stats) LastActivity() time.Time {
+	n := s.lastActivity.Load()
+	if n == 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, n)
+}
+
+// Snapshot returns the current counter values.
+func (s *Stats) Snapshot() StatsSnapshot {
+	return StatsSnapshot{
+		BytesSent:         s.bytesSent.Load(),
+		BytesReceived:     s.bytesReceived.Load(),
+		Streams:           s.streams.Load(),
+		DatagramsSent:     s.datagramsSent.Load(),
+		DatagramsReceived: s.datagramsReceived.Load(),
+		DatagramsDropped:  s.datagramsDropped.Load(),
+		SendsDropped:      s.sendsDropped.Load(),
+		SendRetries:       s.sendRetries.Load(),
+		ReplaysDropped:    s.replaysDropped.Load(),
+	}
+}
+
+// Reset sets all counters to zero.
+func (s *Stats) Reset() {
+	s.bytesSent.Store(0)
+	s.bytesReceived.Store(0)
+	s.streams.Store(0)
+	s.datagramsSent.Store(0)
+	s.datagramsReceived.Store(0)
+	s.datagramsDropped.Store(0)
+	s.sendsDropped.Store(0)
+	s.sendRetries.Store(0)
+	s.replaysDropped.Store(0)
+	s.lastActivity.Store(0)
+}
+
+// LastActivity returns the most recent activity on sess, including the
+// subsessions of a PRIMARY session. It returns false if sess does not
+// track statistics or has recorded no activity.
+func LastActivity(sess Session) (time.Time, bool) {
+	var last time.Time
+	if sp, ok := sess.(StatsProvider); ok {
+		last = sp.Stats().LastActivity()
+	}
+	if primary, ok := sess.(PrimarySession); ok {
+		for _, id := range primary.Subsessions() {
+			sub := primary.Subsession(id)
+			if sub == nil {
+				continue
+			}
+			if t, ok := LastActivity(sub); ok && t.After(last) {
+				last = t
+			}
+		}
+	}
+	return last, !last.IsZero()
+}
+
+// countingConn wraps an I2P stream connection and records traffic.
+type countingConn struct {
+	net.Conn
+	stats *Stats
+}
+
+// NewCountingConn wraps an I2P stream connection so that bytes read are
+// recorded as received and bytes written as sent in stats.
+func NewCountingConn(conn net.Conn, stats *Stats) net.Conn {
+	return &countingConn{Conn: conn, stats: stats}
+}
+
+// Read reads from the I2P stream and records the bytes received.
+func (c *countingConn) Read(b []byte) (int, error) {
+	n, err := c.Conn.Read(b)
+	c.stats.AddBytesReceived(n)
+	return n, err
+}
+
+// Write writes to the I2P stream and records the bytes sent.
+func (c *countingConn) Write(b []byte) (int, error) {
+	n, err := c.Conn.Write(b)
+	c.stats.AddBytesSent(n)
+	return n, err
+}
+
+// TrackStream records a new stream on sess and returns conn wrapped to
+// count its traffic. If sess does not track statistics, conn is returned
+// unchanged.
+func TrackStream(sess Session, conn net.Conn) net.Conn {
+	sp, ok := sess.(StatsProvider)
+	if !ok || conn == nil {
+		return conn
+	}
+	stats := sp.Stats()
+	stats.AddStream()
+	return NewCountingConn(conn, stats)
+}
@@ -0,0 +1,118 @@
+package session
+
+import (
+	"net"
+	"testing"
+	"time"
+)
+
+func TestStats_Counters(t *testing.T) {
+	var s Stats
+	s.AddBytesSent(10)
+	s.AddBytesReceived(20)
+	s.AddStream()
+	s.AddDatagramSent(5)
+	s.AddDatagramReceived(7)
+	s.AddDatagramDropped()
+	s.AddBytesSent(-1)
+
+	got := s.Snapshot()
+	want := StatsSnapshot{
+		BytesSent:         15,
+		BytesReceived:     27,
+		Streams:           1,
+		DatagramsSent:     1,
+		DatagramsReceived: 1,
+		DatagramsDropped:  1,
+	}
+	if got != want {
+		t.Errorf("Snapshot() = %+v, want %+v", got, want)
+	}
+
+	s.Reset()
+	if got := s.Snapshot(); got != (StatsSnapshot{}) {
+		t.Errorf("Snapshot() after Reset() = %+v, want zero", got)
+	}
+}
+
+func TestStats_LastActivity(t *testing.T) {
+	var s Stats
+	if !s.LastActivity().IsZero() {
+		t.Error("LastActivity() should be zero before any traffic")
+	}
+
+	before := time.Now()
+	s.AddDatagramReceived(0)
+	if last := s.LastActivity(); last.Before(before) {
+		t.Errorf("LastActivity() = %v, want at or after %v", last, before)
+	}
+
+	s.Reset()
+	if !s.LastActivity().IsZero() {
+		t.Error("LastActivity() should be zero after Reset()")
+	}
+}
+
+func TestLastActivity_Primary(t *testing.T) {
+	primary := NewPrimarySession("primary", nil, nil, nil)
+	primary.Activate()
+	sub, err := primary.AddSubsession("sub", StyleRaw, SubsessionOptions{})
+	if err != nil {
+		t.Fatalf("AddSubsession() error = %v", err)
+	}
+
+	time.Sleep(time.Millisecond)
+	sub.(StatsProvider).Stats().AddDatagramSent(1)
+
+	last, ok := LastActivity(primary)
+	if !ok {
+		t.Fatal("LastActivity() should report activity")
+	}
+	if want := sub.(StatsProvider).Stats().LastActivity(); !last.Equal(want) {
+		t.Errorf("LastActivity() = %v, want subsession activity %v", last, want)
+	}
+}
+
+func TestTrackStream(t *testing.T) {
+	sess := NewBaseSession("test-id", StyleStream, nil, nil, nil)
+	client, server := net.Pipe()
+	defer server.Close()
+
+	conn := TrackStream(sess, client)
+	defer conn.Close()
+
+	go func() {
+		buf := make([]byte, 5)
+		n, _ := server.Read(buf)
+		server.Write(buf[:n])
+		server.Write([]byte("abc"))
+	}()
+
+	if _, err := conn.Write([]byte("hello")); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	buf := make([]byte, 8)
+	total := 0
+	for total < 8 {
+		n, err := conn.Read(buf[total:])
+		if err != nil {
+			t.Fatalf("Read() error = %v", err)
+		}
+		total += n
+	}
+
+	got := sess.Stats().Snapshot()
+	if got.Streams != 1 || got.BytesSent != 5 || got.BytesReceived != 8 {
+		t.Errorf("Snapshot() = %+v, want 1 stream, 5 sent, 8 received", got)
+	}
+}
+
+func TestTrackStream_NoStats(t *testing.T) {
+	client, server := net.Pipe()
+	defer client.Close()
+	defer server.Close()
+
+	if conn := TrackStream(nil, client); conn != client {
+		t.Error("TrackStream() without a StatsProvider should return conn unchanged")
+	}
+}
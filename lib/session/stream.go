@@ -7,13 +7,14 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"io"
 	"net"
 	"sync"
 	"time"
 
 	go_i2cp "github.com/go-i2p/go-i2cp"
 	"github.com/go-i2p/go-streaming"
+
+	"github.com/go-i2p/go-sam-bridge/lib/util"
 )
 
 // ForwardConnectTimeout is the maximum time allowed to connect to the
@@ -497,6 +498,13 @@ func (s *StreamSessionImpl) acceptAndForward(listener net.Listener, target strin
 		return
 	}
 
+	release, err := ReserveStream(s, StreamInbound)
+	if err != nil {
+		inConn.Close()
+		return
+	}
+	inConn = ReleaseOnClose(inConn, release)
+
 	outConn, err := net.DialTimeout("tcp", target, ForwardConnectTimeout)
 	if err != nil {
 		inConn.Close()
@@ -504,7 +512,7 @@ func (s *StreamSessionImpl) acceptAndForward(listener net.Listener, target strin
 	}
 
 	s.forwardWg.Add(1)
-	go s.forwardConnection(inConn, outConn)
+	go s.forwardConnection(LimitStream(s, inConn), outConn)
 }
 
 // forwardConnection forwards data between two connections bidirectionally.
@@ -529,12 +537,12 @@ func (s *StreamSessionImpl) forwardConnection(i2pConn, tcpConn net.Conn) {
 	done := make(chan struct{}, 2)
 
 	go func() {
-		io.Copy(tcpConn, i2pConn)
+		util.Copy(tcpConn, i2pConn, 0)
 		done <- struct{}{}
 	}()
 
 	go func() {
-		io.Copy(i2pConn, tcpConn)
+		util.Copy(i2pConn, tcpConn, 0)
 		done <- struct{}{}
 	}()
 
@@ -0,0 +1,91 @@
+package session
+
+import (
+	"fmt"
+	"net"
+	"sync"
+	"sync/atomic"
+)
+
+// StreamDirection distinguishes the streams a session opens from those
+// it accepts, which are limited separately.
+type StreamDirection int
+
+const (
+	// StreamOutbound streams are opened with STREAM CONNECT.
+	StreamOutbound StreamDirection = iota
+
+	// StreamInbound streams are accepted with STREAM ACCEPT or FORWARD.
+	StreamInbound
+)
+
+// String returns "outbound" or "inbound".
+func (d StreamDirection) String() string {
+	if d == StreamInbound {
+		return "inbound"
+	}
+	return "outbound"
+}
+
+// ReserveStream reserves one of the concurrent streams sess may have in
+// direction dir, per SessionConfig.MaxOutboundStreams and
+// MaxInboundStreams. It returns an error wrapping ErrStreamLimit if the
+// limit is reached; otherwise the caller must call release once the
+// stream has closed or could not be opened. Sessions that do not track
+// statistics are not limited.
+func ReserveStream(sess Session, dir StreamDirection) (release func(), err error) {
+	sp, ok := sess.(StatsProvider)
+	if !ok {
+		return func() {}, nil
+	}
+	max := 0
+	if cp, ok := sess.(interface{ Config() *SessionConfig }); ok {
+		if cfg := cp.Config(); cfg != nil {
+			max = cfg.MaxOutboundStreams
+			if dir == StreamInbound {
+				max = cfg.MaxInboundStreams
+			}
+		}
+	}
+
+	active := sp.Stats().activeStreams(dir)
+	for {
+		n := active.Load()
+		if max > 0 && n >= int64(max) {
+			return nil, fmt.Errorf("%s %w (max %d)", dir, ErrStreamLimit, max)
+		}
+		if active.CompareAndSwap(n, n+1) {
+			break
+		}
+	}
+	var once sync.Once
+	return func() { once.Do(func() { active.Add(-1) }) }, nil
+}
+
+// ReleaseOnClose returns conn wrapped so that closing it calls release,
+// such as the function ReserveStream returned for the stream.
+func ReleaseOnClose(conn net.Conn, release func()) net.Conn {
+	return &releaseConn{Conn: conn, release: release}
+}
+
+// releaseConn calls release once when the connection is closed.
+type releaseConn struct {
+	net.Conn
+	once    sync.Once
+	release func()
+}
+
+// Close closes the connection and releases its reservation.
+func (c *releaseConn) Close() error {
+	err := c.Conn.Close()
+	c.once.Do(c.release)
+	return err
+}
+
+// activeStreams returns the counter of open streams in direction dir.
+func (s *Stats) activeStreams(dir StreamDirection) *atomic.Int64 {
+	if dir == StreamInbound {
+		return &s.inboundStreams
+	}
+	return &s.outboundStreams
+}
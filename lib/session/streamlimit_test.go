@@ -0,0 +1,52 @@
+package session
+
+import (
+	"errors"
+	"net"
+	"testing"
+)
+
+func TestReserveStream(t *testing.T) {
+	cfg := DefaultSessionConfig()
+	cfg.MaxOutboundStreams = 2
+	sess := NewBaseSession("limited", StyleStream, nil, nil, cfg)
+
+	release1, err := ReserveStream(sess, StreamOutbound)
+	if err != nil {
+		t.Fatalf("ReserveStream() error = %v", err)
+	}
+	if _, err := ReserveStream(sess, StreamOutbound); err != nil {
+		t.Fatalf("ReserveStream() error = %v", err)
+	}
+	if _, err := ReserveStream(sess, StreamOutbound); !errors.Is(err, ErrStreamLimit) {
+		t.Fatalf("ReserveStream() over the limit error = %v, want ErrStreamLimit", err)
+	}
+
+	// Inbound streams are not limited
+	if _, err := ReserveStream(sess, StreamInbound); err != nil {
+		t.Errorf("ReserveStream(inbound) error = %v", err)
+	}
+
+	// Releasing twice frees only one slot
+	release1()
+	release1()
+	if _, err := ReserveStream(sess, StreamOutbound); err != nil {
+		t.Fatalf("ReserveStream() after release error = %v", err)
+	}
+	if _, err := ReserveStream(sess, StreamOutbound); !errors.Is(err, ErrStreamLimit) {
+		t.Errorf("ReserveStream() error = %v, want ErrStreamLimit", err)
+	}
+}
+
+func TestReleaseOnClose(t *testing.T) {
+	a, b := net.Pipe()
+	defer b.Close()
+
+	released := 0
+	conn := ReleaseOnClose(a, func() { released++ })
+	conn.Close()
+	conn.Close()
+	if released != 1 {
+		t.Errorf("release called %d times, want 1", released)
+	}
+}
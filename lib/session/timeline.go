@@ -0,0 +1,53 @@
+package session
+
+import "time"
+
+// Timeline records when a session passed through its lifecycle states.
+// Zero times mean the state has not been reached.
+type Timeline struct {
+	// Created is when the session was created.
+	Created time.Time `json:"created"`
+
+	// Ready is when the session's tunnels were built. It stays zero for
+	// sessions created without an I2CP connection.
+	Ready time.Time `json:"ready,omitzero"`
+
+	// Closing is when the session started closing.
+	Closing time.Time `json:"closing,omitzero"`
+}
+
+// TimelineProvider is implemented by sessions that record status
+// transition times. All sessions embedding *BaseSession implement it.
+type TimelineProvider interface {
+	Timeline() Timeline
+}
+
+// Uptime returns how long the session has existed at now, or existed
+// before it started closing.
+func (t Timeline) Uptime(now time.Time) time.Duration {
+	if t.Created.IsZero() {
+		return 0
+	}
+	end := now
+	if !t.Closing.IsZero() {
+		end = t.Closing
+	}
+	return end.Sub(t.Created)
+}
+
+// TimeToReady returns how long the session's tunnels took to build. It
+// returns false if they have not been reported ready.
+func (t Timeline) TimeToReady() (time.Duration, bool) {
+	if t.Created.IsZero() || t.Ready.IsZero() {
+		return 0, false
+	}
+	return t.Ready.Sub(t.Created), true
+}
+
+// recordStatus updates the timeline for a transition to s at now.
+// Only the first transition into a closing state is recorded.
+func (t *Timeline) recordStatus(s Status, now time.Time) {
+	if (s == StatusClosing || s == StatusClosed) && t.Closing.IsZero() {
+		t.Closing = now
+	}
+}
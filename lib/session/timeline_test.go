@@ -0,0 +1,64 @@
+package session
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTimeline_Durations(t *testing.T) {
+	created := time.Unix(1000, 0)
+	tl := Timeline{Created: created}
+
+	if got := tl.Uptime(created.Add(5 * time.Second)); got != 5*time.Second {
+		t.Errorf("Uptime() = %v, want 5s", got)
+	}
+	if _, ok := tl.TimeToReady(); ok {
+		t.Error("TimeToReady() should be unknown before Ready is set")
+	}
+
+	tl.Ready = created.Add(2 * time.Second)
+	tl.Closing = created.Add(10 * time.Second)
+	if got, ok := tl.TimeToReady(); !ok || got != 2*time.Second {
+		t.Errorf("TimeToReady() = %v, %v, want 2s, true", got, ok)
+	}
+	if got := tl.Uptime(created.Add(time.Hour)); got != 10*time.Second {
+		t.Errorf("Uptime() after closing = %v, want 10s", got)
+	}
+
+	if got := (Timeline{}).Uptime(created); got != 0 {
+		t.Errorf("zero Timeline Uptime() = %v, want 0", got)
+	}
+}
+
+func TestBaseSession_Timeline(t *testing.T) {
+	before := time.Now()
+	b := NewBaseSession("timeline", StyleStream, nil, nil, nil)
+
+	tl := b.Timeline()
+	if tl.Created.Before(before) || !tl.Ready.IsZero() || !tl.Closing.IsZero() {
+		t.Fatalf("new session Timeline() = %+v, want only Created set", tl)
+	}
+
+	b.SetStatus(StatusActive)
+	if !b.Timeline().Ready.IsZero() {
+		t.Error("activation alone should not mark tunnels ready")
+	}
+
+	b.MarkTunnelsReady()
+	ready := b.Timeline().Ready
+	if ready.IsZero() {
+		t.Fatal("MarkTunnelsReady() should set Ready")
+	}
+	b.MarkTunnelsReady()
+	if got := b.Timeline().Ready; !got.Equal(ready) {
+		t.Errorf("second MarkTunnelsReady() changed Ready from %v to %v", ready, got)
+	}
+
+	b.Close()
+	if b.Timeline().Closing.IsZero() {
+		t.Error("Close() should set Closing")
+	}
+}
+
+// Verify BaseSession records status times
+var _ TimelineProvider = (*BaseSession)(nil)
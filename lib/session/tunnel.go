@@ -0,0 +1,81 @@
+package session
+
+import "time"
+
+// TunnelEventType identifies a change in a session's tunnels.
+type TunnelEventType int
+
+const (
+	// TunnelBuilt indicates the router built or rebuilt the tunnels.
+	TunnelBuilt TunnelEventType = iota
+	// TunnelExpired indicates the router tore the tunnels down, such as
+	// after i2cp.closeIdleTime.
+	TunnelExpired
+	// TunnelFailed indicates the router refused or invalidated the
+	// session, or the router connection was lost.
+	TunnelFailed
+)
+
+// String returns the event type as used in SESSION STATS: BUILT, EXPIRED
+// or FAILED.
+func (t TunnelEventType) String() string {
+	switch t {
+	case TunnelBuilt:
+		return "BUILT"
+	case TunnelExpired:
+		return "EXPIRED"
+	case TunnelFailed:
+		return "FAILED"
+	default:
+		return "UNKNOWN"
+	}
+}
+
+// TunnelEvent is a change in a session's tunnels.
+type TunnelEvent struct {
+	Type   TunnelEventType
+	Time   time.Time
+	Reason string
+}
+
+// TunnelHealth summarizes the tunnel events of a session.
+type TunnelHealth struct {
+	// Last is the latest event. Its Time is zero if there has been none.
+	Last TunnelEvent
+
+	// Builds, Expirations and Failures count events by type.
+	Builds      uint64
+	Expirations uint64
+	Failures    uint64
+}
+
+// Record updates h for ev.
+func (h *TunnelHealth) Record(ev TunnelEvent) {
+	h.Last = ev
+	switch ev.Type {
+	case TunnelBuilt:
+		h.Builds++
+	case TunnelExpired:
+		h.Expirations++
+	case TunnelFailed:
+		h.Failures++
+	}
+}
+
+// TunnelEventSource is implemented by I2CP session handles that report
+// tunnel changes. lib/i2cp.I2CPSession implements it.
+type TunnelEventSource interface {
+	// OnTunnelEvent sets fn to be called for each tunnel event, replacing
+	// any earlier fn. If events happened before, fn is called at once
+	// with the latest.
+	OnTunnelEvent(fn func(TunnelEvent))
+}
+
+// TunnelHealthProvider is implemented by sessions and I2CP session
+// handles that track tunnel events. All sessions embedding *BaseSession
+// implement it, counting the events recorded with RecordTunnelEvent.
+type TunnelHealthProvider interface {
+	// TunnelHealth returns the tunnel events seen so far. The second
+	// result is false if tunnels are not tracked.
+	TunnelHealth() (TunnelHealth, bool)
+}
@@ -0,0 +1,40 @@
+package session
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTunnelEventType_String(t *testing.T) {
+	for typ, want := range map[TunnelEventType]string{
+		TunnelBuilt:         "BUILT",
+		TunnelExpired:       "EXPIRED",
+		TunnelFailed:        "FAILED",
+		TunnelEventType(99): "UNKNOWN",
+	} {
+		if got := typ.String(); got != want {
+			t.Errorf("TunnelEventType(%d).String() = %q, want %q", typ, got, want)
+		}
+	}
+}
+
+func TestBaseSession_RecordTunnelEvent(t *testing.T) {
+	s := NewBaseSession("tunnels", StyleStream, nil, nil, nil)
+	if _, ok := s.TunnelHealth(); ok {
+		t.Error("TunnelHealth() reported true before any event")
+	}
+
+	now := time.Now()
+	s.RecordTunnelEvent(TunnelEvent{Type: TunnelBuilt, Time: now})
+	s.RecordTunnelEvent(TunnelEvent{Type: TunnelFailed, Time: now, Reason: "router connection lost"})
+	s.RecordTunnelEvent(TunnelEvent{Type: TunnelBuilt, Time: now})
+	s.RecordTunnelEvent(TunnelEvent{Type: TunnelExpired, Time: now})
+
+	h, ok := s.TunnelHealth()
+	if !ok {
+		t.Fatal("TunnelHealth() reported false after events")
+	}
+	if h.Last.Type != TunnelExpired || h.Builds != 2 || h.Failures != 1 || h.Expirations != 1 {
+		t.Errorf("TunnelHealth() = %+v, want last EXPIRED, 2 builds, 1 failure, 1 expiration", h)
+	}
+}
@@ -0,0 +1,300 @@
+// Package socks implements a SOCKS5 proxy frontend (RFC 1928) that lets
+// unmodified applications reach I2P hosts. Only the CONNECT command with
+// no authentication is supported, and only hostnames ending in .i2p
+// (including .b32.i2p) are accepted; each request is handed to a DialFunc,
+// which the embedding package backs with a shared SAM STREAM session.
+package socks
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-i2p/go-sam-bridge/lib/util"
+)
+
+// SOCKS5 protocol constants from RFC 1928.
+const (
+	version5 = 0x05
+
+	methodNoAuth       = 0x00
+	methodNoAcceptable = 0xff
+
+	cmdConnect = 0x01
+
+	atypIPv4   = 0x01
+	atypDomain = 0x03
+	atypIPv6   = 0x04
+)
+
+// Reply codes from RFC 1928 section 6.
+const (
+	replySucceeded           = 0x00
+	replyGeneralFailure      = 0x01
+	replyNotAllowed          = 0x02
+	replyHostUnreachable     = 0x04
+	replyCommandNotSupported = 0x07
+	replyAddrNotSupported    = 0x08
+)
+
+// DefaultHandshakeTimeout bounds how long a client may take to send its
+// greeting and CONNECT request.
+const DefaultHandshakeTimeout = 30 * time.Second
+
+// ErrNotI2PHost is returned for CONNECT requests to hosts outside .i2p.
+var ErrNotI2PHost = errors.New("socks: only .i2p hosts are supported")
+
+// DialFunc opens a stream to port on the I2P host, which is a .i2p or
+// .b32.i2p hostname. The context is cancelled when the proxy closes.
+type DialFunc func(ctx context.Context, host string, port int) (net.Conn, error)
+
+// Server is a SOCKS5 proxy that connects clients to I2P hosts through a
+// DialFunc. A Server may serve several listeners at once.
+type Server struct {
+	dial DialFunc
+
+	// HandshakeTimeout bounds the SOCKS negotiation of each client.
+	// Zero means DefaultHandshakeTimeout.
+	HandshakeTimeout time.Duration
+
+	// ErrorHandler, if set, is called with errors dialing I2P hosts and
+	// serving clients. It must not block.
+	ErrorHandler func(error)
+
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	mu        sync.Mutex
+	listeners map[net.Listener]struct{}
+	conns     map[net.Conn]struct{}
+	closed    bool
+	wg        sync.WaitGroup
+}
+
+// NewServer creates a Server that opens streams with dial.
+func NewServer(dial DialFunc) *Server {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Server{
+		dial:      dial,
+		ctx:       ctx,
+		cancel:    cancel,
+		listeners: make(map[net.Listener]struct{}),
+		conns:     make(map[net.Conn]struct{}),
+	}
+}
+
+// Serve accepts SOCKS clients on ln until ln fails or the server is
+// closed. It returns nil after Close.
+func (s *Server) Serve(ln net.Listener) error {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		ln.Close()
+		return nil
+	}
+	s.listeners[ln] = struct{}{}
+	s.mu.Unlock()
+
+	defer func() {
+		s.mu.Lock()
+		delete(s.listeners, ln)
+		s.mu.Unlock()
+	}()
+
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			if s.ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+		if !s.track(conn) {
+			conn.Close()
+			return nil
+		}
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			defer s.untrack(conn)
+			s.handle(conn)
+		}()
+	}
+}
+
+// Close stops all listeners and closes every proxied connection.
+// Safe to call multiple times.
+func (s *Server) Close() error {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return nil
+	}
+	s.closed = true
+	s.cancel()
+	for ln := range s.listeners {
+		ln.Close()
+	}
+	for conn := range s.conns {
+		conn.Close()
+	}
+	s.mu.Unlock()
+
+	s.wg.Wait()
+	return nil
+}
+
+// track records an open client connection, or returns false once closed.
+func (s *Server) track(conn net.Conn) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return false
+	}
+	s.conns[conn] = struct{}{}
+	return true
+}
+
+// untrack closes and forgets a client connection.
+func (s *Server) untrack(conn net.Conn) {
+	conn.Close()
+	s.mu.Lock()
+	delete(s.conns, conn)
+	s.mu.Unlock()
+}
+
+// reportError passes err to ErrorHandler, if set.
+func (s *Server) reportError(err error) {
+	if s.ErrorHandler != nil {
+		s.ErrorHandler(err)
+	}
+}
+
+// handle negotiates one client and, on success, relays its stream.
+func (s *Server) handle(conn net.Conn) {
+	timeout := s.HandshakeTimeout
+	if timeout <= 0 {
+		timeout = DefaultHandshakeTimeout
+	}
+	conn.SetDeadline(time.Now().Add(timeout))
+
+	if err := negotiateMethod(conn); err != nil {
+		return
+	}
+	host, port, code := readRequest(conn)
+	if code != replySucceeded {
+		writeReply(conn, code)
+		return
+	}
+	if !IsI2PHost(host) {
+		s.reportError(fmt.Errorf("%w: %s", ErrNotI2PHost, host))
+		writeReply(conn, replyNotAllowed)
+		return
+	}
+
+	stream, err := s.dial(s.ctx, host, port)
+	if err != nil {
+		s.reportError(fmt.Errorf("socks: connect to %s: %w", net.JoinHostPort(host, strconv.Itoa(port)), err))
+		writeReply(conn, replyHostUnreachable)
+		return
+	}
+	defer stream.Close()
+
+	if err := writeReply(conn, replySucceeded); err != nil {
+		return
+	}
+	conn.SetDeadline(time.Time{})
+	util.Relay(conn, stream)
+}
+
+// IsI2PHost reports whether host is an I2P hostname, ending in .i2p
+// (which includes .b32.i2p).
+func IsI2PHost(host string) bool {
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	return len(host) > len(".i2p") && strings.HasSuffix(host, ".i2p")
+}
+
+// negotiateMethod reads the client greeting and selects "no
+// authentication", the only method supported.
+func negotiateMethod(conn net.Conn) error {
+	var hdr [2]byte
+	if _, err := io.ReadFull(conn, hdr[:]); err != nil {
+		return err
+	}
+	if hdr[0] != version5 {
+		return fmt.Errorf("socks: unsupported version %d", hdr[0])
+	}
+	methods := make([]byte, hdr[1])
+	if _, err := io.ReadFull(conn, methods); err != nil {
+		return err
+	}
+	for _, m := range methods {
+		if m == methodNoAuth {
+			_, err := conn.Write([]byte{version5, methodNoAuth})
+			return err
+		}
+	}
+	conn.Write([]byte{version5, methodNoAcceptable})
+	return errors.New("socks: client offers no supported authentication method")
+}
+
+// readRequest reads a SOCKS request and returns the requested host and
+// port, or the reply code to fail it with.
+func readRequest(conn net.Conn) (host string, port int, code byte) {
+	var hdr [4]byte
+	if _, err := io.ReadFull(conn, hdr[:]); err != nil {
+		return "", 0, replyGeneralFailure
+	}
+	if hdr[0] != version5 {
+		return "", 0, replyGeneralFailure
+	}
+
+	switch hdr[3] {
+	case atypDomain:
+		var n [1]byte
+		if _, err := io.ReadFull(conn, n[:]); err != nil {
+			return "", 0, replyGeneralFailure
+		}
+		name := make([]byte, n[0])
+		if _, err := io.ReadFull(conn, name); err != nil {
+			return "", 0, replyGeneralFailure
+		}
+		host = string(name)
+	case atypIPv4, atypIPv6:
+		// Skip the address so the reply is not mistaken for data. I2P
+		// hosts have no IP addresses.
+		size := net.IPv4len
+		if hdr[3] == atypIPv6 {
+			size = net.IPv6len
+		}
+		if _, err := io.ReadFull(conn, make([]byte, size+2)); err != nil {
+			return "", 0, replyGeneralFailure
+		}
+		return "", 0, replyAddrNotSupported
+	default:
+		return "", 0, replyAddrNotSupported
+	}
+
+	var p [2]byte
+	if _, err := io.ReadFull(conn, p[:]); err != nil {
+		return "", 0, replyGeneralFailure
+	}
+	if hdr[1] != cmdConnect {
+		return "", 0, replyCommandNotSupported
+	}
+	return host, int(binary.BigEndian.Uint16(p[:])), replySucceeded
+}
+
+// writeReply sends a reply with the given code. The bound address is
+// always 0.0.0.0:0, since the stream has no local IP endpoint.
+func writeReply(conn net.Conn, code byte) error {
+	_, err := conn.Write([]byte{version5, code, 0x00, atypIPv4, 0, 0, 0, 0, 0, 0})
+	return err
+}
@@ -0,0 +1,185 @@
+package socks
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net"
+	"sync"
+	"testing"
+	"time"
+)
+
+// startTestServer serves s on a loopback listener and returns its address.
+func startTestServer(t *testing.T, s *Server) string {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("net.Listen() error = %v", err)
+	}
+	go s.Serve(ln)
+	t.Cleanup(func() { s.Close() })
+	return ln.Addr().String()
+}
+
+// socksRequest connects to addr, negotiates no authentication and sends
+// req, returning the connection and the reply code.
+func socksRequest(t *testing.T, addr string, req []byte) (net.Conn, byte) {
+	t.Helper()
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatalf("net.Dial() error = %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	conn.SetDeadline(time.Now().Add(2 * time.Second))
+
+	conn.Write([]byte{version5, 1, methodNoAuth})
+	method := make([]byte, 2)
+	if _, err := io.ReadFull(conn, method); err != nil {
+		t.Fatalf("reading method selection: %v", err)
+	}
+	if method[1] != methodNoAuth {
+		t.Fatalf("selected method = %#x, want no authentication", method[1])
+	}
+
+	conn.Write(req)
+	reply := make([]byte, 10)
+	if _, err := io.ReadFull(conn, reply); err != nil {
+		t.Fatalf("reading reply: %v", err)
+	}
+	return conn, reply[1]
+}
+
+// connectRequest builds a CONNECT request for host:port.
+func connectRequest(host string, port int) []byte {
+	req := []byte{version5, cmdConnect, 0x00, atypDomain, byte(len(host))}
+	req = append(req, host...)
+	return append(req, byte(port>>8), byte(port))
+}
+
+func TestServer_Connect(t *testing.T) {
+	var gotHost string
+	var gotPort int
+	addr := startTestServer(t, NewServer(func(ctx context.Context, host string, port int) (net.Conn, error) {
+		gotHost, gotPort = host, port
+		local, remote := net.Pipe()
+		go io.Copy(remote, remote) // echo
+		return local, nil
+	}))
+
+	conn, code := socksRequest(t, addr, connectRequest("example.i2p", 80))
+	if code != replySucceeded {
+		t.Fatalf("reply = %#x, want success", code)
+	}
+	if gotHost != "example.i2p" || gotPort != 80 {
+		t.Errorf("dialed %s:%d, want example.i2p:80", gotHost, gotPort)
+	}
+
+	conn.Write([]byte("ping"))
+	buf := make([]byte, 4)
+	if _, err := io.ReadFull(conn, buf); err != nil || !bytes.Equal(buf, []byte("ping")) {
+		t.Errorf("echo = %q, %v; want %q", buf, err, "ping")
+	}
+}
+
+func TestServer_Rejects(t *testing.T) {
+	dialErr := errors.New("unreachable")
+	tests := []struct {
+		name string
+		req  []byte
+		want byte
+	}{
+		{"clearnet host", connectRequest("example.com", 80), replyNotAllowed},
+		{"ipv4 address", []byte{version5, cmdConnect, 0x00, atypIPv4, 127, 0, 0, 1, 0, 80}, replyAddrNotSupported},
+		{"bind command", append([]byte{version5, 0x02, 0x00, atypDomain, 11}, append([]byte("example.i2p"), 0, 80)...), replyCommandNotSupported},
+		{"dial failure", connectRequest("down.i2p", 80), replyHostUnreachable},
+	}
+
+	var mu sync.Mutex
+	var reported []error
+	s := NewServer(func(ctx context.Context, host string, port int) (net.Conn, error) {
+		return nil, dialErr
+	})
+	s.ErrorHandler = func(err error) {
+		mu.Lock()
+		reported = append(reported, err)
+		mu.Unlock()
+	}
+	addr := startTestServer(t, s)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, code := socksRequest(t, addr, tt.req); code != tt.want {
+				t.Errorf("reply = %#x, want %#x", code, tt.want)
+			}
+		})
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if len(reported) == 0 || !errors.Is(reported[len(reported)-1], dialErr) {
+		t.Errorf("reported errors = %v, want the dial error last", reported)
+	}
+}
+
+func TestServer_NoAcceptableMethod(t *testing.T) {
+	addr := startTestServer(t, NewServer(nil))
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatalf("net.Dial() error = %v", err)
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(2 * time.Second))
+
+	// Only username/password is offered
+	conn.Write([]byte{version5, 1, 0x02})
+	method := make([]byte, 2)
+	if _, err := io.ReadFull(conn, method); err != nil {
+		t.Fatalf("reading method selection: %v", err)
+	}
+	if method[1] != methodNoAcceptable {
+		t.Errorf("selected method = %#x, want %#x", method[1], methodNoAcceptable)
+	}
+}
+
+func TestServer_Close(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("net.Listen() error = %v", err)
+	}
+	s := NewServer(nil)
+	served := make(chan error, 1)
+	go func() { served <- s.Serve(ln) }()
+	time.Sleep(10 * time.Millisecond)
+
+	s.Close()
+	select {
+	case err := <-served:
+		if err != nil {
+			t.Errorf("Serve() after Close = %v, want nil", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Serve() did not return after Close")
+	}
+}
+
+func TestIsI2PHost(t *testing.T) {
+	tests := []struct {
+		host string
+		want bool
+	}{
+		{"example.i2p", true},
+		{"EXAMPLE.I2P", true},
+		{"ukeu3k5oycgaauneqgtnvselmt4yemvoilkln7jpvamvfx7dnkdq.b32.i2p", true},
+		{"example.i2p.", true},
+		{".i2p", false},
+		{"i2p", false},
+		{"example.com", false},
+		{"example.i2p.com", false},
+	}
+	for _, tt := range tests {
+		if got := IsI2PHost(tt.host); got != tt.want {
+			t.Errorf("IsI2PHost(%q) = %v, want %v", tt.host, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,459 @@
+// Package tunnels runs i2ptunnel-style tunnels declared in configuration.
+// A client tunnel listens on a local address and connects everything it
+// accepts to one remote I2P destination. A server tunnel publishes a
+// destination whose keys are kept in a key file and delivers the streams
+// arriving on it to a local target.
+//
+// Each tunnel runs on its own session, provided by a Backend, which the
+// embedding package backs with SAM STREAM sessions on the bridge. A
+// Manager creates the sessions, wires server tunnels to their targets,
+// and restarts a tunnel with increasing delays when its session fails to
+// start or is lost.
+package tunnels
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-i2p/go-sam-bridge/lib/util"
+)
+
+// Tunnel types.
+const (
+	// TypeClient tunnels connections from a local listener to an I2P
+	// destination.
+	TypeClient = "client"
+
+	// TypeServer tunnels streams arriving on an I2P destination to a
+	// local target.
+	TypeServer = "server"
+)
+
+// Restart delays used when Manager leaves them zero.
+const (
+	DefaultRestartDelay    = time.Second
+	DefaultMaxRestartDelay = time.Minute
+)
+
+// ErrInvalidTunnel is returned for a tunnel whose settings are incomplete
+// or inconsistent.
+var ErrInvalidTunnel = errors.New("tunnels: invalid tunnel")
+
+// Tunnel declares a client or server tunnel.
+type Tunnel struct {
+	// Name identifies the tunnel in logs, status and session IDs. It must
+	// be unique.
+	Name string
+
+	// Type is TypeClient or TypeServer.
+	Type string
+
+	// Listen is the local address a client tunnel accepts connections on.
+	Listen string
+
+	// Destination is the I2P hostname, .b32.i2p address or Base64
+	// destination a client tunnel connects to, optionally followed by
+	// ":port".
+	Destination string
+
+	// Target is the host:port a server tunnel delivers streams to.
+	Target string
+
+	// Keyfile holds the tunnel's private keys. It is created on first
+	// start if missing. Required for server tunnels; client tunnels
+	// without one use a transient destination.
+	Keyfile string
+
+	// Options are I2CP options for the tunnel's session, such as
+	// "inbound.length".
+	Options map[string]string
+}
+
+// Validate reports an error wrapping ErrInvalidTunnel unless t is a
+// complete client or server tunnel.
+func (t Tunnel) Validate() error {
+	if t.Name == "" || strings.ContainsAny(t.Name, " \t\r\n=\"") {
+		return fmt.Errorf("%w: name %q", ErrInvalidTunnel, t.Name)
+	}
+	switch t.Type {
+	case TypeClient:
+		if t.Listen == "" || t.Destination == "" {
+			return fmt.Errorf("%w: client tunnel %s needs listen and destination", ErrInvalidTunnel, t.Name)
+		}
+		if _, _, err := splitDestination(t.Destination); err != nil {
+			return fmt.Errorf("%w: client tunnel %s: %v", ErrInvalidTunnel, t.Name, err)
+		}
+	case TypeServer:
+		if t.Target == "" || t.Keyfile == "" {
+			return fmt.Errorf("%w: server tunnel %s needs target and keyfile", ErrInvalidTunnel, t.Name)
+		}
+		if _, _, err := net.SplitHostPort(t.Target); err != nil {
+			return fmt.Errorf("%w: server tunnel %s target: %v", ErrInvalidTunnel, t.Name, err)
+		}
+	default:
+		return fmt.Errorf("%w: tunnel %s type %q", ErrInvalidTunnel, t.Name, t.Type)
+	}
+	return nil
+}
+
+// Validate checks every tunnel in list and that their names are unique.
+func Validate(list []Tunnel) error {
+	names := make(map[string]bool, len(list))
+	for _, t := range list {
+		if err := t.Validate(); err != nil {
+			return err
+		}
+		if names[t.Name] {
+			return fmt.Errorf("%w: duplicate name %s", ErrInvalidTunnel, t.Name)
+		}
+		names[t.Name] = true
+	}
+	return nil
+}
+
+// splitDestination splits an optional ":port" off dest. Base64
+// destinations contain no colon, so one always introduces a port.
+func splitDestination(dest string) (string, int, error) {
+	i := strings.LastIndexByte(dest, ':')
+	if i < 0 {
+		return dest, 0, nil
+	}
+	port, err := strconv.Atoi(dest[i+1:])
+	if err != nil || port < 1 || port > 65535 || i == 0 {
+		return "", 0, fmt.Errorf("destination %q: invalid port", dest)
+	}
+	return dest[:i], port, nil
+}
+
+// Spec describes a tunnel's session for Backend.Start.
+type Spec struct {
+	// Name is the tunnel's name.
+	Name string
+
+	// Keys are the session's private keys; empty means transient.
+	Keys string
+
+	// Options are the tunnel's I2CP options.
+	Options map[string]string
+
+	// ForwardHost and ForwardPort, when ForwardPort is non-zero, are
+	// where streams arriving on the session are delivered.
+	ForwardHost string
+	ForwardPort int
+}
+
+// Backend provides the I2P side of tunnels.
+type Backend interface {
+	// LoadKeys returns the private keys stored in keyfile, generating
+	// and writing new keys if the file does not exist.
+	LoadKeys(keyfile string) (string, error)
+
+	// Start creates a session for a tunnel.
+	Start(ctx context.Context, spec Spec) (Session, error)
+}
+
+// Session is a running tunnel session.
+type Session interface {
+	// Dial opens a stream to port on host, an I2P hostname or Base64
+	// destination. A zero port leaves it unset.
+	Dial(ctx context.Context, host string, port int) (net.Conn, error)
+
+	// Done is closed when the session is lost.
+	Done() <-chan struct{}
+
+	// Close ends the session.
+	Close() error
+}
+
+// Status is a snapshot of a tunnel's state.
+type Status struct {
+	Name string
+	Type string
+
+	// Running is true while the tunnel's session is up.
+	Running bool
+
+	// Restarts counts the sessions started after the first.
+	Restarts int
+
+	// LastError describes the most recent failure, if any.
+	LastError string
+}
+
+// Manager runs a set of tunnels until closed.
+type Manager struct {
+	backend Backend
+
+	// RestartDelay is the wait before the first restart of a failed
+	// tunnel, doubling on each further failure up to MaxRestartDelay.
+	// Zero means DefaultRestartDelay and DefaultMaxRestartDelay.
+	RestartDelay    time.Duration
+	MaxRestartDelay time.Duration
+
+	// ErrorHandler, if set, is called with tunnel failures. It must not
+	// block.
+	ErrorHandler func(error)
+
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	mu      sync.Mutex
+	tunnels []*runner
+	started bool
+	wg      sync.WaitGroup
+}
+
+// runner is one tunnel's configuration and state, guarded by
+// Manager.mu.
+type runner struct {
+	Tunnel
+	listener net.Listener
+
+	session   Session
+	running   bool
+	restarts  int
+	lastError string
+	conns     map[net.Conn]struct{}
+}
+
+// NewManager returns a Manager for list, which must pass Validate.
+func NewManager(backend Backend, list []Tunnel) (*Manager, error) {
+	if err := Validate(list); err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	m := &Manager{backend: backend, ctx: ctx, cancel: cancel}
+	for _, t := range list {
+		m.tunnels = append(m.tunnels, &runner{Tunnel: t, conns: make(map[net.Conn]struct{})})
+	}
+	return m, nil
+}
+
+// Start binds the client tunnels' listeners, so an address in use is
+// reported at once, then brings every tunnel up in the background.
+func (m *Manager) Start() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.started {
+		return errors.New("tunnels: manager already started")
+	}
+
+	for _, r := range m.tunnels {
+		if r.Type != TypeClient {
+			continue
+		}
+		ln, err := net.Listen("tcp", r.Listen)
+		if err != nil {
+			for _, r := range m.tunnels {
+				if r.listener != nil {
+					r.listener.Close()
+					r.listener = nil
+				}
+			}
+			return fmt.Errorf("tunnels: %s: %w", r.Name, err)
+		}
+		r.listener = ln
+	}
+
+	m.started = true
+	for _, r := range m.tunnels {
+		m.wg.Add(1)
+		go m.supervise(r)
+		if r.listener != nil {
+			m.wg.Add(1)
+			go m.accept(r)
+		}
+	}
+	return nil
+}
+
+// Close stops every tunnel and waits for them to finish. Safe to call
+// more than once.
+func (m *Manager) Close() error {
+	m.cancel()
+	m.mu.Lock()
+	for _, r := range m.tunnels {
+		if r.listener != nil {
+			r.listener.Close()
+		}
+		for conn := range r.conns {
+			conn.Close()
+		}
+	}
+	m.mu.Unlock()
+	m.wg.Wait()
+	return nil
+}
+
+// Status returns the state of every tunnel, in configuration order.
+func (m *Manager) Status() []Status {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	out := make([]Status, 0, len(m.tunnels))
+	for _, r := range m.tunnels {
+		out = append(out, Status{
+			Name:      r.Name,
+			Type:      r.Type,
+			Running:   r.running,
+			Restarts:  r.restarts,
+			LastError: r.lastError,
+		})
+	}
+	return out
+}
+
+// ListenAddr returns the address client tunnel name is listening on, or
+// an empty string if there is no such client tunnel or the manager has
+// not started.
+func (m *Manager) ListenAddr(name string) string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, r := range m.tunnels {
+		if r.Name == name && r.listener != nil {
+			return r.listener.Addr().String()
+		}
+	}
+	return ""
+}
+
+// supervise keeps r's session up until the manager closes, restarting it
+// after failures.
+func (m *Manager) supervise(r *runner) {
+	defer m.wg.Done()
+
+	delay := m.RestartDelay
+	if delay <= 0 {
+		delay = DefaultRestartDelay
+	}
+	maxDelay := m.MaxRestartDelay
+	if maxDelay <= 0 {
+		maxDelay = DefaultMaxRestartDelay
+	}
+
+	wait := delay
+	for first := true; ; first = false {
+		if !first {
+			select {
+			case <-time.After(wait):
+			case <-m.ctx.Done():
+				return
+			}
+			wait = min(wait*2, maxDelay)
+			m.mu.Lock()
+			r.restarts++
+			m.mu.Unlock()
+		}
+
+		sess, err := m.startSession(r)
+		if err != nil {
+			if m.ctx.Err() != nil {
+				return
+			}
+			m.fail(r, fmt.Errorf("tunnels: start %s: %w", r.Name, err))
+			continue
+		}
+
+		m.mu.Lock()
+		r.session, r.running = sess, true
+		m.mu.Unlock()
+		wait = delay
+
+		select {
+		case <-sess.Done():
+			m.fail(r, fmt.Errorf("tunnels: %s: session lost", r.Name))
+		case <-m.ctx.Done():
+		}
+
+		m.mu.Lock()
+		r.session, r.running = nil, false
+		m.mu.Unlock()
+		sess.Close()
+		if m.ctx.Err() != nil {
+			return
+		}
+	}
+}
+
+// startSession loads r's keys and starts its session.
+func (m *Manager) startSession(r *runner) (Session, error) {
+	spec := Spec{Name: r.Name, Options: r.Options}
+	if r.Keyfile != "" {
+		keys, err := m.backend.LoadKeys(r.Keyfile)
+		if err != nil {
+			return nil, err
+		}
+		spec.Keys = keys
+	}
+	if r.Type == TypeServer {
+		host, port, _ := net.SplitHostPort(r.Target)
+		spec.ForwardHost = host
+		spec.ForwardPort, _ = strconv.Atoi(port)
+	}
+	return m.backend.Start(m.ctx, spec)
+}
+
+// fail records err against r and reports it.
+func (m *Manager) fail(r *runner, err error) {
+	m.mu.Lock()
+	r.lastError = err.Error()
+	m.mu.Unlock()
+	if m.ErrorHandler != nil {
+		m.ErrorHandler(err)
+	}
+}
+
+// accept serves client tunnel r's listener until it closes.
+func (m *Manager) accept(r *runner) {
+	defer m.wg.Done()
+	for {
+		conn, err := r.listener.Accept()
+		if err != nil {
+			return
+		}
+
+		m.mu.Lock()
+		if m.ctx.Err() != nil {
+			m.mu.Unlock()
+			conn.Close()
+			return
+		}
+		r.conns[conn] = struct{}{}
+		sess := r.session
+		m.wg.Add(1)
+		m.mu.Unlock()
+
+		go func() {
+			defer m.wg.Done()
+			defer func() {
+				conn.Close()
+				m.mu.Lock()
+				delete(r.conns, conn)
+				m.mu.Unlock()
+			}()
+			m.connect(r, sess, conn)
+		}()
+	}
+}
+
+// connect relays conn to r's destination over sess.
+func (m *Manager) connect(r *runner, sess Session, conn net.Conn) {
+	if sess == nil {
+		// The session is down; the client may retry once it is back
+		return
+	}
+	host, port, _ := splitDestination(r.Destination)
+	stream, err := sess.Dial(m.ctx, host, port)
+	if err != nil {
+		if m.ErrorHandler != nil {
+			m.ErrorHandler(fmt.Errorf("tunnels: %s: connect to %s: %w", r.Name, r.Destination, err))
+		}
+		return
+	}
+	util.Relay(conn, stream)
+}
@@ -0,0 +1,267 @@
+package tunnels
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeBackend hands out in-memory sessions whose streams echo, failing
+// the first failStarts starts.
+type fakeBackend struct {
+	mu         sync.Mutex
+	failStarts int
+	specs      []Spec
+	sessions   []*fakeSession
+	dialed     []string
+}
+
+func (b *fakeBackend) LoadKeys(keyfile string) (string, error) {
+	return "keys-of-" + keyfile, nil
+}
+
+func (b *fakeBackend) Start(ctx context.Context, spec Spec) (Session, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.specs = append(b.specs, spec)
+	if b.failStarts > 0 {
+		b.failStarts--
+		return nil, errors.New("router unavailable")
+	}
+	s := &fakeSession{b: b, done: make(chan struct{})}
+	b.sessions = append(b.sessions, s)
+	return s, nil
+}
+
+// session returns the i'th started session, waiting for it.
+func (b *fakeBackend) session(t *testing.T, i int) *fakeSession {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		b.mu.Lock()
+		if len(b.sessions) > i {
+			s := b.sessions[i]
+			b.mu.Unlock()
+			return s
+		}
+		b.mu.Unlock()
+		if time.Now().After(deadline) {
+			t.Fatalf("session %d never started", i)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+type fakeSession struct {
+	b      *fakeBackend
+	done   chan struct{}
+	once   sync.Once
+	closed bool
+}
+
+func (s *fakeSession) Dial(ctx context.Context, host string, port int) (net.Conn, error) {
+	s.b.mu.Lock()
+	s.b.dialed = append(s.b.dialed, net.JoinHostPort(host, strconv.Itoa(port)))
+	s.b.mu.Unlock()
+	local, remote := net.Pipe()
+	go io.Copy(remote, remote) // echo
+	return local, nil
+}
+
+func (s *fakeSession) Done() <-chan struct{} { return s.done }
+
+// lose simulates the session dropping.
+func (s *fakeSession) lose() { s.once.Do(func() { close(s.done) }) }
+
+func (s *fakeSession) Close() error {
+	s.b.mu.Lock()
+	s.closed = true
+	s.b.mu.Unlock()
+	s.lose()
+	return nil
+}
+
+// waitStatus polls m until cond holds for tunnel 0.
+func waitStatus(t *testing.T, m *Manager, cond func(Status) bool) Status {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		st := m.Status()[0]
+		if cond(st) {
+			return st
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("status never reached the expected state: %+v", st)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	client := Tunnel{Name: "irc", Type: TypeClient, Listen: "127.0.0.1:6668", Destination: "irc.postman.i2p:67"}
+	server := Tunnel{Name: "web", Type: TypeServer, Target: "127.0.0.1:8080", Keyfile: "web.dat"}
+
+	tests := []struct {
+		name    string
+		list    []Tunnel
+		wantErr bool
+	}{
+		{"client and server", []Tunnel{client, server}, false},
+		{"client without port", []Tunnel{{Name: "a", Type: TypeClient, Listen: ":1", Destination: "a.i2p"}}, false},
+		{"no name", []Tunnel{{Type: TypeClient, Listen: ":1", Destination: "a.i2p"}}, true},
+		{"name with space", []Tunnel{{Name: "a b", Type: TypeClient, Listen: ":1", Destination: "a.i2p"}}, true},
+		{"unknown type", []Tunnel{{Name: "a", Type: "udp"}}, true},
+		{"client without listen", []Tunnel{{Name: "a", Type: TypeClient, Destination: "a.i2p"}}, true},
+		{"client bad port", []Tunnel{{Name: "a", Type: TypeClient, Listen: ":1", Destination: "a.i2p:http"}}, true},
+		{"server without keyfile", []Tunnel{{Name: "a", Type: TypeServer, Target: "127.0.0.1:80"}}, true},
+		{"server bad target", []Tunnel{{Name: "a", Type: TypeServer, Target: "localhost", Keyfile: "k"}}, true},
+		{"duplicate name", []Tunnel{client, client}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Validate(tt.list)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, ErrInvalidTunnel) {
+				t.Errorf("Validate() error = %v, want ErrInvalidTunnel", err)
+			}
+		})
+	}
+}
+
+func TestManager_ClientTunnel(t *testing.T) {
+	backend := &fakeBackend{}
+	m, err := NewManager(backend, []Tunnel{{
+		Name: "irc", Type: TypeClient, Listen: "127.0.0.1:0", Destination: "irc.postman.i2p:67",
+		Options: map[string]string{"inbound.length": "1"},
+	}})
+	if err != nil {
+		t.Fatalf("NewManager() error = %v", err)
+	}
+	if err := m.Start(); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	defer m.Close()
+	waitStatus(t, m, func(s Status) bool { return s.Running })
+
+	conn, err := net.Dial("tcp", m.ListenAddr("irc"))
+	if err != nil {
+		t.Fatalf("dialing tunnel: %v", err)
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(2 * time.Second))
+	conn.Write([]byte("hi"))
+	buf := make([]byte, 2)
+	if _, err := io.ReadFull(conn, buf); err != nil || string(buf) != "hi" {
+		t.Errorf("echo = %q, %v; want %q", buf, err, "hi")
+	}
+
+	backend.mu.Lock()
+	defer backend.mu.Unlock()
+	if len(backend.dialed) != 1 || backend.dialed[0] != "irc.postman.i2p:67" {
+		t.Errorf("dialed %v, want [irc.postman.i2p:67]", backend.dialed)
+	}
+	spec := backend.specs[0]
+	if spec.Name != "irc" || spec.Keys != "" || spec.ForwardPort != 0 || spec.Options["inbound.length"] != "1" {
+		t.Errorf("spec = %+v, want a transient session without forwarding", spec)
+	}
+}
+
+func TestManager_ServerTunnel(t *testing.T) {
+	backend := &fakeBackend{}
+	m, err := NewManager(backend, []Tunnel{{Name: "web", Type: TypeServer, Target: "127.0.0.1:8080", Keyfile: "web.dat"}})
+	if err != nil {
+		t.Fatalf("NewManager() error = %v", err)
+	}
+	if err := m.Start(); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	waitStatus(t, m, func(s Status) bool { return s.Running })
+
+	backend.mu.Lock()
+	spec := backend.specs[0]
+	backend.mu.Unlock()
+	if spec.Keys != "keys-of-web.dat" || spec.ForwardHost != "127.0.0.1" || spec.ForwardPort != 8080 {
+		t.Errorf("spec = %+v, want keys from web.dat forwarded to 127.0.0.1:8080", spec)
+	}
+
+	m.Close()
+	m.Close()
+	s := backend.session(t, 0)
+	backend.mu.Lock()
+	defer backend.mu.Unlock()
+	if !s.closed {
+		t.Error("session still open after Close")
+	}
+	if st := m.Status()[0]; st.Running {
+		t.Errorf("status after Close = %+v, want not running", st)
+	}
+}
+
+func TestManager_Restart(t *testing.T) {
+	backend := &fakeBackend{failStarts: 2}
+	m, err := NewManager(backend, []Tunnel{{Name: "web", Type: TypeServer, Target: "127.0.0.1:8080", Keyfile: "web.dat"}})
+	if err != nil {
+		t.Fatalf("NewManager() error = %v", err)
+	}
+	m.RestartDelay = time.Millisecond
+	m.MaxRestartDelay = 4 * time.Millisecond
+	var mu sync.Mutex
+	var reported []error
+	m.ErrorHandler = func(err error) {
+		mu.Lock()
+		reported = append(reported, err)
+		mu.Unlock()
+	}
+	if err := m.Start(); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	defer m.Close()
+
+	// Two failed starts, then up.
+	st := waitStatus(t, m, func(s Status) bool { return s.Running })
+	if st.Restarts != 2 || st.LastError == "" {
+		t.Errorf("status = %+v, want 2 restarts and the last error", st)
+	}
+
+	// A lost session is replaced.
+	backend.session(t, 0).lose()
+	backend.session(t, 1)
+	waitStatus(t, m, func(s Status) bool { return s.Running && s.Restarts == 3 })
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(reported) != 3 {
+		t.Errorf("reported %d errors, want 3: %v", len(reported), reported)
+	}
+}
+
+func TestManager_ListenError(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("net.Listen() error = %v", err)
+	}
+	defer ln.Close()
+
+	m, err := NewManager(&fakeBackend{}, []Tunnel{
+		{Name: "a", Type: TypeClient, Listen: "127.0.0.1:0", Destination: "a.i2p"},
+		{Name: "b", Type: TypeClient, Listen: ln.Addr().String(), Destination: "b.i2p"},
+	})
+	if err != nil {
+		t.Fatalf("NewManager() error = %v", err)
+	}
+	if err := m.Start(); err == nil {
+		m.Close()
+		t.Fatal("Start() on an address in use succeeded")
+	}
+	if addr := m.ListenAddr("a"); addr != "" {
+		t.Errorf("ListenAddr(a) after failed Start = %q, want empty", addr)
+	}
+}
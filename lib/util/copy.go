@@ -0,0 +1,68 @@
+package util
+
+import (
+	"io"
+	"net"
+	"sync"
+)
+
+// DefaultCopyBufferSize is the buffer size Copy uses when none is given,
+// the same as io.Copy.
+const DefaultCopyBufferSize = 32 * 1024
+
+// copyPools holds a *sync.Pool of *[]byte per buffer size.
+var copyPools sync.Map
+
+// Copy copies from src to dst like io.Copy, but takes its buffer of size
+// bytes, or DefaultCopyBufferSize if size <= 0, from a pool shared by all
+// copies of that size. This keeps forwarding many streams at once from
+// allocating a buffer per stream and direction.
+func Copy(dst io.Writer, src io.Reader, size int) (int64, error) {
+	if size <= 0 {
+		size = DefaultCopyBufferSize
+	}
+	pool := copyPool(size)
+	buf := pool.Get().(*[]byte)
+	defer pool.Put(buf)
+	// Hide ReadFrom and WriteTo, whose generic fallbacks in package net
+	// allocate a buffer of their own.
+	return io.CopyBuffer(writerOnly{dst}, readerOnly{src}, *buf)
+}
+
+// Relay copies data both ways between a and b with Copy until either
+// side closes, then closes both.
+func Relay(a, b net.Conn) {
+	done := make(chan struct{}, 2)
+	go func() {
+		Copy(a, b, 0)
+		done <- struct{}{}
+	}()
+	go func() {
+		Copy(b, a, 0)
+		done <- struct{}{}
+	}()
+	<-done
+	a.Close()
+	b.Close()
+	<-done
+}
+
+// writerOnly hides all methods of an io.Writer but Write.
+type writerOnly struct{ io.Writer }
+
+// readerOnly hides all methods of an io.Reader but Read.
+type readerOnly struct{ io.Reader }
+
+// copyPool returns the pool of buffers of size bytes.
+func copyPool(size int) *sync.Pool {
+	if p, ok := copyPools.Load(size); ok {
+		return p.(*sync.Pool)
+	}
+	p, _ := copyPools.LoadOrStore(size, &sync.Pool{
+		New: func() any {
+			buf := make([]byte, size)
+			return &buf
+		},
+	})
+	return p.(*sync.Pool)
+}
@@ -0,0 +1,101 @@
+package util
+
+import (
+	"bytes"
+	"io"
+	"net"
+	"strings"
+	"testing"
+)
+
+func TestCopy(t *testing.T) {
+	data := strings.Repeat("sam-bridge ", 10000)
+	for _, size := range []int{0, 1, 512, DefaultCopyBufferSize} {
+		var dst bytes.Buffer
+		n, err := Copy(&dst, strings.NewReader(data), size)
+		if err != nil || n != int64(len(data)) {
+			t.Errorf("size %d: Copy() = %d, %v; want %d, nil", size, n, err, len(data))
+		}
+		if dst.String() != data {
+			t.Errorf("size %d: copied data differs", size)
+		}
+	}
+}
+
+// tcpPair returns both ends of a loopback TCP connection.
+func tcpPair(t *testing.T) (*net.TCPConn, *net.TCPConn) {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+
+	dialed, err := net.Dial("tcp", ln.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	accepted, err := ln.Accept()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		dialed.Close()
+		accepted.Close()
+	})
+	return dialed.(*net.TCPConn), accepted.(*net.TCPConn)
+}
+
+func TestCopy_TCP(t *testing.T) {
+	srcWriter, src := tcpPair(t)
+	dst, dstReader := tcpPair(t)
+
+	data := strings.Repeat("sam-bridge ", 50000)
+	go func() {
+		srcWriter.Write([]byte(data))
+		srcWriter.Close()
+	}()
+	done := make(chan error, 1)
+	go func() {
+		_, err := Copy(dst, src, 0)
+		dst.CloseWrite()
+		done <- err
+	}()
+
+	got, err := io.ReadAll(dstReader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := <-done; err != nil {
+		t.Errorf("Copy() error = %v", err)
+	}
+	if string(got) != data {
+		t.Errorf("copied %d bytes, want %d", len(got), len(data))
+	}
+}
+
+func TestRelay(t *testing.T) {
+	client, a := net.Pipe()
+	b, server := net.Pipe()
+	done := make(chan struct{})
+	go func() {
+		Relay(a, b)
+		close(done)
+	}()
+
+	go client.Write([]byte("ping"))
+	buf := make([]byte, 4)
+	if _, err := io.ReadFull(server, buf); err != nil || string(buf) != "ping" {
+		t.Errorf("relayed %q, %v; want %q", buf, err, "ping")
+	}
+	go server.Write([]byte("pong"))
+	if _, err := io.ReadFull(client, buf); err != nil || string(buf) != "pong" {
+		t.Errorf("relayed %q, %v; want %q", buf, err, "pong")
+	}
+
+	client.Close()
+	<-done
+	if _, err := server.Read(buf); err == nil {
+		t.Error("Relay left the other side open")
+	}
+}
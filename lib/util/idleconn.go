@@ -0,0 +1,53 @@
+package util
+
+import (
+	"net"
+	"time"
+)
+
+// idleConn sets a fresh deadline before every Read and Write, so that an
+// operation fails once the peer has been inactive for the timeout.
+type idleConn struct {
+	net.Conn
+	read  time.Duration
+	write time.Duration
+}
+
+// NewIdleConn wraps conn so that a Read fails with a timeout error if no
+// data arrives for read, and a Write fails if it cannot complete within
+// write. Zero disables the respective timeout; if both are zero, conn is
+// returned unchanged. Forwarding loops treat the error like any other and
+// close both ends, so a dead peer no longer holds a goroutine and socket
+// open forever.
+func NewIdleConn(conn net.Conn, read, write time.Duration) net.Conn {
+	if conn == nil || (read <= 0 && write <= 0) {
+		return conn
+	}
+	return &idleConn{Conn: conn, read: read, write: write}
+}
+
+// Read extends the read deadline by the read timeout, then reads.
+func (c *idleConn) Read(b []byte) (int, error) {
+	if c.read > 0 {
+		if err := c.Conn.SetReadDeadline(time.Now().Add(c.read)); err != nil {
+			return 0, err
+		}
+	}
+	return c.Conn.Read(b)
+}
+
+// Write extends the write deadline by the write timeout, then writes.
+func (c *idleConn) Write(b []byte) (int, error) {
+	if c.write > 0 {
+		if err := c.Conn.SetWriteDeadline(time.Now().Add(c.write)); err != nil {
+			return 0, err
+		}
+	}
+	return c.Conn.Write(b)
+}
+
+// NetConn returns the wrapped connection, so that SocketOptions.Apply
+// reaches the socket underneath.
+func (c *idleConn) NetConn() net.Conn {
+	return c.Conn
+}
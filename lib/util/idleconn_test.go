@@ -0,0 +1,53 @@
+package util
+
+import (
+	"errors"
+	"net"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestNewIdleConn_Disabled(t *testing.T) {
+	a, b := net.Pipe()
+	defer a.Close()
+	defer b.Close()
+	if got := NewIdleConn(a, 0, 0); got != a {
+		t.Errorf("NewIdleConn(conn, 0, 0) = %T, want conn unchanged", got)
+	}
+}
+
+func TestNewIdleConn_ReadTimeout(t *testing.T) {
+	a, b := net.Pipe()
+	defer a.Close()
+	defer b.Close()
+	conn := NewIdleConn(a, 50*time.Millisecond, 0)
+
+	// Data within the timeout extends the deadline
+	go b.Write([]byte("x"))
+	buf := make([]byte, 1)
+	if _, err := conn.Read(buf); err != nil {
+		t.Fatalf("Read() error = %v", err)
+	}
+
+	start := time.Now()
+	_, err := conn.Read(buf)
+	if !errors.Is(err, os.ErrDeadlineExceeded) {
+		t.Fatalf("Read() error = %v, want deadline exceeded", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Read() returned after %v, want about 50ms", elapsed)
+	}
+}
+
+func TestNewIdleConn_WriteTimeout(t *testing.T) {
+	a, b := net.Pipe()
+	defer a.Close()
+	defer b.Close()
+	conn := NewIdleConn(a, 0, 50*time.Millisecond)
+
+	// Nothing reads from b, so the write stalls
+	if _, err := conn.Write([]byte("x")); !errors.Is(err, os.ErrDeadlineExceeded) {
+		t.Errorf("Write() error = %v, want deadline exceeded", err)
+	}
+}
@@ -0,0 +1,179 @@
+package util
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// rotatedTimeFormat is the timestamp suffix appended to rotated log files.
+const rotatedTimeFormat = "20060102-150405.000"
+
+// RotationPolicy controls when a RotatingFile starts a new file and how
+// many old files it keeps. Zero values disable the corresponding limit.
+type RotationPolicy struct {
+	// MaxSize rotates the file before a write would grow it beyond this
+	// many bytes.
+	MaxSize int64
+
+	// MaxAge rotates the file once it has been written to for this long.
+	MaxAge time.Duration
+
+	// MaxBackups is the number of rotated files to keep; older ones are
+	// deleted. Zero keeps all of them.
+	MaxBackups int
+}
+
+// RotatingFile is an io.WriteCloser that appends to a log file and
+// rotates it by size or age. Rotated files are renamed to
+// "<path>.<timestamp>" next to the original.
+// It is safe for concurrent use.
+type RotatingFile struct {
+	path   string
+	policy RotationPolicy
+
+	mu     sync.Mutex
+	file   *os.File
+	size   int64
+	opened time.Time
+	now    func() time.Time
+}
+
+// NewRotatingFile opens path for appending, creating it with mode 0600
+// if needed, and rotates it according to policy.
+func NewRotatingFile(path string, policy RotationPolicy) (*RotatingFile, error) {
+	f := &RotatingFile{path: path, policy: policy, now: time.Now}
+	if err := f.open(); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// Write appends p to the file, rotating first if the policy requires it.
+// A single write is never split across files.
+func (f *RotatingFile) Write(p []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.file == nil {
+		return 0, os.ErrClosed
+	}
+	if f.shouldRotate(int64(len(p))) {
+		if err := f.rotate(); err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := f.file.Write(p)
+	f.size += int64(n)
+	return n, err
+}
+
+// Rotate closes the current file, renames it, and opens a new one.
+func (f *RotatingFile) Rotate() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.file == nil {
+		return os.ErrClosed
+	}
+	return f.rotate()
+}
+
+// Close closes the file. Further writes return os.ErrClosed.
+func (f *RotatingFile) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.file == nil {
+		return nil
+	}
+	err := f.file.Close()
+	f.file = nil
+	return err
+}
+
+// shouldRotate reports whether writing n more bytes requires rotation.
+// An empty file is never rotated, so oversized writes still succeed.
+func (f *RotatingFile) shouldRotate(n int64) bool {
+	if f.size == 0 {
+		return false
+	}
+	if f.policy.MaxSize > 0 && f.size+n > f.policy.MaxSize {
+		return true
+	}
+	return f.policy.MaxAge > 0 && f.now().Sub(f.opened) >= f.policy.MaxAge
+}
+
+// open opens the log file for appending.
+func (f *RotatingFile) open() error {
+	file, err := os.OpenFile(f.path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o600)
+	if err != nil {
+		return fmt.Errorf("opening log file: %w", err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return fmt.Errorf("opening log file: %w", err)
+	}
+	f.file = file
+	f.size = info.Size()
+	f.opened = f.now()
+	return nil
+}
+
+// rotate renames the current file, opens a new one, and prunes backups.
+// Callers must hold f.mu.
+func (f *RotatingFile) rotate() error {
+	if err := f.file.Close(); err != nil {
+		return fmt.Errorf("closing log file: %w", err)
+	}
+	f.file = nil
+
+	rotated := f.path + "." + f.now().Format(rotatedTimeFormat)
+	if err := os.Rename(f.path, rotated); err != nil {
+		// Keep logging to the existing file rather than losing output
+		if openErr := f.open(); openErr != nil {
+			return openErr
+		}
+		return fmt.Errorf("rotating log file: %w", err)
+	}
+
+	if err := f.open(); err != nil {
+		return err
+	}
+	return f.prune()
+}
+
+// prune deletes the oldest rotated files beyond MaxBackups.
+func (f *RotatingFile) prune() error {
+	if f.policy.MaxBackups <= 0 {
+		return nil
+	}
+
+	matches, err := filepath.Glob(f.path + ".*")
+	if err != nil {
+		return err
+	}
+	var backups []string
+	for _, m := range matches {
+		suffix := strings.TrimPrefix(m, f.path+".")
+		if _, err := time.Parse(rotatedTimeFormat, suffix); err == nil {
+			backups = append(backups, m)
+		}
+	}
+	if len(backups) <= f.policy.MaxBackups {
+		return nil
+	}
+
+	// Timestamps sort chronologically
+	sort.Strings(backups)
+	for _, old := range backups[:len(backups)-f.policy.MaxBackups] {
+		if err := os.Remove(old); err != nil {
+			return fmt.Errorf("removing old log file: %w", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,130 @@
+package util
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func rotatedFiles(t *testing.T, path string) []string {
+	t.Helper()
+	matches, err := filepath.Glob(path + ".*")
+	if err != nil {
+		t.Fatalf("Glob() error = %v", err)
+	}
+	return matches
+}
+
+func TestRotatingFile_MaxSize(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bridge.log")
+	f, err := NewRotatingFile(path, RotationPolicy{MaxSize: 10})
+	if err != nil {
+		t.Fatalf("NewRotatingFile() error = %v", err)
+	}
+	defer f.Close()
+
+	// Advance the clock so rotated names are unique
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	f.now = func() time.Time { now = now.Add(time.Second); return now }
+
+	for _, line := range []string{"first\n", "second\n", "third\n"} {
+		if _, err := f.Write([]byte(line)); err != nil {
+			t.Fatalf("Write() error = %v", err)
+		}
+	}
+
+	if got := len(rotatedFiles(t, path)); got != 2 {
+		t.Errorf("got %d rotated files, want 2", got)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("ReadFile() error = %v", err)
+	}
+	if string(data) != "third\n" {
+		t.Errorf("current file = %q, want %q", data, "third\n")
+	}
+}
+
+func TestRotatingFile_MaxAge(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bridge.log")
+	f, err := NewRotatingFile(path, RotationPolicy{MaxAge: time.Hour})
+	if err != nil {
+		t.Fatalf("NewRotatingFile() error = %v", err)
+	}
+	defer f.Close()
+
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	f.now = func() time.Time { return now }
+	f.opened = now
+
+	f.Write([]byte("early\n"))
+	now = now.Add(30 * time.Minute)
+	f.Write([]byte("still early\n"))
+	if got := len(rotatedFiles(t, path)); got != 0 {
+		t.Fatalf("got %d rotated files before MaxAge, want 0", got)
+	}
+
+	now = now.Add(time.Hour)
+	f.Write([]byte("late\n"))
+	rotated := rotatedFiles(t, path)
+	if len(rotated) != 1 {
+		t.Fatalf("got %d rotated files after MaxAge, want 1", len(rotated))
+	}
+	data, _ := os.ReadFile(rotated[0])
+	if !strings.Contains(string(data), "still early") {
+		t.Errorf("rotated file = %q, want earlier lines", data)
+	}
+}
+
+func TestRotatingFile_MaxBackups(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bridge.log")
+	f, err := NewRotatingFile(path, RotationPolicy{MaxBackups: 2})
+	if err != nil {
+		t.Fatalf("NewRotatingFile() error = %v", err)
+	}
+	defer f.Close()
+
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	f.now = func() time.Time { now = now.Add(time.Second); return now }
+
+	for i := 0; i < 4; i++ {
+		f.Write([]byte("line\n"))
+		if err := f.Rotate(); err != nil {
+			t.Fatalf("Rotate() error = %v", err)
+		}
+	}
+
+	rotated := rotatedFiles(t, path)
+	if len(rotated) != 2 {
+		t.Fatalf("got %d rotated files, want 2", len(rotated))
+	}
+	if !strings.HasSuffix(rotated[1], "20260101-000007.000") {
+		t.Errorf("newest backup = %s, want the last rotation kept", rotated[1])
+	}
+}
+
+func TestRotatingFile_AppendsAndCloses(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bridge.log")
+	if err := os.WriteFile(path, []byte("existing\n"), 0o600); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+
+	f, err := NewRotatingFile(path, RotationPolicy{})
+	if err != nil {
+		t.Fatalf("NewRotatingFile() error = %v", err)
+	}
+	f.Write([]byte("appended\n"))
+	if err := f.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	if _, err := f.Write([]byte("late\n")); err == nil {
+		t.Error("Write() after Close() should return error")
+	}
+
+	data, _ := os.ReadFile(path)
+	if string(data) != "existing\nappended\n" {
+		t.Errorf("file = %q, want existing content plus appended line", data)
+	}
+}
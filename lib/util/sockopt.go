@@ -0,0 +1,81 @@
+package util
+
+import (
+	"net"
+	"time"
+)
+
+// SocketOptions tunes the TCP sockets of SAM client connections and of
+// STREAM FORWARD connections to local targets. The zero value keeps Go's
+// defaults: keepalive probes every 15 seconds and TCP_NODELAY set.
+type SocketOptions struct {
+	// KeepAlive is the period between TCP keepalive probes. Zero keeps
+	// Go's default; a negative value disables keepalive. Long-lived idle
+	// streams behind NATs that drop silent mappings need it below the
+	// NAT's timeout.
+	KeepAlive time.Duration
+
+	// DisableNoDelay clears TCP_NODELAY, letting the kernel coalesce
+	// small writes (Nagle's algorithm) at the cost of latency.
+	DisableNoDelay bool
+
+	// ReadBuffer and WriteBuffer set SO_RCVBUF and SO_SNDBUF in bytes.
+	// Zero keeps the operating system's default.
+	ReadBuffer  int
+	WriteBuffer int
+}
+
+// IsZero reports whether o keeps every default.
+func (o SocketOptions) IsZero() bool {
+	return o == SocketOptions{}
+}
+
+// Apply sets o on conn's TCP socket. Connections wrapping another, such
+// as *tls.Conn, are unwrapped through their NetConn method. Apply does
+// nothing for connections that are not TCP, such as Unix sockets.
+func (o SocketOptions) Apply(conn net.Conn) error {
+	if o.IsZero() {
+		return nil
+	}
+	for {
+		w, ok := conn.(interface{ NetConn() net.Conn })
+		if !ok {
+			break
+		}
+		conn = w.NetConn()
+	}
+	tcp, ok := conn.(*net.TCPConn)
+	if !ok {
+		return nil
+	}
+
+	switch {
+	case o.KeepAlive < 0:
+		if err := tcp.SetKeepAlive(false); err != nil {
+			return err
+		}
+	case o.KeepAlive > 0:
+		if err := tcp.SetKeepAlive(true); err != nil {
+			return err
+		}
+		if err := tcp.SetKeepAlivePeriod(o.KeepAlive); err != nil {
+			return err
+		}
+	}
+	if o.DisableNoDelay {
+		if err := tcp.SetNoDelay(false); err != nil {
+			return err
+		}
+	}
+	if o.ReadBuffer > 0 {
+		if err := tcp.SetReadBuffer(o.ReadBuffer); err != nil {
+			return err
+		}
+	}
+	if o.WriteBuffer > 0 {
+		if err := tcp.SetWriteBuffer(o.WriteBuffer); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,41 @@
+package util
+
+import (
+	"crypto/tls"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestSocketOptions_Apply(t *testing.T) {
+	conn, _ := tcpPair(t)
+	opts := SocketOptions{
+		KeepAlive:      30 * time.Second,
+		DisableNoDelay: true,
+		ReadBuffer:     64 * 1024,
+		WriteBuffer:    64 * 1024,
+	}
+	if err := opts.Apply(conn); err != nil {
+		t.Errorf("Apply() error = %v", err)
+	}
+	if err := (SocketOptions{KeepAlive: -1}).Apply(conn); err != nil {
+		t.Errorf("Apply() with keepalive disabled error = %v", err)
+	}
+}
+
+func TestSocketOptions_ApplyUnwraps(t *testing.T) {
+	conn, _ := tcpPair(t)
+	wrapped := tls.Client(conn, &tls.Config{InsecureSkipVerify: true})
+	if err := (SocketOptions{ReadBuffer: 32 * 1024}).Apply(wrapped); err != nil {
+		t.Errorf("Apply() through *tls.Conn error = %v", err)
+	}
+}
+
+func TestSocketOptions_ApplyNonTCP(t *testing.T) {
+	a, b := net.Pipe()
+	defer a.Close()
+	defer b.Close()
+	if err := (SocketOptions{KeepAlive: time.Minute}).Apply(a); err != nil {
+		t.Errorf("Apply() on a pipe error = %v, want nil", err)
+	}
+}
@@ -0,0 +1,12 @@
+// Package sam3compat checks that the go-i2p/sam3 client library works
+// against the bridge unmodified. Its tests start an embedded bridge on an
+// i2cptest.Provider, so no I2P router is needed, and drive it only
+// through sam3 and i2pkeys: HELLO, DEST GENERATE and SESSION CREATE for
+// each session style sam3 offers.
+//
+// It is a separate module so that sam3 is not a dependency of the
+// bridge. Run it from this directory:
+//
+//	go mod tidy
+//	go test ./...
+package sam3compat
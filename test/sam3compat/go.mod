@@ -0,0 +1,53 @@
+module github.com/go-i2p/go-sam-bridge/test/sam3compat
+
+go 1.24.5
+
+require (
+	github.com/go-i2p/go-sam-bridge v0.0.0
+	github.com/go-i2p/i2pkeys v0.33.92
+	github.com/go-i2p/sam3 v0.33.92
+)
+
+require (
+	filippo.io/edwards25519 v1.1.0 // indirect
+	github.com/armon/circbuf v0.0.0-20190214190532-5111143e8da2 // indirect
+	github.com/beevik/ntp v1.5.0 // indirect
+	github.com/cespare/xxhash/v2 v2.3.0 // indirect
+	github.com/dchest/siphash v1.2.3 // indirect
+	github.com/eyedeekay/go-unzip v0.0.0-20240201194209-560d8225b50e // indirect
+	github.com/fsnotify/fsnotify v1.9.0 // indirect
+	github.com/go-i2p/common v0.1.2 // indirect
+	github.com/go-i2p/crypto v0.1.3 // indirect
+	github.com/go-i2p/elgamal v0.0.2 // indirect
+	github.com/go-i2p/go-datagrams v0.1.2 // indirect
+	github.com/go-i2p/go-i2cp v0.1.2 // indirect
+	github.com/go-i2p/go-i2p v0.1.2 // indirect
+	github.com/go-i2p/go-noise v0.1.2 // indirect
+	github.com/go-i2p/go-streaming v0.1.2 // indirect
+	github.com/go-i2p/logger v0.1.2 // indirect
+	github.com/go-i2p/noise v0.0.0-20251212204422-ded862d8cdf9 // indirect
+	github.com/go-i2p/su3 v0.0.1 // indirect
+	github.com/go-viper/mapstructure/v2 v2.5.0 // indirect
+	github.com/hashicorp/golang-lru/v2 v2.0.7 // indirect
+	github.com/oklog/ulid/v2 v2.1.1 // indirect
+	github.com/pelletier/go-toml/v2 v2.2.4 // indirect
+	github.com/sagikazarmark/locafero v0.12.0 // indirect
+	github.com/samber/lo v1.52.0 // indirect
+	github.com/samber/oops v1.21.0 // indirect
+	github.com/sirupsen/logrus v1.9.4 // indirect
+	github.com/spf13/afero v1.15.0 // indirect
+	github.com/spf13/cast v1.10.0 // indirect
+	github.com/spf13/pflag v1.0.10 // indirect
+	github.com/spf13/viper v1.21.0 // indirect
+	github.com/subosito/gotenv v1.6.0 // indirect
+	go.opentelemetry.io/otel v1.39.0 // indirect
+	go.opentelemetry.io/otel/trace v1.39.0 // indirect
+	go.step.sm/crypto v0.76.0 // indirect
+	go.yaml.in/yaml/v3 v3.0.4 // indirect
+	golang.org/x/crypto v0.47.0 // indirect
+	golang.org/x/net v0.49.0 // indirect
+	golang.org/x/sys v0.40.0 // indirect
+	golang.org/x/text v0.33.0 // indirect
+)
+
+replace github.com/go-i2p/go-sam-bridge => ../..
@@ -0,0 +1,152 @@
+package sam3compat
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/go-i2p/i2pkeys"
+	"github.com/go-i2p/sam3"
+
+	"github.com/go-i2p/go-sam-bridge/lib/destination"
+	"github.com/go-i2p/go-sam-bridge/lib/embedding"
+	"github.com/go-i2p/go-sam-bridge/lib/i2cp/i2cptest"
+)
+
+// testBridge is a running bridge and the mock provider behind it.
+type testBridge struct {
+	*embedding.Bridge
+	provider *i2cptest.Provider
+	addr     string
+}
+
+// startBridge runs a bridge on a mock I2CP provider until the test ends.
+func startBridge(t *testing.T) *testBridge {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen() error = %v", err)
+	}
+	provider := i2cptest.NewProvider()
+	b, err := embedding.New(embedding.WithListener(ln), embedding.WithI2CPProvider(provider), embedding.WithDatagramPort(0))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if err := b.Start(context.Background()); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	t.Cleanup(func() { b.Stop(context.Background()) })
+	return &testBridge{Bridge: b, provider: provider, addr: ln.Addr().String()}
+}
+
+// dial connects sam3 to the bridge.
+func (tb *testBridge) dial(t *testing.T) *sam3.SAM {
+	t.Helper()
+	sam, err := sam3.NewSAM(tb.addr)
+	if err != nil {
+		t.Fatalf("sam3.NewSAM() error = %v", err)
+	}
+	t.Cleanup(func() { sam.Close() })
+	return sam
+}
+
+// newKeys generates keys through sam3.
+func newKeys(t *testing.T, sam *sam3.SAM) i2pkeys.I2PKeys {
+	t.Helper()
+	keys, err := sam.NewKeys()
+	if err != nil {
+		t.Fatalf("NewKeys() error = %v", err)
+	}
+	return keys
+}
+
+// waitRegistry waits until the registry holds want sessions.
+func (tb *testBridge) waitRegistry(t *testing.T, want int) {
+	t.Helper()
+	registry := tb.Dependencies().Registry
+	deadline := time.Now().Add(5 * time.Second)
+	for registry.Count() != want {
+		if time.Now().After(deadline) {
+			t.Fatalf("registry holds %v, want %d sessions", registry.All(), want)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestNewKeys(t *testing.T) {
+	tb := startBridge(t)
+	keys := newKeys(t, tb.dial(t))
+
+	// The keys sam3 hands back are a private key file the bridge
+	// accepts, for the same destination sam3 reports.
+	dests := destination.NewManager()
+	dest, _, err := dests.Parse(keys.String())
+	if err != nil {
+		t.Fatalf("Parse(keys) error = %v", err)
+	}
+	pub, err := dests.EncodePublic(dest)
+	if err != nil {
+		t.Fatalf("EncodePublic() error = %v", err)
+	}
+	if got := keys.Addr().Base64(); got != pub {
+		t.Errorf("keys.Addr() = %q, want %q", got, pub)
+	}
+}
+
+func TestStreamSession(t *testing.T) {
+	tb := startBridge(t)
+	sam := tb.dial(t)
+	keys := newKeys(t, sam)
+
+	ss, err := sam.NewStreamSession("compat-stream", keys, []string{"inbound.length=1", "outbound.length=1"})
+	if err != nil {
+		t.Fatalf("NewStreamSession() error = %v", err)
+	}
+	if got, want := ss.Addr().Base64(), keys.Addr().Base64(); got != want {
+		t.Errorf("session Addr() = %q, want %q", got, want)
+	}
+	if tb.Dependencies().Registry.Get("compat-stream") == nil {
+		t.Error("session compat-stream is not registered")
+	}
+	s := tb.provider.Session("compat-stream")
+	if s == nil || s.Config.InboundLength != 1 || s.Config.OutboundLength != 1 {
+		t.Errorf("I2CP session = %+v, want tunnel lengths 1", s)
+	}
+
+	// IDs are unique across the bridge.
+	other := tb.dial(t)
+	if _, err := other.NewStreamSession("compat-stream", newKeys(t, other), nil); err == nil {
+		t.Error("NewStreamSession() with a duplicate ID succeeded")
+	}
+
+	if err := ss.Close(); err != nil {
+		t.Errorf("Close() error = %v", err)
+	}
+	tb.waitRegistry(t, 0)
+}
+
+func TestDatagramSessions(t *testing.T) {
+	tb := startBridge(t)
+
+	sam := tb.dial(t)
+	dg, err := sam.NewDatagramSession("compat-datagram", newKeys(t, sam), sam3.Options_Small, 0)
+	if err != nil {
+		t.Fatalf("NewDatagramSession() error = %v", err)
+	}
+	defer dg.Close()
+
+	sam = tb.dial(t)
+	raw, err := sam.NewRawSession("compat-raw", newKeys(t, sam), sam3.Options_Small, 0)
+	if err != nil {
+		t.Fatalf("NewRawSession() error = %v", err)
+	}
+	defer raw.Close()
+
+	tb.waitRegistry(t, 2)
+	for _, id := range []string{"compat-datagram", "compat-raw"} {
+		if tb.Dependencies().Registry.Get(id) == nil {
+			t.Errorf("session %s is not registered", id)
+		}
+	}
+}